@@ -243,6 +243,19 @@ appCode = $auth_app_code
 appSecret = $auth_app_secret
 enable = $auth_enabled
 enableSync = false
+
+[replication]
+enable = false
+flag =
+targets =
+conflict = source_wins
+fullSyncInterval = 86400
+pageSize = 200
+
+[quality]
+enable = true
+interval = 3600
+pageSize = 500
     '''
 
     template = FileTemplate(migrate_file_template_str)
@@ -373,6 +386,9 @@ maxIDleConns = 1000
 [transaction]
 enable = false
 transactionLifetimeSecond = 60
+
+[rpc]
+compress = gzip,deflate
 '''
 
     template = FileTemplate(txcserver_file_template_str)
@@ -27,6 +27,7 @@ import (
 	"icenter/src/apimachinery/procserver"
 	"icenter/src/apimachinery/toposerver"
 	"icenter/src/apimachinery/util"
+	"icenter/src/common/types"
 )
 
 type ClientSetInterface interface {
@@ -52,7 +53,17 @@ func NewApiMachinery(c *util.APIMachineryConfig, discover discovery.DiscoveryInt
 	}
 
 	flowcontrol := flowctrl.NewRateLimiter(c.QPS, c.Burst)
-	return NewClientSet(client, discover, flowcontrol), nil
+	breakerConfig := flowctrl.DefaultBreakerConfig()
+	if c.Breaker != nil {
+		breakerConfig = *c.Breaker
+	}
+	return &ClientSet{
+		version:  "v3",
+		client:   client,
+		discover: discover,
+		throttle: flowcontrol,
+		breakers: flowctrl.NewBreakers(breakerConfig),
+	}, nil
 }
 
 func NewClientSet(client util.HttpClient, discover discovery.DiscoveryInterface, throttle flowctrl.RateLimiter) ClientSetInterface {
@@ -79,6 +90,7 @@ type ClientSet struct {
 	client   util.HttpClient
 	discover discovery.DiscoveryInterface
 	throttle flowctrl.RateLimiter
+	breakers *flowctrl.Breakers
 	Mock     util.MockInfo
 }
 
@@ -87,6 +99,7 @@ func (cs *ClientSet) HostServer() hostserver.HostServerClientInterface {
 		Client:   cs.client,
 		Discover: cs.discover.HostServer(),
 		Throttle: cs.throttle,
+		Breaker:  cs.breakers.Get(types.CC_MODULE_HOST),
 		Mock:     cs.Mock,
 	}
 	cs.Mock.SetMockData = false
@@ -98,6 +111,7 @@ func (cs *ClientSet) TopoServer() toposerver.TopoServerClientInterface {
 		Client:   cs.client,
 		Discover: cs.discover.TopoServer(),
 		Throttle: cs.throttle,
+		Breaker:  cs.breakers.Get(types.CC_MODULE_TOPO),
 		Mock:     cs.Mock,
 	}
 	cs.Mock.SetMockData = false
@@ -109,6 +123,7 @@ func (cs *ClientSet) ObjectController() objcontroller.ObjControllerClientInterfa
 		Client:   cs.client,
 		Discover: cs.discover.ObjectCtrl(),
 		Throttle: cs.throttle,
+		Breaker:  cs.breakers.Get(types.CC_MODULE_OBJECTCONTROLLER),
 		Mock:     cs.Mock,
 	}
 	return objcontroller.NewObjectControllerInterface(c, cs.version)
@@ -119,6 +134,7 @@ func (cs *ClientSet) ProcServer() procserver.ProcServerClientInterface {
 		Client:   cs.client,
 		Discover: cs.discover.ProcServer(),
 		Throttle: cs.throttle,
+		Breaker:  cs.breakers.Get(types.CC_MODULE_PROC),
 	}
 	cs.Mock.SetMockData = false
 	return procserver.NewProcServerClientInterface(c, cs.version)
@@ -129,6 +145,7 @@ func (cs *ClientSet) AdminServer() adminserver.AdminServerClientInterface {
 		Client:   cs.client,
 		Discover: cs.discover.MigrateServer(),
 		Throttle: cs.throttle,
+		Breaker:  cs.breakers.Get(types.CC_MODULE_MIGRATE),
 		Mock:     cs.Mock,
 	}
 	cs.Mock.SetMockData = false
@@ -140,6 +157,7 @@ func (cs *ClientSet) ApiServer() apiserver.ApiServerClientInterface {
 		Client:   cs.client,
 		Discover: cs.discover.ApiServer(),
 		Throttle: cs.throttle,
+		Breaker:  cs.breakers.Get(types.CC_MODULE_APISERVER),
 	}
 	return apiserver.NewApiServerClientInterface(c, cs.version)
 }
@@ -149,6 +167,7 @@ func (cs *ClientSet) EventServer() eventserver.EventServerClientInterface {
 		Client:   cs.client,
 		Discover: cs.discover.EventServer(),
 		Throttle: cs.throttle,
+		Breaker:  cs.breakers.Get(types.CC_MODULE_EVENTSERVER),
 		Mock:     cs.Mock,
 	}
 	cs.Mock.SetMockData = false
@@ -160,6 +179,7 @@ func (cs *ClientSet) ProcController() proccontroller.ProcCtrlClientInterface {
 		Client:   cs.client,
 		Discover: cs.discover.ProcCtrl(),
 		Throttle: cs.throttle,
+		Breaker:  cs.breakers.Get(types.CC_MODULE_PROCCONTROLLER),
 		Mock:     cs.Mock,
 	}
 	cs.Mock.SetMockData = false
@@ -171,6 +191,7 @@ func (cs *ClientSet) HostController() hostcontroller.HostCtrlClientInterface {
 		Client:   cs.client,
 		Discover: cs.discover.HostCtrl(),
 		Throttle: cs.throttle,
+		Breaker:  cs.breakers.Get(types.CC_MODULE_HOSTCONTROLLER),
 		Mock:     cs.Mock,
 	}
 	return hostcontroller.NewHostCtrlClientInterface(c, cs.version)
@@ -189,6 +210,7 @@ func (cs *ClientSet) CoreService() coreservice.CoreServiceClientInterface {
 		Client:   cs.client,
 		Discover: cs.discover.CoreService(),
 		Throttle: cs.throttle,
+		Breaker:  cs.breakers.Get(types.CC_MODULE_CORESERVICE),
 		Mock:     cs.Mock,
 	}
 	return coreservice.NewCoreServiceClient(c, cs.version)
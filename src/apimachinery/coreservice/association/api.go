@@ -298,3 +298,17 @@ func (asst *association) DeleteInstAssociation(ctx context.Context, h http.Heade
 		Into(resp)
 	return
 }
+
+func (asst *association) TraverseInstAssociation(ctx context.Context, h http.Header, input *metadata.GraphTraversalParams) (resp *metadata.GraphTraversalResponse, err error) {
+	resp = new(metadata.GraphTraversalResponse)
+	subPath := "/read/instanceassociation/traverse"
+
+	err = asst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
@@ -42,6 +42,7 @@ type AssociationClientInterface interface {
 	UpdateInstAssociation(ctx context.Context, h http.Header, input *metadata.UpdateOption) (resp *metadata.UpdatedOptionResult, err error)
 	ReadInstAssociation(ctx context.Context, h http.Header, input *metadata.QueryCondition) (resp *metadata.ReadInstAssociationResult, err error)
 	DeleteInstAssociation(ctx context.Context, h http.Header, input *metadata.DeleteOption) (resp *metadata.DeletedOptionResult, err error)
+	TraverseInstAssociation(ctx context.Context, h http.Header, input *metadata.GraphTraversalParams) (resp *metadata.GraphTraversalResponse, err error)
 }
 
 func NewAssociationClientInterface(client rest.ClientInterface) AssociationClientInterface {
@@ -49,3 +49,17 @@ func (inst *auditlog) SearchAuditLog(ctx context.Context, h http.Header, param m
 		Into(resp)
 	return
 }
+
+func (inst *auditlog) RollbackAuditLogs(ctx context.Context, h http.Header, param metadata.RollbackParams) (resp *metadata.RollbackResponse, err error) {
+	resp = new(metadata.RollbackResponse)
+	subPath := "/rollback/auditlog"
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(param).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
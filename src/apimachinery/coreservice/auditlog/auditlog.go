@@ -23,6 +23,7 @@ import (
 type AuditClientInterface interface {
 	SaveAuditLog(ctx context.Context, h http.Header, logs ...metadata.SaveAuditLogParams) (*metadata.Response, error)
 	SearchAuditLog(ctx context.Context, h http.Header, param metadata.QueryInput) (*metadata.AuditQueryResult, error)
+	RollbackAuditLogs(ctx context.Context, h http.Header, param metadata.RollbackParams) (*metadata.RollbackResponse, error)
 }
 
 func NewAuditClientInterface(client rest.ClientInterface) AuditClientInterface {
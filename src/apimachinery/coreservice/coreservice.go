@@ -17,11 +17,15 @@ import (
 
 	"icenter/src/apimachinery/coreservice/association"
 	"icenter/src/apimachinery/coreservice/auditlog"
+	"icenter/src/apimachinery/coreservice/event"
+	"icenter/src/apimachinery/coreservice/history"
 	"icenter/src/apimachinery/coreservice/host"
 	"icenter/src/apimachinery/coreservice/instance"
 	"icenter/src/apimachinery/coreservice/mainline"
 	"icenter/src/apimachinery/coreservice/model"
+	"icenter/src/apimachinery/coreservice/notification"
 	"icenter/src/apimachinery/coreservice/synchronize"
+	"icenter/src/apimachinery/coreservice/webhook"
 	"icenter/src/apimachinery/rest"
 	"icenter/src/apimachinery/util"
 )
@@ -34,6 +38,10 @@ type CoreServiceClientInterface interface {
 	Mainline() mainline.MainlineClientInterface
 	Host() host.HostClientInterface
 	Audit() auditlog.AuditClientInterface
+	Event() event.EventClientInterface
+	History() history.HistoryClientInterface
+	Webhook() webhook.WebhookClientInterface
+	Notification() notification.NotificationClientInterface
 }
 
 func NewCoreServiceClient(c *util.Capability, version string) CoreServiceClientInterface {
@@ -74,3 +82,19 @@ func (c *coreService) Host() host.HostClientInterface {
 func (c *coreService) Audit() auditlog.AuditClientInterface {
 	return auditlog.NewAuditClientInterface(c.restCli)
 }
+
+func (c *coreService) Event() event.EventClientInterface {
+	return event.NewEventClientInterface(c.restCli)
+}
+
+func (c *coreService) History() history.HistoryClientInterface {
+	return history.NewHistoryClientInterface(c.restCli)
+}
+
+func (c *coreService) Webhook() webhook.WebhookClientInterface {
+	return webhook.NewWebhookClientInterface(c.restCli)
+}
+
+func (c *coreService) Notification() notification.NotificationClientInterface {
+	return notification.NewNotificationClientInterface(c.restCli)
+}
@@ -0,0 +1,48 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package event
+
+import (
+	"context"
+	"net/http"
+
+	"icenter/src/common/metadata"
+)
+
+func (inst *event) ReadEventStream(ctx context.Context, h http.Header, param metadata.ReadEventStreamParams) (resp *metadata.ReadEventStreamResponse, err error) {
+	resp = new(metadata.ReadEventStreamResponse)
+	subPath := "/read/event/stream"
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(param).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *event) CommitEventOffset(ctx context.Context, h http.Header, param metadata.CommitEventOffsetParams) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/update/event/stream/offset"
+
+	err = inst.client.Put().
+		WithContext(ctx).
+		Body(param).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
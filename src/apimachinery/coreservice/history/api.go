@@ -0,0 +1,35 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"net/http"
+
+	"icenter/src/apimachinery/rest"
+	"icenter/src/common/metadata"
+)
+
+type HistoryClientInterface interface {
+	SearchInstanceVersions(ctx context.Context, h http.Header, objID string, instID int64) (*metadata.SearchInstanceVersionsResponse, error)
+	GetInstanceAsOf(ctx context.Context, h http.Header, objID string, instID int64, param metadata.InstanceAsOfParams) (*metadata.InstanceAsOfResponse, error)
+	SearchMainlineInstanceTopoAsOf(ctx context.Context, h http.Header, bkBizID int64, param metadata.MainlineTopoAsOfParams) (*metadata.SearchTopoInstanceNodeResult, error)
+}
+
+func NewHistoryClientInterface(client rest.ClientInterface) HistoryClientInterface {
+	return &history{client: client}
+}
+
+type history struct {
+	client rest.ClientInterface
+}
@@ -0,0 +1,63 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"icenter/src/common/metadata"
+)
+
+func (inst *history) SearchInstanceVersions(ctx context.Context, h http.Header, objID string, instID int64) (resp *metadata.SearchInstanceVersionsResponse, err error) {
+	resp = new(metadata.SearchInstanceVersionsResponse)
+	subPath := fmt.Sprintf("/read/history/model/%s/instance/%d/versions", objID, instID)
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *history) GetInstanceAsOf(ctx context.Context, h http.Header, objID string, instID int64, param metadata.InstanceAsOfParams) (resp *metadata.InstanceAsOfResponse, err error) {
+	resp = new(metadata.InstanceAsOfResponse)
+	subPath := fmt.Sprintf("/read/history/model/%s/instance/%d", objID, instID)
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(param).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *history) SearchMainlineInstanceTopoAsOf(ctx context.Context, h http.Header, bkBizID int64, param metadata.MainlineTopoAsOfParams) (resp *metadata.SearchTopoInstanceNodeResult, err error) {
+	resp = new(metadata.SearchTopoInstanceNodeResult)
+	subPath := fmt.Sprintf("/read/history/mainline/instance/%d", bkBizID)
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(param).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
@@ -117,3 +117,31 @@ func (inst *instance) DeleteInstanceCascade(ctx context.Context, h http.Header,
 		Into(resp)
 	return
 }
+
+func (inst *instance) BackfillComputedAttributes(ctx context.Context, h http.Header, objID string) (resp *metadata.UpdatedOptionResult, err error) {
+	resp = new(metadata.UpdatedOptionResult)
+	subPath := fmt.Sprintf("/update/model/%s/computed/backfill", objID)
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *instance) AggregateInstance(ctx context.Context, h http.Header, objID string, input *metadata.AggregateInstanceParams) (resp *metadata.AggregateInstanceResponse, err error) {
+	resp = new(metadata.AggregateInstanceResponse)
+	subPath := fmt.Sprintf("/read/model/%s/instances/aggregate", objID)
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
@@ -0,0 +1,39 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"context"
+	"net/http"
+
+	"icenter/src/apimachinery/rest"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+)
+
+type NotificationClientInterface interface {
+	CreateRule(ctx context.Context, h http.Header, rule metadata.NotificationRule) (*metadata.CreateNotificationRuleResponse, error)
+	UpdateRule(ctx context.Context, h http.Header, id int64, data mapstr.MapStr) (*metadata.Response, error)
+	DeleteRule(ctx context.Context, h http.Header, id int64) (*metadata.Response, error)
+	SearchRules(ctx context.Context, h http.Header, input metadata.QueryCondition) (*metadata.SearchNotificationRuleResponse, error)
+	GetSetting(ctx context.Context, h http.Header, user string) (*metadata.NotificationSettingResponse, error)
+	SaveSetting(ctx context.Context, h http.Header, setting metadata.NotificationSetting) (*metadata.Response, error)
+}
+
+func NewNotificationClientInterface(client rest.ClientInterface) NotificationClientInterface {
+	return &notification{client: client}
+}
+
+type notification struct {
+	client rest.ClientInterface
+}
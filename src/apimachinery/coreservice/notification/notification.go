@@ -0,0 +1,106 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+)
+
+func (inst *notification) CreateRule(ctx context.Context, h http.Header, rule metadata.NotificationRule) (resp *metadata.CreateNotificationRuleResponse, err error) {
+	resp = new(metadata.CreateNotificationRuleResponse)
+	subPath := "/create/notification/rule"
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(rule).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *notification) UpdateRule(ctx context.Context, h http.Header, id int64, data mapstr.MapStr) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/update/notification/rule/%d", id)
+
+	err = inst.client.Put().
+		WithContext(ctx).
+		Body(data).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *notification) DeleteRule(ctx context.Context, h http.Header, id int64) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/delete/notification/rule/%d", id)
+
+	err = inst.client.Delete().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *notification) SearchRules(ctx context.Context, h http.Header, input metadata.QueryCondition) (resp *metadata.SearchNotificationRuleResponse, err error) {
+	resp = new(metadata.SearchNotificationRuleResponse)
+	subPath := "/read/notification/rule"
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *notification) GetSetting(ctx context.Context, h http.Header, user string) (resp *metadata.NotificationSettingResponse, err error) {
+	resp = new(metadata.NotificationSettingResponse)
+	subPath := fmt.Sprintf("/read/notification/setting/%s", user)
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *notification) SaveSetting(ctx context.Context, h http.Header, setting metadata.NotificationSetting) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/update/notification/setting"
+
+	err = inst.client.Put().
+		WithContext(ctx).
+		Body(setting).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
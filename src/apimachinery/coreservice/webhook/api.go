@@ -0,0 +1,40 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"context"
+	"net/http"
+
+	"icenter/src/apimachinery/rest"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+)
+
+type WebhookClientInterface interface {
+	CreateSubscription(ctx context.Context, h http.Header, subscription metadata.WebhookSubscription) (*metadata.CreateWebhookSubscriptionResponse, error)
+	UpdateSubscription(ctx context.Context, h http.Header, id int64, data mapstr.MapStr) (*metadata.Response, error)
+	DeleteSubscription(ctx context.Context, h http.Header, id int64) (*metadata.Response, error)
+	SearchSubscriptions(ctx context.Context, h http.Header, input metadata.QueryCondition) (*metadata.SearchWebhookSubscriptionResponse, error)
+	SearchDeadLetters(ctx context.Context, h http.Header, input metadata.SearchWebhookDeadLetterParams) (*metadata.SearchWebhookDeadLetterResponse, error)
+	RedeliverDeadLetters(ctx context.Context, h http.Header, input metadata.WebhookDeadLetterParams) (*metadata.UpdatedOptionResult, error)
+	DeleteDeadLetters(ctx context.Context, h http.Header, input metadata.WebhookDeadLetterParams) (*metadata.DeletedOptionResult, error)
+}
+
+func NewWebhookClientInterface(client rest.ClientInterface) WebhookClientInterface {
+	return &webhook{client: client}
+}
+
+type webhook struct {
+	client rest.ClientInterface
+}
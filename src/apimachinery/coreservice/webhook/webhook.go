@@ -0,0 +1,120 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+)
+
+func (inst *webhook) CreateSubscription(ctx context.Context, h http.Header, subscription metadata.WebhookSubscription) (resp *metadata.CreateWebhookSubscriptionResponse, err error) {
+	resp = new(metadata.CreateWebhookSubscriptionResponse)
+	subPath := "/create/webhook/subscription"
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(subscription).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *webhook) UpdateSubscription(ctx context.Context, h http.Header, id int64, data mapstr.MapStr) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/update/webhook/subscription/%d", id)
+
+	err = inst.client.Put().
+		WithContext(ctx).
+		Body(data).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *webhook) DeleteSubscription(ctx context.Context, h http.Header, id int64) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/delete/webhook/subscription/%d", id)
+
+	err = inst.client.Delete().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *webhook) SearchSubscriptions(ctx context.Context, h http.Header, input metadata.QueryCondition) (resp *metadata.SearchWebhookSubscriptionResponse, err error) {
+	resp = new(metadata.SearchWebhookSubscriptionResponse)
+	subPath := "/read/webhook/subscription"
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *webhook) SearchDeadLetters(ctx context.Context, h http.Header, input metadata.SearchWebhookDeadLetterParams) (resp *metadata.SearchWebhookDeadLetterResponse, err error) {
+	resp = new(metadata.SearchWebhookDeadLetterResponse)
+	subPath := "/read/webhook/deadletter"
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *webhook) RedeliverDeadLetters(ctx context.Context, h http.Header, input metadata.WebhookDeadLetterParams) (resp *metadata.UpdatedOptionResult, err error) {
+	resp = new(metadata.UpdatedOptionResult)
+	subPath := "/redeliver/webhook/deadletter"
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *webhook) DeleteDeadLetters(ctx context.Context, h http.Header, input metadata.WebhookDeadLetterParams) (resp *metadata.DeletedOptionResult, err error) {
+	resp = new(metadata.DeletedOptionResult)
+	subPath := "/delete/webhook/deadletter"
+
+	err = inst.client.Delete().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
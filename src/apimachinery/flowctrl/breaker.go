@@ -0,0 +1,386 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flowctrl
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"icenter/src/common/blog"
+	"icenter/src/common/metric"
+)
+
+// ErrBreakerOpen the request is rejected by the open circuit breaker of the server
+var ErrBreakerOpen = errors.New("circuit breaker is open")
+
+// ErrRetryBudgetExhausted the retries of the service exceeds the retry budget
+var ErrRetryBudgetExhausted = errors.New("retry budget is exhausted")
+
+// BreakerState the state of the circuit breaker of a server
+type BreakerState int
+
+const (
+	// StateClosed the requests are sent to the server
+	StateClosed BreakerState = iota
+	// StateOpen the requests to the server are rejected
+	StateOpen
+	// StateHalfOpen a few probe requests are sent to the server to decide whether it recovers
+	StateHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	breakerState = metric.NewGaugeVec("cmdb_apimachinery_breaker_state",
+		"the state of the circuit breaker of the server, 0 closed, 1 open, 2 half-open.", "service", "server")
+	breakerTransitions = metric.NewCounterVec("cmdb_apimachinery_breaker_transitions_total",
+		"the state changes of the circuit breaker of the server.", "service", "server", "state")
+	serverEjections = metric.NewCounterVec("cmdb_apimachinery_server_ejections_total",
+		"the times the server is ejected from the server list.", "service", "server")
+	retryBudgetExhausted = metric.NewCounterVec("cmdb_apimachinery_retry_budget_exhausted_total",
+		"the retries rejected by the retry budget.", "service")
+)
+
+func init() {
+	metric.Register(breakerState)
+	metric.Register(breakerTransitions)
+	metric.Register(serverEjections)
+	metric.Register(retryBudgetExhausted)
+}
+
+// BreakerConfig the config of the circuit breaker, the retry budget and the outlier ejection of a service
+type BreakerConfig struct {
+	// Disabled the requests are sent without the circuit breaker
+	Disabled bool
+	// Window the rolling window of the error rate and the retry budget
+	Window time.Duration
+	// MinRequests the min requests to the server in the window before the breaker opens
+	MinRequests int64
+	// ErrorRate the error rate in the window which opens the breaker
+	ErrorRate float64
+	// OpenTimeout how long the breaker keeps open before it's half-open
+	OpenTimeout time.Duration
+	// HalfOpenRequests the probe requests of the half-open breaker, it's closed after all of them succeed
+	HalfOpenRequests int
+	// RetryRatio the max retries as a fraction of the requests in the window
+	RetryRatio float64
+	// MinRetries the retries allowed in the window regardless of the ratio
+	MinRetries int64
+	// EjectionFailures the consecutive failures which eject the server from the server list
+	EjectionFailures int
+	// EjectionTime the base ejection time, it grows with the times the server is ejected in a row
+	EjectionTime time.Duration
+	// MaxEjectionPercent the max percent of the servers which can be ejected
+	MaxEjectionPercent int
+}
+
+// DefaultBreakerConfig returns the default config of the circuit breaker
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Window:             10 * time.Second,
+		MinRequests:        20,
+		ErrorRate:          0.5,
+		OpenTimeout:        5 * time.Second,
+		HalfOpenRequests:   3,
+		RetryRatio:         0.2,
+		MinRetries:         10,
+		EjectionFailures:   5,
+		EjectionTime:       30 * time.Second,
+		MaxEjectionPercent: 50,
+	}
+}
+
+const windowBuckets = 10
+
+// rollingWindow counts the requests and the failures of the recent buckets
+type rollingWindow struct {
+	bucketSize int64
+	slots      [windowBuckets]int64
+	requests   [windowBuckets]int64
+	failures   [windowBuckets]int64
+}
+
+func newRollingWindow(window time.Duration) *rollingWindow {
+	size := int64(window) / windowBuckets
+	if size <= 0 {
+		size = int64(time.Second)
+	}
+	return &rollingWindow{bucketSize: size}
+}
+
+func (w *rollingWindow) add(now time.Time, requests, failures int64) {
+	slot := now.UnixNano() / w.bucketSize
+	i := slot % windowBuckets
+	if w.slots[i] != slot {
+		w.slots[i], w.requests[i], w.failures[i] = slot, 0, 0
+	}
+	w.requests[i] += requests
+	w.failures[i] += failures
+}
+
+func (w *rollingWindow) sum(now time.Time) (requests, failures int64) {
+	slot := now.UnixNano() / w.bucketSize
+	for i := range w.slots {
+		if slot-w.slots[i] < windowBuckets {
+			requests += w.requests[i]
+			failures += w.failures[i]
+		}
+	}
+	return requests, failures
+}
+
+func (w *rollingWindow) reset() {
+	*w = rollingWindow{bucketSize: w.bucketSize}
+}
+
+// serverState the breaker and the ejection state of a server
+type serverState struct {
+	state    BreakerState
+	window   *rollingWindow
+	openedAt time.Time
+	// probes the probe requests sent in the half-open state
+	probes int
+	// successes the successful probe requests in the half-open state
+	successes int
+
+	consecutiveFailures int
+	ejections           int
+	ejectedUntil        time.Time
+}
+
+// Breaker the circuit breakers of the servers of a service, the retry budget of the service
+// and the outlier ejection of its server list.
+type Breaker struct {
+	name   string
+	config BreakerConfig
+	now    func() time.Time
+
+	lock    sync.Mutex
+	servers map[string]*serverState
+	// budget the requests and the retries of the service
+	budget *rollingWindow
+}
+
+// NewBreaker returns the breaker of the service
+func NewBreaker(name string, config BreakerConfig) *Breaker {
+	return &Breaker{
+		name:    name,
+		config:  config,
+		now:     time.Now,
+		servers: make(map[string]*serverState),
+		budget:  newRollingWindow(config.Window),
+	}
+}
+
+func (b *Breaker) server(server string) *serverState {
+	s, exists := b.servers[server]
+	if !exists {
+		s = &serverState{window: newRollingWindow(b.config.Window)}
+		b.servers[server] = s
+	}
+	return s
+}
+
+func (b *Breaker) setState(server string, s *serverState, state BreakerState) {
+	blog.Warnf("[apimachinery] the circuit breaker of %s server %s changes from %s to %s", b.name, server, s.state, state)
+	s.state = state
+	s.probes, s.successes = 0, 0
+	if state == StateOpen {
+		s.openedAt = b.now()
+	}
+	if state == StateClosed {
+		s.window.reset()
+	}
+	breakerState.Set(float64(state), b.name, server)
+	breakerTransitions.Inc(b.name, server, state.String())
+}
+
+// Servers returns the servers which are not ejected, the ejected ones are kept if more than
+// MaxEjectionPercent of the servers are ejected.
+func (b *Breaker) Servers(servers []string) []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := b.now()
+	healthy := make([]string, 0, len(servers))
+	ejected := make([]string, 0)
+	for _, server := range servers {
+		if s, exists := b.servers[server]; exists && now.Before(s.ejectedUntil) {
+			ejected = append(ejected, server)
+			continue
+		}
+		healthy = append(healthy, server)
+	}
+
+	maxEjected := len(servers) * b.config.MaxEjectionPercent / 100
+	if len(ejected) > maxEjected {
+		healthy = append(healthy, ejected[maxEjected:]...)
+	}
+	return healthy
+}
+
+// Allow returns ErrBreakerOpen if the request to the server is rejected,
+// Done should be called with the result of the request if it's allowed.
+func (b *Breaker) Allow(server string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	s := b.server(server)
+	if s.state == StateOpen && b.now().Sub(s.openedAt) >= b.config.OpenTimeout {
+		b.setState(server, s, StateHalfOpen)
+	}
+
+	switch s.state {
+	case StateOpen:
+		return fmt.Errorf("%s server %s: %v", b.name, server, ErrBreakerOpen)
+	case StateHalfOpen:
+		if s.probes >= b.config.HalfOpenRequests {
+			return fmt.Errorf("%s server %s: %v", b.name, server, ErrBreakerOpen)
+		}
+		s.probes++
+	}
+	return nil
+}
+
+// Cancel releases the request allowed by Allow which is not sent to the server
+func (b *Breaker) Cancel(server string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if s := b.server(server); s.state == StateHalfOpen && s.probes > 0 {
+		s.probes--
+	}
+}
+
+// Done records the result of the request to the server
+func (b *Breaker) Done(server string, success bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := b.now()
+	s := b.server(server)
+
+	if success {
+		s.consecutiveFailures = 0
+		if !now.Before(s.ejectedUntil) {
+			s.ejections = 0
+		}
+	} else {
+		s.consecutiveFailures++
+		if s.consecutiveFailures >= b.config.EjectionFailures && !now.Before(s.ejectedUntil) {
+			s.consecutiveFailures = 0
+			s.ejections++
+			s.ejectedUntil = now.Add(time.Duration(s.ejections) * b.config.EjectionTime)
+			blog.Warnf("[apimachinery] %s server %s is ejected until %s after %d consecutive failures",
+				b.name, server, s.ejectedUntil.Format(time.RFC3339), b.config.EjectionFailures)
+			serverEjections.Inc(b.name, server)
+		}
+	}
+
+	switch s.state {
+	case StateClosed:
+		failures := int64(0)
+		if !success {
+			failures = 1
+		}
+		s.window.add(now, 1, failures)
+		requests, failures := s.window.sum(now)
+		if requests >= b.config.MinRequests && float64(failures) >= b.config.ErrorRate*float64(requests) {
+			b.setState(server, s, StateOpen)
+		}
+	case StateHalfOpen:
+		if !success {
+			b.setState(server, s, StateOpen)
+			return
+		}
+		s.successes++
+		if s.successes >= b.config.HalfOpenRequests {
+			b.setState(server, s, StateClosed)
+		}
+	}
+}
+
+// State returns the breaker state of the server
+func (b *Breaker) State(server string) BreakerState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if s, exists := b.servers[server]; exists {
+		return s.state
+	}
+	return StateClosed
+}
+
+// Request records a request of the service for the retry budget
+func (b *Breaker) Request() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.budget.add(b.now(), 1, 0)
+}
+
+// AllowRetry returns whether a retry is in the retry budget, it's recorded if it's allowed
+func (b *Breaker) AllowRetry() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := b.now()
+	requests, retries := b.budget.sum(now)
+	if float64(retries) >= float64(b.config.MinRetries)+b.config.RetryRatio*float64(requests) {
+		retryBudgetExhausted.Inc(b.name)
+		blog.V(3).Infof("[apimachinery] the retry to %s is rejected, %d retries of %d requests in the window", b.name, retries, requests)
+		return false
+	}
+	b.budget.add(now, 0, 1)
+	return true
+}
+
+// Breakers the breakers of the services which share a config
+type Breakers struct {
+	config   BreakerConfig
+	lock     sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewBreakers returns the breakers of the config
+func NewBreakers(config BreakerConfig) *Breakers {
+	return &Breakers{
+		config:   config,
+		breakers: make(map[string]*Breaker),
+	}
+}
+
+// Get returns the breaker of the service, it returns nil if the breakers is nil or disabled.
+func (b *Breakers) Get(name string) *Breaker {
+	if b == nil || b.config.Disabled {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	breaker, exists := b.breakers[name]
+	if !exists {
+		breaker = NewBreaker(name, b.config)
+		b.breakers[name] = breaker
+	}
+	return breaker
+}
@@ -0,0 +1,132 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flowctrl
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestBreaker() (*Breaker, *time.Time) {
+	now := time.Unix(1000, 0)
+	b := NewBreaker("coreservice", DefaultBreakerConfig())
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreakerStates(t *testing.T) {
+	b, now := newTestBreaker()
+	server := "http://127.0.0.1:50009"
+
+	// the breaker keeps closed before the min requests
+	for i := 0; i < 19; i++ {
+		if err := b.Allow(server); err != nil {
+			t.Fatal(err)
+		}
+		b.Done(server, i%2 == 0)
+	}
+	if b.State(server) != StateClosed {
+		t.Fatalf("the breaker should be closed, got %s", b.State(server))
+	}
+	b.Allow(server)
+	b.Done(server, false)
+	if b.State(server) != StateOpen {
+		t.Fatalf("the breaker should be open, got %s", b.State(server))
+	}
+	if err := b.Allow(server); err == nil {
+		t.Fatal("the request should be rejected by the open breaker")
+	}
+
+	// only the probe requests are allowed after the open timeout
+	*now = now.Add(5 * time.Second)
+	for i := 0; i < 3; i++ {
+		if err := b.Allow(server); err != nil {
+			t.Fatalf("the probe request should be allowed, err: %v", err)
+		}
+	}
+	if err := b.Allow(server); err == nil {
+		t.Fatal("the requests more than the probes should be rejected")
+	}
+	b.Done(server, true)
+	b.Done(server, false)
+	if b.State(server) != StateOpen {
+		t.Fatalf("the breaker should be open after a failed probe, got %s", b.State(server))
+	}
+
+	*now = now.Add(5 * time.Second)
+	for i := 0; i < 3; i++ {
+		b.Allow(server)
+		b.Done(server, true)
+	}
+	if b.State(server) != StateClosed {
+		t.Fatalf("the breaker should be closed after the probes succeed, got %s", b.State(server))
+	}
+
+	// the old failures are out of the window
+	*now = now.Add(11 * time.Second)
+	for i := 0; i < 30; i++ {
+		b.Done(server, i%3 != 0)
+	}
+	if b.State(server) != StateClosed {
+		t.Fatalf("the breaker should be closed under the error rate, got %s", b.State(server))
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	b, now := newTestBreaker()
+	for i := 0; i < 100; i++ {
+		b.Request()
+	}
+	// 10 min retries and 20% of the requests
+	for i := 0; i < 30; i++ {
+		if !b.AllowRetry() {
+			t.Fatalf("the retry %d should be allowed", i)
+		}
+	}
+	if b.AllowRetry() {
+		t.Fatal("the retry should exceed the budget")
+	}
+	*now = now.Add(10 * time.Second)
+	if !b.AllowRetry() {
+		t.Fatal("the retry should be allowed in the new window")
+	}
+}
+
+func TestOutlierEjection(t *testing.T) {
+	b, now := newTestBreaker()
+	servers := []string{"a", "b", "c", "d"}
+	for i := 0; i < 5; i++ {
+		b.Done("b", false)
+		b.Done("c", false)
+		b.Done("d", false)
+	}
+	// at most half of the servers are ejected
+	if got := b.Servers(servers); !reflect.DeepEqual(got, []string{"a", "d"}) {
+		t.Fatalf("unexpected servers %v", got)
+	}
+
+	*now = now.Add(30 * time.Second)
+	if got := b.Servers(servers); !reflect.DeepEqual(got, servers) {
+		t.Fatalf("the ejected servers should be back, got %v", got)
+	}
+
+	// the ejection time grows with the ejections in a row
+	for i := 0; i < 5; i++ {
+		b.Done("b", false)
+	}
+	*now = now.Add(30 * time.Second)
+	if got := b.Servers(servers); !reflect.DeepEqual(got, []string{"a", "c", "d"}) {
+		t.Fatalf("unexpected servers %v", got)
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"syscall"
 	"time"
 
+	"icenter/src/apimachinery/flowctrl"
 	"icenter/src/apimachinery/util"
 	"icenter/src/common/blog"
 	commonUtil "icenter/src/common/util"
@@ -226,8 +227,17 @@ func (r *Request) Do() *Result {
 		return result
 	}
 
+	breaker := r.capability.Breaker
+	if breaker != nil {
+		hosts = breaker.Servers(hosts)
+		breaker.Request()
+	}
+
 	maxRetryCycle := 3
 	var retries int
+	// sent the requests sent to the servers, the requests after the first one are retries.
+	var sent int
+	var lastErr error
 	for try := 0; try < maxRetryCycle; try++ {
 		for index, host := range hosts {
 			retries = try + index
@@ -249,6 +259,22 @@ func (r *Request) Do() *Result {
 			req.Header.Set("Content-Type", "application/json")
 			req.Header.Set("Accept", "application/json")
 
+			if breaker != nil {
+				if err := breaker.Allow(host); err != nil {
+					lastErr = err
+					continue
+				}
+				if sent > 0 && !breaker.AllowRetry() {
+					breaker.Cancel(host)
+					if lastErr == nil {
+						lastErr = flowctrl.ErrRetryBudgetExhausted
+					}
+					result.Err = fmt.Errorf("%v, last error: %v", flowctrl.ErrRetryBudgetExhausted, lastErr)
+					return result
+				}
+			}
+			sent++
+
 			if retries > 0 {
 				r.tryThrottle(url)
 			}
@@ -256,6 +282,8 @@ func (r *Request) Do() *Result {
 			start := time.Now()
 			resp, err := client.Do(req)
 			if err != nil {
+				r.done(host, false)
+				lastErr = err
 				// "Connection reset by peer" is a special err which in most scenario is a a transient error.
 				// Which means that we can retry it. And so does the GET operation.
 				// While the other "write" operation can not simply retry it again, because they are not idempotent.
@@ -279,6 +307,8 @@ func (r *Request) Do() *Result {
 			if resp.Body != nil {
 				data, err := ioutil.ReadAll(resp.Body)
 				if err != nil {
+					r.done(host, false)
+					lastErr = err
 					if err == io.ErrUnexpectedEOF {
 						// retry now
 						time.Sleep(20 * time.Millisecond)
@@ -290,6 +320,7 @@ func (r *Request) Do() *Result {
 				}
 				body = data
 			}
+			r.done(host, !isServerUnavailable(resp.StatusCode))
 			blog.V(4).InfoDepthf(2, "[apimachinery][peek] cost: %dms, %s %s with body %s\nresponse status: %s, response body: %s, rid: %s",
 				cost, string(r.verb), url, r.body, resp.Status, body, rid)
 			result.Body = body
@@ -301,10 +332,27 @@ func (r *Request) Do() *Result {
 
 	}
 
+	if lastErr != nil {
+		result.Err = lastErr
+		return result
+	}
 	result.Err = errors.New("unexpected error")
 	return result
 }
 
+// done records the result of the request to the host in the circuit breaker
+func (r *Request) done(host string, success bool) {
+	if r.capability.Breaker != nil {
+		r.capability.Breaker.Done(host, success)
+	}
+}
+
+// isServerUnavailable returns whether the status code means the server is overloaded or unavailable,
+// which is counted as a failure of the server by the circuit breaker.
+func isServerUnavailable(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
 const maxLatency = 100 * time.Millisecond
 
 func (r *Request) tryThrottle(url string) {
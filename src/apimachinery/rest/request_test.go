@@ -0,0 +1,69 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"icenter/src/apimachinery/flowctrl"
+	"icenter/src/apimachinery/util"
+)
+
+type staticDiscover []string
+
+func (d staticDiscover) GetServers() ([]string, error) {
+	return d, nil
+}
+
+func TestRequestWithBreaker(t *testing.T) {
+	var badRequests, goodRequests int32
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&badRequests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&goodRequests, 1)
+		w.Write([]byte(`{"result": true}`))
+	}))
+	defer good.Close()
+
+	config := flowctrl.DefaultBreakerConfig()
+	config.MinRequests = 5
+	config.EjectionFailures = 100
+	client := NewRESTClient(&util.Capability{
+		Discover: staticDiscover{bad.URL, good.URL},
+		Breaker:  flowctrl.NewBreaker("coreservice", config),
+	}, "/api/v3/")
+
+	for i := 0; i < 5; i++ {
+		client.Get().SubResource("/ping").Do()
+	}
+	if state := client.Get().capability.Breaker.State(bad.URL); state != flowctrl.StateOpen {
+		t.Fatalf("the breaker of the unavailable server should be open, got %s", state)
+	}
+
+	// the requests are sent to the other server when the breaker is open
+	for i := 0; i < 5; i++ {
+		result := client.Get().SubResource("/ping").Do()
+		if result.Err != nil || result.StatusCode != http.StatusOK {
+			t.Fatalf("the request should be sent to the good server, got %+v", result)
+		}
+	}
+	if badRequests != 5 || goodRequests != 5 {
+		t.Errorf("unexpected requests, bad: %d, good: %d", badRequests, goodRequests)
+	}
+}
@@ -23,13 +23,17 @@ type APIMachineryConfig struct {
 	// request's burst value
 	Burst     int64
 	TLSConfig *TLSClientConfig
+	// the circuit breaker config of the services, the default one is used if it's nil
+	Breaker *flowctrl.BreakerConfig
 }
 
 type Capability struct {
 	Client   HttpClient
 	Discover discovery.Interface
 	Throttle flowctrl.RateLimiter
-	Mock     MockInfo
+	// Breaker the circuit breaker of the service, the requests are sent without it if it's nil
+	Breaker *flowctrl.Breaker
+	Mock    MockInfo
 }
 
 type MockInfo struct {
@@ -18,7 +18,9 @@ import (
 
 	"icenter/src/apimachinery/util"
 	"icenter/src/auth/authcenter"
+	"icenter/src/auth/local"
 	"icenter/src/auth/meta"
+	"icenter/src/common/storage/dal"
 )
 
 type Authorize interface {
@@ -65,6 +67,11 @@ type ResourceHandler interface {
 // This allows bk-cmdb to support other kind of auth center.
 // tls can be nil if it is not care.
 // authConfig is a way to parse configuration info for the connection to a auth center.
-func NewAuthorize(tls *util.TLSClientConfig, authConfig authcenter.AuthConfig) (Authorize, error) {
+// db is used by the local backend to load the user group privileges, it can be nil
+// if the local backend is not used.
+func NewAuthorize(tls *util.TLSClientConfig, authConfig authcenter.AuthConfig, db dal.RDB) (Authorize, error) {
+	if authConfig.Backend == authcenter.BackendLocal {
+		return local.NewLocal(authConfig, db)
+	}
 	return authcenter.NewAuthCenter(tls, authConfig)
 }
@@ -63,6 +63,24 @@ func ParseConfigFromKV(prefix string, configmap map[string]string) (AuthConfig,
 		}
 	}
 
+	cfg.Backend = BackendAuthCenter
+	if backend, exist := configmap[prefix+".backend"]; exist && len(backend) > 0 {
+		cfg.Backend = backend
+	}
+
+	switch cfg.Backend {
+	case BackendAuthCenter:
+	case BackendLocal:
+		// the local backend does not connect to the auth center at all.
+		if admins, exist := configmap[prefix+".admins"]; exist && len(admins) > 0 {
+			cfg.Admins = strings.Split(strings.Replace(admins, " ", "", -1), ",")
+		}
+		cfg.SystemID = SystemIDCMDB
+		return cfg, nil
+	default:
+		return cfg, fmt.Errorf(`invalid auth "backend" value: %s`, cfg.Backend)
+	}
+
 	address, exist := configmap[prefix+".address"]
 	if !exist {
 		return cfg, errors.New(`missing "address" configuration for auth center`)
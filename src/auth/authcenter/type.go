@@ -34,8 +34,22 @@ type AuthConfig struct {
 	Enable bool
 	// enable sync auth data to iam
 	EnableSync bool
+	// the auth backend that is used to authorize, which can be
+	// BackendAuthCenter or BackendLocal, default is BackendAuthCenter.
+	Backend string
+	// the users who have all the permissions, only used by the local backend.
+	Admins []string
 }
 
+// the supported auth backends
+const (
+	// BackendAuthCenter authorize with blueking's auth center.
+	BackendAuthCenter = "authcenter"
+	// BackendLocal authorize with the user group privileges stored in cmdb's database,
+	// which does not need any external auth center.
+	BackendLocal = "local"
+)
+
 type RegisterInfo struct {
 	CreatorType string           `json:"creator_type"`
 	CreatorID   string           `json:"creator_id"`
@@ -146,3 +146,29 @@ func (am *AuthManager) DeregisterAssociationTypeByIDs(ctx context.Context, heade
 
 	return am.Authorize.DeregisterResource(ctx, resources...)
 }
+
+// AuthorizeByInstanceAssociationID authorize the instance associations by their ids, the association
+// to be created has no id yet, so that it's authorized with the zero id.
+func (am *AuthManager) AuthorizeByInstanceAssociationID(ctx context.Context, header http.Header, action meta.Action, ids ...int64) error {
+	if am.Enabled() == false {
+		return nil
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	resources := make([]meta.ResourceAttribute, 0, len(ids))
+	for _, id := range ids {
+		resources = append(resources, meta.ResourceAttribute{
+			Basic: meta.Basic{
+				Type:       meta.ModelInstanceAssociation,
+				Action:     action,
+				InstanceID: id,
+			},
+			SupplierAccount: util.GetOwnerID(header),
+		})
+	}
+
+	return am.batchAuthorize(ctx, header, resources...)
+}
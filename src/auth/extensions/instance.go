@@ -289,6 +289,37 @@ func (am *AuthManager) AuthorizeByInstances(ctx context.Context, header http.Hea
 	return am.batchAuthorize(ctx, header, resources...)
 }
 
+// AuthorizeInstanceCreate authorize to create the instance of the model within the business
+func (am *AuthManager) AuthorizeInstanceCreate(ctx context.Context, header http.Header, businessID int64, objID string) error {
+	if am.Enabled() == false {
+		return nil
+	}
+
+	// the model's id in the layer decides which model's privilege is required
+	objects, err := am.collectObjectsByObjectIDs(ctx, header, businessID, objID)
+	if err != nil {
+		return fmt.Errorf("collect object by id %s failed, err: %+v", objID, err)
+	}
+
+	resource := meta.ResourceAttribute{
+		Basic: meta.Basic{
+			Type:   meta.ModelInstance,
+			Action: meta.Create,
+		},
+		Layers: []meta.Item{
+			{
+				Type:       meta.Model,
+				Name:       objID,
+				InstanceID: objects[0].ID,
+			},
+		},
+		SupplierAccount: util.GetOwnerID(header),
+		BusinessID:      businessID,
+	}
+
+	return am.authorize(ctx, header, businessID, resource)
+}
+
 func (am *AuthManager) UpdateRegisteredInstances(ctx context.Context, header http.Header, instances ...InstanceSimplify) error {
 	rid := util.ExtractRequestIDFromContext(ctx)
 
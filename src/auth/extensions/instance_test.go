@@ -0,0 +1,71 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extensions
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"icenter/src/apimachinery"
+	"icenter/src/auth"
+	"icenter/src/auth/authcenter"
+	"icenter/src/auth/local"
+	"icenter/src/common"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal/memory"
+)
+
+// TestAuthorizeInstanceCreate restore an instance of a custom model with the local backend
+func TestAuthorizeInstanceCreate(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewMemory()
+	require.NoError(t, db.Table(common.BKTableNameObjDes).Insert(ctx, map[string]interface{}{
+		common.BKFieldID:      10,
+		common.BKObjIDField:   "switch",
+		common.BKOwnerIDField: "0",
+	}))
+	require.NoError(t, db.Table(common.BKTableNameBaseApp).Insert(ctx, map[string]interface{}{
+		common.BKAppIDField:       2,
+		common.BKMaintainersField: "tom,spike",
+		common.BKOwnerIDField:     "0",
+	}))
+	require.NoError(t, db.Table(common.BKTableNameUserGroup).Insert(ctx, map[string]interface{}{
+		common.BKUserGroupIDField: "ops",
+		common.BKUserListField:    "tom;jerry",
+		common.BKOwnerIDField:     "0",
+	}))
+	require.NoError(t, db.Table(common.BKTableNameUserGroupPrivilege).Insert(ctx, map[string]interface{}{
+		common.BKUserGroupIDField: "ops",
+		common.BKOwnerIDField:     "0",
+		"model_config":            map[string]interface{}{"bk_network": map[string]interface{}{"switch": []string{"update"}}},
+	}))
+	backend, err := local.NewLocal(authcenter.AuthConfig{Enable: true, Backend: authcenter.BackendLocal}, db)
+	require.NoError(t, err)
+
+	resp := metadata.ReadModelResult{BaseResp: metadata.SuccessBaseResp}
+	resp.Data.Count = 1
+	resp.Data.Info = []metadata.SearchModelInfo{{Spec: metadata.Object{ID: 10, ObjectID: "switch", OwnerID: "0"}}}
+	am := NewAuthManager(apimachinery.NewMockApiMachinery().MockDo(resp), backend)
+
+	header := http.Header{}
+	header.Set(common.BKHTTPHeaderUser, "tom")
+	header.Set(common.BKHTTPOwnerID, "0")
+	require.NoError(t, am.AuthorizeInstanceCreate(ctx, header, 2, "switch"))
+
+	// the maintainer of the business who has no privilege on the model
+	header.Set(common.BKHTTPHeaderUser, "spike")
+	require.Equal(t, auth.NoAuthorizeError, am.AuthorizeInstanceCreate(ctx, header, 2, "switch"))
+}
@@ -0,0 +1,482 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"icenter/src/auth/authcenter"
+	"icenter/src/auth/authcenter/permit"
+	"icenter/src/auth/meta"
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/util"
+)
+
+// Local authorize the resources with the user group privileges stored in cmdb's own database,
+// which are managed by the /topo/privilege/group/* api, so that it does not need any external auth center.
+type Local struct {
+	config authcenter.AuthConfig
+	db     dal.RDB
+	now    func() time.Time
+
+	// policies the cached policies of the users, they're dropped by Refresh when the user groups or their
+	// privileges are changed, and reloaded after policyCacheTTL for the changes made by the other processes.
+	lock     sync.RWMutex
+	policies map[string]cachedPolicy
+}
+
+// policyCacheTTL how long a loaded policy is used before it's loaded again
+const policyCacheTTL = 30 * time.Second
+
+type cachedPolicy struct {
+	policy   *userPolicy
+	expireAt time.Time
+}
+
+// NewLocal create a local authorizer with the database which stores the user group privileges.
+func NewLocal(cfg authcenter.AuthConfig, db dal.RDB) (*Local, error) {
+	blog.V(5).Infof("new local authorizer with parameters cfg: %+v", cfg)
+	if cfg.Enable && db == nil {
+		return nil, errors.New("local authorizer need a db to load the privileges")
+	}
+
+	return &Local{
+		config:   cfg,
+		db:       db,
+		now:      time.Now,
+		policies: make(map[string]cachedPolicy),
+	}, nil
+}
+
+func (l *Local) Enabled() bool {
+	return l.config.Enable
+}
+
+func (l *Local) Authorize(ctx context.Context, a *meta.AuthAttribute) (decision meta.Decision, err error) {
+	if !l.config.Enable {
+		return meta.Decision{Authorized: true}, nil
+	}
+
+	// filter out SkipAction, which set by api server to skip authorization
+	noSkipResources := make([]meta.ResourceAttribute, 0)
+	for _, resource := range a.Resources {
+		if resource.Action == meta.SkipAction {
+			continue
+		}
+		noSkipResources = append(noSkipResources, resource)
+	}
+	a.Resources = noSkipResources
+	if len(noSkipResources) == 0 {
+		blog.V(5).Infof("Authorize skip. auth attribute: %+v", a)
+		return meta.Decision{Authorized: true}, nil
+	}
+
+	decisions, err := l.AuthorizeBatch(ctx, a.User, a.Resources...)
+	if err != nil {
+		return meta.Decision{}, err
+	}
+
+	noAuth := make([]string, 0)
+	for i, item := range decisions {
+		if !item.Authorized {
+			noAuth = append(noAuth, fmt.Sprintf("resource [%v] permission deny by reason: %s", a.Resources[i].Type, item.Reason))
+		}
+	}
+
+	if len(noAuth) > 0 {
+		return meta.Decision{
+			Authorized: false,
+			Reason:     fmt.Sprintf("%v", noAuth),
+		}, nil
+	}
+
+	return meta.Decision{Authorized: true}, nil
+}
+
+func (l *Local) AuthorizeBatch(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) (decisions []meta.Decision, err error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	decisions = make([]meta.Decision, len(resources))
+	if !l.config.Enable {
+		for i := range decisions {
+			decisions[i].Authorized = true
+		}
+		return decisions, nil
+	}
+
+	policy, err := l.getPolicy(ctx, user.SupplierAccount, user.UserName)
+	if err != nil {
+		blog.Errorf("auth batch, but load user %s policy failed, err: %v, rid: %s", user.UserName, err, rid)
+		return nil, err
+	}
+
+	objIDs, err := l.getLayerObjectIDs(ctx, resources...)
+	if err != nil {
+		blog.Errorf("auth batch, but get model of resources failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	var businessIDs []int64
+	for index, rsc := range resources {
+		if permit.ShouldSkipAuthorize(&rsc) {
+			decisions[index].Authorized = true
+			blog.V(5).Infof("skip authorization for resource: %+v, rid: %s", rsc, rid)
+			continue
+		}
+
+		if rsc.BusinessID > 0 && !policy.admin {
+			if businessIDs == nil {
+				businessIDs, err = l.getAnyAuthorizedBusinessList(ctx, user, policy)
+				if err != nil {
+					blog.Errorf("auth batch, but get user %s business failed, err: %v, rid: %s", user.UserName, err, rid)
+					return nil, err
+				}
+			}
+			if !util.InArray(rsc.BusinessID, businessIDs) {
+				decisions[index].Reason = fmt.Sprintf("user is not authorized with business %d", rsc.BusinessID)
+				continue
+			}
+		}
+
+		decisions[index] = policy.decide(&rsc, objIDs)
+	}
+
+	return decisions, nil
+}
+
+func (l *Local) GetAnyAuthorizedBusinessList(ctx context.Context, user meta.UserInfo) ([]int64, error) {
+	if !l.config.Enable {
+		return make([]int64, 0), nil
+	}
+
+	policy, err := l.getPolicy(ctx, user.SupplierAccount, user.UserName)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.getAnyAuthorizedBusinessList(ctx, user, policy)
+}
+
+// get a user's authorized read business list.
+func (l *Local) GetExactAuthorizedBusinessList(ctx context.Context, user meta.UserInfo) ([]int64, error) {
+	if !l.config.Enable {
+		return make([]int64, 0), nil
+	}
+
+	policy, err := l.getPolicy(ctx, user.SupplierAccount, user.UserName)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.getBusinessList(ctx, user, policy.admin)
+}
+
+func (l *Local) AdminEntrance(ctx context.Context, user meta.UserInfo) ([]string, error) {
+	systemList := make([]string, 0)
+	if !l.config.Enable {
+		return systemList, nil
+	}
+
+	policy, err := l.getPolicy(ctx, user.SupplierAccount, user.UserName)
+	if err != nil {
+		return nil, err
+	}
+
+	if policy.hasSystemPrivilege(sysModel) {
+		systemList = append(systemList, authcenter.SystemIDCMDB)
+	}
+	return systemList, nil
+}
+
+func (l *Local) GetAuthorizedAuditList(ctx context.Context, user meta.UserInfo, businessID int64) ([]authcenter.AuthorizedResource, error) {
+	if !l.config.Enable {
+		return nil, nil
+	}
+
+	policy, err := l.getPolicy(ctx, user.SupplierAccount, user.UserName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !policy.hasSystemPrivilege(sysAudit) {
+		return nil, nil
+	}
+
+	resourceType := authcenter.SysAuditLog
+	if businessID > 0 {
+		resourceType = authcenter.BizAuditLog
+		if !policy.admin {
+			businessIDs, err := l.getAnyAuthorizedBusinessList(ctx, user, policy)
+			if err != nil {
+				return nil, err
+			}
+			if !util.InArray(businessID, businessIDs) {
+				return nil, nil
+			}
+		}
+	}
+
+	objIDs := policy.searchableModels()
+	if policy.admin {
+		objIDs, err = l.getAllObjectIDs(ctx, user.SupplierAccount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the last layer of the resource id is the model that the audit log belongs to.
+	audit := authcenter.AuthorizedResource{
+		ActionID:     authcenter.Get,
+		ResourceType: resourceType,
+		ResourceIDs:  make([][]authcenter.RscTypeAndID, 0),
+	}
+	for _, objID := range objIDs {
+		audit.ResourceIDs = append(audit.ResourceIDs, []authcenter.RscTypeAndID{
+			{
+				ResourceType: resourceType,
+				ResourceID:   objID,
+			},
+		})
+	}
+
+	return []authcenter.AuthorizedResource{audit}, nil
+}
+
+// the resources are stored in cmdb itself, so that the local authorizer
+// does not need to register or deregister any of them.
+
+func (l *Local) RegisterResource(ctx context.Context, rs ...meta.ResourceAttribute) error {
+	return nil
+}
+
+func (l *Local) DryRunRegisterResource(ctx context.Context, rs ...meta.ResourceAttribute) (*authcenter.RegisterInfo, error) {
+	return nil, nil
+}
+
+func (l *Local) DeregisterResource(ctx context.Context, rs ...meta.ResourceAttribute) error {
+	return nil
+}
+
+func (l *Local) RawDeregisterResource(ctx context.Context, scope authcenter.ScopeInfo, rs ...meta.BackendResource) error {
+	return nil
+}
+
+func (l *Local) UpdateResource(ctx context.Context, rs *meta.ResourceAttribute) error {
+	return nil
+}
+
+func (l *Local) Get(ctx context.Context) error {
+	return nil
+}
+
+func (l *Local) ListResources(ctx context.Context, r *meta.ResourceAttribute) ([]meta.BackendResource, error) {
+	return nil, nil
+}
+
+func (l *Local) Init(ctx context.Context, config meta.InitConfig) error {
+	return nil
+}
+
+// getAnyAuthorizedBusinessList returns the businesses that user has any permission with,
+// which is the business that user plays a role in, or all the business if user can search business.
+func (l *Local) getAnyAuthorizedBusinessList(ctx context.Context, user meta.UserInfo, policy *userPolicy) ([]int64, error) {
+	return l.getBusinessList(ctx, user, policy.hasModelPrivilege(common.BKInnerObjIDApp, privilegeSearch))
+}
+
+// getBusinessList returns all the businesses if all is true, otherwise returns
+// the businesses that user plays a role in.
+func (l *Local) getBusinessList(ctx context.Context, user meta.UserInfo, all bool) ([]int64, error) {
+	fields := append([]string{common.BKAppIDField}, businessRoleFields...)
+	cond := util.SetQueryOwner(map[string]interface{}{}, user.SupplierAccount)
+	businesses := make([]map[string]interface{}, 0)
+	if err := l.db.Table(common.BKTableNameBaseApp).Find(cond).Fields(fields...).All(ctx, &businesses); err != nil {
+		return nil, err
+	}
+
+	businessIDs := make([]int64, 0)
+	for _, biz := range businesses {
+		id, err := util.GetInt64ByInterface(biz[common.BKAppIDField])
+		if err != nil {
+			return nil, fmt.Errorf("invalid business id %v, err: %v", biz[common.BKAppIDField], err)
+		}
+
+		if all {
+			businessIDs = append(businessIDs, id)
+			continue
+		}
+
+		for _, field := range businessRoleFields {
+			users, ok := biz[field].(string)
+			if ok && util.InStrArr(splitUsers(users), user.UserName) {
+				businessIDs = append(businessIDs, id)
+				break
+			}
+		}
+	}
+
+	return businessIDs, nil
+}
+
+// getLayerObjectIDs returns the model's object id of the resources' model layer.
+// map's key is the model's id, value is it's object id.
+func (l *Local) getLayerObjectIDs(ctx context.Context, resources ...meta.ResourceAttribute) (map[int64]string, error) {
+	ids := make([]int64, 0)
+	for _, rsc := range resources {
+		if id := modelLayerID(&rsc); id > 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	objIDs := make(map[int64]string)
+	if len(ids) == 0 {
+		return objIDs, nil
+	}
+
+	cond := map[string]interface{}{
+		common.BKFieldID: map[string]interface{}{common.BKDBIN: ids},
+	}
+	models := make([]map[string]interface{}, 0)
+	err := l.db.Table(common.BKTableNameObjDes).Find(cond).Fields(common.BKFieldID, common.BKObjIDField).All(ctx, &models)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, model := range models {
+		id, err := util.GetInt64ByInterface(model[common.BKFieldID])
+		if err != nil {
+			return nil, fmt.Errorf("invalid model id %v, err: %v", model[common.BKFieldID], err)
+		}
+		objIDs[id] = util.GetStrByInterface(model[common.BKObjIDField])
+	}
+
+	return objIDs, nil
+}
+
+func (l *Local) getAllObjectIDs(ctx context.Context, supplierAccount string) ([]string, error) {
+	cond := util.SetQueryOwner(map[string]interface{}{}, supplierAccount)
+	models := make([]map[string]interface{}, 0)
+	if err := l.db.Table(common.BKTableNameObjDes).Find(cond).Fields(common.BKObjIDField).All(ctx, &models); err != nil {
+		return nil, err
+	}
+
+	objIDs := make([]string, 0)
+	for _, model := range models {
+		objIDs = append(objIDs, util.GetStrByInterface(model[common.BKObjIDField]))
+	}
+	return objIDs, nil
+}
+
+// modelLayerID returns the model's id of a model instance resource,
+// which is recorded as the last model layer of this resource.
+func modelLayerID(rsc *meta.ResourceAttribute) int64 {
+	if rsc.Type != meta.ModelInstance && rsc.Type != meta.MainlineInstance {
+		return 0
+	}
+
+	for i := len(rsc.Layers) - 1; i >= 0; i-- {
+		if rsc.Layers[i].Type == meta.Model {
+			return rsc.Layers[i].InstanceID
+		}
+	}
+	return 0
+}
+
+// convertAction convert the resource action to the privilege configured in user group.
+func convertAction(action meta.Action) string {
+	switch action {
+	case meta.Find, meta.FindMany:
+		return privilegeSearch
+	case meta.Create, meta.CreateMany:
+		return privilegeCreate
+	case meta.Delete, meta.DeleteMany, meta.Archive:
+		return privilegeDelete
+	default:
+		return privilegeUpdate
+	}
+}
+
+// decide make the authorize decision of a resource with the user's policy.
+// objIDs is the model's id to object id map of model instance resources.
+func (p *userPolicy) decide(rsc *meta.ResourceAttribute, objIDs map[int64]string) meta.Decision {
+	privilege := convertAction(rsc.Action)
+
+	var objID string
+	switch rsc.Type {
+	case meta.Business:
+		objID = common.BKInnerObjIDApp
+	case meta.ModelSet:
+		objID = common.BKInnerObjIDSet
+	case meta.ModelModule:
+		objID = common.BKInnerObjIDModule
+	case meta.Process:
+		objID = common.BKInnerObjIDProc
+	case meta.Plat:
+		objID = common.BKInnerObjIDPlat
+	case meta.HostInstance, meta.DynamicGrouping:
+		// the hosts in resource pool is controlled by the global business config.
+		if rsc.BusinessID <= 0 && privilege != privilegeSearch {
+			return p.decideSystem(sysResource)
+		}
+		objID = common.BKInnerObjIDHost
+
+	case meta.ModelInstance, meta.MainlineInstance:
+		var exist bool
+		objID, exist = objIDs[modelLayerID(rsc)]
+		if !exist {
+			return meta.Decision{Reason: "can not find the model of this instance"}
+		}
+
+	case meta.Model,
+		meta.ModelClassification,
+		meta.ModelAttribute,
+		meta.ModelAttributeGroup,
+		meta.ModelUnique,
+		meta.ModelAssociation,
+		meta.AssociationType,
+		meta.MainlineModel,
+		meta.MainlineModelTopology,
+		meta.ModelTopology,
+		meta.SystemBase:
+		if privilege == privilegeSearch {
+			return meta.Decision{Authorized: true}
+		}
+		return p.decideSystem(sysModel)
+
+	case meta.EventPushing:
+		return p.decideSystem(sysEvent)
+	case meta.AuditLog:
+		return p.decideSystem(sysAudit)
+	default:
+		if p.admin {
+			return meta.Decision{Authorized: true}
+		}
+		return meta.Decision{Reason: fmt.Sprintf("unsupported resource type: %s", rsc.Type)}
+	}
+
+	if p.hasModelPrivilege(objID, privilege) {
+		return meta.Decision{Authorized: true}
+	}
+	return meta.Decision{Reason: fmt.Sprintf("no %s privilege on model %s", privilege, objID)}
+}
+
+func (p *userPolicy) decideSystem(privilege string) meta.Decision {
+	if p.hasSystemPrivilege(privilege) {
+		return meta.Decision{Authorized: true}
+	}
+	return meta.Decision{Reason: fmt.Sprintf("no %s system privilege", privilege)}
+}
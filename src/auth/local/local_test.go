@@ -0,0 +1,156 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"icenter/src/auth/authcenter"
+	"icenter/src/auth/meta"
+	"icenter/src/common"
+	"icenter/src/common/storage/dal/memory"
+)
+
+func TestPolicyDecide(t *testing.T) {
+	policy := &userPolicy{
+		models: map[string][]string{
+			"host":   {privilegeSearch, privilegeUpdate},
+			"switch": {privilegeSearch, privilegeDelete},
+		},
+		system: []string{sysAudit},
+	}
+	objIDs := map[int64]string{10: "switch"}
+
+	tests := []struct {
+		name       string
+		resource   meta.ResourceAttribute
+		authorized bool
+	}{
+		{
+			name:       "update host in business",
+			resource:   meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Update}, BusinessID: 2},
+			authorized: true,
+		},
+		{
+			name:       "create host implied by update",
+			resource:   meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Create}, BusinessID: 2},
+			authorized: true,
+		},
+		{
+			name:       "add host to resource pool",
+			resource:   meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.AddHostToResourcePool}},
+			authorized: false,
+		},
+		{
+			name: "delete model instance",
+			resource: meta.ResourceAttribute{
+				Basic:  meta.Basic{Type: meta.ModelInstance, Action: meta.Delete, InstanceID: 1},
+				Layers: meta.Layers{{Type: meta.Model, InstanceID: 10}},
+			},
+			authorized: true,
+		},
+		{
+			name: "update model instance",
+			resource: meta.ResourceAttribute{
+				Basic:  meta.Basic{Type: meta.ModelInstance, Action: meta.Update, InstanceID: 1},
+				Layers: meta.Layers{{Type: meta.Model, InstanceID: 10}},
+			},
+			authorized: false,
+		},
+		{
+			name:       "model instance without model layer",
+			resource:   meta.ResourceAttribute{Basic: meta.Basic{Type: meta.ModelInstance, Action: meta.Find}},
+			authorized: false,
+		},
+		{
+			name:       "update model",
+			resource:   meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Model, Action: meta.Update}},
+			authorized: false,
+		},
+		{
+			name:       "read audit log",
+			resource:   meta.ResourceAttribute{Basic: meta.Basic{Type: meta.AuditLog, Action: meta.FindMany}},
+			authorized: true,
+		},
+		{
+			name:       "delete business",
+			resource:   meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Business, Action: meta.Delete}},
+			authorized: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy.decide(&tt.resource, objIDs)
+			require.Equal(t, tt.authorized, decision.Authorized, decision.Reason)
+		})
+	}
+
+	admin := &userPolicy{admin: true}
+	for _, tt := range tests[:5] {
+		require.True(t, admin.decide(&tt.resource, objIDs).Authorized, tt.name)
+	}
+}
+
+func TestSplitUsers(t *testing.T) {
+	require.Equal(t, []string{"admin", "tom", "jerry"}, splitUsers("admin;tom,jerry;"))
+	require.Empty(t, splitUsers(""))
+}
+
+func TestPolicyCache(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewMemory()
+	group := map[string]interface{}{
+		common.BKUserGroupIDField: "ops",
+		common.BKUserListField:    "tom",
+		common.BKOwnerIDField:     "0",
+	}
+	require.NoError(t, db.Table(common.BKTableNameUserGroup).Insert(ctx, group))
+	privilege := map[string]interface{}{
+		common.BKUserGroupIDField: "ops",
+		common.BKOwnerIDField:     "0",
+		"sys_config":              map[string]interface{}{"back_config": []string{sysAudit}},
+	}
+	require.NoError(t, db.Table(common.BKTableNameUserGroupPrivilege).Insert(ctx, privilege))
+
+	l, err := NewLocal(authcenter.AuthConfig{Enable: true, Backend: authcenter.BackendLocal}, db)
+	require.NoError(t, err)
+	now := time.Unix(1000, 0)
+	l.now = func() time.Time { return now }
+
+	policy, err := l.getPolicy(ctx, "0", "tom")
+	require.NoError(t, err)
+	require.True(t, policy.hasSystemPrivilege(sysAudit))
+
+	// the cached policy is used until it's refreshed or expired
+	filter := map[string]interface{}{common.BKUserGroupIDField: "ops"}
+	require.NoError(t, db.Table(common.BKTableNameUserGroupPrivilege).Delete(ctx, filter))
+	policy, err = l.getPolicy(ctx, "0", "tom")
+	require.NoError(t, err)
+	require.True(t, policy.hasSystemPrivilege(sysAudit))
+
+	l.Refresh()
+	policy, err = l.getPolicy(ctx, "0", "tom")
+	require.NoError(t, err)
+	require.False(t, policy.hasSystemPrivilege(sysAudit))
+
+	require.NoError(t, db.Table(common.BKTableNameUserGroupPrivilege).Insert(ctx, privilege))
+	now = now.Add(policyCacheTTL)
+	policy, err = l.getPolicy(ctx, "0", "tom")
+	require.NoError(t, err)
+	require.True(t, policy.hasSystemPrivilege(sysAudit))
+}
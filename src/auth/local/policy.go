@@ -0,0 +1,206 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"icenter/src/common"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/util"
+)
+
+// the privileges that can be configured on a model in user group's model config.
+const (
+	privilegeSearch = "search"
+	privilegeCreate = "create"
+	privilegeUpdate = "update"
+	privilegeDelete = "delete"
+)
+
+// the system privileges that can be configured in user group's sys config.
+const (
+	// global business config, which means the host resource pool.
+	sysResource = "resource"
+	// back config
+	sysModel = "model"
+	sysEvent = "event"
+	sysAudit = "audit"
+)
+
+// the business role fields, users listed in one of these fields
+// is authorized with this business.
+var businessRoleFields = []string{
+	common.BKMaintainersField,
+	common.BKProductPMField,
+	common.BKDeveloperField,
+	common.BKTesterField,
+}
+
+// groupPrivilege is the privilege document of a user group stored in cc_UserGroupPrivilege.
+type groupPrivilege struct {
+	GroupID         string                         `bson:"group_id"`
+	SupplierAccount string                         `bson:"bk_supplier_account"`
+	ModelConfig     map[string]map[string][]string `bson:"model_config"`
+	SysConfig       struct {
+		Globalbusi []string `bson:"global_busi"`
+		BackConfig []string `bson:"back_config"`
+	} `bson:"sys_config"`
+}
+
+// userPolicy is the merged privileges of all the user groups that a user belongs to.
+type userPolicy struct {
+	// the user has all the permissions.
+	admin bool
+	// object id -> privileges
+	models map[string][]string
+	// the global business config and back config
+	system []string
+}
+
+func (p *userPolicy) hasModelPrivilege(objID, privilege string) bool {
+	if p.admin {
+		return true
+	}
+	privileges, exist := p.models[objID]
+	if !exist {
+		return false
+	}
+	if util.InStrArr(privileges, privilege) {
+		return true
+	}
+
+	// the privileges used to be configured on the web page only contains
+	// search, update and delete, so update implies create.
+	if privilege == privilegeCreate {
+		return util.InStrArr(privileges, privilegeUpdate)
+	}
+	return false
+}
+
+func (p *userPolicy) hasSystemPrivilege(privilege string) bool {
+	if p.admin {
+		return true
+	}
+	return util.InStrArr(p.system, privilege)
+}
+
+// searchableModels returns the object ids that this user can search.
+func (p *userPolicy) searchableModels() []string {
+	objIDs := make([]string, 0)
+	for objID := range p.models {
+		if p.hasModelPrivilege(objID, privilegeSearch) {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	return objIDs
+}
+
+// getPolicy returns the cached policy of the user, it's loaded if it's not cached or expired.
+func (l *Local) getPolicy(ctx context.Context, supplierAccount, userName string) (*userPolicy, error) {
+	key := supplierAccount + "/" + userName
+	now := l.now()
+	l.lock.RLock()
+	cached, exist := l.policies[key]
+	l.lock.RUnlock()
+	if exist && now.Before(cached.expireAt) {
+		return cached.policy, nil
+	}
+
+	policy, err := l.loadPolicy(ctx, supplierAccount, userName)
+	if err != nil {
+		return nil, err
+	}
+	l.lock.Lock()
+	l.policies[key] = cachedPolicy{policy: policy, expireAt: now.Add(policyCacheTTL)}
+	l.lock.Unlock()
+	return policy, nil
+}
+
+// Refresh drop all the cached policies, it's called when the user groups or their privileges are changed.
+func (l *Local) Refresh() {
+	l.lock.Lock()
+	l.policies = make(map[string]cachedPolicy)
+	l.lock.Unlock()
+}
+
+// loadPolicy load all the user groups that this user belongs to, and merge their privileges.
+func (l *Local) loadPolicy(ctx context.Context, supplierAccount, userName string) (*userPolicy, error) {
+	policy := &userPolicy{
+		models: make(map[string][]string),
+		system: make([]string, 0),
+	}
+	if util.InStrArr(l.config.Admins, userName) {
+		policy.admin = true
+		return policy, nil
+	}
+
+	groupIDs, err := UserGroupIDs(ctx, l.db, supplierAccount, userName)
+	if err != nil {
+		return nil, err
+	}
+	if len(groupIDs) == 0 {
+		return policy, nil
+	}
+
+	privilegeCond := util.SetQueryOwner(map[string]interface{}{
+		common.BKUserGroupIDField: map[string]interface{}{common.BKDBIN: groupIDs},
+	}, supplierAccount)
+	privileges := make([]groupPrivilege, 0)
+	if err := l.db.Table(common.BKTableNameUserGroupPrivilege).Find(privilegeCond).All(ctx, &privileges); err != nil {
+		return nil, err
+	}
+
+	for _, privilege := range privileges {
+		for _, models := range privilege.ModelConfig {
+			for objID, actions := range models {
+				policy.models[objID] = append(policy.models[objID], actions...)
+			}
+		}
+		policy.system = append(policy.system, privilege.SysConfig.Globalbusi...)
+		policy.system = append(policy.system, privilege.SysConfig.BackConfig...)
+	}
+	policy.system = util.RemoveDuplicatesAndEmpty(policy.system)
+
+	return policy, nil
+}
+
+// UserGroupIDs returns the ids of the user groups that the user belongs to.
+func UserGroupIDs(ctx context.Context, db dal.RDB, supplierAccount, userName string) ([]string, error) {
+	groupCond := util.SetQueryOwner(map[string]interface{}{
+		common.BKUserListField: map[string]interface{}{common.BKDBLIKE: regexp.QuoteMeta(userName)},
+	}, supplierAccount)
+	groups := make([]metadata.UserGroup, 0)
+	if err := db.Table(common.BKTableNameUserGroup).Find(groupCond).All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	groupIDs := make([]string, 0)
+	for _, group := range groups {
+		// the like condition may match a user whose name contains this user name.
+		if util.InStrArr(splitUsers(group.UserList), userName) {
+			groupIDs = append(groupIDs, group.GroupID)
+		}
+	}
+	return groupIDs, nil
+}
+
+// splitUsers split the user list which is joined by ';' or ','
+func splitUsers(users string) []string {
+	return strings.FieldsFunc(users, func(r rune) bool {
+		return r == ';' || r == ','
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
 
 	"icenter/src/auth/meta"
@@ -28,6 +29,8 @@ type RequestContext struct {
 	Elements []string
 	// http request body contents.
 	Body []byte
+	// the query parameters of the request url
+	Query url.Values
 
 	Metadata metadata.Metadata
 }
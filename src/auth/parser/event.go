@@ -26,7 +26,9 @@ func (ps *parseStream) eventRelated() *parseStream {
 		return ps
 	}
 
-	ps.subscribe()
+	ps.subscribe().
+		webhook().
+		notification()
 
 	return ps
 }
@@ -140,3 +142,179 @@ func (ps *parseStream) subscribe() *parseStream {
 
 	return ps
 }
+
+const (
+	createWebhookSubscriptionPattern  = "/api/v3/webhook/subscription"
+	findWebhookSubscriptionPattern    = "/api/v3/webhook/subscription/search"
+	findWebhookDeadLetterPattern      = "/api/v3/webhook/deadletter/search"
+	redeliverWebhookDeadLetterPattern = "/api/v3/webhook/deadletter/redeliver"
+	deleteWebhookDeadLetterPattern    = "/api/v3/webhook/deadletter"
+)
+
+var (
+	updateWebhookSubscriptionRegexp = regexp.MustCompile(`^/api/v3/webhook/subscription/[0-9]+/?$`)
+	deleteWebhookSubscriptionRegexp = regexp.MustCompile(`^/api/v3/webhook/subscription/[0-9]+/?$`)
+)
+
+// webhook the webhook subscriptions and their dead letters are authorized as the event pushing.
+func (ps *parseStream) webhook() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// create a webhook subscription
+	if ps.hitPattern(createWebhookSubscriptionPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.EventPushing,
+					Action: meta.Create,
+				},
+			},
+		}
+		return ps
+	}
+
+	// find the webhook subscriptions or the dead letters
+	if ps.hitPattern(findWebhookSubscriptionPattern, http.MethodPost) ||
+		ps.hitPattern(findWebhookDeadLetterPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.EventPushing,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	// update a webhook subscription, or redeliver the dead letters
+	if ps.hitRegexp(updateWebhookSubscriptionRegexp, http.MethodPut) ||
+		ps.hitPattern(redeliverWebhookDeadLetterPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.EventPushing,
+					Action: meta.Update,
+				},
+			},
+		}
+		return ps
+	}
+
+	// delete a webhook subscription, or the dead letters
+	if ps.hitRegexp(deleteWebhookSubscriptionRegexp, http.MethodDelete) ||
+		ps.hitPattern(deleteWebhookDeadLetterPattern, http.MethodDelete) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.EventPushing,
+					Action: meta.Delete,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
+const (
+	createNotificationRulePattern = "/api/v3/notification/rule"
+	findNotificationRulePattern   = "/api/v3/notification/rule/search"
+	notificationSettingPattern    = "/api/v3/notification/setting"
+)
+
+var (
+	updateNotificationRuleRegexp = regexp.MustCompile(`^/api/v3/notification/rule/[0-9]+/?$`)
+	deleteNotificationRuleRegexp = regexp.MustCompile(`^/api/v3/notification/rule/[0-9]+/?$`)
+)
+
+// notification the notification rules are authorized as the event pushing, and the notification
+// setting is the user's own custom setting.
+func (ps *parseStream) notification() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// create a notification rule
+	if ps.hitPattern(createNotificationRulePattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.EventPushing,
+					Action: meta.Create,
+				},
+			},
+		}
+		return ps
+	}
+
+	// find the notification rules
+	if ps.hitPattern(findNotificationRulePattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.EventPushing,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	// update a notification rule
+	if ps.hitRegexp(updateNotificationRuleRegexp, http.MethodPut) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.EventPushing,
+					Action: meta.Update,
+				},
+			},
+		}
+		return ps
+	}
+
+	// delete a notification rule
+	if ps.hitRegexp(deleteNotificationRuleRegexp, http.MethodDelete) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.EventPushing,
+					Action: meta.Delete,
+				},
+			},
+		}
+		return ps
+	}
+
+	// get the notification setting of the current user
+	if ps.hitPattern(notificationSettingPattern, http.MethodGet) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.UserCustom,
+					Action: meta.Find,
+				},
+			},
+		}
+		return ps
+	}
+
+	// save the notification setting of the current user, such as the opted out rules
+	if ps.hitPattern(notificationSettingPattern, http.MethodPut) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.UserCustom,
+					Action: meta.Update,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
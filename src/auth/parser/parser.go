@@ -16,6 +16,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"mime"
 	"net/http"
 	"regexp"
 	"strings"
@@ -24,6 +25,7 @@ import (
 	"icenter/src/common"
 	"icenter/src/common/backbone"
 	"icenter/src/common/metadata"
+	"icenter/src/common/spreadsheet"
 	"icenter/src/common/util"
 
 	"github.com/emicklei/go-restful"
@@ -42,7 +44,8 @@ func ParseAttribute(req *restful.Request, engine *backbone.Engine) (*meta.AuthAt
 	// TODO: this condition should be optimized.
 	// one of the api like body is an array is
 	// put: /api/v3/objectatt/group/property
-	if len(body) > 0 && body[0] != '[' {
+	// and the spreadsheet is uploaded to import the instances.
+	if len(body) > 0 && body[0] != '[' && !isSpreadsheetUpload(req.Request) {
 		if err := json.Unmarshal(body, &meta); err != nil {
 			return nil, fmt.Errorf("parse attribute, but unmarshal body failed, err: %v", err)
 		}
@@ -59,6 +62,7 @@ func ParseAttribute(req *restful.Request, engine *backbone.Engine) (*meta.AuthAt
 		URI:      req.Request.URL.Path,
 		Elements: elements,
 		Body:     body,
+		Query:    req.Request.URL.Query(),
 		Metadata: meta.Metadata,
 	}
 
@@ -70,6 +74,16 @@ func ParseAttribute(req *restful.Request, engine *backbone.Engine) (*meta.AuthAt
 	return stream.Parse()
 }
 
+// isSpreadsheetUpload returns whether the body of the request is an uploaded spreadsheet instead of json
+func isSpreadsheetUpload(req *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		return true
+	}
+	_, err := spreadsheet.ParseFormat(mediaType)
+	return err == nil
+}
+
 // ParseCommonInfo get common info from req, aims at avoiding too much repeat code
 func ParseCommonInfo(requestHeader *http.Header) (*meta.CommonInfo, error) {
 	commonInfo := new(meta.CommonInfo)
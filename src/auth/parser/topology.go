@@ -555,6 +555,7 @@ const (
 
 var (
 	deleteObjectInstanceAssociationRegexp = regexp.MustCompile("/api/v3/inst/association/[0-9]+/action/delete")
+	updateObjectInstanceAssociationRegexp = regexp.MustCompile("/api/v3/inst/association/[0-9]+/action/update")
 )
 
 func (ps *parseStream) objectInstanceAssociation() *parseStream {
@@ -613,6 +614,31 @@ func (ps *parseStream) objectInstanceAssociation() *parseStream {
 		return ps
 	}
 
+	// update the attributes of object's instance association operation.
+	if ps.hitRegexp(updateObjectInstanceAssociationRegexp, http.MethodPut) {
+		if len(ps.RequestCtx.Elements) != 7 {
+			ps.err = errors.New("update object instance association, but got invalid url")
+			return ps
+		}
+
+		assoID, err := strconv.ParseInt(ps.RequestCtx.Elements[4], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("update object instance association, but got invalid association id %s", ps.RequestCtx.Elements[4])
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:       meta.ModelInstanceAssociation,
+					Action:     meta.Update,
+					InstanceID: assoID,
+				},
+			},
+		}
+		return ps
+	}
+
 	return ps
 }
 
@@ -632,6 +658,9 @@ var (
 	findBusinessInstanceTopologyRegexp  = regexp.MustCompile(`^/api/v3/topo/inst/[^\s/]+/[0-9]+/?$`)
 	findObjectInstancesRegexp           = regexp.MustCompile(`^/api/v3/inst/search/owner/[^\s/]+/object/[^\s/]+/?$`)
 	findObjectInstancesDetailRegexp     = regexp.MustCompile(`^/api/v3/inst/search/owner/[^\s/]+/object/[^\s/]+/detail/?$`)
+	aggregateObjectInstancesRegexp      = regexp.MustCompile(`^/api/v3/inst/aggregate/[^\s/]+/[^\s/]+/?$`)
+	importObjectInstancesRegexp         = regexp.MustCompile(`^/api/v3/inst/transfer/import/[^\s/]+/[^\s/]+/?$`)
+	exportObjectInstancesRegexp         = regexp.MustCompile(`^/api/v3/inst/transfer/export/[^\s/]+/[^\s/]+/?$`)
 )
 
 func (ps *parseStream) objectInstance() *parseStream {
@@ -938,6 +967,110 @@ func (ps *parseStream) objectInstance() *parseStream {
 		return ps
 	}
 
+	// aggregate object's instances operation, limited to the business if it's set.
+	if ps.hitRegexp(aggregateObjectInstancesRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 6 {
+			ps.err = errors.New("aggregate object's instances, but got invalid url")
+			return ps
+		}
+		bizID, err := ps.parseBusinessID()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.ModelInstance,
+					Action: meta.FindMany,
+				},
+				Layers: []meta.Item{
+					{
+						Type: meta.Model,
+						Name: ps.RequestCtx.Elements[5],
+					},
+				},
+			},
+		}
+		return ps
+	}
+
+	// import object's instances from the spreadsheet, the rows are created or updated.
+	if ps.hitRegexp(importObjectInstancesRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 7 {
+			ps.err = errors.New("import object's instances, but got invalid url")
+			return ps
+		}
+		// the body is the spreadsheet, so the business is set in the query.
+		var bizID int64
+		if value := ps.RequestCtx.Query.Get(common.BKAppIDField); len(value) != 0 {
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || id <= 0 {
+				ps.err = fmt.Errorf("import object's instances, but got invalid business id %s", value)
+				return ps
+			}
+			bizID = id
+		}
+
+		layers := []meta.Item{
+			{
+				Type: meta.Model,
+				Name: ps.RequestCtx.Elements[6],
+			},
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.ModelInstance,
+					Action: meta.Create,
+				},
+				Layers: layers,
+			},
+			{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.ModelInstance,
+					Action: meta.Update,
+				},
+				Layers: layers,
+			},
+		}
+		return ps
+	}
+
+	// export object's instances to the spreadsheet.
+	if ps.hitRegexp(exportObjectInstancesRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 7 {
+			ps.err = errors.New("export object's instances, but got invalid url")
+			return ps
+		}
+		bizID, err := ps.parseBusinessID()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.ModelInstance,
+					Action: meta.FindMany,
+				},
+				Layers: []meta.Item{
+					{
+						Type: meta.Model,
+						Name: ps.RequestCtx.Elements[6],
+					},
+				},
+			},
+		}
+		return ps
+	}
+
 	if ps.hitPattern(findObjectBatchRegexp, http.MethodPost) {
 		bizID, err := ps.parseBusinessID()
 		if err != nil && err != metadata.LabelKeyNotExistError {
@@ -1921,6 +2054,8 @@ func (ps *parseStream) instanceAudit() *parseStream {
 var (
 	findPrivilege = regexp.MustCompile(`^/api/v3/topo/privilege/.*$`)
 	postPrivilege = regexp.MustCompile(`^/api/v3/topo/privilege/.*$`)
+
+	deleteFieldPrivilege = regexp.MustCompile(`^/api/v3/topo/privilege/field/.*$`)
 )
 
 func (ps *parseStream) privilege() *parseStream {
@@ -1928,7 +2063,9 @@ func (ps *parseStream) privilege() *parseStream {
 		return ps
 	}
 
-	if ps.hitRegexp(findPrivilege, http.MethodGet) || ps.hitRegexp(findPrivilege, http.MethodPost) {
+	// the field privileges are only managed by the admins, which is checked by the topo server.
+	if ps.hitRegexp(findPrivilege, http.MethodGet) || ps.hitRegexp(findPrivilege, http.MethodPost) ||
+		ps.hitRegexp(deleteFieldPrivilege, http.MethodDelete) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
 			{
 				Basic: meta.Basic{
@@ -26,9 +26,14 @@ import (
 	cc "icenter/src/common/backbone/configcenter"
 	"icenter/src/common/backbone/service_mange/zk"
 	"icenter/src/common/blog"
+	crd "icenter/src/common/confregdiscover"
 	"icenter/src/common/errors"
 	"icenter/src/common/language"
+	"icenter/src/common/metric"
+	"icenter/src/common/registerdiscover"
 	"icenter/src/common/types"
+
+	"github.com/emicklei/go-restful"
 )
 
 // BackboneParameter Used to constrain different services to ensure
@@ -99,15 +104,16 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 	}
 
 	common.SetServerInfo(input.SrvInfo)
-	client, err := newSvcManagerClient(ctx, input.Regdiscv)
+	backend, err := newRegDiscv(ctx, input.Regdiscv)
 	if err != nil {
-		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", input.Regdiscv, err)
+		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", redactRegDiscv(input.Regdiscv), err)
 	}
-	serviceDiscovery, err := discovery.NewServiceDiscovery(client)
+	rd := registerdiscover.NewRegDiscoverWithServer(backend.services)
+	serviceDiscovery, err := discovery.NewServiceDiscoveryWithRegDiscover(rd)
 	if err != nil {
-		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", input.Regdiscv, err)
+		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", redactRegDiscv(input.Regdiscv), err)
 	}
-	disc, err := NewServiceRegister(client)
+	disc, err := NewServiceRegisterWithRegDiscover(rd)
 	if err != nil {
 		return nil, fmt.Errorf("new service discover failed, err:%v", err)
 	}
@@ -125,7 +131,8 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 	if err != nil {
 		return nil, fmt.Errorf("new engine failed, err: %v", err)
 	}
-	engine.client = client
+	engine.client = backend.zkClient
+	engine.confRegDiscv = backend.configs
 	engine.apiMachineryConfig = apiMachineryConfig
 	engine.discovery = serviceDiscovery
 	engine.ServiceManageInterface = serviceDiscovery
@@ -137,7 +144,7 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 		OnErrorUpdate:    engine.onErrorUpdate,
 	}
 
-	err = cc.NewConfigCenter(ctx, client, common.GetIdentification(), input.ConfigPath, handler)
+	err = cc.New(ctx, common.GetIdentification(), input.ConfigPath, backend.configs, handler)
 	if err != nil {
 		return nil, fmt.Errorf("new config center failed, err: %v", err)
 	}
@@ -146,6 +153,11 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 }
 
 func StartServer(ctx context.Context, e *Engine, HTTPHandler http.Handler) error {
+	// all the servers export the request metrics with /metrics
+	if container, ok := HTTPHandler.(*restful.Container); ok {
+		metric.InstrumentContainer(container)
+	}
+
 	e.server = Server{
 		ListenAddr: e.srvInfo.IP,
 		ListenPort: e.srvInfo.Port,
@@ -179,6 +191,7 @@ type Engine struct {
 	apiMachineryConfig *util.APIMachineryConfig
 
 	client                 *zk.ZkClient
+	confRegDiscv           crd.ConfRegDiscvIf
 	ServiceManageInterface discovery.ServiceManageInterface
 	SvcDisc                ServiceRegisterInterface
 	discovery              discovery.DiscoveryInterface
@@ -202,10 +215,16 @@ func (e *Engine) ApiMachineryConfig() *util.APIMachineryConfig {
 	return e.apiMachineryConfig
 }
 
+// ServiceManageClient returns the zookeeper client, it's nil if the regdiscv is not zookeeper.
 func (e *Engine) ServiceManageClient() *zk.ZkClient {
 	return e.client
 }
 
+// ConfRegDiscover returns the config center backend
+func (e *Engine) ConfRegDiscover() crd.ConfRegDiscvIf {
+	return e.confRegDiscv
+}
+
 func (e *Engine) onLanguageUpdate(previous, current map[string]language.LanguageMap) {
 	e.Lock()
 	defer e.Unlock()
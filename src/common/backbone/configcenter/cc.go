@@ -98,17 +98,20 @@ func (c *CC) run() error {
 		return err
 	}
 
+	// watch all the changes, so that the fixed error messages and languages are reloaded at once.
 	go func() {
-		select {
-		case pEvent := <-procEvent:
-			c.onProcChange(pEvent)
-		case eEvent := <-errEvent:
-			c.onErrorChange(eEvent)
-		case langEvent := <-langEvent:
-			c.onLanguageChange(langEvent)
-		case <-c.ctx.Done():
-			blog.Warnf("config center event watch stopped because of context done.")
-			return
+		for {
+			select {
+			case pEvent := <-procEvent:
+				c.onProcChange(pEvent)
+			case eEvent := <-errEvent:
+				c.onErrorChange(eEvent)
+			case langEvent := <-langEvent:
+				c.onLanguageChange(langEvent)
+			case <-c.ctx.Done():
+				blog.Warnf("config center event watch stopped because of context done.")
+				return
+			}
 		}
 	}()
 	return nil
@@ -29,8 +29,13 @@ type ServiceRegisterInterface interface {
 }
 
 func NewServiceRegister(client *zk.ZkClient) (ServiceRegisterInterface, error) {
+	return NewServiceRegisterWithRegDiscover(registerdiscover.NewRegDiscoverEx(client))
+}
+
+// NewServiceRegisterWithRegDiscover create the service register with the register and discover backend
+func NewServiceRegisterWithRegDiscover(disc *registerdiscover.RegDiscover) (ServiceRegisterInterface, error) {
 	s := new(serviceRegister)
-	s.client = registerdiscover.NewRegDiscoverEx(client)
+	s.client = disc
 	return s, nil
 }
 
@@ -0,0 +1,99 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package confregdiscover
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"icenter/src/common/blog"
+	"icenter/src/common/registry"
+)
+
+// HTTPRegDiscover config register and discover by the registry hosted by a service
+type HTTPRegDiscover struct {
+	client  *registry.Client
+	rootCtx context.Context
+}
+
+// NewHTTPRegDiscover create a object of HTTPRegDiscover, the address is like http://127.0.0.1:2390
+func NewHTTPRegDiscover(ctx context.Context, address string) *HTTPRegDiscover {
+	return &HTTPRegDiscover{
+		client:  registry.NewClient(address),
+		rootCtx: ctx,
+	}
+}
+
+// Ping to ping server
+func (h *HTTPRegDiscover) Ping() error {
+	return h.client.Ping()
+}
+
+// Write to save config data into the registry
+func (h *HTTPRegDiscover) Write(key string, data []byte) error {
+	return h.client.WriteConfig(h.rootCtx, key, string(data))
+}
+
+// Read the config data from the registry
+func (h *HTTPRegDiscover) Read(key string) (string, error) {
+	resp, err := h.client.ReadConfig(h.rootCtx, key, 0, 0)
+	if err != nil {
+		return "", err
+	}
+	if !resp.Exists {
+		return "", fmt.Errorf("config %s not exists", key)
+	}
+	return resp.Data, nil
+}
+
+// Discover the config change, an event is sent when the config is changed.
+func (h *HTTPRegDiscover) Discover(key string) (<-chan *DiscoverEvent, error) {
+	env := make(chan *DiscoverEvent, 1)
+	go h.loopDiscover(key, env)
+	return env, nil
+}
+
+func (h *HTTPRegDiscover) loopDiscover(key string, env chan *DiscoverEvent) {
+	var index uint64
+	var previous *string
+	for {
+		// wait for the change after the first fetch
+		wait := 30 * time.Second
+		if index == 0 {
+			wait = 0
+		}
+		resp, err := h.client.ReadConfig(h.rootCtx, key, index, wait)
+		if err != nil {
+			blog.Errorf("fail to watch config %s, err: %v, retry after 5s", key, err)
+			select {
+			case <-h.rootCtx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		index = resp.Index
+		// the index is changed by the other keys too
+		if !resp.Exists || (previous != nil && *previous == resp.Data) {
+			continue
+		}
+		previous = &resp.Data
+
+		select {
+		case env <- &DiscoverEvent{Key: key, Data: []byte(resp.Data)}:
+		case <-h.rootCtx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package confregdiscover
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MemRegDiscover config register and discover in the process, it's used when the services are discovered
+// from a static service map, and the configs are loaded from the local files.
+type MemRegDiscover struct {
+	rootCtx context.Context
+	lock    sync.RWMutex
+	configs map[string][]byte
+	// changed is closed and replaced when a config is written
+	changed chan struct{}
+}
+
+// NewMemRegDiscover create a object of MemRegDiscover
+func NewMemRegDiscover(ctx context.Context) *MemRegDiscover {
+	return &MemRegDiscover{
+		rootCtx: ctx,
+		configs: make(map[string][]byte),
+		changed: make(chan struct{}),
+	}
+}
+
+// Ping to ping server
+func (m *MemRegDiscover) Ping() error {
+	return nil
+}
+
+// Write to save config data in memory
+func (m *MemRegDiscover) Write(key string, data []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.configs[key] = append([]byte(nil), data...)
+	close(m.changed)
+	m.changed = make(chan struct{})
+	return nil
+}
+
+// Read the config data
+func (m *MemRegDiscover) Read(key string) (string, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	data, exists := m.configs[key]
+	if !exists {
+		return "", fmt.Errorf("config %s not exists", key)
+	}
+	return string(data), nil
+}
+
+// Discover the config change, an event is sent when the config is written.
+func (m *MemRegDiscover) Discover(key string) (<-chan *DiscoverEvent, error) {
+	env := make(chan *DiscoverEvent, 1)
+	go func() {
+		var previous []byte
+		for {
+			m.lock.RLock()
+			data, exists := m.configs[key]
+			changed := m.changed
+			m.lock.RUnlock()
+
+			// the other keys may be written
+			if exists && (previous == nil || !bytes.Equal(previous, data)) {
+				previous = data
+				select {
+				case env <- &DiscoverEvent{Key: key, Data: data}:
+				case <-m.rootCtx.Done():
+					return
+				}
+			}
+			select {
+			case <-changed:
+			case <-m.rootCtx.Done():
+				return
+			}
+		}
+	}()
+	return env, nil
+}
@@ -692,6 +692,30 @@ const (
 	// FieldTypeBool the bool type
 	FieldTypeBool string = "bool"
 
+	// FieldTypeIPv4 the ipv4 address field type
+	FieldTypeIPv4 string = "ipv4"
+
+	// FieldTypeIPv6 the ipv6 address field type
+	FieldTypeIPv6 string = "ipv6"
+
+	// FieldTypeCIDR the cidr network field type
+	FieldTypeCIDR string = "cidr"
+
+	// FieldTypeURL the url field type
+	FieldTypeURL string = "url"
+
+	// FieldTypeEmail the email field type
+	FieldTypeEmail string = "email"
+
+	// FieldTypeJSON the json field type
+	FieldTypeJSON string = "json"
+
+	// FieldTypeList the typed array field type
+	FieldTypeList string = "list"
+
+	// FieldTypeComputed the computed field type, whose value is evaluated from an expression
+	FieldTypeComputed string = "computed"
+
 	// FieldTypeSingleLenChar the single char length limit
 	FieldTypeSingleLenChar int = 256
 
@@ -1020,11 +1044,19 @@ const (
 	SynchronizeModelTypeBase = "model"
 	// SynchronizeModelTypeModelClassificationRelation synchroneize model classification relation
 	SynchronizeModelTypeModelClassificationRelation = "model_classification_relation"
+	// SynchronizeModelTypeAssociationKind synchronize association kind
+	SynchronizeModelTypeAssociationKind = "association_kind"
+	// SynchronizeModelTypeModelAssociation synchronize model association
+	SynchronizeModelTypeModelAssociation = "model_association"
+	// SynchronizeModelTypeModelUnique synchronize model unique
+	SynchronizeModelTypeModelUnique = "model_unique"
 
 	/* synchronize instance assoication sign*/
 
 	// SynchronizeAssociationTypeModelHost synchroneize model ggroup
 	SynchronizeAssociationTypeModelHost = "module_host"
+	// SynchronizeAssociationTypeInstAsst synchronize instance association
+	SynchronizeAssociationTypeInstAsst = "inst_association"
 )
 
 const (
@@ -12,6 +12,8 @@
 
 package common
 
+//go:generate go run gen_errcodes.go
+
 // CC error number defined in this file
 // Errno name is composed of the following format CCErr[XXX]
 const (
@@ -165,6 +167,19 @@ const (
 	CCErrCommGetMultipleObject      = 1199052
 	CCErrCommAuthCenterIsNotEnabled = 1199053
 
+	// CCErrCommParamsNeedIP the parameter must be ip address
+	CCErrCommParamsNeedIP = 1199054
+	// CCErrCommParamsNeedCIDR the parameter must be cidr network
+	CCErrCommParamsNeedCIDR = 1199055
+	// CCErrCommParamsNeedURL the parameter must be url
+	CCErrCommParamsNeedURL = 1199056
+	// CCErrCommParamsNeedEmail the parameter must be email address
+	CCErrCommParamsNeedEmail = 1199057
+	// CCErrCommParamsNeedJSON the parameter must be json object or array
+	CCErrCommParamsNeedJSON = 1199058
+	// CCErrCommParamsNeedList the parameter must be array
+	CCErrCommParamsNeedList = 1199059
+
 	// CCErrCommInternalServerError %s Internal Server Error
 	CCErrCommInternalServerError = 1199999
 
@@ -385,6 +400,10 @@ const (
 	// mainline's object unique can not be updated, deleted or create new rules.
 	CCErrorTopoMainlineObjectCanNotBeChanged   = 1101085
 	CCErrorTopoGetAuthorizedBusinessListFailed = 1101086
+	// CCErrorTopoFieldNoReadPermission the user can not read the sensitive property
+	CCErrorTopoFieldNoReadPermission = 1101087
+	// CCErrorTopoFieldNoWritePermission the user can not write the sensitive property
+	CCErrorTopoFieldNoWritePermission = 1101088
 
 	// objectcontroller 1102XXX
 
@@ -634,6 +653,12 @@ const (
 	CCErrCoreServiceTransferHostModuleErr = 1113009
 	// CCErrCoreServiceEventPushEventFailed failed to sent event
 	CCErrCoreServiceEventPushEventFailed = 1113010
+	// CCErrCoreServiceAuditLogNotRollbackable audit log [%v] can not be rolled back
+	CCErrCoreServiceAuditLogNotRollbackable = 1113011
+	// CCErrCoreServiceRollbackConflict instance [%s:%d] has been changed after audit log [%d]
+	CCErrCoreServiceRollbackConflict = 1113012
+	// CCErrCoreServiceInstanceAlreadyExist instance [%s:%d] already exists
+	CCErrCoreServiceInstanceAlreadyExist = 1113013
 
 	// synchronize data coreservice  11139xx
 	CCErrCoreServiceSyncError = 1113900
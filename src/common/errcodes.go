@@ -0,0 +1,388 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Code generated by gen_errcodes.go from errInfo.go; DO NOT EDIT.
+
+package common
+
+// CCErrorCodes the CCErr* codes defined in errInfo.go, the key is the name of the code.
+// the error packs are validated with them, run go generate after the codes are changed.
+var CCErrorCodes = map[string]int{
+	"CCErrTopoAppDeleteFailed":                                  1001031,
+	"CCErrTopoAppUpdateFailed":                                  1001032,
+	"CCErrTopoAppSearchFailed":                                  1001033,
+	"CCErrTopoAppCreateFailed":                                  1001034,
+	"CCErrTopoForbiddenToDeleteModelFailed":                     1001035,
+	"CCErrTopoMainlineCreatFailed":                              1001037,
+	"CCErrTopoMainlineDeleteFailed":                             1001038,
+	"CCErrTopoMainlineSelectFailed":                             1001039,
+	"CCErrTopoTopoSelectFailed":                                 1001040,
+	"CCErrTopoUserGroupCreateFailed":                            1001041,
+	"CCErrTopoUserGroupDeleteFailed":                            1001042,
+	"CCErrTopoUserGroupUpdateFailed":                            1001043,
+	"CCErrTopoUserGroupSelectFailed":                            1001044,
+	"CCErrTopoUserGroupPrivilegeUpdateFailed":                   1001045,
+	"CCErrTopoUserGroupPrivilegeSelectFailed":                   1001046,
+	"CCErrTopoUserPrivilegeSelectFailed":                        1001047,
+	"CCErrTopoRolePrivilegeCreateFailed":                        1001048,
+	"CCErrTopoDeleteMainLineObjectAndInstNameRepeat":            1001049,
+	"CCErrHostNotAllowedToMutiBiz":                              1001050,
+	"CCErrTopoGraphicsSearchFailed":                             1001051,
+	"CCErrTopoGraphicsUpdateFailed":                             1001052,
+	"CCErrTopoObjectUniqueCreateFailed":                         1001060,
+	"CCErrTopoObjectUniqueUpdateFailed":                         1001061,
+	"CCErrTopoObjectUniqueDeleteFailed":                         1001062,
+	"CCErrTopoObjectUniqueSearchFailed":                         1001063,
+	"CCErrTopoObjectPropertyNotFound":                           1001064,
+	"CCErrTopoObjectPropertyUsedByUnique":                       1001065,
+	"CCErrTopoObjectUniqueKeyKindInvalid":                       1001066,
+	"CCErrTopoObjectUniquePresetCouldNotDelOrEdit":              1001067,
+	"CCErrTopoObjectUniqueCanNotHasMutiMustCheck":               1001068,
+	"CCErrTopoObjectUniqueShouldHaveMoreThanOne":                1001069,
+	"CCErrAPIGetAuthorizedAppListFromAuthFailed":                1100001,
+	"CCErrAPIGetUserResourceAuthStatusFailed":                   1100002,
+	"CCErrAPINoObjectInstancesIsFound":                          1100003,
+	"CCErrTopoInstCreateFailed":                                 1101000,
+	"CCErrTopoInstDeleteFailed":                                 1101001,
+	"CCErrTopoInstUpdateFailed":                                 1101002,
+	"CCErrTopoInstSelectFailed":                                 1101003,
+	"CCErrTopoModuleCreateFailed":                               1101004,
+	"CCErrTopoModuleDeleteFailed":                               1101005,
+	"CCErrTopoModuleUpdateFailed":                               1101006,
+	"CCErrTopoModuleSelectFailed":                               1101007,
+	"CCErrTopoSetCreateFailed":                                  1101008,
+	"CCErrTopoSetDeleteFailed":                                  1101009,
+	"CCErrTopoSetUpdateFailed":                                  1101010,
+	"CCErrTopoSetSelectFailed":                                  1101011,
+	"CCErrTopoInstHasHostChild":                                 1101012,
+	"CCErrTopoObjectCreateFailed":                               1101013,
+	"CCErrTopoObjectDeleteFailed":                               1101014,
+	"CCErrTopoObjectUpdateFailed":                               1101015,
+	"CCErrTopoObjectSelectFailed":                               1101016,
+	"CCErrTopoObjectAttributeCreateFailed":                      1101017,
+	"CCErrTopoObjectAttributeDeleteFailed":                      1101018,
+	"CCErrTopoObjectAttributeUpdateFailed":                      1101019,
+	"CCErrTopoObjectAttributeSelectFailed":                      1101020,
+	"CCErrTopoObjectClassificationCreateFailed":                 1101021,
+	"CCErrTopoObjectClassificationDeleteFailed":                 1101022,
+	"CCErrTopoObjectClassificationUpdateFailed":                 1101023,
+	"CCErrTopoObjectClassificationSelectFailed":                 1101024,
+	"CCErrTopoObjectGroupCreateFailed":                          1101025,
+	"CCErrTopoObjectGroupDeleteFailed":                          1101026,
+	"CCErrTopoObjectGroupUpdateFailed":                          1101027,
+	"CCErrTopoObjectGroupSelectFailed":                          1101028,
+	"CCErrTopoObjectClassificationHasObject":                    1101029,
+	"CCErrTopoHasHost":                                          1101030,
+	"CCErrTopoHasHostCheckFailed":                               1101030,
+	"CCErrTopoGetCloudErrStrFaild":                              1101031,
+	"CCErrTopoCloudNotFound":                                    1101032,
+	"CCErrTopoGetAppFailed":                                     1101033,
+	"CCErrTopoGetModuleFailed":                                  1101034,
+	"CCErrTopoBizTopoLevelOverLimit":                            1101035,
+	"CCErrTopoInstHasBeenAssociation":                           1101036,
+	"CCErrTopoObjectHasSomeInstsForbiddenToDelete":              1101037,
+	"CCErrTopoAssociationAlreadyExist":                          1101038,
+	"CCErrTopoAssociationSourceObjectNotExist":                  1101039,
+	"CCErrTopoAssociationDestinationObjectNotExist":             1101040,
+	"CCErrTopoInvalidObjectAssociationID":                       1101041,
+	"CCErrTopoGotMultipleAssociationInstance":                   1101042,
+	"CCErrTopoAssociationHasAlreadyBeenInstantiated":            1101043,
+	"CCErrTopoGetAssociationKindFailed":                         1101044,
+	"CCErrorTopoAssociationMissingParameters":                   1101045,
+	"CCErrorTopoObjectAssociationNotExist":                      1101046,
+	"CCErrorTopoObjectAssociationUpdateForbiddenFields":         1101047,
+	"CCErrorTopoMainlineObjectAssociationNotExist":              1101048,
+	"CCErrorTopoImportAssociation":                              1101049,
+	"CCErrorTopoGetMultipleAssoKindInstWithOneID":               1101050,
+	"CCErrorTopoDeletePredefinedAssociationKind":                1101051,
+	"CCErrorTopoCreateMultipleInstancesForOneToOneAssociation":  1101052,
+	"CCErrorTopoObjectHasAlreadyAssociated":                     1101053,
+	"CCErrorTopoUpdatePredefinedAssociation":                    1101054,
+	"CCErrorTopoDeletePredefinedAssociation":                    1101055,
+	"CCErrorTopoAssociationDoNotExist":                          1101056,
+	"CCErrorTopoObjectInstanceMissingInstanceNameField":         1101057,
+	"CCErrorTopoInvalidObjectInstanceNameFieldValue":            1101058,
+	"CCErrorTopoMutipleObjectInstanceName":                      1101059,
+	"CCErrorTopoAssociationKindHasBeenUsed":                     1101060,
+	"CCErrorTopoCreateMultipleInstancesForOneToManyAssociation": 1101061,
+	"CCErrorTopoAssKindHasApplyToObject":                        1101070,
+	"CCErrorTopoPreAssKindCanNotBeDelete":                       1101071,
+	"CCErrorTopoAsstKindIsNotExist":                             1101072,
+	"CCErrorAsstInstIsNotExist":                                 1101073,
+	"CCErrorInstToAsstIsNotExist":                               1101074,
+	"CCErrorInstHasAsst":                                        1101075,
+	"CCErrTopoCreateAssoKindFailed":                             1101076,
+	"CCErrTopoUpdateAssoKindFailed":                             1101077,
+	"CCErrTopoDeleteAssoKindFailed":                             1101078,
+	"CCErrTopoMulueIDNotfoundFailed":                            1101080,
+	"CCErrTopoBkAppNotAllowedDelete":                            1101081,
+	"CCErrorTopoAssociationKindMainlineUnavailable":             1101082,
+	"CCErrorTopoAssociationKindInconsistent":                    1101083,
+	"CCErrorTopoModleStopped":                                   1101084,
+	"CCErrorTopoMainlineObjectCanNotBeChanged":                  1101085,
+	"CCErrorTopoGetAuthorizedBusinessListFailed":                1101086,
+	"CCErrorTopoFieldNoReadPermission":                          1101087,
+	"CCErrorTopoFieldNoWritePermission":                         1101088,
+	"CCErrObjectPropertyGroupInsertFailed":                      1102000,
+	"CCErrObjectPropertyGroupDeleteFailed":                      1102001,
+	"CCErrObjectPropertyGroupSelectFailed":                      1102002,
+	"CCErrObjectPropertyGroupUpdateFailed":                      1102003,
+	"CCErrObjectCreateInstFailed":                               1102004,
+	"CCErrObjectDBOpErrno":                                      1102004,
+	"CCErrObjectUpdateInstFailed":                               1102005,
+	"CCErrObjectDeleteInstFailed":                               1102006,
+	"CCErrObjectSelectInstFailed":                               1102007,
+	"CCErrObjectSelectIdentifierFailed":                         1102008,
+	"CCErrEventSubscribeInsertFailed":                           1103000,
+	"CCErrEventSubscribeDeleteFailed":                           1103001,
+	"CCErrEventSubscribeSelectFailed":                           1103002,
+	"CCErrEventSubscribeUpdateFailed":                           1103003,
+	"CCErrEventSubscribePingFailed":                             1103004,
+	"CCErrEventSubscribeTelnetFailed":                           1103005,
+	"CCErrEventPushEventFailed":                                 1103006,
+	"CCErrHostModuleRelationAddFailed":                          1104000,
+	"CCErrCommMigrateFailed":                                    1105000,
+	"CCErrCommInitAuthcenterFailed":                             1105001,
+	"CCErrHostSelectInst":                                       1106000,
+	"CCErrHostCreateInst":                                       1106002,
+	"CCErrHostGetSnapshot":                                      1106003,
+	"CCErrHostTransferModule":                                   1106004,
+	"CCErrDelDefaultModuleHostConfig":                           1106005,
+	"CCErrGetModule":                                            1106006,
+	"CCErrDelOriginHostModuelRelationship":                      1106007,
+	"CCErrGetOriginHostModuelRelationship":                      1106008,
+	"CCErrTransferHostFromPool":                                 1106009,
+	"CCErrAlreadyAssign":                                        1106010,
+	"CCErrNotBelongToIdleModule":                                1106011,
+	"CCErrTransfer2ResourcePool":                                1106012,
+	"CCErrCreateUserCustom":                                     1106013,
+	"CCErrHostFavouriteQueryFail":                               1106014,
+	"CCErrHostFavouriteCreateFail":                              1106015,
+	"CCErrHostFavouriteUpdateFail":                              1106016,
+	"CCErrHostFavouriteDeleteFail":                              1106017,
+	"CCErrHostFavouriteDupFail":                                 1106018,
+	"CCErrHostGetSnapshotChannelEmpty":                          1106019,
+	"CCErrHostGetSnapshotChannelClose":                          1106020,
+	"CCErrCloudCreateSyncTaskFail":                              1106021,
+	"CCErrCloudConfirmHistoryAddFail":                           1106022,
+	"CCErrCloudSyncHistorySearchFail":                           1106023,
+	"CCErrProcDeleteProc2Module":                                1107001,
+	"CCErrProcCreateProc2Module":                                1107002,
+	"CCErrProcSelectProc2Module":                                1107003,
+	"CCErrProcCreateProcConf":                                   1107004,
+	"CCErrProcDeleteProcConf":                                   1107005,
+	"CCErrProcGetProcConf":                                      1107006,
+	"CCErrProcUpdateProcConf":                                   1107007,
+	"CCErrProcCreateInstanceModel":                              1107008,
+	"CCErrProcGetInstanceModel":                                 1107009,
+	"CCErrProcDeleteInstanceModel":                              1107010,
+	"CCErrProcDeleteProc2Template":                              1107011,
+	"CCErrProcCreateProc2Template":                              1107012,
+	"CCErrProcSelectProc2Template":                              1107013,
+	"CCErrProcSearchDetailFaile":                                1108001,
+	"CCErrProcBindToMoudleFaile":                                1108002,
+	"CCErrProcUnBindToMoudleFaile":                              1108003,
+	"CCErrProcSelectBindToMoudleFaile":                          1108004,
+	"CCErrProcUpdateProcessFaile":                               1108005,
+	"CCErrProcSearchProcessFaile":                               1108006,
+	"CCErrProcDeleteProcessFaile":                               1108007,
+	"CCErrProcCreateProcessFaile":                               1108008,
+	"CCErrProcFieldValidFaile":                                  1108009,
+	"CCErrProcGetByApplicationIDFail":                           1108010,
+	"CCErrProcGetByIP":                                          1108011,
+	"CCErrProcOperateFaile":                                     1108012,
+	"CCErrProcBindWithModule":                                   1108013,
+	"CCErrProcDeleteTemplateFail":                               1108014,
+	"CCErrProcUpdateTemplateFail":                               1108015,
+	"CCErrProcSearchTemplateFail":                               1108016,
+	"CCErrProcBindToTemplateFailed":                             1108017,
+	"CCErrProcUnBindToTemplateFailed":                           1108018,
+	"CCErrProcSelectBindToTemplateFailed":                       1108019,
+	"CCErrProcQueryTaskInfoFail":                                1108020,
+	"CCErrProcQueryTaskWaitOPFail":                              1108021,
+	"CCErrProcQueryTaskOPErrFail":                               1108022,
+	"CCErrProcCreateTemplateFail":                               1108023,
+	"CCErrAuditSaveLogFaile":                                    1109001,
+	"CCErrAuditTakeSnapshotFaile":                               1109001,
+	"CCErrHostGetFail":                                          1110001,
+	"CCErrHostUpdateFail":                                       1110002,
+	"CCErrHostUpdateFieldFail":                                  1110003,
+	"CCErrHostCreateFail":                                       1110004,
+	"CCErrHostModifyFail":                                       1110005,
+	"CCErrHostDeleteFail":                                       1110006,
+	"CCErrHostFiledValdFail":                                    1110007,
+	"CCErrHostNotFound":                                         1110008,
+	"CCErrHostLength":                                           1110009,
+	"CCErrHostDetailFail":                                       1110010,
+	"CCErrHostSnap":                                             1110011,
+	"CCErrHostFeildValidFail":                                   1110012,
+	"CCErrHostFavCreateFail":                                    1110013,
+	"CCErrHostEmptyFavName":                                     1110014,
+	"CCErrHostFavUpdateFail":                                    1110015,
+	"CCErrHostFavDeleteFail":                                    1110016,
+	"CCErrHostFavGetFail":                                       1110017,
+	"CCErrHostHisCreateFail":                                    1110018,
+	"CCErrHostHisGetFail":                                       1110019,
+	"CCErrHostCustomCreateFail":                                 1110020,
+	"CCErrHostCustomGetFail":                                    1110021,
+	"CCErrHostCustomGetDefaultFail":                             1110022,
+	"CCErrHostNotINAPP":                                         1110023,
+	"CCErrHostNotINAPPFail":                                     1110024,
+	"CCErrHostDELResourcePool":                                  1110025,
+	"CCErrHostAddRelationFail":                                  1110026,
+	"CCErrHostMoveResourcePoolFail":                             1110027,
+	"CCErrHostEditRelationPoolFail":                             1110028,
+	"CCErrAddHostToModule":                                      1110029,
+	"CCErrAddHostToModuleFailStr":                               1110030,
+	"CCErrCloudSyncCreateFail":                                  1110031,
+	"CCErrCloudHistoryCreateFail":                               1110032,
+	"CCErrCloudConfirmCreateFail":                               1110033,
+	"CCErrCloudGetConfirmFail":                                  1110034,
+	"CCErrCloudAddConfirmHistoryFail":                           1110035,
+	"CCErrCloudGetTaskFail":                                     1110036,
+	"CCErrCloudGetConfirmHistoryFail":                           1110037,
+	"CCErrCloudTaskNameAlreadyExist":                            1110038,
+	"CCErrCloudSyncStartFail":                                   1110039,
+	"CCErrAddUserCustomQueryFaild":                              1110040,
+	"CCErrUpdateUserCustomQueryFaild":                           1110041,
+	"CCErrDeleteUserCustomQueryFaild":                           1110042,
+	"CCErrSearchUserCustomQueryFaild":                           1110043,
+	"CCErrGetUserCustomQueryDetailFaild":                        1110044,
+	"CCErrHostModuleConfigFaild":                                1110045,
+	"CCErrHostGetSetFaild":                                      1110046,
+	"CCErrHostGetAPPFail":                                       1110047,
+	"CCErrHostAPPNotFoundFail":                                  1110048,
+	"CCErrHostGetModuleFail":                                    1110049,
+	"CCErrHostAgentStatusFail":                                  1110050,
+	"CCErrHostNotResourceFail":                                  1110051,
+	"CCErrHostBelongResourceFail":                               1110052,
+	"CCErrHostGetResourceFail":                                  1110053,
+	"CCErrHostModuleNotExist":                                   1110054,
+	"CCErrDeleteHostFromBusiness":                               1110055,
+	"CCErrHostNotBelongIDLEModuleErr":                           1110056,
+	"CCErrHostMulueIDNotFoundORHasMutliInnerModuleIDFailed":     1110057,
+	"CCErrHostSearchNeedObjectInstIDErr":                        1110058,
+	"CCErrWebFileNoFound":                                       1111001,
+	"CCErrWebFileSaveFail":                                      1111002,
+	"CCErrWebOpenFileFail":                                      1111003,
+	"CCErrWebFileContentEmpty":                                  1111004,
+	"CCErrWebFileContentFail":                                   1111005,
+	"CCErrWebGetHostFail":                                       1111006,
+	"CCErrWebCreateEXCELFail":                                   1111007,
+	"CCErrWebGetObjectFail":                                     1111008,
+	"CCErrWebGetAddNetDeviceResultFail":                         1111009,
+	"CCErrWebGetAddNetPropertyResultFail":                       1111010,
+	"CCErrWebGetNetDeviceFail":                                  1111011,
+	"CCErrWebGetNetPropertyFail":                                1111012,
+	"CCErrCollectNetDeviceCreateFail":                           1112000,
+	"CCErrCollectNetDeviceGetFail":                              1112001,
+	"CCErrCollectNetDeviceDeleteFail":                           1112002,
+	"CCErrCollectObjIDNotNetDevice":                             1112003,
+	"CCErrCollectNetPropertyCreateFail":                         1112004,
+	"CCErrCollectNetPropertyGetFail":                            1112005,
+	"CCErrCollectNetPropertyDeleteFail":                         1112006,
+	"CCErrCollectNetDeviceObjPropertyNotExist":                  1112007,
+	"CCErrCollectDeviceNotExist":                                1112008,
+	"CCErrCollectPeriodFormatFail":                              1112009,
+	"CCErrCollectNetDeviceHasPropertyDeleteFail":                1112010,
+	"CCErrCollectNetCollectorSearchFail":                        1112011,
+	"CCErrCollectNetCollectorUpdateFail":                        1112012,
+	"CCErrCollectNetCollectorDiscoverFail":                      1112013,
+	"CCErrCollectNetReportSearchFail":                           1112014,
+	"CCErrCollectNetReportConfirmFail":                          1112015,
+	"CCErrCollectNetHistorySearchFail":                          1112016,
+	"CCErrCollectNetDeviceUpdateFail":                           1112017,
+	"CCErrCollectNetPropertyUpdateFail":                         1112018,
+	"CCErrCoreServiceModelAttributeGroupHasSomeAttributes":      1113001,
+	"CCErrCoreServiceHostNotBelongBusiness":                     1113002,
+	"CCErrCoreServiceHostNotExist":                              1113003,
+	"CCErrCoreServiceHasModuleNotBelongBusiness":                1113004,
+	"CCErrCoreServiceModuleContainDefaultModuleErr":             1113005,
+	"CCErrCoreServiceBusinessNotExist":                          1113006,
+	"CCErrCoreServiceDefaultModuleNotExist":                     1113007,
+	"CCErrCoreServiceModuleNotDefaultModuleErr":                 1113008,
+	"CCErrCoreServiceTransferHostModuleErr":                     1113009,
+	"CCErrCoreServiceEventPushEventFailed":                      1113010,
+	"CCErrCoreServiceAuditLogNotRollbackable":                   1113011,
+	"CCErrCoreServiceRollbackConflict":                          1113012,
+	"CCErrCoreServiceInstanceAlreadyExist":                      1113013,
+	"CCErrCoreServiceSyncError":                                 1113900,
+	"CCErrCoreServiceSyncDataClassifyNotExistError":             1113901,
+	"CCErrSynchronizeError":                                     1114001,
+	"CCErrAPIServerV2APPNameLenErr":                             1170001,
+	"CCErrAPIServerV2DirectErr":                                 1170002,
+	"CCErrAPIServerV2SetNameLenErr":                             1170003,
+	"CCErrAPIServerV2MultiModuleIDErr":                          1170004,
+	"CCErrAPIServerV2MultiSetIDErr":                             1170005,
+	"CCErrAPIServerV2OSTypeErr":                                 1170006,
+	"CCErrAPIServerV2HostModuleContainDefaultModuleErr":         1170007,
+	"CCErrCommJSONUnmarshalFailed":                              1199000,
+	"CCErrCommJSONMarshalFailed":                                1199001,
+	"CCErrCommHTTPDoRequestFailed":                              1199002,
+	"CCErrCommHTTPInputInvalid":                                 1199003,
+	"CCErrCommHTTPReadBodyFailed":                               1199004,
+	"CCErrCommHTTPBodyEmpty":                                    1199005,
+	"CCErrCommParamsInvalid":                                    1199006,
+	"CCErrCommParamsNeedString":                                 1199007,
+	"CCErrCommParamsLostField":                                  1199008,
+	"CCErrCommParamsNeedInt":                                    1199009,
+	"CCErrCommParamsNeedSet":                                    1199010,
+	"CCErrCommParamsIsInvalid":                                  1199011,
+	"CCErrCommParseDataFailed":                                  1199013,
+	"CCErrCommDuplicateItem":                                    1199014,
+	"CCErrCommOverLimit":                                        1199015,
+	"CCErrFieldRegValidFailed":                                  1199016,
+	"CCErrCommDBSelectFailed":                                   1199017,
+	"CCErrCommDBInsertFailed":                                   1199018,
+	"CCErrCommNotFound":                                         1199019,
+	"CCErrCommDBUpdateFailed":                                   1199020,
+	"CCErrCommDBDeleteFailed":                                   1199021,
+	"CCErrCommRelyOnServerAddressFailed":                        1199022,
+	"CCErrCommExcelTemplateFailed":                              1199023,
+	"CCErrCommParamsNeedTimeZone":                               1199024,
+	"CCErrCommParamsNeedBool":                                   1199025,
+	"CCErrCommConfMissItem":                                     1199026,
+	"CCErrCommNotAuthItem":                                      1199027,
+	"CCErrCommFieldNotValid":                                    1199028,
+	"CCErrCommReplyDataFormatError":                             1199029,
+	"CCErrCommPostInputParseError":                              1199030,
+	"CCErrCommResourceInitFailed":                               1199031,
+	"CCErrCommParamsShouldBeString":                             1199032,
+	"CCErrCommSearchPropertyFailed":                             1199033,
+	"CCErrCommParamsShouldBeEnum":                               1199034,
+	"CCErrCommXXExceedLimit":                                    1199035,
+	"CCErrProxyRequestFailed":                                   1199036,
+	"CCErrRewriteRequestUriFailed":                              1199037,
+	"CCErrCommInstDataNil":                                      1199038,
+	"CCErrCommInstFieldNotFound":                                1199039,
+	"CCErrCommInstFieldConvFail":                                1199040,
+	"CCErrCommUtilHandleFail":                                   1199041,
+	"CCErrCommParamsNeedFloat":                                  1199042,
+	"CCErrCommFieldNotValidFail":                                1199043,
+	"CCErrCommNotAllSuccess":                                    1199044,
+	"CCErrCommParseAuthAttributeFailed":                         1199045,
+	"CCErrCommCheckAuthorizeFailed":                             1199046,
+	"CCErrCommAuthNotHavePermission":                            1199047,
+	"CCErrCommAuthorizeFailed":                                  1199048,
+	"CCErrCommRegistResourceToIAMFailed":                        1199049,
+	"CCErrCommUnRegistResourceToIAMFailed":                      1199050,
+	"CCErrCommInappropriateVisitToIAM":                          1199051,
+	"CCErrCommGetMultipleObject":                                1199052,
+	"CCErrCommAuthCenterIsNotEnabled":                           1199053,
+	"CCErrCommParamsNeedIP":                                     1199054,
+	"CCErrCommParamsNeedCIDR":                                   1199055,
+	"CCErrCommParamsNeedURL":                                    1199056,
+	"CCErrCommParamsNeedEmail":                                  1199057,
+	"CCErrCommParamsNeedJSON":                                   1199058,
+	"CCErrCommParamsNeedList":                                   1199059,
+	"CCErrCommInternalServerError":                              1199999,
+}
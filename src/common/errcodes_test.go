@@ -0,0 +1,60 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestCCErrorCodes make sure errcodes.go is generated again after the codes in errInfo.go are changed
+func TestCCErrorCodes(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "errInfo.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			for index, name := range value.Names {
+				if !strings.HasPrefix(name.Name, "CCErr") || index >= len(value.Values) {
+					continue
+				}
+				lit, ok := value.Values[index].(*ast.BasicLit)
+				if !ok || lit.Kind != token.INT {
+					continue
+				}
+				code, _ := strconv.Atoi(lit.Value)
+				if CCErrorCodes[name.Name] != code {
+					t.Errorf("%s is %d in errInfo.go, but %d in errcodes.go, run go generate", name.Name, code, CCErrorCodes[name.Name])
+				}
+				count++
+			}
+		}
+	}
+	if count != len(CCErrorCodes) {
+		t.Errorf("errInfo.go has %d codes, but errcodes.go has %d, run go generate", count, len(CCErrorCodes))
+	}
+	if CCErrorCodes["CCErrCommParamsIsInvalid"] != CCErrCommParamsIsInvalid {
+		t.Errorf("unexpected code of CCErrCommParamsIsInvalid %d", CCErrorCodes["CCErrCommParamsIsInvalid"])
+	}
+}
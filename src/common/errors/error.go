@@ -20,12 +20,15 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
 	"icenter/src/common/blog"
 )
 
 // ccErrorHelper CC 错误处理接口的实现
 type ccErrorHelper struct {
+	// lock the error packages are replaced when they're reloaded from the config center
+	lock    sync.RWMutex
 	errCode map[string]ErrorCode // the key is a language code, for：en,cn,jp,us etc.
 }
 
@@ -71,7 +74,9 @@ func (cli *ccErrorHelper) CCErrorf(language string, ErrorCode int, args ...inter
 // load load language package file from dir
 func (cli *ccErrorHelper) Load(errcode map[string]ErrorCode) {
 	// blog.V(3).Infof("loaded error resource: %#v", errcode)
+	cli.lock.Lock()
 	cli.errCode = errcode
+	cli.lock.Unlock()
 }
 
 func LoadErrorResourceFromDir(dir string) (map[string]ErrorCode, error) {
@@ -130,15 +135,18 @@ func LoadErrorResourceFromDir(dir string) (map[string]ErrorCode, error) {
 }
 
 func (cli *ccErrorHelper) GetErrorCode() map[string]ErrorCode {
+	cli.lock.RLock()
+	defer cli.lock.RUnlock()
 	return cli.errCode
 }
 
 // getErrorCode get error code manager
 func (cli *ccErrorHelper) getErrorCode(language string) ErrorCode {
-	codemgr, ok := cli.errCode[language]
+	errCode := cli.GetErrorCode()
+	codemgr, ok := errCode[language]
 	if !ok && language != defaultLanguage {
 		// when the specified language not found, find it from default language package
-		codemgr, ok = cli.errCode[defaultLanguage]
+		codemgr, ok = errCode[defaultLanguage]
 		if !ok {
 			return nil
 		}
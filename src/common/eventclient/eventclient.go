@@ -34,6 +34,13 @@ type Client interface {
 	Push(context.Context, ...*metadata.EventInst) error
 }
 
+// StreamClient is the client which saves the events in the event log before they are delivered
+type StreamClient interface {
+	Client
+	Stream() *EventStream
+	QueueDepth() int
+}
+
 func NewEventWithHeader(header http.Header) *metadata.EventInst {
 	return &metadata.EventInst{
 		OwnerID:     util.GetOwnerID(header),
@@ -47,79 +54,163 @@ func NewEventWithHeader(header http.Header) *metadata.EventInst {
 type ClientViaRedis struct {
 	rdb       dal.RDB
 	cache     *redis.Client
+	stream    *EventStream
 	queue     chan *eventtmp
 	pending   *eventtmp
 	queueLock sync.Mutex
+	// marks the events pushed to redis which are not marked in the event log yet, the value is the time
+	// until which the event is marked again, because the row of a transactional event is not visible until commit.
+	marks    map[int64]time.Time
+	markLock sync.Mutex
 }
 
+const (
+	// replayInterval the interval to replay the events which are not pushed to redis
+	replayInterval = 30 * time.Second
+	// replayDelay the events saved within the delay are still in the queue of some process,
+	// so only the events saved before it are replayed.
+	replayDelay = time.Minute
+	// replayBatch the max events count replayed in one batch
+	replayBatch = 500
+	// markInterval the interval to mark the pushed events in the event log in batches
+	markInterval = 3 * time.Second
+	// txnMarkWindow the transactional events are marked again within the window after they are pushed,
+	// their rows become visible when the transaction commits, it's longer than the transaction lifetime.
+	txnMarkWindow = 5 * time.Minute
+	// txnReplayDelay only the transactional events saved before it are replayed, they are marked by then
+	// if they are pushed already.
+	txnReplayDelay = txnMarkWindow + time.Minute
+)
+
 func NewClientViaRedis(cache *redis.Client, rdb dal.RDB) *ClientViaRedis {
 	// we limit the queue size to 4k*2500=10M， assume that 4k per event
 	const queuesize = 2500
 
 	ec := &ClientViaRedis{
-		rdb:   rdb,
-		cache: cache,
-		queue: make(chan *eventtmp, queuesize),
+		rdb:    rdb,
+		cache:  cache,
+		stream: NewEventStream(rdb),
+		queue:  make(chan *eventtmp, queuesize),
+		marks:  make(map[int64]time.Time),
 	}
 	go ec.runPusher()
+	go ec.runMarker()
 	return ec
 }
 
-func (c *ClientViaRedis) Push(ctx context.Context, events ...*metadata.EventInst) error {
+// Stream returns the durable event log that the events are saved in
+func (c *ClientViaRedis) Stream() *EventStream {
+	return c.stream
+}
+
+// QueueDepth returns the count of the events waiting to be pushed to redis
+func (c *ClientViaRedis) QueueDepth() int {
 	c.queueLock.Lock()
-	for i := range events {
-		if events[i] != nil {
-			var allEqual = true
-			for _, data := range events[i].Data {
-				equal, err := instEqual(data)
-				if err != nil {
-					return fmt.Errorf("[event] compare failed: %v, source data is %#v", err, data)
-				}
-				if !equal {
-					allEqual = false
-					break
-				}
-			}
+	defer c.queueLock.Unlock()
+	depth := len(c.queue)
+	if c.pending != nil {
+		depth++
+	}
+	return depth
+}
 
-			if allEqual {
-				continue
-			}
+// Push save the events to the event log, and then push them to redis asynchronously.
+// the events which are not pushed because of the full queue or process restart are
+// replayed from the event log, so they are delivered at least once.
+func (c *ClientViaRedis) Push(ctx context.Context, events ...*metadata.EventInst) error {
+	ets, err := buildEvents(ctx, c.rdb, events)
+	if err != nil {
+		return err
+	}
 
-			eventID, err := c.rdb.NextSequence(ctx, common.EventCacheEventIDKey)
-			if err != nil {
-				c.queueLock.Unlock()
-				return fmt.Errorf("[event] generate eventID failed: %v", err)
-			}
-			events[i].ID = int64(eventID)
+	if err := c.stream.append(ctx, ets...); err != nil {
+		return fmt.Errorf("[event] save events to event log failed: %v", err)
+	}
+
+	c.queueLock.Lock()
+	defer c.queueLock.Unlock()
+	for _, et := range ets {
+		select {
+		case c.queue <- et:
+		default:
+			// channel fulled, the event is saved in the event log already, it will be replayed later.
+			blog.Warnf("[event] event queue is full, event %d will be replayed from event log", et.ID)
+		}
+	}
+	return nil
+}
+
+// buildEvents assign the event ids and marshal the events, the events which change nothing are skipped.
+func buildEvents(ctx context.Context, rdb dal.RDB, events []*metadata.EventInst) ([]*eventtmp, error) {
+	ets := make([]*eventtmp, 0, len(events))
+	for i := range events {
+		if events[i] == nil {
+			return nil, fmt.Errorf("[event] event could not be nil")
+		}
 
-			value, err := json.Marshal(events[i])
+		var allEqual = true
+		for _, data := range events[i].Data {
+			equal, err := instEqual(data)
 			if err != nil {
-				c.queueLock.Unlock()
-				return fmt.Errorf("[event] marshal json error: %v, raw: %#v", err, events[i])
+				return nil, fmt.Errorf("[event] compare failed: %v, source data is %#v", err, data)
 			}
-			et := &eventtmp{EventInst: events[i], data: value}
-			select {
-			case c.queue <- et:
-			default:
-				// channel fulled, so we drop 200 oldest events from queue
-				// TODO save to disk if possible
-				c.pending = nil
-				var ok bool
-				for i := 0; i < 200; i-- {
-					_, ok = <-c.queue
-					if !ok {
-						break
-					}
-				}
-				c.queue <- et
+			if !equal {
+				allEqual = false
+				break
 			}
+		}
 
-		} else {
-			c.queueLock.Unlock()
-			return fmt.Errorf("[event] event could not be nil")
+		if allEqual {
+			continue
+		}
+
+		eventID, err := rdb.NextSequence(ctx, common.EventCacheEventIDKey)
+		if err != nil {
+			return nil, fmt.Errorf("[event] generate eventID failed: %v", err)
+		}
+		events[i].ID = int64(eventID)
+
+		value, err := json.Marshal(events[i])
+		if err != nil {
+			return nil, fmt.Errorf("[event] marshal json error: %v, raw: %#v", err, events[i])
 		}
+		ets = append(ets, &eventtmp{EventInst: events[i], data: value})
+	}
+
+	return ets, nil
+}
+
+// ClientViaStream saves the events to the event log only, the consumers read them from the event log.
+// it's used when there is no redis, e.g. the data is saved in memory in the single node dev mode.
+type ClientViaStream struct {
+	rdb    dal.RDB
+	stream *EventStream
+}
+
+// NewClientViaStream returns a new event client which doesn't push the events to redis
+func NewClientViaStream(rdb dal.RDB) *ClientViaStream {
+	return &ClientViaStream{rdb: rdb, stream: NewEventStream(rdb)}
+}
+
+// Stream returns the durable event log that the events are saved in
+func (c *ClientViaStream) Stream() *EventStream {
+	return c.stream
+}
+
+// QueueDepth always returns 0, the events are not queued to push to redis
+func (c *ClientViaStream) QueueDepth() int {
+	return 0
+}
+
+// Push save the events to the event log
+func (c *ClientViaStream) Push(ctx context.Context, events ...*metadata.EventInst) error {
+	ets, err := buildEvents(ctx, c.rdb, events)
+	if err != nil {
+		return err
+	}
+	if err := c.stream.append(ctx, ets...); err != nil {
+		return fmt.Errorf("[event] save events to event log failed: %v", err)
 	}
-	c.queueLock.Unlock()
 	return nil
 }
 
@@ -129,16 +220,23 @@ type eventtmp struct {
 }
 
 func (c *ClientViaRedis) runPusher() {
+	replayTicker := time.NewTicker(replayInterval)
+	defer replayTicker.Stop()
+
 	var err error
 	for {
 		// 1. get el
 		var event *eventtmp
-		if c.pending != nil {
-			c.queueLock.Lock()
-			event = c.pending
-			c.queueLock.Unlock()
-		} else {
-			event = <-c.queue
+		c.queueLock.Lock()
+		event = c.pending
+		c.queueLock.Unlock()
+		if event == nil {
+			select {
+			case event = <-c.queue:
+			case <-replayTicker.C:
+				c.replay()
+				continue
+			}
 		}
 
 		// 2. ignore if el is nil
@@ -160,6 +258,91 @@ func (c *ClientViaRedis) runPusher() {
 		c.queueLock.Lock()
 		c.pending = nil
 		c.queueLock.Unlock()
+		c.markPushed(event)
+	}
+}
+
+// replay push the events in the event log which are not pushed to redis
+func (c *ClientViaRedis) replay() {
+	ctx := context.Background()
+	for {
+		now := time.Now()
+		logs, err := c.stream.unpushed(ctx, now.Add(-replayDelay), now.Add(-txnReplayDelay), replayBatch)
+		if err != nil {
+			blog.Errorf("[event] find the events to replay failed: %v", err)
+			return
+		}
+
+		for _, log := range logs {
+			event := &metadata.EventInst{}
+			if err := json.Unmarshal([]byte(log.Data), event); err != nil {
+				blog.Errorf("[event] unmarshal event %d to replay failed: %v, skip it", log.ID, err)
+				c.markPushed(&eventtmp{EventInst: &metadata.EventInst{ID: log.ID}})
+				continue
+			}
+			// the row of a transactional event is visible after the transaction commits,
+			// so it's pushed as a committed event.
+			event.TxnID = ""
+			et := &eventtmp{EventInst: event, data: []byte(log.Data)}
+			if err := c.pushToRedis(et); err != nil {
+				blog.Errorf("[event] replay event %d to redis failed: %v, we will retry later", log.ID, err)
+				return
+			}
+			c.markPushed(et)
+		}
+
+		if len(logs) < replayBatch {
+			return
+		}
+	}
+}
+
+// markPushed mark the event pushed in the event log later in batch, so that it will not be replayed.
+func (c *ClientViaRedis) markPushed(event *eventtmp) {
+	until := time.Now()
+	if event.TxnID != "" {
+		until = until.Add(txnMarkWindow)
+	}
+	c.markLock.Lock()
+	c.marks[event.ID] = until
+	c.markLock.Unlock()
+}
+
+func (c *ClientViaRedis) runMarker() {
+	ticker := time.NewTicker(markInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		c.flushMarks()
+	}
+}
+
+// flushMarks mark the pushed events in the event log, the transactional events are kept to mark again
+// until their windows end, the update matches nothing before the transaction commits.
+func (c *ClientViaRedis) flushMarks() {
+	now := time.Now()
+	c.markLock.Lock()
+	ids := make([]int64, 0, len(c.marks))
+	for id := range c.marks {
+		ids = append(ids, id)
+	}
+	c.markLock.Unlock()
+
+	for start := 0; start < len(ids); start += replayBatch {
+		end := start + replayBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := c.stream.markPushed(context.Background(), ids[start:end]...); err != nil {
+			blog.Errorf("[event] mark %d events pushed failed: %v, we will retry later", end-start, err)
+			continue
+		}
+		c.markLock.Lock()
+		for _, id := range ids[start:end] {
+			if until, exist := c.marks[id]; exist && !until.After(now) {
+				delete(c.marks, id)
+			}
+		}
+		c.markLock.Unlock()
 	}
 }
 
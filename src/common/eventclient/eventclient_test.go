@@ -15,6 +15,7 @@ package eventclient
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"icenter/src/common"
 	"icenter/src/common/metadata"
@@ -55,3 +56,17 @@ func TestNewEventWithHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestParseStreamConfigFromKV(t *testing.T) {
+	config := ParseStreamConfigFromKV("eventlog", map[string]string{"eventlog.retention_days": "3"})
+	if config.Retention != 3*24*time.Hour {
+		t.Errorf("ParseStreamConfigFromKV() retention = %v, want 72h", config.Retention)
+	}
+
+	for _, days := range []string{"", "0", "-1", "abc"} {
+		config = ParseStreamConfigFromKV("eventlog", map[string]string{"eventlog.retention_days": days})
+		if config.Retention != DefaultEventRetention {
+			t.Errorf("ParseStreamConfigFromKV(%q) retention = %v, want default", days, config.Retention)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eventclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+)
+
+const (
+	// DefaultEventRetention the default retention of the events in the event log
+	DefaultEventRetention = 7 * 24 * time.Hour
+	// defaultReadLimit the default max events count of one read
+	defaultReadLimit = 200
+	// maxReadLimit the max events count of one read
+	maxReadLimit = 1000
+	// settleWindow the events are saved within the transactions of the requests, so an event could be visible
+	// later than the events with greater ids, but no later than the lifetime of the transaction.
+	settleWindow = 2 * time.Minute
+)
+
+// StreamConfig the config of the event log
+type StreamConfig struct {
+	// Retention how long the events are kept in the event log
+	Retention time.Duration
+}
+
+// ParseStreamConfigFromKV returns a new stream config, the retention is configured in days.
+func ParseStreamConfigFromKV(prefix string, configmap map[string]string) StreamConfig {
+	config := StreamConfig{Retention: DefaultEventRetention}
+	days, err := strconv.Atoi(configmap[prefix+".retention_days"])
+	if err == nil && days > 0 {
+		config.Retention = time.Duration(days) * 24 * time.Hour
+	}
+	return config
+}
+
+// EventStream is the durable event log. every event is saved with its event id before
+// it is pushed to the cache, so the event is never lost even if the process restarts,
+// and the consumers can read the events from any event id with their stored offsets.
+type EventStream struct {
+	db           dal.RDB
+	settleWindow time.Duration
+}
+
+// NewEventStream returns a new event stream saved in the db
+func NewEventStream(db dal.RDB) *EventStream {
+	return &EventStream{db: db, settleWindow: settleWindow}
+}
+
+// append save the events to the event log
+func (s *EventStream) append(ctx context.Context, events ...*eventtmp) error {
+	if len(events) == 0 {
+		return nil
+	}
+	now := time.Now()
+	logs := make([]metadata.EventLog, 0, len(events))
+	for _, event := range events {
+		logs = append(logs, metadata.EventLog{
+			ID:         event.ID,
+			TxnID:      event.TxnID,
+			EventType:  event.EventType,
+			Action:     event.Action,
+			ObjType:    event.ObjType,
+			OwnerID:    event.OwnerID,
+			Data:       string(event.data),
+			CreateTime: now,
+		})
+	}
+	return s.db.Table(common.BKTableNameEventLog).Insert(ctx, logs)
+}
+
+// markPushed mark the events have been pushed to the cache
+func (s *EventStream) markPushed(ctx context.Context, eventIDs ...int64) error {
+	filter := map[string]interface{}{"event_id": map[string]interface{}{common.BKDBIN: eventIDs}}
+	return s.db.Table(common.BKTableNameEventLog).Update(ctx, filter, map[string]interface{}{"pushed": true})
+}
+
+// unpushed returns the events which are not pushed to the cache yet, the events out of the transactions
+// are saved before the time, the transactional events are saved before the txnBefore.
+func (s *EventStream) unpushed(ctx context.Context, before, txnBefore time.Time, limit uint64) ([]metadata.EventLog, error) {
+	filter := map[string]interface{}{
+		"pushed": false,
+		common.BKDBOR: []map[string]interface{}{
+			{"txn_id": "", common.CreateTimeField: map[string]interface{}{common.BKDBLT: before}},
+			{"txn_id": map[string]interface{}{common.BKDBNE: ""}, common.CreateTimeField: map[string]interface{}{common.BKDBLT: txnBefore}},
+		},
+	}
+	logs := make([]metadata.EventLog, 0)
+	err := s.db.Table(common.BKTableNameEventLog).Find(filter).Sort("event_id").Limit(limit).All(ctx, &logs)
+	return logs, err
+}
+
+// Read returns the events whose event id is greater than the from id in order.
+func (s *EventStream) Read(ctx context.Context, fromID int64, limit int64) ([]metadata.EventInst, error) {
+	filter := map[string]interface{}{
+		"event_id": map[string]interface{}{common.BKDBGT: fromID},
+	}
+	return s.find(ctx, filter, limit)
+}
+
+func (s *EventStream) find(ctx context.Context, filter map[string]interface{}, limit int64) ([]metadata.EventInst, error) {
+	if limit <= 0 {
+		limit = defaultReadLimit
+	}
+	if limit > maxReadLimit {
+		limit = maxReadLimit
+	}
+
+	logs := make([]metadata.EventLog, 0)
+	if err := s.db.Table(common.BKTableNameEventLog).Find(filter).Sort("event_id").Limit(uint64(limit)).All(ctx, &logs); err != nil {
+		return nil, err
+	}
+
+	events := make([]metadata.EventInst, 0, len(logs))
+	for _, log := range logs {
+		event := metadata.EventInst{}
+		if err := json.Unmarshal([]byte(log.Data), &event); err != nil {
+			return nil, fmt.Errorf("[event] unmarshal event %d failed: %v", log.ID, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// consumerOffset returns the stored offset of the consumer, it's an empty one if the consumer never commits.
+func (s *EventStream) consumerOffset(ctx context.Context, consumer string) (*metadata.EventConsumerOffset, error) {
+	offset := &metadata.EventConsumerOffset{}
+	err := s.db.Table(common.BKTableNameEventConsumer).Find(map[string]interface{}{"consumer": consumer}).One(ctx, offset)
+	if err != nil {
+		if s.db.IsNotFoundError(err) {
+			return &metadata.EventConsumerOffset{Consumer: consumer}, nil
+		}
+		return nil, err
+	}
+	offset.Settled = settledID(offset)
+	return offset, nil
+}
+
+// settledID returns the settled id of the consumer, it's the offset if the offset
+// is committed before the settled id is saved.
+func settledID(offset *metadata.EventConsumerOffset) int64 {
+	if offset.Settled == 0 && len(offset.Consumed) == 0 {
+		return offset.Offset
+	}
+	return offset.Settled
+}
+
+// saveOffset save the offset of the consumer
+func (s *EventStream) saveOffset(ctx context.Context, offset *metadata.EventConsumerOffset) error {
+	table := s.db.Table(common.BKTableNameEventConsumer)
+	filter := map[string]interface{}{"consumer": offset.Consumer}
+	offset.LastTime = time.Now()
+
+	count, err := table.Find(filter).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		err = table.Insert(ctx, offset)
+		if err == nil || !s.db.IsDuplicatedError(err) {
+			return err
+		}
+		// another one has inserted the consumer, so update it.
+	}
+	return table.Update(ctx, filter, offset)
+}
+
+// Offset returns the last event id that the consumer has committed, it is 0 if the consumer never commits.
+func (s *EventStream) Offset(ctx context.Context, consumer string) (int64, error) {
+	offset, err := s.consumerOffset(ctx, consumer)
+	if err != nil {
+		return 0, err
+	}
+	return offset.Offset, nil
+}
+
+// Commit save the last event id that the consumer has consumed, the events delivered to the consumer
+// before it are consumed. the offset is reset to the event id if the consumer commits without reading.
+func (s *EventStream) Commit(ctx context.Context, consumer string, eventID int64) error {
+	if len(consumer) == 0 {
+		return fmt.Errorf("[event] consumer could not be empty")
+	}
+
+	offset, err := s.consumerOffset(ctx, consumer)
+	if err != nil {
+		return err
+	}
+	if len(offset.Delivered) == 0 {
+		offset.Offset = eventID
+		offset.Settled = eventID
+		offset.Consumed = nil
+		return s.saveOffset(ctx, offset)
+	}
+
+	delivered := make([]int64, 0)
+	for _, id := range offset.Delivered {
+		if id <= eventID {
+			offset.Consumed = append(offset.Consumed, id)
+			continue
+		}
+		delivered = append(delivered, id)
+	}
+	offset.Delivered = delivered
+	if eventID > offset.Offset {
+		offset.Offset = eventID
+	}
+	if err := s.settle(ctx, offset); err != nil {
+		return err
+	}
+	return s.saveOffset(ctx, offset)
+}
+
+// settle move the settled id of the consumer forward, over the events which are saved before the
+// settle window and consumed. the consumed ids before the settled id are not needed any more.
+func (s *EventStream) settle(ctx context.Context, offset *metadata.EventConsumerOffset) error {
+	table := s.db.Table(common.BKTableNameEventLog)
+	horizon := make([]metadata.EventLog, 0)
+	filter := map[string]interface{}{
+		"event_id":             map[string]interface{}{common.BKDBLTE: offset.Offset},
+		common.CreateTimeField: map[string]interface{}{common.BKDBLT: time.Now().Add(-s.settleWindow)},
+	}
+	if err := table.Find(filter).Fields("event_id").Sort("-event_id").Limit(1).All(ctx, &horizon); err != nil {
+		return err
+	}
+	if len(horizon) == 0 || horizon[0].ID <= offset.Settled {
+		return nil
+	}
+
+	// the settled id stops before the first event which is not consumed yet.
+	settled := horizon[0].ID
+	idFilter := map[string]interface{}{common.BKDBGT: offset.Settled, common.BKDBLTE: settled}
+	if len(offset.Consumed) != 0 {
+		idFilter[common.BKDBNIN] = offset.Consumed
+	}
+	pending := make([]metadata.EventLog, 0)
+	if err := table.Find(map[string]interface{}{"event_id": idFilter}).Fields("event_id").Sort("event_id").Limit(1).All(ctx, &pending); err != nil {
+		return err
+	}
+	if len(pending) != 0 {
+		settled = pending[0].ID - 1
+	}
+	if settled <= offset.Settled {
+		return nil
+	}
+
+	offset.Settled = settled
+	consumed := make([]int64, 0, len(offset.Consumed))
+	for _, id := range offset.Consumed {
+		if id > settled {
+			consumed = append(consumed, id)
+		}
+	}
+	offset.Consumed = consumed
+	return nil
+}
+
+// Consume returns the events after the consumer's stored offset, the consumer should commit the
+// last event id after the events are handled. the events saved within the settle window are read
+// again until they are settled, so the event which is visible later than the ones with greater
+// ids, e.g. saved in a long transaction, is still delivered once it's visible.
+func (s *EventStream) Consume(ctx context.Context, consumer string, limit int64) ([]metadata.EventInst, int64, error) {
+	offset, err := s.consumerOffset(ctx, consumer)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	idFilter := map[string]interface{}{common.BKDBGT: offset.Settled}
+	if len(offset.Consumed) != 0 {
+		idFilter[common.BKDBNIN] = offset.Consumed
+	}
+	events, err := s.find(ctx, map[string]interface{}{"event_id": idFilter}, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(events) == 0 && len(offset.Delivered) == 0 {
+		return events, offset.Offset, nil
+	}
+
+	offset.Delivered = make([]int64, 0, len(events))
+	for _, event := range events {
+		offset.Delivered = append(offset.Delivered, event.ID)
+	}
+	if err := s.saveOffset(ctx, offset); err != nil {
+		return nil, 0, err
+	}
+	return events, offset.Offset, nil
+}
+
+// Purge delete the events saved before the time, the events which are not settled by all the consumers are kept.
+func (s *EventStream) Purge(ctx context.Context, before time.Time) error {
+	filter := map[string]interface{}{
+		common.CreateTimeField: map[string]interface{}{common.BKDBLT: before},
+	}
+
+	offsets := make([]metadata.EventConsumerOffset, 0)
+	if err := s.db.Table(common.BKTableNameEventConsumer).Find(map[string]interface{}{}).All(ctx, &offsets); err != nil {
+		return err
+	}
+	if len(offsets) != 0 {
+		minSettled := settledID(&offsets[0])
+		for index := range offsets {
+			if settled := settledID(&offsets[index]); settled < minSettled {
+				minSettled = settled
+			}
+		}
+		filter["event_id"] = map[string]interface{}{common.BKDBLTE: minSettled}
+	}
+	return s.db.Table(common.BKTableNameEventLog).Delete(ctx, filter)
+}
+
+// RunRetention purge the events out of the retention periodically, it never returns.
+func (s *EventStream) RunRetention(config StreamConfig) {
+	if config.Retention <= 0 {
+		config.Retention = DefaultEventRetention
+	}
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		before := time.Now().Add(-config.Retention)
+		if err := s.Purge(context.Background(), before); err != nil {
+			blog.Errorf("[event] purge the events before %s failed: %v", before, err)
+		}
+		<-ticker.C
+	}
+}
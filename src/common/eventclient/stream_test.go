@@ -0,0 +1,246 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eventclient
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal/memory"
+)
+
+func appendTestEvents(t *testing.T, stream *EventStream, ids ...int64) {
+	for _, id := range ids {
+		event := &metadata.EventInst{ID: id, EventType: metadata.EventTypeInstData, ObjType: "host"}
+		data, err := json.Marshal(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := stream.append(context.Background(), &eventtmp{EventInst: event, data: data}); err != nil {
+			t.Fatalf("append event %d failed, err: %v", id, err)
+		}
+	}
+}
+
+func eventIDs(events []metadata.EventInst) []int64 {
+	ids := make([]int64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func consume(t *testing.T, stream *EventStream, consumer string, expect ...int64) {
+	events, _, err := stream.Consume(context.Background(), consumer, 0)
+	if err != nil {
+		t.Fatalf("consume failed, err: %v", err)
+	}
+	if ids := eventIDs(events); len(ids) != len(expect) || (len(ids) != 0 && !reflect.DeepEqual(ids, expect)) {
+		t.Fatalf("consume events %v, want %v", ids, expect)
+	}
+}
+
+func commit(t *testing.T, stream *EventStream, consumer string, eventID int64) {
+	if err := stream.Commit(context.Background(), consumer, eventID); err != nil {
+		t.Fatalf("commit %d failed, err: %v", eventID, err)
+	}
+}
+
+func TestStreamConsumeCommit(t *testing.T) {
+	stream := NewEventStream(memory.NewMemory())
+	appendTestEvents(t, stream, 1, 2, 3)
+
+	consume(t, stream, "test", 1, 2, 3)
+	// the consumer only handled a part of the events
+	commit(t, stream, "test", 2)
+	consume(t, stream, "test", 3)
+	commit(t, stream, "test", 3)
+	consume(t, stream, "test")
+
+	offset, err := stream.Offset(context.Background(), "test")
+	if err != nil || offset != 3 {
+		t.Errorf("offset = %d, err: %v, want 3", offset, err)
+	}
+}
+
+func TestStreamLateEvent(t *testing.T) {
+	stream := NewEventStream(memory.NewMemory())
+	// the event 1 is saved in a transaction, which is visible after the event 2.
+	appendTestEvents(t, stream, 2)
+	consume(t, stream, "test", 2)
+	commit(t, stream, "test", 2)
+
+	appendTestEvents(t, stream, 1, 3)
+	consume(t, stream, "test", 1, 3)
+	commit(t, stream, "test", 3)
+	consume(t, stream, "test")
+}
+
+func TestStreamSettle(t *testing.T) {
+	stream := NewEventStream(memory.NewMemory())
+	stream.settleWindow = -time.Minute
+	appendTestEvents(t, stream, 1, 2, 4)
+
+	consume(t, stream, "test", 1, 2, 4)
+	commit(t, stream, "test", 4)
+	offset, err := stream.consumerOffset(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset.Settled != 4 || len(offset.Consumed) != 0 {
+		t.Errorf("the consumed events should be settled, got %+v", offset)
+	}
+
+	// the settled id stops before the late event which is not consumed yet.
+	appendTestEvents(t, stream, 3, 5)
+	offset.Settled = 2
+	offset.Consumed = []int64{4}
+	offset.Delivered = []int64{5}
+	if err := stream.saveOffset(context.Background(), offset); err != nil {
+		t.Fatal(err)
+	}
+	commit(t, stream, "test", 5)
+	if offset, err = stream.consumerOffset(context.Background(), "test"); err != nil {
+		t.Fatal(err)
+	}
+	if offset.Settled != 2 || !reflect.DeepEqual(offset.Consumed, []int64{4, 5}) {
+		t.Errorf("the settled id should stop before event 3, got %+v", offset)
+	}
+	consume(t, stream, "test", 3)
+}
+
+func TestStreamLegacyOffset(t *testing.T) {
+	db := memory.NewMemory()
+	stream := NewEventStream(db)
+	appendTestEvents(t, stream, 1, 2, 3)
+	legacy := metadata.EventConsumerOffset{Consumer: "test", Offset: 2}
+	if err := db.Table(common.BKTableNameEventConsumer).Insert(context.Background(), legacy); err != nil {
+		t.Fatal(err)
+	}
+	consume(t, stream, "test", 3)
+
+	// commit without reading resets the offset
+	commit(t, stream, "reset", 1)
+	consume(t, stream, "reset", 2, 3)
+}
+
+func TestStreamReplay(t *testing.T) {
+	stream := NewEventStream(memory.NewMemory())
+	appendTestEvents(t, stream, 1, 2, 3)
+	consume(t, stream, "test", 1, 2, 3)
+	commit(t, stream, "test", 3)
+
+	events, err := stream.Read(context.Background(), 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := eventIDs(events); !reflect.DeepEqual(ids, []int64{2, 3}) {
+		t.Errorf("replay events %v, want [2 3]", ids)
+	}
+}
+
+func TestStreamPurge(t *testing.T) {
+	db := memory.NewMemory()
+	stream := NewEventStream(db)
+	stream.settleWindow = -time.Minute
+	appendTestEvents(t, stream, 1, 2, 3)
+	consume(t, stream, "fast", 1, 2, 3)
+	commit(t, stream, "fast", 3)
+	consume(t, stream, "slow", 1, 2, 3)
+	commit(t, stream, "slow", 1)
+
+	if err := stream.Purge(context.Background(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	events, err := stream.Read(context.Background(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := eventIDs(events); !reflect.DeepEqual(ids, []int64{2, 3}) {
+		t.Errorf("the events not consumed by the slow consumer should be kept, got %v", ids)
+	}
+}
+
+func TestClientViaStream(t *testing.T) {
+	client := NewClientViaStream(memory.NewMemory())
+	changed := &metadata.EventInst{EventType: metadata.EventTypeInstData, ObjType: "host",
+		Data: []metadata.EventData{{CurData: map[string]interface{}{"bk_host_id": 1}}}}
+	unchanged := &metadata.EventInst{EventType: metadata.EventTypeInstData, ObjType: "host",
+		Data: []metadata.EventData{{PreData: map[string]interface{}{"bk_host_id": 1}, CurData: map[string]interface{}{"bk_host_id": 1}}}}
+	if err := client.Push(context.Background(), changed, unchanged); err != nil {
+		t.Fatalf("push failed, err: %v", err)
+	}
+	// the event which changes nothing is not saved
+	consume(t, client.Stream(), "test", changed.ID)
+}
+
+func TestStreamUnpushed(t *testing.T) {
+	stream := NewEventStream(memory.NewMemory())
+	appendTestEvents(t, stream, 1, 2)
+	txnEvent := &metadata.EventInst{ID: 3, TxnID: "txn", EventType: metadata.EventTypeInstData, ObjType: "host"}
+	if err := stream.append(context.Background(), &eventtmp{EventInst: txnEvent, data: []byte("{}")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.markPushed(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// the transactional event is replayed only after the longer delay
+	now := time.Now()
+	logs, err := stream.unpushed(context.Background(), now.Add(time.Minute), now.Add(-time.Minute), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].ID != 2 {
+		t.Fatalf("unpushed events %+v, want only event 2", logs)
+	}
+	if logs, err = stream.unpushed(context.Background(), now.Add(time.Minute), now.Add(time.Minute), 10); err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 2 || logs[1].ID != 3 {
+		t.Fatalf("unpushed events %+v, want event 2 and 3", logs)
+	}
+}
+
+func TestFlushMarks(t *testing.T) {
+	stream := NewEventStream(memory.NewMemory())
+	client := &ClientViaRedis{stream: stream, marks: make(map[int64]time.Time)}
+	// the transactional event 2 is pushed before its transaction commits
+	appendTestEvents(t, stream, 1)
+	client.markPushed(&eventtmp{EventInst: &metadata.EventInst{ID: 1}})
+	client.markPushed(&eventtmp{EventInst: &metadata.EventInst{ID: 2, TxnID: "txn"}})
+	client.flushMarks()
+	if _, exist := client.marks[1]; exist {
+		t.Errorf("event 1 should not be marked again")
+	}
+	if _, exist := client.marks[2]; !exist {
+		t.Fatalf("the transactional event 2 should be marked again")
+	}
+
+	// the transaction commits
+	appendTestEvents(t, stream, 2)
+	client.flushMarks()
+	now := time.Now()
+	logs, err := stream.unpushed(context.Background(), now.Add(time.Minute), now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 0 {
+		t.Errorf("all the events should be marked pushed, got %+v", logs)
+	}
+}
@@ -0,0 +1,331 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Env the environment that the expression is evaluated in
+type Env interface {
+	// Field returns the value of the field of the instance, it's nil if not exist.
+	Field(name string) interface{}
+	// Associated returns the values of the field of the instances of the model which are associated with the instance.
+	Associated(objID, field string) ([]interface{}, error)
+}
+
+// MapEnv the environment of an instance without any association
+type MapEnv map[string]interface{}
+
+// Field returns the value of the field
+func (e MapEnv) Field(name string) interface{} {
+	return e[name]
+}
+
+// Associated returns no value
+func (e MapEnv) Associated(objID, field string) ([]interface{}, error) {
+	return nil, nil
+}
+
+// Eval evaluate the expression in the environment, the result is nil, a bool,
+// a float64, a string or a slice of them.
+func (e *Expression) Eval(env Env) (interface{}, error) {
+	return eval(e.root, env)
+}
+
+func eval(n node, env Env) (interface{}, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+	case *fieldNode:
+		return normalize(env.Field(n.name)), nil
+	case *unaryNode:
+		x, err := eval(n.x, env)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			return !truthy(x), nil
+		}
+		if x == nil {
+			return nil, nil
+		}
+		num, err := toNumber(x)
+		if err != nil {
+			return nil, err
+		}
+		return -num, nil
+	case *binaryNode:
+		return evalBinary(n, env)
+	case *condNode:
+		cond, err := eval(n.cond, env)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(cond) {
+			return eval(n.x, env)
+		}
+		return eval(n.y, env)
+	case *callNode:
+		return n.fn.call(env, n.args)
+	default:
+		return nil, fmt.Errorf("unknown node %T", n)
+	}
+}
+
+func evalBinary(n *binaryNode, env Env) (interface{}, error) {
+	x, err := eval(n.x, env)
+	if err != nil {
+		return nil, err
+	}
+	// the logical operators are short circuit.
+	switch n.op {
+	case "&&":
+		if !truthy(x) {
+			return false, nil
+		}
+		y, err := eval(n.y, env)
+		return truthy(y), err
+	case "||":
+		if truthy(x) {
+			return true, nil
+		}
+		y, err := eval(n.y, env)
+		return truthy(y), err
+	}
+
+	y, err := eval(n.y, env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(x, y), nil
+	case "!=":
+		return !equal(x, y), nil
+	case "<", "<=", ">", ">=":
+		if x == nil || y == nil {
+			return false, nil
+		}
+		cmp, err := compare(x, y)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	case "+":
+		_, xIsStr := x.(string)
+		_, yIsStr := y.(string)
+		if xIsStr || yIsStr {
+			return toString(x) + toString(y), nil
+		}
+	}
+
+	// the arithmetic with null is null
+	if x == nil || y == nil {
+		return nil, nil
+	}
+	a, err := toNumber(x)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toNumber(y)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return a / b, nil
+	case "%":
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(a, b), nil
+	default:
+		return nil, fmt.Errorf("unknown operator %s", n.op)
+	}
+}
+
+// normalize convert the value of the field into the value of the expression
+func normalize(val interface{}) interface{} {
+	switch value := val.(type) {
+	case nil, bool, string, float64:
+		return value
+	case json.Number:
+		num, err := value.Float64()
+		if err != nil {
+			return value.String()
+		}
+		return num
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, item := range value {
+			result[i] = normalize(item)
+		}
+		return result
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Slice, reflect.Array:
+		result := make([]interface{}, rv.Len())
+		for i := range result {
+			result[i] = normalize(rv.Index(i).Interface())
+		}
+		return result
+	}
+	return fmt.Sprint(val)
+}
+
+func truthy(val interface{}) bool {
+	switch value := val.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case float64:
+		return value != 0
+	case string:
+		return len(value) != 0
+	case []interface{}:
+		return len(value) != 0
+	default:
+		return true
+	}
+}
+
+// toNumber convert the value into a finite number, NaN and Inf are rejected
+// as they can not be used as the arguments of the functions.
+func toNumber(val interface{}) (float64, error) {
+	switch value := val.(type) {
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return 0, fmt.Errorf("%v is not a finite number", value)
+		}
+		return value, nil
+	case bool:
+		if value {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		num, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || math.IsNaN(num) || math.IsInf(num, 0) {
+			return 0, fmt.Errorf("%q is not a number", value)
+		}
+		return num, nil
+	default:
+		return 0, fmt.Errorf("%v is not a number", val)
+	}
+}
+
+func toString(val interface{}) string {
+	switch value := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = toString(item)
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func equal(x, y interface{}) bool {
+	if x == nil || y == nil {
+		return x == nil && y == nil
+	}
+	cmp, err := compare(x, y)
+	if err != nil {
+		return reflect.DeepEqual(x, y)
+	}
+	return cmp == 0
+}
+
+// compare compares the numbers, the strings or the bools, the number is
+// compared with the string as number.
+func compare(x, y interface{}) (int, error) {
+	xStr, xIsStr := x.(string)
+	yStr, yIsStr := y.(string)
+	if xIsStr && yIsStr {
+		return strings.Compare(xStr, yStr), nil
+	}
+	a, err := toNumber(x)
+	if err != nil {
+		return 0, err
+	}
+	b, err := toNumber(y)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case a < b:
+		return -1, nil
+	case a > b:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// ToBool convert the result of the expression into bool, null, false, 0, "" and empty list are false.
+func ToBool(val interface{}) bool {
+	return truthy(normalize(val))
+}
+
+// ToNumber convert the result of the expression into number
+func ToNumber(val interface{}) (float64, error) {
+	return toNumber(normalize(val))
+}
+
+// ToString convert the result of the expression into string, the items of a list are joined with ",".
+func ToString(val interface{}) string {
+	return toString(normalize(val))
+}
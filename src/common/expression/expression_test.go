@@ -0,0 +1,147 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"reflect"
+	"testing"
+)
+
+type asstEnv struct {
+	MapEnv
+	assts map[string][]interface{}
+}
+
+func (e asstEnv) Associated(objID, field string) ([]interface{}, error) {
+	return e.assts[objID+"."+field], nil
+}
+
+func TestEval(t *testing.T) {
+	env := asstEnv{
+		MapEnv: MapEnv{
+			"bk_inst_name": "web",
+			"cpu":          int64(8),
+			"mem":          16.5,
+			"region":       "",
+			"tags":         []string{"a", "b"},
+		},
+		assts: map[string][]interface{}{
+			"host.bk_cpu": {int64(4), int64(8)},
+		},
+	}
+	tests := []struct {
+		expr   string
+		result interface{}
+	}{
+		{`1 + 2 * 3`, float64(7)},
+		{`(1 + 2) * 3`, float64(9)},
+		{`10 % 4 - -1`, float64(3)},
+		{`bk_inst_name + "-" + cpu`, "web-8"},
+		{`cpu > 4 && mem < 20`, true},
+		{`!(cpu == 8) || false`, false},
+		{`cpu >= 8 ? "large" : cpu >= 4 ? "medium" : "small"`, "large"},
+		{`upper(substr(bk_inst_name, 0, 2))`, "WE"},
+		{`concat(bk_inst_name, ":", round(mem / 3, 2))`, "web:5.5"},
+		{`coalesce(region, "default")`, "default"},
+		{`if(cpu > 100, 1 / 0, "ok")`, "ok"},
+		{`unknown_field + 1`, nil},
+		{`len(tags) + len(bk_inst_name)`, float64(5)},
+		{`join(tags, "|")`, "a|b"},
+		{`sum(assts("host", "bk_cpu"))`, float64(12)},
+		{`asst("host", "bk_cpu")`, float64(4)},
+		{`count(assts("set", "bk_set_name"))`, float64(0)},
+		{`MAX(cpu, mem, 3)`, 16.5},
+	}
+	for _, test := range tests {
+		expr, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("parse %s failed, err: %v", test.expr, err)
+			continue
+		}
+		result, err := expr.Eval(env)
+		if err != nil {
+			t.Errorf("eval %s failed, err: %v", test.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, test.result) {
+			t.Errorf("eval %s, expect %#v, but got %#v", test.expr, test.result, result)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	exprs := []string{
+		``,
+		`1 +`,
+		`(1 + 2`,
+		`"abc`,
+		`cpu ? 1`,
+		`exec("rm")`,
+		`upper()`,
+		`a $ b`,
+		`1 2`,
+	}
+	for _, expr := range exprs {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("parse %s should fail", expr)
+		}
+	}
+
+	deep := ""
+	for i := 0; i < 100; i++ {
+		deep += "("
+	}
+	if _, err := Parse(deep + "1"); err == nil {
+		t.Errorf("parse the deep nested expression should fail")
+	}
+}
+
+func TestEvalError(t *testing.T) {
+	for _, src := range []string{`1 / 0`, `"a" * 2`, `substr("abc", "NaN")`, `substr("abc", 0, "nan")`, `tonumber("Inf")`} {
+		expr, err := Parse(src)
+		if err != nil {
+			t.Fatalf("parse %s failed, err: %v", src, err)
+		}
+		if _, err := expr.Eval(MapEnv{}); err == nil {
+			t.Errorf("eval %s should fail", src)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	expr, err := Parse(`concat(b, a) + if(c, a, "x")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expr.Fields(), []string{"a", "b", "c"}) {
+		t.Errorf("unexpected fields %v", expr.Fields())
+	}
+}
+
+func TestAssociations(t *testing.T) {
+	expr, err := Parse(`sum(assts("host", "bk_cpu")) + asst("set", "bk_capacity") + count(assts("host", "bk_cpu"))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Association{{ObjectID: "host", Field: "bk_cpu"}, {ObjectID: "set", Field: "bk_capacity"}}
+	if !reflect.DeepEqual(expr.Associations(), expect) {
+		t.Errorf("unexpected associations %v", expr.Associations())
+	}
+
+	// the lookups should be known before the evaluation
+	for _, src := range []string{`asst(model, "bk_cpu")`, `assts("host", concat("bk_", "cpu"))`, `asst("host", "")`} {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("parse %s should fail", src)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type function struct {
+	minArgs int
+	// maxArgs the max count of the arguments, -1 means no limit.
+	maxArgs int
+	// lazy the arguments are evaluated by the function itself
+	lazy bool
+	impl func(env Env, args []interface{}, nodes []node) (interface{}, error)
+}
+
+func (f *function) call(env Env, nodes []node) (interface{}, error) {
+	if f.lazy {
+		return f.impl(env, nil, nodes)
+	}
+	args := make([]interface{}, len(nodes))
+	for i, n := range nodes {
+		value, err := eval(n, env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	return f.impl(env, args, nodes)
+}
+
+// fn returns a function whose arguments are evaluated before it's called
+func fn(minArgs, maxArgs int, impl func(args []interface{}) (interface{}, error)) *function {
+	return &function{
+		minArgs: minArgs,
+		maxArgs: maxArgs,
+		impl: func(_ Env, args []interface{}, _ []node) (interface{}, error) {
+			return impl(args)
+		},
+	}
+}
+
+// numberFn returns a function of one number, the result of null is null.
+func numberFn(impl func(float64) float64) *function {
+	return fn(1, 1, func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		num, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return impl(num), nil
+	})
+}
+
+// stringFn returns a function whose first argument is a string, the result of null is null.
+func stringFn(minArgs, maxArgs int, impl func(s string, args []interface{}) (interface{}, error)) *function {
+	return fn(minArgs, maxArgs, func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return impl(toString(args[0]), args[1:])
+	})
+}
+
+// listArgs returns the items of the list arguments and the other arguments
+func listArgs(args []interface{}) []interface{} {
+	items := make([]interface{}, 0)
+	for _, arg := range args {
+		if list, ok := arg.([]interface{}); ok {
+			items = append(items, list...)
+			continue
+		}
+		items = append(items, arg)
+	}
+	return items
+}
+
+func aggregate(impl func(nums []float64) interface{}) *function {
+	return fn(1, -1, func(args []interface{}) (interface{}, error) {
+		nums := make([]float64, 0)
+		for _, item := range listArgs(args) {
+			if item == nil {
+				continue
+			}
+			num, err := toNumber(item)
+			if err != nil {
+				return nil, err
+			}
+			nums = append(nums, num)
+		}
+		return impl(nums), nil
+	})
+}
+
+func associated(env Env, args []interface{}) ([]interface{}, error) {
+	objID, field := toString(args[0]), toString(args[1])
+	if len(objID) == 0 || len(field) == 0 {
+		return nil, fmt.Errorf("the model and the field of the associated instances should be set")
+	}
+	values, err := env.Associated(objID, field)
+	if err != nil {
+		return nil, err
+	}
+	return normalize(values).([]interface{}), nil
+}
+
+// functions the builtin functions, the name is case insensitive.
+var functions = map[string]*function{
+	// string functions
+	"concat": fn(1, -1, func(args []interface{}) (interface{}, error) {
+		var sb strings.Builder
+		for _, arg := range args {
+			sb.WriteString(toString(arg))
+		}
+		return sb.String(), nil
+	}),
+	"upper": stringFn(1, 1, func(s string, _ []interface{}) (interface{}, error) { return strings.ToUpper(s), nil }),
+	"lower": stringFn(1, 1, func(s string, _ []interface{}) (interface{}, error) { return strings.ToLower(s), nil }),
+	"trim":  stringFn(1, 1, func(s string, _ []interface{}) (interface{}, error) { return strings.TrimSpace(s), nil }),
+	"substr": stringFn(2, 3, func(s string, args []interface{}) (interface{}, error) {
+		runes := []rune(s)
+		start, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		begin := int(math.Max(0, math.Min(start, float64(len(runes)))))
+		end := len(runes)
+		if len(args) > 1 {
+			length, err := toNumber(args[1])
+			if err != nil {
+				return nil, err
+			}
+			end = int(math.Max(float64(begin), math.Min(float64(begin)+length, float64(len(runes)))))
+		}
+		return string(runes[begin:end]), nil
+	}),
+	"replace": stringFn(3, 3, func(s string, args []interface{}) (interface{}, error) {
+		return strings.Replace(s, toString(args[0]), toString(args[1]), -1), nil
+	}),
+	"contains": stringFn(2, 2, func(s string, args []interface{}) (interface{}, error) {
+		return strings.Contains(s, toString(args[0])), nil
+	}),
+	"startswith": stringFn(2, 2, func(s string, args []interface{}) (interface{}, error) {
+		return strings.HasPrefix(s, toString(args[0])), nil
+	}),
+	"endswith": stringFn(2, 2, func(s string, args []interface{}) (interface{}, error) {
+		return strings.HasSuffix(s, toString(args[0])), nil
+	}),
+	"split": stringFn(2, 2, func(s string, args []interface{}) (interface{}, error) {
+		items := strings.Split(s, toString(args[0]))
+		result := make([]interface{}, len(items))
+		for i, item := range items {
+			result[i] = item
+		}
+		return result, nil
+	}),
+	"join": fn(2, 2, func(args []interface{}) (interface{}, error) {
+		items := make([]string, 0)
+		for _, item := range listArgs(args[:1]) {
+			if item != nil {
+				items = append(items, toString(item))
+			}
+		}
+		return strings.Join(items, toString(args[1])), nil
+	}),
+	"len": fn(1, 1, func(args []interface{}) (interface{}, error) {
+		switch value := args[0].(type) {
+		case nil:
+			return float64(0), nil
+		case []interface{}:
+			return float64(len(value)), nil
+		default:
+			return float64(len([]rune(toString(value)))), nil
+		}
+	}),
+	"tostring": fn(1, 1, func(args []interface{}) (interface{}, error) { return toString(args[0]), nil }),
+	"tonumber": fn(1, 1, func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return toNumber(args[0])
+	}),
+
+	// number functions
+	"abs":   numberFn(math.Abs),
+	"floor": numberFn(math.Floor),
+	"ceil":  numberFn(math.Ceil),
+	"round": fn(1, 2, func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		num, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		digits := float64(0)
+		if len(args) > 1 {
+			if digits, err = toNumber(args[1]); err != nil {
+				return nil, err
+			}
+		}
+		scale := math.Pow(10, math.Floor(digits))
+		return math.Round(num*scale) / scale, nil
+	}),
+	"min": aggregate(func(nums []float64) interface{} {
+		if len(nums) == 0 {
+			return nil
+		}
+		result := nums[0]
+		for _, num := range nums[1:] {
+			result = math.Min(result, num)
+		}
+		return result
+	}),
+	"max": aggregate(func(nums []float64) interface{} {
+		if len(nums) == 0 {
+			return nil
+		}
+		result := nums[0]
+		for _, num := range nums[1:] {
+			result = math.Max(result, num)
+		}
+		return result
+	}),
+	"sum": aggregate(func(nums []float64) interface{} {
+		result := float64(0)
+		for _, num := range nums {
+			result += num
+		}
+		return result
+	}),
+	"avg": aggregate(func(nums []float64) interface{} {
+		if len(nums) == 0 {
+			return nil
+		}
+		result := float64(0)
+		for _, num := range nums {
+			result += num
+		}
+		return result / float64(len(nums))
+	}),
+	"count": fn(1, -1, func(args []interface{}) (interface{}, error) {
+		count := 0
+		for _, item := range listArgs(args) {
+			if item != nil {
+				count++
+			}
+		}
+		return float64(count), nil
+	}),
+
+	// conditional functions, the arguments are evaluated only when they are used.
+	"if": {
+		minArgs: 2,
+		maxArgs: 3,
+		lazy:    true,
+		impl: func(env Env, _ []interface{}, nodes []node) (interface{}, error) {
+			cond, err := eval(nodes[0], env)
+			if err != nil {
+				return nil, err
+			}
+			if truthy(cond) {
+				return eval(nodes[1], env)
+			}
+			if len(nodes) > 2 {
+				return eval(nodes[2], env)
+			}
+			return nil, nil
+		},
+	},
+	"coalesce": {
+		minArgs: 1,
+		maxArgs: -1,
+		lazy:    true,
+		impl: func(env Env, _ []interface{}, nodes []node) (interface{}, error) {
+			for _, n := range nodes {
+				value, err := eval(n, env)
+				if err != nil {
+					return nil, err
+				}
+				if value != nil && value != "" {
+					return value, nil
+				}
+			}
+			return nil, nil
+		},
+	},
+
+	// lookups on the associated instances, asst returns the value of the first associated instance,
+	// and assts returns the values of all the associated instances. the model and the field are strings.
+	"asst": {
+		minArgs: 2,
+		maxArgs: 2,
+		impl: func(env Env, args []interface{}, _ []node) (interface{}, error) {
+			values, err := associated(env, args)
+			if err != nil || len(values) == 0 {
+				return nil, err
+			}
+			return values[0], nil
+		},
+	},
+	"assts": {
+		minArgs: 2,
+		maxArgs: 2,
+		impl: func(env Env, args []interface{}, _ []node) (interface{}, error) {
+			return associated(env, args)
+		},
+	},
+}
@@ -0,0 +1,123 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// num the value of the number token
+	num float64
+	pos int
+}
+
+// operators the operators sorted by length, so that the longest one is matched first.
+var operators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"+", "-", "*", "/", "%", "<", ">", "!", "?", ":", "(", ")", ",",
+}
+
+// tokenize split the expression into tokens, the last token is always tokenEOF.
+func tokenize(src string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(src)
+	for pos := 0; pos < len(runes); {
+		r := runes[pos]
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+
+		case unicode.IsDigit(r) || (r == '.' && pos+1 < len(runes) && unicode.IsDigit(runes[pos+1])):
+			start := pos
+			for pos < len(runes) && (unicode.IsDigit(runes[pos]) || runes[pos] == '.') {
+				pos++
+			}
+			text := string(runes[start:pos])
+			num, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %s at %d", text, start)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, num: num, pos: start})
+
+		case r == '"' || r == '\'':
+			start := pos
+			pos++
+			var sb strings.Builder
+			closed := false
+			for pos < len(runes) {
+				c := runes[pos]
+				if c == '\\' && pos+1 < len(runes) {
+					pos++
+					switch runes[pos] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					default:
+						sb.WriteRune(runes[pos])
+					}
+					pos++
+					continue
+				}
+				pos++
+				if c == r {
+					closed = true
+					break
+				}
+				sb.WriteRune(c)
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := pos
+			for pos < len(runes) && (unicode.IsLetter(runes[pos]) || unicode.IsDigit(runes[pos]) || runes[pos] == '_') {
+				pos++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:pos]), pos: start})
+
+		default:
+			matched := ""
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[pos:]), op) {
+					matched = op
+					break
+				}
+			}
+			if len(matched) == 0 {
+				return nil, fmt.Errorf("unexpected character %q at %d", r, pos)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: matched, pos: pos})
+			pos += len([]rune(matched))
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
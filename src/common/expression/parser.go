@@ -0,0 +1,325 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// MaxLength the max length of an expression
+	MaxLength = 4096
+	// maxDepth the max nesting depth of an expression
+	maxDepth = 64
+)
+
+type node interface{}
+
+type literalNode struct {
+	value interface{}
+}
+
+type fieldNode struct {
+	name string
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+type binaryNode struct {
+	op   string
+	x, y node
+}
+
+type condNode struct {
+	cond, x, y node
+}
+
+type callNode struct {
+	name string
+	fn   *function
+	args []node
+}
+
+// binaryPrecedence the precedence of the binary operators, the greater binds tighter.
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+// Expression a parsed expression, it is safe to be evaluated concurrently.
+type Expression struct {
+	src          string
+	root         node
+	fields       []string
+	associations []Association
+}
+
+// Association a field of the associated instances of a model, which is looked up by the expression
+type Association struct {
+	ObjectID string
+	Field    string
+}
+
+// Parse parse the expression. the expression supports the number, string, true, false and null
+// literals, the fields of the instance, the arithmetic, comparison and logical operators, the
+// conditional operator "cond ? x : y" and the builtin functions, such as asst("host", "bk_host_innerip").
+func Parse(src string) (*Expression, error) {
+	if len(strings.TrimSpace(src)) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	if len(src) > MaxLength {
+		return nil, fmt.Errorf("the expression is longer than %d", MaxLength)
+	}
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, fields: make(map[string]bool), associations: make(map[Association]bool)}
+	root, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at %d", tok.text, tok.pos)
+	}
+
+	fields := make([]string, 0, len(p.fields))
+	for field := range p.fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	associations := make([]Association, 0, len(p.associations))
+	for asst := range p.associations {
+		associations = append(associations, asst)
+	}
+	sort.Slice(associations, func(i, j int) bool {
+		if associations[i].ObjectID != associations[j].ObjectID {
+			return associations[i].ObjectID < associations[j].ObjectID
+		}
+		return associations[i].Field < associations[j].Field
+	})
+	return &Expression{src: src, root: root, fields: fields, associations: associations}, nil
+}
+
+// String returns the source of the expression
+func (e *Expression) String() string {
+	return e.src
+}
+
+// Fields returns the fields of the instance which are referenced by the expression
+func (e *Expression) Fields() []string {
+	return e.fields
+}
+
+// Associations returns the fields of the associated instances which are looked up by the expression
+func (e *Expression) Associations() []Association {
+	return e.associations
+}
+
+type parser struct {
+	tokens       []token
+	pos          int
+	depth        int
+	fields       map[string]bool
+	associations map[Association]bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(op string) error {
+	tok := p.next()
+	if tok.kind != tokenOperator || tok.text != op {
+		return fmt.Errorf("expect %s at %d", op, tok.pos)
+	}
+	return nil
+}
+
+func (p *parser) isOperator(op string) bool {
+	tok := p.peek()
+	return tok.kind == tokenOperator && tok.text == op
+}
+
+// parseExpr parse the conditional expression, whose binary operators bind tighter than the min precedence.
+func (p *parser) parseExpr(minPrecedence int) (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, fmt.Errorf("the expression is nested deeper than %d", maxDepth)
+	}
+
+	x, err := p.parseBinary(minPrecedence)
+	if err != nil {
+		return nil, err
+	}
+	if minPrecedence > 0 || !p.isOperator("?") {
+		return x, nil
+	}
+
+	p.next()
+	y, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	z, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	return &condNode{cond: x, x: y, y: z}, nil
+}
+
+func (p *parser) parseBinary(minPrecedence int) (node, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		precedence, ok := binaryPrecedence[tok.text]
+		if tok.kind != tokenOperator || !ok || precedence <= minPrecedence {
+			return x, nil
+		}
+		p.next()
+		y, err := p.parseExpr(precedence)
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryNode{op: tok.text, x: x, y: y}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOperator("!") || p.isOperator("-") {
+		op := p.next().text
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxDepth {
+			return nil, fmt.Errorf("the expression is nested deeper than %d", maxDepth)
+		}
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		return &literalNode{value: tok.num}, nil
+	case tokenString:
+		return &literalNode{value: tok.text}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if !p.isOperator("(") {
+			p.fields[tok.text] = true
+			return &fieldNode{name: tok.text}, nil
+		}
+		return p.parseCall(tok)
+	case tokenOperator:
+		if tok.text == "(" {
+			x, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of the expression")
+	}
+	return nil, fmt.Errorf("unexpected %s at %d", tok.text, tok.pos)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, exist := functions[strings.ToLower(name.text)]
+	if !exist {
+		return nil, fmt.Errorf("unknown function %s at %d", name.text, name.pos)
+	}
+	p.next()
+
+	args := make([]node, 0)
+	if !p.isOperator(")") {
+		for {
+			arg, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if !p.isOperator(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("wrong number of arguments of function %s at %d", name.text, name.pos)
+	}
+	if lower := strings.ToLower(name.text); lower == "asst" || lower == "assts" {
+		// the lookups are known before the evaluation, so that the instances which look up
+		// the changed instances could be found.
+		objID, isStr := literalString(args[0])
+		field, isFieldStr := literalString(args[1])
+		if !isStr || !isFieldStr || len(objID) == 0 || len(field) == 0 {
+			return nil, fmt.Errorf("the model and the field of function %s at %d should be strings", name.text, name.pos)
+		}
+		p.associations[Association{ObjectID: objID, Field: field}] = true
+	}
+	return &callNode{name: name.text, fn: fn, args: args}, nil
+}
+
+func literalString(n node) (string, bool) {
+	literal, ok := n.(*literalNode)
+	if !ok {
+		return "", false
+	}
+	value, ok := literal.value.(string)
+	return value, ok
+}
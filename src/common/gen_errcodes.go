@@ -0,0 +1,111 @@
+//go:build ignore
+// +build ignore
+
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// gen_errcodes generates errcodes.go with the CCErr* codes defined in errInfo.go, run it with go generate.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const license = `/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+`
+
+func main() {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "errInfo.go", nil, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "parse errInfo.go failed, err: %v\n", err)
+		os.Exit(1)
+	}
+
+	type errCode struct {
+		name string
+		code int
+	}
+	codes := make([]errCode, 0)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			for index, name := range value.Names {
+				if !strings.HasPrefix(name.Name, "CCErr") || index >= len(value.Values) {
+					continue
+				}
+				lit, ok := value.Values[index].(*ast.BasicLit)
+				if !ok || lit.Kind != token.INT {
+					continue
+				}
+				code, err := strconv.Atoi(lit.Value)
+				if err != nil {
+					continue
+				}
+				codes = append(codes, errCode{name: name.Name, code: code})
+			}
+		}
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if codes[i].code != codes[j].code {
+			return codes[i].code < codes[j].code
+		}
+		return codes[i].name < codes[j].name
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString(license)
+	buf.WriteString("// Code generated by gen_errcodes.go from errInfo.go; DO NOT EDIT.\n\n")
+	buf.WriteString("package common\n\n")
+	buf.WriteString("// CCErrorCodes the CCErr* codes defined in errInfo.go, the key is the name of the code.\n")
+	buf.WriteString("// the error packs are validated with them, run go generate after the codes are changed.\n")
+	buf.WriteString("var CCErrorCodes = map[string]int{\n")
+	for _, code := range codes {
+		fmt.Fprintf(&buf, "\t%q: %d,\n", code.name, code.code)
+	}
+	buf.WriteString("}\n")
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "format errcodes.go failed, err: %v\n", err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile("errcodes.go", source, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "write errcodes.go failed, err: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -20,12 +20,15 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
 	"icenter/src/common/blog"
 )
 
 // ccErrorHelper CC 错误处理接口的实现
 type ccLanguageHelper struct {
+	// lock the language packages are replaced when they're reloaded from the config center
+	lock sync.RWMutex
 	lang map[string]LanguageMap // the key is a language code, for：en,cn,jp,us etc.
 }
 
@@ -53,7 +56,9 @@ func (cli *ccLanguageHelper) Languagef(language string, key string, args ...inte
 // load load language package file from dir
 func (cli *ccLanguageHelper) Load(lang map[string]LanguageMap) {
 	// blog.V(3).Infof("loaded language resource: %#v", lang)
+	cli.lock.Lock()
 	cli.lang = lang
+	cli.lock.Unlock()
 }
 
 // LoadLanguageResourceFromDir  load language resource from file
@@ -113,15 +118,18 @@ func LoadLanguageResourceFromDir(dir string) (map[string]LanguageMap, error) {
 }
 
 func (cli *ccLanguageHelper) GetLang() map[string]LanguageMap {
+	cli.lock.RLock()
+	defer cli.lock.RUnlock()
 	return cli.lang
 }
 
 // getLanguageKey get error code manager
 func (cli *ccLanguageHelper) getLanguageKey(language string) LanguageMap {
-	codemgr, ok := cli.lang[language]
+	lang := cli.GetLang()
+	codemgr, ok := lang[language]
 	if !ok && language != defaultLanguage {
 		// when the specified language not found, find it from default language package
-		codemgr, ok = cli.lang[defaultLanguage]
+		codemgr, ok = lang[defaultLanguage]
 		if !ok {
 			return nil
 		}
@@ -34,6 +34,9 @@ const (
 	// AssociationFieldAssociationId auto incr id
 	AssociationFieldAssociationId   = "id"
 	AssociationFieldAssociationKind = "bk_asst_id"
+	// AssociationFieldAttributes the attributes of the association kinds and the model associations, and the
+	// attribute values of the instance associations
+	AssociationFieldAttributes = "attributes"
 )
 
 type SearchAssociationTypeRequest struct {
@@ -55,10 +58,11 @@ type CreateAssociationTypeResult struct {
 }
 
 type UpdateAssociationTypeRequest struct {
-	AsstName  string `field:"bk_asst_name" json:"bk_asst_name" bson:"bk_asst_name"`
-	SrcDes    string `field:"src_des" json:"src_des" bson:"src_des"`
-	DestDes   string `field:"dest_des" json:"dest_des" bson:"dest_des"`
-	Direction string `field:"direction" json:"direction" bson:"direction"`
+	AsstName   string                 `field:"bk_asst_name" json:"bk_asst_name" bson:"bk_asst_name"`
+	SrcDes     string                 `field:"src_des" json:"src_des" bson:"src_des"`
+	DestDes    string                 `field:"dest_des" json:"dest_des" bson:"dest_des"`
+	Direction  string                 `field:"direction" json:"direction" bson:"direction"`
+	Attributes []AssociationAttribute `field:"attributes" json:"attributes,omitempty" bson:"attributes,omitempty"`
 }
 
 type UpdateAssociationTypeResult struct {
@@ -86,7 +90,8 @@ type CreateAssociationObjectResult struct {
 }
 
 type UpdateAssociationObjectRequest struct {
-	AsstName string `field:"bk_asst_name" json:"bk_asst_name" bson:"bk_asst_name"`
+	AsstName   string                 `field:"bk_asst_name" json:"bk_asst_name" bson:"bk_asst_name"`
+	Attributes []AssociationAttribute `field:"attributes" json:"attributes,omitempty" bson:"attributes,omitempty"`
 }
 
 type UpdateAssociationObjectResult struct {
@@ -120,9 +125,10 @@ type SearchAssociationInstResult struct {
 }
 
 type CreateAssociationInstRequest struct {
-	ObjectAsstID string `field:"bk_obj_asst_id" json:"bk_obj_asst_id,omitempty" bson:"bk_obj_asst_id,omitempty"`
-	InstID       int64  `field:"bk_inst_id" json:"bk_inst_id,omitempty" bson:"bk_inst_id,omitempty"`
-	AsstInstID   int64  `field:"bk_asst_inst_id" json:"bk_asst_inst_id,omitempty" bson:"bk_asst_inst_id,omitempty"`
+	ObjectAsstID string        `field:"bk_obj_asst_id" json:"bk_obj_asst_id,omitempty" bson:"bk_obj_asst_id,omitempty"`
+	InstID       int64         `field:"bk_inst_id" json:"bk_inst_id,omitempty" bson:"bk_inst_id,omitempty"`
+	AsstInstID   int64         `field:"bk_asst_inst_id" json:"bk_asst_inst_id,omitempty" bson:"bk_asst_inst_id,omitempty"`
+	Attributes   mapstr.MapStr `field:"attributes" json:"attributes,omitempty" bson:"attributes,omitempty"`
 }
 type CreateAssociationInstResult struct {
 	BaseResp `json:",inline"`
@@ -186,6 +192,8 @@ type AssociationKind struct {
 	Direction AssociationDirection `field:"direction" json:"direction" bson:"direction"`
 	// whether this is a pre-defined kind.
 	IsPre *bool `field:"ispre" json:"ispre" bson:"ispre"`
+	// the attributes of the instance associations of this kind, e.g. the port of a connection.
+	Attributes []AssociationAttribute `field:"attributes" json:"attributes,omitempty" bson:"attributes,omitempty"`
 	//	define the metadata of association kind
 	Metadata `field:"metadata" json:"metadata" bson:"metadata"`
 }
@@ -200,6 +208,17 @@ func (cli *AssociationKind) Parse(data mapstr.MapStr) (*AssociationKind, error)
 	return cli, err
 }
 
+// AssociationAttribute an attribute of the instance associations, which is declared by the association kind or the
+// model association, e.g. the port of a "connect" association or the weight of a "depend" association.
+type AssociationAttribute struct {
+	PropertyID   string `field:"bk_property_id" json:"bk_property_id" bson:"bk_property_id"`
+	PropertyName string `field:"bk_property_name" json:"bk_property_name" bson:"bk_property_name"`
+	// one of singlechar, longchar, int, float, enum, date, time and bool
+	PropertyType string      `field:"bk_property_type" json:"bk_property_type" bson:"bk_property_type"`
+	Option       interface{} `field:"option" json:"option" bson:"option"`
+	IsRequired   bool        `field:"isrequired" json:"isrequired" bson:"isrequired"`
+}
+
 type AssociationOnDeleteAction string
 type AssociationMapping string
 
@@ -244,6 +263,9 @@ type Association struct {
 	// describe whether this association is a pre-defined association or not,
 	// if true, it means this association is used by cmdb itself.
 	IsPre *bool `field:"ispre" json:"ispre" bson:"ispre"`
+	// the attributes of the instance associations, which override the attributes with the same id of the
+	// association kind.
+	Attributes []AssociationAttribute `field:"attributes" json:"attributes,omitempty" bson:"attributes,omitempty"`
 
 	ClassificationID string `field:"bk_classification_id" json:"-" bson:"-"`
 	ObjectIcon       string `field:"bk_obj_icon" json:"-" bson:"-"`
@@ -321,6 +343,8 @@ type InstAsst struct {
 	ObjectAsstID string `field:"bk_obj_asst_id" json:"bk_obj_asst_id" bson:"bk_obj_asst_id"`
 	// association kind id
 	AssociationKindID string `field:"bk_asst_id" json:"bk_asst_id" bson:"bk_asst_id"`
+	// the values of the attributes declared by the association kind and the model association
+	Attributes mapstr.MapStr `field:"attributes" json:"attributes,omitempty" bson:"attributes,omitempty"`
 
 	//	define the metadata of assocication kind
 	Metadata `field:"metadata" json:"metadata" bson:"metadata"`
@@ -0,0 +1,141 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+// the kinds of the data quality rules
+const (
+	// QualityRuleRequired the property of the instances can not be empty
+	QualityRuleRequired = "required"
+	// QualityRuleRegex the non empty values of the property must match the pattern
+	QualityRuleRegex = "regex"
+	// QualityRuleStale the instances must be updated in the stale days, by the last_time
+	QualityRuleStale = "stale"
+	// QualityRuleOrphanAssociation the instances at both sides of the associations in cc_InstAsst must exist
+	QualityRuleOrphanAssociation = "orphan_association"
+	// QualityRuleUnique the non empty values of the property are unique among the instances of all the owners
+	QualityRuleUnique = "unique"
+)
+
+// the statuses of the data quality violations
+const (
+	// QualityViolationOpen the violation is found and not handled yet
+	QualityViolationOpen = "open"
+	// QualityViolationAcknowledged the violation is known and accepted, it's not counted in the score
+	QualityViolationAcknowledged = "acknowledged"
+	// QualityViolationFixed the violation is fixed by the fix api
+	QualityViolationFixed = "fixed"
+	// QualityViolationResolved the violation is not found by the later checks any more
+	QualityViolationResolved = "resolved"
+)
+
+// QualityRule the data quality rule of the instances of a model
+type QualityRule struct {
+	ID       int64  `json:"id" bson:"id"`
+	Name     string `json:"name" bson:"name"`
+	ObjectID string `json:"bk_obj_id" bson:"bk_obj_id"`
+	// Kind one of required, regex, stale, orphan_association and unique
+	Kind string `json:"kind" bson:"kind"`
+	// PropertyID the property checked by the required, regex and unique rules
+	PropertyID string `json:"bk_property_id" bson:"bk_property_id"`
+	// Pattern the regular expression of the regex rule
+	Pattern string `json:"pattern" bson:"pattern"`
+	// StaleDays the instances not updated in the days violate the stale rule
+	StaleDays int `json:"stale_days" bson:"stale_days"`
+	// Weight the weight of the rule in the score, the default is 1.
+	Weight float64 `json:"weight" bson:"weight"`
+	// FixValue the value the property is set to when the violations of the required or
+	// regex rule are fixed, the violations can not be fixed without it.
+	FixValue   interface{} `json:"fix_value" bson:"fix_value"`
+	Enabled    bool        `json:"enabled" bson:"enabled"`
+	OwnerID    string      `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string      `json:"creator" bson:"creator"`
+	CreateTime time.Time   `json:"create_time" bson:"create_time"`
+	LastTime   time.Time   `json:"last_time" bson:"last_time"`
+}
+
+// QualityViolation an instance or an association violating a data quality rule
+type QualityViolation struct {
+	ID       int64  `json:"id" bson:"id"`
+	RuleID   int64  `json:"rule_id" bson:"rule_id"`
+	Kind     string `json:"kind" bson:"kind"`
+	ObjectID string `json:"bk_obj_id" bson:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id" bson:"bk_inst_id"`
+	// AsstID the id of the association in cc_InstAsst violating the orphan association rule
+	AsstID     int64       `json:"asst_id" bson:"asst_id"`
+	BizID      int64       `json:"bk_biz_id" bson:"bk_biz_id"`
+	PropertyID string      `json:"bk_property_id" bson:"bk_property_id"`
+	Value      interface{} `json:"value" bson:"value"`
+	Message    string      `json:"message" bson:"message"`
+	Status     string      `json:"status" bson:"status"`
+	Operator   string      `json:"operator" bson:"operator"`
+	OwnerID    string      `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	// CreateTime the time the violation is found first
+	CreateTime time.Time `json:"create_time" bson:"create_time"`
+	// CheckTime the time the violation is found by the latest check
+	CheckTime time.Time `json:"check_time" bson:"check_time"`
+	LastTime  time.Time `json:"last_time" bson:"last_time"`
+}
+
+// QualityScore the data quality score of a business, which is the percent of the weighted checks
+// passed by the instances of the business. the instances of no business are scored as business 0.
+type QualityScore struct {
+	BizID   int64   `json:"bk_biz_id" bson:"bk_biz_id"`
+	OwnerID string  `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Score   float64 `json:"score" bson:"score"`
+	// Checks the weighted count of the rules checked on the instances
+	Checks float64 `json:"checks" bson:"checks"`
+	// Violations the count of the open violations
+	Violations int64     `json:"violations" bson:"violations"`
+	CheckTime  time.Time `json:"check_time" bson:"check_time"`
+}
+
+// SearchQualityViolationsRequest search the violations, the empty fields match all.
+type SearchQualityViolationsRequest struct {
+	RuleID   int64  `json:"rule_id"`
+	ObjectID string `json:"bk_obj_id"`
+	BizID    *int64 `json:"bk_biz_id"`
+	Status   string `json:"status"`
+	// Page the default sort is by the id
+	Page BasePage `json:"page"`
+}
+
+// SearchQualityViolationsResult the violations and the total count
+type SearchQualityViolationsResult struct {
+	Count uint64             `json:"count"`
+	Info  []QualityViolation `json:"info"`
+}
+
+// QualityViolationIDs the ids of the violations to acknowledge or fix
+type QualityViolationIDs struct {
+	IDs []int64 `json:"ids"`
+}
+
+// QualityFixResult the result of fixing a violation
+type QualityFixResult struct {
+	ID      int64  `json:"id"`
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// QualityCheckResult the summary of a check round
+type QualityCheckResult struct {
+	Rules      int            `json:"rules"`
+	Violations int64          `json:"violations"`
+	Scores     []QualityScore `json:"scores"`
+	StartTime  time.Time      `json:"start_time"`
+	EndTime    time.Time      `json:"end_time"`
+}
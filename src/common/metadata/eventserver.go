@@ -193,3 +193,55 @@ func (n *ConfirmMode) Scan(value interface{}) error {
 func (n ConfirmMode) Value() (driver.Value, error) {
 	return string(n), nil
 }
+
+// EventLog the event persisted in the event log table, which is ordered by the event id.
+type EventLog struct {
+	ID         int64     `json:"event_id" bson:"event_id"`
+	TxnID      string    `json:"txn_id" bson:"txn_id"`
+	EventType  string    `json:"event_type" bson:"event_type"`
+	Action     string    `json:"action" bson:"action"`
+	ObjType    string    `json:"obj_type" bson:"obj_type"`
+	OwnerID    string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Pushed     bool      `json:"pushed" bson:"pushed"`
+	Data       string    `json:"data" bson:"data"` // the json format event
+	CreateTime time.Time `json:"create_time" bson:"create_time"`
+}
+
+// EventConsumerOffset the last event id that a consumer has consumed
+type EventConsumerOffset struct {
+	Consumer string `json:"consumer" bson:"consumer"`
+	Offset   int64  `json:"offset" bson:"offset"`
+	// Settled the events before it are all consumed, and no more events before it could be visible.
+	Settled int64 `json:"settled" bson:"settled"`
+	// Consumed the ids of the consumed events after the settled id
+	Consumed []int64 `json:"consumed" bson:"consumed"`
+	// Delivered the ids of the events read by the consumer, but not committed yet
+	Delivered []int64   `json:"delivered" bson:"delivered"`
+	LastTime  time.Time `json:"last_time" bson:"last_time"`
+}
+
+// ReadEventStreamParams read the events after the event id from the event log,
+// the consumer's stored offset is used when the from id is not set.
+type ReadEventStreamParams struct {
+	Consumer string `json:"consumer"`
+	FromID   *int64 `json:"from_id"`
+	Limit    int64  `json:"limit"`
+}
+
+// ReadEventStreamResult the events read from the event log
+type ReadEventStreamResult struct {
+	Offset int64       `json:"offset"`
+	Info   []EventInst `json:"info"`
+}
+
+// ReadEventStreamResponse the response of read event stream
+type ReadEventStreamResponse struct {
+	BaseResp `json:",inline"`
+	Data     ReadEventStreamResult `json:"data"`
+}
+
+// CommitEventOffsetParams save the last consumed event id of the consumer
+type CommitEventOffsetParams struct {
+	Consumer string `json:"consumer"`
+	Offset   int64  `json:"offset"`
+}
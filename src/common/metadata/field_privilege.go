@@ -0,0 +1,44 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+const (
+	// FieldMaskRedact the value of the property is replaced with FieldRedactedValue for the users who can not read it
+	FieldMaskRedact = "redact"
+	// FieldMaskOmit the property is removed for the users who can not read it
+	FieldMaskOmit = "omit"
+
+	// FieldRedactedValue the value of the redacted properties
+	FieldRedactedValue = "******"
+)
+
+// FieldPrivilege the user groups which can read or write a sensitive property of a model,
+// the other users can not read or write it.
+type FieldPrivilege struct {
+	OwnerID    string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	ObjectID   string `json:"bk_obj_id" bson:"bk_obj_id"`
+	PropertyID string `json:"bk_property_id" bson:"bk_property_id"`
+	// ReadGroups the user groups which can read the property
+	ReadGroups []string `json:"read_groups" bson:"read_groups"`
+	// WriteGroups the user groups which can read and write the property
+	WriteGroups []string `json:"write_groups" bson:"write_groups"`
+	// Mask how the property is hidden from the other users, redact or omit, the default is redact.
+	Mask       string    `json:"mask" bson:"mask"`
+	Modifier   string    `json:"modifier" bson:"modifier"`
+	CreateTime time.Time `json:"create_time" bson:"create_time"`
+	LastTime   time.Time `json:"last_time" bson:"last_time"`
+}
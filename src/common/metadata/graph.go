@@ -0,0 +1,89 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+const (
+	// GraphDirectionOut traverse from the association source to the target
+	GraphDirectionOut = "out"
+	// GraphDirectionIn traverse from the association target to the source
+	GraphDirectionIn = "in"
+	// GraphDirectionBoth traverse the associations in both directions
+	GraphDirectionBoth = "both"
+
+	// GraphOutputSubgraph returns the de-duplicated nodes and edges reached
+	GraphOutputSubgraph = "subgraph"
+	// GraphOutputPaths returns every path from the start nodes
+	GraphOutputPaths = "paths"
+)
+
+// GraphNode an instance in the association graph
+type GraphNode struct {
+	ObjectID string `json:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id"`
+}
+
+// GraphHopFilter limit the associations a hop can walk through, empty means no limit.
+type GraphHopFilter struct {
+	// AssociationKinds the association kinds of the edge
+	AssociationKinds []string `json:"bk_asst_id"`
+	// ObjectIDs the object of the instance reached by the hop
+	ObjectIDs []string `json:"bk_obj_id"`
+}
+
+// GraphTraversalParams the params to traverse the instance associations
+type GraphTraversalParams struct {
+	Start []GraphNode `json:"start"`
+	// Direction is one of out, in and both, default is out.
+	Direction string `json:"direction"`
+	// Hops the filter of every hop in order, the hops beyond them use the last filter.
+	Hops     []GraphHopFilter `json:"hops"`
+	MaxDepth int              `json:"max_depth"`
+	// Output is one of subgraph and paths, default is subgraph.
+	Output string `json:"output"`
+	// Targets only the paths ending at the instances of these objects are returned in paths output.
+	Targets []string `json:"target_obj_ids"`
+	// Limit the max count of the nodes in subgraph output, or the paths in paths output,
+	// at most limit associations are looked up to walk the graph.
+	Limit int `json:"limit"`
+}
+
+// GraphNodeResult a node reached and its min depth from the start nodes
+type GraphNodeResult struct {
+	GraphNode `json:",inline"`
+	Depth     int `json:"depth"`
+}
+
+// GraphEdge an instance association reached and its min depth from the start nodes
+type GraphEdge struct {
+	InstAsst `json:",inline"`
+	Depth    int `json:"depth"`
+}
+
+// GraphPath a path from a start node, the edges are the association ids between the nodes.
+type GraphPath struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []int64     `json:"edges"`
+}
+
+// GraphTraversalResult the subgraph or the paths reached, truncated means the limit is reached.
+type GraphTraversalResult struct {
+	Nodes     []GraphNodeResult `json:"nodes"`
+	Edges     []GraphEdge       `json:"edges"`
+	Paths     []GraphPath       `json:"paths"`
+	Truncated bool              `json:"truncated"`
+}
+
+type GraphTraversalResponse struct {
+	BaseResp `json:",inline"`
+	Data     GraphTraversalResult `json:"data"`
+}
@@ -0,0 +1,77 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+
+	"icenter/src/common/mapstr"
+)
+
+// InstanceVersion one version of an instance, which is rebuilt from an audit log
+type InstanceVersion struct {
+	Version  int           `json:"version"`
+	OpType   int           `json:"op_type"`
+	OpDesc   string        `json:"op_desc"`
+	Operator string        `json:"operator"`
+	OpTime   time.Time     `json:"op_time"`
+	Data     mapstr.MapStr `json:"data"`
+}
+
+// InstanceVersionsResult the versions of an instance in time order
+type InstanceVersionsResult struct {
+	Count int               `json:"count"`
+	Info  []InstanceVersion `json:"info"`
+}
+
+// SearchInstanceVersionsResponse the response of search instance versions
+type SearchInstanceVersionsResponse struct {
+	BaseResp `json:",inline"`
+	Data     InstanceVersionsResult `json:"data"`
+}
+
+// InstanceAsOfParams get an instance as of the time
+type InstanceAsOfParams struct {
+	AsOf time.Time `json:"as_of"`
+}
+
+// InstanceAsOfResponse the response of get an instance as of a time
+type InstanceAsOfResponse struct {
+	BaseResp `json:",inline"`
+	Data     mapstr.MapStr `json:"data"`
+}
+
+// MainlineTopoAsOfParams search the mainline instance topo as of the time
+type MainlineTopoAsOfParams struct {
+	AsOf       time.Time `json:"as_of"`
+	WithDetail bool      `json:"with_detail"`
+}
+
+// InstSnapshot the full snapshot of an instance, which is taken periodically
+// so that the history can be rebuilt without replaying all the audit logs.
+type InstSnapshot struct {
+	SnapshotID int64         `json:"snapshot_id" bson:"snapshot_id"`
+	ObjectID   string        `json:"bk_obj_id" bson:"bk_obj_id"`
+	InstID     int64         `json:"inst_id" bson:"inst_id"`
+	OwnerID    string        `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Data       mapstr.MapStr `json:"data" bson:"data"`
+}
+
+// InstSnapshotBatch a batch of the instance snapshots, the snapshots of all the instances
+// are taken between the start time and the finish time.
+type InstSnapshotBatch struct {
+	SnapshotID int64     `json:"snapshot_id" bson:"snapshot_id"`
+	StartTime  time.Time `json:"start_time" bson:"start_time"`
+	FinishTime time.Time `json:"finish_time" bson:"finish_time"`
+	Count      int64     `json:"count" bson:"count"`
+}
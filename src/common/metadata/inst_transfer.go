@@ -0,0 +1,54 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import "icenter/src/common/mapstr"
+
+const (
+	// InstImportActionCreate the row is created as a new instance
+	InstImportActionCreate = "create"
+	// InstImportActionUpdate the row updates the instance with the same unique keys
+	InstImportActionUpdate = "update"
+	// InstImportActionFailed the row is not imported
+	InstImportActionFailed = "failed"
+)
+
+// InstImportResult the result of importing the instances of a model from a spreadsheet
+type InstImportResult struct {
+	DryRun  bool `json:"dry_run"`
+	Total   int  `json:"total"`
+	Created int  `json:"created"`
+	Updated int  `json:"updated"`
+	Failed  int  `json:"failed"`
+	// UniqueKeys the property ids the rows are matched by
+	UniqueKeys []string `json:"unique_keys"`
+	// IgnoredColumns the header of the columns which are not mapped to a writable attribute,
+	// or mapped to a sensitive attribute that the user can not write.
+	IgnoredColumns []string        `json:"ignored_columns"`
+	Rows           []InstImportRow `json:"rows"`
+}
+
+// InstImportRow the import result of a row, the row number starts from 1 and the header is the first row.
+type InstImportRow struct {
+	Row    int    `json:"row"`
+	Action string `json:"action"`
+	InstID int64  `json:"bk_inst_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// InstExportRequest the instances matching the condition are exported with the fields,
+// all the attributes are exported if the fields are not set.
+type InstExportRequest struct {
+	Condition mapstr.MapStr `json:"condition"`
+	Fields    []string      `json:"fields"`
+}
@@ -0,0 +1,91 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metadata
+
+import (
+	"icenter/src/common/mapstr"
+)
+
+const (
+	// AggregateCount count the instances of the group
+	AggregateCount = "count"
+	// AggregateSum sum the values of a numeric property
+	AggregateSum = "sum"
+	// AggregateAvg average the values of a numeric property
+	AggregateAvg = "avg"
+	// AggregateMin the min value of a numeric property
+	AggregateMin = "min"
+	// AggregateMax the max value of a numeric property
+	AggregateMax = "max"
+
+	// AggregateIntervalDay bucket the date property by day, the key is like 2019-01-02
+	AggregateIntervalDay = "day"
+	// AggregateIntervalWeek bucket the date property by iso week, the key is like 2019-W01
+	AggregateIntervalWeek = "week"
+	// AggregateIntervalMonth bucket the date property by month, the key is like 2019-01
+	AggregateIntervalMonth = "month"
+	// AggregateIntervalYear bucket the date property by year, the key is like 2019
+	AggregateIntervalYear = "year"
+)
+
+// AggregateGroupBy a property to group the instances by, the date properties can be bucketed by the interval.
+type AggregateGroupBy struct {
+	Field    string `json:"field"`
+	Interval string `json:"interval"`
+}
+
+// AggregateMetric the function applied to a numeric property of the instances of a group,
+// the field is not needed by count.
+type AggregateMetric struct {
+	Func  string `json:"func"`
+	Field string `json:"field"`
+}
+
+// Name the key of the metric in the result, such as count and sum_bk_cpu.
+func (m AggregateMetric) Name() string {
+	if m.Func == AggregateCount || m.Field == "" {
+		return m.Func
+	}
+	return m.Func + "_" + m.Field
+}
+
+// AggregateInstanceParams the params to aggregate the instances of a model
+type AggregateInstanceParams struct {
+	// Condition filter the instances before they are grouped
+	Condition mapstr.MapStr `json:"condition"`
+	// BizID limit the instances to the business if it's not zero
+	BizID   int64              `json:"bk_biz_id"`
+	GroupBy []AggregateGroupBy `json:"group_by"`
+	// Metrics default is the count of the instances
+	Metrics []AggregateMetric `json:"metrics"`
+	// Limit the max count of the groups returned, default is 1000
+	Limit int `json:"limit"`
+}
+
+// AggregateInstanceGroup a group of the instances, the key is the value of every group by field,
+// the names are the display names of the enum options and the business ids in the key.
+type AggregateInstanceGroup struct {
+	Key     mapstr.MapStr `json:"key"`
+	Names   mapstr.MapStr `json:"names"`
+	Metrics mapstr.MapStr `json:"metrics"`
+}
+
+// AggregateInstanceResult the groups ordered by the key, truncated means the limit is reached.
+type AggregateInstanceResult struct {
+	Groups    []AggregateInstanceGroup `json:"groups"`
+	Truncated bool                     `json:"truncated"`
+}
+
+type AggregateInstanceResponse struct {
+	BaseResp `json:",inline"`
+	Data     AggregateInstanceResult `json:"data"`
+}
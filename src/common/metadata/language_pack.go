@@ -0,0 +1,72 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+const (
+	// LanguagePackError the error message packs, the keys are the error codes.
+	LanguagePackError = "error"
+	// LanguagePackLanguage the ui and other language string packs
+	LanguagePackLanguage = "language"
+)
+
+// LanguagePack a version of the error messages or the language strings of a language,
+// the active version overrides the pack in the resource files.
+type LanguagePack struct {
+	Kind string `json:"kind" bson:"kind"`
+	// Language the language code, such as default, en, zh-TW or ja
+	Language string `json:"language" bson:"language"`
+	Version  int64  `json:"version" bson:"version"`
+	Active   bool   `json:"active" bson:"active"`
+	// Entries the messages, the key is the error code or the language key.
+	Entries map[string]string `json:"entries,omitempty" bson:"-"`
+	// EntriesJSON the json format entries saved in db, as the keys may contain dots.
+	EntriesJSON string    `json:"-" bson:"entries"`
+	Comment     string    `json:"comment" bson:"comment"`
+	Creator     string    `json:"creator" bson:"creator"`
+	CreateTime  time.Time `json:"create_time" bson:"create_time"`
+}
+
+// LanguagePackReport the result of validating a pack
+type LanguagePackReport struct {
+	Kind     string `json:"kind"`
+	Language string `json:"language"`
+	// Version the active version, 0 means the pack is loaded from the resource files.
+	Version int64 `json:"version"`
+	Count   int   `json:"count"`
+	// Missing the error codes in errInfo.go or the keys in the default language which are not translated
+	Missing []string `json:"missing"`
+	// Unknown the keys which are not error codes, or not in the default language
+	Unknown []string `json:"unknown"`
+	// FormatMismatch the keys whose format verbs are different from the default language
+	FormatMismatch []string `json:"format_mismatch"`
+}
+
+// SaveLanguagePackRequest save a new version of a pack
+type SaveLanguagePackRequest struct {
+	Entries map[string]string `json:"entries"`
+	// Merge the entries are merged into the current pack, otherwise they replace it.
+	Merge   bool   `json:"merge"`
+	Comment string `json:"comment"`
+	// DryRun only returns the validation report, nothing is saved.
+	DryRun bool `json:"dry_run"`
+}
+
+// SaveLanguagePackResult the result of saving a pack
+type SaveLanguagePackResult struct {
+	Version int64              `json:"version"`
+	Report  LanguagePackReport `json:"report"`
+}
@@ -28,6 +28,8 @@ type OperationLog struct {
 	ExtInfo       string      `bson:"ext_info"            json:"ext_info"`
 	CreateTime    time.Time   `bson:"op_time"         json:"op_time"`
 	InstID        int64       `bson:"inst_id"             json:"inst_id"`
+	ID            int64       `bson:"id"                  json:"id"`
+	RequestID     string      `bson:"rid"                 json:"rid"`
 }
 
 // TableName return the table name
@@ -0,0 +1,153 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+
+	"icenter/src/common/mapstr"
+)
+
+const (
+	// NotificationChannelEmail the notifications are sent by email through the smtp server
+	NotificationChannelEmail = "email"
+	// NotificationChannelHTTP the notifications are posted to the http url of the user or the global one
+	NotificationChannelHTTP = "http"
+
+	// NotificationPending the notification is waiting for its digest window, the quiet hours or a retry
+	NotificationPending = "pending"
+	// NotificationFailed the notification is failed after all the retries
+	NotificationFailed = "failed"
+)
+
+// NotificationRule notify the users when the properties of the instances of a model are changed
+type NotificationRule struct {
+	ID   int64  `json:"id" bson:"id"`
+	Name string `json:"name" bson:"name"`
+	// ObjectID the model of the instances, such as host, set or the custom model ids
+	ObjectID string `json:"bk_obj_id" bson:"bk_obj_id"`
+	// PropertyIDs the rule matches when any of the properties is changed
+	PropertyIDs []string `json:"bk_property_ids" bson:"bk_property_ids"`
+	// Actions the actions of the events, create, update or delete, the empty list matches all.
+	Actions []string `json:"actions" bson:"actions"`
+	// Condition the mongodb style condition on the changed data, such as {"cur_data.bk_service_status": "2"}
+	Condition mapstr.MapStr `json:"condition" bson:"-"`
+	// ConditionJSON the json format condition saved in db, as the operator can not be the key of a mongodb document.
+	ConditionJSON string `json:"-" bson:"condition"`
+	// Channels the channels the notifications are sent through, email or http
+	Channels []string `json:"channels" bson:"channels"`
+	// Recipients the user names notified of every change
+	Recipients []string `json:"recipients" bson:"recipients"`
+	// RecipientFields the properties of the instance whose values are the users to notify, such as operator and bk_bak_operator
+	RecipientFields []string `json:"recipient_fields" bson:"recipient_fields"`
+	// DigestMinutes the changes in the minutes are sent in one message, 0 means they're sent at once.
+	DigestMinutes int       `json:"digest_minutes" bson:"digest_minutes"`
+	Enabled       bool      `json:"enabled" bson:"enabled"`
+	OwnerID       string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator       string    `json:"creator" bson:"creator"`
+	CreateTime    time.Time `json:"create_time" bson:"create_time"`
+	LastTime      time.Time `json:"last_time" bson:"last_time"`
+}
+
+// NotificationSetting the notification preference of a user
+type NotificationSetting struct {
+	User string `json:"bk_username" bson:"bk_username"`
+	// Email the email address, the default one is the user name at the configured email domain.
+	Email string `json:"email" bson:"email"`
+	// HTTPURL the url the http notifications are posted to, the default one is the configured url.
+	HTTPURL string `json:"http_url" bson:"http_url"`
+	// OptOutAll the user receives no notification
+	OptOutAll bool `json:"opt_out_all" bson:"opt_out_all"`
+	// OptOutRules the rules the user receives no notification of
+	OptOutRules []int64 `json:"opt_out_rules" bson:"opt_out_rules"`
+	// QuietHours the notifications are held during the quiet hours, and sent after them.
+	QuietHours *NotificationQuietHours `json:"quiet_hours" bson:"quiet_hours"`
+	OwnerID    string                  `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	LastTime   time.Time               `json:"last_time" bson:"last_time"`
+}
+
+// NotificationQuietHours the daily time range of the quiet hours, such as 22:00 to 08:00.
+type NotificationQuietHours struct {
+	// Start the start time in HH:MM format
+	Start string `json:"start" bson:"start"`
+	// End the end time in HH:MM format, it's on the next day if it's not after the start.
+	End string `json:"end" bson:"end"`
+	// Timezone the IANA time zone name, such as Asia/Shanghai, the local time zone is used if it's not set.
+	Timezone string `json:"timezone" bson:"timezone"`
+}
+
+// NotificationChange the change of a property
+type NotificationChange struct {
+	PropertyID string      `json:"bk_property_id" bson:"bk_property_id"`
+	PreValue   interface{} `json:"pre_value" bson:"pre_value"`
+	CurValue   interface{} `json:"cur_value" bson:"cur_value"`
+}
+
+// Notification the change of an instance to notify a user of through a channel
+type Notification struct {
+	ID       int64                `json:"id" bson:"id"`
+	RuleID   int64                `json:"rule_id" bson:"rule_id"`
+	User     string               `json:"bk_username" bson:"bk_username"`
+	Channel  string               `json:"channel" bson:"channel"`
+	EventID  int64                `json:"event_id" bson:"event_id"`
+	ObjectID string               `json:"bk_obj_id" bson:"bk_obj_id"`
+	InstID   int64                `json:"bk_inst_id" bson:"bk_inst_id"`
+	InstName string               `json:"bk_inst_name" bson:"bk_inst_name"`
+	Action   string               `json:"action" bson:"action"`
+	Changes  []NotificationChange `json:"changes" bson:"changes"`
+	OwnerID  string               `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Status   string               `json:"status" bson:"status"`
+	Attempts int                  `json:"attempts" bson:"attempts"`
+	// NextTime the time when the notification is sent, with the other pending ones of the rule, the user and the channel.
+	NextTime   time.Time `json:"next_time" bson:"next_time"`
+	LastError  string    `json:"last_error" bson:"last_error"`
+	CreateTime time.Time `json:"create_time" bson:"create_time"`
+	LastTime   time.Time `json:"last_time" bson:"last_time"`
+}
+
+// NotificationMessage the digest of the notifications of a rule sent to a user
+type NotificationMessage struct {
+	User    string `json:"bk_username"`
+	Channel string `json:"channel"`
+	// Address the email address or the http url
+	Address  string         `json:"-"`
+	RuleID   int64          `json:"rule_id"`
+	RuleName string         `json:"rule_name"`
+	Subject  string         `json:"subject"`
+	Content  string         `json:"content"`
+	Items    []Notification `json:"items"`
+}
+
+// CreateNotificationRuleResponse the response of create notification rule
+type CreateNotificationRuleResponse struct {
+	BaseResp `json:",inline"`
+	Data     NotificationRule `json:"data"`
+}
+
+// SearchNotificationRuleResult the notification rules found
+type SearchNotificationRuleResult struct {
+	Count uint64             `json:"count"`
+	Info  []NotificationRule `json:"info"`
+}
+
+// SearchNotificationRuleResponse the response of search notification rules
+type SearchNotificationRuleResponse struct {
+	BaseResp `json:",inline"`
+	Data     SearchNotificationRuleResult `json:"data"`
+}
+
+// NotificationSettingResponse the response of get notification setting
+type NotificationSettingResponse struct {
+	BaseResp `json:",inline"`
+	Data     NotificationSetting `json:"data"`
+}
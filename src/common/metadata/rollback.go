@@ -0,0 +1,71 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"icenter/src/common/mapstr"
+)
+
+const (
+	// RollbackActionCreate create the instance or association again, which is deleted by the audit log
+	RollbackActionCreate = "create"
+	// RollbackActionUpdate update the instance to the data before the audit log
+	RollbackActionUpdate = "update"
+	// RollbackActionDelete delete the instance or association, which is created by the audit log
+	RollbackActionDelete = "delete"
+)
+
+// RollbackParams roll back the changes recorded in the audit logs, the audit logs
+// are selected by the ids, or by the request id if the ids are not set.
+type RollbackParams struct {
+	AuditIDs  []int64 `json:"audit_ids"`
+	RequestID string  `json:"request_id"`
+	// DryRun only returns the changes, nothing is changed.
+	DryRun bool `json:"dry_run"`
+	// Force overwrite the changes after the audit logs, the changes which can not be applied are skipped.
+	Force bool `json:"force"`
+}
+
+// RollbackFieldDiff the difference of a field between the current data and the restored data
+type RollbackFieldDiff struct {
+	Field    string      `json:"field"`
+	Current  interface{} `json:"current"`
+	Restored interface{} `json:"restored"`
+}
+
+// RollbackChange the change to roll back an audit log
+type RollbackChange struct {
+	AuditID  int64  `json:"audit_id"`
+	ObjectID string `json:"bk_obj_id"`
+	InstID   int64  `json:"inst_id"`
+	Action   string `json:"action"`
+	// Conflict the instance or association has been changed after the audit log.
+	Conflict bool `json:"conflict"`
+	// Skipped the change can not be applied because of the conflict.
+	Skipped bool                `json:"skipped"`
+	Current mapstr.MapStr       `json:"current"`
+	Restore mapstr.MapStr       `json:"restore"`
+	Diff    []RollbackFieldDiff `json:"diff"`
+}
+
+// RollbackResult the changes to roll back the audit logs, in the order they are applied.
+type RollbackResult struct {
+	DryRun  bool             `json:"dry_run"`
+	Changes []RollbackChange `json:"changes"`
+}
+
+// RollbackResponse the response of roll back the audit logs
+type RollbackResponse struct {
+	BaseResp `json:",inline"`
+	Data     RollbackResult `json:"data"`
+}
@@ -0,0 +1,133 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+
+	"icenter/src/common/mapstr"
+)
+
+const (
+	// WebhookDeliveryPending the delivery is waiting to be sent or retried
+	WebhookDeliveryPending = "pending"
+	// WebhookDeliveryDead the delivery is failed after all the retries, it's kept in the dead letter list.
+	WebhookDeliveryDead = "dead"
+)
+
+// WebhookSubscription the callback url which the matched events are posted to
+type WebhookSubscription struct {
+	ID          int64  `json:"id" bson:"id"`
+	Name        string `json:"name" bson:"name"`
+	CallbackURL string `json:"callback_url" bson:"callback_url"`
+	// Secret the key to sign the payload with hmac-sha256, it's never returned by the search.
+	Secret string        `json:"secret,omitempty" bson:"secret"`
+	Filter WebhookFilter `json:"filter" bson:"filter"`
+	// Enabled the events are not delivered to the disabled subscription
+	Enabled bool `json:"enabled" bson:"enabled"`
+	// TimeoutSeconds the timeout of every delivery request
+	TimeoutSeconds int64 `json:"timeout_seconds" bson:"timeout_seconds"`
+	// MaxRetries the max retries of a failed delivery before it's moved to the dead letter list
+	MaxRetries int          `json:"max_retries" bson:"max_retries"`
+	Stats      WebhookStats `json:"stats" bson:"stats"`
+	OwnerID    string       `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string       `json:"creator" bson:"creator"`
+	CreateTime time.Time    `json:"create_time" bson:"create_time"`
+	LastTime   time.Time    `json:"last_time" bson:"last_time"`
+}
+
+// WebhookFilter the filter of the events, the event matches if it matches all the set items,
+// and the empty list matches all.
+type WebhookFilter struct {
+	// EventTypes the event types, such as instdata, relation and association
+	EventTypes []string `json:"event_types" bson:"event_types"`
+	// ObjectIDs the object ids of the events, such as host, set, module, biz or the custom model ids
+	ObjectIDs []string `json:"bk_obj_ids" bson:"bk_obj_ids"`
+	// Actions the actions of the events, create, update or delete
+	Actions []string `json:"actions" bson:"actions"`
+	// Condition the mongodb style condition on the data of the event, such as {"cur_data.bk_os_type": "1"}
+	Condition mapstr.MapStr `json:"condition" bson:"-"`
+	// ConditionJSON the json format condition saved in db, as the operator can not be the key of a mongodb document.
+	ConditionJSON string `json:"-" bson:"condition"`
+	// ChangedFields the event matches only if one of the fields is changed
+	ChangedFields []string `json:"changed_fields" bson:"changed_fields"`
+}
+
+// WebhookStats the delivery statistics of a subscription, every attempt of a delivery is counted.
+type WebhookStats struct {
+	Total      int64      `json:"total" bson:"total"`
+	Success    int64      `json:"success" bson:"success"`
+	Failure    int64      `json:"failure" bson:"failure"`
+	DeadLetter int64      `json:"dead_letter" bson:"dead_letter"`
+	LastTime   *time.Time `json:"last_time" bson:"last_time"`
+	LastError  string     `json:"last_error" bson:"last_error"`
+}
+
+// WebhookDelivery the delivery of an event to a subscription
+type WebhookDelivery struct {
+	ID             int64  `json:"id" bson:"id"`
+	SubscriptionID int64  `json:"subscription_id" bson:"subscription_id"`
+	EventID        int64  `json:"event_id" bson:"event_id"`
+	OwnerID        string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	// Payload the json format DistInst posted to the callback url
+	Payload    string    `json:"payload" bson:"payload"`
+	Status     string    `json:"status" bson:"status"`
+	Attempts   int       `json:"attempts" bson:"attempts"`
+	NextTime   time.Time `json:"next_time" bson:"next_time"`
+	StatusCode int       `json:"status_code" bson:"status_code"`
+	LastError  string    `json:"last_error" bson:"last_error"`
+	CreateTime time.Time `json:"create_time" bson:"create_time"`
+	LastTime   time.Time `json:"last_time" bson:"last_time"`
+}
+
+// CreateWebhookSubscriptionResponse the response of create webhook subscription
+type CreateWebhookSubscriptionResponse struct {
+	BaseResp `json:",inline"`
+	Data     WebhookSubscription `json:"data"`
+}
+
+// SearchWebhookSubscriptionResult the subscriptions found
+type SearchWebhookSubscriptionResult struct {
+	Count uint64                `json:"count"`
+	Info  []WebhookSubscription `json:"info"`
+}
+
+// SearchWebhookSubscriptionResponse the response of search webhook subscriptions
+type SearchWebhookSubscriptionResponse struct {
+	BaseResp `json:",inline"`
+	Data     SearchWebhookSubscriptionResult `json:"data"`
+}
+
+// SearchWebhookDeadLetterParams search the dead letters of the subscription, or all the subscriptions if it's not set.
+type SearchWebhookDeadLetterParams struct {
+	SubscriptionID int64    `json:"subscription_id"`
+	Page           BasePage `json:"page"`
+}
+
+// SearchWebhookDeadLetterResult the dead letters found
+type SearchWebhookDeadLetterResult struct {
+	Count uint64            `json:"count"`
+	Info  []WebhookDelivery `json:"info"`
+}
+
+// SearchWebhookDeadLetterResponse the response of search webhook dead letters
+type SearchWebhookDeadLetterResponse struct {
+	BaseResp `json:",inline"`
+	Data     SearchWebhookDeadLetterResult `json:"data"`
+}
+
+// WebhookDeadLetterParams the dead letters to redeliver or delete
+type WebhookDeadLetterParams struct {
+	SubscriptionID int64   `json:"subscription_id"`
+	IDs            []int64 `json:"ids"`
+}
@@ -0,0 +1,170 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metric
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Family a metric family exported in the prometheus text format
+type Family interface {
+	// Name the metric name of the family
+	Name() string
+	// Write write the samples of the family in the prometheus text format
+	Write(w io.Writer)
+}
+
+// Registry the metric families exported by the process
+type Registry struct {
+	lock     sync.RWMutex
+	families map[string]Family
+}
+
+// NewRegistry create an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]Family)}
+}
+
+// Register add the family to the registry, the family with the same name is replaced.
+func (r *Registry) Register(family Family) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.families[family.Name()] = family
+}
+
+// Unregister remove the family with the name
+func (r *Registry) Unregister(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.families, name)
+}
+
+// WriteText write all the families in the prometheus text format ordered by name
+func (r *Registry) WriteText(w io.Writer) error {
+	r.lock.RLock()
+	families := make([]Family, 0, len(r.families))
+	for _, family := range r.families {
+		families = append(families, family)
+	}
+	r.lock.RUnlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].Name() < families[j].Name() })
+
+	buf := bufio.NewWriter(w)
+	for _, family := range families {
+		family.Write(buf)
+	}
+	return buf.Flush()
+}
+
+var defaultRegistry = NewRegistry()
+
+// Register add the family to the default registry, which is exported by the /metrics of all the servers.
+func Register(family Family) {
+	defaultRegistry.Register(family)
+}
+
+// Unregister remove the family from the default registry
+func Unregister(name string) {
+	defaultRegistry.Unregister(name)
+}
+
+// PrometheusContentType the content type of the prometheus text format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// PrometheusHandler returns the handler exporting the default registry and the golang runtime
+// metrics in the prometheus text format.
+func PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", PrometheusContentType)
+		buf := bufio.NewWriter(w)
+		writeGoMetrics(buf)
+		if err := buf.Flush(); err != nil {
+			return
+		}
+		defaultRegistry.WriteText(w)
+	})
+}
+
+// writeGoMetrics write the golang runtime metrics as gauges
+func writeGoMetrics(w io.Writer) {
+	for _, m := range newGoMetricCollector().Collector.Collect() {
+		meta := m.GetMeta()
+		value, err := m.GetValue()
+		if err != nil || value == nil || value.Type != Float {
+			continue
+		}
+		writeHeader(w, meta.Name, strings.TrimSpace(meta.Help), "gauge")
+		writeSample(w, meta.Name, nil, nil, value.Float)
+	}
+}
+
+func writeHeader(w io.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+// writeSample write a sample line, the extra label is appended after the labels, eg: the le of the buckets.
+func writeSample(w io.Writer, name string, labels, values []string, value float64, extra ...string) {
+	io.WriteString(w, name)
+	if len(labels)+len(extra) > 0 {
+		io.WriteString(w, "{")
+		for i := range labels {
+			if i > 0 {
+				io.WriteString(w, ",")
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", labels[i], escapeLabelValue(values[i]))
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			if i > 0 || len(labels) > 0 {
+				io.WriteString(w, ",")
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extra[i], escapeLabelValue(extra[i+1]))
+		}
+		io.WriteString(w, "}")
+	}
+	io.WriteString(w, " ")
+	io.WriteString(w, formatFloat(value))
+	io.WriteString(w, "\n")
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
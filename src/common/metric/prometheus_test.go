@@ -0,0 +1,98 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metric
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+)
+
+func TestWriteText(t *testing.T) {
+	registry := NewRegistry()
+	counter := NewCounterVec("test_total", "Test counter.", "table", "operation")
+	counter.Inc("cc_HostBase", "find")
+	counter.Add(2, "cc_HostBase", "find")
+	counter.Inc("cc_\"quoted\"", "insert")
+	histogram := NewHistogramVec("test_seconds", "Test\nhistogram.", []float64{0.1, 1}, "route")
+	histogram.Observe(0.05, "/a")
+	histogram.Observe(0.5, "/a")
+	histogram.Observe(5, "/a")
+	registry.Register(histogram)
+	registry.Register(counter)
+	registry.Register(NewGaugeFunc("test_depth", "Test gauge.", func() float64 { return 3 }))
+
+	buf := new(bytes.Buffer)
+	if err := registry.WriteText(buf); err != nil {
+		t.Fatal(err)
+	}
+	expect := `# HELP test_depth Test gauge.
+# TYPE test_depth gauge
+test_depth 3
+# HELP test_seconds Test\nhistogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{route="/a",le="0.1"} 1
+test_seconds_bucket{route="/a",le="1"} 2
+test_seconds_bucket{route="/a",le="+Inf"} 3
+test_seconds_sum{route="/a"} 5.55
+test_seconds_count{route="/a"} 3
+# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{table="cc_\"quoted\"",operation="insert"} 1
+test_total{table="cc_HostBase",operation="find"} 3
+`
+	if buf.String() != expect {
+		t.Errorf("unexpected text:\n%s\nexpect:\n%s", buf.String(), expect)
+	}
+}
+
+func TestInstrumentContainer(t *testing.T) {
+	container := restful.NewContainer()
+	ws := new(restful.WebService)
+	ws.Path("/test/v3").Produces(restful.MIME_JSON)
+	ws.Route(ws.POST("/inst/{id}").To(func(req *restful.Request, resp *restful.Response) {
+		resp.WriteHeaderAndEntity(http.StatusBadRequest, map[string]interface{}{"result": false, "bk_error_code": 1199011})
+	}))
+	container.Add(ws)
+	InstrumentContainer(container)
+
+	server := httptest.NewServer(container)
+	defer server.Close()
+	resp, err := http.Post(server.URL+"/test/v3/inst/1", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != PrometheusContentType {
+		t.Errorf("unexpected content type %s", resp.Header.Get("Content-Type"))
+	}
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(resp.Body)
+	sample := `cmdb_http_request_duration_seconds_count{route="/test/v3/inst/{id}",method="POST",code="400",bk_error_code="1199011"} 1`
+	if !strings.Contains(buf.String(), sample) {
+		t.Errorf("the request is not recorded:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "# TYPE go_goroutines gauge") {
+		t.Errorf("the golang metrics are not exported")
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metric
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful"
+)
+
+// requestDuration the latency of the http requests, the rate and the error rate are got from its count.
+var requestDuration = NewHistogramVec("cmdb_http_request_duration_seconds",
+	"Latency of the http requests by route template, method, status code and bk_error_code.",
+	DefaultBuckets, "route", "method", "code", "bk_error_code")
+
+func init() {
+	Register(requestDuration)
+}
+
+// InstrumentContainer record the requests of the routes in the container, and serve the metrics
+// of the process with /metrics in the prometheus text format.
+func InstrumentContainer(container *restful.Container) {
+	container.Filter(requestFilter)
+	container.Handle("/metrics", PrometheusHandler())
+}
+
+// requestFilter record the latency of the request, the requests not matching any route are not recorded,
+// because their responses are written before the filters.
+func requestFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	route := req.SelectedRoutePath()
+	if len(route) == 0 {
+		chain.ProcessFilter(req, resp)
+		return
+	}
+
+	start := time.Now()
+	recorder := &responseRecorder{ResponseWriter: resp.ResponseWriter, status: http.StatusOK}
+	resp.ResponseWriter = recorder
+	chain.ProcessFilter(req, resp)
+	resp.ResponseWriter = recorder.ResponseWriter
+
+	requestDuration.Observe(time.Since(start).Seconds(), route, req.Request.Method,
+		strconv.Itoa(recorder.status), recorder.errorCode())
+}
+
+// bodyPrefixSize the size of the response prefix kept to get the bk_error_code,
+// which is the second field of the responses.
+const bodyPrefixSize = 256
+
+// responseRecorder record the status code and the prefix of the response body
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	prefix []byte
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if remain := bodyPrefixSize - len(r.prefix); remain > 0 {
+		if remain > len(data) {
+			remain = len(data)
+		}
+		r.prefix = append(r.prefix, data[:remain]...)
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack let the rpc servers take over the connection
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+var errorCodeKey = []byte(`"bk_error_code":`)
+
+// errorCode returns the bk_error_code of the response, it's "none" if the response has no error code.
+func (r *responseRecorder) errorCode() string {
+	index := bytes.Index(r.prefix, errorCodeKey)
+	if index < 0 {
+		return "none"
+	}
+	data := bytes.TrimLeft(r.prefix[index+len(errorCodeKey):], " ")
+	end := 0
+	for end < len(data) && (data[end] == '-' || (data[end] >= '0' && data[end] <= '9')) {
+		end++
+	}
+	if end == 0 {
+		return "none"
+	}
+	return string(data[:end])
+}
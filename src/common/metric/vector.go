@@ -0,0 +1,223 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metric
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets the default buckets of the latency histograms in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// vec the samples of a family, one for each combination of the label values
+type vec struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+
+	lock     sync.RWMutex
+	children map[string]*child
+}
+
+type child struct {
+	values []string
+	value  float64
+	// buckets the counts of the histogram buckets, they're not cumulative.
+	buckets []uint64
+	count   uint64
+}
+
+func newVec(name, help, typ string, labels []string) vec {
+	return vec{
+		name:     name,
+		help:     help,
+		typ:      typ,
+		labels:   labels,
+		children: make(map[string]*child),
+	}
+}
+
+func (v *vec) Name() string {
+	return v.name
+}
+
+// with returns the child of the label values, the caller should hold the lock.
+func (v *vec) with(values []string, buckets int) *child {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, but got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	c, exists := v.children[key]
+	if !exists {
+		c = &child{values: append([]string(nil), values...)}
+		if buckets > 0 {
+			c.buckets = make([]uint64, buckets)
+		}
+		v.children[key] = c
+	}
+	return c
+}
+
+// sortedChildren returns a copy of the children ordered by the label values
+func (v *vec) sortedChildren() []child {
+	v.lock.RLock()
+	children := make([]child, 0, len(v.children))
+	for _, c := range v.children {
+		copied := *c
+		copied.buckets = append([]uint64(nil), c.buckets...)
+		children = append(children, copied)
+	}
+	v.lock.RUnlock()
+	sort.Slice(children, func(i, j int) bool {
+		return strings.Join(children[i].values, "\xff") < strings.Join(children[j].values, "\xff")
+	})
+	return children
+}
+
+func (v *vec) writeValues(w io.Writer) {
+	writeHeader(w, v.name, v.help, v.typ)
+	for _, c := range v.sortedChildren() {
+		writeSample(w, v.name, v.labels, c.values, c.value)
+	}
+}
+
+// CounterVec a counter for each combination of the label values
+type CounterVec struct {
+	vec
+}
+
+// NewCounterVec create a counter family
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{vec: newVec(name, help, "counter", labels)}
+}
+
+// Inc increase the counter of the label values by 1
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add increase the counter of the label values, the delta should not be negative.
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 {
+		return
+	}
+	c.lock.Lock()
+	c.with(values, 0).value += delta
+	c.lock.Unlock()
+}
+
+// Write implements Family
+func (c *CounterVec) Write(w io.Writer) {
+	c.writeValues(w)
+}
+
+// GaugeVec a gauge for each combination of the label values
+type GaugeVec struct {
+	vec
+}
+
+// NewGaugeVec create a gauge family
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{vec: newVec(name, help, "gauge", labels)}
+}
+
+// Set set the gauge of the label values
+func (g *GaugeVec) Set(value float64, values ...string) {
+	g.lock.Lock()
+	g.with(values, 0).value = value
+	g.lock.Unlock()
+}
+
+// Add add the delta to the gauge of the label values, the delta could be negative.
+func (g *GaugeVec) Add(delta float64, values ...string) {
+	g.lock.Lock()
+	g.with(values, 0).value += delta
+	g.lock.Unlock()
+}
+
+// Write implements Family
+func (g *GaugeVec) Write(w io.Writer) {
+	g.writeValues(w)
+}
+
+// HistogramVec a histogram for each combination of the label values
+type HistogramVec struct {
+	vec
+	buckets []float64
+}
+
+// NewHistogramVec create a histogram family, the buckets are the upper bounds in increasing order,
+// the DefaultBuckets are used if it's empty.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	return &HistogramVec{vec: newVec(name, help, "histogram", labels), buckets: buckets}
+}
+
+// Observe add an observation to the histogram of the label values
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	index := sort.SearchFloat64s(h.buckets, value)
+	h.lock.Lock()
+	c := h.with(values, len(h.buckets))
+	if index < len(h.buckets) {
+		c.buckets[index]++
+	}
+	c.count++
+	c.value += value
+	h.lock.Unlock()
+}
+
+// Write implements Family
+func (h *HistogramVec) Write(w io.Writer) {
+	writeHeader(w, h.name, h.help, h.typ)
+	for _, c := range h.sortedChildren() {
+		cumulative := uint64(0)
+		for i, bound := range h.buckets {
+			cumulative += c.buckets[i]
+			writeSample(w, h.name+"_bucket", h.labels, c.values, float64(cumulative), "le", formatFloat(bound))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, c.values, float64(c.count), "le", formatFloat(math.Inf(1)))
+		writeSample(w, h.name+"_sum", h.labels, c.values, c.value)
+		writeSample(w, h.name+"_count", h.labels, c.values, float64(c.count))
+	}
+}
+
+// GaugeFunc a gauge whose value is got when it's exported, eg: the length of a queue.
+type GaugeFunc struct {
+	name  string
+	help  string
+	value func() float64
+}
+
+// NewGaugeFunc create a gauge with the function returning its value
+func NewGaugeFunc(name, help string, value func() float64) *GaugeFunc {
+	return &GaugeFunc{name: name, help: help, value: value}
+}
+
+// Name implements Family
+func (g *GaugeFunc) Name() string {
+	return g.name
+}
+
+// Write implements Family
+func (g *GaugeFunc) Write(w io.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	writeSample(w, g.name, nil, nil, g.value())
+}
@@ -0,0 +1,220 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registerdiscover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"icenter/src/common/blog"
+	"icenter/src/common/types"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ServiceMap the static service map, the addresses of each module are like http://127.0.0.1:50009,
+// and the first one of a module is the master.
+type ServiceMap struct {
+	Services map[string][]string `json:"services" yaml:"services"`
+}
+
+// FileRegDiscv discover the services from a static service map file in yaml or json,
+// the file is reloaded when it's changed.
+type FileRegDiscv struct {
+	file    string
+	rootCxt context.Context
+
+	lock     sync.RWMutex
+	services map[string][]string
+	modTime  time.Time
+	// changed is closed and replaced when the file is reloaded
+	changed chan struct{}
+}
+
+// NewFileRegDiscv create a object of FileRegDiscv, and watch the file until the context is done.
+func NewFileRegDiscv(ctx context.Context, file string) (*FileRegDiscv, error) {
+	f := &FileRegDiscv{
+		file:    file,
+		rootCxt: ctx,
+		changed: make(chan struct{}),
+	}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	go f.watch()
+	return f, nil
+}
+
+// ParseServiceMap parse the service map in yaml or json
+func ParseServiceMap(data []byte) (*ServiceMap, error) {
+	serviceMap := new(ServiceMap)
+	if err := yaml.Unmarshal(data, serviceMap); err != nil {
+		return nil, err
+	}
+	for module, addrs := range serviceMap.Services {
+		for _, addr := range addrs {
+			if _, err := parseServiceAddress(addr); err != nil {
+				return nil, fmt.Errorf("invalid address %s of %s, err: %v", addr, module, err)
+			}
+		}
+	}
+	return serviceMap, nil
+}
+
+// parseServiceAddress returns the server info of the address, the scheme is http by default.
+func parseServiceAddress(addr string) (*types.ServerInfo, error) {
+	info := &types.ServerInfo{Scheme: "http"}
+	if index := strings.Index(addr, "://"); index >= 0 {
+		info.Scheme = addr[:index]
+		addr = addr[index+3:]
+	}
+	host, port, err := net.SplitHostPort(strings.TrimRight(addr, "/"))
+	if err != nil {
+		return nil, err
+	}
+	portNum, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || portNum == 0 {
+		return nil, fmt.Errorf("invalid port %s", port)
+	}
+	info.IP = host
+	info.Port = uint(portNum)
+	return info, nil
+}
+
+func (f *FileRegDiscv) load() error {
+	stat, err := os.Stat(f.file)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(f.file)
+	if err != nil {
+		return err
+	}
+	serviceMap, err := ParseServiceMap(data)
+	if err != nil {
+		return fmt.Errorf("parse service map %s failed, err: %v", f.file, err)
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.modTime = stat.ModTime()
+	if reflect.DeepEqual(f.services, serviceMap.Services) {
+		return nil
+	}
+	f.services = serviceMap.Services
+	close(f.changed)
+	f.changed = make(chan struct{})
+	return nil
+}
+
+// watch reload the file when its modify time is changed
+func (f *FileRegDiscv) watch() {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.rootCxt.Done():
+			return
+		case <-ticker.C:
+		}
+		stat, err := os.Stat(f.file)
+		if err != nil {
+			blog.Errorf("stat service map %s failed, err: %v", f.file, err)
+			continue
+		}
+		f.lock.RLock()
+		modTime := f.modTime
+		f.lock.RUnlock()
+		if stat.ModTime().Equal(modTime) {
+			continue
+		}
+		if err := f.load(); err != nil {
+			blog.Errorf("reload service map failed, the previous one is kept, err: %v", err)
+			continue
+		}
+		blog.Infof("service map %s is reloaded", f.file)
+	}
+}
+
+// Ping to ping server
+func (f *FileRegDiscv) Ping() error {
+	_, err := os.Stat(f.file)
+	return err
+}
+
+// RegisterAndWatch the services are listed in the file, so nothing is registered
+func (f *FileRegDiscv) RegisterAndWatch(path string, data []byte) error {
+	blog.Infof("the services are discovered from %s, skip registering %s", f.file, path)
+	return nil
+}
+
+// GetServNodes get server nodes by path
+func (f *FileRegDiscv) GetServNodes(path string) ([]string, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return append([]string(nil), f.services[filepath.Base(path)]...), nil
+}
+
+// Discover returns the servers of the module, the module is the base of the path.
+func (f *FileRegDiscv) Discover(path string) (<-chan *DiscoverEvent, error) {
+	env := make(chan *DiscoverEvent, 1)
+	go f.loopDiscover(path, env)
+	return env, nil
+}
+
+func (f *FileRegDiscv) loopDiscover(path string, env chan *DiscoverEvent) {
+	module := filepath.Base(path)
+	var previous *DiscoverEvent
+	for {
+		f.lock.RLock()
+		addrs := f.services[module]
+		changed := f.changed
+		f.lock.RUnlock()
+
+		discvEnv := &DiscoverEvent{Key: path}
+		for _, addr := range addrs {
+			info, err := parseServiceAddress(addr)
+			if err != nil {
+				continue
+			}
+			data, _ := json.Marshal(info)
+			discvEnv.Nodes = append(discvEnv.Nodes, addr)
+			discvEnv.Server = append(discvEnv.Server, string(data))
+		}
+
+		if previous == nil || !reflect.DeepEqual(previous, discvEnv) {
+			previous = discvEnv
+			select {
+			case env <- discvEnv:
+			case <-f.rootCxt.Done():
+				return
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-f.rootCxt.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registerdiscover
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"icenter/src/common/blog"
+	"icenter/src/common/registry"
+)
+
+// HTTPRegDiscv do register and discover by the registry hosted by a service
+type HTTPRegDiscv struct {
+	client  *registry.Client
+	ttl     time.Duration
+	rootCxt context.Context
+}
+
+// NewHTTPRegDiscv create a object of HTTPRegDiscv, the address is like http://127.0.0.1:2390
+func NewHTTPRegDiscv(ctx context.Context, address string) *HTTPRegDiscv {
+	return &HTTPRegDiscv{
+		client:  registry.NewClient(address),
+		ttl:     registry.DefaultTTL,
+		rootCxt: ctx,
+	}
+}
+
+// Ping to ping server
+func (h *HTTPRegDiscv) Ping() error {
+	return h.client.Ping()
+}
+
+// RegisterAndWatch register the node and keep it alive with heartbeats, the node is registered
+// again if it's expired, eg: the registry is restarted.
+func (h *HTTPRegDiscv) RegisterAndWatch(path string, data []byte) error {
+	blog.Infof("register server and keep it alive. path(%s), data(%s)", path, string(data))
+	go func() {
+		var node string
+		ticker := time.NewTicker(h.ttl / 3)
+		defer ticker.Stop()
+		for {
+			if len(node) == 0 {
+				var err error
+				if node, err = h.client.Register(h.rootCxt, path, string(data), h.ttl); err != nil {
+					blog.Errorf("fail to register server node(%s). err:%v", path, err)
+				}
+			} else if err := h.client.Heartbeat(h.rootCxt, node); err != nil {
+				blog.Errorf("fail to keep register node(%s) alive, err:%v", node, err)
+				if err == registry.ErrNodeNotFound {
+					node = ""
+					continue
+				}
+			}
+
+			select {
+			case <-h.rootCxt.Done():
+				blog.Infof("register node(%s) done, now exist service register.", path)
+				if len(node) != 0 {
+					h.client.Deregister(context.Background(), node)
+				}
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// GetServNodes get server nodes by path
+func (h *HTTPRegDiscv) GetServNodes(path string) ([]string, error) {
+	resp, err := h.client.Services(h.rootCxt, path, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]string, 0, len(resp.Nodes))
+	for _, node := range resp.Nodes {
+		nodes = append(nodes, node.Name)
+	}
+	return nodes, nil
+}
+
+// Discover watch the children of the path, an event is sent when the children are changed.
+func (h *HTTPRegDiscv) Discover(path string) (<-chan *DiscoverEvent, error) {
+	env := make(chan *DiscoverEvent, 1)
+	go h.loopDiscover(path, env)
+	return env, nil
+}
+
+func (h *HTTPRegDiscv) loopDiscover(path string, env chan *DiscoverEvent) {
+	var index uint64
+	var previous *DiscoverEvent
+	for {
+		// wait for the change after the first fetch
+		wait := 30 * time.Second
+		if index == 0 {
+			wait = 0
+		}
+		resp, err := h.client.Services(h.rootCxt, path, index, wait)
+		if err != nil {
+			blog.Errorf("fail to discover the children of path(%s), err: %v, retry after 5s", path, err)
+			select {
+			case <-h.rootCxt.Done():
+				blog.Infof("discover path(%s) done", path)
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		index = resp.Index
+
+		discvEnv := &DiscoverEvent{Key: path}
+		for _, node := range resp.Nodes {
+			discvEnv.Nodes = append(discvEnv.Nodes, node.Name)
+			discvEnv.Server = append(discvEnv.Server, node.Data)
+		}
+		// the index is changed by the other paths too
+		if previous != nil && reflect.DeepEqual(previous, discvEnv) {
+			continue
+		}
+		previous = discvEnv
+
+		select {
+		case env <- discvEnv:
+		case <-h.rootCxt.Done():
+			blog.Infof("discover path(%s) done", path)
+			return
+		}
+	}
+}
@@ -0,0 +1,112 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registerdiscover
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"icenter/src/common/registry"
+)
+
+func nextEvent(t *testing.T, events <-chan *DiscoverEvent) *DiscoverEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(10 * time.Second):
+		t.Fatal("no discover event")
+		return nil
+	}
+}
+
+func TestHTTPRegDiscv(t *testing.T) {
+	server := httptest.NewServer(registry.New("cc", "secret").Handler())
+	defer server.Close()
+	address := strings.Replace(server.URL, "http://", "http://cc:secret@", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	masterCtx, stopMaster := context.WithCancel(ctx)
+	master := NewHTTPRegDiscv(masterCtx, address)
+	slave := NewHTTPRegDiscv(ctx, address)
+
+	events, err := slave.Discover("/cc/services/endpoints/coreservice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(t, events); len(event.Server) != 0 {
+		t.Fatalf("unexpected servers %v", event.Server)
+	}
+
+	master.RegisterAndWatch("/cc/services/endpoints/coreservice/10.0.0.1", []byte("master"))
+	if event := nextEvent(t, events); strings.Join(event.Server, ",") != "master" {
+		t.Fatalf("unexpected servers %v", event.Server)
+	}
+	slave.RegisterAndWatch("/cc/services/endpoints/coreservice/10.0.0.2", []byte("slave"))
+	if event := nextEvent(t, events); strings.Join(event.Server, ",") != "master,slave" {
+		t.Fatalf("unexpected servers %v", event.Server)
+	}
+
+	// the slave becomes the master after the master exits
+	stopMaster()
+	if event := nextEvent(t, events); strings.Join(event.Server, ",") != "slave" {
+		t.Fatalf("unexpected servers %v", event.Server)
+	}
+}
+
+func TestFileRegDiscv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "regdiscv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "services.yaml")
+	content := "services:\n  coreservice:\n    - http://127.0.0.1:50009\n    - 127.0.0.2:50009\n"
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	disc, err := NewFileRegDiscv(ctx, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, _ := disc.Discover("/cc/services/endpoints/coreservice")
+	event := nextEvent(t, events)
+	if len(event.Server) != 2 || !strings.Contains(event.Server[0], `"ip":"127.0.0.1"`) ||
+		!strings.Contains(event.Server[1], `"scheme":"http"`) {
+		t.Fatalf("unexpected servers %v", event.Server)
+	}
+
+	// the file is reloaded after it's changed, a json file is accepted too
+	content = `{"services": {"coreservice": ["https://127.0.0.3:50009"]}}`
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(file, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	event = nextEvent(t, events)
+	if len(event.Server) != 1 || !strings.Contains(event.Server[0], `"scheme":"https"`) {
+		t.Fatalf("unexpected servers %v", event.Server)
+	}
+
+	if _, err := ParseServiceMap([]byte("services:\n  topo:\n    - 127.0.0.1\n")); err == nil {
+		t.Errorf("the address without port should be invalid")
+	}
+}
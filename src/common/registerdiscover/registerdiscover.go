@@ -53,6 +53,13 @@ func NewRegDiscoverEx(client *zk.ZkClient) *RegDiscover {
 	return regDiscv
 }
 
+// NewRegDiscoverWithServer used to create a object of RegDiscover with the register and discover backend
+func NewRegDiscoverWithServer(rdServer RegDiscvServer) *RegDiscover {
+	return &RegDiscover{
+		rdServer: rdServer,
+	}
+}
+
 // RegisterAndWatchService register service info into register-discover platform
 // and then watch the service info, if not exist, then register again
 // key is the index of registered service
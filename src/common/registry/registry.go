@@ -0,0 +1,351 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"icenter/src/common/blog"
+)
+
+// Registry the registered nodes and the configs
+type Registry struct {
+	lock    sync.Mutex
+	seq     uint64
+	index   uint64
+	nodes   map[string]*node
+	configs map[string]string
+	// changed is closed and replaced on every change, so that the watchers are waked up.
+	changed chan struct{}
+	now     func() time.Time
+	// user and password authorize the writes like the digest auth of zookeeper, the reads are open.
+	user     string
+	password string
+}
+
+type node struct {
+	parent string
+	name   string
+	data   string
+	seq    uint64
+	ttl    time.Duration
+	expire time.Time
+}
+
+// New create an empty registry, the writes are authorized by the user and the password, and all of them
+// are rejected if the password is empty.
+func New(user, password string) *Registry {
+	return &Registry{
+		// the index starts from 1, so that the clients never fetched could use 0
+		index:    1,
+		nodes:    make(map[string]*node),
+		configs:  make(map[string]string),
+		changed:  make(chan struct{}),
+		now:      time.Now,
+		user:     user,
+		password: password,
+	}
+}
+
+// ListenAndServe serve the registry on the address until the context is done, the clients write to the
+// registry with the user and the password, see NewClient.
+func ListenAndServe(ctx context.Context, addr, user, password string) error {
+	if len(password) == 0 {
+		return errors.New("the password of the registry is required")
+	}
+	r := New(user, password)
+	server := &http.Server{Addr: addr, Handler: r.Handler()}
+	go r.Run(ctx)
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			blog.Fatalf("registry listen and serve on %s failed, err: %v", addr, err)
+		}
+	}()
+	blog.Infof("start the registry on %s", addr)
+	return nil
+}
+
+// Run remove the expired nodes until the context is done
+func (r *Registry) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.expire()
+		}
+	}
+}
+
+// notify mark the registry changed, the caller should hold the lock.
+func (r *Registry) notify() {
+	r.index++
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+func (r *Registry) expire() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := r.now()
+	expired := false
+	for name, n := range r.nodes {
+		if now.After(n.expire) {
+			blog.Warnf("registry node %s is expired", name)
+			delete(r.nodes, name)
+			expired = true
+		}
+	}
+	if expired {
+		r.notify()
+	}
+}
+
+// Register create an ephemeral sequential node of the path
+func (r *Registry) Register(nodePath, data string, ttl time.Duration) string {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.seq++
+	n := &node{
+		parent: path.Dir(nodePath),
+		name:   fmt.Sprintf("%s%010d", path.Base(nodePath), r.seq),
+		data:   data,
+		seq:    r.seq,
+		ttl:    ttl,
+		expire: r.now().Add(ttl),
+	}
+	fullPath := path.Join(n.parent, n.name)
+	r.nodes[fullPath] = n
+	r.notify()
+	return fullPath
+}
+
+// Heartbeat extend the ttl of the node
+func (r *Registry) Heartbeat(nodePath string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	n, exists := r.nodes[nodePath]
+	if !exists {
+		return ErrNodeNotFound
+	}
+	n.expire = r.now().Add(n.ttl)
+	return nil
+}
+
+// Deregister remove the node
+func (r *Registry) Deregister(nodePath string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, exists := r.nodes[nodePath]; exists {
+		delete(r.nodes, nodePath)
+		r.notify()
+	}
+}
+
+// Services returns the children of the path in the registration order, it waits for a change
+// until the wait is timeout if the index is not less than the registry's.
+func (r *Registry) Services(ctx context.Context, parent string, index uint64, wait time.Duration) *ServicesResponse {
+	r.waitChange(ctx, index, wait)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	children := make([]*node, 0)
+	for _, n := range r.nodes {
+		if n.parent == parent {
+			children = append(children, n)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].seq < children[j].seq })
+	resp := &ServicesResponse{Index: r.index, Nodes: make([]Node, 0, len(children))}
+	for _, n := range children {
+		resp.Nodes = append(resp.Nodes, Node{Name: n.name, Data: n.data})
+	}
+	return resp
+}
+
+// WriteConfig save the config data
+func (r *Registry) WriteConfig(key, data string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if current, exists := r.configs[key]; exists && current == data {
+		return
+	}
+	r.configs[key] = data
+	r.notify()
+}
+
+// ReadConfig returns the config data, it waits for a change like Services.
+func (r *Registry) ReadConfig(ctx context.Context, key string, index uint64, wait time.Duration) *ConfigResponse {
+	r.waitChange(ctx, index, wait)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	data, exists := r.configs[key]
+	return &ConfigResponse{Index: r.index, Exists: exists, Data: data}
+}
+
+func (r *Registry) waitChange(ctx context.Context, index uint64, wait time.Duration) {
+	if wait <= 0 {
+		return
+	}
+	if wait > maxWait {
+		wait = maxWait
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		r.lock.Lock()
+		if r.index > index {
+			r.lock.Unlock()
+			return
+		}
+		changed := r.changed
+		r.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Handler returns the http handler of the registry api
+func (r *Registry) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/registry/v1/ping", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+	})
+	mux.HandleFunc("/registry/v1/services/register", r.authorize(func(w http.ResponseWriter, req *http.Request) {
+		input := new(RegisterRequest)
+		if !decode(w, req, input) {
+			return
+		}
+		if len(input.Path) == 0 {
+			writeError(w, http.StatusBadRequest, "path is required")
+			return
+		}
+		nodePath := r.Register(input.Path, input.Data, time.Duration(input.TTL)*time.Second)
+		writeJSON(w, http.StatusOK, &RegisterResponse{Node: nodePath})
+	}))
+	mux.HandleFunc("/registry/v1/services/heartbeat", r.authorize(func(w http.ResponseWriter, req *http.Request) {
+		input := new(NodeRequest)
+		if !decode(w, req, input) {
+			return
+		}
+		if err := r.Heartbeat(input.Node); err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+	}))
+	mux.HandleFunc("/registry/v1/services/deregister", r.authorize(func(w http.ResponseWriter, req *http.Request) {
+		input := new(NodeRequest)
+		if !decode(w, req, input) {
+			return
+		}
+		r.Deregister(input.Node)
+		writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+	}))
+	mux.HandleFunc("/registry/v1/services", func(w http.ResponseWriter, req *http.Request) {
+		index, wait := watchParams(req)
+		writeJSON(w, http.StatusOK, r.Services(req.Context(), req.URL.Query().Get("path"), index, wait))
+	})
+	mux.HandleFunc("/registry/v1/configs", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			if !r.authorized(req) {
+				writeError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			input := new(ConfigRequest)
+			if !decode(w, req, input) {
+				return
+			}
+			r.WriteConfig(input.Key, input.Data)
+			writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+			return
+		}
+		index, wait := watchParams(req)
+		writeJSON(w, http.StatusOK, r.ReadConfig(req.Context(), req.URL.Query().Get("key"), index, wait))
+	})
+	return mux
+}
+
+// authorize reject the requests which are not authorized
+func (r *Registry) authorize(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !r.authorized(req) {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		handler(w, req)
+	}
+}
+
+// authorized check the user and the password of the basic auth of the request
+func (r *Registry) authorized(req *http.Request) bool {
+	user, password, ok := req.BasicAuth()
+	if !ok || len(r.password) == 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(user+":"+password), []byte(r.user+":"+r.password)) == 1
+}
+
+// watchParams returns the index and the wait seconds of the watch request
+func watchParams(req *http.Request) (uint64, time.Duration) {
+	index, _ := strconv.ParseUint(req.URL.Query().Get("index"), 10, 64)
+	wait, _ := strconv.ParseInt(req.URL.Query().Get("wait"), 10, 64)
+	return index, time.Duration(wait) * time.Second
+}
+
+func decode(w http.ResponseWriter, req *http.Request, input interface{}) bool {
+	if req.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return false
+	}
+	if err := json.NewDecoder(req.Body).Decode(input); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": strings.TrimSpace(message)})
+}
@@ -0,0 +1,116 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	r := New("cc", "secret")
+	now := time.Unix(1000, 0)
+	r.now = func() time.Time { return now }
+	server := httptest.NewServer(r.Handler())
+	defer server.Close()
+	client := NewClient(strings.Replace(server.URL, "http://", "http://cc:secret@", 1))
+	ctx := context.Background()
+
+	if err := client.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	first, err := client.Register(ctx, "/cc/services/endpoints/topo/10.0.0.2", "second-ip-first", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != "/cc/services/endpoints/topo/10.0.0.20000000001" {
+		t.Errorf("unexpected node %s", first)
+	}
+	if _, err := client.Register(ctx, "/cc/services/endpoints/topo/10.0.0.1", "first-ip-second", 20*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// the nodes are listed in the registration order
+	resp, err := client.Services(ctx, "/cc/services/endpoints/topo", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Nodes) != 2 || resp.Nodes[0].Data != "second-ip-first" || resp.Nodes[1].Data != "first-ip-second" {
+		t.Fatalf("unexpected nodes %+v", resp.Nodes)
+	}
+
+	// the watch returns after a change
+	done := make(chan *ServicesResponse)
+	go func() {
+		changed, _ := client.Services(ctx, "/cc/services/endpoints/topo", resp.Index, 10*time.Second)
+		done <- changed
+	}()
+	time.Sleep(100 * time.Millisecond)
+	now = now.Add(15 * time.Second)
+	r.expire()
+	select {
+	case changed := <-done:
+		if changed == nil || len(changed.Nodes) != 1 || changed.Nodes[0].Data != "first-ip-second" {
+			t.Errorf("the expired node should be removed, got %+v", changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the watch is not waked up by the change")
+	}
+	if err := client.Heartbeat(ctx, first); err != ErrNodeNotFound {
+		t.Errorf("the heartbeat of the expired node should fail, got %v", err)
+	}
+
+	// the configs
+	if err := client.WriteConfig(ctx, "/cc/services/errors", "{}"); err != nil {
+		t.Fatal(err)
+	}
+	config, err := client.ReadConfig(ctx, "/cc/services/errors", 0, 0)
+	if err != nil || !config.Exists || config.Data != "{}" {
+		t.Errorf("unexpected config %+v, err: %v", config, err)
+	}
+	config, err = client.ReadConfig(ctx, "/cc/services/language", 0, 0)
+	if err != nil || config.Exists {
+		t.Errorf("the config should not exist, got %+v, err: %v", config, err)
+	}
+
+	// the writes without the password are rejected, the reads are open
+	for _, address := range []string{server.URL, strings.Replace(server.URL, "http://", "http://cc:wrong@", 1)} {
+		other := NewClient(address)
+		if _, err := other.Register(ctx, "/cc/services/endpoints/topo/10.0.0.3", "unauthorized", 10*time.Second); err == nil {
+			t.Errorf("the register without the password should fail")
+		}
+		if err := other.Heartbeat(ctx, first); err == nil || err == ErrNodeNotFound {
+			t.Errorf("the heartbeat without the password should be unauthorized, got %v", err)
+		}
+		if err := other.Deregister(ctx, first); err == nil {
+			t.Errorf("the deregister without the password should fail")
+		}
+		if err := other.WriteConfig(ctx, "/cc/services/errors", "[]"); err == nil {
+			t.Errorf("the config write without the password should fail")
+		}
+		config, err = other.ReadConfig(ctx, "/cc/services/errors", 0, 0)
+		if err != nil || config.Data != "{}" {
+			t.Errorf("unexpected config %+v, err: %v", config, err)
+		}
+	}
+
+	// all the writes are rejected without a password
+	open := httptest.NewServer(New("", "").Handler())
+	defer open.Close()
+	if err := NewClient(open.URL).WriteConfig(ctx, "/cc/services/errors", "{}"); err == nil {
+		t.Errorf("the registry without a password should reject the writes")
+	}
+}
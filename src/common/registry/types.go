@@ -0,0 +1,77 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package registry is a small service registry and config store served with http, it's hosted by
+// one of the services so that the small deployments and the integration tests run without zookeeper.
+// the services are registered as ephemeral sequential nodes which expire without heartbeats, and the
+// nodes are listed in the registration order, so the first one is the master like with zookeeper.
+package registry
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultTTL the default ttl of the registered nodes
+	DefaultTTL = 15 * time.Second
+	// maxWait the max time to wait for a change
+	maxWait = time.Minute
+)
+
+// ErrNodeNotFound the node is expired or deregistered
+var ErrNodeNotFound = errors.New("node not found")
+
+// RegisterRequest register an ephemeral node, the name of the node is the base of the path
+// followed by a sequence of 10 digits, and the parent is the dir of the path.
+type RegisterRequest struct {
+	Path string `json:"path"`
+	Data string `json:"data"`
+	// TTL the seconds the node lives without heartbeats
+	TTL int64 `json:"ttl"`
+}
+
+// RegisterResponse the registered node
+type RegisterResponse struct {
+	Node string `json:"node"`
+}
+
+// NodeRequest the request of the heartbeat and the deregistration
+type NodeRequest struct {
+	Node string `json:"node"`
+}
+
+// Node a registered node
+type Node struct {
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+// ServicesResponse the children nodes of the path in the registration order
+type ServicesResponse struct {
+	// Index the index of the registry, it's increased on every change.
+	Index uint64 `json:"index"`
+	Nodes []Node `json:"nodes"`
+}
+
+// ConfigRequest write the config data
+type ConfigRequest struct {
+	Key  string `json:"key"`
+	Data string `json:"data"`
+}
+
+// ConfigResponse the config data
+type ConfigResponse struct {
+	Index  uint64 `json:"index"`
+	Exists bool   `json:"exists"`
+	Data   string `json:"data"`
+}
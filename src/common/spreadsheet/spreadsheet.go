@@ -0,0 +1,123 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package spreadsheet reads and writes the rows of the csv and xlsx files.
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Format the format of the spreadsheet
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+const (
+	// ContentTypeCSV the content type of the csv file
+	ContentTypeCSV = "text/csv"
+	// ContentTypeXLSX the content type of the xlsx file
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// utf8BOM is written at the beginning of the csv file, so that excel opens it in utf-8.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// ParseFormat returns the format of the name, it's a format name, a file name or a content type.
+func ParseFormat(name string) (Format, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if idx := strings.Index(name, ";"); idx >= 0 {
+		name = strings.TrimSpace(name[:idx])
+	}
+	switch name {
+	case string(FormatCSV), ContentTypeCSV, "application/csv":
+		return FormatCSV, nil
+	case string(FormatXLSX), ContentTypeXLSX:
+		return FormatXLSX, nil
+	}
+	if ext := strings.TrimPrefix(filepath.Ext(name), "."); ext != "" && ext != name {
+		return ParseFormat(ext)
+	}
+	return "", fmt.Errorf("unsupported spreadsheet format %s", name)
+}
+
+// ContentType returns the content type of the format
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return ContentTypeXLSX
+	}
+	return ContentTypeCSV
+}
+
+// Reader reads the rows of a spreadsheet
+type Reader interface {
+	// Read returns the next row, it returns io.EOF after the last row.
+	Read() ([]string, error)
+}
+
+// Writer writes the rows of a spreadsheet
+type Writer interface {
+	Write(row []string) error
+	// Close flushes the rows, the writer can't be used after it's closed.
+	Close() error
+}
+
+// NewReader returns the reader of the first sheet of the data
+func NewReader(format Format, data []byte) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		return reader, nil
+	case FormatXLSX:
+		return newXLSXReader(data)
+	default:
+		return nil, fmt.Errorf("unsupported spreadsheet format %s", format)
+	}
+}
+
+// NewWriter returns the writer which writes the rows to w
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		if _, err := w.Write(utf8BOM); err != nil {
+			return nil, err
+		}
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported spreadsheet format %s", format)
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvWriter) Write(row []string) error {
+	return c.writer.Write(row)
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
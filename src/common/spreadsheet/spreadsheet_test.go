@@ -0,0 +1,116 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func readAll(t *testing.T, format Format, data []byte) [][]string {
+	reader, err := NewReader(format, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := make([][]string, 0)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return rows
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"bk_inst_name", "名称", "a<b & \"c\""},
+		{"host-1", "", "line1\nline2"},
+		{"host-2", "x", ""},
+	}
+	for _, format := range []Format{FormatCSV, FormatXLSX} {
+		buf := new(bytes.Buffer)
+		writer, err := NewWriter(format, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range rows {
+			if err := writer.Write(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		got := readAll(t, format, buf.Bytes())
+		if format == FormatXLSX {
+			// the empty cells at the end of the rows are omitted in xlsx
+			got[2] = append(got[2], "")
+		}
+		if !reflect.DeepEqual(got, rows) {
+			t.Errorf("%s: expect %q, got %q", format, rows, got)
+		}
+	}
+}
+
+func TestReadSharedStrings(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="data" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId3" Target="/xl/worksheets/data.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       `<sst><si><t>name</t></si><si><r><t>ho</t></r><r><t>st</t></r></si></sst>`,
+		"xl/worksheets/data.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="b"><v>1</v></c></row>` +
+			`<row r="3"><c r="B3" t="s"><v>1</v></c><c r="C3"><v>12.5</v></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+	for name, content := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	expect := [][]string{{"name", "", "true"}, {}, {"", "host", "12.5"}}
+	if got := readAll(t, FormatXLSX, buf.Bytes()); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect %q, got %q", expect, got)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	cases := map[string]Format{
+		"csv":                     FormatCSV,
+		"hosts.XLSX":              FormatXLSX,
+		"text/csv; charset=utf-8": FormatCSV,
+		ContentTypeXLSX:           FormatXLSX,
+	}
+	for name, expect := range cases {
+		if got, err := ParseFormat(name); err != nil || got != expect {
+			t.Errorf("%s: expect %s, got %s, err: %v", name, expect, got, err)
+		}
+	}
+	if _, err := ParseFormat("hosts.xls"); err == nil {
+		t.Errorf("xls should not be supported")
+	}
+	if columnName(27) != "AB" || columnIndex("AB12") != 27 {
+		t.Errorf("unexpected column name %s or index %d", columnName(27), columnIndex("AB12"))
+	}
+}
@@ -0,0 +1,300 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const relationshipNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText the text of a shared string or an inline string, the rich text is made up of runs.
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t *xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var sb strings.Builder
+	for _, run := range t.Runs {
+		sb.WriteString(run.T)
+	}
+	return sb.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string    `xml:"r,attr"`
+			T      string    `xml:"t,attr"`
+			V      string    `xml:"v"`
+			Inline *xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type xlsxReader struct {
+	rows [][]string
+	next int
+}
+
+// newXLSXReader loads the rows of the first sheet of the xlsx data
+func newXLSXReader(data []byte) (*xlsxReader, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx file, %v", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, file := range zr.File {
+		files[file.Name] = file
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	shared := new(xlsxSharedStrings)
+	if file, exists := files["xl/sharedStrings.xml"]; exists {
+		if err := decodeXMLFile(file, shared); err != nil {
+			return nil, err
+		}
+	}
+
+	file, exists := files[sheetPath]
+	if !exists {
+		return nil, fmt.Errorf("invalid xlsx file, the sheet %s is missing", sheetPath)
+	}
+	sheet := new(xlsxSheet)
+	if err := decodeXMLFile(file, sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		// the empty rows are omitted in the sheet, add them back to keep the row numbers.
+		for row.R > len(rows)+1 {
+			rows = append(rows, []string{})
+		}
+		values := make([]string, 0, len(row.Cells))
+		for _, cell := range row.Cells {
+			if cell.R != "" {
+				for len(values) < columnIndex(cell.R) {
+					values = append(values, "")
+				}
+			}
+			var value string
+			switch cell.T {
+			case "s":
+				idx, err := strconv.Atoi(cell.V)
+				if err != nil || idx < 0 || idx >= len(shared.Items) {
+					return nil, fmt.Errorf("invalid xlsx file, the shared string %s of cell %s is missing", cell.V, cell.R)
+				}
+				value = shared.Items[idx].String()
+			case "inlineStr":
+				if cell.Inline != nil {
+					value = cell.Inline.String()
+				}
+			case "b":
+				value = strconv.FormatBool(cell.V == "1")
+			default:
+				value = cell.V
+			}
+			values = append(values, value)
+		}
+		rows = append(rows, values)
+	}
+	return &xlsxReader{rows: rows}, nil
+}
+
+// firstSheetPath returns the path of the first sheet in the workbook
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	workbook := new(xlsxWorkbook)
+	file, exists := files["xl/workbook.xml"]
+	if !exists {
+		return "", fmt.Errorf("invalid xlsx file, the workbook is missing")
+	}
+	if err := decodeXMLFile(file, workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("invalid xlsx file, there is no sheet")
+	}
+
+	rels := new(xlsxRelationships)
+	if file, exists := files["xl/_rels/workbook.xml.rels"]; exists {
+		if err := decodeXMLFile(file, rels); err != nil {
+			return "", err
+		}
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "xl/worksheets/sheet1.xml", nil
+}
+
+func decodeXMLFile(file *zip.File, v interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("invalid xlsx file, open %s failed, %v", file.Name, err)
+	}
+	defer reader.Close()
+	if err := xml.NewDecoder(reader).Decode(v); err != nil {
+		return fmt.Errorf("invalid xlsx file, decode %s failed, %v", file.Name, err)
+	}
+	return nil
+}
+
+func (x *xlsxReader) Read() ([]string, error) {
+	if x.next >= len(x.rows) {
+		return nil, io.EOF
+	}
+	x.next++
+	return x.rows[x.next-1], nil
+}
+
+// columnIndex returns the zero based column index of the cell reference like AB12
+func columnIndex(ref string) int {
+	col := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		col = col*26 + int(c-'A'+1)
+	}
+	return col - 1
+}
+
+// columnName returns the name of the zero based column index
+func columnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+var xlsxStaticFiles = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="` + relationshipNamespace + `/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="` + relationshipNamespace + `">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="` + relationshipNamespace + `/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxWriter writes the rows to the only sheet of the xlsx file as inline strings,
+// the rows are written to the underlying writer as they come.
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet io.Writer
+	row   int
+	buf   bytes.Buffer
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	for _, file := range xlsxStaticFiles {
+		writer, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(writer, file.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(sheet, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{zip: zw, sheet: sheet}, nil
+}
+
+func (x *xlsxWriter) Write(row []string) error {
+	x.row++
+	x.buf.Reset()
+	fmt.Fprintf(&x.buf, `<row r="%d">`, x.row)
+	for col, value := range row {
+		if value == "" {
+			continue
+		}
+		fmt.Fprintf(&x.buf, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, columnName(col), x.row)
+		if err := xml.EscapeText(&x.buf, []byte(value)); err != nil {
+			return err
+		}
+		x.buf.WriteString(`</t></is></c>`)
+	}
+	x.buf.WriteString(`</row>`)
+	_, err := x.buf.WriteTo(x.sheet)
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
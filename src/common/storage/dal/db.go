@@ -77,6 +77,12 @@ type Table interface {
 	Update(ctx context.Context, filter Filter, doc interface{}) error
 	// Delete 删除数据
 	Delete(ctx context.Context, filter Filter) error
+	// Upsert 更新第一条匹配的数据, 不存在则插入, doc 可以为更新操作符
+	Upsert(ctx context.Context, filter Filter, doc interface{}) error
+	// FindOneAndUpdate 原子更新第一条匹配的数据并返回更新前或更新后的数据
+	FindOneAndUpdate(ctx context.Context, filter Filter, doc interface{}, opts FindOneAndUpdateOptions, result interface{}) error
+	// BulkWrite 批量写入, 返回 *BulkWriteError 时 result 为成功部分的统计
+	BulkWrite(ctx context.Context, models []WriteModel, opts BulkWriteOptions) (*BulkWriteResult, error)
 
 	// CreateIndex 创建索引
 	CreateIndex(ctx context.Context, index Index) error
@@ -116,6 +122,13 @@ type Find interface {
 	One(ctx context.Context, result interface{}) error
 	// Count 统计数量(非事务)
 	Count(ctx context.Context) (uint64, error)
+	// Iterate 使用游标逐条读取, 结果分批加载而不是一次性加载到内存, handle 返回错误时停止读取并返回该错误
+	Iterate(ctx context.Context, handle func(doc Decoder) error) error
+}
+
+// Decoder decode the document read by the cursor
+type Decoder interface {
+	Decode(result interface{}) error
 }
 
 // Index define the DB index struct
@@ -0,0 +1,83 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package matcher matches the documents with the mongodb style filters, it is shared by the memory
+// db and the ones which match the documents out of the db, such as the events.
+package matcher
+
+import (
+	"reflect"
+	"strconv"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ToDoc convert the document or the filter into a bson document, so that all the
+// documents saved and compared are in the same form as they are read from mongodb.
+func ToDoc(value interface{}) (bson.M, error) {
+	doc := bson.M{}
+	if value == nil || reflect.ValueOf(value).Kind() == reflect.Map && reflect.ValueOf(value).IsNil() {
+		return doc, nil
+	}
+	data, err := bson.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// SubDoc returns the value as a document if it is
+func SubDoc(value interface{}) (bson.M, bool) {
+	switch doc := value.(type) {
+	case bson.M:
+		return doc, true
+	case map[string]interface{}:
+		return bson.M(doc), true
+	default:
+		return nil, false
+	}
+}
+
+// Resolve returns all the values of the dotted path as mongodb does when the documents are
+// queried, the path walks into every element if an array of documents is in the path.
+func Resolve(value interface{}, fields []string) []interface{} {
+	if len(fields) == 0 {
+		return []interface{}{value}
+	}
+	if sub, ok := SubDoc(value); ok {
+		next, exist := sub[fields[0]]
+		if !exist {
+			return nil
+		}
+		return Resolve(next, fields[1:])
+	}
+	array, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	if idx, err := strconv.Atoi(fields[0]); err == nil {
+		if idx < 0 || idx >= len(array) {
+			return nil
+		}
+		return Resolve(array[idx], fields[1:])
+	}
+	values := make([]interface{}, 0)
+	for _, elem := range array {
+		if _, ok := SubDoc(elem); ok {
+			values = append(values, Resolve(elem, fields)...)
+		}
+	}
+	return values
+}
@@ -0,0 +1,479 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package matcher
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// MatchDoc whether the document matches the mongodb style filter
+func MatchDoc(doc bson.M, filter bson.M) (bool, error) {
+	for key, cond := range filter {
+		var matched bool
+		var err error
+		switch key {
+		case "$and", "$or", "$nor":
+			matched, err = matchLogical(doc, key, cond)
+		case "$comment":
+			matched = true
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false, fmt.Errorf("unsupported query operator %s", key)
+			}
+			matched, err = matchField(Resolve(doc, strings.Split(key, ".")), cond)
+		}
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchLogical(doc bson.M, operator string, cond interface{}) (bool, error) {
+	items, ok := cond.([]interface{})
+	if !ok || len(items) == 0 {
+		return false, fmt.Errorf("%s must be a nonempty array", operator)
+	}
+	for _, item := range items {
+		filter, ok := SubDoc(item)
+		if !ok {
+			return false, fmt.Errorf("the item of %s must be a document", operator)
+		}
+		matched, err := MatchDoc(doc, filter)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case operator == "$and" && !matched:
+			return false, nil
+		case operator == "$or" && matched:
+			return true, nil
+		case operator == "$nor" && matched:
+			return false, nil
+		}
+	}
+	return operator != "$or", nil
+}
+
+// OperatorDoc returns the condition as operators if all the keys of it are operators
+func OperatorDoc(cond interface{}) (bson.M, bool) {
+	doc, ok := SubDoc(cond)
+	if !ok || len(doc) == 0 {
+		return nil, false
+	}
+	for key := range doc {
+		if !strings.HasPrefix(key, "$") {
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// matchField whether the values of a field matches the condition
+func matchField(values []interface{}, cond interface{}) (bool, error) {
+	if ops, ok := OperatorDoc(cond); ok {
+		for op, arg := range ops {
+			matched, err := matchOperator(values, op, arg, ops)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	if regex, ok := cond.(bson.RegEx); ok {
+		return matchRegex(values, regex.Pattern, regex.Options)
+	}
+	return MatchEqual(values, cond), nil
+}
+
+func matchOperator(values []interface{}, op string, arg interface{}, ops bson.M) (bool, error) {
+	switch op {
+	case "$eq":
+		return MatchEqual(values, arg), nil
+	case "$ne":
+		return !MatchEqual(values, arg), nil
+	case "$gt", "$gte", "$lt", "$lte":
+		for _, value := range candidates(values) {
+			if !comparable(value, arg) {
+				continue
+			}
+			cmp := CompareOrder(value, arg)
+			if op == "$gt" && cmp > 0 || op == "$gte" && cmp >= 0 || op == "$lt" && cmp < 0 || op == "$lte" && cmp <= 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$in", "$nin":
+		items, ok := arg.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s needs an array", op)
+		}
+		in := false
+		for _, item := range items {
+			matched, err := matchField(values, item)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				in = true
+				break
+			}
+		}
+		return in == (op == "$in"), nil
+	case "$exists":
+		return Truthy(arg) == (len(values) != 0), nil
+	case "$regex":
+		options, _ := ops["$options"].(string)
+		switch pattern := arg.(type) {
+		case string:
+			return matchRegex(values, pattern, options)
+		case bson.RegEx:
+			return matchRegex(values, pattern.Pattern, pattern.Options+options)
+		default:
+			return false, fmt.Errorf("$regex needs a string")
+		}
+	case "$options":
+		if _, ok := ops["$regex"]; !ok {
+			return false, fmt.Errorf("$options needs a $regex")
+		}
+		return true, nil
+	case "$not":
+		matched, err := matchField(values, arg)
+		return !matched, err
+	case "$size":
+		for _, value := range values {
+			if array, ok := value.([]interface{}); ok && CompareOrder(len(array), arg) == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$all":
+		items, ok := arg.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("$all needs an array")
+		}
+		for _, item := range items {
+			if !MatchEqual(values, item) {
+				return false, nil
+			}
+		}
+		return len(items) != 0, nil
+	case "$elemMatch":
+		for _, value := range values {
+			array, ok := value.([]interface{})
+			if !ok {
+				continue
+			}
+			for _, elem := range array {
+				matched, err := matchElem(elem, arg)
+				if err != nil {
+					return false, err
+				}
+				if matched {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported query operator %s", op)
+	}
+}
+
+func matchElem(elem interface{}, cond interface{}) (bool, error) {
+	if _, ok := OperatorDoc(cond); ok {
+		return matchField([]interface{}{elem}, cond)
+	}
+	doc, ok := SubDoc(elem)
+	filter, isDoc := SubDoc(cond)
+	if !ok || !isDoc {
+		return false, nil
+	}
+	return MatchDoc(doc, filter)
+}
+
+// candidates returns the values and the elements of the array values, a condition matches an array if it matches any element.
+func candidates(values []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		result = append(result, value)
+		if array, ok := value.([]interface{}); ok {
+			result = append(result, array...)
+		}
+	}
+	return result
+}
+
+// MatchEqual whether any of the values or their elements equals the expected value
+func MatchEqual(values []interface{}, expect interface{}) bool {
+	if len(values) == 0 {
+		return expect == nil
+	}
+	for _, value := range candidates(values) {
+		if EqualValue(value, expect) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRegex(values []interface{}, pattern, options string) (bool, error) {
+	flags := ""
+	for _, flag := range "ims" {
+		if strings.ContainsRune(options, flag) {
+			flags += string(flag)
+		}
+	}
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	for _, value := range candidates(values) {
+		if str, ok := value.(string); ok && regex.MatchString(str) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Truthy whether the value is treated as true by mongodb
+func Truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	}
+	if number, ok := ToFloat(value); ok {
+		return number != 0
+	}
+	return true
+}
+
+// ToFloat returns the number value as float64, it returns false if the value is not a number.
+func ToFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// typeOrder returns the order of the value type as mongodb compares the values of different types
+func typeOrder(value interface{}) int {
+	if value == nil {
+		return 1
+	}
+	if _, ok := ToFloat(value); ok {
+		return 2
+	}
+	switch value.(type) {
+	case string:
+		return 3
+	case bson.M, map[string]interface{}:
+		return 4
+	case []interface{}:
+		return 5
+	case []byte, bson.Binary:
+		return 6
+	case bson.ObjectId:
+		return 7
+	case bool:
+		return 8
+	case time.Time:
+		return 9
+	case bson.RegEx:
+		return 11
+	default:
+		return 12
+	}
+}
+
+// comparable whether the values can be compared by $gt, $lt and so on, only the values of the same type order can.
+func comparable(left, right interface{}) bool {
+	return typeOrder(left) == typeOrder(right)
+}
+
+// CompareOrder compare the values in the mongodb sort order
+func CompareOrder(left, right interface{}) int {
+	leftOrder, rightOrder := typeOrder(left), typeOrder(right)
+	if leftOrder != rightOrder {
+		return leftOrder - rightOrder
+	}
+
+	switch leftOrder {
+	case 1:
+		return 0
+	case 2:
+		l, _ := ToFloat(left)
+		r, _ := ToFloat(right)
+		return compareFloat(l, r)
+	case 3:
+		return strings.Compare(left.(string), right.(string))
+	case 7:
+		return strings.Compare(string(left.(bson.ObjectId)), string(right.(bson.ObjectId)))
+	case 8:
+		l, r := left.(bool), right.(bool)
+		if l == r {
+			return 0
+		}
+		if !l {
+			return -1
+		}
+		return 1
+	case 9:
+		l, r := left.(time.Time), right.(time.Time)
+		if l.Equal(r) {
+			return 0
+		}
+		if l.Before(r) {
+			return -1
+		}
+		return 1
+	case 4:
+		// the fields of the documents are compared in the alphabetical order as the field order is not kept
+		l, _ := SubDoc(left)
+		r, _ := SubDoc(right)
+		lkeys, rkeys := sortedKeys(l), sortedKeys(r)
+		for idx := 0; idx < len(lkeys) && idx < len(rkeys); idx++ {
+			if cmp := strings.Compare(lkeys[idx], rkeys[idx]); cmp != 0 {
+				return cmp
+			}
+			if cmp := CompareOrder(l[lkeys[idx]], r[rkeys[idx]]); cmp != 0 {
+				return cmp
+			}
+		}
+		return len(lkeys) - len(rkeys)
+	case 5:
+		l, r := left.([]interface{}), right.([]interface{})
+		for idx := 0; idx < len(l) && idx < len(r); idx++ {
+			if cmp := CompareOrder(l[idx], r[idx]); cmp != 0 {
+				return cmp
+			}
+		}
+		return len(l) - len(r)
+	default:
+		return strings.Compare(fmt.Sprint(left), fmt.Sprint(right))
+	}
+}
+
+func sortedKeys(doc bson.M) []string {
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func compareFloat(left, right float64) int {
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// EqualValue whether the values are equal, the numbers are equal if they have the same value whatever the types are.
+func EqualValue(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	if l, ok := ToFloat(left); ok {
+		r, ok := ToFloat(right)
+		return ok && l == r
+	}
+
+	if l, ok := SubDoc(left); ok {
+		r, ok := SubDoc(right)
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for key, value := range l {
+			other, exist := r[key]
+			if !exist || !EqualValue(value, other) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if l, ok := left.([]interface{}); ok {
+		r, ok := right.([]interface{})
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for idx := range l {
+			if !EqualValue(l[idx], r[idx]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if l, ok := left.(time.Time); ok {
+		r, ok := right.(time.Time)
+		return ok && l.Equal(r)
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+// Match whether the document matches the mongodb style filter, it's used to
+// match the documents which are not saved in the db, such as the events.
+func Match(doc interface{}, filter interface{}) (bool, error) {
+	d, err := ToDoc(doc)
+	if err != nil {
+		return false, err
+	}
+	f, err := ToDoc(filter)
+	if err != nil {
+		return false, err
+	}
+	return MatchDoc(d, f)
+}
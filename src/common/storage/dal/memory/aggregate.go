@@ -0,0 +1,570 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+
+	"icenter/src/common/storage/dal/matcher"
+)
+
+// stageHandler returns the documents after the stage
+type stageHandler func(c *Collection, ctx context.Context, docs []bson.M, arg interface{}) ([]bson.M, error)
+
+// stages the supported aggregate stages
+var stages map[string]stageHandler
+
+func init() {
+	stages = map[string]stageHandler{
+		"$match":       matchStage,
+		"$sort":        sortStage,
+		"$skip":        skipStage,
+		"$limit":       limitStage,
+		"$count":       countStage,
+		"$project":     projectStage,
+		"$unwind":      unwindStage,
+		"$group":       groupStage,
+		"$graphLookup": graphLookupStage,
+		"$addFields":   addFieldsStage,
+		"$lookup":      lookupStage,
+	}
+	initOperators()
+}
+
+// aggregate run the pipeline on the documents of the collection
+func (c *Collection) aggregate(ctx context.Context, pipeline interface{}) ([]bson.M, error) {
+	wrapper := struct {
+		Pipeline []bson.M `bson:"pipeline"`
+	}{}
+	data, err := bson.Marshal(bson.M{"pipeline": pipeline})
+	if err != nil {
+		return nil, err
+	}
+	if err := bson.Unmarshal(data, &wrapper); err != nil {
+		return nil, fmt.Errorf("the pipeline must be an array of stages, %v", err)
+	}
+
+	c.store.Lock()
+	defer c.store.Unlock()
+	tab, err := c.table(ctx, c.collName, false)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]bson.M, 0)
+	if tab != nil {
+		for _, doc := range tab.docs {
+			docs = append(docs, copyDoc(doc))
+		}
+	}
+
+	for _, stage := range wrapper.Pipeline {
+		if len(stage) != 1 {
+			return nil, fmt.Errorf("a pipeline stage must have exactly one field, got %v", stage)
+		}
+		for name, arg := range stage {
+			handler, ok := stages[name]
+			if !ok {
+				return nil, fmt.Errorf("unsupported aggregate stage %s", name)
+			}
+			if docs, err = handler(c, ctx, docs, arg); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return docs, nil
+}
+
+func matchStage(c *Collection, ctx context.Context, docs []bson.M, arg interface{}) ([]bson.M, error) {
+	filter, ok := matcher.SubDoc(arg)
+	if !ok {
+		return nil, fmt.Errorf("$match needs a document")
+	}
+	result := make([]bson.M, 0, len(docs))
+	for _, doc := range docs {
+		matched, err := matcher.MatchDoc(doc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			result = append(result, doc)
+		}
+	}
+	return result, nil
+}
+
+func sortStage(c *Collection, ctx context.Context, docs []bson.M, arg interface{}) ([]bson.M, error) {
+	spec, ok := matcher.SubDoc(arg)
+	if !ok {
+		return nil, fmt.Errorf("$sort needs a document")
+	}
+	// the order of the sort fields is lost in the bson document, so sort them by name.
+	fields := make([]string, 0, len(spec))
+	for field := range spec {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for idx, field := range fields {
+		if order, _ := matcher.ToFloat(spec[field]); order < 0 {
+			fields[idx] = "-" + field
+		}
+	}
+	sortDocs(docs, fields)
+	return docs, nil
+}
+
+func skipStage(c *Collection, ctx context.Context, docs []bson.M, arg interface{}) ([]bson.M, error) {
+	skip, ok := matcher.ToFloat(arg)
+	if !ok || skip < 0 {
+		return nil, fmt.Errorf("$skip needs a non-negative number")
+	}
+	if int(skip) >= len(docs) {
+		return []bson.M{}, nil
+	}
+	return docs[int(skip):], nil
+}
+
+func limitStage(c *Collection, ctx context.Context, docs []bson.M, arg interface{}) ([]bson.M, error) {
+	limit, ok := matcher.ToFloat(arg)
+	if !ok || limit <= 0 {
+		return nil, fmt.Errorf("$limit needs a positive number")
+	}
+	if int(limit) < len(docs) {
+		return docs[:int(limit)], nil
+	}
+	return docs, nil
+}
+
+func countStage(c *Collection, ctx context.Context, docs []bson.M, arg interface{}) ([]bson.M, error) {
+	field, ok := arg.(string)
+	if !ok || field == "" {
+		return nil, fmt.Errorf("$count needs a field name")
+	}
+	if len(docs) == 0 {
+		return []bson.M{}, nil
+	}
+	return []bson.M{{field: len(docs)}}, nil
+}
+
+func projectStage(c *Collection, ctx context.Context, docs []bson.M, arg interface{}) ([]bson.M, error) {
+	spec, ok := matcher.SubDoc(arg)
+	if !ok {
+		return nil, fmt.Errorf("$project needs a document")
+	}
+	exclude := false
+	for field, value := range spec {
+		if field != "_id" && (value == false || isZero(value)) {
+			exclude = true
+		}
+	}
+
+	result := make([]bson.M, 0, len(docs))
+	for _, doc := range docs {
+		if exclude {
+			for field := range spec {
+				unsetPath(doc, field)
+			}
+			result = append(result, doc)
+			continue
+		}
+
+		projected := bson.M{}
+		if value, exist := doc["_id"]; exist {
+			projected["_id"] = value
+		}
+		for field, value := range spec {
+			if value == false || isZero(value) {
+				unsetPath(projected, field)
+				continue
+			}
+			if value == true || !isZero(value) && isNumber(value) {
+				if value, exist := getPath(doc, field); exist {
+					setPath(projected, field, value)
+				}
+				continue
+			}
+			setPath(projected, field, evalExpr(doc, value))
+		}
+		result = append(result, projected)
+	}
+	return result, nil
+}
+
+func unwindStage(c *Collection, ctx context.Context, docs []bson.M, arg interface{}) ([]bson.M, error) {
+	path, preserve := "", false
+	switch spec := arg.(type) {
+	case string:
+		path = spec
+	default:
+		doc, ok := matcher.SubDoc(arg)
+		if !ok {
+			return nil, fmt.Errorf("$unwind needs a field path")
+		}
+		path, _ = doc["path"].(string)
+		preserve = matcher.Truthy(doc["preserveNullAndEmptyArrays"])
+	}
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("$unwind needs a field path prefixed with $")
+	}
+	path = path[1:]
+
+	result := make([]bson.M, 0, len(docs))
+	for _, doc := range docs {
+		value, exist := getPath(doc, path)
+		array, isArray := value.([]interface{})
+		switch {
+		case isArray && len(array) != 0:
+			for _, elem := range array {
+				unwound := copyDoc(doc)
+				setPath(unwound, path, elem)
+				result = append(result, unwound)
+			}
+		case exist && value != nil && !isArray:
+			result = append(result, doc)
+		case preserve:
+			result = append(result, doc)
+		}
+	}
+	return result, nil
+}
+
+// accumulator the state of an accumulator of a group
+type accumulator struct {
+	op      string
+	expr    interface{}
+	values  []interface{}
+	sum     float64
+	count   int
+	integer bool
+	set     bool
+	value   interface{}
+}
+
+func groupStage(c *Collection, ctx context.Context, docs []bson.M, arg interface{}) ([]bson.M, error) {
+	spec, ok := matcher.SubDoc(arg)
+	if !ok {
+		return nil, fmt.Errorf("$group needs a document")
+	}
+	idExpr, ok := spec["_id"]
+	if !ok {
+		return nil, fmt.Errorf("$group needs an _id")
+	}
+
+	type group struct {
+		id           interface{}
+		accumulators map[string]*accumulator
+	}
+	groups := make(map[string]*group)
+	keys := make([]string, 0)
+	for _, doc := range docs {
+		id := evalExpr(doc, idExpr)
+		key := fmt.Sprintf("%v", id)
+		g, exist := groups[key]
+		if !exist {
+			g = &group{id: id, accumulators: make(map[string]*accumulator)}
+			for field, value := range spec {
+				if field == "_id" {
+					continue
+				}
+				acc, ok := matcher.SubDoc(value)
+				if !ok || len(acc) != 1 {
+					return nil, fmt.Errorf("the accumulator of %s must have exactly one operator", field)
+				}
+				for op, expr := range acc {
+					g.accumulators[field] = &accumulator{op: op, expr: expr, integer: true}
+				}
+			}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		for field, acc := range g.accumulators {
+			if err := acc.add(evalExpr(doc, acc.expr)); err != nil {
+				return nil, fmt.Errorf("accumulate %s failed, %v", field, err)
+			}
+		}
+	}
+
+	result := make([]bson.M, 0, len(keys))
+	for _, key := range keys {
+		g := groups[key]
+		doc := bson.M{"_id": g.id}
+		for field, acc := range g.accumulators {
+			doc[field] = acc.result()
+		}
+		result = append(result, doc)
+	}
+	return result, nil
+}
+
+func (a *accumulator) add(value interface{}) error {
+	switch a.op {
+	case "$sum":
+		if number, ok := matcher.ToFloat(value); ok {
+			a.sum += number
+			if number != math.Trunc(number) {
+				a.integer = false
+			}
+		}
+	case "$avg":
+		if number, ok := matcher.ToFloat(value); ok {
+			a.sum += number
+			a.count++
+		}
+	case "$push":
+		a.values = append(a.values, value)
+	case "$addToSet":
+		for _, exist := range a.values {
+			if matcher.EqualValue(exist, value) {
+				return nil
+			}
+		}
+		a.values = append(a.values, value)
+	case "$first":
+		if !a.set {
+			a.value, a.set = value, true
+		}
+	case "$last":
+		a.value, a.set = value, true
+	case "$max":
+		if value != nil && (!a.set || matcher.CompareOrder(value, a.value) > 0) {
+			a.value, a.set = value, true
+		}
+	case "$min":
+		if value != nil && (!a.set || matcher.CompareOrder(value, a.value) < 0) {
+			a.value, a.set = value, true
+		}
+	default:
+		return fmt.Errorf("unsupported accumulator %s", a.op)
+	}
+	return nil
+}
+
+func (a *accumulator) result() interface{} {
+	switch a.op {
+	case "$sum":
+		if a.integer {
+			return int64(a.sum)
+		}
+		return a.sum
+	case "$avg":
+		if a.count == 0 {
+			return nil
+		}
+		return a.sum / float64(a.count)
+	case "$push", "$addToSet":
+		if a.values == nil {
+			return []interface{}{}
+		}
+		return a.values
+	default:
+		return a.value
+	}
+}
+
+func graphLookupStage(c *Collection, ctx context.Context, docs []bson.M, arg interface{}) ([]bson.M, error) {
+	spec, ok := matcher.SubDoc(arg)
+	if !ok {
+		return nil, fmt.Errorf("$graphLookup needs a document")
+	}
+	from, _ := spec["from"].(string)
+	connectFrom, _ := spec["connectFromField"].(string)
+	connectTo, _ := spec["connectToField"].(string)
+	as, _ := spec["as"].(string)
+	depthField, _ := spec["depthField"].(string)
+	if from == "" || connectFrom == "" || connectTo == "" || as == "" {
+		return nil, fmt.Errorf("$graphLookup needs from, connectFromField, connectToField and as")
+	}
+	maxDepth := -1
+	if value, exist := spec["maxDepth"]; exist {
+		depth, ok := matcher.ToFloat(value)
+		if !ok || depth < 0 {
+			return nil, fmt.Errorf("$graphLookup maxDepth must be a non-negative number")
+		}
+		maxDepth = int(depth)
+	}
+
+	tab, err := c.table(ctx, from, false)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]bson.M, 0)
+	if tab != nil {
+		restrict, _ := matcher.SubDoc(spec["restrictSearchWithMatch"])
+		for _, doc := range tab.docs {
+			matched, err := matcher.MatchDoc(doc, restrict)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				candidates = append(candidates, doc)
+			}
+		}
+	}
+
+	for _, doc := range docs {
+		found := make([]interface{}, 0)
+		visited := make(map[int]bool)
+		frontier := flatten(evalExpr(doc, spec["startWith"]))
+		for depth := 0; len(frontier) != 0 && (maxDepth < 0 || depth <= maxDepth); depth++ {
+			next := make([]interface{}, 0)
+			for idx, candidate := range candidates {
+				if visited[idx] || !matchAny(matcher.Resolve(candidate, strings.Split(connectTo, ".")), frontier) {
+					continue
+				}
+				visited[idx] = true
+				result := copyDoc(candidate)
+				if depthField != "" {
+					result[depthField] = int64(depth)
+				}
+				found = append(found, result)
+				value, _ := getPath(candidate, connectFrom)
+				next = append(next, flatten(value)...)
+			}
+			frontier = next
+		}
+		doc[as] = found
+	}
+	return docs, nil
+}
+
+// addFieldsStage set the fields with the expressions, which are evaluated on the input document
+func addFieldsStage(c *Collection, ctx context.Context, docs []bson.M, arg interface{}) ([]bson.M, error) {
+	spec, ok := matcher.SubDoc(arg)
+	if !ok {
+		return nil, fmt.Errorf("$addFields needs a document")
+	}
+	for _, doc := range docs {
+		values := make(map[string]interface{}, len(spec))
+		for field, expr := range spec {
+			values[field] = evalExpr(doc, expr)
+		}
+		for field, value := range values {
+			setPath(doc, field, value)
+		}
+	}
+	return docs, nil
+}
+
+// lookupStage join the documents of the other collection whose foreign field equals the local field
+func lookupStage(c *Collection, ctx context.Context, docs []bson.M, arg interface{}) ([]bson.M, error) {
+	spec, ok := matcher.SubDoc(arg)
+	if !ok {
+		return nil, fmt.Errorf("$lookup needs a document")
+	}
+	from, _ := spec["from"].(string)
+	localField, _ := spec["localField"].(string)
+	foreignField, _ := spec["foreignField"].(string)
+	as, _ := spec["as"].(string)
+	if from == "" || localField == "" || foreignField == "" || as == "" {
+		return nil, fmt.Errorf("$lookup needs from, localField, foreignField and as")
+	}
+
+	tab, err := c.table(ctx, from, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		found := make([]interface{}, 0)
+		local := matcher.Resolve(doc, strings.Split(localField, "."))
+		if len(local) == 0 {
+			local = []interface{}{nil}
+		}
+		if tab != nil {
+			for _, candidate := range tab.docs {
+				if matchAny(matcher.Resolve(candidate, strings.Split(foreignField, ".")), local) {
+					found = append(found, copyDoc(candidate))
+				}
+			}
+		}
+		doc[as] = found
+	}
+	return docs, nil
+}
+
+// matchAny whether any of the values or their elements equals any of the expected values
+func matchAny(values []interface{}, expects []interface{}) bool {
+	for _, expect := range expects {
+		if matcher.MatchEqual(values, expect) {
+			return true
+		}
+	}
+	return false
+}
+
+func flatten(value interface{}) []interface{} {
+	if value == nil {
+		return nil
+	}
+	if array, ok := value.([]interface{}); ok {
+		return array
+	}
+	return []interface{}{value}
+}
+
+// evalExpr evaluate the expression on the document, the string prefixed with $ is a field path,
+// $$ROOT is the document itself, and the document expression is evaluated field by field.
+func evalExpr(doc bson.M, expr interface{}) interface{} {
+	switch value := expr.(type) {
+	case string:
+		if value == "$$ROOT" {
+			return copyDoc(doc)
+		}
+		if strings.HasPrefix(value, "$") {
+			if result, exist := getPath(doc, value[1:]); exist {
+				return result
+			}
+			// the path walks into the array of documents returns the values of all the elements
+			if values := matcher.Resolve(doc, strings.Split(value[1:], ".")); len(values) != 0 {
+				return values
+			}
+			return nil
+		}
+		return value
+	case []interface{}:
+		result := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			result = append(result, evalExpr(doc, item))
+		}
+		return result
+	}
+	if sub, ok := matcher.SubDoc(expr); ok {
+		if len(sub) == 1 {
+			for name, arg := range sub {
+				if operator, exist := operators[name]; exist {
+					return operator(doc, arg)
+				}
+			}
+		}
+		result := bson.M{}
+		for key, item := range sub {
+			result[key] = evalExpr(doc, item)
+		}
+		return result
+	}
+	return expr
+}
+
+func isNumber(value interface{}) bool {
+	_, ok := matcher.ToFloat(value)
+	return ok
+}
+
+func isZero(value interface{}) bool {
+	number, ok := matcher.ToFloat(value)
+	return ok && number == 0
+}
@@ -0,0 +1,441 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/dal/matcher"
+	"icenter/src/common/util"
+)
+
+// Collection implement dal.Table interface
+type Collection struct {
+	collName string // 集合名
+	*Memory
+}
+
+// Find 查询多个并反序列化到 Result
+func (c *Collection) Find(filter dal.Filter) dal.Find {
+	return &Find{Collection: c, filter: filter}
+}
+
+// Find define a find operation
+type Find struct {
+	*Collection
+	fields []string
+	filter dal.Filter
+	start  uint64
+	limit  uint64
+	sort   []string
+}
+
+// Fields 查询字段
+func (f *Find) Fields(fields ...string) dal.Find {
+	for _, field := range fields {
+		if len(field) <= 0 {
+			continue
+		}
+		f.fields = append(f.fields, field)
+	}
+	return f
+}
+
+// Sort 查询排序
+func (f *Find) Sort(sort string) dal.Find {
+	if sort != "" {
+		f.sort = strings.Split(sort, ",")
+	}
+	return f
+}
+
+// Start 查询上标
+func (f *Find) Start(start uint64) dal.Find {
+	f.start = start
+	return f
+}
+
+// Limit 查询限制
+func (f *Find) Limit(limit uint64) dal.Find {
+	f.limit = limit
+	return f
+}
+
+// find returns the copies of the matched documents after sorted, skipped and limited
+func (f *Find) find(ctx context.Context) ([]bson.M, error) {
+	filter, err := matcher.ToDoc(f.filter)
+	if err != nil {
+		return nil, err
+	}
+
+	f.store.Lock()
+	defer f.store.Unlock()
+	tab, err := f.table(ctx, f.collName, false)
+	if err != nil || tab == nil {
+		return nil, err
+	}
+
+	docs := make([]bson.M, 0)
+	for _, doc := range tab.docs {
+		matched, err := matcher.MatchDoc(doc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			docs = append(docs, doc)
+		}
+	}
+
+	sortDocs(docs, f.sort)
+	if f.start >= uint64(len(docs)) {
+		return []bson.M{}, nil
+	}
+	docs = docs[f.start:]
+	if f.limit > 0 && f.limit < uint64(len(docs)) {
+		docs = docs[:f.limit]
+	}
+
+	result := make([]bson.M, 0, len(docs))
+	for _, doc := range docs {
+		result = append(result, project(doc, f.fields))
+	}
+	return result, nil
+}
+
+// All 查询多个
+func (f *Find) All(ctx context.Context, result interface{}) error {
+	docs, err := f.find(ctx)
+	if err != nil {
+		return err
+	}
+	return decodeAll(docs, result)
+}
+
+// One 查询一个
+func (f *Find) One(ctx context.Context, result interface{}) error {
+	f.limit = 1
+	docs, err := f.find(ctx)
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return dal.ErrDocumentNotFound
+	}
+	return decode(docs[0], result)
+}
+
+// Count 统计数量(非事务)
+func (f *Find) Count(ctx context.Context) (uint64, error) {
+	find := &Find{Collection: f.Collection, filter: f.filter}
+	docs, err := find.find(ctx)
+	return uint64(len(docs)), err
+}
+
+// Iterate 使用游标逐条读取, the documents are copied when it starts, so the handle can write the db.
+func (f *Find) Iterate(ctx context.Context, handle func(doc dal.Decoder) error) error {
+	docs, err := f.find(ctx)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := handle(document(doc)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Insert 插入数据, docs 可以为 单个数据 或者 多个数据
+func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
+	inserts := make([]bson.M, 0)
+	for _, item := range util.ConverToInterfaceSlice(docs) {
+		doc, err := matcher.ToDoc(item)
+		if err != nil {
+			return err
+		}
+		if _, exist := doc["_id"]; !exist {
+			doc["_id"] = bson.NewObjectId()
+		}
+		inserts = append(inserts, doc)
+	}
+
+	c.store.Lock()
+	defer c.store.Unlock()
+	tab, err := c.table(ctx, c.collName, true)
+	if err != nil {
+		return err
+	}
+	for _, doc := range inserts {
+		if err := tab.checkUnique(doc, -1); err != nil {
+			return err
+		}
+		tab.docs = append(tab.docs, doc)
+		tab.version++
+	}
+	return nil
+}
+
+// Update 更新数据
+func (c *Collection) Update(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	cond, err := matcher.ToDoc(filter)
+	if err != nil {
+		return err
+	}
+	data, err := matcher.ToDoc(doc)
+	if err != nil {
+		return err
+	}
+
+	c.store.Lock()
+	defer c.store.Unlock()
+	tab, err := c.table(ctx, c.collName, false)
+	if err != nil || tab == nil {
+		return err
+	}
+	return tab.update(cond, func(doc bson.M) bool {
+		for key, value := range data {
+			setPath(doc, key, value)
+		}
+		return true
+	})
+}
+
+// Delete 删除数据
+func (c *Collection) Delete(ctx context.Context, filter dal.Filter) error {
+	cond, err := matcher.ToDoc(filter)
+	if err != nil {
+		return err
+	}
+
+	c.store.Lock()
+	defer c.store.Unlock()
+	tab, err := c.table(ctx, c.collName, false)
+	if err != nil || tab == nil {
+		return err
+	}
+	_, err = tab.remove(cond)
+	return err
+}
+
+// update apply the change to the copies of the matched documents, and replace them if all of them are valid.
+// the change returns false if the document is not changed.
+func (t *table) update(filter bson.M, change func(doc bson.M) bool) error {
+	updated := make(map[int]bson.M)
+	for idx, doc := range t.docs {
+		matched, err := matcher.MatchDoc(doc, filter)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+		doc = copyDoc(doc)
+		if change(doc) {
+			updated[idx] = doc
+		}
+	}
+	if len(updated) == 0 {
+		return nil
+	}
+
+	docs := append([]bson.M{}, t.docs...)
+	for idx, doc := range updated {
+		docs[idx] = doc
+	}
+	origin := t.docs
+	t.docs = docs
+	for idx, doc := range updated {
+		if err := t.checkUnique(doc, idx); err != nil {
+			t.docs = origin
+			return err
+		}
+	}
+	t.version++
+	return nil
+}
+
+// checkUnique check the document against the unique indexes, the document at the skip index is ignored.
+func (t *table) checkUnique(doc bson.M, skip int) error {
+	for _, index := range t.indexes {
+		if !index.Unique {
+			continue
+		}
+		key := indexKey(doc, index)
+		for idx, other := range t.docs {
+			if idx != skip && matcher.EqualValue(indexKey(other, index), key) {
+				return dal.ErrDuplicated
+			}
+		}
+	}
+	return nil
+}
+
+// indexKey returns the values of the index fields in the field order
+func indexKey(doc bson.M, index dal.Index) []interface{} {
+	fields := make([]string, 0, len(index.Keys))
+	for field := range index.Keys {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	key := make([]interface{}, 0, len(fields))
+	for _, field := range fields {
+		value, _ := getPath(doc, field)
+		key = append(key, value)
+	}
+	return key
+}
+
+// indexName returns the name mongodb generates for the index without name, e.g. bk_biz_id_1
+func indexName(index dal.Index) string {
+	fields := make([]string, 0, len(index.Keys))
+	for field := range index.Keys {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("%s_%d", field, index.Keys[field]))
+	}
+	return strings.Join(parts, "_")
+}
+
+// CreateIndex 创建索引
+func (c *Collection) CreateIndex(ctx context.Context, index dal.Index) error {
+	c.store.Lock()
+	defer c.store.Unlock()
+	tab, err := c.table(ctx, c.collName, true)
+	if err != nil {
+		return err
+	}
+	if index.Name == "" {
+		index.Name = indexName(index)
+	}
+	for _, exist := range tab.indexes {
+		if exist.Name == index.Name {
+			return fmt.Errorf("There's already an index with name %s", index.Name)
+		}
+	}
+	tab.indexes = append(tab.indexes, index)
+	if index.Unique {
+		for idx, doc := range tab.docs {
+			if err := tab.checkUnique(doc, idx); err != nil {
+				tab.indexes = tab.indexes[:len(tab.indexes)-1]
+				return err
+			}
+		}
+	}
+	tab.version++
+	return nil
+}
+
+// DropIndex remove index by name
+func (c *Collection) DropIndex(ctx context.Context, indexName string) error {
+	c.store.Lock()
+	defer c.store.Unlock()
+	tab, err := c.table(ctx, c.collName, false)
+	if err != nil {
+		return err
+	}
+	if tab != nil {
+		for idx, index := range tab.indexes {
+			if index.Name == indexName {
+				tab.indexes = append(tab.indexes[:idx], tab.indexes[idx+1:]...)
+				tab.version++
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("index not found with name %s", indexName)
+}
+
+// Indexes get all indexes for the collection
+func (c *Collection) Indexes(ctx context.Context) ([]dal.Index, error) {
+	c.store.Lock()
+	defer c.store.Unlock()
+	tab, err := c.table(ctx, c.collName, false)
+	if err != nil || tab == nil {
+		return []dal.Index{}, err
+	}
+	return append([]dal.Index{}, tab.indexes...), nil
+}
+
+// AddColumn add a new column for the collection
+func (c *Collection) AddColumn(ctx context.Context, column string, value interface{}) error {
+	return c.changeColumn(ctx, func(doc bson.M) bool {
+		if _, exist := getPath(doc, column); exist {
+			return false
+		}
+		setPath(doc, column, value)
+		return true
+	})
+}
+
+// RenameColumn rename a column for the collection
+func (c *Collection) RenameColumn(ctx context.Context, oldName, newColumn string) error {
+	return c.changeColumn(ctx, func(doc bson.M) bool {
+		value, exist := getPath(doc, oldName)
+		if !exist {
+			return false
+		}
+		unsetPath(doc, oldName)
+		setPath(doc, newColumn, value)
+		return true
+	})
+}
+
+// DropColumn remove a column by the name
+func (c *Collection) DropColumn(ctx context.Context, field string) error {
+	return c.changeColumn(ctx, func(doc bson.M) bool {
+		return unsetPath(doc, field)
+	})
+}
+
+func (c *Collection) changeColumn(ctx context.Context, change func(doc bson.M) bool) error {
+	c.store.Lock()
+	defer c.store.Unlock()
+	tab, err := c.table(ctx, c.collName, false)
+	if err != nil || tab == nil {
+		return err
+	}
+	return tab.update(bson.M{}, change)
+}
+
+// AggregateAll aggregate all operation
+func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error {
+	docs, err := c.aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return decodeAll(docs, result)
+}
+
+// AggregateOne aggregate one operation
+func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
+	docs, err := c.aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return dal.ErrDocumentNotFound
+	}
+	return decode(docs[0], result)
+}
@@ -0,0 +1,174 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+
+	"icenter/src/common/storage/dal/matcher"
+)
+
+// copyDoc returns a deep copy of the document
+func copyDoc(doc bson.M) bson.M {
+	result, err := matcher.ToDoc(doc)
+	if err != nil {
+		// the document is always marshaled successfully because it's unmarshaled from bson
+		panic(err)
+	}
+	return result
+}
+
+// decode decode the document into the result as it's read from mongodb
+func decode(doc bson.M, result interface{}) error {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, result)
+}
+
+// document the document read by the iterator
+type document bson.M
+
+func (d document) Decode(result interface{}) error {
+	return decode(bson.M(d), result)
+}
+
+// decodeAll decode the documents into the result, which must be a slice address
+func decodeAll(docs []bson.M, result interface{}) error {
+	resultv := reflect.ValueOf(result)
+	if resultv.Kind() != reflect.Ptr || resultv.Elem().Kind() != reflect.Slice {
+		return errors.New("result argument must be a slice address")
+	}
+
+	elemt := resultv.Elem().Type().Elem()
+	slice := reflect.MakeSlice(resultv.Elem().Type(), 0, len(docs))
+	for _, doc := range docs {
+		if elemt.Kind() == reflect.Interface {
+			slice = reflect.Append(slice, reflect.ValueOf(doc))
+			continue
+		}
+		elemp := reflect.New(elemt)
+		if err := decode(doc, elemp.Interface()); err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, elemp.Elem())
+	}
+	resultv.Elem().Set(slice)
+	return nil
+}
+
+// getPath returns the value of the dotted path, the number in the path is the index of an array.
+func getPath(doc bson.M, path string) (interface{}, bool) {
+	var value interface{} = doc
+	for _, field := range strings.Split(path, ".") {
+		if sub, ok := matcher.SubDoc(value); ok {
+			if value, ok = sub[field]; !ok {
+				return nil, false
+			}
+			continue
+		}
+		array, ok := value.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		idx, err := strconv.Atoi(field)
+		if err != nil || idx < 0 || idx >= len(array) {
+			return nil, false
+		}
+		value = array[idx]
+	}
+	return value, true
+}
+
+// setPath set the value of the dotted path, the embedded documents are created if not exist.
+func setPath(doc bson.M, path string, value interface{}) {
+	fields := strings.Split(path, ".")
+	for _, field := range fields[:len(fields)-1] {
+		sub, ok := matcher.SubDoc(doc[field])
+		if !ok {
+			sub = bson.M{}
+			doc[field] = sub
+		}
+		doc = sub
+	}
+	doc[fields[len(fields)-1]] = value
+}
+
+// unsetPath remove the dotted path, returns false if it does not exist.
+func unsetPath(doc bson.M, path string) bool {
+	fields := strings.Split(path, ".")
+	for _, field := range fields[:len(fields)-1] {
+		sub, ok := matcher.SubDoc(doc[field])
+		if !ok {
+			return false
+		}
+		doc = sub
+	}
+	if _, exist := doc[fields[len(fields)-1]]; !exist {
+		return false
+	}
+	delete(doc, fields[len(fields)-1])
+	return true
+}
+
+// project returns a copy of the document with the fields only, the _id is excluded as the local mongo does.
+func project(doc bson.M, fields []string) bson.M {
+	if len(fields) == 0 {
+		result := copyDoc(doc)
+		delete(result, "_id")
+		return result
+	}
+
+	result := bson.M{}
+	for _, field := range fields {
+		if value, exist := getPath(doc, field); exist {
+			setPath(result, field, value)
+		}
+	}
+	return copyDoc(result)
+}
+
+// sortDocs sort the documents by the fields, the field is in descending order if it's prefixed with -.
+func sortDocs(docs []bson.M, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, field := range fields {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimLeft(field, "+-")
+			if field == "" {
+				continue
+			}
+			left, _ := getPath(docs[i], field)
+			right, _ := getPath(docs[j], field)
+			cmp := matcher.CompareOrder(left, right)
+			if cmp == 0 {
+				continue
+			}
+			if desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+}
@@ -0,0 +1,88 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"gopkg.in/mgo.v2/bson"
+
+	"icenter/src/common/storage/dal"
+)
+
+// ErrNotEmpty the data is loaded into a db which is not empty
+var ErrNotEmpty = errors.New("the memory db is not empty")
+
+// dump the data of the db, it's saved in the mongo extended json to keep the types of the values
+type dump struct {
+	Tables    map[string]dumpTable `bson:"tables"`
+	Sequences map[string]int64     `bson:"sequences"`
+}
+
+type dumpTable struct {
+	Indexes []dal.Index `bson:"indexes"`
+	Docs    []bson.M    `bson:"docs"`
+}
+
+// Dump writes all the tables and the sequences of the db to w, the data can be loaded by Load.
+func (m *Memory) Dump(w io.Writer) error {
+	m.store.Lock()
+	data := dump{
+		Tables:    make(map[string]dumpTable, len(m.store.tables)),
+		Sequences: make(map[string]int64, len(m.store.sequences)),
+	}
+	for name, tab := range m.store.tables {
+		data.Tables[name] = dumpTable{Indexes: tab.indexes, Docs: tab.docs}
+	}
+	for name, seq := range m.store.sequences {
+		data.Sequences[name] = int64(seq)
+	}
+	out, err := bson.MarshalJSON(data)
+	m.store.Unlock()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// Load loads the data written by Dump into the db, the db must be empty.
+func (m *Memory) Load(r io.Reader) error {
+	in, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	data := dump{}
+	if err := bson.UnmarshalJSON(in, &data); err != nil {
+		return err
+	}
+
+	m.store.Lock()
+	defer m.store.Unlock()
+	if len(m.store.tables) != 0 || len(m.store.sequences) != 0 {
+		return ErrNotEmpty
+	}
+	for name, dt := range data.Tables {
+		tab := &table{docs: make([]bson.M, 0, len(dt.Docs)), indexes: dt.Indexes}
+		for _, doc := range dt.Docs {
+			tab.docs = append(tab.docs, copyDoc(doc))
+		}
+		m.store.tables[name] = tab
+	}
+	for name, seq := range data.Sequences {
+		m.store.sequences[name] = uint64(seq)
+	}
+	return nil
+}
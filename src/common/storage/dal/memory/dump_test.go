@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"icenter/src/common/storage/dal"
+)
+
+func TestDumpLoad(t *testing.T) {
+	ctx := context.Background()
+	db := prepareHosts(t)
+	index := dal.Index{Name: "bk_host_innerip_1", Keys: map[string]int32{"bk_host_innerip": 1}, Unique: true}
+	require.NoError(t, db.Table("cc_HostBase").CreateIndex(ctx, index))
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, db.Table("cc_System").Insert(ctx, map[string]interface{}{"type": "version", "create_time": now}))
+	_, err := db.NextSequence(ctx, "cc_HostBase")
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, db.Dump(buf))
+	loaded := NewMemory()
+	require.NoError(t, loaded.Load(bytes.NewReader(buf.Bytes())))
+	require.Equal(t, ErrNotEmpty, loaded.Load(bytes.NewReader(buf.Bytes())))
+
+	hosts := make([]testHost, 0)
+	require.NoError(t, loaded.Table("cc_HostBase").Find(nil).Sort("bk_host_id").All(ctx, &hosts))
+	require.Equal(t, 4, len(hosts))
+	require.Equal(t, testHost{ID: 2, IP: "10.0.0.2", Cloud: 0, Labels: []string{"web", "db"}}, hosts[1])
+	// the types of the values and the indexes are kept
+	version := struct {
+		CreateTime time.Time `bson:"create_time"`
+	}{}
+	require.NoError(t, loaded.Table("cc_System").Find(nil).One(ctx, &version))
+	require.True(t, now.Equal(version.CreateTime))
+	err = loaded.Table("cc_HostBase").Insert(ctx, testHost{ID: 5, IP: "10.0.0.1"})
+	require.True(t, loaded.IsDuplicatedError(err))
+	id, err := loaded.NextSequence(ctx, "cc_HostBase")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), id)
+}
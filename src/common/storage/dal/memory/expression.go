@@ -0,0 +1,170 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+
+	"icenter/src/common/storage/dal/matcher"
+)
+
+// operator evaluate the expression operator with its argument on the document
+type operator func(doc bson.M, arg interface{}) interface{}
+
+// operators the supported expression operators
+var operators map[string]operator
+
+func initOperators() {
+	operators = map[string]operator{
+		"$arrayElemAt":    arrayElemAt,
+		"$dateToString":   dateToString,
+		"$dateFromString": dateFromString,
+	}
+}
+
+// arrayElemAt returns the element at the index, the negative index counts from the end
+func arrayElemAt(doc bson.M, arg interface{}) interface{} {
+	args, ok := evalExpr(doc, arg).([]interface{})
+	if !ok || len(args) != 2 {
+		return nil
+	}
+	array, ok := args[0].([]interface{})
+	index, isNumber := matcher.ToFloat(args[1])
+	if !ok || !isNumber {
+		return nil
+	}
+	idx := int(index)
+	if idx < 0 {
+		idx += len(array)
+	}
+	if idx < 0 || idx >= len(array) {
+		return nil
+	}
+	return array[idx]
+}
+
+// dateToString format the date with the format, such as "%Y-%m-%d"
+func dateToString(doc bson.M, arg interface{}) interface{} {
+	spec, ok := matcher.SubDoc(arg)
+	if !ok {
+		return nil
+	}
+	date, ok := evalExpr(doc, spec["date"]).(time.Time)
+	if !ok {
+		return nil
+	}
+	format, _ := spec["format"].(string)
+	if format == "" {
+		format = "%Y-%m-%dT%H:%M:%S.%LZ"
+	}
+	return formatDate(date.UTC(), format)
+}
+
+// dateFromString parse the date string with the format, returns onNull for the null string and onError
+// if it can't be parsed
+func dateFromString(doc bson.M, arg interface{}) interface{} {
+	spec, ok := matcher.SubDoc(arg)
+	if !ok {
+		return nil
+	}
+	value := evalExpr(doc, spec["dateString"])
+	if value == nil {
+		return evalExpr(doc, spec["onNull"])
+	}
+	str, ok := value.(string)
+	if !ok {
+		return evalExpr(doc, spec["onError"])
+	}
+	layout := time.RFC3339Nano
+	if format, _ := spec["format"].(string); format != "" {
+		var err error
+		if layout, err = dateLayout(format); err != nil {
+			return evalExpr(doc, spec["onError"])
+		}
+	}
+	date, err := time.ParseInLocation(layout, str, time.UTC)
+	if err != nil {
+		return evalExpr(doc, spec["onError"])
+	}
+	return date
+}
+
+// formatDate format the date with the mongodb format specifiers
+func formatDate(date time.Time, format string) string {
+	buf := strings.Builder{}
+	for idx := 0; idx < len(format); idx++ {
+		if format[idx] != '%' || idx+1 == len(format) {
+			buf.WriteByte(format[idx])
+			continue
+		}
+		idx++
+		switch format[idx] {
+		case 'Y':
+			fmt.Fprintf(&buf, "%04d", date.Year())
+		case 'm':
+			fmt.Fprintf(&buf, "%02d", int(date.Month()))
+		case 'd':
+			fmt.Fprintf(&buf, "%02d", date.Day())
+		case 'H':
+			fmt.Fprintf(&buf, "%02d", date.Hour())
+		case 'M':
+			fmt.Fprintf(&buf, "%02d", date.Minute())
+		case 'S':
+			fmt.Fprintf(&buf, "%02d", date.Second())
+		case 'L':
+			fmt.Fprintf(&buf, "%03d", date.Nanosecond()/int(time.Millisecond))
+		case 'j':
+			fmt.Fprintf(&buf, "%03d", date.YearDay())
+		case 'G':
+			year, _ := date.ISOWeek()
+			fmt.Fprintf(&buf, "%04d", year)
+		case 'V':
+			_, week := date.ISOWeek()
+			fmt.Fprintf(&buf, "%02d", week)
+		case 'u':
+			weekday := int(date.Weekday())
+			if weekday == 0 {
+				weekday = 7
+			}
+			fmt.Fprintf(&buf, "%d", weekday)
+		default:
+			buf.WriteByte(format[idx])
+		}
+	}
+	return buf.String()
+}
+
+// dateLayout convert the mongodb format specifiers to the go time layout
+func dateLayout(format string) (string, error) {
+	replacer := map[byte]string{'Y': "2006", 'm': "01", 'd': "02", 'H': "15", 'M': "04", 'S': "05", 'L': "000", '%': "%"}
+	buf := strings.Builder{}
+	for idx := 0; idx < len(format); idx++ {
+		if format[idx] != '%' {
+			buf.WriteByte(format[idx])
+			continue
+		}
+		if idx+1 == len(format) {
+			return "", fmt.Errorf("incomplete format specifier in %s", format)
+		}
+		idx++
+		layout, ok := replacer[format[idx]]
+		if !ok {
+			return "", fmt.Errorf("unsupported format specifier %%%c", format[idx])
+		}
+		buf.WriteString(layout)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,280 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+	"gopkg.in/mgo.v2/bson"
+
+	"icenter/src/common"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/types"
+)
+
+// ErrTransactionConflict the tables changed in the transaction are changed by others before it commits
+var ErrTransactionConflict = errors.New("transaction conflict, the tables are changed by others")
+
+// Memory implement dal.DB interface, all the data are saved in the process memory.
+// it's used by the unit tests and the single node dev mode, the data is lost when the process exits.
+type Memory struct {
+	store *store
+	txn   *transaction
+}
+
+var _ dal.DB = new(Memory)
+
+// store the tables and the sequences shared by all the clones
+type store struct {
+	sync.Mutex
+	tables    map[string]*table
+	sequences map[string]uint64
+	txns      map[string]*transaction
+}
+
+// table the documents in the insert order and the indexes of a table
+type table struct {
+	docs    []bson.M
+	indexes []dal.Index
+	// version increase on every change, it's used to detect the transaction conflict.
+	version uint64
+}
+
+// transaction the tables in the transaction are copied from the store when they are accessed
+// at the first time, the changes are saved to the store when it commits if no one else has
+// changed these tables since then.
+type transaction struct {
+	id       string
+	tables   map[string]*table
+	versions map[string]uint64
+}
+
+// NewMemory returns a new empty memory db
+func NewMemory() *Memory {
+	return &Memory{
+		store: &store{
+			tables:    make(map[string]*table),
+			sequences: make(map[string]uint64),
+			txns:      make(map[string]*transaction),
+		},
+	}
+}
+
+// Clone return the new client
+func (m *Memory) Clone() dal.DB {
+	nm := Memory{
+		store: m.store,
+		txn:   m.txn,
+	}
+	return &nm
+}
+
+// Close replica client
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Ping replica client
+func (m *Memory) Ping() error {
+	return nil
+}
+
+// IsDuplicatedError check duplicated error
+func (m *Memory) IsDuplicatedError(err error) bool {
+	return err == dal.ErrDuplicated
+}
+
+// IsNotFoundError check the not found error
+func (m *Memory) IsNotFoundError(err error) bool {
+	return err == dal.ErrDocumentNotFound
+}
+
+// Table collection operation
+func (m *Memory) Table(collName string) dal.Table {
+	return &Collection{collName: collName, Memory: m}
+}
+
+// NextSequence 获取新序列号(非事务)
+func (m *Memory) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+	m.store.Lock()
+	defer m.store.Unlock()
+	m.store.sequences[sequenceName]++
+	return m.store.sequences[sequenceName], nil
+}
+
+// StartTransaction 开启新事务
+func (m *Memory) StartTransaction(ctx context.Context) (dal.DB, error) {
+	if m.txn != nil {
+		return nil, dal.ErrTransactionStated
+	}
+	txn := &transaction{
+		id:       xid.New().String(),
+		tables:   make(map[string]*table),
+		versions: make(map[string]uint64),
+	}
+	m.store.Lock()
+	m.store.txns[txn.id] = txn
+	m.store.Unlock()
+
+	return &Memory{store: m.store, txn: txn}, nil
+}
+
+// Commit 提交事务
+func (m *Memory) Commit(ctx context.Context) error {
+	if m.txn == nil {
+		return dal.ErrTransactionNotFound
+	}
+	m.store.Lock()
+	defer m.store.Unlock()
+	txn := m.txn
+	m.txn = nil
+	if _, exist := m.store.txns[txn.id]; !exist {
+		return dal.ErrTransactionNotFound
+	}
+	delete(m.store.txns, txn.id)
+
+	for name := range txn.tables {
+		if m.store.version(name) != txn.versions[name] {
+			return ErrTransactionConflict
+		}
+	}
+	for name, tab := range txn.tables {
+		tab.version = txn.versions[name] + 1
+		m.store.tables[name] = tab
+	}
+	return nil
+}
+
+// Abort 取消事务
+func (m *Memory) Abort(ctx context.Context) error {
+	if m.txn == nil {
+		return dal.ErrTransactionNotFound
+	}
+	m.store.Lock()
+	delete(m.store.txns, m.txn.id)
+	m.store.Unlock()
+	m.txn = nil
+	return nil
+}
+
+// TxnInfo 当前事务信息，用于事务发起者往下传递
+func (m *Memory) TxnInfo() *types.Transaction {
+	if m.txn == nil {
+		return &types.Transaction{}
+	}
+	return &types.Transaction{TxnID: m.txn.id, CreateTime: time.Now(), LastTime: time.Now()}
+}
+
+// HasTable 判断是否存在集合
+func (m *Memory) HasTable(collName string) (bool, error) {
+	m.store.Lock()
+	defer m.store.Unlock()
+	_, exist := m.store.tables[collName]
+	return exist, nil
+}
+
+// DropTable 移除集合
+func (m *Memory) DropTable(collName string) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+	delete(m.store.tables, collName)
+	return nil
+}
+
+// CreateTable 创建集合
+func (m *Memory) CreateTable(collName string) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+	if _, exist := m.store.tables[collName]; exist {
+		return dal.ErrDuplicated
+	}
+	m.store.tables[collName] = newTable()
+	return nil
+}
+
+func newTable() *table {
+	return &table{
+		docs:    make([]bson.M, 0),
+		indexes: []dal.Index{{Name: "_id_", Keys: map[string]int32{"_id": 1}, Unique: true}},
+	}
+}
+
+func (s *store) version(name string) uint64 {
+	if tab, exist := s.tables[name]; exist {
+		return tab.version
+	}
+	return 0
+}
+
+// transaction returns the transaction of the db or the one joined with the context, it's nil if not in transaction.
+// the store must be locked by the caller.
+func (m *Memory) transaction(ctx context.Context) (*transaction, error) {
+	if m.txn != nil {
+		return m.txn, nil
+	}
+	opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption)
+	if !ok || opt.TxnID == "" {
+		return nil, nil
+	}
+	txn, exist := m.store.txns[opt.TxnID]
+	if !exist {
+		return nil, dal.ErrTransactionNotFound
+	}
+	return txn, nil
+}
+
+// table returns the table to read or write, it's created if create is true and the table does not exist,
+// the table is nil if it's not created. the store must be locked by the caller.
+func (m *Memory) table(ctx context.Context, name string, create bool) (*table, error) {
+	txn, err := m.transaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if txn == nil {
+		tab, exist := m.store.tables[name]
+		if !exist && create {
+			tab = newTable()
+			m.store.tables[name] = tab
+		}
+		return tab, nil
+	}
+
+	tab, exist := txn.tables[name]
+	if !exist {
+		txn.versions[name] = m.store.version(name)
+		if origin, ok := m.store.tables[name]; ok {
+			tab = origin.copy()
+		} else {
+			tab = newTable()
+		}
+		txn.tables[name] = tab
+	}
+	return tab, nil
+}
+
+func (t *table) copy() *table {
+	nt := &table{
+		docs:    make([]bson.M, 0, len(t.docs)),
+		indexes: append([]dal.Index{}, t.indexes...),
+		version: t.version,
+	}
+	for _, doc := range t.docs {
+		nt.docs = append(nt.docs, copyDoc(doc))
+	}
+	return nt
+}
@@ -0,0 +1,341 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"icenter/src/common"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/storage/dal"
+)
+
+type testHost struct {
+	ID     int64    `bson:"bk_host_id"`
+	IP     string   `bson:"bk_host_innerip"`
+	Cloud  int64    `bson:"bk_cloud_id"`
+	Labels []string `bson:"labels"`
+}
+
+func prepareHosts(t *testing.T) *Memory {
+	db := NewMemory()
+	hosts := []testHost{
+		{ID: 1, IP: "10.0.0.1", Cloud: 0, Labels: []string{"web"}},
+		{ID: 2, IP: "10.0.0.2", Cloud: 0, Labels: []string{"web", "db"}},
+		{ID: 3, IP: "192.168.0.1", Cloud: 1},
+		{ID: 4, IP: "192.168.0.2", Cloud: 1, Labels: []string{"db"}},
+	}
+	require.NoError(t, db.Table("cc_HostBase").Insert(context.Background(), hosts))
+	return db
+}
+
+func TestFind(t *testing.T) {
+	ctx := context.Background()
+	db := prepareHosts(t)
+	table := db.Table("cc_HostBase")
+
+	cases := []struct {
+		filter map[string]interface{}
+		expect []int64
+	}{
+		{nil, []int64{1, 2, 3, 4}},
+		{map[string]interface{}{"bk_cloud_id": 1}, []int64{3, 4}},
+		{map[string]interface{}{"bk_host_id": map[string]interface{}{common.BKDBIN: []int{1, 3}}}, []int64{1, 3}},
+		{map[string]interface{}{"bk_host_id": map[string]interface{}{common.BKDBNIN: []int64{1, 3}}}, []int64{2, 4}},
+		{map[string]interface{}{"bk_host_innerip": map[string]interface{}{common.BKDBLIKE: "^10\\."}}, []int64{1, 2}},
+		{map[string]interface{}{"bk_host_id": map[string]interface{}{common.BKDBGT: 1, common.BKDBLTE: 3}}, []int64{2, 3}},
+		{map[string]interface{}{"labels": "db"}, []int64{2, 4}},
+		{map[string]interface{}{"labels": map[string]interface{}{"$size": 0}}, []int64{3}},
+		{map[string]interface{}{"bk_host_innerip": nil}, []int64{}},
+		{map[string]interface{}{"operator": map[string]interface{}{common.BKDBExists: false}}, []int64{1, 2, 3, 4}},
+		{map[string]interface{}{common.BKDBOR: []map[string]interface{}{{"bk_host_id": 1}, {"bk_cloud_id": 1}}}, []int64{1, 3, 4}},
+		{map[string]interface{}{common.BKDBAND: []map[string]interface{}{{"labels": "web"}, {"labels": "db"}}}, []int64{2}},
+		{map[string]interface{}{"bk_host_id": map[string]interface{}{common.BKDBNot: map[string]interface{}{common.BKDBGT: 2}}}, []int64{1, 2}},
+	}
+	for _, c := range cases {
+		hosts := make([]testHost, 0)
+		require.NoError(t, table.Find(c.filter).Sort("bk_host_id").All(ctx, &hosts))
+		ids := make([]int64, 0)
+		for _, host := range hosts {
+			ids = append(ids, host.ID)
+		}
+		require.Equal(t, c.expect, ids, "filter: %v", c.filter)
+
+		count, err := table.Find(c.filter).Count(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(len(c.expect)), count)
+	}
+
+	hosts := make([]mapstr.MapStr, 0)
+	require.NoError(t, table.Find(nil).Fields("bk_host_id").Sort("-bk_cloud_id,bk_host_id").Start(1).Limit(2).All(ctx, &hosts))
+	require.Equal(t, 2, len(hosts))
+	require.Equal(t, 1, len(hosts[0]))
+	id, err := hosts[0].Int64("bk_host_id")
+	require.NoError(t, err)
+	require.Equal(t, int64(4), id)
+
+	host := testHost{}
+	require.Equal(t, dal.ErrDocumentNotFound, table.Find(map[string]interface{}{"bk_host_id": 5}).One(ctx, &host))
+	require.NoError(t, table.Find(map[string]interface{}{"bk_host_id": 2}).One(ctx, &host))
+	require.Equal(t, "10.0.0.2", host.IP)
+}
+
+func TestIterate(t *testing.T) {
+	ctx := context.Background()
+	db := prepareHosts(t)
+	table := db.Table("cc_HostBase")
+
+	ids := make([]int64, 0)
+	err := table.Find(map[string]interface{}{"bk_cloud_id": 0}).Sort("-bk_host_id").Iterate(ctx, func(doc dal.Decoder) error {
+		host := testHost{}
+		if err := doc.Decode(&host); err != nil {
+			return err
+		}
+		ids = append(ids, host.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int64{2, 1}, ids)
+
+	stop := errors.New("stop")
+	count := 0
+	err = table.Find(nil).Iterate(ctx, func(doc dal.Decoder) error {
+		count++
+		return stop
+	})
+	require.Equal(t, stop, err)
+	require.Equal(t, 1, count)
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	db := prepareHosts(t)
+	table := db.Table("cc_HostBase")
+
+	require.NoError(t, table.Update(ctx, map[string]interface{}{"bk_cloud_id": 1}, map[string]interface{}{"operator.name": "admin"}))
+	count, err := table.Find(map[string]interface{}{"operator.name": "admin"}).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+
+	require.NoError(t, table.Delete(ctx, map[string]interface{}{"bk_cloud_id": 0}))
+	count, err = table.Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+
+	require.NoError(t, table.DropColumn(ctx, "operator"))
+	count, err = table.Find(map[string]interface{}{"operator": map[string]interface{}{common.BKDBExists: true}}).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), count)
+}
+
+func TestUniqueIndex(t *testing.T) {
+	ctx := context.Background()
+	db := prepareHosts(t)
+	table := db.Table("cc_HostBase")
+
+	index := dal.Index{Name: "bk_host_innerip_1_bk_cloud_id_1", Keys: map[string]int32{"bk_host_innerip": 1, "bk_cloud_id": 1}, Unique: true}
+	require.NoError(t, table.CreateIndex(ctx, index))
+	require.Error(t, table.CreateIndex(ctx, index))
+
+	err := table.Insert(ctx, testHost{ID: 5, IP: "10.0.0.1", Cloud: 0})
+	require.True(t, db.IsDuplicatedError(err))
+	require.NoError(t, table.Insert(ctx, testHost{ID: 5, IP: "10.0.0.1", Cloud: 1}))
+
+	err = table.Update(ctx, map[string]interface{}{"bk_host_id": 2}, map[string]interface{}{"bk_host_innerip": "10.0.0.1"})
+	require.True(t, db.IsDuplicatedError(err))
+	host := testHost{}
+	require.NoError(t, table.Find(map[string]interface{}{"bk_host_id": 2}).One(ctx, &host))
+	require.Equal(t, "10.0.0.2", host.IP)
+
+	indexes, err := table.Indexes(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(indexes))
+	require.NoError(t, table.DropIndex(ctx, index.Name))
+	require.NoError(t, table.Insert(ctx, testHost{ID: 6, IP: "10.0.0.1", Cloud: 0}))
+}
+
+func TestNextSequence(t *testing.T) {
+	db := NewMemory()
+	for i := uint64(1); i <= 3; i++ {
+		id, err := db.NextSequence(context.Background(), "cc_HostBase")
+		require.NoError(t, err)
+		require.Equal(t, i, id)
+	}
+	id, err := db.Clone().NextSequence(context.Background(), "cc_ObjectBase")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), id)
+}
+
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := prepareHosts(t)
+
+	txn, err := db.StartTransaction(ctx)
+	require.NoError(t, err)
+	require.NoError(t, txn.Table("cc_HostBase").Delete(ctx, map[string]interface{}{"bk_host_id": 1}))
+	// the change is invisible out of the transaction before it commits
+	count, err := db.Table("cc_HostBase").Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), count)
+
+	// join the transaction with the context as the services do
+	joined := context.WithValue(ctx, common.CCContextKeyJoinOption, dal.JoinOption{TxnID: txn.TxnInfo().TxnID})
+	count, err = db.Table("cc_HostBase").Find(nil).Count(joined)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), count)
+
+	require.NoError(t, txn.Commit(ctx))
+	count, err = db.Table("cc_HostBase").Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), count)
+	_, err = db.Table("cc_HostBase").Find(nil).Count(joined)
+	require.Equal(t, dal.ErrTransactionNotFound, err)
+
+	// aborted
+	txn, err = db.StartTransaction(ctx)
+	require.NoError(t, err)
+	require.NoError(t, txn.Table("cc_HostBase").Insert(ctx, testHost{ID: 9}))
+	require.NoError(t, txn.Abort(ctx))
+	count, err = db.Table("cc_HostBase").Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), count)
+
+	// conflict
+	txn, err = db.StartTransaction(ctx)
+	require.NoError(t, err)
+	require.NoError(t, txn.Table("cc_HostBase").Insert(ctx, testHost{ID: 9}))
+	require.NoError(t, db.Table("cc_HostBase").Insert(ctx, testHost{ID: 10}))
+	require.Equal(t, ErrTransactionConflict, txn.Commit(ctx))
+}
+
+func TestAggregate(t *testing.T) {
+	ctx := context.Background()
+	db := prepareHosts(t)
+
+	result := make([]struct {
+		Cloud int64   `bson:"_id"`
+		Count int64   `bson:"count"`
+		IDs   []int64 `bson:"ids"`
+	}, 0)
+	pipeline := []map[string]interface{}{
+		{common.BKDBMatch: map[string]interface{}{"bk_host_id": map[string]interface{}{common.BKDBGT: 1}}},
+		{common.BKDBGroup: map[string]interface{}{
+			"_id":   "$bk_cloud_id",
+			"count": map[string]interface{}{common.BKDBSum: 1},
+			"ids":   map[string]interface{}{common.BKDBPush: "$bk_host_id"},
+		}},
+		{"$sort": map[string]interface{}{"_id": 1}},
+	}
+	require.NoError(t, db.Table("cc_HostBase").AggregateAll(ctx, pipeline, &result))
+	require.Equal(t, 2, len(result))
+	require.Equal(t, int64(1), result[0].Count)
+	require.Equal(t, []int64{3, 4}, result[1].IDs)
+
+	assts := []map[string]interface{}{
+		{"id": 1, "bk_inst_id": 1, "bk_asst_inst_id": 2},
+		{"id": 2, "bk_inst_id": 2, "bk_asst_inst_id": 3},
+		{"id": 3, "bk_inst_id": 3, "bk_asst_inst_id": 1},
+		{"id": 4, "bk_inst_id": 5, "bk_asst_inst_id": 6},
+	}
+	require.NoError(t, db.Table("cc_InstAsst").Insert(ctx, assts))
+	lookup := []map[string]interface{}{
+		{common.BKDBMatch: map[string]interface{}{"id": 1}},
+		{"$graphLookup": map[string]interface{}{
+			"from":             "cc_InstAsst",
+			"startWith":        "$bk_asst_inst_id",
+			"connectFromField": "bk_asst_inst_id",
+			"connectToField":   "bk_inst_id",
+			"as":               "edges",
+			"depthField":       "depth",
+		}},
+	}
+	found := struct {
+		Edges []struct {
+			ID    int64 `bson:"id"`
+			Depth int64 `bson:"depth"`
+		} `bson:"edges"`
+	}{}
+	require.NoError(t, db.Table("cc_InstAsst").AggregateOne(ctx, lookup, &found))
+	require.Equal(t, 3, len(found.Edges))
+	require.Equal(t, int64(2), found.Edges[0].ID)
+	require.Equal(t, int64(0), found.Edges[0].Depth)
+	require.Equal(t, int64(1), found.Edges[1].Depth)
+	// the cycle ends when the start association is reached again
+	require.Equal(t, int64(1), found.Edges[2].ID)
+	require.Equal(t, int64(2), found.Edges[2].Depth)
+}
+
+func TestAggregateLookupAndDates(t *testing.T) {
+	ctx := context.Background()
+	db := prepareHosts(t)
+
+	relations := []map[string]interface{}{
+		{"bk_host_id": 1, "bk_biz_id": 2},
+		{"bk_host_id": 2, "bk_biz_id": 2},
+		{"bk_host_id": 3, "bk_biz_id": 3},
+	}
+	require.NoError(t, db.Table("cc_ModuleHostConfig").Insert(ctx, relations))
+	dates := map[int64]string{1: "2019-12-30", 2: "2020-01-03", 3: "2020-01-06", 4: "invalid"}
+	for id, date := range dates {
+		require.NoError(t, db.Table("cc_HostBase").Update(ctx, map[string]interface{}{"bk_host_id": id},
+			map[string]interface{}{"online_date": date}))
+	}
+
+	pipeline := []map[string]interface{}{
+		{"$lookup": map[string]interface{}{
+			"from":         "cc_ModuleHostConfig",
+			"localField":   "bk_host_id",
+			"foreignField": "bk_host_id",
+			"as":           "relations",
+		}},
+		{"$addFields": map[string]interface{}{
+			"bk_biz_id": map[string]interface{}{"$arrayElemAt": []interface{}{"$relations.bk_biz_id", 0}},
+			"week": map[string]interface{}{"$dateToString": map[string]interface{}{
+				"format": "%G-W%V",
+				"date": map[string]interface{}{"$dateFromString": map[string]interface{}{
+					"dateString": "$online_date",
+					"format":     "%Y-%m-%d",
+					"onError":    nil,
+				}},
+			}},
+		}},
+		{common.BKDBGroup: map[string]interface{}{
+			"_id":   map[string]interface{}{"biz": "$bk_biz_id", "week": "$week"},
+			"count": map[string]interface{}{common.BKDBSum: 1},
+			"avg":   map[string]interface{}{"$avg": "$bk_host_id"},
+		}},
+		{"$sort": map[string]interface{}{"_id": 1}},
+	}
+	result := make([]struct {
+		ID struct {
+			Biz  interface{} `bson:"biz"`
+			Week interface{} `bson:"week"`
+		} `bson:"_id"`
+		Count int64   `bson:"count"`
+		Avg   float64 `bson:"avg"`
+	}, 0)
+	require.NoError(t, db.Table("cc_HostBase").AggregateAll(ctx, pipeline, &result))
+	require.Equal(t, 3, len(result))
+	// the host without any relation and with the invalid date is grouped into the null group
+	require.Nil(t, result[0].ID.Biz)
+	require.Nil(t, result[0].ID.Week)
+	// 2019-12-30 and 2020-01-03 are in the first iso week of 2020
+	require.Equal(t, "2020-W01", result[1].ID.Week)
+	require.Equal(t, int64(2), result[1].Count)
+	require.Equal(t, 1.5, result[1].Avg)
+	require.Equal(t, "2020-W02", result[2].ID.Week)
+}
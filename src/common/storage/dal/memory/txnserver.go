@@ -0,0 +1,76 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"icenter/src/common/blog"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/rpc"
+	"icenter/src/common/storage/types"
+)
+
+// NewTxnServer returns the rpc server which serves the transaction operations of the tmserver protocol
+// with the memory db, so that the other processes can start the transactions joined by the requests to
+// the process which holds the data in the single node dev mode. the data operations are not served,
+// they are sent to the process which holds the data.
+func NewTxnServer(m *Memory, opt rpc.ServerOptions) *rpc.Server {
+	server := rpc.NewServer()
+	server.SetOptions(opt)
+	server.Handle(types.CommandRDBOperation, func(input rpc.Request) (interface{}, error) {
+		reply := &types.OPReply{}
+		header := types.MsgHeader{}
+		if err := input.Decode(&header); err != nil {
+			reply.Message = err.Error()
+			return reply, nil
+		}
+		reply.RequestID = header.RequestID
+		blog.V(4).Infof("[MEMORY TRANSACTION] %+v", &header)
+
+		var err error
+		switch header.OPCode {
+		case types.OPStartTransactionCode:
+			var txn dal.DB
+			if txn, err = m.StartTransaction(context.Background()); err == nil {
+				reply.TxnID = txn.TxnInfo().TxnID
+			}
+		case types.OPCommitCode:
+			err = m.join(header.TxnID).Commit(context.Background())
+		case types.OPAbortCode:
+			err = m.join(header.TxnID).Abort(context.Background())
+		default:
+			err = fmt.Errorf("operation %s is not supported by the memory db", header.OPCode)
+		}
+		if err != nil {
+			reply.Message = err.Error()
+			return reply, nil
+		}
+		reply.Success = true
+		return reply, nil
+	})
+	return server
+}
+
+// join returns the db in the transaction, the commit and abort fail with dal.ErrTransactionNotFound
+// if the transaction does not exist.
+func (m *Memory) join(txnID string) *Memory {
+	m.store.Lock()
+	txn, exist := m.store.txns[txnID]
+	m.store.Unlock()
+	if !exist {
+		txn = &transaction{id: txnID}
+	}
+	return &Memory{store: m.store, txn: txn}
+}
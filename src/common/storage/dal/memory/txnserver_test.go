@@ -0,0 +1,60 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"icenter/src/common"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/dal/mongo/remote"
+	"icenter/src/common/storage/rpc"
+)
+
+func TestTxnServer(t *testing.T) {
+	ctx := context.Background()
+	db := prepareHosts(t)
+	server := httptest.NewServer(NewTxnServer(db, rpc.ServerOptions{}))
+	defer server.Close()
+	client, err := remote.New(strings.TrimPrefix(server.URL, "http://"), true)
+	require.NoError(t, err)
+	defer client.Close()
+
+	// the transaction started by the other process is joined by the requests with the txn id
+	txn, err := client.StartTransaction(ctx)
+	require.NoError(t, err)
+	joined := context.WithValue(ctx, common.CCContextKeyJoinOption, dal.JoinOption{TxnID: txn.TxnInfo().TxnID})
+	require.NoError(t, db.Table("cc_HostBase").Delete(joined, map[string]interface{}{"bk_host_id": 1}))
+	count, err := db.Table("cc_HostBase").Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), count)
+	require.NoError(t, txn.Commit(ctx))
+	count, err = db.Table("cc_HostBase").Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), count)
+
+	txn, err = client.StartTransaction(ctx)
+	require.NoError(t, err)
+	joined = context.WithValue(ctx, common.CCContextKeyJoinOption, dal.JoinOption{TxnID: txn.TxnInfo().TxnID})
+	require.NoError(t, db.Table("cc_HostBase").Delete(joined, map[string]interface{}{"bk_host_id": 2}))
+	require.NoError(t, txn.Abort(ctx))
+	count, err = db.Table("cc_HostBase").Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), count)
+	require.Error(t, txn.Commit(ctx))
+}
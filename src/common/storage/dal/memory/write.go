@@ -0,0 +1,360 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/dal/matcher"
+)
+
+// Upsert 更新第一条匹配的数据, 不存在则插入
+func (c *Collection) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	cond, ops, err := toUpdate(filter, doc)
+	if err != nil {
+		return err
+	}
+
+	c.store.Lock()
+	defer c.store.Unlock()
+	tab, err := c.table(ctx, c.collName, true)
+	if err != nil {
+		return err
+	}
+	_, _, err = tab.updateOne(cond, ops, true)
+	return err
+}
+
+// FindOneAndUpdate 原子更新第一条匹配的数据并返回更新前或更新后的数据
+func (c *Collection) FindOneAndUpdate(ctx context.Context, filter dal.Filter, doc interface{}, opts dal.FindOneAndUpdateOptions, result interface{}) error {
+	cond, ops, err := toUpdate(filter, doc)
+	if err != nil {
+		return err
+	}
+
+	c.store.Lock()
+	defer c.store.Unlock()
+	tab, err := c.table(ctx, c.collName, opts.Upsert)
+	if err != nil {
+		return err
+	}
+	if tab == nil {
+		return dal.ErrDocumentNotFound
+	}
+	before, after, err := tab.updateOne(cond, ops, opts.Upsert)
+	if err != nil {
+		return err
+	}
+	switch {
+	case after == nil:
+		return dal.ErrDocumentNotFound
+	case opts.ReturnNew:
+		return decode(after, result)
+	case before == nil:
+		// the upsert has no document before updated
+		return nil
+	default:
+		return decode(before, result)
+	}
+}
+
+// BulkWrite 批量写入
+func (c *Collection) BulkWrite(ctx context.Context, models []dal.WriteModel, opts dal.BulkWriteOptions) (*dal.BulkWriteResult, error) {
+	result := &dal.BulkWriteResult{}
+	failed := &dal.BulkWriteError{}
+	for idx, model := range models {
+		err := c.write(ctx, model, result)
+		if err == nil {
+			continue
+		}
+		failed.Errors = append(failed.Errors, dal.WriteError{Index: idx, Message: err.Error()})
+		if opts.Ordered {
+			break
+		}
+	}
+	if len(failed.Errors) > 0 {
+		return result, failed
+	}
+	return result, nil
+}
+
+// write execute the write model and add the counts to the result
+func (c *Collection) write(ctx context.Context, model dal.WriteModel, result *dal.BulkWriteResult) error {
+	switch model.Kind {
+	case dal.WriteKindInsert:
+		if err := c.Insert(ctx, model.Doc); err != nil {
+			return err
+		}
+		result.InsertedCount++
+		return nil
+	case dal.WriteKindDelete:
+		cond, err := matcher.ToDoc(model.Filter)
+		if err != nil {
+			return err
+		}
+		c.store.Lock()
+		defer c.store.Unlock()
+		tab, err := c.table(ctx, c.collName, false)
+		if err != nil || tab == nil {
+			return err
+		}
+		deleted, err := tab.remove(cond)
+		result.DeletedCount += deleted
+		return err
+	case dal.WriteKindUpdate, dal.WriteKindUpsert:
+	default:
+		return fmt.Errorf("unknown write kind %s", model.Kind)
+	}
+
+	cond, ops, err := toUpdate(model.Filter, model.Doc)
+	if err != nil {
+		return err
+	}
+	c.store.Lock()
+	defer c.store.Unlock()
+	tab, err := c.table(ctx, c.collName, model.Kind == dal.WriteKindUpsert)
+	if err != nil || tab == nil {
+		return err
+	}
+
+	if model.Kind == dal.WriteKindUpsert {
+		before, after, err := tab.updateOne(cond, ops, true)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			result.UpsertedCount++
+			return nil
+		}
+		result.MatchedCount++
+		if !reflect.DeepEqual(before, after) {
+			result.ModifiedCount++
+		}
+		return nil
+	}
+
+	matched, modified, err := tab.updateMany(cond, ops)
+	if err != nil {
+		return err
+	}
+	result.MatchedCount += matched
+	result.ModifiedCount += modified
+	return nil
+}
+
+// updateMany apply the update operators to all the matched documents, nothing is changed if any of them fails.
+func (t *table) updateMany(filter, ops bson.M) (uint64, uint64, error) {
+	// the operators are checked on the copies first, so that the update won't fail halfway
+	for _, doc := range t.docs {
+		matched, err := matcher.MatchDoc(doc, filter)
+		if err != nil {
+			return 0, 0, err
+		}
+		if matched {
+			if err := applyUpdate(copyDoc(doc), ops, false); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	var matched, modified uint64
+	err := t.update(filter, func(doc bson.M) bool {
+		matched++
+		before := copyDoc(doc)
+		applyUpdate(doc, ops, false)
+		if reflect.DeepEqual(before, doc) {
+			return false
+		}
+		modified++
+		return true
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return matched, modified, nil
+}
+
+// remove delete the matched documents and returns the count of them
+func (t *table) remove(filter bson.M) (uint64, error) {
+	docs := make([]bson.M, 0, len(t.docs))
+	for _, doc := range t.docs {
+		matched, err := matcher.MatchDoc(doc, filter)
+		if err != nil {
+			return 0, err
+		}
+		if !matched {
+			docs = append(docs, doc)
+		}
+	}
+	deleted := uint64(len(t.docs) - len(docs))
+	if deleted > 0 {
+		t.docs = docs
+		t.version++
+	}
+	return deleted, nil
+}
+
+// updateOne apply the update operators to the first matched document, or insert a new one built from the
+// equality conditions of the filter if upsert. it returns the copies of the document before and after updated,
+// the before is nil if it's inserted, both of them are nil if nothing is matched and not upsert.
+func (t *table) updateOne(filter, ops bson.M, upsert bool) (bson.M, bson.M, error) {
+	for idx, doc := range t.docs {
+		matched, err := matcher.MatchDoc(doc, filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !matched {
+			continue
+		}
+
+		updated := copyDoc(doc)
+		if err := applyUpdate(updated, ops, false); err != nil {
+			return nil, nil, err
+		}
+		if err := t.checkUnique(updated, idx); err != nil {
+			return nil, nil, err
+		}
+		if !reflect.DeepEqual(doc, updated) {
+			t.docs = append([]bson.M{}, t.docs...)
+			t.docs[idx] = updated
+			t.version++
+		}
+		return copyDoc(doc), copyDoc(updated), nil
+	}
+
+	if !upsert {
+		return nil, nil, nil
+	}
+	inserted := bson.M{}
+	for key, value := range filter {
+		if strings.HasPrefix(key, "$") {
+			continue
+		}
+		if _, isOperator := matcher.OperatorDoc(value); isOperator {
+			continue
+		}
+		setPath(inserted, key, value)
+	}
+	inserted = copyDoc(inserted)
+	if err := applyUpdate(inserted, ops, true); err != nil {
+		return nil, nil, err
+	}
+	if _, exist := inserted["_id"]; !exist {
+		inserted["_id"] = bson.NewObjectId()
+	}
+	if err := t.checkUnique(inserted, -1); err != nil {
+		return nil, nil, err
+	}
+	t.docs = append(t.docs, inserted)
+	t.version++
+	return nil, copyDoc(inserted), nil
+}
+
+// toUpdate convert the filter and the update doc, the doc is wrapped by $set if it's not update operators
+func toUpdate(filter dal.Filter, doc interface{}) (bson.M, bson.M, error) {
+	cond, err := matcher.ToDoc(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	ops, err := matcher.ToDoc(dal.UpdateOperators(doc))
+	if err != nil {
+		return nil, nil, err
+	}
+	return cond, ops, nil
+}
+
+// applyUpdate apply the update operators to the document, $setOnInsert is applied only if insert.
+func applyUpdate(doc bson.M, ops bson.M, insert bool) error {
+	for op, arg := range ops {
+		fields, ok := matcher.SubDoc(arg)
+		if !ok {
+			return fmt.Errorf("the argument of %s must be a document", op)
+		}
+		for path, value := range fields {
+			switch op {
+			case "$set":
+				setPath(doc, path, value)
+			case "$setOnInsert":
+				if insert {
+					setPath(doc, path, value)
+				}
+			case "$unset":
+				unsetPath(doc, path)
+			case "$inc":
+				result, err := increase(doc, path, value)
+				if err != nil {
+					return err
+				}
+				setPath(doc, path, result)
+			case "$push", "$addToSet":
+				array := make([]interface{}, 0)
+				if exist, ok := getPath(doc, path); ok && exist != nil {
+					if array, ok = exist.([]interface{}); !ok {
+						return fmt.Errorf("cannot apply %s to the non-array field %s", op, path)
+					}
+				}
+				values := []interface{}{value}
+				if each, ok := matcher.SubDoc(value); ok {
+					if items, ok := each["$each"].([]interface{}); ok {
+						values = items
+					}
+				}
+				for _, item := range values {
+					if op == "$addToSet" && matcher.MatchEqual(array, item) {
+						continue
+					}
+					array = append(array, item)
+				}
+				setPath(doc, path, array)
+			default:
+				return fmt.Errorf("unsupported update operator %s", op)
+			}
+		}
+	}
+	return nil
+}
+
+// increase returns the field value increased, it keeps to be an integer if both of them are integers.
+func increase(doc bson.M, path string, value interface{}) (interface{}, error) {
+	exist, ok := getPath(doc, path)
+	if !ok || exist == nil {
+		exist = 0
+	}
+	delta, ok := matcher.ToFloat(value)
+	if !ok {
+		return nil, fmt.Errorf("cannot increment with the non-numeric argument %v", value)
+	}
+	number, ok := matcher.ToFloat(exist)
+	if !ok {
+		return nil, fmt.Errorf("cannot apply $inc to the non-numeric field %s", path)
+	}
+	if isInteger(exist) && isInteger(value) {
+		return int64(number) + int64(delta), nil
+	}
+	return number + delta, nil
+}
+
+func isInteger(value interface{}) bool {
+	switch value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	default:
+		return false
+	}
+}
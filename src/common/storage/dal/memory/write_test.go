@@ -0,0 +1,133 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"icenter/src/common/storage/dal"
+)
+
+func TestUpsert(t *testing.T) {
+	ctx := context.Background()
+	db := prepareHosts(t)
+	table := db.Table("cc_HostBase")
+
+	require.NoError(t, table.Upsert(ctx, map[string]interface{}{"bk_host_id": 1}, map[string]interface{}{"bk_cloud_id": 2}))
+	require.NoError(t, table.Upsert(ctx, map[string]interface{}{"bk_host_id": 5}, map[string]interface{}{
+		"$set":         map[string]interface{}{"bk_host_innerip": "10.0.0.5"},
+		"$setOnInsert": map[string]interface{}{"bk_cloud_id": 3},
+	}))
+
+	host := testHost{}
+	require.NoError(t, table.Find(map[string]interface{}{"bk_host_id": 1}).One(ctx, &host))
+	require.Equal(t, int64(2), host.Cloud)
+	require.NoError(t, table.Find(map[string]interface{}{"bk_host_id": 5}).One(ctx, &host))
+	require.Equal(t, testHost{ID: 5, IP: "10.0.0.5", Cloud: 3}, host)
+
+	count, err := table.Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), count)
+}
+
+func TestFindOneAndUpdate(t *testing.T) {
+	ctx := context.Background()
+	db := prepareHosts(t)
+	table := db.Table("cc_HostBase")
+
+	before := testHost{}
+	inc := map[string]interface{}{"$inc": map[string]interface{}{"bk_cloud_id": 1}}
+	require.NoError(t, table.FindOneAndUpdate(ctx, map[string]interface{}{"bk_host_id": 3}, inc, dal.FindOneAndUpdateOptions{}, &before))
+	require.Equal(t, int64(1), before.Cloud)
+
+	after := testHost{}
+	opts := dal.FindOneAndUpdateOptions{ReturnNew: true}
+	require.NoError(t, table.FindOneAndUpdate(ctx, map[string]interface{}{"bk_host_id": 3}, inc, opts, &after))
+	require.Equal(t, int64(3), after.Cloud)
+
+	require.Equal(t, dal.ErrDocumentNotFound, table.FindOneAndUpdate(ctx, map[string]interface{}{"bk_host_id": 6}, inc, opts, &after))
+
+	// upsert returns nothing before updated
+	upserted := testHost{}
+	opts = dal.FindOneAndUpdateOptions{Upsert: true}
+	require.NoError(t, table.FindOneAndUpdate(ctx, map[string]interface{}{"bk_host_id": 6}, inc, opts, &upserted))
+	require.Equal(t, testHost{}, upserted)
+	opts.ReturnNew = true
+	require.NoError(t, table.FindOneAndUpdate(ctx, map[string]interface{}{"bk_host_id": 6}, inc, opts, &upserted))
+	require.Equal(t, testHost{ID: 6, Cloud: 2}, upserted)
+}
+
+func TestBulkWrite(t *testing.T) {
+	ctx := context.Background()
+	db := prepareHosts(t)
+	table := db.Table("cc_HostBase")
+	require.NoError(t, table.CreateIndex(ctx, dal.Index{Name: "ip", Keys: map[string]int32{"bk_host_innerip": 1}, Unique: true}))
+
+	models := []dal.WriteModel{
+		dal.NewInsertModel(testHost{ID: 5, IP: "10.0.0.5"}),
+		dal.NewUpdateModel(map[string]interface{}{"bk_cloud_id": 1}, map[string]interface{}{"labels": []string{"cloud"}}),
+		dal.NewInsertModel(testHost{ID: 6, IP: "10.0.0.1"}),
+		dal.NewUpsertModel(map[string]interface{}{"bk_host_id": 7}, map[string]interface{}{"bk_host_innerip": "10.0.0.7"}),
+		dal.NewDeleteModel(map[string]interface{}{"bk_host_id": 2}),
+	}
+
+	// ordered stops at the duplicated insert
+	result, err := table.BulkWrite(ctx, models, dal.BulkWriteOptions{Ordered: true})
+	require.Equal(t, &dal.BulkWriteResult{InsertedCount: 1, MatchedCount: 2, ModifiedCount: 2}, result)
+	failed, ok := err.(*dal.BulkWriteError)
+	require.True(t, ok)
+	require.Equal(t, 1, len(failed.Errors))
+	require.Equal(t, 2, failed.Errors[0].Index)
+
+	// unordered executes the rest
+	result, err = table.BulkWrite(ctx, models[2:], dal.BulkWriteOptions{})
+	require.Equal(t, &dal.BulkWriteResult{UpsertedCount: 1, DeletedCount: 1}, result)
+	failed, ok = err.(*dal.BulkWriteError)
+	require.True(t, ok)
+	require.Equal(t, []dal.WriteError{{Index: 0, Message: dal.ErrDuplicated.Error()}}, failed.Errors)
+
+	hosts := make([]testHost, 0)
+	require.NoError(t, table.Find(nil).Sort("bk_host_id").All(ctx, &hosts))
+	ids := make([]int64, 0)
+	for _, host := range hosts {
+		ids = append(ids, host.ID)
+	}
+	require.Equal(t, []int64{1, 3, 4, 5, 7}, ids)
+	require.Equal(t, []string{"cloud"}, hosts[1].Labels)
+}
+
+func TestBulkWriteInTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := prepareHosts(t)
+
+	txn, err := db.StartTransaction(ctx)
+	require.NoError(t, err)
+	models := []dal.WriteModel{
+		dal.NewUpsertModel(map[string]interface{}{"bk_host_id": 5}, map[string]interface{}{"bk_cloud_id": 2}),
+		dal.NewDeleteModel(map[string]interface{}{"bk_cloud_id": 0}),
+	}
+	_, err = txn.Table("cc_HostBase").BulkWrite(ctx, models, dal.BulkWriteOptions{Ordered: true})
+	require.NoError(t, err)
+
+	count, err := db.Table("cc_HostBase").Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), count)
+
+	require.NoError(t, txn.Commit(ctx))
+	count, err = db.Table("cc_HostBase").Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), count)
+}
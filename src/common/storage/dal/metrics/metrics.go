@@ -0,0 +1,216 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"icenter/src/common/metric"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/types"
+)
+
+var (
+	operationDuration = metric.NewHistogramVec("cmdb_mongo_operation_duration_seconds",
+		"Latency of the db operations by table and operation.", metric.DefaultBuckets, "table", "operation")
+	operationErrors = metric.NewCounterVec("cmdb_mongo_operation_errors_total",
+		"Count of the failed db operations by table and operation, not found is not an error.", "table", "operation")
+)
+
+func init() {
+	metric.Register(operationDuration)
+	metric.Register(operationErrors)
+}
+
+// NewDB returns a db recording the latency and the errors of the operations of each table
+func NewDB(db dal.DB) dal.DB {
+	if _, ok := db.(*metricsDB); ok {
+		return db
+	}
+	return &metricsDB{DB: db}
+}
+
+type metricsDB struct {
+	dal.DB
+}
+
+// observe record the operation, the not found error of the find one is not counted as an error.
+func (m *metricsDB) observe(table, operation string, start time.Time, err error) {
+	operationDuration.Observe(time.Since(start).Seconds(), table, operation)
+	if err != nil && !m.DB.IsNotFoundError(err) {
+		operationErrors.Inc(table, operation)
+	}
+}
+
+func (m *metricsDB) Clone() dal.DB {
+	return &metricsDB{DB: m.DB.Clone()}
+}
+
+func (m *metricsDB) Table(collection string) dal.Table {
+	return &metricsTable{Table: m.DB.Table(collection), db: m, name: collection}
+}
+
+func (m *metricsDB) StartTransaction(ctx context.Context) (dal.DB, error) {
+	start := time.Now()
+	db, err := m.DB.StartTransaction(ctx)
+	m.observe("", "start_transaction", start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsDB{DB: db}, nil
+}
+
+func (m *metricsDB) Commit(ctx context.Context) error {
+	start := time.Now()
+	err := m.DB.Commit(ctx)
+	m.observe("", "commit", start, err)
+	return err
+}
+
+func (m *metricsDB) Abort(ctx context.Context) error {
+	start := time.Now()
+	err := m.DB.Abort(ctx)
+	m.observe("", "abort", start, err)
+	return err
+}
+
+func (m *metricsDB) TxnInfo() *types.Transaction {
+	return m.DB.TxnInfo()
+}
+
+func (m *metricsDB) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+	start := time.Now()
+	id, err := m.DB.NextSequence(ctx, sequenceName)
+	m.observe(sequenceName, "next_sequence", start, err)
+	return id, err
+}
+
+type metricsTable struct {
+	dal.Table
+	db   *metricsDB
+	name string
+}
+
+func (t *metricsTable) Find(filter dal.Filter) dal.Find {
+	return &metricsFind{Find: t.Table.Find(filter), table: t}
+}
+
+func (t *metricsTable) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
+	start := time.Now()
+	err := t.Table.AggregateOne(ctx, pipeline, result)
+	t.db.observe(t.name, "aggregate", start, err)
+	return err
+}
+
+func (t *metricsTable) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error {
+	start := time.Now()
+	err := t.Table.AggregateAll(ctx, pipeline, result)
+	t.db.observe(t.name, "aggregate", start, err)
+	return err
+}
+
+func (t *metricsTable) Insert(ctx context.Context, docs interface{}) error {
+	start := time.Now()
+	err := t.Table.Insert(ctx, docs)
+	t.db.observe(t.name, "insert", start, err)
+	return err
+}
+
+func (t *metricsTable) Update(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	start := time.Now()
+	err := t.Table.Update(ctx, filter, doc)
+	t.db.observe(t.name, "update", start, err)
+	return err
+}
+
+func (t *metricsTable) Delete(ctx context.Context, filter dal.Filter) error {
+	start := time.Now()
+	err := t.Table.Delete(ctx, filter)
+	t.db.observe(t.name, "delete", start, err)
+	return err
+}
+
+func (t *metricsTable) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	start := time.Now()
+	err := t.Table.Upsert(ctx, filter, doc)
+	t.db.observe(t.name, "upsert", start, err)
+	return err
+}
+
+func (t *metricsTable) FindOneAndUpdate(ctx context.Context, filter dal.Filter, doc interface{}, opts dal.FindOneAndUpdateOptions, result interface{}) error {
+	start := time.Now()
+	err := t.Table.FindOneAndUpdate(ctx, filter, doc, opts, result)
+	t.db.observe(t.name, "find_one_and_update", start, err)
+	return err
+}
+
+func (t *metricsTable) BulkWrite(ctx context.Context, models []dal.WriteModel, opts dal.BulkWriteOptions) (*dal.BulkWriteResult, error) {
+	start := time.Now()
+	result, err := t.Table.BulkWrite(ctx, models, opts)
+	t.db.observe(t.name, "bulk_write", start, err)
+	return result, err
+}
+
+type metricsFind struct {
+	dal.Find
+	table *metricsTable
+}
+
+func (f *metricsFind) Fields(fields ...string) dal.Find {
+	f.Find = f.Find.Fields(fields...)
+	return f
+}
+
+func (f *metricsFind) Sort(sort string) dal.Find {
+	f.Find = f.Find.Sort(sort)
+	return f
+}
+
+func (f *metricsFind) Start(start uint64) dal.Find {
+	f.Find = f.Find.Start(start)
+	return f
+}
+
+func (f *metricsFind) Limit(limit uint64) dal.Find {
+	f.Find = f.Find.Limit(limit)
+	return f
+}
+
+func (f *metricsFind) All(ctx context.Context, result interface{}) error {
+	start := time.Now()
+	err := f.Find.All(ctx, result)
+	f.table.db.observe(f.table.name, "find", start, err)
+	return err
+}
+
+func (f *metricsFind) One(ctx context.Context, result interface{}) error {
+	start := time.Now()
+	err := f.Find.One(ctx, result)
+	f.table.db.observe(f.table.name, "find_one", start, err)
+	return err
+}
+
+func (f *metricsFind) Count(ctx context.Context) (uint64, error) {
+	start := time.Now()
+	count, err := f.Find.Count(ctx)
+	f.table.db.observe(f.table.name, "count", start, err)
+	return count, err
+}
+
+func (f *metricsFind) Iterate(ctx context.Context, handle func(doc dal.Decoder) error) error {
+	start := time.Now()
+	err := f.Find.Iterate(ctx, handle)
+	f.table.db.observe(f.table.name, "iterate", start, err)
+	return err
+}
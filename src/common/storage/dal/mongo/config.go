@@ -16,6 +16,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"icenter/src/common/storage/rpc"
 )
 
 // Config config
@@ -30,6 +32,8 @@ type Config struct {
 	MaxOpenConns string
 	MaxIdleConns string
 	Transaction  string
+	// RPC the connection config of the tmserver when the transaction is enabled
+	RPC rpc.Config
 }
 
 // BuildURI return mongo uri according to  https://docs.mongodb.com/manual/reference/connection-string/
@@ -78,5 +82,6 @@ func ParseConfigFromKV(prefix string, conifgmap map[string]string) Config {
 		MaxIdleConns: conifgmap[prefix+".maxIDleConns"],
 		Mechanism:    conifgmap[prefix+".mechanism"],
 		Transaction:  conifgmap[prefix+".transaction"],
+		RPC:          rpc.ParseConfigFromKV(prefix+".rpc", conifgmap),
 	}
 }
@@ -218,6 +218,20 @@ func (f *MockFind) Count(ctx context.Context) (uint64, error) {
 	return f.Mock.retval.Count, err
 }
 
+// Iterate 使用游标逐条读取, the mocked result of All is iterated
+func (f *MockFind) Iterate(ctx context.Context, handle func(doc dal.Decoder) error) error {
+	docs := make([]bson.Raw, 0)
+	if err := f.All(ctx, &docs); err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if err := handle(rawDocument(doc)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Insert 插入数据, docs 可以为 单个数据 或者 多个数据
 func (c *MockCollection) Insert(ctx context.Context, docs interface{}) error {
 	bsonout, err := bson.Marshal(docs)
@@ -272,6 +286,74 @@ func (c *MockCollection) Delete(ctx context.Context, filter dal.Filter) error {
 	return nil
 }
 
+// Upsert 更新第一条匹配的数据, 不存在则插入
+func (c *MockCollection) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	bsonout, err := bson.Marshal([]interface{}{filter, doc})
+	if err != nil {
+		return err
+	}
+
+	key := "UPSERT:" + c.collName + ":" + string(bsonout)
+	if retval, ok := c.Mock.cache[key]; ok {
+		return retval.Err
+	}
+
+	c.Mock.cache[key] = c.Mock.retval
+	c.Mock.retval = nil
+
+	return nil
+}
+
+// FindOneAndUpdate 原子更新第一条匹配的数据并返回更新前或更新后的数据
+func (c *MockCollection) FindOneAndUpdate(ctx context.Context, filter dal.Filter, doc interface{}, opts dal.FindOneAndUpdateOptions, result interface{}) error {
+	bsonout, err := bson.Marshal([]interface{}{filter, doc, opts})
+	if err != nil {
+		return err
+	}
+
+	key := "FINDONEANDUPDATE:" + c.collName + ":" + string(bsonout)
+	if retval, ok := c.Mock.cache[key]; ok {
+		err = bson.Unmarshal(retval.RawResult, result)
+		if err != nil {
+			return err
+		}
+		return retval.Err
+	}
+
+	bsonout, err = bson.Marshal(result)
+	if err != nil {
+		return err
+	}
+	c.Mock.retval.RawResult = bsonout
+	c.Mock.cache[key] = c.Mock.retval
+	c.Mock.retval = nil
+	return nil
+}
+
+// BulkWrite 批量写入
+func (c *MockCollection) BulkWrite(ctx context.Context, models []dal.WriteModel, opts dal.BulkWriteOptions) (*dal.BulkWriteResult, error) {
+	bsonout, err := bson.Marshal(bson.M{"models": models, "opts": opts})
+	if err != nil {
+		return nil, err
+	}
+
+	key := "BULKWRITE:" + c.collName + ":" + string(bsonout)
+	if retval, ok := c.Mock.cache[key]; ok {
+		result := &dal.BulkWriteResult{}
+		if len(retval.RawResult) > 0 {
+			if err := bson.Unmarshal(retval.RawResult, result); err != nil {
+				return nil, err
+			}
+		}
+		return result, retval.Err
+	}
+
+	c.Mock.cache[key] = c.Mock.retval
+	c.Mock.retval = nil
+
+	return &dal.BulkWriteResult{}, nil
+}
+
 // NextSequence 获取新序列号(非事务)
 func (c *Mock) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
 
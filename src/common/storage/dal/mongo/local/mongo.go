@@ -14,6 +14,7 @@ package local
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"time"
 
@@ -181,6 +182,37 @@ func (f *Find) Count(ctx context.Context) (uint64, error) {
 	return uint64(count), err
 }
 
+// Iterate 使用游标逐条读取
+func (f *Find) Iterate(ctx context.Context, handle func(doc dal.Decoder) error) error {
+	f.dbc.Refresh()
+	query := f.dbc.DB(f.dbname).C(f.collName).Find(f.filter)
+	query = query.Select(f.projection)
+	query = query.Skip(int(f.start))
+	query = query.Limit(int(f.limit))
+	query = query.Sort(f.sort...)
+	iter := query.Batch(types.CursorBatchSize).Iter()
+
+	raw := bson.Raw{}
+	for iter.Next(&raw) {
+		if err := ctx.Err(); err != nil {
+			iter.Close()
+			return err
+		}
+		if err := handle(rawDocument(raw)); err != nil {
+			iter.Close()
+			return err
+		}
+	}
+	return iter.Close()
+}
+
+// rawDocument the document read by the iterator
+type rawDocument bson.Raw
+
+func (d rawDocument) Decode(result interface{}) error {
+	return bson.Raw(d).Unmarshal(result)
+}
+
 // Insert 插入数据, docs 可以为 单个数据 或者 多个数据
 func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
 	c.dbc.Refresh()
@@ -202,6 +234,88 @@ func (c *Collection) Delete(ctx context.Context, filter dal.Filter) error {
 	return err
 }
 
+// Upsert 更新第一条匹配的数据, 不存在则插入
+func (c *Collection) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	c.dbc.Refresh()
+	_, err := c.dbc.DB(c.dbname).C(c.collName).Upsert(filter, dal.UpdateOperators(doc))
+	return err
+}
+
+// FindOneAndUpdate 原子更新第一条匹配的数据并返回更新前或更新后的数据
+func (c *Collection) FindOneAndUpdate(ctx context.Context, filter dal.Filter, doc interface{}, opts dal.FindOneAndUpdateOptions, result interface{}) error {
+	c.dbc.Refresh()
+	change := mgo.Change{
+		Update:    dal.UpdateOperators(doc),
+		Upsert:    opts.Upsert,
+		ReturnNew: opts.ReturnNew,
+	}
+	_, err := c.dbc.DB(c.dbname).C(c.collName).Find(filter).Apply(change, result)
+	if err == mgo.ErrNotFound {
+		return dal.ErrDocumentNotFound
+	}
+	return err
+}
+
+// BulkWrite 批量写入
+func (c *Collection) BulkWrite(ctx context.Context, models []dal.WriteModel, opts dal.BulkWriteOptions) (*dal.BulkWriteResult, error) {
+	c.dbc.Refresh()
+	coll := c.dbc.DB(c.dbname).C(c.collName)
+	result := &dal.BulkWriteResult{}
+	failed := &dal.BulkWriteError{}
+	for idx, model := range models {
+		err := write(coll, model, result)
+		if err == nil {
+			continue
+		}
+		failed.Errors = append(failed.Errors, dal.WriteError{Index: idx, Message: err.Error()})
+		if opts.Ordered {
+			break
+		}
+	}
+	if len(failed.Errors) > 0 {
+		return result, failed
+	}
+	return result, nil
+}
+
+// write execute the write model and add the counts to the result
+func write(coll *mgo.Collection, model dal.WriteModel, result *dal.BulkWriteResult) error {
+	switch model.Kind {
+	case dal.WriteKindInsert:
+		if err := coll.Insert(model.Doc); err != nil {
+			return err
+		}
+		result.InsertedCount++
+	case dal.WriteKindUpdate:
+		info, err := coll.UpdateAll(model.Filter, dal.UpdateOperators(model.Doc))
+		if err != nil {
+			return err
+		}
+		result.MatchedCount += uint64(info.Matched)
+		result.ModifiedCount += uint64(info.Updated)
+	case dal.WriteKindUpsert:
+		info, err := coll.Upsert(model.Filter, dal.UpdateOperators(model.Doc))
+		if err != nil {
+			return err
+		}
+		if info.UpsertedId != nil {
+			result.UpsertedCount++
+		} else {
+			result.MatchedCount += uint64(info.Matched)
+			result.ModifiedCount += uint64(info.Updated)
+		}
+	case dal.WriteKindDelete:
+		info, err := coll.RemoveAll(model.Filter)
+		if err != nil {
+			return err
+		}
+		result.DeletedCount += uint64(info.Removed)
+	default:
+		return fmt.Errorf("unknown write kind %s", model.Kind)
+	}
+	return nil
+}
+
 // NextSequence 获取新序列号(非事务)
 func (c *Mongo) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
 	c.dbc.Refresh()
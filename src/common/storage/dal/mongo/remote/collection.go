@@ -147,6 +147,152 @@ func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
 	return nil
 }
 
+// Upsert 更新第一条匹配的数据, 不存在则插入
+func (c *Collection) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	// build msg
+	msg := types.OPUpsertOperation{}
+	msg.OPCode = types.OPUpsertCode
+	msg.Collection = c.collection
+	if err := msg.DOC.Encode(dal.UpdateOperators(doc)); err != nil {
+		return err
+	}
+	if err := msg.Selector.Encode(filter); err != nil {
+		return err
+	}
+
+	// set txn
+	opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption)
+	if ok {
+		msg.RequestID = opt.RequestID
+		msg.TxnID = opt.TxnID
+	}
+	if c.TxnID != "" {
+		msg.TxnID = c.TxnID
+	}
+
+	// call
+	reply := types.OPReply{}
+	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	if err != nil {
+		return err
+	}
+	if !reply.Success {
+		return errors.New(reply.Message)
+	}
+	return nil
+}
+
+// FindOneAndUpdate 原子更新第一条匹配的数据并返回更新前或更新后的数据
+func (c *Collection) FindOneAndUpdate(ctx context.Context, filter dal.Filter, doc interface{}, opts dal.FindOneAndUpdateOptions, result interface{}) error {
+	// build msg
+	msg := types.OPFindAndModifyOperation{}
+	msg.OPCode = types.OPFindAndModifyCode
+	msg.Collection = c.collection
+	if err := msg.DOC.Encode(dal.UpdateOperators(doc)); err != nil {
+		return err
+	}
+	if err := msg.Selector.Encode(filter); err != nil {
+		return err
+	}
+	msg.Upsert = opts.Upsert
+	msg.ReturnNew = opts.ReturnNew
+
+	// set txn
+	opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption)
+	if ok {
+		msg.RequestID = opt.RequestID
+		msg.TxnID = opt.TxnID
+	}
+	if c.TxnID != "" {
+		msg.TxnID = c.TxnID
+	}
+
+	// call
+	reply := types.OPReply{}
+	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	if err != nil {
+		return err
+	}
+	if !reply.Success {
+		return errors.New(reply.Message)
+	}
+
+	// the upsert without the document before updated
+	if len(reply.Docs) <= 0 {
+		if opts.Upsert && !opts.ReturnNew {
+			return nil
+		}
+		return dal.ErrDocumentNotFound
+	}
+	return reply.Docs.Decode(result)
+}
+
+// BulkWrite 批量写入
+func (c *Collection) BulkWrite(ctx context.Context, models []dal.WriteModel, opts dal.BulkWriteOptions) (*dal.BulkWriteResult, error) {
+	// build msg
+	msg := types.OPBulkWriteOperation{}
+	msg.OPCode = types.OPBulkWriteCode
+	msg.Collection = c.collection
+	msg.Ordered = opts.Ordered
+	for _, model := range models {
+		item := types.BulkWriteModel{Kind: model.Kind}
+		switch model.Kind {
+		case dal.WriteKindInsert:
+			if err := item.DOC.Encode(model.Doc); err != nil {
+				return nil, err
+			}
+		case dal.WriteKindUpdate, dal.WriteKindUpsert:
+			if err := item.DOC.Encode(dal.UpdateOperators(model.Doc)); err != nil {
+				return nil, err
+			}
+		}
+		if err := item.Selector.Encode(model.Filter); err != nil {
+			return nil, err
+		}
+		msg.Models = append(msg.Models, item)
+	}
+
+	// set txn
+	opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption)
+	if ok {
+		msg.RequestID = opt.RequestID
+		msg.TxnID = opt.TxnID
+	}
+	if c.TxnID != "" {
+		msg.TxnID = c.TxnID
+	}
+
+	// call
+	reply := types.OPReply{}
+	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	if err != nil {
+		return nil, err
+	}
+	if !reply.Success {
+		return nil, errors.New(reply.Message)
+	}
+
+	ret := types.BulkWriteResult{}
+	if err := reply.Docs.Decode(&ret); err != nil {
+		return nil, err
+	}
+	result := &dal.BulkWriteResult{
+		InsertedCount: ret.InsertedCount,
+		MatchedCount:  ret.MatchedCount,
+		ModifiedCount: ret.ModifiedCount,
+		UpsertedCount: ret.UpsertedCount,
+		DeletedCount:  ret.DeletedCount,
+	}
+	if len(ret.Errors) > 0 {
+		failed := &dal.BulkWriteError{}
+		for _, item := range ret.Errors {
+			failed.Errors = append(failed.Errors, dal.WriteError{Index: item.Index, Message: item.Message})
+		}
+		return result, failed
+	}
+	return result, nil
+}
+
 // CreateIndex 创建索引
 func (c *Collection) CreateIndex(ctx context.Context, index dal.Index) error {
 	return dal.ErrNotImplemented
@@ -216,5 +362,33 @@ func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, res
 
 // AggregateAll 聚合查询
 func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error {
-	return dal.ErrNotImplemented
+	// build msg
+	msg := types.OPAggregateOperation{}
+	msg.OPCode = types.OPAggregateAllCode
+	msg.Collection = c.collection
+
+	if err := msg.Pipiline.Encode(pipeline); err != nil {
+		return err
+	}
+
+	// set txn
+	opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption)
+	if ok {
+		msg.RequestID = opt.RequestID
+		msg.TxnID = opt.TxnID
+	}
+	if c.TxnID != "" {
+		msg.TxnID = c.TxnID
+	}
+
+	// call
+	reply := types.OPReply{}
+	err := c.rpc.Call(types.CommandRDBOperation, msg, &reply)
+	if err != nil {
+		return err
+	}
+	if !reply.Success {
+		return errors.New(reply.Message)
+	}
+	return reply.Docs.Decode(result)
 }
@@ -18,6 +18,7 @@ import (
 
 	"icenter/src/common"
 	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/rpc"
 	"icenter/src/common/storage/types"
 )
 
@@ -133,3 +134,47 @@ func (f *Find) Count(ctx context.Context) (uint64, error) {
 	}
 	return reply.Count, nil
 }
+
+// Iterate 使用游标逐条读取, tmserver 按批返回, 每处理完一批再请求下一批
+func (f *Find) Iterate(ctx context.Context, handle func(doc dal.Decoder) error) error {
+	// set txn
+	opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption)
+	if ok {
+		f.msg.RequestID = opt.RequestID
+		f.msg.TxnID = opt.TxnID
+	}
+	if f.TxnID != "" {
+		f.msg.TxnID = f.TxnID
+	}
+
+	// open the cursor
+	stream, err := f.rpc.CallStream(types.CommandRDBCursorOperation, f.msg)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	next := types.OPCursorNext{RequestID: f.msg.RequestID}
+	for {
+		if err := stream.Send(&next); err != nil {
+			return err
+		}
+		reply := types.OPReply{}
+		err := stream.RecvContext(ctx, &reply)
+		if err == rpc.ErrStreamStoped {
+			// all the documents are read
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !reply.Success {
+			return errors.New(reply.Message)
+		}
+		for _, doc := range reply.Docs {
+			if err := handle(doc); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -55,7 +55,11 @@ func NewWithDiscover(getServer types.GetServerFunc, config mongo.Config) (db dal
 		}, nil
 	}
 
-	pool, err := rpc.NewClientPool("tcp", getServer, "/txn/v3/rpc")
+	rpcOpt, err := config.RPC.ClientOptions()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := rpc.NewClientPool("tcp", getServer, "/txn/v3/rpc", rpcOpt)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,128 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dal
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"icenter/src/common/storage/types"
+)
+
+// FindOneAndUpdateOptions the options of the find one and update
+type FindOneAndUpdateOptions struct {
+	// Upsert insert a new document if no document matches the filter
+	Upsert bool
+	// ReturnNew returns the document after updated, the document before updated is returned by default
+	ReturnNew bool
+}
+
+// the kinds of the write models
+const (
+	WriteKindInsert = types.BulkWriteInsert
+	WriteKindUpdate = types.BulkWriteUpdate
+	WriteKindUpsert = types.BulkWriteUpsert
+	WriteKindDelete = types.BulkWriteDelete
+)
+
+// WriteModel a write of the bulk write
+type WriteModel struct {
+	Kind   string
+	Filter Filter
+	Doc    interface{}
+}
+
+// NewInsertModel insert the doc
+func NewInsertModel(doc interface{}) WriteModel {
+	return WriteModel{Kind: WriteKindInsert, Doc: doc}
+}
+
+// NewUpdateModel update all the documents matched the filter, the same as Table.Update
+func NewUpdateModel(filter Filter, doc interface{}) WriteModel {
+	return WriteModel{Kind: WriteKindUpdate, Filter: filter, Doc: doc}
+}
+
+// NewUpsertModel update the first document matched the filter or insert a new one, the same as Table.Upsert
+func NewUpsertModel(filter Filter, doc interface{}) WriteModel {
+	return WriteModel{Kind: WriteKindUpsert, Filter: filter, Doc: doc}
+}
+
+// NewDeleteModel delete all the documents matched the filter, the same as Table.Delete
+func NewDeleteModel(filter Filter) WriteModel {
+	return WriteModel{Kind: WriteKindDelete, Filter: filter}
+}
+
+// BulkWriteOptions the options of the bulk write
+type BulkWriteOptions struct {
+	// Ordered the writes are executed in order and stop at the first failed one,
+	// otherwise all of them are executed and the failed ones are reported together.
+	Ordered bool
+}
+
+// BulkWriteResult the result of the bulk write
+type BulkWriteResult struct {
+	InsertedCount uint64 `json:"inserted_count"`
+	MatchedCount  uint64 `json:"matched_count"`
+	ModifiedCount uint64 `json:"modified_count"`
+	UpsertedCount uint64 `json:"upserted_count"`
+	DeletedCount  uint64 `json:"deleted_count"`
+}
+
+// WriteError the failed write of the bulk write
+type WriteError struct {
+	// Index the index of the write model
+	Index   int    `json:"index"`
+	Message string `json:"message"`
+}
+
+// BulkWriteError the bulk write error with all the failed writes
+type BulkWriteError struct {
+	Errors []WriteError
+}
+
+func (e *BulkWriteError) Error() string {
+	buf := bytes.NewBufferString("bulk write failed:")
+	for _, err := range e.Errors {
+		fmt.Fprintf(buf, " [%d] %s;", err.Index, err.Message)
+	}
+	return strings.TrimSuffix(buf.String(), ";")
+}
+
+// IsUpdateOperators returns whether the update doc is made of the update operators like $set and $inc,
+// such doc is applied as it is by Upsert, FindOneAndUpdate and the bulk writes, the other docs are set
+// to the matched documents like Update.
+func IsUpdateOperators(doc interface{}) bool {
+	value := reflect.ValueOf(doc)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Map || value.Type().Key().Kind() != reflect.String || value.Len() == 0 {
+		return false
+	}
+	for _, key := range value.MapKeys() {
+		if !strings.HasPrefix(key.String(), "$") {
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateOperators returns the update operators of the doc, it's wrapped by $set if it's not operators
+func UpdateOperators(doc interface{}) interface{} {
+	if IsUpdateOperators(doc) {
+		return doc
+	}
+	return map[string]interface{}{"$set": doc}
+}
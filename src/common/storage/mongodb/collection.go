@@ -14,6 +14,7 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 
 	"icenter/src/common/storage/mongodb/options/aggregateopt"
 	"icenter/src/common/storage/mongodb/options/deleteopt"
@@ -23,6 +24,9 @@ import (
 	"icenter/src/common/storage/mongodb/options/updateopt"
 )
 
+// ErrDocumentNotFound no document matched the filter
+var ErrDocumentNotFound = errors.New("document not found")
+
 // CollectionInterface collection operation methods
 type CollectionInterface interface {
 	Name() string
@@ -37,15 +41,24 @@ type CollectionInterface interface {
 
 	Find(ctx context.Context, filter interface{}, opts *findopt.Many, output interface{}) error
 	FindOne(ctx context.Context, filter interface{}, opts *findopt.One, output interface{}) error
+	// Iterate read the documents by a cursor, the handle is called for each document
+	Iterate(ctx context.Context, filter interface{}, opts *findopt.Many, batchSize int32, handle func(doc Decoder) error) error
+	// FindOneAndModify returns ErrDocumentNotFound if no document is returned
 	FindOneAndModify(ctx context.Context, filter interface{}, update interface{}, opts *findopt.FindAndModify, output interface{}) error
 
 	AggregateOne(ctx context.Context, pipeline interface{}, opts *aggregateopt.One, output interface{}) error
+	AggregateMany(ctx context.Context, pipeline interface{}, opts *aggregateopt.Many, output interface{}) error
 
 	InsertOne(ctx context.Context, document interface{}, opts *insertopt.One) error
 	InsertMany(ctx context.Context, document []interface{}, opts *insertopt.Many) error
 
 	UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts *updateopt.Many) (*UpdateResult, error)
 	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts *updateopt.One) (*UpdateResult, error)
+	// UpsertOne the update is the update operators, it's applied as it is
+	UpsertOne(ctx context.Context, filter interface{}, update interface{}) (*UpdateResult, error)
+
+	// BulkWrite the failed writes are returned in the result, the error is returned only if the bulk write can not be executed
+	BulkWrite(ctx context.Context, models []WriteModel, ordered bool) (*BulkWriteResult, error)
 
 	ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts *replaceopt.One) (*ReplaceOneResult, error)
 }
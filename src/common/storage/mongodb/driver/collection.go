@@ -15,6 +15,7 @@ package driver
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 
 	"icenter/src/common/storage/mongodb"
@@ -297,6 +298,48 @@ func decodeCusorIntoSlice(ctx context.Context, cursor *mongo.Cursor, result inte
 	return nil
 }
 
+func (c *collection) Iterate(ctx context.Context, filter interface{}, opts *findopt.Many, batchSize int32, handle func(doc mongodb.Decoder) error) error {
+
+	findOptions := &options.FindOptions{}
+	if nil != opts {
+		findOptions = opts.ConvertToMongoOptions()
+	}
+	if batchSize > 0 {
+		findOptions.SetBatchSize(batchSize)
+	}
+
+	// in a session
+	if nil != c.innerSession {
+		return mongo.WithSession(ctx, c.innerSession, func(mctx mongo.SessionContext) error {
+
+			cursor, err := c.innerCollection.Find(mctx, filter, findOptions)
+			if nil != err {
+				return err
+			}
+
+			defer cursor.Close(mctx)
+			return iterateCursor(mctx, cursor, handle)
+		})
+	}
+
+	// no session
+	cursor, err := c.innerCollection.Find(ctx, filter, findOptions)
+	if nil != err {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return iterateCursor(ctx, cursor, handle)
+}
+
+func iterateCursor(ctx context.Context, cursor *mongo.Cursor, handle func(doc mongodb.Decoder) error) error {
+	for cursor.Next(ctx) {
+		if err := handle(cursor); nil != err {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
 func (c *collection) FindOne(ctx context.Context, filter interface{}, opts *findopt.One, output interface{}) error {
 
 	findOptions := &options.FindOneOptions{}
@@ -325,12 +368,19 @@ func (c *collection) FindOneAndModify(ctx context.Context, filter interface{}, u
 	// in a session
 	if nil != c.innerSession {
 		return mongo.WithSession(ctx, c.innerSession, func(mctx mongo.SessionContext) error {
-			return c.innerCollection.FindOneAndUpdate(mctx, filter, update, findOneAndModify).Decode(output)
+			return notFoundError(c.innerCollection.FindOneAndUpdate(mctx, filter, update, findOneAndModify).Decode(output))
 		})
 	}
 
 	// no session
-	return c.innerCollection.FindOneAndUpdate(ctx, filter, update, findOneAndModify).Decode(output)
+	return notFoundError(c.innerCollection.FindOneAndUpdate(ctx, filter, update, findOneAndModify).Decode(output))
+}
+
+func notFoundError(err error) error {
+	if err == mongo.ErrNoDocuments {
+		return mongodb.ErrDocumentNotFound
+	}
+	return err
 }
 
 func (c *collection) AggregateOne(ctx context.Context, pipeline interface{}, opts *aggregateopt.One, output interface{}) error {
@@ -365,6 +415,34 @@ func (c *collection) AggregateOne(ctx context.Context, pipeline interface{}, opt
 	return cursor.Err()
 }
 
+func (c *collection) AggregateMany(ctx context.Context, pipeline interface{}, opts *aggregateopt.Many, output interface{}) error {
+	aggregateOptions := &options.AggregateOptions{}
+	if nil != opts {
+		aggregateOptions = opts.ConvertToMongoOptions()
+	}
+
+	// in a session
+	if nil != c.innerSession {
+		return mongo.WithSession(ctx, c.innerSession, func(mctx mongo.SessionContext) error {
+			cursor, err := c.innerCollection.Aggregate(mctx, pipeline, aggregateOptions)
+			if nil != err {
+				return err
+			}
+
+			defer cursor.Close(mctx)
+			return decodeCusorIntoSlice(mctx, cursor, output)
+		})
+	}
+
+	// no session
+	cursor, err := c.innerCollection.Aggregate(ctx, pipeline, aggregateOptions)
+	if nil != err {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return decodeCusorIntoSlice(ctx, cursor, output)
+}
+
 func (c *collection) InsertOne(ctx context.Context, document interface{}, opts *insertopt.One) error {
 
 	insertOption := &options.InsertOneOptions{}
@@ -526,3 +604,105 @@ func (c *collection) ReplaceOne(ctx context.Context, filter interface{}, replace
 		},
 	}, nil
 }
+
+func (c *collection) UpsertOne(ctx context.Context, filter interface{}, update interface{}) (*mongodb.UpdateResult, error) {
+
+	updateOption := options.Update().SetUpsert(true)
+
+	// in a session
+	if nil != c.innerSession {
+
+		returnResult := &mongodb.UpdateResult{}
+		err := mongo.WithSession(ctx, c.innerSession, func(mctx mongo.SessionContext) error {
+			updateResult, err := c.innerCollection.UpdateOne(mctx, filter, update, updateOption)
+			if nil != err {
+				return err
+			}
+
+			returnResult = &mongodb.UpdateResult{
+				MatchedCount:  uint64(updateResult.MatchedCount),
+				ModifiedCount: uint64(updateResult.ModifiedCount),
+				UpsertedCount: uint64(updateResult.UpsertedCount),
+			}
+			return nil
+		})
+
+		return returnResult, err
+	}
+
+	// no session
+	updateResult, err := c.innerCollection.UpdateOne(ctx, filter, update, updateOption)
+	if nil != err {
+		return &mongodb.UpdateResult{}, err
+	}
+
+	return &mongodb.UpdateResult{
+		MatchedCount:  uint64(updateResult.MatchedCount),
+		ModifiedCount: uint64(updateResult.ModifiedCount),
+		UpsertedCount: uint64(updateResult.UpsertedCount),
+	}, nil
+}
+
+func (c *collection) BulkWrite(ctx context.Context, models []mongodb.WriteModel, ordered bool) (*mongodb.BulkWriteResult, error) {
+
+	if len(models) == 0 {
+		return &mongodb.BulkWriteResult{}, nil
+	}
+
+	writeModels := make([]mongo.WriteModel, 0, len(models))
+	for _, model := range models {
+		switch model.Kind {
+		case mongodb.WriteInsert:
+			writeModels = append(writeModels, mongo.NewInsertOneModel().SetDocument(model.Document))
+		case mongodb.WriteUpdate:
+			writeModels = append(writeModels, mongo.NewUpdateManyModel().SetFilter(model.Filter).SetUpdate(model.Document))
+		case mongodb.WriteUpsert:
+			writeModels = append(writeModels, mongo.NewUpdateOneModel().SetFilter(model.Filter).SetUpdate(model.Document).SetUpsert(true))
+		case mongodb.WriteDelete:
+			writeModels = append(writeModels, mongo.NewDeleteManyModel().SetFilter(model.Filter))
+		default:
+			return nil, fmt.Errorf("unknown write kind %s", model.Kind)
+		}
+	}
+	bulkOption := options.BulkWrite().SetOrdered(ordered)
+
+	// in a session
+	if nil != c.innerSession {
+		returnResult := &mongodb.BulkWriteResult{}
+		err := mongo.WithSession(ctx, c.innerSession, func(mctx mongo.SessionContext) error {
+			var err error
+			returnResult, err = bulkWriteResult(c.innerCollection.BulkWrite(mctx, writeModels, bulkOption))
+			return err
+		})
+		return returnResult, err
+	}
+
+	// no session
+	return bulkWriteResult(c.innerCollection.BulkWrite(ctx, writeModels, bulkOption))
+}
+
+// bulkWriteResult convert the bulk write result, the failed writes are returned in the result,
+// the driver doesn't report the counts of the succeeded writes in this case.
+func bulkWriteResult(result *mongo.BulkWriteResult, err error) (*mongodb.BulkWriteResult, error) {
+	if exception, ok := err.(mongo.BulkWriteException); ok {
+		returnResult := &mongodb.BulkWriteResult{}
+		for _, writeErr := range exception.WriteErrors {
+			returnResult.Errors = append(returnResult.Errors, mongodb.WriteError{Index: writeErr.Index, Message: writeErr.Message})
+		}
+		if nil != exception.WriteConcernError && len(returnResult.Errors) == 0 {
+			return returnResult, exception
+		}
+		return returnResult, nil
+	}
+	if nil != err {
+		return &mongodb.BulkWriteResult{}, err
+	}
+
+	return &mongodb.BulkWriteResult{
+		InsertedCount: uint64(result.InsertedCount),
+		MatchedCount:  uint64(result.MatchedCount),
+		ModifiedCount: uint64(result.ModifiedCount),
+		UpsertedCount: uint64(result.UpsertedCount),
+		DeletedCount:  uint64(result.DeletedCount),
+	}, nil
+}
@@ -25,6 +25,22 @@ type UpdateResult struct {
 	ModifiedCount uint64 `json:"modifiedCount"`
 }
 
+// WriteError the failed write of the bulk write
+type WriteError struct {
+	Index   int    `json:"index"`
+	Message string `json:"message"`
+}
+
+// BulkWriteResult is a result of the bulk write
+type BulkWriteResult struct {
+	InsertedCount uint64       `json:"insertedCount"`
+	MatchedCount  uint64       `json:"matchedCount"`
+	ModifiedCount uint64       `json:"modifiedCount"`
+	UpsertedCount uint64       `json:"upsertedCount"`
+	DeletedCount  uint64       `json:"deletedCount"`
+	Errors        []WriteError `json:"errors"`
+}
+
 // ReplaceOneResult the  replace one function result
 type ReplaceOneResult struct {
 	UpdateResult `json:",inline"`
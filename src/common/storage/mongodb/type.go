@@ -28,6 +28,11 @@ type OpenCloser interface {
 	Closer
 }
 
+// Decoder decode the current document into the output
+type Decoder interface {
+	Decode(output interface{}) error
+}
+
 // Index the collection index definition
 type Index struct {
 	Keys       map[string]int32 `json:"keys"`
@@ -35,3 +40,18 @@ type Index struct {
 	Unique     bool             `json:"unique"`
 	Background bool             `json:"background"`
 }
+
+// the kinds of the write models
+const (
+	WriteInsert = "insert"
+	WriteUpdate = "update"
+	WriteUpsert = "upsert"
+	WriteDelete = "delete"
+)
+
+// WriteModel a write of the bulk write, the Document is the inserted document or the update operators
+type WriteModel struct {
+	Kind     string
+	Filter   interface{}
+	Document interface{}
+}
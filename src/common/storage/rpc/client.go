@@ -99,21 +99,36 @@ func Dial(connect string) (*client, error) {
 // DialHTTPPath connects to an HTTP RPC server
 // at the specified network address and path.
 func DialHTTPPath(network, address, path string) (*client, error) {
+	return DialHTTPPathWithOptions(network, address, path, nil)
+}
+
+// DialHTTPPathWithOptions connects to an HTTP RPC server at the specified network address and path,
+// and negotiates the compression, tls and authentication with the options, opt could be nil.
+func DialHTTPPathWithOptions(network, address, path string, opt *ClientOptions) (*client, error) {
 	blog.V(3).Infof("connecting to rpc server %s", address)
+	if opt == nil {
+		opt = &ClientOptions{}
+	}
 	var err error
 	conn, err := net.Dial("tcp", address)
 	if err != nil {
 		return nil, fmt.Errorf("[rpc] dail tcp error: %v", err)
 	}
-	io.WriteString(conn, "CONNECT "+path+" HTTP/1.0\n\n")
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	io.WriteString(conn, opt.connectRequest(path))
 
 	// Require successful HTTP response
 	// before switching to RPC protocol.
 	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
 	if err == nil && resp.Status == connected {
-		return NewClient(conn, "deflate")
-	}
-	if err == nil {
+		var compress string
+		var rpcConn net.Conn
+		rpcConn, compress, err = opt.handshake(conn, address, resp)
+		if err == nil {
+			rpcConn.SetDeadline(time.Time{})
+			return NewClient(rpcConn, compress)
+		}
+	} else if err == nil {
 		err = errors.New("unexpected HTTP response: " + resp.Status)
 	}
 	conn.Close()
@@ -160,17 +175,20 @@ func (c *client) CallStream(cmd string, input interface{}) (*StreamMessage, erro
 	c.stream.store(msg.seq, sm)
 	go func() {
 		for streammsg := range sm.output {
+			if c.done.IsSet() {
+				break
+			}
 			c.send <- streammsg
-			if msg.typz == TypeStreamClose {
+			if streammsg.typz == TypeStreamClose {
 				break
 			}
 			if sm.done.IsSet() || c.done.IsSet() {
 				break
 			}
 		}
-		sm.err = ErrStreamStoped
+		close(sm.stopped)
+		c.stream.remove(msg.seq)
 		close(sm.input)
-		close(sm.output)
 	}()
 
 	return sm, nil
@@ -256,8 +274,8 @@ func (c *client) handleRequest(req *Message) {
 	c.messages[req.seq] = req
 	c.messageMutex.Unlock()
 
-	c.send <- req
 	blog.V(5).Infof("[rpc client]sent message data: %s", req.Data)
+	c.send <- req
 }
 
 func (c *client) handleResponse(resp *Message) {
@@ -271,17 +289,21 @@ func (c *client) handleResponse(resp *Message) {
 			c.replyError(msg)
 		}
 		c.messageMutex.Unlock()
+		c.stream.closeAll(resp.transportErr)
 		return
 	}
 	if resp.typz == TypeStream || resp.typz == TypeStreamClose {
+		// the response is reused by the next read, so it's copied
+		streammsg := resp.copy()
+		streammsg.Data = resp.Data
 		c.stream.RLock()
 		stream, ok := c.stream.get(resp.seq)
-		c.stream.RUnlock()
 		if ok {
-			stream.input <- resp
+			stream.input <- streammsg
 		} else {
 			blog.Warnf("[rpc client] stream not found, resp is %s", resp.Data)
 		}
+		c.stream.RUnlock()
 		return
 	}
 	c.messageMutex.Lock()
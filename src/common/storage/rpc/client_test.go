@@ -19,6 +19,7 @@ import (
 	"net/http/httptest"
 	gorpc "net/rpc"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -108,6 +109,90 @@ func BenchmarkGORPCParallel(b *testing.B) {
 	})
 }
 
+type Counter struct {
+	Value int
+}
+
+// count send the next value on every request until it reaches the limit
+func count(limit int, closed chan<- error) HandlerStreamFunc {
+	return func(req Request, stream ServerStream) error {
+		for value := 1; value <= limit; value++ {
+			if err := stream.Recv(&Counter{}); err != nil {
+				closed <- err
+				return nil
+			}
+			if err := stream.Send(&Counter{Value: value}); err != nil {
+				return err
+			}
+		}
+		closed <- nil
+		return nil
+	}
+}
+
+func newStreamClient(t *testing.T, limit int, closed chan<- error) (*client, func()) {
+	rpc := NewServer()
+	rpc.HandleStream("count", count(limit, closed))
+	mux := http.NewServeMux()
+	mux.Handle("/rpc", rpc)
+	ts := httptest.NewServer(mux)
+
+	address, err := util.GetDailAddress(ts.URL)
+	require.NoError(t, err)
+	cli, err := DialHTTPPath("tcp", address, "/rpc")
+	require.NoError(t, err)
+	return cli, func() {
+		cli.Close()
+		ts.Close()
+	}
+}
+
+func TestCallStream(t *testing.T) {
+	closed := make(chan error, 1)
+	cli, stop := newStreamClient(t, 3, closed)
+	defer stop()
+
+	stream, err := cli.CallStream("count", &Req{Name: "count"})
+	require.NoError(t, err)
+	values := make([]int, 0)
+	for {
+		require.NoError(t, stream.Send(&Counter{}))
+		counter := Counter{}
+		err := stream.Recv(&counter)
+		if err == ErrStreamStoped {
+			break
+		}
+		require.NoError(t, err)
+		values = append(values, counter.Value)
+	}
+	require.NoError(t, stream.Close())
+	require.Equal(t, []int{1, 2, 3}, values)
+	require.NoError(t, <-closed)
+}
+
+func TestCallStreamClose(t *testing.T) {
+	closed := make(chan error, 1)
+	cli, stop := newStreamClient(t, 10, closed)
+	defer stop()
+
+	stream, err := cli.CallStream("count", &Req{Name: "count"})
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&Counter{}))
+	counter := Counter{}
+	require.NoError(t, stream.Recv(&counter))
+	require.Equal(t, 1, counter.Value)
+
+	// the handler waiting for the next request is stopped
+	require.NoError(t, stream.Close())
+	require.NoError(t, stream.Close())
+	select {
+	case err := <-closed:
+		require.Equal(t, ErrStreamStoped, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stream handler is not stopped")
+	}
+}
+
 type GORPC struct{}
 
 func (*GORPC) OK(req Req, reply *Reply) error {
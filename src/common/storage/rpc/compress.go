@@ -9,32 +9,121 @@
  * either express or implied. See the License for the specific language governing permissions and
  * limitations under the License.
  */
-
 package rpc
 
 import (
 	"bufio"
 	"compress/flate"
+	"compress/gzip"
+	"fmt"
 	"io"
+	"sync"
 )
 
-type compressor interface {
-	flushWriter
-	io.Reader
+// the compression codecs supported by default
+const (
+	CompressDeflate = "deflate"
+	CompressGzip    = "gzip"
+)
+
+// Compression create the readers and writers of a compression codec,
+// the writer must send all the pending data to the underlying writer on Flush
+type Compression interface {
+	NewReader(r io.Reader) (io.Reader, error)
+	NewWriter(w io.Writer) (FlushWriter, error)
 }
 
-type flushWriter interface {
+// FlushWriter a writer which could flush the buffered data
+type FlushWriter interface {
 	io.Writer
 	Flush() error
 }
 
+var compressions = struct {
+	sync.RWMutex
+	names  []string
+	codecs map[string]Compression
+}{codecs: map[string]Compression{}}
+
+// RegisterCompression register the compression codec with the name, the later one is preferred
+// when the client offers all the registered codecs
+func RegisterCompression(name string, compression Compression) {
+	compressions.Lock()
+	defer compressions.Unlock()
+	if _, ok := compressions.codecs[name]; !ok {
+		compressions.names = append([]string{name}, compressions.names...)
+	}
+	compressions.codecs[name] = compression
+}
+
+// Compressions returns the names of the registered compression codecs in preference order
+func Compressions() []string {
+	compressions.RLock()
+	defer compressions.RUnlock()
+	return append([]string{}, compressions.names...)
+}
+
+func getCompression(name string) (Compression, bool) {
+	compressions.RLock()
+	defer compressions.RUnlock()
+	compression, ok := compressions.codecs[name]
+	return compression, ok
+}
+
+func init() {
+	RegisterCompression(CompressGzip, &gzipCompression{})
+	RegisterCompression(CompressDeflate, &deflateCompression{})
+}
+
+type deflateCompression struct{}
+
+func (*deflateCompression) NewReader(r io.Reader) (io.Reader, error) {
+	return flate.NewReader(r), nil
+}
+
+func (*deflateCompression) NewWriter(w io.Writer) (FlushWriter, error) {
+	return flate.NewWriter(w, flate.DefaultCompression)
+}
+
+type gzipCompression struct{}
+
+// NewReader the gzip reader reads the header once it's created, so it's created
+// on the first read, otherwise the handshake blocks until the peer sends a message
+func (*gzipCompression) NewReader(r io.Reader) (io.Reader, error) {
+	return &lazyReader{newReader: func() (io.Reader, error) { return gzip.NewReader(r) }}, nil
+}
+
+func (*gzipCompression) NewWriter(w io.Writer) (FlushWriter, error) {
+	return gzip.NewWriter(w), nil
+}
+
+type lazyReader struct {
+	reader    io.Reader
+	newReader func() (io.Reader, error)
+}
+
+func (l *lazyReader) Read(p []byte) (n int, err error) {
+	if l.reader == nil {
+		if l.reader, err = l.newReader(); err != nil {
+			return 0, err
+		}
+	}
+	return l.reader.Read(p)
+}
+
+type compressor interface {
+	FlushWriter
+	io.Reader
+}
+
+// Compressor compress the data written and decompress the data read
 type Compressor struct {
 	zr io.Reader
-	zw flushWriter
+	zw FlushWriter
 }
 
 type flushWraper struct {
-	zw    flushWriter
+	zw    FlushWriter
 	flush func() error
 }
 
@@ -48,37 +137,40 @@ func (f *flushWraper) Write(p []byte) (n int, err error) {
 	return f.zw.Write(p)
 }
 
-func newFlushWraper(w flushWriter, flush func() error) flushWriter {
+func newFlushWraper(w FlushWriter, flush func() error) FlushWriter {
 	return &flushWraper{
 		zw:    w,
 		flush: flush,
 	}
 }
 
+// newCompressor returns the compressor of the codec, the data is not compressed if the codec is empty
 func newCompressor(r io.Reader, w io.Writer, compress string) (*Compressor, error) {
-	var zr io.Reader
-	var zw flushWriter
-	var err error
-
 	bw := bufio.NewWriterSize(w, writeBufferSize)
-	compress = ""
-	switch compress {
-	case "deflate":
-		zr = flate.NewReader(r)
-		zw, err = flate.NewWriter(bw, flate.DefaultCompression)
-		if err != nil {
-			return nil, err
-		}
-		zw = newFlushWraper(zw, bw.Flush)
-	default:
-		br := bufio.NewReaderSize(r, readBufferSize)
-		zr = br
-		zw = bw
+	br := bufio.NewReaderSize(r, readBufferSize)
+	if compress == "" {
+		return &Compressor{
+			zr: br,
+			zw: bw,
+		}, nil
+	}
+
+	compression, ok := getCompression(compress)
+	if !ok {
+		return nil, fmt.Errorf("unsupported compression %s", compress)
+	}
+	zr, err := compression.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+	zw, err := compression.NewWriter(bw)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Compressor{
 		zr: zr,
-		zw: zw,
+		zw: newFlushWraper(zw, bw.Flush),
 	}, nil
 }
 
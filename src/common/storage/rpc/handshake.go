@@ -0,0 +1,318 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"icenter/src/common/ssl"
+)
+
+// the headers of the connect request and response, the client offers the compression codecs
+// and asks for tls, and the server answers the chosen ones and the challenge of the secret.
+// the peers without these headers are served as before: no compression, no tls.
+const (
+	headerCompress  = "Rpc-Compress"
+	headerTLS       = "Rpc-Tls"
+	headerChallenge = "Rpc-Challenge"
+
+	tlsOn            = "on"
+	handshakeTimeout = 10 * time.Second
+	challengeSize    = 16
+)
+
+var (
+	authAccepted = []byte("OK")
+	authRejected = []byte("NO")
+)
+
+// handshake errors
+var (
+	ErrTLSRequired     = errors.New("tls is required by the rpc server")
+	ErrTLSNotSupported = errors.New("tls is not enabled on the rpc server")
+	ErrSecretRequired  = errors.New("secret is required by the rpc server")
+	ErrUnauthorized    = errors.New("rpc authentication failed")
+)
+
+// ClientOptions the options of the client connection
+type ClientOptions struct {
+	// Compress the compression codecs offered in preference order, all the registered ones are offered if it's nil
+	Compress []string
+	// TLS switch the connection to tls after the handshake, the connection fails if the server doesn't support it
+	TLS *tls.Config
+	// Secret answer the challenge of the server
+	Secret string
+}
+
+// ServerOptions the options of the server connection
+type ServerOptions struct {
+	// Compress the accepted compression codecs, all the registered ones are accepted if it's nil
+	Compress []string
+	// TLS accept the tls connections, the client certificates are verified if the ClientCAs is set
+	TLS *tls.Config
+	// AllowPlaintext accept the clients without tls when the tls is enabled, e.g. the clients not upgraded yet
+	AllowPlaintext bool
+	// Secret the clients must sign the challenge with it if it's set
+	Secret string
+}
+
+// Config the config of the rpc connection
+type Config struct {
+	// Compress the compression codecs separated by comma, "none" disables the compression
+	Compress       string
+	CAFile         string
+	CertFile       string
+	KeyFile        string
+	Password       string
+	ServerName     string
+	AllowPlaintext string
+	Secret         string
+}
+
+// ParseConfigFromKV returns a new config
+func ParseConfigFromKV(prefix string, configmap map[string]string) Config {
+	return Config{
+		Compress:       configmap[prefix+".compress"],
+		CAFile:         configmap[prefix+".tls.caFile"],
+		CertFile:       configmap[prefix+".tls.certFile"],
+		KeyFile:        configmap[prefix+".tls.keyFile"],
+		Password:       configmap[prefix+".tls.password"],
+		ServerName:     configmap[prefix+".tls.serverName"],
+		AllowPlaintext: configmap[prefix+".tls.allowPlaintext"],
+		Secret:         configmap[prefix+".secret"],
+	}
+}
+
+func (c Config) compressions() []string {
+	switch strings.TrimSpace(c.Compress) {
+	case "":
+		return nil
+	case "none":
+		return []string{}
+	}
+	names := make([]string, 0)
+	for _, name := range strings.Split(c.Compress, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ClientOptions returns the client options, the tls is enabled if the ca file is set,
+// and the client certificate is sent if the cert file is set
+func (c Config) ClientOptions() (*ClientOptions, error) {
+	opt := &ClientOptions{
+		Compress: c.compressions(),
+		Secret:   c.Secret,
+	}
+	if c.CAFile == "" {
+		return opt, nil
+	}
+
+	var err error
+	if c.CertFile != "" {
+		opt.TLS, err = ssl.ClientTLSConfVerity(c.CAFile, c.CertFile, c.KeyFile, c.Password)
+	} else {
+		opt.TLS, err = ssl.ClientTslConfVerityServer(c.CAFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load rpc client tls config failed, err: %v", err)
+	}
+	opt.TLS.ServerName = c.ServerName
+	return opt, nil
+}
+
+// ServerOptions returns the server options, the tls is enabled if the cert file is set,
+// and the client certificates are required if the ca file is set
+func (c Config) ServerOptions() (*ServerOptions, error) {
+	opt := &ServerOptions{
+		Compress:       c.compressions(),
+		AllowPlaintext: c.AllowPlaintext == "true",
+		Secret:         c.Secret,
+	}
+	if c.CertFile == "" {
+		return opt, nil
+	}
+
+	var err error
+	opt.TLS, err = ssl.ServerTslConf(c.CAFile, c.CertFile, c.KeyFile, c.Password)
+	if err != nil {
+		return nil, fmt.Errorf("load rpc server tls config failed, err: %v", err)
+	}
+	return opt, nil
+}
+
+// connectRequest returns the connect request with the offers of the client
+func (opt *ClientOptions) connectRequest(path string) string {
+	offers := opt.Compress
+	if offers == nil {
+		offers = Compressions()
+	}
+
+	req := "CONNECT " + path + " HTTP/1.0\n"
+	if len(offers) > 0 {
+		req += headerCompress + ": " + strings.Join(offers, ",") + "\n"
+	}
+	if opt.TLS != nil {
+		req += headerTLS + ": " + tlsOn + "\n"
+	}
+	return req + "\n"
+}
+
+// handshake finish the handshake of the client with the connect response,
+// returns the connection to use and the compression codec
+func (opt *ClientOptions) handshake(conn net.Conn, address string, resp *http.Response) (net.Conn, string, error) {
+	compress := resp.Header.Get(headerCompress)
+	if _, ok := getCompression(compress); compress != "" && !ok {
+		return nil, "", fmt.Errorf("unsupported compression %s chosen by the rpc server", compress)
+	}
+
+	if opt.TLS != nil {
+		if resp.Header.Get(headerTLS) != tlsOn {
+			return nil, "", ErrTLSNotSupported
+		}
+		config := opt.TLS
+		if config.ServerName == "" && !config.InsecureSkipVerify {
+			config = config.Clone()
+			config.ServerName, _, _ = net.SplitHostPort(address)
+		}
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, "", fmt.Errorf("rpc tls handshake failed, err: %v", err)
+		}
+		conn = tlsConn
+	}
+
+	if challenge := resp.Header.Get(headerChallenge); challenge != "" {
+		if opt.Secret == "" {
+			return nil, "", ErrSecretRequired
+		}
+		if _, err := io.WriteString(conn, sign(opt.Secret, challenge)); err != nil {
+			return nil, "", err
+		}
+		ack := make([]byte, len(authAccepted))
+		if _, err := io.ReadFull(conn, ack); err != nil || !hmac.Equal(ack, authAccepted) {
+			return nil, "", ErrUnauthorized
+		}
+	}
+	return conn, compress, nil
+}
+
+// negotiation the result of the server side negotiation
+type negotiation struct {
+	compress  string
+	tls       bool
+	challenge string
+}
+
+// negotiate choose the compression codec and the tls according to the connect request
+func (opt *ServerOptions) negotiate(req *http.Request) (*negotiation, error) {
+	result := &negotiation{}
+	for _, name := range strings.Split(req.Header.Get(headerCompress), ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := getCompression(name); ok && opt.accept(name) {
+			result.compress = name
+			break
+		}
+	}
+
+	result.tls = req.Header.Get(headerTLS) == tlsOn
+	if result.tls && opt.TLS == nil {
+		return nil, ErrTLSNotSupported
+	}
+	if !result.tls && opt.TLS != nil && !opt.AllowPlaintext {
+		return nil, ErrTLSRequired
+	}
+
+	if opt.Secret != "" {
+		nonce := make([]byte, challengeSize)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		result.challenge = hex.EncodeToString(nonce)
+	}
+	return result, nil
+}
+
+func (opt *ServerOptions) accept(compress string) bool {
+	if opt.Compress == nil {
+		return true
+	}
+	for _, name := range opt.Compress {
+		if name == compress {
+			return true
+		}
+	}
+	return false
+}
+
+// header returns the response headers of the negotiation
+func (n *negotiation) header() string {
+	header := ""
+	if n.compress != "" {
+		header += headerCompress + ": " + n.compress + "\n"
+	}
+	if n.tls {
+		header += headerTLS + ": " + tlsOn + "\n"
+	}
+	if n.challenge != "" {
+		header += headerChallenge + ": " + n.challenge + "\n"
+	}
+	return header
+}
+
+// handshake finish the handshake of the server after the connect response is sent,
+// returns the connection to use
+func (opt *ServerOptions) handshake(conn net.Conn, n *negotiation) (net.Conn, error) {
+	if n.tls {
+		tlsConn := tls.Server(conn, opt.TLS)
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, fmt.Errorf("rpc tls handshake failed, err: %v", err)
+		}
+		conn = tlsConn
+	}
+
+	if n.challenge != "" {
+		expect := sign(opt.Secret, n.challenge)
+		signature := make([]byte, len(expect))
+		if _, err := io.ReadFull(conn, signature); err != nil {
+			return nil, err
+		}
+		if !hmac.Equal(signature, []byte(expect)) {
+			conn.Write(authRejected)
+			return nil, ErrUnauthorized
+		}
+		if _, err := conn.Write(authAccepted); err != nil {
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// sign returns the hex encoded hmac-sha256 of the challenge with the secret
+func sign(secret, challenge string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(challenge))
+	return hex.EncodeToString(mac.Sum(nil))
+}
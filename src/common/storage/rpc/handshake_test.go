@@ -0,0 +1,197 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rpc
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"icenter/src/common/util"
+)
+
+func newOptionsServer(t *testing.T, opt ServerOptions) (string, func()) {
+	rpc := NewServer()
+	rpc.SetOptions(opt)
+	rpc.Handle("ok", OK)
+	mux := http.NewServeMux()
+	mux.Handle("/rpc", rpc)
+	ts := httptest.NewServer(mux)
+
+	address, err := util.GetDailAddress(ts.URL)
+	require.NoError(t, err)
+	return address, ts.Close
+}
+
+func callOK(t *testing.T, cli *client) {
+	defer cli.Close()
+	reply := Reply{}
+	require.NoError(t, cli.Call("ok", &Req{Name: "ok"}, &reply))
+	require.True(t, reply.OK)
+}
+
+func TestNegotiateCompress(t *testing.T) {
+	cases := []struct {
+		offer  string
+		accept []string
+		expect string
+	}{
+		{"", nil, ""},
+		{"gzip, deflate", nil, "gzip"},
+		{"snappy,deflate", nil, "deflate"},
+		{"gzip,deflate", []string{"deflate"}, "deflate"},
+		{"gzip", []string{}, ""},
+	}
+	for _, c := range cases {
+		req := &http.Request{Header: http.Header{}}
+		if c.offer != "" {
+			req.Header.Set(headerCompress, c.offer)
+		}
+		opt := &ServerOptions{Compress: c.accept}
+		n, err := opt.negotiate(req)
+		require.NoError(t, err)
+		require.Equal(t, c.expect, n.compress, "offer: %s", c.offer)
+	}
+}
+
+func TestDialCompress(t *testing.T) {
+	address, stop := newOptionsServer(t, ServerOptions{})
+	defer stop()
+
+	for _, compress := range [][]string{nil, {CompressGzip}, {CompressDeflate}, {}} {
+		cli, err := DialHTTPPathWithOptions("tcp", address, "/rpc", &ClientOptions{Compress: compress})
+		require.NoError(t, err)
+		callOK(t, cli)
+	}
+}
+
+// TestDialLegacy the clients without the handshake headers are served without compression
+func TestDialLegacy(t *testing.T) {
+	address, stop := newOptionsServer(t, ServerOptions{})
+	defer stop()
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	io.WriteString(conn, "CONNECT /rpc HTTP/1.0\n\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
+	require.NoError(t, err)
+	require.Equal(t, connected, resp.Status)
+	require.Equal(t, "", resp.Header.Get(headerCompress))
+
+	cli, err := NewClient(conn, "")
+	require.NoError(t, err)
+	callOK(t, cli)
+}
+
+func TestDialSecret(t *testing.T) {
+	address, stop := newOptionsServer(t, ServerOptions{Secret: "s3cret"})
+	defer stop()
+
+	cli, err := DialHTTPPathWithOptions("tcp", address, "/rpc", &ClientOptions{Secret: "s3cret"})
+	require.NoError(t, err)
+	callOK(t, cli)
+
+	_, err = DialHTTPPathWithOptions("tcp", address, "/rpc", &ClientOptions{Secret: "wrong"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), ErrUnauthorized.Error())
+
+	_, err = DialHTTPPath("tcp", address, "/rpc")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), ErrSecretRequired.Error())
+}
+
+func TestDialTLS(t *testing.T) {
+	ca, caKey := newCertificate(t, nil, nil, true)
+	serverCert, serverKey := newCertificate(t, ca, caKey, false)
+	clientCert, clientKey := newCertificate(t, ca, caKey, false)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	address, stop := newOptionsServer(t, ServerOptions{
+		TLS: &tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+			ClientCAs:    pool,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		},
+	})
+	defer stop()
+
+	cli, err := DialHTTPPathWithOptions("tcp", address, "/rpc", &ClientOptions{
+		TLS: &tls.Config{
+			RootCAs:      pool,
+			Certificates: []tls.Certificate{{Certificate: [][]byte{clientCert.Raw}, PrivateKey: clientKey}},
+		},
+	})
+	require.NoError(t, err)
+	callOK(t, cli)
+
+	// without the client certificate, the server rejects it after the client finishes the tls 1.3 handshake
+	cli, err = DialHTTPPathWithOptions("tcp", address, "/rpc", &ClientOptions{TLS: &tls.Config{RootCAs: pool}})
+	if err == nil {
+		err = cli.Call("ok", &Req{Name: "ok"}, &Reply{})
+		cli.Close()
+	}
+	require.Error(t, err)
+
+	// without tls
+	_, err = DialHTTPPath("tcp", address, "/rpc")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), ErrTLSRequired.Error())
+
+	// the server without tls
+	plainAddress, stopPlain := newOptionsServer(t, ServerOptions{})
+	defer stopPlain()
+	_, err = DialHTTPPathWithOptions("tcp", plainAddress, "/rpc", &ClientOptions{TLS: &tls.Config{RootCAs: pool}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), ErrTLSNotSupported.Error())
+}
+
+// newCertificate create a certificate for 127.0.0.1 signed by the parent, or a self signed ca if parent is nil
+func newCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, isCA bool) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "rpc test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
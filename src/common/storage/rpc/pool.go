@@ -29,12 +29,19 @@ type Pool struct {
 
 	getServer types.GetServerFunc
 	lastIndex int
+	network   string
+	path      string
+	options   *ClientOptions
 }
 
-func NewClientPool(network string, getServer types.GetServerFunc, path string) (*Pool, error) {
+// NewClientPool returns the pool of the connections to the servers, opt could be nil
+func NewClientPool(network string, getServer types.GetServerFunc, path string, opt *ClientOptions) (*Pool, error) {
 	pool := &Pool{
 		conns:     make(chan Client, 40),
 		getServer: getServer,
+		network:   network,
+		path:      path,
+		options:   opt,
 	}
 	var err error
 	var conn Client
@@ -92,7 +99,7 @@ func (p *Pool) new() (Client, error) {
 		return nil, fmt.Errorf("GetDailAddress %s, failed: %v", servers[p.lastIndex], err)
 	}
 
-	return DialHTTPPath("tcp", address, "/txn/v3/rpc")
+	return DialHTTPPathWithOptions(p.network, address, p.path, p.options)
 }
 
 func (p *Pool) pop() Client {
@@ -17,6 +17,7 @@ import (
 	"io"
 	"net/http"
 	"runtime/debug"
+	"time"
 
 	"icenter/src/common/blog"
 	"icenter/src/common/util"
@@ -26,6 +27,7 @@ import (
 type Server struct {
 	ctx            context.Context
 	codec          Codec
+	options        ServerOptions
 	handlers       map[string]HandlerFunc
 	streamHandlers map[string]HandlerStreamFunc
 }
@@ -58,17 +60,33 @@ func (s *Server) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 		blog.Errorf("rpc hijack failed %s: %s", req.RemoteAddr, err.Error())
 		return
 	}
-	session, err := NewServerSession(s, conn, "deflate")
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	negotiation, err := s.options.negotiate(req)
 	if err != nil {
-		blog.Errorf("rpc new server session faile %s: %s", req.RemoteAddr, err.Error())
-		if _, err = io.WriteString(conn, "HTTP/1.0 "+connectfaile+"\n\n"); err != nil {
+		blog.Errorf("rpc negotiate with %s failed: %s", req.RemoteAddr, err.Error())
+		if _, err = io.WriteString(conn, "HTTP/1.0 "+connectfaile+": "+err.Error()+"\n\n"); err != nil {
 			blog.Errorf("write string failed %s: %v", req.RemoteAddr, err)
 		}
+		conn.Close()
 		return
 	}
 
-	if _, err = io.WriteString(conn, "HTTP/1.0 "+connected+"\n\n"); err != nil {
+	if _, err = io.WriteString(conn, "HTTP/1.0 "+connected+"\n"+negotiation.header()+"\n"); err != nil {
 		blog.Errorf("write string failed %s: %v", req.RemoteAddr, err)
+		conn.Close()
+		return
+	}
+	rpcConn, err := s.options.handshake(conn, negotiation)
+	if err != nil {
+		blog.Errorf("rpc handshake with %s failed: %s", req.RemoteAddr, err.Error())
+		conn.Close()
+		return
+	}
+	rpcConn.SetDeadline(time.Time{})
+	session, err := NewServerSession(s, rpcConn, negotiation.compress)
+	if err != nil {
+		blog.Errorf("rpc new server session faile %s: %s", req.RemoteAddr, err.Error())
+		rpcConn.Close()
 		return
 	}
 	blog.V(3).Infof("connected from rpc client %s", req.RemoteAddr)
@@ -89,6 +107,11 @@ func (s *Server) HandleStream(name string, f HandlerStreamFunc) {
 	s.streamHandlers[name] = f
 }
 
+// SetOptions set the options to negotiate the connections
+func (s *Server) SetOptions(opt ServerOptions) {
+	s.options = opt
+}
+
 // SetCodec set server codec
 func (s *Server) SetCodec(codec Codec) {
 	s.codec = codec
@@ -204,7 +227,7 @@ func (s *ServerSession) handleStream(f HandlerStreamFunc, msg *Message) {
 		if err != nil {
 			nmsg.Data = []byte(err.Error())
 		}
-		stream.output <- nmsg
+		stream.push(nmsg)
 	}()
 	go func() {
 	serverstreamloop:
@@ -214,13 +237,12 @@ func (s *ServerSession) handleStream(f HandlerStreamFunc, msg *Message) {
 				break serverstreamloop
 			}
 			if s.done.IsSet() || stream.done.IsSet() {
-				stream.err = ErrStreamStoped
 				break
 			}
 		}
+		close(stream.stopped)
 		s.stream.remove(msg.seq)
 		close(stream.input)
-		close(stream.output)
 	}()
 }
 
@@ -248,6 +270,8 @@ func (s *ServerSession) readloop() error {
 		}
 		if err = s.readFromWire(); err != nil {
 			s.Stop()
+			// the stream handlers waiting for the client are stopped
+			s.stream.closeAll(err)
 			return nil
 		}
 	}
@@ -14,6 +14,7 @@ package rpc
 
 import (
 	"bytes"
+	"context"
 	"encoding"
 	"encoding/json"
 	"errors"
@@ -66,13 +67,30 @@ func (s *streamstore) remove(seq uint32) {
 	s.Unlock()
 }
 
+// closeAll tell all the streams that the connection is broken, the receivers get the err.
+func (s *streamstore) closeAll(err error) {
+	s.RLock()
+	defer s.RUnlock()
+	for _, stream := range s.stream {
+		msg := stream.root.copy()
+		msg.typz = TypeStreamClose
+		msg.Data = []byte(err.Error())
+		select {
+		case stream.input <- msg:
+		default:
+		}
+	}
+}
+
 // StreamMessage define
 type StreamMessage struct {
 	root   *Message
 	input  chan *Message
 	output chan *Message
-	done   *util.AtomicBool
-	err    error
+	// stopped is closed when the output is no longer forwarded to the peer
+	stopped chan struct{}
+	done    *util.AtomicBool
+	err     error
 }
 
 // ServerStream define interface
@@ -84,19 +102,35 @@ type ServerStream interface {
 // NewStreamMessage returns a new StreamMessage
 func NewStreamMessage(root *Message) *StreamMessage {
 	return &StreamMessage{
-		root:   root,
-		input:  make(chan *Message, 10),
-		output: make(chan *Message, 10),
-		done:   util.NewBool(false),
+		root:    root,
+		input:   make(chan *Message, 10),
+		output:  make(chan *Message, 10),
+		stopped: make(chan struct{}),
+		done:    util.NewBool(false),
 	}
 }
 
 // Recv receive message
-func (m StreamMessage) Recv(result interface{}) error {
+func (m *StreamMessage) Recv(result interface{}) error {
+	return m.RecvContext(context.Background(), result)
+}
+
+// RecvContext receive message until the context is done
+func (m *StreamMessage) RecvContext(ctx context.Context, result interface{}) error {
 	if m.err != nil {
 		return m.err
 	}
-	msg := <-m.input
+	var msg *Message
+	var ok bool
+	select {
+	case msg, ok = <-m.input:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if !ok {
+		m.err = ErrStreamStoped
+		return m.err
+	}
 	if msg.typz == TypeStreamClose {
 		m.err = ErrStreamStoped
 		if len(msg.Data) > 0 {
@@ -108,7 +142,7 @@ func (m StreamMessage) Recv(result interface{}) error {
 }
 
 // Send send message
-func (m StreamMessage) Send(data interface{}) error {
+func (m *StreamMessage) Send(data interface{}) error {
 	if m.err != nil {
 		return m.err
 	}
@@ -117,16 +151,26 @@ func (m StreamMessage) Send(data interface{}) error {
 	if err := msg.Encode(data); err != nil {
 		return err
 	}
-	m.output <- msg
-	return nil
+	return m.push(msg)
 }
 
 // Close should only call by client
-func (m StreamMessage) Close() error {
+func (m *StreamMessage) Close() error {
+	if !m.done.SetIfNotSet() {
+		return nil
+	}
 	msg := m.root.copy()
 	msg.typz = TypeStreamClose
-	m.output <- msg
-	return nil
+	return m.push(msg)
+}
+
+func (m *StreamMessage) push(msg *Message) error {
+	select {
+	case m.output <- msg:
+		return nil
+	case <-m.stopped:
+		return ErrStreamStoped
+	}
 }
 
 // MessageType define
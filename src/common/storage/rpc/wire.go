@@ -29,7 +29,7 @@ type Wire interface {
 // BinaryWire implements Wire interface
 type BinaryWire struct {
 	conn   io.ReadWriteCloser
-	writer flushWriter
+	writer FlushWriter
 	reader io.Reader
 }
 
@@ -18,6 +18,7 @@ import (
 	"icenter/src/common/core/cc/config"
 	"icenter/src/common/storage/dal/mongo"
 	"icenter/src/common/storage/dal/redis"
+	"icenter/src/common/storage/rpc"
 
 	"github.com/spf13/pflag"
 )
@@ -48,6 +49,7 @@ type Config struct {
 	MongoDB     mongo.Config
 	Redis       redis.Config
 	Transaction TransactionConfig
+	RPC         rpc.Config
 }
 
 // TransactionConfig transaction config structure
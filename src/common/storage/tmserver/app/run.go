@@ -72,8 +72,13 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 
 		blog.Infof("connected to mongo %v", tmServer.config.MongoDB.BuildURI())
 
+		rpcOpt, err := tmServer.config.RPC.ServerOptions()
+		if nil != err {
+			return err
+		}
+
 		// set core service
-		coreService.SetConfig(engine, db, tmServer.config.Transaction)
+		coreService.SetConfig(engine, db, tmServer.config.Transaction, *rpcOpt)
 		break
 	}
 	tmServer.engin = engine
@@ -20,6 +20,7 @@ import (
 	cc "icenter/src/common/backbone/configcenter"
 	"icenter/src/common/storage/dal/mongo"
 	"icenter/src/common/storage/dal/redis"
+	"icenter/src/common/storage/rpc"
 	"icenter/src/common/storage/tmserver/app/options"
 	"icenter/src/common/storage/tmserver/service"
 	"icenter/src/common/types"
@@ -49,6 +50,7 @@ func (s *Server) onConfigUpdate(previous, current cc.ProcessConfig) {
 
 		s.config.Transaction.Enable = current.ConfigMap["transaction.enable"]
 		s.config.Transaction.TransactionLifetimeSecond = current.ConfigMap["transaction.transactionLifetimeSecond"]
+		s.config.RPC = rpc.ParseConfigFromKV("rpc", current.ConfigMap)
 	}
 }
 
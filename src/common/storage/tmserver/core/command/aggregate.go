@@ -23,6 +23,7 @@ import (
 
 func init() {
 	core.GCommands.SetCommand(types.OPAggregateCode, &aggregate{})
+	core.GCommands.SetCommand(types.OPAggregateAllCode, &aggregate{})
 }
 
 var _ core.SetDBProxy = (*aggregate)(nil)
@@ -45,9 +46,6 @@ func (d *aggregate) Execute(ctx core.ContextParams, decoder rpc.Request) (*types
 	}
 	blog.V(4).Infof("[MONGO OPERATION] %+v", &msg)
 
-	opt := aggregateopt.One{}
-	//opt.Sort = msg.Sort
-
 	var targetCol mongodb.CollectionInterface
 	if nil != ctx.Session {
 		targetCol = ctx.Session.Collection(msg.Collection)
@@ -55,7 +53,12 @@ func (d *aggregate) Execute(ctx core.ContextParams, decoder rpc.Request) (*types
 		targetCol = d.dbProxy.Collection(msg.Collection)
 	}
 
-	err := targetCol.AggregateOne(ctx, msg.Pipiline, &opt, &reply.Docs)
+	var err error
+	if msg.OPCode == types.OPAggregateAllCode {
+		err = targetCol.AggregateMany(ctx, msg.Pipiline, &aggregateopt.Many{}, &reply.Docs)
+	} else {
+		err = targetCol.AggregateOne(ctx, msg.Pipiline, &aggregateopt.One{}, &reply.Docs)
+	}
 	if nil == err {
 		reply.Success = true
 	} else {
@@ -0,0 +1,85 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"icenter/src/common/blog"
+	"icenter/src/common/storage/mongodb"
+	"icenter/src/common/storage/rpc"
+	"icenter/src/common/storage/tmserver/core"
+	"icenter/src/common/storage/types"
+)
+
+func init() {
+	core.GCommands.SetCommand(types.OPBulkWriteCode, &bulkWrite{})
+}
+
+var _ core.SetDBProxy = (*bulkWrite)(nil)
+
+type bulkWrite struct {
+	dbProxy mongodb.Client
+}
+
+func (d *bulkWrite) SetDBProxy(db mongodb.Client) {
+	d.dbProxy = db
+}
+
+func (d *bulkWrite) Execute(ctx core.ContextParams, decoder rpc.Request) (*types.OPReply, error) {
+
+	msg := types.OPBulkWriteOperation{}
+	reply := &types.OPReply{}
+	reply.RequestID = ctx.Header.RequestID
+	if err := decoder.Decode(&msg); nil != err {
+		reply.Message = err.Error()
+		return reply, err
+	}
+	blog.V(4).Infof("[MONGO OPERATION] %+v", &msg)
+
+	var targetCol mongodb.CollectionInterface
+	if nil != ctx.Session {
+		targetCol = ctx.Session.Collection(msg.Collection)
+	} else {
+		targetCol = d.dbProxy.Collection(msg.Collection)
+	}
+
+	models := make([]mongodb.WriteModel, 0, len(msg.Models))
+	for _, model := range msg.Models {
+		models = append(models, mongodb.WriteModel{Kind: model.Kind, Filter: model.Selector, Document: model.DOC})
+	}
+
+	// the failed writes are returned in the result
+	result, err := targetCol.BulkWrite(ctx, models, msg.Ordered)
+	if nil != err {
+		reply.Message = err.Error()
+		return reply, err
+	}
+
+	ret := types.BulkWriteResult{
+		InsertedCount: result.InsertedCount,
+		MatchedCount:  result.MatchedCount,
+		ModifiedCount: result.ModifiedCount,
+		UpsertedCount: result.UpsertedCount,
+		DeletedCount:  result.DeletedCount,
+	}
+	for _, item := range result.Errors {
+		ret.Errors = append(ret.Errors, types.BulkWriteError{Index: item.Index, Message: item.Message})
+	}
+	doc := types.Document{}
+	if err := doc.Encode(ret); nil != err {
+		reply.Message = err.Error()
+		return reply, err
+	}
+	reply.Docs = types.Documents{doc}
+	reply.Success = true
+	return reply, nil
+}
@@ -103,6 +103,11 @@ func (d *findAndModify) Execute(ctx core.ContextParams, decoder rpc.Request) (*t
 
 	reply.Docs = types.Documents{types.Document{}}
 	err := targetCol.FindOneAndModify(ctx, msg.Selector, msg.DOC, &opt, &reply.Docs[0])
+	if mongodb.ErrDocumentNotFound == err {
+		// no document matched, or the upsert returns the document before updated
+		reply.Docs = types.Documents{}
+		err = nil
+	}
 	if nil == err {
 		reply.Success = true
 	} else {
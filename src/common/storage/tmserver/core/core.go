@@ -25,12 +25,14 @@ import (
 // Core core operation methods
 type Core interface {
 	ExecuteCommand(ctx ContextParams, input rpc.Request) (*types.OPReply, error)
+	ExecuteCursor(ctx ContextParams, input rpc.Request, stream rpc.ServerStream) error
 	Subscribe(chan *types.Transaction)
 	UnSubscribe(chan<- *types.Transaction)
 }
 
 type core struct {
 	txn *transaction.Manager
+	db  mongodb.Client
 }
 
 // SetTransaction set txc method interface
@@ -55,7 +57,7 @@ func New(txnMgr *transaction.Manager, db mongodb.Client) Core {
 		}
 	}
 
-	return &core{txn: txnMgr}
+	return &core{txn: txnMgr, db: db}
 }
 
 func (c *core) ExecuteCommand(ctx ContextParams, input rpc.Request) (*types.OPReply, error) {
@@ -0,0 +1,118 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"errors"
+	"strings"
+
+	"icenter/src/common/blog"
+	"icenter/src/common/storage/mongodb"
+	"icenter/src/common/storage/mongodb/options/findopt"
+	"icenter/src/common/storage/rpc"
+	"icenter/src/common/storage/types"
+)
+
+// errCursorClosed the client closes the cursor before all the documents are read
+var errCursorClosed = errors.New("cursor closed by the client")
+
+// ExecuteCursor send the documents of the find operation in batches, a batch is read from mongodb and sent only
+// after the client requests it, and the cursor is closed once the client closes the stream.
+func (c *core) ExecuteCursor(ctx ContextParams, input rpc.Request, stream rpc.ServerStream) error {
+
+	msg := types.OPFindOperation{}
+	if err := input.Decode(&msg); nil != err {
+		return err
+	}
+	blog.V(4).Infof("[MONGO CURSOR] %+v", &msg)
+
+	var targetCol mongodb.CollectionInterface
+	if 0 != len(ctx.Header.TxnID) {
+		session := c.txn.GetSession(ctx.Header.TxnID)
+		if nil == session {
+			return errors.New("session not found")
+		}
+		targetCol = session.Session.Collection(msg.Collection)
+	} else {
+		targetCol = c.db.Collection(msg.Collection)
+	}
+
+	// wait for the first batch request
+	next := types.OPCursorNext{}
+	if err := stream.Recv(&next); nil != err {
+		return nil
+	}
+
+	batch := make(types.Documents, 0, types.CursorBatchSize)
+	err := targetCol.Iterate(ctx, msg.Selector, findOptions(&msg), types.CursorBatchSize, func(cursor mongodb.Decoder) error {
+		doc := types.Document{}
+		if err := cursor.Decode(&doc); nil != err {
+			return err
+		}
+		batch = append(batch, doc)
+		if len(batch) < types.CursorBatchSize {
+			return nil
+		}
+
+		if err := sendBatch(stream, next.RequestID, batch); nil != err {
+			return err
+		}
+		batch = make(types.Documents, 0, types.CursorBatchSize)
+		if err := stream.Recv(&next); nil != err {
+			return errCursorClosed
+		}
+		return nil
+	})
+	if errCursorClosed == err {
+		blog.V(4).Infof("[MONGO CURSOR] closed by the client, rid: %s", next.RequestID)
+		return nil
+	}
+	if nil != err {
+		blog.Errorf("[MONGO CURSOR] failed: %v, rid: %s", err, next.RequestID)
+		return err
+	}
+	if 0 != len(batch) {
+		return sendBatch(stream, next.RequestID, batch)
+	}
+	return nil
+}
+
+func sendBatch(stream rpc.ServerStream, requestID string, batch types.Documents) error {
+	reply := types.OPReply{}
+	reply.RequestID = requestID
+	reply.Success = true
+	reply.Count = uint64(len(batch))
+	reply.Docs = batch
+	return stream.Send(&reply)
+}
+
+// findOptions convert the projection, sort and page of the find operation
+func findOptions(msg *types.OPFindOperation) *findopt.Many {
+	opt := findopt.Many{}
+	opt.Skip = int64(msg.Start)
+	opt.Limit = int64(msg.Limit)
+	for field := range msg.Projection {
+		opt.Fields = append(opt.Fields, findopt.FieldItem{Name: field})
+	}
+	for _, field := range strings.Split(msg.Sort, ",") {
+		field = strings.TrimSpace(field)
+		switch {
+		case 0 == len(field):
+		case strings.HasPrefix(field, "-"):
+			opt.Sort = append(opt.Sort, findopt.SortItem{Name: strings.TrimPrefix(field, "-"), Descending: true})
+		default:
+			opt.Sort = append(opt.Sort, findopt.SortItem{Name: strings.TrimPrefix(field, "+")})
+		}
+	}
+	return &opt
+}
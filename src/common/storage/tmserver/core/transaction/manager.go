@@ -61,6 +61,13 @@ func New(ctx context.Context, opt options.TransactionConfig, db mongodb.Client,
 	return tm
 }
 
+// ActiveCount returns the count of the transactions in progress
+func (tm *Manager) ActiveCount() int {
+	tm.sessionMutex.Lock()
+	defer tm.sessionMutex.Unlock()
+	return len(tm.cache)
+}
+
 func (tm *Manager) Subscribe(ch chan<- *types.Transaction) {
 	tm.pubsubMutex.Lock()
 	tm.subscribers[ch] = true
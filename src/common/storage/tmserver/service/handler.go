@@ -35,6 +35,17 @@ func (s *coreService) DBOperation(input rpc.Request) (interface{}, error) {
 
 }
 
+// DBCursor read the documents by a cursor, they are sent in batches on the client's demand
+func (s *coreService) DBCursor(input rpc.Request, stream rpc.ServerStream) error {
+
+	ctx := core.ContextParams{Context: context.Background(), ListenIP: s.listenIP}
+	if err := input.Decode(&ctx.Header); nil != err {
+		return err
+	}
+
+	return s.core.ExecuteCursor(ctx, input, stream)
+}
+
 func (s *coreService) WatchTransaction(input rpc.Request, stream rpc.ServerStream) (err error) {
 	ch := make(chan *types.Transaction, 100)
 	s.core.Subscribe(ch)
@@ -18,6 +18,7 @@ import (
 
 	"icenter/src/common/backbone"
 	"icenter/src/common/blog"
+	"icenter/src/common/metric"
 	"icenter/src/common/storage/mongodb"
 	"icenter/src/common/storage/rpc"
 	"icenter/src/common/storage/tmserver/app/options"
@@ -31,7 +32,7 @@ import (
 // Service service methods
 type Service interface {
 	WebService() *restful.WebService
-	SetConfig(engin *backbone.Engine, db mongodb.Client, txnCfg options.TransactionConfig)
+	SetConfig(engin *backbone.Engine, db mongodb.Client, txnCfg options.TransactionConfig, rpcOpt rpc.ServerOptions)
 }
 
 // New create a new service instance
@@ -52,15 +53,17 @@ type coreService struct {
 	listenPort uint
 }
 
-func (s *coreService) SetConfig(engin *backbone.Engine, db mongodb.Client, txnCfg options.TransactionConfig) {
+func (s *coreService) SetConfig(engin *backbone.Engine, db mongodb.Client, txnCfg options.TransactionConfig, rpcOpt rpc.ServerOptions) {
 
 	// set config
 	s.engine = engin
 	s.dbProxy = db
 	s.rpc = rpc.NewServer()
+	s.rpc.SetOptions(rpcOpt)
 
 	// init all handlers
 	s.rpc.Handle(types.CommandRDBOperation, s.DBOperation)
+	s.rpc.HandleStream(types.CommandRDBCursorOperation, s.DBCursor)
 	s.rpc.HandleStream(types.CommandWatchTransactionOperation, s.WatchTransaction)
 
 	// create a new core instance
@@ -79,6 +82,9 @@ func (s *coreService) SetConfig(engin *backbone.Engine, db mongodb.Client, txnCf
 
 	s.core = core.New(txn, db)
 
+	metric.Register(metric.NewGaugeFunc("cmdb_tmserver_active_transactions",
+		"Number of the transactions in progress.", func() float64 { return float64(txn.ActiveCount()) }))
+
 }
 
 func (s *coreService) WebService() *restful.WebService {
@@ -23,6 +23,7 @@ import (
 	"icenter/src/common/backbone"
 	"icenter/src/common/storage/dal/mongo"
 	mgo "icenter/src/common/storage/mongodb/driver"
+	"icenter/src/common/storage/rpc"
 	"icenter/src/common/storage/tmserver/app/options"
 	"icenter/src/common/storage/tmserver/service"
 
@@ -76,7 +77,7 @@ func startCoreService(t *testing.T, ip string, port uint) {
 
 	// set core service
 	_ = coreService
-	coreService.SetConfig(engine, db, options.TransactionConfig{})
+	coreService.SetConfig(engine, db, options.TransactionConfig{}, rpc.ServerOptions{})
 
 	return
 }
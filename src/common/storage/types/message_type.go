@@ -37,6 +37,12 @@ const (
 	OPCountCode
 	// OPAggregateCode aggregate operation code
 	OPAggregateCode
+	// OPAggregateAllCode aggregate operation code which returns all the results
+	OPAggregateAllCode
+	// OPUpsertCode upsert operation code
+	OPUpsertCode
+	// OPBulkWriteCode bulk write operation code
+	OPBulkWriteCode
 	// OPStartTransactionCode start a transaction code
 	OPStartTransactionCode OPCode = 666
 	// OPCommitCode transaction commit operation code
@@ -67,6 +73,12 @@ func (c OPCode) String() string {
 		return "OPAbortTransaction"
 	case OPAggregateCode:
 		return "OPAggregate"
+	case OPAggregateAllCode:
+		return "OPAggregateAll"
+	case OPUpsertCode:
+		return "OPUpsert"
+	case OPBulkWriteCode:
+		return "OPBulkWrite"
 	default:
 		return "UNKNOW"
 	}
@@ -112,6 +124,16 @@ type OPFindOperation struct {
 	Sort       string   // sort string
 }
 
+// CursorBatchSize the count of the documents in a batch of the cursor
+const CursorBatchSize = 500
+
+// OPCursorNext request the next batch of the cursor, the cursor query is sent by the
+// CommandRDBCursorOperation stream with an OPFindOperation, then the client sends an
+// OPCursorNext for every batch, so the server reads no more than the client can handle.
+type OPCursorNext struct {
+	RequestID string
+}
+
 // OPCountOperation count operation request structure
 type OPCountOperation struct {
 	MsgHeader           // 标准报文头
@@ -130,6 +152,53 @@ type OPFindAndModifyOperation struct {
 	ReturnNew  bool
 }
 
+// OPUpsertOperation upsert operation request structure
+type OPUpsertOperation struct {
+	MsgHeader           // 标准报文头
+	Collection string   // "dbname.collectionname"
+	DOC        Document // 指定要执行的更新操作符
+	Selector   Document // 文档查询条件
+}
+
+// the kinds of the bulk write models
+const (
+	BulkWriteInsert = "insert"
+	BulkWriteUpdate = "update"
+	BulkWriteUpsert = "upsert"
+	BulkWriteDelete = "delete"
+)
+
+// BulkWriteModel a write of the bulk write operation
+type BulkWriteModel struct {
+	Kind     string
+	DOC      Document // 插入的文档或更新操作符
+	Selector Document // 文档查询条件
+}
+
+// OPBulkWriteOperation bulk write operation request structure
+type OPBulkWriteOperation struct {
+	MsgHeader                   // 标准报文头
+	Collection string           // "dbname.collectionname"
+	Ordered    bool             // 按顺序执行, 遇到失败即停止
+	Models     []BulkWriteModel // 写入操作
+}
+
+// BulkWriteError the failed write of the bulk write operation
+type BulkWriteError struct {
+	Index   int    `bson:"index"`
+	Message string `bson:"message"`
+}
+
+// BulkWriteResult the bulk write operation result, it's the first document of the reply
+type BulkWriteResult struct {
+	InsertedCount uint64           `bson:"inserted_count"`
+	MatchedCount  uint64           `bson:"matched_count"`
+	ModifiedCount uint64           `bson:"modified_count"`
+	UpsertedCount uint64           `bson:"upserted_count"`
+	DeletedCount  uint64           `bson:"deleted_count"`
+	Errors        []BulkWriteError `bson:"errors"`
+}
+
 // OPStartTransactionOperation transaction request structure
 type OPStartTransactionOperation struct {
 	MsgHeader
@@ -191,6 +191,7 @@ func decodeBsonArray(inArr, outArr interface{}) error {
 
 const (
 	CommandRDBOperation              = "RDB"
+	CommandRDBCursorOperation        = "RDBCursor"
 	CommandWatchTransactionOperation = "WatchTransaction"
 )
 
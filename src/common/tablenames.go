@@ -94,6 +94,44 @@ const (
 	BKTableNameCloudSyncHistory       = "cc_CloudSyncHistory"
 	BKTableNameCloudResourceConfirm   = "cc_CloudResourceConfirm"
 	BKTableNameResourceConfirmHistory = "cc_ResourceConfirmHistory"
+
+	// BKTableNameEventLog the table name of the durable instance event log
+	BKTableNameEventLog = "cc_EventLog"
+	// BKTableNameEventConsumer the table name of the event log consumer offsets
+	BKTableNameEventConsumer = "cc_EventConsumer"
+
+	// BKTableNameInstSnapshot the table name of the instance snapshots
+	BKTableNameInstSnapshot = "cc_InstSnapshot"
+	// BKTableNameInstSnapshotBatch the table name of the instance snapshot batches
+	BKTableNameInstSnapshotBatch = "cc_InstSnapshotBatch"
+
+	// BKTableNameWebhookSubscription the table name of the webhook subscriptions
+	BKTableNameWebhookSubscription = "cc_WebhookSubscription"
+	// BKTableNameWebhookDelivery the table name of the pending and dead letter webhook deliveries
+	BKTableNameWebhookDelivery = "cc_WebhookDelivery"
+
+	// BKTableNameNotificationRule the table name of the attribute change notification rules
+	BKTableNameNotificationRule = "cc_NotificationRule"
+	// BKTableNameNotificationSetting the table name of the notification settings of the users
+	BKTableNameNotificationSetting = "cc_NotificationSetting"
+	// BKTableNameNotification the table name of the pending and failed notifications
+	BKTableNameNotification = "cc_Notification"
+
+	// BKTableNameLanguagePack the table name of the versions of the error message and language packs
+	BKTableNameLanguagePack = "cc_LanguagePack"
+
+	// BKTableNameFieldPrivilege the table name of the field level privileges of the model attributes
+	BKTableNameFieldPrivilege = "cc_FieldPrivilege"
+
+	// BKTableNameQualityRule the table name of the data quality rules
+	BKTableNameQualityRule = "cc_QualityRule"
+	// BKTableNameQualityViolation the table name of the violations of the data quality rules
+	BKTableNameQualityViolation = "cc_QualityViolation"
+	// BKTableNameQualityScore the table name of the data quality scores of the businesses
+	BKTableNameQualityScore = "cc_QualityScore"
+
+	// BKTableNameMigrationHistory the table name of the upgrader steps history
+	BKTableNameMigrationHistory = "cc_MigrationHistory"
 )
 
 // AllTables alltables
@@ -142,6 +180,21 @@ var AllTables = []string{
 	BKTableNameResourceConfirmHistory,
 	BKTableNameObjUnique,
 	BKTableNameAsstDes,
+	BKTableNameEventLog,
+	BKTableNameEventConsumer,
+	BKTableNameInstSnapshot,
+	BKTableNameInstSnapshotBatch,
+	BKTableNameWebhookSubscription,
+	BKTableNameWebhookDelivery,
+	BKTableNameNotificationRule,
+	BKTableNameNotificationSetting,
+	BKTableNameNotification,
+	BKTableNameLanguagePack,
+	BKTableNameFieldPrivilege,
+	BKTableNameQualityRule,
+	BKTableNameQualityViolation,
+	BKTableNameQualityScore,
+	BKTableNameMigrationHistory,
 }
 
 // GetInstTableName returns inst data table name
@@ -47,7 +47,8 @@ func parseConditionFromMapStr(inputCond *mongoCondition, inputKey string, inputC
 
 		case universalsql.EQ, universalsql.NEQ,
 			universalsql.GT, universalsql.GTE, universalsql.LTE, universalsql.LT,
-			universalsql.IN, universalsql.NIN, universalsql.REGEX, universalsql.EXISTS:
+			universalsql.IN, universalsql.NIN, universalsql.REGEX, universalsql.EXISTS,
+			universalsql.IPINCIDR:
 			ele, err := convertToElement(inputKey, operatorKey, val, outputCond, inputCondMapStr)
 			if nil != err {
 				return err
@@ -132,6 +133,8 @@ func convertToElement(key, operator string, val interface{}, inputCond *mongoCon
 		return &Regex{Key: key, Val: val}, nil
 	case universalsql.EXISTS:
 		return &Exists{Key: key, Val: val}, nil
+	case universalsql.IPINCIDR:
+		return newIPInCIDR(key, val)
 	default:
 		// deal embed condition
 		return nil, fmt.Errorf("not support the operator '%s'", operator)
@@ -161,3 +161,17 @@ func TestNewConditionFromMapStrFromCommonCondition(t *testing.T) {
 	_, err := mongo.NewConditionFromMapStr(target.ToMapStr())
 	require.NoError(t, err)
 }
+
+func TestNewConditionFromMapStrWithIPInCIDR(t *testing.T) {
+
+	cond, err := mongo.NewConditionFromMapStr(mapstr.MapStr{
+		"bk_host_innerip": mapstr.MapStr{"$ip_in_cidr": []interface{}{"10.0.0.0/8"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, mapstr.MapStr{"$regex": `^(?:(?:10\.\d{1,3}\.\d{1,3}\.\d{1,3}))$`}, cond.ToMapStr()["bk_host_innerip"])
+
+	_, err = mongo.NewConditionFromMapStr(mapstr.MapStr{
+		"bk_host_innerip": mapstr.MapStr{"$ip_in_cidr": "10.0.0.0"},
+	})
+	require.Error(t, err)
+}
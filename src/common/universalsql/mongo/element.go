@@ -13,8 +13,11 @@
 package mongo
 
 import (
+	"fmt"
+
 	"icenter/src/common/mapstr"
 	"icenter/src/common/universalsql"
+	"icenter/src/common/util"
 )
 
 type element struct {
@@ -154,6 +157,42 @@ func (r *Regex) ToMapStr() mapstr.MapStr {
 	}
 }
 
+// IPInCIDR match the ipv4 address within the cidr networks, it is converted to $regex
+type IPInCIDR element
+
+var _ universalsql.ConditionElement = (*IPInCIDR)(nil)
+
+func newIPInCIDR(key string, val interface{}) (*IPInCIDR, error) {
+	cidrs := make([]string, 0)
+	switch value := val.(type) {
+	case string:
+		cidrs = append(cidrs, value)
+	default:
+		for _, item := range util.ConverToInterfaceSlice(val) {
+			cidr, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("the value of operator '%s' must be cidr string, not %v", universalsql.IPINCIDR, item)
+			}
+			cidrs = append(cidrs, cidr)
+		}
+	}
+
+	regex, err := util.IPv4CIDRToRegex(cidrs...)
+	if nil != err {
+		return nil, fmt.Errorf("the value of operator '%s' is invalid, %v", universalsql.IPINCIDR, err)
+	}
+	return &IPInCIDR{Key: key, Val: regex}, nil
+}
+
+// ToMapStr return the format result
+func (i *IPInCIDR) ToMapStr() mapstr.MapStr {
+	return mapstr.MapStr{
+		i.Key: mapstr.MapStr{
+			universalsql.REGEX: i.Val,
+		},
+	}
+}
+
 // Comparison Operator End
 
 // Exists Operator Start
@@ -24,6 +24,10 @@ const (
 	NEQ   string = "$ne"
 	REGEX string = "$regex"
 
+	// IPINCIDR match the ipv4 field whose value is within the cidr networks,
+	// the value is a cidr string or an array of them.
+	IPINCIDR string = "$ip_in_cidr"
+
 	//Logic Operator
 	AND string = "$and"
 	OR  string = "$or"
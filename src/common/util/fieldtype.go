@@ -0,0 +1,388 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	mgobson "gopkg.in/mgo.v2/bson"
+
+	"icenter/src/common"
+	"icenter/src/common/expression"
+)
+
+var emailRegexp = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)+$`)
+
+// ListItemTypes the field types that can be used as the item type of a list field
+var ListItemTypes = []string{
+	common.FieldTypeSingleChar,
+	common.FieldTypeInt,
+	common.FieldTypeFloat,
+	common.FieldTypeIPv4,
+	common.FieldTypeIPv6,
+	common.FieldTypeCIDR,
+	common.FieldTypeURL,
+	common.FieldTypeEmail,
+}
+
+// IPOption the option of ipv4, ipv6 and cidr field, the value must be within one of the cidr ranges if it is set.
+type IPOption struct {
+	CIDR []string `json:"cidr" bson:"cidr"`
+}
+
+// URLOption the option of url field, the url scheme must be one of the schemes if it is set.
+type URLOption struct {
+	Schemes []string `json:"schemes" bson:"schemes"`
+}
+
+// ListOption the option of list field
+type ListOption struct {
+	// ItemType the field type of every item in this list, default is singlechar.
+	ItemType string `json:"item_type" bson:"item_type"`
+	// MaxItems the max item count of this list, 0 means no limit.
+	MaxItems int `json:"max_items" bson:"max_items"`
+	// ItemOption the option of every item, which is decided by the item type.
+	ItemOption interface{} `json:"item_option" bson:"item_option"`
+}
+
+// ComputedResultTypes the field types that can be used as the result type of a computed field
+var ComputedResultTypes = []string{
+	common.FieldTypeSingleChar,
+	common.FieldTypeInt,
+	common.FieldTypeFloat,
+	common.FieldTypeBool,
+}
+
+// ComputedOption the option of computed field
+type ComputedOption struct {
+	// Expression the expression which the value is evaluated from, see expression.Parse for the syntax.
+	Expression string `json:"expression" bson:"expression"`
+	// ResultType the field type of the value, default is singlechar.
+	ResultType string `json:"result_type" bson:"result_type"`
+}
+
+// IsIPv4 whether the input is an ipv4 address
+func IsIPv4(sInput string) bool {
+	ip := net.ParseIP(sInput)
+	return ip != nil && ip.To4() != nil && !strings.Contains(sInput, ":")
+}
+
+// IsIPv6 whether the input is an ipv6 address in canonical form, so that it can be compared as string.
+func IsIPv6(sInput string) bool {
+	ip := net.ParseIP(sInput)
+	return ip != nil && strings.Contains(sInput, ":") && ip.String() == sInput
+}
+
+// IsCIDR whether the input is a cidr network, like 10.0.0.0/8
+func IsCIDR(sInput string) bool {
+	_, _, err := net.ParseCIDR(sInput)
+	return err == nil
+}
+
+// IsURL whether the input is an absolute url with scheme and host
+func IsURL(sInput string) bool {
+	u, err := url.Parse(sInput)
+	return err == nil && len(u.Scheme) != 0 && len(u.Host) != 0
+}
+
+// IsEmail whether the input is an email address
+func IsEmail(sInput string) bool {
+	return len(sInput) <= common.FieldTypeSingleLenChar && emailRegexp.MatchString(sInput)
+}
+
+// IsJSON whether the input is a json object or array, or a string of them.
+func IsJSON(val interface{}) bool {
+	switch value := val.(type) {
+	case string:
+		value = strings.TrimSpace(value)
+		if !strings.HasPrefix(value, "{") && !strings.HasPrefix(value, "[") {
+			return false
+		}
+		return json.Valid([]byte(value))
+	case map[string]interface{}, []interface{}, bson.M, bson.D, bson.A, mgobson.M:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseIPOption parse the option of ipv4, ipv6 and cidr field
+func ParseIPOption(option interface{}) (IPOption, error) {
+	ipOption := IPOption{}
+	if err := decodeFieldOption(option, &ipOption); err != nil {
+		return ipOption, err
+	}
+	for _, cidr := range ipOption.CIDR {
+		if !IsCIDR(cidr) {
+			return ipOption, fmt.Errorf("invalid cidr %s", cidr)
+		}
+	}
+	return ipOption, nil
+}
+
+// Contains whether the ip address or the cidr network is within the cidr ranges,
+// it always returns true if there is no cidr range.
+func (o IPOption) Contains(value string) bool {
+	if len(o.CIDR) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(value)
+	var network *net.IPNet
+	if ip == nil {
+		var err error
+		ip, network, err = net.ParseCIDR(value)
+		if err != nil {
+			return false
+		}
+		ip = network.IP
+	}
+
+	for _, cidr := range o.CIDR {
+		_, allowed, err := net.ParseCIDR(cidr)
+		if err != nil || !allowed.Contains(ip) {
+			continue
+		}
+		if network == nil {
+			return true
+		}
+		// the network must be as small as the allowed one.
+		allowedOnes, _ := allowed.Mask.Size()
+		ones, _ := network.Mask.Size()
+		if ones >= allowedOnes {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseURLOption parse the option of url field
+func ParseURLOption(option interface{}) (URLOption, error) {
+	urlOption := URLOption{}
+	err := decodeFieldOption(option, &urlOption)
+	return urlOption, err
+}
+
+// Allowed whether the url's scheme is allowed, it always returns true if there is no scheme limited.
+func (o URLOption) Allowed(value string) bool {
+	if len(o.Schemes) == 0 {
+		return true
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	for _, scheme := range o.Schemes {
+		if strings.EqualFold(scheme, u.Scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseListOption parse the option of list field
+func ParseListOption(option interface{}) (ListOption, error) {
+	listOption := ListOption{}
+	if err := decodeFieldOption(option, &listOption); err != nil {
+		return listOption, err
+	}
+	if len(listOption.ItemType) == 0 {
+		listOption.ItemType = common.FieldTypeSingleChar
+	}
+	if !InStrArr(ListItemTypes, listOption.ItemType) {
+		return listOption, fmt.Errorf("unsupported list item type %s", listOption.ItemType)
+	}
+	if listOption.MaxItems < 0 {
+		return listOption, fmt.Errorf("invalid max items %d", listOption.MaxItems)
+	}
+	return listOption, nil
+}
+
+// ParseComputedOption parse the option of computed field, the expression is parsed as well.
+func ParseComputedOption(option interface{}) (ComputedOption, *expression.Expression, error) {
+	computedOption := ComputedOption{}
+	if err := decodeFieldOption(option, &computedOption); err != nil {
+		return computedOption, nil, err
+	}
+	if len(computedOption.ResultType) == 0 {
+		computedOption.ResultType = common.FieldTypeSingleChar
+	}
+	if !InStrArr(ComputedResultTypes, computedOption.ResultType) {
+		return computedOption, nil, fmt.Errorf("unsupported result type %s", computedOption.ResultType)
+	}
+	expr, err := expression.Parse(computedOption.Expression)
+	if err != nil {
+		return computedOption, nil, err
+	}
+	return computedOption, expr, nil
+}
+
+// ValidListItems valid every item of the list value with the list option.
+func ValidListItems(val interface{}, option ListOption) error {
+	items := ConverToInterfaceSlice(val)
+	if option.MaxItems > 0 && len(items) > option.MaxItems {
+		return fmt.Errorf("over the max items %d", option.MaxItems)
+	}
+
+	var ipOption IPOption
+	var urlOption URLOption
+	var err error
+	switch option.ItemType {
+	case common.FieldTypeIPv4, common.FieldTypeIPv6, common.FieldTypeCIDR:
+		ipOption, err = ParseIPOption(option.ItemOption)
+	case common.FieldTypeURL:
+		urlOption, err = ParseURLOption(option.ItemOption)
+	}
+	if err != nil {
+		return err
+	}
+
+	for index, item := range items {
+		if !validListItem(option.ItemType, item, ipOption, urlOption) {
+			return fmt.Errorf("item %d %v is not a valid %s", index, item, option.ItemType)
+		}
+	}
+	return nil
+}
+
+func validListItem(itemType string, item interface{}, ipOption IPOption, urlOption URLOption) bool {
+	switch itemType {
+	case common.FieldTypeInt:
+		_, err := GetInt64ByInterface(item)
+		return err == nil
+	case common.FieldTypeFloat:
+		_, err := GetFloat64ByInterface(item)
+		return err == nil
+	}
+
+	value, ok := item.(string)
+	if !ok {
+		return false
+	}
+	switch itemType {
+	case common.FieldTypeSingleChar:
+		return len(value) <= common.FieldTypeSingleLenChar
+	case common.FieldTypeIPv4:
+		return IsIPv4(value) && ipOption.Contains(value)
+	case common.FieldTypeIPv6:
+		return IsIPv6(value) && ipOption.Contains(value)
+	case common.FieldTypeCIDR:
+		return IsCIDR(value) && ipOption.Contains(value)
+	case common.FieldTypeURL:
+		return IsURL(value) && urlOption.Allowed(value)
+	case common.FieldTypeEmail:
+		return IsEmail(value)
+	default:
+		return false
+	}
+}
+
+// IPv4CIDRToRegex convert the ipv4 cidr networks to a regular expression, which matches
+// all the ipv4 address strings within these networks, so that the ipv4 field can be searched with cidr.
+func IPv4CIDRToRegex(cidrs ...string) (string, error) {
+	patterns := make([]string, 0)
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return "", err
+		}
+		ip := network.IP.To4()
+		if ip == nil {
+			return "", fmt.Errorf("%s is not a ipv4 cidr", cidr)
+		}
+		ones, _ := network.Mask.Size()
+
+		octets := make([]string, 4)
+		for i := 0; i < 4; i++ {
+			switch {
+			case ones >= (i+1)*8:
+				// fixed octet
+				octets[i] = strconv.Itoa(int(ip[i]))
+			case ones > i*8:
+				// partial octet, enumerate all the values within the network.
+				hostBits := uint((i+1)*8 - ones)
+				low := int(ip[i])
+				high := low | (1<<hostBits - 1)
+				values := make([]string, 0, high-low+1)
+				for v := low; v <= high; v++ {
+					values = append(values, strconv.Itoa(v))
+				}
+				octets[i] = "(?:" + strings.Join(values, "|") + ")"
+			default:
+				octets[i] = `\d{1,3}`
+			}
+		}
+		patterns = append(patterns, "(?:"+strings.Join(octets, `\.`)+")")
+	}
+
+	if len(patterns) == 0 {
+		return "", fmt.Errorf("no cidr")
+	}
+	return "^(?:" + strings.Join(patterns, "|") + ")$", nil
+}
+
+// decodeFieldOption decode the field option which may be a json string, a map
+// or a bson document read from db into the result.
+func decodeFieldOption(option interface{}, result interface{}) error {
+	if nil == option || "" == option {
+		return nil
+	}
+
+	var data []byte
+	var err error
+	switch value := option.(type) {
+	case string:
+		data = []byte(value)
+	default:
+		data, err = json.Marshal(normalizeBSON(value))
+		if err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, result)
+}
+
+// normalizeBSON convert the bson documents into maps, so that they can be marshaled as json object.
+func normalizeBSON(val interface{}) interface{} {
+	switch value := val.(type) {
+	case bson.D:
+		return normalizeBSON(value.Map())
+	case bson.M:
+		return normalizeBSON(map[string]interface{}(value))
+	case mgobson.M:
+		return normalizeBSON(map[string]interface{}(value))
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[k] = normalizeBSON(v)
+		}
+		return result
+	case bson.A:
+		return normalizeBSON([]interface{}(value))
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			result[i] = normalizeBSON(v)
+		}
+		return result
+	default:
+		return val
+	}
+}
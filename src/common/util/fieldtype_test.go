@@ -0,0 +1,114 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/stretchr/testify/require"
+
+	"icenter/src/common"
+)
+
+func TestIsFieldType(t *testing.T) {
+	require.True(t, IsIPv4("10.0.0.1"))
+	require.False(t, IsIPv4("10.0.0.256"))
+	require.False(t, IsIPv4("::ffff:10.0.0.1"))
+	require.True(t, IsIPv6("fe80::1"))
+	require.False(t, IsIPv6("fe80:0:0:0:0:0:0:1"))
+	require.False(t, IsIPv6("10.0.0.1"))
+	require.True(t, IsCIDR("10.0.0.0/8"))
+	require.False(t, IsCIDR("10.0.0.0"))
+	require.True(t, IsURL("https://example.com/path"))
+	require.False(t, IsURL("example.com"))
+	require.True(t, IsEmail("ops@example.com"))
+	require.False(t, IsEmail("ops@"))
+	require.True(t, IsJSON(`{"a": 1}`))
+	require.True(t, IsJSON(map[string]interface{}{"a": 1}))
+	require.False(t, IsJSON("1"))
+}
+
+func TestIPOption(t *testing.T) {
+	option, err := ParseIPOption(map[string]interface{}{"cidr": []interface{}{"10.0.0.0/8", "192.168.1.0/24"}})
+	require.NoError(t, err)
+	require.True(t, option.Contains("10.1.2.3"))
+	require.True(t, option.Contains("192.168.1.0/25"))
+	require.False(t, option.Contains("192.168.0.0/16"))
+	require.False(t, option.Contains("172.16.0.1"))
+
+	option, err = ParseIPOption(bson.D{{Key: "cidr", Value: bson.A{"fd00::/8"}}})
+	require.NoError(t, err)
+	require.True(t, option.Contains("fd00::1"))
+
+	_, err = ParseIPOption(`{"cidr": ["10.0.0.0"]}`)
+	require.Error(t, err)
+
+	option, err = ParseIPOption(nil)
+	require.NoError(t, err)
+	require.True(t, option.Contains("1.1.1.1"))
+}
+
+func TestValidListItems(t *testing.T) {
+	option, err := ParseListOption(map[string]interface{}{"item_type": common.FieldTypeIPv4, "max_items": 2, "item_option": map[string]interface{}{"cidr": []string{"10.0.0.0/8"}}})
+	require.NoError(t, err)
+	require.NoError(t, ValidListItems([]interface{}{"10.0.0.1", "10.0.0.2"}, option))
+	require.Error(t, ValidListItems([]interface{}{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, option))
+	require.Error(t, ValidListItems([]interface{}{"172.16.0.1"}, option))
+
+	option, err = ParseListOption(nil)
+	require.NoError(t, err)
+	require.Equal(t, common.FieldTypeSingleChar, option.ItemType)
+	require.Error(t, ValidListItems([]interface{}{1}, option))
+
+	_, err = ParseListOption(`{"item_type": "list"}`)
+	require.Error(t, err)
+}
+
+func TestParseComputedOption(t *testing.T) {
+	option, expr, err := ParseComputedOption(map[string]interface{}{"expression": `cpu * 2`, "result_type": common.FieldTypeInt})
+	require.NoError(t, err)
+	require.Equal(t, common.FieldTypeInt, option.ResultType)
+	require.Equal(t, []string{"cpu"}, expr.Fields())
+
+	option, _, err = ParseComputedOption(`{"expression": "upper(name)"}`)
+	require.NoError(t, err)
+	require.Equal(t, common.FieldTypeSingleChar, option.ResultType)
+
+	_, _, err = ParseComputedOption(`{"expression": "name +"}`)
+	require.Error(t, err)
+	_, _, err = ParseComputedOption(`{"expression": "name", "result_type": "enum"}`)
+	require.Error(t, err)
+	_, _, err = ParseComputedOption(nil)
+	require.Error(t, err)
+}
+
+func TestIPv4CIDRToRegex(t *testing.T) {
+	pattern, err := IPv4CIDRToRegex("10.16.0.0/12", "192.168.1.0/24")
+	require.NoError(t, err)
+	reg := regexp.MustCompile(pattern)
+	require.True(t, reg.MatchString("10.16.0.1"))
+	require.True(t, reg.MatchString("10.31.255.255"))
+	require.False(t, reg.MatchString("10.32.0.1"))
+	require.False(t, reg.MatchString("110.16.0.1"))
+	require.True(t, reg.MatchString("192.168.1.100"))
+	require.False(t, reg.MatchString("192.168.10.1"))
+
+	pattern, err = IPv4CIDRToRegex("0.0.0.0/0")
+	require.NoError(t, err)
+	require.True(t, regexp.MustCompile(pattern).MatchString("8.8.8.8"))
+
+	_, err = IPv4CIDRToRegex("fd00::/8")
+	require.Error(t, err)
+}
@@ -107,7 +107,31 @@ func ValidPropertyOption(propertyType string, option interface{}, errProxy error
 				return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option.max")
 			}
 		}
-
+	case common.FieldTypeIPv4, common.FieldTypeIPv6, common.FieldTypeCIDR:
+		if _, err := ParseIPOption(option); nil != err {
+			blog.Errorf(" option %v not ip option, err: %v", option, err)
+			return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+		}
+	case common.FieldTypeURL:
+		if _, err := ParseURLOption(option); nil != err {
+			blog.Errorf(" option %v not url option, err: %v", option, err)
+			return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+		}
+	case common.FieldTypeList:
+		listOption, err := ParseListOption(option)
+		if nil != err {
+			blog.Errorf(" option %v not list option, err: %v", option, err)
+			return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+		}
+		switch listOption.ItemType {
+		case common.FieldTypeIPv4, common.FieldTypeIPv6, common.FieldTypeCIDR, common.FieldTypeURL:
+			return ValidPropertyOption(listOption.ItemType, listOption.ItemOption, errProxy)
+		}
+	case common.FieldTypeComputed:
+		if _, _, err := ParseComputedOption(option); nil != err {
+			blog.Errorf(" option %v not computed option, err: %v", option, err)
+			return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+		}
 	}
 	return nil
 }
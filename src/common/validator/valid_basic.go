@@ -14,6 +14,7 @@ package validator
 
 import (
 	"net/http"
+	"reflect"
 	"regexp"
 	"strconv"
 
@@ -133,6 +134,18 @@ func (valid *ValidMap) ValidMap(valData map[string]interface{}, validType string
 			err = valid.validFloat(val, key)
 		case common.FieldTypeUser:
 			err = valid.validUser(val, key)
+		case common.FieldTypeIPv4, common.FieldTypeIPv6:
+			err = valid.validIP(val, key, fieldType)
+		case common.FieldTypeCIDR:
+			err = valid.validCIDR(val, key)
+		case common.FieldTypeURL:
+			err = valid.validURL(val, key)
+		case common.FieldTypeEmail:
+			err = valid.validEmail(val, key)
+		case common.FieldTypeJSON:
+			err = valid.validJSON(val, key)
+		case common.FieldTypeList:
+			err = valid.validList(val, key)
 		default:
 			continue
 		}
@@ -495,3 +508,164 @@ func (valid *ValidMap) validTime(val interface{}, key string) error {
 	}
 	return nil
 }
+// validIP valid object attribute that is ipv4 or ipv6 type
+func (valid *ValidMap) validIP(val interface{}, key string, fieldType string) error {
+	if nil == val || "" == val {
+		if valid.require[key] {
+			blog.Error("params can not be null")
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	value, ok := val.(string)
+	if !ok {
+		blog.Errorf("params %s:%#v should be ip", key, val)
+		return valid.errif.Errorf(common.CCErrCommParamsNeedIP, key)
+	}
+	if (fieldType == common.FieldTypeIPv4 && !util.IsIPv4(value)) || (fieldType == common.FieldTypeIPv6 && !util.IsIPv6(value)) {
+		blog.Errorf("params %s:%s is not a valid %s", key, value, fieldType)
+		return valid.errif.Errorf(common.CCErrCommParamsNeedIP, key)
+	}
+
+	return valid.validIPRange(value, key)
+}
+
+// validCIDR valid object attribute that is cidr type
+func (valid *ValidMap) validCIDR(val interface{}, key string) error {
+	if nil == val || "" == val {
+		if valid.require[key] {
+			blog.Error("params can not be null")
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	value, ok := val.(string)
+	if !ok || !util.IsCIDR(value) {
+		blog.Errorf("params %s:%#v should be cidr", key, val)
+		return valid.errif.Errorf(common.CCErrCommParamsNeedCIDR, key)
+	}
+
+	return valid.validIPRange(value, key)
+}
+
+// validIPRange valid the ip or cidr value is within the allowed cidr ranges in option
+func (valid *ValidMap) validIPRange(value string, key string) error {
+	property, ok := valid.propertys[key]
+	if !ok {
+		return nil
+	}
+	ipOption, err := util.ParseIPOption(property.Option)
+	if nil != err {
+		blog.Warnf("ParseIPOption failed: %v", err)
+		return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+	}
+	if !ipOption.Contains(value) {
+		blog.Errorf("params %s:%s not in cidr %v", key, value, ipOption.CIDR)
+		return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+	}
+	return nil
+}
+
+// validURL valid object attribute that is url type
+func (valid *ValidMap) validURL(val interface{}, key string) error {
+	if nil == val || "" == val {
+		if valid.require[key] {
+			blog.Error("params can not be null")
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	value, ok := val.(string)
+	if !ok || len(value) > common.FieldTypeLongLenChar || !util.IsURL(value) {
+		blog.Errorf("params %s:%#v should be url", key, val)
+		return valid.errif.Errorf(common.CCErrCommParamsNeedURL, key)
+	}
+
+	property, ok := valid.propertys[key]
+	if !ok {
+		return nil
+	}
+	urlOption, err := util.ParseURLOption(property.Option)
+	if nil != err {
+		blog.Warnf("ParseURLOption failed: %v", err)
+		return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+	}
+	if !urlOption.Allowed(value) {
+		blog.Errorf("params %s:%s scheme not in %v", key, value, urlOption.Schemes)
+		return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+	}
+	return nil
+}
+
+// validEmail valid object attribute that is email type
+func (valid *ValidMap) validEmail(val interface{}, key string) error {
+	if nil == val || "" == val {
+		if valid.require[key] {
+			blog.Error("params can not be null")
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	value, ok := val.(string)
+	if !ok || !util.IsEmail(value) {
+		blog.Errorf("params %s:%#v should be email", key, val)
+		return valid.errif.Errorf(common.CCErrCommParamsNeedEmail, key)
+	}
+	return nil
+}
+
+// validJSON valid object attribute that is json type
+func (valid *ValidMap) validJSON(val interface{}, key string) error {
+	if nil == val || "" == val {
+		if valid.require[key] {
+			blog.Error("params can not be null")
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	if !util.IsJSON(val) {
+		blog.Errorf("params %s:%#v should be json", key, val)
+		return valid.errif.Errorf(common.CCErrCommParamsNeedJSON, key)
+	}
+	return nil
+}
+
+// validList valid object attribute that is list type
+func (valid *ValidMap) validList(val interface{}, key string) error {
+	if nil == val {
+		if valid.require[key] {
+			blog.Error("params can not be null")
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	if reflect.TypeOf(val).Kind() != reflect.Slice {
+		blog.Errorf("params %s:%#v should be list", key, val)
+		return valid.errif.Errorf(common.CCErrCommParamsNeedList, key)
+	}
+	if valid.require[key] && reflect.ValueOf(val).Len() == 0 {
+		blog.Error("params can not be empty")
+		return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+	}
+
+	property, ok := valid.propertys[key]
+	if !ok {
+		return nil
+	}
+	listOption, err := util.ParseListOption(property.Option)
+	if nil != err {
+		blog.Warnf("ParseListOption failed: %v", err)
+		return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+	}
+	if err := util.ValidListItems(val, listOption); nil != err {
+		blog.Errorf("params %s:%#v not valid, %v", key, val, err)
+		return valid.errif.Errorf(common.CCErrCommFieldNotValidFail, key+" "+err.Error())
+	}
+	return nil
+}
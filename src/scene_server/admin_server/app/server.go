@@ -20,17 +20,22 @@ import (
 	"sync"
 	"time"
 
+	"icenter/src/apimachinery/util"
 	"icenter/src/auth/authcenter"
 	"icenter/src/common/backbone"
 	"icenter/src/common/backbone/configcenter"
 	cc "icenter/src/common/backbone/configcenter"
 	"icenter/src/common/blog"
+	"icenter/src/common/registry"
+	"icenter/src/common/storage/dal/metrics"
 	"icenter/src/common/storage/dal/mongo"
 	"icenter/src/common/storage/dal/mongo/local"
 	"icenter/src/common/types"
 	"icenter/src/common/version"
 	"icenter/src/scene_server/admin_server/app/options"
 	"icenter/src/scene_server/admin_server/configures"
+	"icenter/src/scene_server/admin_server/quality"
+	"icenter/src/scene_server/admin_server/replicator"
 	svc "icenter/src/scene_server/admin_server/service"
 	"icenter/src/scene_server/admin_server/synchronizer"
 )
@@ -49,6 +54,13 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 	process.onHostConfigUpdate(*pconfig, *pconfig)
 	service := svc.NewService(ctx)
 
+	// host the registry, so that the services could run without zookeeper
+	if len(process.Config.Register.Listen) != 0 {
+		if err := registry.ListenAndServe(ctx, process.Config.Register.Listen, process.Config.Register.User, process.Config.Register.Password); err != nil {
+			return fmt.Errorf("start registry failed, err: %v", err)
+		}
+	}
+
 	input := &backbone.BackboneParameter{
 		ConfigUpdate: process.onHostConfigUpdate,
 		ConfigPath:   op.ServConf.ExConfig,
@@ -64,7 +76,7 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 	service.Config = *process.Config
 	process.Core = engine
 	process.Service = service
-	process.icenter = configures.NewConfCenter(ctx, engine.ServiceManageClient())
+	process.icenter = configures.NewConfCenter(ctx, engine.ConfRegDiscover())
 	for {
 		if process.Config == nil {
 			time.Sleep(time.Second * 2)
@@ -75,7 +87,11 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 		if err != nil {
 			return fmt.Errorf("connect mongo server failed %s", err.Error())
 		}
-		process.Service.SetDB(db)
+		mdb := metrics.NewDB(db)
+		process.Service.SetDB(mdb)
+		// the active versions of the error message and language packs in db override the resource files
+		process.icenter.SetDB(mdb)
+		process.Service.SetConfCenter(process.icenter)
 		process.Service.SetApiSrvAddr(process.Config.ProcSrvConfig.CCApiSrvAddr)
 		err = process.icenter.Start(
 			process.Config.Configures.Dir,
@@ -86,7 +102,10 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 			return err
 		}
 
-		if process.Config.AuthCenter.Enable {
+		if process.Config.AuthCenter.Enable && process.Config.AuthCenter.Backend == authcenter.BackendLocal {
+			// the local backend authorizes with the privileges in db, there is nothing to init or sync.
+			blog.Info("enable local auth backend, auth center access is not needed.")
+		} else if process.Config.AuthCenter.Enable {
 			blog.Info("enable auth center access.")
 			authcli, err := authcenter.NewAuthCenter(nil, process.Config.AuthCenter)
 			if err != nil {
@@ -103,6 +122,26 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 		} else {
 			blog.Infof("disable auth center access.")
 		}
+
+		if process.Config.Replication.Enable {
+			apiMachineryConfig := &util.APIMachineryConfig{QPS: 1000, Burst: 2000}
+			r, err := replicator.NewAPIReplicator(process.Config.Replication, engine.CoreAPI.CoreService(), apiMachineryConfig)
+			if err != nil {
+				return fmt.Errorf("new replicator failed: %v", err)
+			}
+			r.SetIsMaster(engine.ServiceManageInterface.IsMaster)
+			process.Service.SetReplicator(r)
+			go r.Run(ctx)
+			blog.Infof("enable replication to %d targets.", len(process.Config.Replication.Targets))
+		}
+
+		if process.Config.Quality.Enable {
+			checker := quality.NewChecker(process.Config.Quality, mdb, engine.CoreAPI.TopoServer())
+			checker.SetIsMaster(engine.ServiceManageInterface.IsMaster)
+			process.Service.SetQualityChecker(checker)
+			go checker.Run(ctx)
+			blog.Infof("enable data quality checks every %s.", process.Config.Quality.Interval)
+		}
 		break
 	}
 	if err := backbone.StartServer(ctx, engine, service.WebService()); err != nil {
@@ -142,6 +181,9 @@ func (h *MigrateServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 		h.Config.Configures.Dir = current.ConfigMap["confs.dir"]
 
 		h.Config.Register.Address = current.ConfigMap["register-server.addrs"]
+		h.Config.Register.Listen = current.ConfigMap["register-server.listen"]
+		h.Config.Register.User = current.ConfigMap["register-server.usr"]
+		h.Config.Register.Password = current.ConfigMap["register-server.pwd"]
 
 		h.Config.ProcSrvConfig.CCApiSrvAddr, _ = current.ConfigMap["procsrv.cc_api"]
 
@@ -150,6 +192,16 @@ func (h *MigrateServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 		if err != nil && h.Config.AuthCenter.Enable {
 			blog.Errorf("parse authcenter error: %v, config: %+v", err, current.ConfigMap)
 		}
+
+		h.Config.Replication, err = replicator.ParseConfigFromKV("replication", current.ConfigMap)
+		if err != nil && h.Config.Replication.Enable {
+			blog.Errorf("parse replication config error: %v", err)
+		}
+
+		h.Config.Quality, err = quality.ParseConfigFromKV("quality", current.ConfigMap)
+		if err != nil {
+			blog.Errorf("parse quality config error: %v", err)
+		}
 	}
 }
 
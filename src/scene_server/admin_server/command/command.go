@@ -20,11 +20,16 @@ import (
 	"github.com/spf13/pflag"
 	"icenter/src/common"
 	"icenter/src/common/backbone/configcenter"
+	"icenter/src/common/storage/dal"
 	"icenter/src/common/storage/dal/mongo"
 	"icenter/src/common/storage/dal/mongo/local"
 )
 
-const bkbizCmdName = "bkbiz"
+const (
+	bkbizCmdName   = "bkbiz"
+	bkmodelCmdName = "bkmodel"
+	bkseedCmdName  = "bkseed"
+)
 
 const (
 	scopeAll = "all"
@@ -33,7 +38,16 @@ const (
 // Parse run app command
 func Parse(args []string) error {
 	ctx := context.Background()
-	if len(args) <= 1 || args[1] != bkbizCmdName {
+	if len(args) <= 1 {
+		return nil
+	}
+	if args[1] == bkmodelCmdName {
+		return parseBKModel(ctx, args)
+	}
+	if args[1] == bkseedCmdName {
+		return parseBKSeed(ctx, args)
+	}
+	if args[1] != bkbizCmdName {
 		return nil
 	}
 
@@ -63,17 +77,9 @@ func Parse(args []string) error {
 		return err
 	}
 
-	// read config
-	config, err := configcenter.ParseConfigWithFile(configPosition)
-	if nil != err {
-		return fmt.Errorf("parse config file error %s", err.Error())
-	}
-	mongoConfig := mongo.ParseConfigFromKV("mongodb", config.ConfigMap)
-
-	// connect to mongo db
-	db, err := local.NewMgo(mongoConfig.BuildURI(), 0)
+	db, err := connectDB(configPosition)
 	if err != nil {
-		return fmt.Errorf("connect mongo server failed %s", err.Error())
+		return err
 	}
 	opt := &option{
 		position: filePath,
@@ -120,3 +126,20 @@ func Parse(args []string) error {
 	os.Exit(0)
 	return nil
 }
+
+// connectDB connect to the mongo db in the config file
+func connectDB(configPosition string) (dal.RDB, error) {
+	// read config
+	config, err := configcenter.ParseConfigWithFile(configPosition)
+	if nil != err {
+		return nil, fmt.Errorf("parse config file error %s", err.Error())
+	}
+	mongoConfig := mongo.ParseConfigFromKV("mongodb", config.ConfigMap)
+
+	// connect to mongo db
+	db, err := local.NewMgo(mongoConfig.BuildURI(), 0)
+	if err != nil {
+		return nil, fmt.Errorf("connect mongo server failed %s", err.Error())
+	}
+	return db, nil
+}
@@ -17,7 +17,6 @@ import (
 	"fmt"
 
 	"icenter/src/common"
-	"icenter/src/common/blog"
 	"icenter/src/common/condition"
 	"icenter/src/common/metadata"
 	"icenter/src/common/storage/dal"
@@ -31,8 +30,10 @@ func getBKTopo(ctx context.Context, db dal.RDB, opt *option) (*Topo, error) {
 	if nil != err {
 		return nil, err
 	}
+
+	var assts []*metadata.Association
 	if opt.scope == "all" || opt.scope == common.BKInnerObjIDApp {
-		assts, err := getMainlineAssociation(ctx, db, opt)
+		assts, err = getMainlineAssociation(ctx, db, opt)
 		if nil != err {
 			return nil, err
 		}
@@ -41,24 +42,35 @@ func getBKTopo(ctx context.Context, db dal.RDB, opt *option) (*Topo, error) {
 			return nil, err
 		}
 		objIds = append(objIds, topo...)
-		pcmap := getPCmap(assts)
-
-		err = getTree(ctx, db, root, pcmap)
-		if nil != err {
-			return nil, err
-		}
 		result.Mainline = topo
-		result.BizTopo = root
 	}
-
 	if opt.scope == scopeAll || opt.scope == common.BKInnerObjIDProc {
 		objIds = append(objIds, common.BKInnerObjIDProc)
+	}
 
-		bizID, err := root.getInstID()
-		if err != nil {
+	// the instances are trimmed to the required fields while they are read in mini mode
+	exp := &exporter{}
+	if opt.mini {
+		if _, exp.keys, err = getModelAttributes(ctx, db, opt, objIds); nil != err {
+			return nil, err
+		}
+		exp.keys[common.BKInnerObjIDProc] = append(exp.keys[common.BKInnerObjIDProc], "bind_ip", "port", "protocol", "bk_func_name", "work_path", "bk_start_param_regex")
+	}
+
+	bizID, err := root.getInstID()
+	if err != nil {
+		return nil, err
+	}
+	if result.Mainline != nil {
+		if err = exp.getTree(ctx, db, root, bizID, getPCmap(assts)); nil != err {
 			return nil, err
 		}
-		procmodules, err := getProcessTopo(ctx, db, opt, bizID)
+		root.Data = exp.trim(common.BKInnerObjIDApp, root.Data)
+		result.BizTopo = root
+	}
+
+	if opt.scope == scopeAll || opt.scope == common.BKInnerObjIDProc {
+		procmodules, err := exp.getProcessTopo(ctx, db, bizID)
 		if nil != err {
 			return nil, err
 		}
@@ -69,31 +81,23 @@ func getBKTopo(ctx context.Context, db dal.RDB, opt *option) (*Topo, error) {
 		result.ProcTopos = proctopo
 	}
 
-	if opt.mini {
-
-		_, keys, err := getModelAttributes(ctx, db, opt, objIds)
-		if nil != err {
-			return nil, err
-		}
+	return result, nil
+}
 
-		if result.BizTopo != nil {
-			err := result.BizTopo.walk(func(node *Node) error {
-				node.Data = util.CopyMap(node.Data, keys[node.ObjID], []string{common.BKInstParentStr})
-				return nil
-			})
-			if err != nil {
-				blog.Errorf("walk through biz topo failed, err: %+v", err)
-			}
-		}
+// exporter read the instances of the business topo, the keys are the fields kept in mini mode.
+type exporter struct {
+	keys map[string][]string
+}
 
-		if result.ProcTopos != nil {
-			for _, proc := range result.ProcTopos.Processes {
-				proc.Data = util.CopyMap(proc.Data, append(keys[common.BKInnerObjIDProc], "bind_ip", "port", "protocol", "bk_func_name", "work_path", "bk_start_param_regex"), []string{common.BKInstParentStr, common.BKAppIDField, common.BKOwnerIDField})
-			}
-		}
+// trim returns the data with the kept fields only in mini mode
+func (e *exporter) trim(objID string, data map[string]interface{}) map[string]interface{} {
+	if e.keys == nil {
+		return data
 	}
-
-	return result, nil
+	if objID == common.BKInnerObjIDProc {
+		return util.CopyMap(data, e.keys[objID], []string{common.BKInstParentStr, common.BKAppIDField, common.BKOwnerIDField})
+	}
+	return util.CopyMap(data, e.keys[objID], []string{common.BKInstParentStr})
 }
 
 func getBKAppNode(ctx context.Context, db dal.RDB, opt *option) (*Node, error) {
@@ -110,17 +114,13 @@ func getBKAppNode(ctx context.Context, db dal.RDB, opt *option) (*Node, error) {
 	return bkApp, nil
 }
 
-func getTree(ctx context.Context, db dal.RDB, root *Node, pcmap map[string]*metadata.Association) error {
+// getTree read the children of the node whose instance id is instID recursively
+func (e *exporter) getTree(ctx context.Context, db dal.RDB, root *Node, instID uint64, pcmap map[string]*metadata.Association) error {
 	asst := pcmap[root.ObjID]
 	if asst == nil {
 		return nil
 	}
 
-	instID, err := root.getInstID()
-	if nil != err {
-		return nil
-	}
-
 	childCondition := condition.CreateCondition()
 	childCondition.Field(common.BKInstParentStr).Eq(instID)
 
@@ -132,24 +132,35 @@ func getTree(ctx context.Context, db dal.RDB, root *Node, pcmap map[string]*meta
 	}
 
 	// blog.InfoJSON("get childs for %s:%d", asst.ObjectID, instID)
-	childs := make([]map[string]interface{}, 0)
 	tablename := common.GetInstTableName(asst.ObjectID)
 
-	err = db.Table(tablename).Find(childCondition.ToMapStr()).All(ctx, &childs)
+	// the ids are taken before the children are trimmed, the id fields are not kept in mini mode
+	childIDs := make(map[*Node]uint64)
+	err := db.Table(tablename).Find(childCondition.ToMapStr()).Iterate(ctx, func(doc dal.Decoder) error {
+		child := &Node{ObjID: asst.ObjectID}
+		if err := doc.Decode(&child.Data); err != nil {
+			return err
+		}
+		if childID, err := child.getInstID(); nil == err {
+			childIDs[child] = childID
+		}
+		child.Data = e.trim(asst.ObjectID, child.Data)
+		root.Children = append(root.Children, child)
+		return nil
+	})
 	if nil != err {
 		return fmt.Errorf("get inst for %s error: %s", asst.ObjectID, err.Error())
 	}
 
-	for _, child := range childs {
-		root.Children = append(root.Children, &Node{ObjID: asst.ObjectID, Data: child})
-	}
-
-	child := pcmap[asst.ObjectID]
-	if child == nil {
+	if pcmap[asst.ObjectID] == nil {
 		return nil
 	}
 	for _, child := range root.Children {
-		err = getTree(ctx, db, child, pcmap)
+		childID, ok := childIDs[child]
+		if !ok {
+			continue
+		}
+		err = e.getTree(ctx, db, child, childID, pcmap)
 		if nil != err {
 			return err
 		}
@@ -210,41 +221,39 @@ func getMainlineAssociation(ctx context.Context, db dal.RDB, opt *option) ([]*me
 	return assts, nil
 }
 
-func getProcessTopo(ctx context.Context, db dal.RDB, opt *option, bizID uint64) ([]*Process, error) {
+func (e *exporter) getProcessTopo(ctx context.Context, db dal.RDB, bizID uint64) ([]*Process, error) {
 	// fetch all process module
-	procmodules := make([]ProModule, 0)
 	cond := condition.CreateCondition()
 	cond.Field(common.BKAppIDField).Eq(bizID)
-	err := db.Table(common.BKTableNameProcModule).Find(cond.ToMapStr()).All(ctx, &procmodules)
-	if nil != err {
-		return nil, fmt.Errorf("get process faile %s", err.Error())
-	}
-
 	procmodMap := map[uint64][]string{} // processID -> modules
-	procIDs := make([]uint64, 0)
-	for _, pm := range procmodules {
-		procIDs = append(procIDs, pm.ProcessID)
+	err := db.Table(common.BKTableNameProcModule).Find(cond.ToMapStr()).Iterate(ctx, func(doc dal.Decoder) error {
+		pm := ProModule{}
+		if err := doc.Decode(&pm); err != nil {
+			return err
+		}
 		procmodMap[pm.ProcessID] = append(procmodMap[pm.ProcessID], pm.ModuleName)
-	}
-
-	// fetch all process
-	procs := make([]map[string]interface{}, 0)
-	err = db.Table(common.BKTableNameBaseProcess).Find(cond.ToMapStr()).All(ctx, &procs)
+		return nil
+	})
 	if nil != err {
 		return nil, fmt.Errorf("get process faile %s", err.Error())
 	}
 
+	// fetch all process
 	topos := make([]*Process, 0)
-	for _, proc := range procs {
-		topo := Process{
-			Data: proc,
+	err = db.Table(common.BKTableNameBaseProcess).Find(cond.ToMapStr()).Iterate(ctx, func(doc dal.Decoder) error {
+		proc := make(map[string]interface{})
+		if err := doc.Decode(&proc); err != nil {
+			return err
 		}
 		procID, err := getInt64(proc[common.BKProcessIDField])
 		if nil != err {
-			return nil, err
+			return err
 		}
-		topo.Modules = procmodMap[procID]
-		topos = append(topos, &topo)
+		topos = append(topos, &Process{Data: e.trim(common.BKInnerObjIDProc, proc), Modules: procmodMap[procID]})
+		return nil
+	})
+	if nil != err {
+		return nil, fmt.Errorf("get process faile %s", err.Error())
 	}
 
 	return topos, nil
@@ -0,0 +1,118 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"icenter/src/common"
+	"icenter/src/common/storage/dal"
+	"icenter/src/scene_server/admin_server/schema"
+)
+
+// parseBKModel run the bkmodel command, which exports the model layer to a yaml or json file,
+// or applies such a file to the model layer.
+func parseBKModel(ctx context.Context, args []string) error {
+	var (
+		exportFlag     bool
+		importFlag     bool
+		dryRunFlag     bool
+		filePath       string
+		configPosition string
+		ownerID        string
+	)
+
+	cmdFlags := pflag.NewFlagSet(bkmodelCmdName, pflag.ExitOnError)
+	cmdFlags.BoolVar(&dryRunFlag, "dryrun", false, "dryrun flag, if this flag seted, we will just print the plan but not execute to db")
+	cmdFlags.BoolVar(&exportFlag, "export", false, "export flag")
+	cmdFlags.BoolVar(&importFlag, "import", false, "import flag")
+	cmdFlags.StringVar(&filePath, "file", "", "export/import filepath, the format is json if the extension is .json, otherwise yaml")
+	cmdFlags.StringVar(&configPosition, "config", "conf/api.conf", "The config path. e.g conf/api.conf")
+	cmdFlags.StringVar(&ownerID, "owner", common.BKDefaultOwnerID, "the supplier account of the model layer")
+	err := cmdFlags.Parse(args[1:])
+	if err != nil {
+		return err
+	}
+	if len(filePath) == 0 {
+		return fmt.Errorf("the file is not set")
+	}
+
+	db, err := connectDB(configPosition)
+	if err != nil {
+		return err
+	}
+
+	if exportFlag {
+		fmt.Printf("exporting the models to %s\n", filePath)
+		if err := exportModels(ctx, db, ownerID, filePath); err != nil {
+			fmt.Printf("export error: %s", err.Error())
+			os.Exit(2)
+		}
+		fmt.Printf("the models have been export to %s\n", filePath)
+	} else if importFlag {
+		if dryRunFlag {
+			fmt.Printf("dryrun import the models from %s\n", filePath)
+		} else {
+			fmt.Printf("importing the models from %s\n", filePath)
+		}
+		if err := importModels(ctx, db, ownerID, filePath, dryRunFlag); err != nil {
+			fmt.Printf("import error: %s", err.Error())
+			os.Exit(2)
+		}
+		if !dryRunFlag {
+			fmt.Printf("the models have been import from %s\n", filePath)
+		}
+	} else {
+		fmt.Printf("invalide argument")
+	}
+
+	os.Exit(0)
+	return nil
+}
+
+func exportModels(ctx context.Context, db dal.RDB, ownerID, filePath string) error {
+	s, err := schema.Export(ctx, db, ownerID)
+	if err != nil {
+		return err
+	}
+	data, err := schema.Marshal(s, schema.FormatOfFile(filePath))
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, data, 0644)
+}
+
+func importModels(ctx context.Context, db dal.RDB, ownerID, filePath string, dryrun bool) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	target, err := schema.Unmarshal(data, schema.FormatOfFile(filePath))
+	if err != nil {
+		return err
+	}
+	plan, err := schema.BuildPlan(ctx, db, ownerID, target)
+	if err != nil {
+		return err
+	}
+	fmt.Print(plan.String())
+	if dryrun || len(plan.Changes) == 0 {
+		return nil
+	}
+	return schema.Apply(ctx, db, ownerID, common.CCSystemOperatorUserName, plan)
+}
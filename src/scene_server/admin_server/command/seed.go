@@ -0,0 +1,66 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"icenter/src/common"
+	"icenter/src/common/storage/dal/memory"
+	"icenter/src/scene_server/admin_server/upgrader"
+)
+
+// parseBKSeed run the bkseed command, which runs all the upgraders on an empty memory db and dumps it to a file,
+// the coreservice loads the file as the initial data when the data is saved in memory.
+func parseBKSeed(ctx context.Context, args []string) error {
+	var filePath string
+	cmdFlags := pflag.NewFlagSet(bkseedCmdName, pflag.ExitOnError)
+	cmdFlags.StringVar(&filePath, "file", "", "the file to save the initial data")
+	if err := cmdFlags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if len(filePath) == 0 {
+		return fmt.Errorf("the file is not set")
+	}
+
+	fmt.Printf("dumping the initial data to %s\n", filePath)
+	if err := dumpSeed(ctx, filePath); err != nil {
+		fmt.Printf("dump error: %s", err.Error())
+		os.Exit(2)
+	}
+	fmt.Printf("the initial data has been dumped to %s\n", filePath)
+	os.Exit(0)
+	return nil
+}
+
+func dumpSeed(ctx context.Context, filePath string) error {
+	db := memory.NewMemory()
+	conf := &upgrader.Config{OwnerID: common.BKDefaultOwnerID, SupplierID: common.BKDefaultSupplierID, User: "migrate"}
+	if err := upgrader.Upgrade(ctx, db, conf); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err := db.Dump(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -13,19 +13,20 @@
 package configures
 
 import (
-	"encoding/json"
 	"path/filepath"
 
 	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sync"
 
-	"icenter/src/common/backbone/service_mange/zk"
 	"icenter/src/common/blog"
 	"icenter/src/common/confregdiscover"
 	"icenter/src/common/errors"
 	"icenter/src/common/language"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
 	"icenter/src/common/types"
 )
 
@@ -33,13 +34,20 @@ import (
 type ConfCenter struct {
 	confRegDiscv confregdiscover.ConfRegDiscvIf
 	ctx          context.Context
+
+	lock sync.RWMutex
+	// db the versions of the error message and language packs are stored in it
+	db dal.RDB
+	// files the packs loaded from the resource files, the key is the kind and then the language.
+	files map[string]map[string]map[string]string
 }
 
 // NewConfCenter create a ConfCenter object
-func NewConfCenter(ctx context.Context, client *zk.ZkClient) *ConfCenter {
+func NewConfCenter(ctx context.Context, confRegDiscv confregdiscover.ConfRegDiscvIf) *ConfCenter {
 	return &ConfCenter{
 		ctx:          ctx,
-		confRegDiscv: confregdiscover.NewZkRegDiscover(client),
+		confRegDiscv: confRegDiscv,
+		files:        make(map[string]map[string]map[string]string),
 	}
 }
 
@@ -72,6 +80,8 @@ func (cc *ConfCenter) Start(confDir, errres, languageres string) error {
 	} else {
 		blog.Infof("writed languate packages to center %v", types.CC_SERVLANG_BASEPATH)
 	}
+	cc.logReports(cc.ctx, metadata.LanguagePackError)
+	cc.logReports(cc.ctx, metadata.LanguagePackLanguage)
 
 	// TODO discover config file change
 	go func() {
@@ -99,9 +109,16 @@ func (cc *ConfCenter) writeErrorRes2Center(errorres string) error {
 		return fmt.Errorf("load error resource error: %s", err)
 	}
 
-	data, err := json.Marshal(errcode)
-	key := types.CC_SERVERROR_BASEPATH
-	return cc.confRegDiscv.Write(key, data)
+	packs := make(map[string]map[string]string, len(errcode))
+	for lang, entries := range errcode {
+		packs[lang] = entries
+	}
+	cc.lock.Lock()
+	cc.files[metadata.LanguagePackError] = packs
+	cc.lock.Unlock()
+
+	// the active versions in db override the packs in the files
+	return cc.publish(cc.ctx, metadata.LanguagePackError)
 }
 
 func (cc *ConfCenter) writeLanguageRes2Center(languageres string) error {
@@ -121,12 +138,16 @@ func (cc *ConfCenter) writeLanguageRes2Center(languageres string) error {
 		return fmt.Errorf("load language resource error: %s", err)
 	}
 
-	data, err := json.Marshal(languagepack)
-	if err != nil {
-		return err
+	packs := make(map[string]map[string]string, len(languagepack))
+	for lang, entries := range languagepack {
+		packs[lang] = entries
 	}
-	key := types.CC_SERVLANG_BASEPATH
-	return cc.confRegDiscv.Write(key, data)
+	cc.lock.Lock()
+	cc.files[metadata.LanguagePackLanguage] = packs
+	cc.lock.Unlock()
+
+	// the active versions in db override the packs in the files
+	return cc.publish(cc.ctx, metadata.LanguagePackLanguage)
 }
 
 //WriteConfs2Center save configurs into center.
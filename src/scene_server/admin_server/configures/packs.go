@@ -0,0 +1,374 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configures
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/types"
+)
+
+// defaultLanguage the language used when the requested one is not found, the others are checked against it.
+const defaultLanguage = "default"
+
+var languageCodeRegexp = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+var formatVerbRegexp = regexp.MustCompile(`%[-+# 0]*(\[\d+\])?(\d+|\*)?(\.(\d+|\*)?)?[a-zA-Z%]`)
+
+// SetDB set the db where the versions of the packs are stored, the packs are loaded
+// from the resource files only if it's not set.
+func (cc *ConfCenter) SetDB(db dal.RDB) {
+	cc.lock.Lock()
+	cc.db = db
+	cc.lock.Unlock()
+}
+
+// Packs returns the validation reports of the packs which are published
+func (cc *ConfCenter) Packs(ctx context.Context, kind string) ([]metadata.LanguagePackReport, error) {
+	packs, versions, err := cc.effectivePacks(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]metadata.LanguagePackReport, 0, len(packs))
+	for lang, entries := range packs {
+		report, err := validatePack(kind, lang, entries, packs[defaultLanguage])
+		if err != nil {
+			blog.Warnf("the %s pack of %s is invalid, err: %v", kind, lang, err)
+		}
+		report.Version = versions[lang]
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Language < reports[j].Language })
+	return reports, nil
+}
+
+// GetPack returns the published pack of the language
+func (cc *ConfCenter) GetPack(ctx context.Context, kind, lang string) (*metadata.LanguagePack, error) {
+	packs, versions, err := cc.effectivePacks(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	entries, exists := packs[lang]
+	if !exists {
+		return nil, fmt.Errorf("the %s pack of %s not exists", kind, lang)
+	}
+	return &metadata.LanguagePack{
+		Kind:     kind,
+		Language: lang,
+		Version:  versions[lang],
+		Active:   true,
+		Entries:  entries,
+	}, nil
+}
+
+// PackVersions returns the saved versions of the pack, the entries are not returned.
+func (cc *ConfCenter) PackVersions(ctx context.Context, kind, lang string) ([]metadata.LanguagePack, error) {
+	db, err := cc.packDB(kind)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]metadata.LanguagePack, 0)
+	cond := mapstr.MapStr{"kind": kind, "language": lang}
+	if err := db.Table(common.BKTableNameLanguagePack).Find(cond).Sort("-version").All(ctx, &versions); err != nil {
+		return nil, err
+	}
+	for index := range versions {
+		versions[index].EntriesJSON = ""
+	}
+	return versions, nil
+}
+
+// SavePack validate the entries and save them as a new version of the pack, the new version is published at once.
+func (cc *ConfCenter) SavePack(ctx context.Context, kind, lang, creator string, input metadata.SaveLanguagePackRequest) (*metadata.SaveLanguagePackResult, error) {
+	db, err := cc.packDB(kind)
+	if err != nil {
+		return nil, err
+	}
+	if !languageCodeRegexp.MatchString(lang) {
+		return nil, fmt.Errorf("invalid language code %s", lang)
+	}
+	packs, _, err := cc.effectivePacks(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]string)
+	if input.Merge {
+		for key, val := range packs[lang] {
+			entries[key] = val
+		}
+	}
+	for key, val := range input.Entries {
+		entries[key] = val
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("the %s pack of %s is empty", kind, lang)
+	}
+	reference := packs[defaultLanguage]
+	if lang == defaultLanguage {
+		reference = entries
+	}
+	report, err := validatePack(kind, lang, entries, reference)
+	if err != nil {
+		return nil, err
+	}
+	result := &metadata.SaveLanguagePackResult{Report: report}
+	if input.DryRun {
+		return result, nil
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	latest := make([]metadata.LanguagePack, 0)
+	cond := mapstr.MapStr{"kind": kind, "language": lang}
+	table := db.Table(common.BKTableNameLanguagePack)
+	if err := table.Find(cond).Sort("-version").Limit(1).All(ctx, &latest); err != nil {
+		return nil, err
+	}
+	pack := metadata.LanguagePack{
+		Kind:        kind,
+		Language:    lang,
+		Version:     1,
+		Active:      true,
+		EntriesJSON: string(data),
+		Comment:     input.Comment,
+		Creator:     creator,
+		CreateTime:  time.Now(),
+	}
+	if len(latest) != 0 {
+		pack.Version = latest[0].Version + 1
+	}
+	if err := table.Update(ctx, cond, mapstr.MapStr{"active": false}); err != nil {
+		return nil, err
+	}
+	if err := table.Insert(ctx, pack); err != nil {
+		return nil, err
+	}
+	if err := cc.publish(ctx, kind); err != nil {
+		return nil, err
+	}
+	blog.Infof("version %d of the %s pack of %s is published by %s, missing %d keys", pack.Version, kind, lang, creator, len(report.Missing))
+
+	result.Version = pack.Version
+	result.Report.Version = pack.Version
+	return result, nil
+}
+
+// ActivatePack publish the saved version of the pack, version 0 publishes the pack in the resource files.
+func (cc *ConfCenter) ActivatePack(ctx context.Context, kind, lang string, version int64) error {
+	db, err := cc.packDB(kind)
+	if err != nil {
+		return err
+	}
+	table := db.Table(common.BKTableNameLanguagePack)
+	cond := mapstr.MapStr{"kind": kind, "language": lang}
+	if version != 0 {
+		count, err := table.Find(mapstr.MapStr{"kind": kind, "language": lang, "version": version}).Count(ctx)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("version %d of the %s pack of %s not exists", version, kind, lang)
+		}
+	}
+	if err := table.Update(ctx, cond, mapstr.MapStr{"active": false}); err != nil {
+		return err
+	}
+	if version != 0 {
+		cond["version"] = version
+		if err := table.Update(ctx, cond, mapstr.MapStr{"active": true}); err != nil {
+			return err
+		}
+	}
+	return cc.publish(ctx, kind)
+}
+
+func (cc *ConfCenter) packDB(kind string) (dal.RDB, error) {
+	if kind != metadata.LanguagePackError && kind != metadata.LanguagePackLanguage {
+		return nil, fmt.Errorf("invalid pack kind %s", kind)
+	}
+	cc.lock.RLock()
+	defer cc.lock.RUnlock()
+	if cc.db == nil {
+		return nil, fmt.Errorf("the db of the packs is not set")
+	}
+	return cc.db, nil
+}
+
+// effectivePacks returns the packs in the resource files overridden by the active versions in db,
+// and the active versions, the key is the language.
+func (cc *ConfCenter) effectivePacks(ctx context.Context, kind string) (map[string]map[string]string, map[string]int64, error) {
+	if kind != metadata.LanguagePackError && kind != metadata.LanguagePackLanguage {
+		return nil, nil, fmt.Errorf("invalid pack kind %s", kind)
+	}
+	cc.lock.RLock()
+	db := cc.db
+	packs := make(map[string]map[string]string, len(cc.files[kind]))
+	for lang, entries := range cc.files[kind] {
+		packs[lang] = entries
+	}
+	cc.lock.RUnlock()
+
+	versions := make(map[string]int64)
+	if db == nil {
+		return packs, versions, nil
+	}
+	active := make([]metadata.LanguagePack, 0)
+	cond := mapstr.MapStr{"kind": kind, "active": true}
+	if err := db.Table(common.BKTableNameLanguagePack).Find(cond).All(ctx, &active); err != nil {
+		return nil, nil, err
+	}
+	for _, pack := range active {
+		entries := make(map[string]string)
+		if err := json.Unmarshal([]byte(pack.EntriesJSON), &entries); err != nil {
+			blog.Errorf("decode version %d of the %s pack of %s failed, err: %v", pack.Version, kind, pack.Language, err)
+			continue
+		}
+		packs[pack.Language] = entries
+		versions[pack.Language] = pack.Version
+	}
+	return packs, versions, nil
+}
+
+// publish write the effective packs to the config center, the services reload them when they're changed.
+func (cc *ConfCenter) publish(ctx context.Context, kind string) error {
+	packs, _, err := cc.effectivePacks(ctx, kind)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(packs)
+	if err != nil {
+		return err
+	}
+	key := types.CC_SERVLANG_BASEPATH
+	if kind == metadata.LanguagePackError {
+		key = types.CC_SERVERROR_BASEPATH
+	}
+	return cc.confRegDiscv.Write(key, data)
+}
+
+// logReports flag the missing keys of the published packs
+func (cc *ConfCenter) logReports(ctx context.Context, kind string) {
+	reports, err := cc.Packs(ctx, kind)
+	if err != nil {
+		blog.Errorf("validate the %s packs failed, err: %v", kind, err)
+		return
+	}
+	for _, report := range reports {
+		if len(report.Missing)+len(report.Unknown)+len(report.FormatMismatch) == 0 {
+			continue
+		}
+		blog.Warnf("the %s pack of %s (version %d) has %d missing keys, %d unknown keys and %d format mismatched keys",
+			kind, report.Language, report.Version, len(report.Missing), len(report.Unknown), len(report.FormatMismatch))
+	}
+}
+
+// validatePack check the entries against the error codes in errInfo.go or the keys of the default language,
+// and the format verbs against the reference, which is the default language.
+func validatePack(kind, lang string, entries, reference map[string]string) (metadata.LanguagePackReport, error) {
+	report := metadata.LanguagePackReport{
+		Kind:           kind,
+		Language:       lang,
+		Count:          len(entries),
+		Missing:        make([]string, 0),
+		Unknown:        make([]string, 0),
+		FormatMismatch: make([]string, 0),
+	}
+
+	switch kind {
+	case metadata.LanguagePackError:
+		codes := make(map[string]bool, len(common.CCErrorCodes))
+		for _, code := range common.CCErrorCodes {
+			codes[strconv.Itoa(code)] = true
+		}
+		for key := range entries {
+			if _, err := strconv.Atoi(key); err != nil {
+				return report, fmt.Errorf("the key %s of the error pack is not an error code", key)
+			}
+			if !codes[key] {
+				report.Unknown = append(report.Unknown, key)
+			}
+		}
+		for code := range codes {
+			if _, exists := entries[code]; !exists {
+				report.Missing = append(report.Missing, code)
+			}
+		}
+	case metadata.LanguagePackLanguage:
+		if lang != defaultLanguage && reference != nil {
+			for key := range reference {
+				if _, exists := entries[key]; !exists {
+					report.Missing = append(report.Missing, key)
+				}
+			}
+			for key := range entries {
+				if _, exists := reference[key]; !exists {
+					report.Unknown = append(report.Unknown, key)
+				}
+			}
+		}
+	default:
+		return report, fmt.Errorf("invalid pack kind %s", kind)
+	}
+
+	if lang != defaultLanguage {
+		for key, message := range entries {
+			if origin, exists := reference[key]; exists && !sameVerbs(origin, message) {
+				report.FormatMismatch = append(report.FormatMismatch, key)
+			}
+		}
+	}
+	sort.Strings(report.Missing)
+	sort.Strings(report.Unknown)
+	sort.Strings(report.FormatMismatch)
+	return report, nil
+}
+
+// sameVerbs whether the messages have the same format verbs in the same order, so that the
+// translated message is formatted with the same arguments.
+func sameVerbs(a, b string) bool {
+	verbsA, verbsB := formatVerbs(a), formatVerbs(b)
+	if len(verbsA) != len(verbsB) {
+		return false
+	}
+	for index := range verbsA {
+		if verbsA[index] != verbsB[index] {
+			return false
+		}
+	}
+	return true
+}
+
+func formatVerbs(message string) []byte {
+	verbs := make([]byte, 0)
+	for _, verb := range formatVerbRegexp.FindAllString(message, -1) {
+		if verb == "%%" {
+			continue
+		}
+		verbs = append(verbs, verb[len(verb)-1])
+	}
+	return verbs
+}
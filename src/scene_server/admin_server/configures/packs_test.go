@@ -0,0 +1,190 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configures
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"icenter/src/common"
+	"icenter/src/common/confregdiscover"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal/memory"
+	"icenter/src/common/types"
+)
+
+func writeResource(t *testing.T, dir, lang string, entries map[string]string) {
+	if err := os.MkdirAll(filepath.Join(dir, lang), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, lang, "common.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readPublished(t *testing.T, disc confregdiscover.ConfRegDiscvIf, key string) map[string]map[string]string {
+	data, err := disc.Read(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packs := make(map[string]map[string]string)
+	if err := json.Unmarshal([]byte(data), &packs); err != nil {
+		t.Fatal(err)
+	}
+	return packs
+}
+
+func TestValidatePack(t *testing.T) {
+	invalid := strconv.Itoa(common.CCErrCommParamsIsInvalid)
+	notFound := strconv.Itoa(common.CCErrCommNotFound)
+	reference := map[string]string{invalid: "param %s is invalid", notFound: "not found"}
+
+	report, err := validatePack(metadata.LanguagePackError, "ja", map[string]string{
+		invalid: "パラメータが無効です",
+		"1":     "unknown",
+	}, reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// several names share the same code
+	codes := make(map[int]bool)
+	for _, code := range common.CCErrorCodes {
+		codes[code] = true
+	}
+	if len(report.Missing) != len(codes)-1 {
+		t.Errorf("expect %d missing codes, got %d", len(codes)-1, len(report.Missing))
+	}
+	if len(report.Unknown) != 1 || report.Unknown[0] != "1" {
+		t.Errorf("unexpected unknown codes %v", report.Unknown)
+	}
+	if len(report.FormatMismatch) != 1 || report.FormatMismatch[0] != invalid {
+		t.Errorf("unexpected format mismatched codes %v", report.FormatMismatch)
+	}
+
+	if _, err := validatePack(metadata.LanguagePackError, "ja", map[string]string{"title": "x"}, reference); err == nil {
+		t.Errorf("the key of the error pack should be an error code")
+	}
+
+	report, err = validatePack(metadata.LanguagePackLanguage, "zh-TW",
+		map[string]string{"title": "標題", "extra": "x"}, map[string]string{"title": "标题", "host": "主机"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Missing) != 1 || report.Missing[0] != "host" || len(report.Unknown) != 1 || report.Unknown[0] != "extra" {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	if !sameVerbs("%s has %d items, 100%%", "%s 有 %d 项, 100%%") || sameVerbs("%s and %d", "%d and %s") {
+		t.Errorf("unexpected format verbs comparison")
+	}
+}
+
+func TestSavePack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "language")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	errDir, langDir, confDir := filepath.Join(dir, "errors"), filepath.Join(dir, "language"), filepath.Join(dir, "conf")
+	invalid := strconv.Itoa(common.CCErrCommParamsIsInvalid)
+	writeResource(t, errDir, "default", map[string]string{invalid: "参数 %s 无效"})
+	writeResource(t, errDir, "en", map[string]string{invalid: "param %s is invalid"})
+	writeResource(t, langDir, "default", map[string]string{"title": "标题", "host": "主机"})
+	if err := os.MkdirAll(confDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	disc := confregdiscover.NewMemRegDiscover(ctx)
+	cc := NewConfCenter(ctx, disc)
+	cc.SetDB(memory.NewMemory())
+	if err := cc.Start(confDir, errDir, langDir); err != nil {
+		t.Fatal(err)
+	}
+	if packs := readPublished(t, disc, types.CC_SERVERROR_BASEPATH); packs["en"][invalid] != "param %s is invalid" {
+		t.Fatalf("the error packs in the files should be published, got %v", packs)
+	}
+
+	// a fixed translation is merged into the pack in the files
+	result, err := cc.SavePack(ctx, metadata.LanguagePackError, "en", "admin", metadata.SaveLanguagePackRequest{
+		Entries: map[string]string{invalid: "the parameter %s is invalid"},
+		Merge:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Version != 1 || len(result.Report.FormatMismatch) != 0 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if packs := readPublished(t, disc, types.CC_SERVERROR_BASEPATH); packs["en"][invalid] != "the parameter %s is invalid" {
+		t.Fatalf("the saved pack should be published, got %v", packs["en"])
+	}
+
+	// a new language, the dry run saves nothing
+	input := metadata.SaveLanguagePackRequest{Entries: map[string]string{"title": "標題"}, DryRun: true}
+	result, err = cc.SavePack(ctx, metadata.LanguagePackLanguage, "zh-TW", "admin", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Version != 0 || len(result.Report.Missing) != 1 || result.Report.Missing[0] != "host" {
+		t.Fatalf("unexpected dry run result %+v", result)
+	}
+	if _, exists := readPublished(t, disc, types.CC_SERVLANG_BASEPATH)["zh-TW"]; exists {
+		t.Fatalf("the dry run should not publish the pack")
+	}
+	input.DryRun = false
+	if _, err := cc.SavePack(ctx, metadata.LanguagePackLanguage, "zh-TW", "admin", input); err != nil {
+		t.Fatal(err)
+	}
+	if packs := readPublished(t, disc, types.CC_SERVLANG_BASEPATH); packs["zh-TW"]["title"] != "標題" || packs["default"]["host"] != "主机" {
+		t.Fatalf("unexpected language packs %v", packs)
+	}
+
+	// the second version, and then roll back to the first one, and to the files.
+	if _, err := cc.SavePack(ctx, metadata.LanguagePackError, "en", "admin", metadata.SaveLanguagePackRequest{
+		Entries: map[string]string{invalid: "invalid %s"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	versions, err := cc.PackVersions(ctx, metadata.LanguagePackError, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].Version != 2 || !versions[0].Active || versions[1].Active {
+		t.Fatalf("unexpected versions %+v", versions)
+	}
+	if err := cc.ActivatePack(ctx, metadata.LanguagePackError, "en", 1); err != nil {
+		t.Fatal(err)
+	}
+	if packs := readPublished(t, disc, types.CC_SERVERROR_BASEPATH); packs["en"][invalid] != "the parameter %s is invalid" {
+		t.Fatalf("version 1 should be published, got %v", packs["en"])
+	}
+	if err := cc.ActivatePack(ctx, metadata.LanguagePackError, "en", 0); err != nil {
+		t.Fatal(err)
+	}
+	if packs := readPublished(t, disc, types.CC_SERVERROR_BASEPATH); packs["en"][invalid] != "param %s is invalid" {
+		t.Fatalf("the pack in the files should be published, got %v", packs["en"])
+	}
+	if err := cc.ActivatePack(ctx, metadata.LanguagePackError, "en", 3); err == nil {
+		t.Errorf("the version not exists should not be activated")
+	}
+}
@@ -13,32 +13,5 @@
 package main
 
 import (
-	_ "icenter/src/scene_server/admin_server/upgrader/v3.0.8"
-	_ "icenter/src/scene_server/admin_server/upgrader/v3.0.9-beta.1"
-	_ "icenter/src/scene_server/admin_server/upgrader/v3.0.9-beta.3"
-	_ "icenter/src/scene_server/admin_server/upgrader/v3.1.0-alpha.2"
-	_ "icenter/src/scene_server/admin_server/upgrader/x08.09.04.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x08.09.11.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x08.09.13.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x08.09.17.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x08.09.18.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x08.09.26.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x18.09.30.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x18.10.10.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x18.10.30.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x18.10.30.02"
-	_ "icenter/src/scene_server/admin_server/upgrader/x18.11.07.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x18.11.19.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.05.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.12.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.12.02"
-	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.12.03"
-	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.12.04"
-	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.12.05"
-	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.12.06"
-	_ "icenter/src/scene_server/admin_server/upgrader/x19.01.18.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x19.02.15.10"
-	_ "icenter/src/scene_server/admin_server/upgrader/x19.04.16.01"
-	_ "icenter/src/scene_server/admin_server/upgrader/x19.04.16.02"
-	_ "icenter/src/scene_server/admin_server/upgrader/x19.04.16.03"
+	_ "icenter/src/scene_server/admin_server/upgrader/versions"
 )
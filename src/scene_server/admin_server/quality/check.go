@@ -0,0 +1,555 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quality
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/util"
+)
+
+// violationKey identifies a violation of a rule, by the instance and the association
+type violationKey struct {
+	ruleID int64
+	instID int64
+	asstID int64
+}
+
+// scoreKey identifies the business the score is of
+type scoreKey struct {
+	ownerID string
+	bizID   int64
+}
+
+// instRef the instance checked by the rules
+type instRef struct {
+	id      int64
+	ownerID string
+	bizID   int64
+	value   interface{}
+}
+
+// checkRound the violations found and the checks made in a check round
+type checkRound struct {
+	now        time.Time
+	weights    map[int64]float64
+	violations map[violationKey]*metadata.QualityViolation
+	checks     map[scoreKey]float64
+}
+
+func (r *checkRound) addCheck(rule *metadata.QualityRule, bizID int64) {
+	r.checks[scoreKey{ownerID: rule.OwnerID, bizID: bizID}] += rule.Weight
+}
+
+func (r *checkRound) addViolation(rule *metadata.QualityRule, violation metadata.QualityViolation) {
+	violation.RuleID = rule.ID
+	violation.Kind = rule.Kind
+	violation.ObjectID = rule.ObjectID
+	violation.PropertyID = rule.PropertyID
+	violation.OwnerID = rule.OwnerID
+	violation.CheckTime = r.now
+	violation.LastTime = r.now
+	key := violationKey{ruleID: rule.ID, instID: violation.InstID, asstID: violation.AsstID}
+	r.violations[key] = &violation
+}
+
+// Check checks the instances with all the enabled rules, saves the violations and the scores of the businesses.
+// the violations not found any more are resolved, the acknowledged ones are kept acknowledged.
+func (c *Checker) Check(ctx context.Context) (*metadata.QualityCheckResult, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	result := &metadata.QualityCheckResult{StartTime: c.now()}
+	rules := make([]metadata.QualityRule, 0)
+	if err := c.db.Table(common.BKTableNameQualityRule).Find(map[string]interface{}{"enabled": true}).All(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	round := &checkRound{
+		now:        result.StartTime,
+		weights:    make(map[int64]float64),
+		violations: make(map[violationKey]*metadata.QualityViolation),
+		checks:     make(map[scoreKey]float64),
+	}
+	objRules := make(map[string][]metadata.QualityRule)
+	objIDs := make([]string, 0)
+	for _, rule := range rules {
+		if _, exists := objRules[rule.ObjectID]; !exists {
+			objIDs = append(objIDs, rule.ObjectID)
+		}
+		objRules[rule.ObjectID] = append(objRules[rule.ObjectID], rule)
+		round.weights[rule.ID] = rule.Weight
+	}
+	sort.Strings(objIDs)
+	for _, objID := range objIDs {
+		if err := c.checkObject(ctx, objID, objRules[objID], round); err != nil {
+			return nil, fmt.Errorf("check the instances of %s failed, err: %v", objID, err)
+		}
+	}
+
+	if err := c.saveViolations(ctx, round); err != nil {
+		return nil, fmt.Errorf("save the violations failed, err: %v", err)
+	}
+	scores, err := c.saveScores(ctx, round)
+	if err != nil {
+		return nil, fmt.Errorf("save the scores failed, err: %v", err)
+	}
+
+	result.Rules = len(rules)
+	result.Violations = int64(len(round.violations))
+	result.Scores = scores
+	result.EndTime = c.now()
+	return result, nil
+}
+
+// checkObject reads the instances of the model page by page, and checks them with the rules of the model
+func (c *Checker) checkObject(ctx context.Context, objID string, rules []metadata.QualityRule, round *checkRound) error {
+	patterns := make(map[int64]*regexp.Regexp)
+	for _, rule := range rules {
+		if rule.Kind == metadata.QualityRuleRegex {
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern of rule %d, err: %v", rule.ID, err)
+			}
+			patterns[rule.ID] = pattern
+		}
+	}
+
+	hostBiz := make(map[int64]int64)
+	if objID == common.BKInnerObjIDHost {
+		var err error
+		if hostBiz, err = c.hostBusinesses(ctx); err != nil {
+			return err
+		}
+	}
+
+	idField := common.GetInstIDField(objID)
+	filter := map[string]interface{}{}
+	if !common.IsInnerModel(objID) {
+		filter[common.BKObjIDField] = objID
+	}
+	insts := make(map[int64]instRef)
+	// rule id -> value -> the instances with the value
+	values := make(map[int64]map[string][]instRef)
+	for start := uint64(0); ; start += c.config.PageSize {
+		page := make([]mapstr.MapStr, 0)
+		err := c.db.Table(common.GetInstTableName(objID)).Find(filter).Sort(idField).Start(start).Limit(c.config.PageSize).All(ctx, &page)
+		if err != nil {
+			return err
+		}
+
+		for _, inst := range page {
+			id, err := util.GetInt64ByInterface(inst[idField])
+			if err != nil {
+				continue
+			}
+			ref := instRef{id: id, ownerID: util.GetStrByInterface(inst[common.BKOwnerIDField])}
+			if objID == common.BKInnerObjIDHost {
+				ref.bizID = hostBiz[id]
+			} else {
+				ref.bizID = instBusiness(inst)
+			}
+			insts[id] = ref
+
+			for index := range rules {
+				rule := &rules[index]
+				if rule.Kind == metadata.QualityRuleUnique {
+					// the values of all the owners are collected, and only the instances of the owner are checked
+					if value := inst[rule.PropertyID]; !isEmpty(value) {
+						if _, exists := values[rule.ID]; !exists {
+							values[rule.ID] = make(map[string][]instRef)
+						}
+						key := strings.TrimSpace(fmt.Sprint(value))
+						item := ref
+						item.value = value
+						values[rule.ID][key] = append(values[rule.ID][key], item)
+					}
+					if rule.OwnerID == ref.ownerID {
+						round.addCheck(rule, ref.bizID)
+					}
+					continue
+				}
+				if rule.Kind == metadata.QualityRuleOrphanAssociation || rule.OwnerID != ref.ownerID {
+					continue
+				}
+				round.addCheck(rule, ref.bizID)
+				if message, failed := checkInst(rule, patterns[rule.ID], inst, round.now); failed {
+					round.addViolation(rule, metadata.QualityViolation{
+						InstID:  id,
+						BizID:   ref.bizID,
+						Value:   inst[rule.PropertyID],
+						Message: message,
+					})
+				}
+			}
+		}
+		if uint64(len(page)) < c.config.PageSize {
+			break
+		}
+	}
+
+	for index := range rules {
+		rule := &rules[index]
+		switch rule.Kind {
+		case metadata.QualityRuleUnique:
+			checkUnique(rule, values[rule.ID], round)
+		case metadata.QualityRuleOrphanAssociation:
+			if err := c.checkAssociations(ctx, rule, insts, round); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkInst checks the instance with the required, regex or stale rule, returns the message of the violation
+func checkInst(rule *metadata.QualityRule, pattern *regexp.Regexp, inst mapstr.MapStr, now time.Time) (string, bool) {
+	value := inst[rule.PropertyID]
+	switch rule.Kind {
+	case metadata.QualityRuleRequired:
+		if isEmpty(value) {
+			return fmt.Sprintf("the %s is empty", rule.PropertyID), true
+		}
+	case metadata.QualityRuleRegex:
+		if !isEmpty(value) && !pattern.MatchString(fmt.Sprint(value)) {
+			return fmt.Sprintf("the %s %v does not match %s", rule.PropertyID, value, rule.Pattern), true
+		}
+	case metadata.QualityRuleStale:
+		lastTime, ok := parseTime(value)
+		if !ok {
+			return fmt.Sprintf("the %s is unknown", rule.PropertyID), true
+		}
+		if lastTime.Before(now.AddDate(0, 0, -rule.StaleDays)) {
+			return fmt.Sprintf("not updated since %s", lastTime.Format(time.RFC3339)), true
+		}
+	}
+	return "", false
+}
+
+// checkUnique the instances of the rule's owner violate the rule if their values are used by other instances
+func checkUnique(rule *metadata.QualityRule, values map[string][]instRef, round *checkRound) {
+	for _, refs := range values {
+		if len(refs) < 2 {
+			continue
+		}
+		for _, ref := range refs {
+			if ref.ownerID != rule.OwnerID {
+				continue
+			}
+			round.addViolation(rule, metadata.QualityViolation{
+				InstID:  ref.id,
+				BizID:   ref.bizID,
+				Value:   ref.value,
+				Message: fmt.Sprintf("the %s %v is used by %d instances", rule.PropertyID, ref.value, len(refs)),
+			})
+		}
+	}
+}
+
+// checkAssociations the instances at both sides of the associations of the model must exist
+func (c *Checker) checkAssociations(ctx context.Context, rule *metadata.QualityRule, insts map[int64]instRef, round *checkRound) error {
+	objID := rule.ObjectID
+	filter := map[string]interface{}{
+		common.BKOwnerIDField: rule.OwnerID,
+		common.BKDBOR: []map[string]interface{}{
+			{common.BKObjIDField: objID},
+			{common.BKAsstObjIDField: objID},
+		},
+	}
+	assts := make([]metadata.InstAsst, 0)
+	for start := uint64(0); ; start += c.config.PageSize {
+		page := make([]metadata.InstAsst, 0)
+		err := c.db.Table(common.BKTableNameInstAsst).Find(filter).Sort(common.BKFieldID).Start(start).Limit(c.config.PageSize).All(ctx, &page)
+		if err != nil {
+			return err
+		}
+		assts = append(assts, page...)
+		if uint64(len(page)) < c.config.PageSize {
+			break
+		}
+	}
+
+	// the instances of the other models, object id -> instance id -> exists
+	others := make(map[string]map[int64]bool)
+	addOther := func(otherObjID string, instID int64) {
+		if otherObjID == objID {
+			return
+		}
+		if _, exists := others[otherObjID]; !exists {
+			others[otherObjID] = make(map[int64]bool)
+		}
+		others[otherObjID][instID] = false
+	}
+	for _, asst := range assts {
+		addOther(asst.ObjectID, asst.InstID)
+		addOther(asst.AsstObjectID, asst.AsstInstID)
+	}
+	for otherObjID, ids := range others {
+		if err := c.markExisting(ctx, otherObjID, ids); err != nil {
+			return err
+		}
+	}
+
+	exists := func(instObjID string, instID int64) bool {
+		if instObjID == objID {
+			_, exists := insts[instID]
+			return exists
+		}
+		return others[instObjID][instID]
+	}
+	for _, asst := range assts {
+		instID := asst.InstID
+		if asst.ObjectID != objID {
+			instID = asst.AsstInstID
+		}
+		bizID := insts[instID].bizID
+		round.addCheck(rule, bizID)
+
+		missing := make([]string, 0)
+		if !exists(asst.ObjectID, asst.InstID) {
+			missing = append(missing, fmt.Sprintf("%s %d", asst.ObjectID, asst.InstID))
+		}
+		if !exists(asst.AsstObjectID, asst.AsstInstID) {
+			missing = append(missing, fmt.Sprintf("%s %d", asst.AsstObjectID, asst.AsstInstID))
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		round.addViolation(rule, metadata.QualityViolation{
+			InstID:  instID,
+			AsstID:  asst.ID,
+			BizID:   bizID,
+			Value:   asst.ObjectAsstID,
+			Message: fmt.Sprintf("the %s of the association %s does not exist", strings.Join(missing, ", "), asst.ObjectAsstID),
+		})
+	}
+	return nil
+}
+
+// markExisting set the ids of the model which exist in db to true
+func (c *Checker) markExisting(ctx context.Context, objID string, ids map[int64]bool) error {
+	idField := common.GetInstIDField(objID)
+	all := make([]int64, 0, len(ids))
+	for id := range ids {
+		all = append(all, id)
+	}
+	for start := 0; start < len(all); start += int(c.config.PageSize) {
+		end := start + int(c.config.PageSize)
+		if end > len(all) {
+			end = len(all)
+		}
+		filter := map[string]interface{}{idField: map[string]interface{}{common.BKDBIN: all[start:end]}}
+		if !common.IsInnerModel(objID) {
+			filter[common.BKObjIDField] = objID
+		}
+		existing := make([]mapstr.MapStr, 0)
+		if err := c.db.Table(common.GetInstTableName(objID)).Find(filter).Fields(idField).All(ctx, &existing); err != nil {
+			return err
+		}
+		for _, item := range existing {
+			if id, err := util.GetInt64ByInterface(item[idField]); err == nil {
+				ids[id] = true
+			}
+		}
+	}
+	return nil
+}
+
+// hostBusinesses returns the business of the hosts
+func (c *Checker) hostBusinesses(ctx context.Context) (map[int64]int64, error) {
+	relations := make([]mapstr.MapStr, 0)
+	err := c.db.Table(common.BKTableNameModuleHostConfig).Find(map[string]interface{}{}).Fields(common.BKHostIDField, common.BKAppIDField).All(ctx, &relations)
+	if err != nil {
+		return nil, err
+	}
+	hostBiz := make(map[int64]int64)
+	for _, relation := range relations {
+		hostID, err := util.GetInt64ByInterface(relation[common.BKHostIDField])
+		if err != nil {
+			continue
+		}
+		bizID, err := util.GetInt64ByInterface(relation[common.BKAppIDField])
+		if err != nil {
+			continue
+		}
+		hostBiz[hostID] = bizID
+	}
+	return hostBiz, nil
+}
+
+// saveViolations update the violations found before, insert the new ones, and resolve the ones not found any more.
+// the statuses of the violations in the round are set to the saved ones.
+func (c *Checker) saveViolations(ctx context.Context, round *checkRound) error {
+	existing := make([]metadata.QualityViolation, 0)
+	filter := map[string]interface{}{
+		"status": map[string]interface{}{
+			common.BKDBIN: []string{metadata.QualityViolationOpen, metadata.QualityViolationAcknowledged},
+		},
+	}
+	if err := c.db.Table(common.BKTableNameQualityViolation).Find(filter).All(ctx, &existing); err != nil {
+		return err
+	}
+
+	for _, item := range existing {
+		idFilter := map[string]interface{}{common.BKFieldID: item.ID}
+		found, exists := round.violations[violationKey{ruleID: item.RuleID, instID: item.InstID, asstID: item.AsstID}]
+		if !exists {
+			resolved := map[string]interface{}{"status": metadata.QualityViolationResolved, common.LastTimeField: round.now}
+			if err := c.db.Table(common.BKTableNameQualityViolation).Update(ctx, idFilter, resolved); err != nil {
+				return err
+			}
+			continue
+		}
+		found.ID = item.ID
+		found.Status = item.Status
+		found.Operator = item.Operator
+		found.CreateTime = item.CreateTime
+		if err := c.db.Table(common.BKTableNameQualityViolation).Update(ctx, idFilter, found); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]violationKey, 0)
+	for key, violation := range round.violations {
+		if violation.ID == 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].ruleID != keys[j].ruleID {
+			return keys[i].ruleID < keys[j].ruleID
+		}
+		if keys[i].instID != keys[j].instID {
+			return keys[i].instID < keys[j].instID
+		}
+		return keys[i].asstID < keys[j].asstID
+	})
+	for _, key := range keys {
+		violation := round.violations[key]
+		id, err := c.db.NextSequence(ctx, common.BKTableNameQualityViolation)
+		if err != nil {
+			return err
+		}
+		violation.ID = int64(id)
+		violation.Status = metadata.QualityViolationOpen
+		violation.CreateTime = round.now
+		if err := c.db.Table(common.BKTableNameQualityViolation).Insert(ctx, violation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveScores replace the scores of the businesses with the ones of the round, the acknowledged violations
+// are not counted.
+func (c *Checker) saveScores(ctx context.Context, round *checkRound) ([]metadata.QualityScore, error) {
+	failed := make(map[scoreKey]float64)
+	counts := make(map[scoreKey]int64)
+	for _, violation := range round.violations {
+		if violation.Status != metadata.QualityViolationOpen {
+			continue
+		}
+		key := scoreKey{ownerID: violation.OwnerID, bizID: violation.BizID}
+		failed[key] += round.weights[violation.RuleID]
+		counts[key]++
+	}
+
+	scores := make([]metadata.QualityScore, 0, len(round.checks))
+	for key, checks := range round.checks {
+		score := 100.0
+		if checks > 0 {
+			score = math.Max(0, 100*(checks-failed[key])/checks)
+		}
+		scores = append(scores, metadata.QualityScore{
+			BizID:      key.bizID,
+			OwnerID:    key.ownerID,
+			Score:      math.Round(score*100) / 100,
+			Checks:     checks,
+			Violations: counts[key],
+			CheckTime:  round.now,
+		})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].OwnerID != scores[j].OwnerID {
+			return scores[i].OwnerID < scores[j].OwnerID
+		}
+		return scores[i].BizID < scores[j].BizID
+	})
+
+	if err := c.db.Table(common.BKTableNameQualityScore).Delete(ctx, map[string]interface{}{}); err != nil {
+		return nil, err
+	}
+	if len(scores) != 0 {
+		if err := c.db.Table(common.BKTableNameQualityScore).Insert(ctx, scores); err != nil {
+			return nil, err
+		}
+	}
+	return scores, nil
+}
+
+// instBusiness returns the business of the instance by the bk_biz_id, or the business label of the metadata
+func instBusiness(inst mapstr.MapStr) int64 {
+	if bizID, err := util.GetInt64ByInterface(inst[common.BKAppIDField]); err == nil {
+		return bizID
+	}
+	meta, err := inst.MapStr(metadata.BKMetadata)
+	if err != nil {
+		return 0
+	}
+	label, err := meta.MapStr(metadata.BKLabel)
+	if err != nil {
+		return 0
+	}
+	bizID, _ := strconv.ParseInt(util.GetStrByInterface(label[common.BKAppIDField]), 10, 64)
+	return bizID
+}
+
+// isEmpty returns whether the value is nil, blank or an empty list
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return len(strings.TrimSpace(v)) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// parseTime returns the time of the value saved in db, or the time string
+func parseTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case *time.Time:
+		return *v, v != nil && !v.IsZero()
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, common.TimeTransferModel} {
+			if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,64 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quality
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPageSize      = 500
+	defaultCheckInterval = time.Hour
+)
+
+// Config the data quality check config
+type Config struct {
+	Enable bool
+	// Interval the interval between the checks of all the rules
+	Interval time.Duration
+	// PageSize the count of the instances read from db at a time
+	PageSize uint64
+}
+
+// ParseConfigFromKV returns a new config, the checks are enabled by default.
+func ParseConfigFromKV(prefix string, configmap map[string]string) (Config, error) {
+	var err error
+	cfg := Config{Enable: true, Interval: defaultCheckInterval, PageSize: defaultPageSize}
+	if enable, exist := configmap[prefix+".enable"]; exist {
+		cfg.Enable, err = strconv.ParseBool(enable)
+		if err != nil {
+			return Config{}, errors.New(`invalid quality "enable" value`)
+		}
+	}
+	if !cfg.Enable {
+		return cfg, nil
+	}
+
+	if interval := configmap[prefix+".interval"]; len(interval) != 0 {
+		seconds, err := strconv.ParseInt(interval, 10, 64)
+		if err != nil || seconds <= 0 {
+			return cfg, errors.New(`invalid quality "interval" value`)
+		}
+		cfg.Interval = time.Duration(seconds) * time.Second
+	}
+
+	if pageSize := configmap[prefix+".pageSize"]; len(pageSize) != 0 {
+		cfg.PageSize, err = strconv.ParseUint(pageSize, 10, 64)
+		if err != nil || cfg.PageSize == 0 {
+			return cfg, errors.New(`invalid quality "pageSize" value`)
+		}
+	}
+	return cfg, nil
+}
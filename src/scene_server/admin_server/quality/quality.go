@@ -0,0 +1,214 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quality
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"icenter/src/apimachinery/toposerver"
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+)
+
+// Checker checks the instances with the data quality rules on schedule, the violations found
+// are saved with the scores of the businesses.
+type Checker struct {
+	config   Config
+	db       dal.RDB
+	client   toposerver.TopoServerClientInterface
+	isMaster func() bool
+	now      func() time.Time
+
+	// lock only one check runs at a time
+	lock sync.Mutex
+}
+
+// NewChecker returns a new checker, the violations are fixed through the topo server client.
+func NewChecker(config Config, db dal.RDB, client toposerver.TopoServerClientInterface) *Checker {
+	return &Checker{
+		config:   config,
+		db:       db,
+		client:   client,
+		isMaster: func() bool { return true },
+		now:      time.Now,
+	}
+}
+
+// SetIsMaster set the function tells whether the server is the master, only the master checks on schedule.
+func (c *Checker) SetIsMaster(isMaster func() bool) {
+	c.isMaster = isMaster
+}
+
+// Run checks all the rules at every interval until the context is done
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !c.isMaster() {
+			continue
+		}
+		result, err := c.Check(ctx)
+		if err != nil {
+			blog.Errorf("[quality] check the data quality rules failed, err: %v", err)
+			continue
+		}
+		blog.Infof("[quality] checked %d rules, found %d violations", result.Rules, result.Violations)
+	}
+}
+
+// CreateRule validate and save a new rule
+func (c *Checker) CreateRule(ctx context.Context, ownerID, user string, rule metadata.QualityRule) (*metadata.QualityRule, error) {
+	if err := c.validateRule(ctx, &rule); err != nil {
+		return nil, err
+	}
+	id, err := c.db.NextSequence(ctx, common.BKTableNameQualityRule)
+	if err != nil {
+		return nil, err
+	}
+	now := c.now()
+	rule.ID = int64(id)
+	rule.OwnerID = ownerID
+	rule.Creator = user
+	rule.CreateTime = now
+	rule.LastTime = now
+	if err := c.db.Table(common.BKTableNameQualityRule).Insert(ctx, rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// UpdateRule validate and replace the rule of the id
+func (c *Checker) UpdateRule(ctx context.Context, ownerID string, id int64, rule metadata.QualityRule) (*metadata.QualityRule, error) {
+	existing, err := c.GetRule(ctx, ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.validateRule(ctx, &rule); err != nil {
+		return nil, err
+	}
+	rule.ID = existing.ID
+	rule.OwnerID = existing.OwnerID
+	rule.Creator = existing.Creator
+	rule.CreateTime = existing.CreateTime
+	rule.LastTime = c.now()
+	filter := map[string]interface{}{common.BKFieldID: id, common.BKOwnerIDField: ownerID}
+	if err := c.db.Table(common.BKTableNameQualityRule).Update(ctx, filter, rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteRule delete the rule and its violations
+func (c *Checker) DeleteRule(ctx context.Context, ownerID string, id int64) error {
+	if _, err := c.GetRule(ctx, ownerID, id); err != nil {
+		return err
+	}
+	filter := map[string]interface{}{common.BKFieldID: id, common.BKOwnerIDField: ownerID}
+	if err := c.db.Table(common.BKTableNameQualityRule).Delete(ctx, filter); err != nil {
+		return err
+	}
+	return c.db.Table(common.BKTableNameQualityViolation).Delete(ctx, map[string]interface{}{"rule_id": id})
+}
+
+// GetRule returns the rule of the id
+func (c *Checker) GetRule(ctx context.Context, ownerID string, id int64) (*metadata.QualityRule, error) {
+	rules := make([]metadata.QualityRule, 0)
+	filter := map[string]interface{}{common.BKFieldID: id, common.BKOwnerIDField: ownerID}
+	if err := c.db.Table(common.BKTableNameQualityRule).Find(filter).All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("quality rule %d not found", id)
+	}
+	return &rules[0], nil
+}
+
+// SearchRules returns the rules of the owner, or the rules of the model if the object id is set
+func (c *Checker) SearchRules(ctx context.Context, ownerID, objID string) ([]metadata.QualityRule, error) {
+	filter := map[string]interface{}{common.BKOwnerIDField: ownerID}
+	if len(objID) != 0 {
+		filter[common.BKObjIDField] = objID
+	}
+	rules := make([]metadata.QualityRule, 0)
+	if err := c.db.Table(common.BKTableNameQualityRule).Find(filter).Sort(common.BKFieldID).All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// validateRule checks the rule and sets the default values
+func (c *Checker) validateRule(ctx context.Context, rule *metadata.QualityRule) error {
+	if len(rule.Name) == 0 {
+		return errors.New("the name of the rule is empty")
+	}
+	if len(rule.ObjectID) == 0 {
+		return errors.New("the bk_obj_id of the rule is empty")
+	}
+	count, err := c.db.Table(common.BKTableNameObjDes).Find(map[string]interface{}{common.BKObjIDField: rule.ObjectID}).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("the model %s not found", rule.ObjectID)
+	}
+	if rule.Weight < 0 {
+		return errors.New("the weight of the rule can not be negative")
+	}
+	if rule.Weight == 0 {
+		rule.Weight = 1
+	}
+
+	switch rule.Kind {
+	case metadata.QualityRuleRequired, metadata.QualityRuleUnique:
+	case metadata.QualityRuleRegex:
+		if _, err := regexp.Compile(rule.Pattern); err != nil || len(rule.Pattern) == 0 {
+			return fmt.Errorf("invalid pattern %s of the regex rule", rule.Pattern)
+		}
+	case metadata.QualityRuleStale:
+		if rule.StaleDays <= 0 {
+			return errors.New("the stale_days of the stale rule must be positive")
+		}
+		rule.PropertyID = common.LastTimeField
+		return nil
+	case metadata.QualityRuleOrphanAssociation:
+		rule.PropertyID = ""
+		return nil
+	default:
+		return fmt.Errorf("invalid kind %s of the rule", rule.Kind)
+	}
+
+	if len(rule.PropertyID) == 0 {
+		return fmt.Errorf("the bk_property_id of the %s rule is empty", rule.Kind)
+	}
+	cond := map[string]interface{}{common.BKObjIDField: rule.ObjectID, common.BKPropertyIDField: rule.PropertyID}
+	count, err = c.db.Table(common.BKTableNameObjAttDes).Find(cond).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("the property %s of the model %s not found", rule.PropertyID, rule.ObjectID)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quality
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal/memory"
+)
+
+func newTestChecker(t *testing.T, now time.Time) *Checker {
+	ctx := context.Background()
+	db := memory.NewMemory()
+	insert := func(table string, docs ...map[string]interface{}) {
+		for _, doc := range docs {
+			if err := db.Table(table).Insert(ctx, doc); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	insert(common.BKTableNameObjDes,
+		map[string]interface{}{common.BKObjIDField: common.BKInnerObjIDHost},
+		map[string]interface{}{common.BKObjIDField: "switch"},
+	)
+	insert(common.BKTableNameObjAttDes,
+		map[string]interface{}{common.BKObjIDField: common.BKInnerObjIDHost, common.BKPropertyIDField: "operator"},
+		map[string]interface{}{common.BKObjIDField: common.BKInnerObjIDHost, common.BKPropertyIDField: "bk_sn"},
+	)
+	fresh, stale := now.Add(-time.Hour), now.AddDate(0, 0, -40)
+	insert(common.BKTableNameBaseHost,
+		map[string]interface{}{common.BKHostIDField: 1, common.BKOwnerIDField: "0", "operator": "admin", "bk_sn": "SN-1", common.LastTimeField: fresh},
+		map[string]interface{}{common.BKHostIDField: 2, common.BKOwnerIDField: "0", "operator": "", "bk_sn": "sn-2", common.LastTimeField: stale},
+		map[string]interface{}{common.BKHostIDField: 3, common.BKOwnerIDField: "1", "operator": "", "bk_sn": "SN-1", common.LastTimeField: fresh},
+	)
+	insert(common.BKTableNameModuleHostConfig,
+		map[string]interface{}{common.BKHostIDField: 1, common.BKAppIDField: 2},
+		map[string]interface{}{common.BKHostIDField: 2, common.BKAppIDField: 3},
+	)
+	insert(common.BKTableNameBaseInst,
+		map[string]interface{}{common.BKInstIDField: 10, common.BKObjIDField: "switch", common.BKOwnerIDField: "0"},
+	)
+	insert(common.BKTableNameInstAsst,
+		map[string]interface{}{common.BKFieldID: 100, common.BKOwnerIDField: "0", common.BKObjIDField: common.BKInnerObjIDHost, "bk_inst_id": 1, common.BKAsstObjIDField: "switch", "bk_asst_inst_id": 10},
+		map[string]interface{}{common.BKFieldID: 101, common.BKOwnerIDField: "0", common.BKObjIDField: common.BKInnerObjIDHost, "bk_inst_id": 1, common.BKAsstObjIDField: "switch", "bk_asst_inst_id": 11},
+	)
+
+	checker := NewChecker(Config{Enable: true, Interval: time.Hour, PageSize: 2}, db, nil)
+	checker.now = func() time.Time { return now }
+	return checker
+}
+
+func TestValidateRule(t *testing.T) {
+	ctx := context.Background()
+	checker := newTestChecker(t, time.Now())
+
+	invalid := []metadata.QualityRule{
+		{Name: "no kind", ObjectID: common.BKInnerObjIDHost},
+		{Name: "no model", ObjectID: "router", Kind: metadata.QualityRuleRequired, PropertyID: "operator"},
+		{Name: "no property", ObjectID: common.BKInnerObjIDHost, Kind: metadata.QualityRuleRequired, PropertyID: "bk_comment"},
+		{Name: "bad pattern", ObjectID: common.BKInnerObjIDHost, Kind: metadata.QualityRuleRegex, PropertyID: "bk_sn", Pattern: "("},
+		{Name: "no days", ObjectID: common.BKInnerObjIDHost, Kind: metadata.QualityRuleStale},
+	}
+	for _, rule := range invalid {
+		if _, err := checker.CreateRule(ctx, "0", "admin", rule); err == nil {
+			t.Errorf("the rule %s should be invalid", rule.Name)
+		}
+	}
+
+	rule, err := checker.CreateRule(ctx, "0", "admin", metadata.QualityRule{Name: "stale", ObjectID: common.BKInnerObjIDHost, Kind: metadata.QualityRuleStale, StaleDays: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.ID == 0 || rule.Weight != 1 || rule.PropertyID != common.LastTimeField || rule.OwnerID != "0" {
+		t.Errorf("unexpected rule %+v", rule)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	checker := newTestChecker(t, now)
+
+	rules := []metadata.QualityRule{
+		{Name: "operator", ObjectID: common.BKInnerObjIDHost, Kind: metadata.QualityRuleRequired, PropertyID: "operator", FixValue: "admin", Enabled: true},
+		{Name: "sn format", ObjectID: common.BKInnerObjIDHost, Kind: metadata.QualityRuleRegex, PropertyID: "bk_sn", Pattern: "^SN-[0-9]+$", Enabled: true},
+		{Name: "sn unique", ObjectID: common.BKInnerObjIDHost, Kind: metadata.QualityRuleUnique, PropertyID: "bk_sn", Weight: 2, Enabled: true},
+		{Name: "stale", ObjectID: common.BKInnerObjIDHost, Kind: metadata.QualityRuleStale, StaleDays: 30, Enabled: true},
+		{Name: "orphan", ObjectID: common.BKInnerObjIDHost, Kind: metadata.QualityRuleOrphanAssociation, Enabled: true},
+		{Name: "disabled", ObjectID: common.BKInnerObjIDHost, Kind: metadata.QualityRuleRequired, PropertyID: "bk_sn"},
+	}
+	for index := range rules {
+		rule, err := checker.CreateRule(ctx, "0", "admin", rules[index])
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules[index] = *rule
+	}
+
+	result, err := checker.Check(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// host 2: operator, sn format and stale; host 1: sn unique with host 3 of owner 1, orphan association 101
+	if result.Rules != 5 || result.Violations != 5 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	violations, err := checker.SearchViolations(ctx, "0", metadata.SearchQualityViolationsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[string]metadata.QualityViolation)
+	for _, violation := range violations.Info {
+		kinds[violation.Kind] = violation
+		if violation.Status != metadata.QualityViolationOpen {
+			t.Errorf("the violation %+v should be open", violation)
+		}
+	}
+	if kinds[metadata.QualityRuleUnique].InstID != 1 || kinds[metadata.QualityRuleOrphanAssociation].AsstID != 101 ||
+		kinds[metadata.QualityRuleRequired].InstID != 2 || kinds[metadata.QualityRuleStale].BizID != 3 {
+		t.Errorf("unexpected violations %+v", violations.Info)
+	}
+
+	// biz 2: host 1 checked by 4 rules with weight 5 and 2 associations, failed unique and an association
+	// biz 3: host 2 checked with weight 5, failed operator, sn format and stale
+	scores, err := checker.Scores(ctx, "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 2 || scores[0].BizID != 2 || scores[0].Score != 57.14 || scores[1].BizID != 3 || scores[1].Score != 40 {
+		t.Errorf("unexpected scores %+v", scores)
+	}
+
+	// the acknowledged violations are kept and not counted, the violations not found any more are resolved
+	bizID := int64(3)
+	biz3, err := checker.SearchViolations(ctx, "0", metadata.SearchQualityViolationsRequest{BizID: &bizID, Status: metadata.QualityViolationOpen})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if biz3.Count != 3 {
+		t.Fatalf("unexpected violations of biz 3: %+v", biz3.Info)
+	}
+	ids := []int64{biz3.Info[0].ID, biz3.Info[1].ID, biz3.Info[2].ID}
+	if err := checker.Acknowledge(ctx, "0", "admin", ids); err != nil {
+		t.Fatal(err)
+	}
+	if err := checker.db.Table(common.BKTableNameInstAsst).Delete(ctx, map[string]interface{}{common.BKFieldID: 101}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checker.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	scores, err = checker.Scores(ctx, "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scores[0].Score != 66.67 || scores[1].Score != 100 {
+		t.Errorf("unexpected scores %+v", scores)
+	}
+	all, err := checker.SearchViolations(ctx, "0", metadata.SearchQualityViolationsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make(map[string]int)
+	for _, violation := range all.Info {
+		statuses[violation.Status]++
+	}
+	if all.Count != 5 || statuses[metadata.QualityViolationAcknowledged] != 3 || statuses[metadata.QualityViolationResolved] != 1 {
+		t.Errorf("unexpected violations %+v", all.Info)
+	}
+
+	// the unique violations can not be fixed automatically
+	results, err := checker.Fix(ctx, http.Header{}, "0", []int64{kinds[metadata.QualityRuleUnique].ID, 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Success || results[1].Success {
+		t.Errorf("unexpected fix results %+v", results)
+	}
+}
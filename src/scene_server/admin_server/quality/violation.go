@@ -0,0 +1,192 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quality
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/metadata"
+	"icenter/src/common/util"
+)
+
+const defaultViolationLimit = 100
+
+// SearchViolations returns the violations of the owner matching the request
+func (c *Checker) SearchViolations(ctx context.Context, ownerID string, input metadata.SearchQualityViolationsRequest) (*metadata.SearchQualityViolationsResult, error) {
+	filter := map[string]interface{}{common.BKOwnerIDField: ownerID}
+	if input.RuleID != 0 {
+		filter["rule_id"] = input.RuleID
+	}
+	if len(input.ObjectID) != 0 {
+		filter[common.BKObjIDField] = input.ObjectID
+	}
+	if input.BizID != nil {
+		filter[common.BKAppIDField] = *input.BizID
+	}
+	if len(input.Status) != 0 {
+		filter["status"] = input.Status
+	}
+
+	count, err := c.db.Table(common.BKTableNameQualityViolation).Find(filter).Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort := input.Page.Sort
+	if len(sort) == 0 {
+		sort = common.BKFieldID
+	}
+	limit := input.Page.Limit
+	if limit <= 0 {
+		limit = defaultViolationLimit
+	}
+	start := input.Page.Start
+	if start < 0 {
+		start = 0
+	}
+	violations := make([]metadata.QualityViolation, 0)
+	err = c.db.Table(common.BKTableNameQualityViolation).Find(filter).Sort(sort).Start(uint64(start)).Limit(uint64(limit)).All(ctx, &violations)
+	if err != nil {
+		return nil, err
+	}
+	return &metadata.SearchQualityViolationsResult{Count: count, Info: violations}, nil
+}
+
+// Acknowledge mark the open violations as acknowledged, they are not counted in the scores since the next check
+func (c *Checker) Acknowledge(ctx context.Context, ownerID, user string, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	filter := map[string]interface{}{
+		common.BKFieldID:      map[string]interface{}{common.BKDBIN: ids},
+		common.BKOwnerIDField: ownerID,
+		"status":              metadata.QualityViolationOpen,
+	}
+	doc := map[string]interface{}{
+		"status":             metadata.QualityViolationAcknowledged,
+		"operator":           user,
+		common.LastTimeField: c.now(),
+	}
+	return c.db.Table(common.BKTableNameQualityViolation).Update(ctx, filter, doc)
+}
+
+// Scores returns the scores of the businesses of the owner by the latest check
+func (c *Checker) Scores(ctx context.Context, ownerID string) ([]metadata.QualityScore, error) {
+	scores := make([]metadata.QualityScore, 0)
+	filter := map[string]interface{}{common.BKOwnerIDField: ownerID}
+	if err := c.db.Table(common.BKTableNameQualityScore).Find(filter).Sort(common.BKAppIDField).All(ctx, &scores); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// Fix fixes the open or acknowledged violations through the topo server with the header of the request,
+// so that the changes are authorized, validated and audited as the ones of the user. the property of the required or
+// regex rule is set to the fix value, and the orphan association is deleted. the other violations
+// can not be fixed automatically.
+func (c *Checker) Fix(ctx context.Context, header http.Header, ownerID string, ids []int64) ([]metadata.QualityFixResult, error) {
+	violations := make([]metadata.QualityViolation, 0)
+	filter := map[string]interface{}{
+		common.BKFieldID:      map[string]interface{}{common.BKDBIN: ids},
+		common.BKOwnerIDField: ownerID,
+		"status": map[string]interface{}{
+			common.BKDBIN: []string{metadata.QualityViolationOpen, metadata.QualityViolationAcknowledged},
+		},
+	}
+	if err := c.db.Table(common.BKTableNameQualityViolation).Find(filter).All(ctx, &violations); err != nil {
+		return nil, err
+	}
+	found := make(map[int64]metadata.QualityViolation)
+	ruleIDs := make([]int64, 0)
+	for _, violation := range violations {
+		found[violation.ID] = violation
+		ruleIDs = append(ruleIDs, violation.RuleID)
+	}
+
+	rules := make([]metadata.QualityRule, 0)
+	ruleFilter := map[string]interface{}{common.BKFieldID: map[string]interface{}{common.BKDBIN: ruleIDs}}
+	if err := c.db.Table(common.BKTableNameQualityRule).Find(ruleFilter).All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	ruleMap := make(map[int64]metadata.QualityRule)
+	for _, rule := range rules {
+		ruleMap[rule.ID] = rule
+	}
+
+	results := make([]metadata.QualityFixResult, 0, len(ids))
+	for _, id := range ids {
+		result := metadata.QualityFixResult{ID: id}
+		violation, exists := found[id]
+		if !exists {
+			result.Message = "the violation is not found or not open"
+			results = append(results, result)
+			continue
+		}
+		rule, exists := ruleMap[violation.RuleID]
+		if !exists {
+			result.Message = fmt.Sprintf("the rule %d is not found", violation.RuleID)
+			results = append(results, result)
+			continue
+		}
+
+		if err := c.fix(ctx, header, &rule, &violation); err != nil {
+			blog.Errorf("[quality] fix the violation %d failed, err: %v, rid: %s", id, err, util.GetHTTPCCRequestID(header))
+			result.Message = err.Error()
+			results = append(results, result)
+			continue
+		}
+		doc := map[string]interface{}{
+			"status":             metadata.QualityViolationFixed,
+			"operator":           util.GetUser(header),
+			common.LastTimeField: c.now(),
+		}
+		if err := c.db.Table(common.BKTableNameQualityViolation).Update(ctx, map[string]interface{}{common.BKFieldID: id}, doc); err != nil {
+			return nil, err
+		}
+		result.Success = true
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// fix apply the fix of the rule to the violation
+func (c *Checker) fix(ctx context.Context, header http.Header, rule *metadata.QualityRule, violation *metadata.QualityViolation) error {
+	switch rule.Kind {
+	case metadata.QualityRuleRequired, metadata.QualityRuleRegex:
+		if rule.FixValue == nil {
+			return fmt.Errorf("the fix_value of the rule %d is not set", rule.ID)
+		}
+		data := map[string]interface{}{rule.PropertyID: rule.FixValue}
+		rsp, err := c.client.Instance().UpdateInst(ctx, violation.OwnerID, violation.ObjectID, violation.InstID, header, data)
+		if err != nil {
+			return err
+		}
+		if !rsp.Result {
+			return fmt.Errorf("%s", rsp.ErrMsg)
+		}
+	case metadata.QualityRuleOrphanAssociation:
+		rsp, err := c.client.Association().DeleteInst(ctx, header, violation.AsstID)
+		if err != nil {
+			return err
+		}
+		if !rsp.Result {
+			return fmt.Errorf("%s", rsp.ErrMsg)
+		}
+	default:
+		return fmt.Errorf("the violations of the %s rule can not be fixed automatically", rule.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replicator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"icenter/src/apimachinery/coreservice"
+	"icenter/src/apimachinery/flowctrl"
+	"icenter/src/apimachinery/util"
+	"icenter/src/common"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+)
+
+// Source reads the data and the events from the source cmdb
+type Source interface {
+	Find(ctx context.Context, input *metadata.SynchronizeFindInfoParameter) ([]mapstr.MapStr, uint64, error)
+	// ReadEvents returns the events after the offset the consumer has committed
+	ReadEvents(ctx context.Context, consumer string, limit int64) ([]metadata.EventInst, error)
+	CommitOffset(ctx context.Context, consumer string, offset int64) error
+}
+
+// Target writes the data to a target cmdb
+type Target interface {
+	Find(ctx context.Context, input *metadata.SynchronizeFindInfoParameter) ([]mapstr.MapStr, uint64, error)
+	// Synchronize write the data, the items failed to write are returned, the error is returned
+	// only when the request is failed.
+	Synchronize(ctx context.Context, input *metadata.SynchronizeParameter) ([]metadata.ExceptionResult, error)
+	ClearData(ctx context.Context, input *metadata.SynchronizeClearDataParameter) error
+}
+
+// replicationHeader the header of the requests, the data of all the owners are replicated.
+func replicationHeader() http.Header {
+	header := http.Header{}
+	header.Set(common.BKHTTPOwnerID, common.BKSuperOwnerID)
+	header.Set(common.BKHTTPHeaderUser, common.BKSynchronizeDataTaskDefaultUser)
+	header.Set("Content-Type", "application/json")
+	return header
+}
+
+// NewAPISource returns the source reading from the coreservice of the source cmdb
+func NewAPISource(client coreservice.CoreServiceClientInterface) Source {
+	return &apiSource{client: client}
+}
+
+type apiSource struct {
+	client coreservice.CoreServiceClientInterface
+}
+
+func (s *apiSource) Find(ctx context.Context, input *metadata.SynchronizeFindInfoParameter) ([]mapstr.MapStr, uint64, error) {
+	return find(ctx, s.client, input)
+}
+
+func (s *apiSource) ReadEvents(ctx context.Context, consumer string, limit int64) ([]metadata.EventInst, error) {
+	resp, err := s.client.Event().ReadEventStream(ctx, replicationHeader(), metadata.ReadEventStreamParams{Consumer: consumer, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Result {
+		return nil, errors.New(resp.ErrMsg)
+	}
+	return resp.Data.Info, nil
+}
+
+func (s *apiSource) CommitOffset(ctx context.Context, consumer string, offset int64) error {
+	resp, err := s.client.Event().CommitEventOffset(ctx, replicationHeader(), metadata.CommitEventOffsetParams{Consumer: consumer, Offset: offset})
+	if err != nil {
+		return err
+	}
+	if !resp.Result {
+		return errors.New(resp.ErrMsg)
+	}
+	return nil
+}
+
+// NewAPITarget returns the target writing to the coreservice of the target cmdb
+func NewAPITarget(config TargetConfig, apiMachineryConfig *util.APIMachineryConfig) (Target, error) {
+	client, err := util.NewClient(apiMachineryConfig.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	capability := &util.Capability{
+		Client:   client,
+		Discover: staticDiscovery(config.Addrs),
+		Throttle: flowctrl.NewRateLimiter(apiMachineryConfig.QPS, apiMachineryConfig.Burst),
+	}
+	return &apiTarget{client: coreservice.NewCoreServiceClient(capability, "v3")}, nil
+}
+
+// staticDiscovery the addresses of a target, which is in another region without the service discovery.
+type staticDiscovery []string
+
+func (d staticDiscovery) GetServers() ([]string, error) {
+	if len(d) == 0 {
+		return nil, errors.New("no target server address")
+	}
+	return d, nil
+}
+
+type apiTarget struct {
+	client coreservice.CoreServiceClientInterface
+}
+
+func (t *apiTarget) Find(ctx context.Context, input *metadata.SynchronizeFindInfoParameter) ([]mapstr.MapStr, uint64, error) {
+	return find(ctx, t.client, input)
+}
+
+func (t *apiTarget) Synchronize(ctx context.Context, input *metadata.SynchronizeParameter) ([]metadata.ExceptionResult, error) {
+	var resp *metadata.SynchronizeResult
+	var err error
+	switch input.OperateDataType {
+	case metadata.SynchronizeOperateDataTypeInstance:
+		resp, err = t.client.Synchronize().SynchronizeInstance(ctx, replicationHeader(), input)
+	case metadata.SynchronizeOperateDataTypeModel:
+		resp, err = t.client.Synchronize().SynchronizeModel(ctx, replicationHeader(), input)
+	case metadata.SynchronizeOperateDataTypeAssociation:
+		resp, err = t.client.Synchronize().SynchronizeAssociation(ctx, replicationHeader(), input)
+	default:
+		return nil, errors.New("unknown synchronize data type")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Result {
+		if len(resp.Data.Exceptions) != 0 {
+			return resp.Data.Exceptions, nil
+		}
+		return nil, errors.New(resp.ErrMsg)
+	}
+	return nil, nil
+}
+
+func (t *apiTarget) ClearData(ctx context.Context, input *metadata.SynchronizeClearDataParameter) error {
+	resp, err := t.client.Synchronize().SynchronizeClearData(ctx, replicationHeader(), input)
+	if err != nil {
+		return err
+	}
+	if !resp.Result {
+		return errors.New(resp.ErrMsg)
+	}
+	return nil
+}
+
+func find(ctx context.Context, client coreservice.CoreServiceClientInterface, input *metadata.SynchronizeFindInfoParameter) ([]mapstr.MapStr, uint64, error) {
+	resp, err := client.Synchronize().SynchronizeFind(ctx, replicationHeader(), input)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !resp.Result {
+		return nil, 0, errors.New(resp.ErrMsg)
+	}
+	return resp.Data.Info, uint64(resp.Data.Count), nil
+}
@@ -0,0 +1,124 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replicator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the conflict policies, a conflict happens when the data in the target is changed after it's replicated.
+const (
+	// ConflictSourceWins the data in the target is always replaced by the one in the source.
+	ConflictSourceWins = "source_wins"
+	// ConflictLastWriterWins the data with the later last_time is kept.
+	ConflictLastWriterWins = "last_writer_wins"
+)
+
+const (
+	defaultPageSize         = 200
+	defaultFullSyncInterval = 24 * time.Hour
+)
+
+// Config the replication config
+type Config struct {
+	Enable bool
+	// Flag the synchronize flag of the source, the data replicated to the targets are marked with it,
+	// so that the data deleted in the source could be cleared in the targets.
+	Flag     string
+	Targets  []TargetConfig
+	Conflict string
+	// FullSyncInterval the interval of the full synchronization, the changes between them are
+	// replicated with the event stream.
+	FullSyncInterval time.Duration
+	PageSize         uint64
+}
+
+// TargetConfig the coreservice addresses of a target cmdb, eg: http://127.0.0.1:50009
+type TargetConfig struct {
+	Name  string
+	Addrs []string
+}
+
+// ParseConfigFromKV returns a new config, the targets are in the format of
+// "name@addr1,addr2;name2@addr3".
+func ParseConfigFromKV(prefix string, configmap map[string]string) (Config, error) {
+	var err error
+	var cfg Config
+	enable, exist := configmap[prefix+".enable"]
+	if !exist {
+		return Config{}, nil
+	}
+
+	cfg.Enable, err = strconv.ParseBool(enable)
+	if err != nil {
+		return Config{}, errors.New(`invalid replication "enable" value`)
+	}
+	if !cfg.Enable {
+		return Config{}, nil
+	}
+
+	cfg.Flag = strings.TrimSpace(configmap[prefix+".flag"])
+	if len(cfg.Flag) == 0 {
+		return cfg, errors.New(`missing "flag" configuration for replication`)
+	}
+
+	for _, item := range strings.Split(strings.Replace(configmap[prefix+".targets"], " ", "", -1), ";") {
+		if len(item) == 0 {
+			continue
+		}
+		parts := strings.SplitN(item, "@", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return cfg, fmt.Errorf(`invalid replication target "%s"`, item)
+		}
+		target := TargetConfig{Name: parts[0]}
+		for _, addr := range strings.Split(parts[1], ",") {
+			if len(addr) != 0 {
+				target.Addrs = append(target.Addrs, strings.TrimSuffix(addr, "/"))
+			}
+		}
+		cfg.Targets = append(cfg.Targets, target)
+	}
+	if len(cfg.Targets) == 0 {
+		return cfg, errors.New(`missing "targets" configuration for replication`)
+	}
+
+	cfg.Conflict = ConflictSourceWins
+	if conflict := configmap[prefix+".conflict"]; len(conflict) != 0 {
+		cfg.Conflict = conflict
+	}
+	if cfg.Conflict != ConflictSourceWins && cfg.Conflict != ConflictLastWriterWins {
+		return cfg, fmt.Errorf(`invalid replication "conflict" value: %s`, cfg.Conflict)
+	}
+
+	cfg.FullSyncInterval = defaultFullSyncInterval
+	if interval := configmap[prefix+".fullSyncInterval"]; len(interval) != 0 {
+		seconds, err := strconv.ParseInt(interval, 10, 64)
+		if err != nil || seconds <= 0 {
+			return cfg, errors.New(`invalid replication "fullSyncInterval" value`)
+		}
+		cfg.FullSyncInterval = time.Duration(seconds) * time.Second
+	}
+
+	cfg.PageSize = defaultPageSize
+	if pageSize := configmap[prefix+".pageSize"]; len(pageSize) != 0 {
+		cfg.PageSize, err = strconv.ParseUint(pageSize, 10, 64)
+		if err != nil || cfg.PageSize == 0 {
+			return cfg, errors.New(`invalid replication "pageSize" value`)
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,210 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replicator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"icenter/src/apimachinery/coreservice"
+	"icenter/src/apimachinery/util"
+	"icenter/src/common/blog"
+)
+
+const (
+	// syncInterval the interval to replicate the new events
+	syncInterval = 5 * time.Second
+	// retryInterval the delay to retry a failed full synchronization
+	retryInterval = time.Minute
+	// eventBatch the max events count replicated at once
+	eventBatch = 200
+)
+
+// Replicator replicates the models, instances and associations of the source cmdb to the targets,
+// all the data are replicated periodically, and the changes between are replicated with the event
+// stream. the data written by the replication don't generate events, so the cmdbs could replicate
+// to each other.
+type Replicator struct {
+	config   Config
+	targets  []*targetReplicator
+	isMaster func() bool
+}
+
+// New create a replicator, the targets are the ones in the config with the same name.
+func New(config Config, source Source, targets map[string]Target, isMaster func() bool) (*Replicator, error) {
+	r := &Replicator{config: config, isMaster: isMaster}
+	for _, targetConfig := range config.Targets {
+		target, exists := targets[targetConfig.Name]
+		if !exists {
+			return nil, fmt.Errorf("replication target %s not found", targetConfig.Name)
+		}
+		r.targets = append(r.targets, newTargetReplicator(targetConfig.Name, &r.config, source, target))
+	}
+	return r, nil
+}
+
+// NewAPIReplicator create a replicator reading from the coreservice of the source cmdb,
+// and writing to the coreservices of the targets.
+func NewAPIReplicator(config Config, client coreservice.CoreServiceClientInterface, apiMachineryConfig *util.APIMachineryConfig) (*Replicator, error) {
+	targets := make(map[string]Target)
+	for _, targetConfig := range config.Targets {
+		target, err := NewAPITarget(targetConfig, apiMachineryConfig)
+		if err != nil {
+			return nil, fmt.Errorf("new replication target %s failed, err: %v", targetConfig.Name, err)
+		}
+		targets[targetConfig.Name] = target
+	}
+	return New(config, NewAPISource(client), targets, nil)
+}
+
+// SetIsMaster set the function whether the process is the master, only the master replicates the data.
+func (r *Replicator) SetIsMaster(isMaster func() bool) {
+	r.isMaster = isMaster
+}
+
+// Run replicate the data to the targets until the context is done, the targets are replicated concurrently.
+func (r *Replicator) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, target := range r.targets {
+		wg.Add(1)
+		go func(target *targetReplicator) {
+			defer wg.Done()
+			target.run(ctx, r.isMaster)
+		}(target)
+	}
+	wg.Wait()
+}
+
+// Status returns the replication status of the targets
+func (r *Replicator) Status() []TargetStatus {
+	statuses := make([]TargetStatus, 0, len(r.targets))
+	for _, target := range r.targets {
+		statuses = append(statuses, target.getStatus())
+	}
+	return statuses
+}
+
+// FullSync request a full synchronization of the target, it runs in the next round.
+func (r *Replicator) FullSync(name string) error {
+	for _, target := range r.targets {
+		if target.name == name {
+			target.requestFullSync()
+			return nil
+		}
+	}
+	return fmt.Errorf("replication target %s not found", name)
+}
+
+type targetReplicator struct {
+	name   string
+	config *Config
+	source Source
+	target Target
+	now    func() time.Time
+
+	lock   sync.RWMutex
+	status TargetStatus
+	// nextFullSync the time of the next full synchronization, it's zero before the first one.
+	nextFullSync time.Time
+}
+
+func newTargetReplicator(name string, config *Config, source Source, target Target) *targetReplicator {
+	return &targetReplicator{
+		name:   name,
+		config: config,
+		source: source,
+		target: target,
+		now:    time.Now,
+		status: TargetStatus{Name: name, FailedItems: make([]FailedItem, 0)},
+	}
+}
+
+func (t *targetReplicator) run(ctx context.Context, isMaster func() bool) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(syncInterval):
+		}
+		if isMaster != nil && !isMaster() {
+			continue
+		}
+		t.replicate(ctx)
+	}
+}
+
+// replicate run the full synchronization if it's due, and then replicate the new events until caught up.
+func (t *targetReplicator) replicate(ctx context.Context) {
+	if t.fullSyncDue() {
+		if err := t.fullSync(ctx); err != nil {
+			blog.Errorf("[replication] full synchronize to target %s failed, err: %v", t.name, err)
+			t.setError(err)
+			t.setNextFullSync(t.now().Add(retryInterval))
+		} else {
+			t.setNextFullSync(t.now().Add(t.config.FullSyncInterval))
+		}
+	}
+
+	for {
+		count, err := t.incremental(ctx)
+		if err != nil {
+			blog.Errorf("[replication] replicate the events to target %s failed, err: %v", t.name, err)
+			t.setError(err)
+			return
+		}
+		if count < eventBatch {
+			return
+		}
+	}
+}
+
+func (t *targetReplicator) fullSyncDue() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return !t.now().Before(t.nextFullSync)
+}
+
+func (t *targetReplicator) requestFullSync() {
+	t.setNextFullSync(time.Time{})
+}
+
+func (t *targetReplicator) setNextFullSync(next time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.nextFullSync = next
+	t.status.NextFullSyncTime = nil
+	if !next.IsZero() {
+		t.status.NextFullSyncTime = timePtr(next)
+	}
+}
+
+func (t *targetReplicator) getStatus() TargetStatus {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.status.clone()
+}
+
+func (t *targetReplicator) updateStatus(update func(status *TargetStatus)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	update(&t.status)
+}
+
+func (t *targetReplicator) setError(err error) {
+	t.updateStatus(func(status *TargetStatus) {
+		status.LastError = err.Error()
+		status.LastErrorTime = timePtr(t.now())
+	})
+}
@@ -0,0 +1,294 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replicator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/util"
+)
+
+type fakeSource struct {
+	data    map[string][]mapstr.MapStr
+	events  []metadata.EventInst
+	offsets map[string]int64
+}
+
+func (s *fakeSource) Find(_ context.Context, input *metadata.SynchronizeFindInfoParameter) ([]mapstr.MapStr, uint64, error) {
+	items := s.data[input.DataClassify]
+	start := int(input.Start)
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + int(input.Limit)
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], uint64(len(items)), nil
+}
+
+func (s *fakeSource) ReadEvents(_ context.Context, consumer string, limit int64) ([]metadata.EventInst, error) {
+	events := make([]metadata.EventInst, 0)
+	for _, event := range s.events {
+		if event.ID > s.offsets[consumer] && int64(len(events)) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (s *fakeSource) CommitOffset(_ context.Context, consumer string, offset int64) error {
+	s.offsets[consumer] = offset
+	return nil
+}
+
+type fakeItem struct {
+	info    mapstr.MapStr
+	version int64
+}
+
+type fakeTarget struct {
+	data    map[string]map[int64]fakeItem
+	reject  map[int64]bool
+	cleared int
+}
+
+func (t *fakeTarget) Find(_ context.Context, input *metadata.SynchronizeFindInfoParameter) ([]mapstr.MapStr, uint64, error) {
+	items := make([]mapstr.MapStr, 0)
+	for _, item := range t.data[input.DataClassify] {
+		items = append(items, item.info)
+	}
+	return items, uint64(len(items)), nil
+}
+
+func (t *fakeTarget) Synchronize(_ context.Context, input *metadata.SynchronizeParameter) ([]metadata.ExceptionResult, error) {
+	if t.data[input.DataClassify] == nil {
+		t.data[input.DataClassify] = make(map[int64]fakeItem)
+	}
+	exceptions := make([]metadata.ExceptionResult, 0)
+	for idx, item := range input.InfoArray {
+		if t.reject[item.ID] {
+			exceptions = append(exceptions, metadata.ExceptionResult{OriginIndex: int64(idx), Message: "rejected"})
+			continue
+		}
+		if input.OperateType == metadata.SynchronizeOperateTypeDelete {
+			delete(t.data[input.DataClassify], item.ID)
+			continue
+		}
+		t.data[input.DataClassify][item.ID] = fakeItem{info: item.Info, version: input.Version}
+	}
+	return exceptions, nil
+}
+
+func (t *fakeTarget) ClearData(_ context.Context, input *metadata.SynchronizeClearDataParameter) error {
+	t.cleared++
+	for _, items := range t.data {
+		for id, item := range items {
+			if item.version < input.Version {
+				delete(items, id)
+			}
+		}
+	}
+	return nil
+}
+
+func host(id int64, name string, lastTime interface{}) mapstr.MapStr {
+	return mapstr.MapStr{"_id": "mongo", common.BKHostIDField: id, common.BKHostInnerIPField: name, common.LastTimeField: lastTime}
+}
+
+func hostEvent(id int64, action string, data mapstr.MapStr, actionTime time.Time) metadata.EventInst {
+	eventData := metadata.EventData{CurData: data}
+	if action == metadata.EventActionDelete {
+		eventData = metadata.EventData{PreData: data}
+	}
+	return metadata.EventInst{
+		ID:         id,
+		EventType:  metadata.EventTypeInstData,
+		Action:     action,
+		ActionTime: metadata.Time{Time: actionTime},
+		ObjType:    common.BKInnerObjIDHost,
+		Data:       []metadata.EventData{eventData},
+	}
+}
+
+func newTestReplicator(conflict string, source Source, target Target, now *time.Time) *targetReplicator {
+	config := &Config{Flag: "site-a", Conflict: conflict, FullSyncInterval: time.Hour, PageSize: 1}
+	t := newTargetReplicator("site-b", config, source, target)
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func targetHosts(target *fakeTarget) map[int64]string {
+	hosts := make(map[int64]string)
+	for id, item := range target.data[common.BKInnerObjIDHost] {
+		hosts[id] = util.GetStrByInterface(item.info[common.BKHostInnerIPField])
+	}
+	return hosts
+}
+
+func TestReplicate(t *testing.T) {
+	now := time.Unix(1000, 0)
+	source := &fakeSource{
+		data: map[string][]mapstr.MapStr{
+			common.SynchronizeModelTypeModelClassificationRelation: {{common.BKFieldID: 1, common.BKObjIDField: common.BKInnerObjIDHost}},
+			common.BKInnerObjIDHost:                                {host(1, "h1", now), host(2, "h2", now)},
+		},
+		offsets: make(map[string]int64),
+	}
+	target := &fakeTarget{
+		data: map[string]map[int64]fakeItem{
+			common.BKInnerObjIDHost: {9: {info: host(9, "stale", now), version: 1}},
+		},
+		reject: make(map[int64]bool),
+	}
+	r := newTestReplicator(ConflictSourceWins, source, target, &now)
+
+	// full synchronization replicates all the pages, and clears the data deleted in the source
+	r.replicate(context.Background())
+	if hosts := targetHosts(target); fmt.Sprint(hosts) != "map[1:h1 2:h2]" {
+		t.Fatalf("unexpected hosts after full sync: %v", hosts)
+	}
+	if _, exists := target.data[common.BKInnerObjIDHost][1].info["_id"]; exists {
+		t.Errorf("the mongo id should not be replicated")
+	}
+	status := r.getStatus()
+	if status.LastFullSyncVersion != now.Unix() || status.NextFullSyncTime == nil || !status.NextFullSyncTime.Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected full sync status: %+v", status)
+	}
+
+	// the events are replicated incrementally
+	now = now.Add(time.Minute)
+	source.events = []metadata.EventInst{
+		hostEvent(1, metadata.EventActionCreate, host(3, "h3", now), now),
+		hostEvent(2, metadata.EventActionUpdate, host(1, "h1-new", now), now),
+		hostEvent(3, metadata.EventActionDelete, host(2, "h2", now), now),
+	}
+	r.replicate(context.Background())
+	if hosts := targetHosts(target); fmt.Sprint(hosts) != "map[1:h1-new 3:h3]" {
+		t.Fatalf("unexpected hosts after incremental sync: %v", hosts)
+	}
+	status = r.getStatus()
+	if status.Offset != 3 || !status.CaughtUp || status.LagSeconds != 0 {
+		t.Errorf("unexpected incremental status: %+v", status)
+	}
+	if source.offsets["replication_site-a_site-b"] != 3 {
+		t.Errorf("the offset should be committed, got %v", source.offsets)
+	}
+
+	// the deleted data are not cleared when any item is failed
+	source.data[common.BKInnerObjIDHost] = []mapstr.MapStr{host(1, "h1-new", now), host(4, "h4", now)}
+	target.reject[4] = true
+	r.requestFullSync()
+	r.replicate(context.Background())
+	if target.cleared != 1 {
+		t.Errorf("the data should not be cleared after a failure, cleared %d times", target.cleared)
+	}
+	status = r.getStatus()
+	if status.Failed != 1 || len(status.FailedItems) != 1 || status.FailedItems[0].ID != 4 || len(status.LastError) == 0 {
+		t.Errorf("the failed item should be recorded: %+v", status)
+	}
+	if status.NextFullSyncTime == nil || !status.NextFullSyncTime.Equal(now.Add(retryInterval)) {
+		t.Errorf("the failed full sync should be retried, next: %v", status.NextFullSyncTime)
+	}
+}
+
+func TestReplicateLastWriterWins(t *testing.T) {
+	now := time.Unix(1000, 0)
+	later := now.Add(time.Minute)
+	source := &fakeSource{
+		data: map[string][]mapstr.MapStr{
+			common.SynchronizeModelTypeModelClassificationRelation: {{common.BKFieldID: 1, common.BKObjIDField: common.BKInnerObjIDHost}},
+			common.BKInnerObjIDHost:                                {host(1, "source", now), host(2, "source", later)},
+		},
+		offsets: make(map[string]int64),
+	}
+	target := &fakeTarget{
+		data: map[string]map[int64]fakeItem{
+			common.BKInnerObjIDHost: {
+				1: {info: host(1, "target", later), version: 1},
+				2: {info: host(2, "target", now), version: 1},
+			},
+		},
+		reject: make(map[int64]bool),
+	}
+	r := newTestReplicator(ConflictLastWriterWins, source, target, &later)
+
+	// the newer target item is kept and survives the clear, the older one is overwritten
+	r.replicate(context.Background())
+	if hosts := targetHosts(target); fmt.Sprint(hosts) != "map[1:target 2:source]" {
+		t.Fatalf("unexpected hosts after full sync: %v", hosts)
+	}
+	if status := r.getStatus(); status.Conflicts != 1 {
+		t.Errorf("expect 1 conflict after full sync, got %d", status.Conflicts)
+	}
+
+	// the target item changed after the deletion is not deleted
+	target.data[common.BKInnerObjIDHost][3] = fakeItem{info: host(3, "target", later.Format(time.RFC3339Nano)), version: 1}
+	source.events = []metadata.EventInst{
+		hostEvent(1, metadata.EventActionDelete, host(3, "source", now), now),
+		hostEvent(2, metadata.EventActionDelete, host(2, "source", later), later),
+	}
+	r.replicate(context.Background())
+	if hosts := targetHosts(target); fmt.Sprint(hosts) != "map[1:target 3:target]" {
+		t.Fatalf("unexpected hosts after incremental sync: %v", hosts)
+	}
+	if status := r.getStatus(); status.Conflicts != 2 {
+		t.Errorf("expect 2 conflicts after incremental sync, got %d", status.Conflicts)
+	}
+}
+
+func TestReplicateAssociations(t *testing.T) {
+	now := time.Unix(1000, 0)
+	source := &fakeSource{
+		data: map[string][]mapstr.MapStr{
+			common.SynchronizeModelTypeAssociationKind:  {{common.BKFieldID: 1, common.AssociationKindIDField: "belong"}},
+			common.SynchronizeModelTypeModelAssociation: {{common.BKFieldID: 2, common.AssociationObjAsstIDField: "host_belong_set"}},
+			common.SynchronizeModelTypeModelUnique:      {{common.BKFieldID: 3, common.BKObjIDField: common.BKInnerObjIDHost}},
+		},
+		offsets: make(map[string]int64),
+	}
+	target := &fakeTarget{data: make(map[string]map[int64]fakeItem), reject: make(map[int64]bool)}
+	r := newTestReplicator(ConflictSourceWins, source, target, &now)
+
+	// the model associations, association kinds and uniques are replicated by the full synchronization
+	r.replicate(context.Background())
+	for _, classify := range []string{common.SynchronizeModelTypeAssociationKind,
+		common.SynchronizeModelTypeModelAssociation, common.SynchronizeModelTypeModelUnique} {
+		if len(target.data[classify]) != 1 {
+			t.Errorf("the %s should be replicated, got %v", classify, target.data[classify])
+		}
+	}
+
+	// the instance association events are replicated incrementally
+	asst := mapstr.MapStr{common.BKFieldID: 5, common.BKInstIDField: 1, common.BKAsstInstIDField: 2}
+	source.events = []metadata.EventInst{
+		{ID: 1, EventType: metadata.EventTypeAssociation, Action: metadata.EventActionCreate,
+			ActionTime: metadata.Time{Time: now}, Data: []metadata.EventData{{CurData: asst}}},
+	}
+	r.replicate(context.Background())
+	if _, exists := target.data[common.SynchronizeAssociationTypeInstAsst][5]; !exists {
+		t.Fatalf("the created instance association should be replicated, got %v", target.data)
+	}
+	source.events = append(source.events, metadata.EventInst{ID: 2, EventType: metadata.EventTypeAssociation,
+		Action: metadata.EventActionDelete, ActionTime: metadata.Time{Time: now}, Data: []metadata.EventData{{PreData: asst}}})
+	r.replicate(context.Background())
+	if len(target.data[common.SynchronizeAssociationTypeInstAsst]) != 0 {
+		t.Errorf("the deleted instance association should be removed, got %v", target.data)
+	}
+}
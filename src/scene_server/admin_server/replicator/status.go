@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replicator
+
+import (
+	"time"
+
+	"icenter/src/common/metadata"
+)
+
+// maxFailedItems the max count of the failed items kept in the status
+const maxFailedItems = 100
+
+// TargetStatus the replication status of a target
+type TargetStatus struct {
+	Name string `json:"name"`
+	// Offset the id of the last source event replicated to the target
+	Offset int64 `json:"offset"`
+	// CaughtUp all the events in the source are replicated
+	CaughtUp bool `json:"caught_up"`
+	// LagSeconds how long the last replicated event is behind now, it's 0 if the target is caught up.
+	LagSeconds          float64    `json:"lag_seconds"`
+	LastSyncTime        *time.Time `json:"last_sync_time"`
+	FullSyncRunning     bool       `json:"full_sync_running"`
+	LastFullSyncTime    *time.Time `json:"last_full_sync_time"`
+	LastFullSyncVersion int64      `json:"last_full_sync_version"`
+	NextFullSyncTime    *time.Time `json:"next_full_sync_time"`
+	// Replicated the count of the items written to the target
+	Replicated uint64 `json:"replicated"`
+	// Conflicts the count of the items whose change in the target is kept with the last writer wins policy
+	Conflicts uint64 `json:"conflicts"`
+	// Failed the count of the items failed to write to the target
+	Failed        uint64       `json:"failed"`
+	FailedItems   []FailedItem `json:"failed_items"`
+	LastError     string       `json:"last_error"`
+	LastErrorTime *time.Time   `json:"last_error_time"`
+}
+
+// FailedItem an item failed to write to the target, the newest ones are kept.
+type FailedItem struct {
+	DataType     metadata.SynchronizeOperateDataType `json:"data_type"`
+	DataClassify string                              `json:"data_classify"`
+	ID           int64                               `json:"id"`
+	Message      string                              `json:"message"`
+	Time         time.Time                           `json:"time"`
+}
+
+func (s *TargetStatus) addFailedItem(item FailedItem) {
+	s.Failed++
+	s.FailedItems = append(s.FailedItems, item)
+	if len(s.FailedItems) > maxFailedItems {
+		s.FailedItems = s.FailedItems[len(s.FailedItems)-maxFailedItems:]
+	}
+}
+
+func (s *TargetStatus) clone() TargetStatus {
+	status := *s
+	status.FailedItems = append([]FailedItem{}, s.FailedItems...)
+	return status
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
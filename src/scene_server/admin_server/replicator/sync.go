@@ -0,0 +1,400 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replicator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/util"
+)
+
+// syncKind a kind of data synchronized with the datasync api, the items are identified by the id field.
+type syncKind struct {
+	dataType metadata.SynchronizeOperateDataType
+	classify string
+	// idField the field of the item id, the module host relations have no id.
+	idField string
+}
+
+// modelKinds the model data, they're synchronized before the instances.
+var modelKinds = []syncKind{
+	{metadata.SynchronizeOperateDataTypeModel, common.SynchronizeModelTypeClassification, common.BKFieldID},
+	{metadata.SynchronizeOperateDataTypeModel, common.SynchronizeModelTypeModelClassificationRelation, common.BKFieldID},
+	{metadata.SynchronizeOperateDataTypeModel, common.SynchronizeModelTypeAttributeGroup, common.BKFieldID},
+	{metadata.SynchronizeOperateDataTypeModel, common.SynchronizeModelTypeAttribute, common.BKFieldID},
+	{metadata.SynchronizeOperateDataTypeModel, common.SynchronizeModelTypeModelUnique, common.BKFieldID},
+	{metadata.SynchronizeOperateDataTypeModel, common.SynchronizeModelTypeAssociationKind, common.BKFieldID},
+	{metadata.SynchronizeOperateDataTypeModel, common.SynchronizeModelTypeModelAssociation, common.BKFieldID},
+}
+
+// associationKinds the association data, they're synchronized after the instances.
+var associationKinds = []syncKind{
+	moduleHostKind,
+	instAsstKind,
+}
+
+var moduleHostKind = syncKind{metadata.SynchronizeOperateDataTypeAssociation, common.SynchronizeAssociationTypeModelHost, ""}
+
+var instAsstKind = syncKind{metadata.SynchronizeOperateDataTypeAssociation, common.SynchronizeAssociationTypeInstAsst, common.BKFieldID}
+
+func instanceKind(objID string) syncKind {
+	return syncKind{metadata.SynchronizeOperateDataTypeInstance, objID, common.GetInstIDField(objID)}
+}
+
+// fullSync replicate all the data of the source with a new version, and then clear the data replicated
+// by the previous versions, which are deleted in the source. the data are not cleared if any item is
+// failed to write, otherwise the item would be deleted in the target.
+func (t *targetReplicator) fullSync(ctx context.Context) error {
+	start := t.now()
+	version := start.Unix()
+	failed := t.getStatus().Failed
+	t.updateStatus(func(status *TargetStatus) { status.FullSyncRunning = true })
+	defer t.updateStatus(func(status *TargetStatus) { status.FullSyncRunning = false })
+	blog.Infof("[replication] full synchronize to target %s with version %d start", t.name, version)
+
+	for _, kind := range modelKinds {
+		if err := t.syncKind(ctx, kind, version); err != nil {
+			return err
+		}
+	}
+
+	objIDs, err := t.objectIDs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, objID := range objIDs {
+		if err := t.syncKind(ctx, instanceKind(objID), version); err != nil {
+			return err
+		}
+	}
+
+	for _, kind := range associationKinds {
+		if err := t.syncKind(ctx, kind, version); err != nil {
+			return err
+		}
+	}
+
+	if t.getStatus().Failed != failed {
+		return fmt.Errorf("some items are failed to synchronize, the deleted data are not cleared")
+	}
+	input := &metadata.SynchronizeClearDataParameter{
+		Tamestamp:       t.now().Unix(),
+		Version:         version,
+		SynchronizeFlag: t.config.Flag,
+	}
+	input.GenerateSign(common.SynchronizeSignPrefix)
+	if err := t.target.ClearData(ctx, input); err != nil {
+		return fmt.Errorf("clear the deleted data failed, err: %v", err)
+	}
+
+	t.updateStatus(func(status *TargetStatus) {
+		status.LastFullSyncTime = timePtr(start)
+		status.LastFullSyncVersion = version
+	})
+	blog.Infof("[replication] full synchronize to target %s with version %d done, cost: %v", t.name, version, t.now().Sub(start))
+	return nil
+}
+
+// syncKind replicate all the items of the kind page by page
+func (t *targetReplicator) syncKind(ctx context.Context, kind syncKind, version int64) error {
+	for start := uint64(0); ; start += t.config.PageSize {
+		input := &metadata.SynchronizeFindInfoParameter{
+			DataType:     kind.dataType,
+			DataClassify: kind.classify,
+			Condition:    mapstr.MapStr{},
+			Start:        start,
+			Limit:        t.config.PageSize,
+		}
+		items, count, err := t.source.Find(ctx, input)
+		if err != nil {
+			return fmt.Errorf("find %s from source failed, err: %v", kind.classify, err)
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		if err := t.push(ctx, kind, metadata.SynchronizeOperateTypeRepalce, items, version, time.Time{}); err != nil {
+			return fmt.Errorf("synchronize %s to target failed, err: %v", kind.classify, err)
+		}
+		if start+uint64(len(items)) >= count {
+			return nil
+		}
+	}
+}
+
+// objectIDs returns the ids of all the models in the source
+func (t *targetReplicator) objectIDs(ctx context.Context) ([]string, error) {
+	objIDs := make([]string, 0)
+	for start := uint64(0); ; start += t.config.PageSize {
+		input := &metadata.SynchronizeFindInfoParameter{
+			DataType:     metadata.SynchronizeOperateDataTypeModel,
+			DataClassify: common.SynchronizeModelTypeModelClassificationRelation,
+			Condition:    mapstr.MapStr{},
+			Start:        start,
+			Limit:        t.config.PageSize,
+		}
+		items, count, err := t.source.Find(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("find models from source failed, err: %v", err)
+		}
+		for _, item := range items {
+			if objID, err := item.String(common.BKObjIDField); err == nil && len(objID) != 0 {
+				objIDs = append(objIDs, objID)
+			}
+		}
+		if len(items) == 0 || start+uint64(len(items)) >= count {
+			return objIDs, nil
+		}
+	}
+}
+
+// incremental replicate the events after the committed offset, returns the count of the events.
+func (t *targetReplicator) incremental(ctx context.Context) (int, error) {
+	consumer := fmt.Sprintf("replication_%s_%s", t.config.Flag, t.name)
+	events, err := t.source.ReadEvents(ctx, consumer, eventBatch)
+	if err != nil {
+		return 0, fmt.Errorf("read events from source failed, err: %v", err)
+	}
+
+	var applied *metadata.EventInst
+	var applyErr error
+	for index := range events {
+		if applyErr = t.apply(ctx, &events[index]); applyErr != nil {
+			break
+		}
+		applied = &events[index]
+	}
+	if applied != nil {
+		if err := t.source.CommitOffset(ctx, consumer, applied.ID); err != nil {
+			return 0, fmt.Errorf("commit offset %d failed, err: %v", applied.ID, err)
+		}
+	}
+
+	now := t.now()
+	t.updateStatus(func(status *TargetStatus) {
+		if applied != nil {
+			status.Offset = applied.ID
+			status.LagSeconds = now.Sub(applied.ActionTime.Time).Seconds()
+		}
+		status.CaughtUp = applyErr == nil && len(events) < eventBatch
+		if status.CaughtUp {
+			status.LagSeconds = 0
+		}
+		if applyErr == nil {
+			status.LastSyncTime = timePtr(now)
+		}
+	})
+	if applyErr != nil {
+		return 0, applyErr
+	}
+	return len(events), nil
+}
+
+// apply replicate the change of the event, only the instance, the instance association and the module host
+// relation changes are replicated, the others are replicated by the full synchronization.
+func (t *targetReplicator) apply(ctx context.Context, event *metadata.EventInst) error {
+	var kind syncKind
+	switch event.EventType {
+	case metadata.EventTypeInstData:
+		kind = instanceKind(event.ObjType)
+	case metadata.EventTypeRelation:
+		kind = moduleHostKind
+	case metadata.EventTypeAssociation:
+		kind = instAsstKind
+	default:
+		return nil
+	}
+
+	version := t.now().Unix()
+	for _, data := range event.Data {
+		opType := metadata.SynchronizeOperateTypeRepalce
+		value := data.CurData
+		if event.Action == metadata.EventActionDelete {
+			opType = metadata.SynchronizeOperateTypeDelete
+			value = data.PreData
+		}
+		item, err := mapstr.NewFromInterface(value)
+		if err != nil || len(item) == 0 {
+			blog.Warnf("[replication] skip the event %d without data, err: %v", event.ID, err)
+			continue
+		}
+		if err := t.push(ctx, kind, opType, []mapstr.MapStr{item}, version, event.ActionTime.Time); err != nil {
+			return fmt.Errorf("replicate event %d failed, err: %v", event.ID, err)
+		}
+	}
+	return nil
+}
+
+// push write the items to the target, the items failed to write are recorded in the status,
+// the error is returned only when the request is failed. the changed time is the time of the
+// deletion, which is compared with the target item's last time with the last writer wins policy.
+func (t *targetReplicator) push(ctx context.Context, kind syncKind, opType metadata.SynchronizeOperateType,
+	items []mapstr.MapStr, version int64, changed time.Time) error {
+
+	syncItems := make([]*metadata.SynchronizeItem, 0, len(items))
+	for index, item := range items {
+		info := cleanItem(item)
+		id := int64(index)
+		if len(kind.idField) != 0 {
+			var err error
+			if id, err = util.GetInt64ByInterface(info[kind.idField]); err != nil {
+				t.recordFailure(kind, 0, fmt.Sprintf("invalid %s: %v", kind.idField, info[kind.idField]))
+				continue
+			}
+		}
+		syncItems = append(syncItems, &metadata.SynchronizeItem{ID: id, Info: info})
+	}
+
+	if t.config.Conflict == ConflictLastWriterWins && len(kind.idField) != 0 {
+		var err error
+		if syncItems, err = t.resolveConflicts(ctx, kind, opType, syncItems, changed); err != nil {
+			return err
+		}
+	}
+	if len(syncItems) == 0 {
+		return nil
+	}
+
+	input := &metadata.SynchronizeParameter{
+		OperateType:     opType,
+		OperateDataType: kind.dataType,
+		DataClassify:    kind.classify,
+		InfoArray:       syncItems,
+		Version:         version,
+		SynchronizeFlag: t.config.Flag,
+	}
+	exceptions, err := t.target.Synchronize(ctx, input)
+	if err != nil {
+		return err
+	}
+	for _, exception := range exceptions {
+		// the origin index is the index of the failed item in the info array
+		id := exception.OriginIndex
+		if id >= 0 && id < int64(len(syncItems)) {
+			id = syncItems[id].ID
+		}
+		t.recordFailure(kind, id, exception.Message)
+	}
+	t.updateStatus(func(status *TargetStatus) {
+		status.Replicated += uint64(len(syncItems) - len(exceptions))
+	})
+	return nil
+}
+
+// resolveConflicts keep the target items which are changed after the source ones. the kept item is
+// written back to the target, so that it's marked with the new version and not cleared as deleted.
+func (t *targetReplicator) resolveConflicts(ctx context.Context, kind syncKind, opType metadata.SynchronizeOperateType,
+	items []*metadata.SynchronizeItem, changed time.Time) ([]*metadata.SynchronizeItem, error) {
+
+	if len(items) == 0 {
+		return items, nil
+	}
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	input := &metadata.SynchronizeFindInfoParameter{
+		DataType:     kind.dataType,
+		DataClassify: kind.classify,
+		Condition:    mapstr.MapStr{kind.idField: mapstr.MapStr{common.BKDBIN: ids}},
+		Limit:        uint64(len(ids)),
+	}
+	existing, _, err := t.target.Find(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("find %s from target failed, err: %v", kind.classify, err)
+	}
+	targetItems := make(map[int64]mapstr.MapStr, len(existing))
+	for _, item := range existing {
+		if id, err := util.GetInt64ByInterface(item[kind.idField]); err == nil {
+			targetItems[id] = item
+		}
+	}
+
+	resolved := make([]*metadata.SynchronizeItem, 0, len(items))
+	conflicts := uint64(0)
+	for _, item := range items {
+		targetItem, exists := targetItems[item.ID]
+		if !exists {
+			resolved = append(resolved, item)
+			continue
+		}
+		targetTime, ok := lastTime(targetItem)
+		sourceTime := changed
+		if opType != metadata.SynchronizeOperateTypeDelete {
+			sourceTime, _ = lastTime(item.Info)
+		}
+		if !ok || !targetTime.After(sourceTime) {
+			resolved = append(resolved, item)
+			continue
+		}
+
+		conflicts++
+		if opType == metadata.SynchronizeOperateTypeDelete {
+			continue
+		}
+		resolved = append(resolved, &metadata.SynchronizeItem{ID: item.ID, Info: cleanItem(targetItem)})
+	}
+	if conflicts > 0 {
+		t.updateStatus(func(status *TargetStatus) { status.Conflicts += conflicts })
+	}
+	return resolved, nil
+}
+
+func (t *targetReplicator) recordFailure(kind syncKind, id int64, message string) {
+	blog.Errorf("[replication] synchronize %s %d to target %s failed, err: %s", kind.classify, id, t.name, message)
+	t.updateStatus(func(status *TargetStatus) {
+		status.addFailedItem(FailedItem{
+			DataType:     kind.dataType,
+			DataClassify: kind.classify,
+			ID:           id,
+			Message:      message,
+			Time:         t.now(),
+		})
+	})
+}
+
+// cleanItem returns a copy of the item without the mongo object id, which differs between the cmdbs.
+func cleanItem(item mapstr.MapStr) mapstr.MapStr {
+	info := make(mapstr.MapStr, len(item))
+	for key, value := range item {
+		if key == "_id" {
+			continue
+		}
+		info[key] = value
+	}
+	return info
+}
+
+// lastTime returns the last changed time of the item
+func lastTime(item mapstr.MapStr) (time.Time, bool) {
+	switch value := item[common.LastTimeField].(type) {
+	case time.Time:
+		return value, true
+	case *time.Time:
+		if value != nil {
+			return *value, true
+		}
+	case metadata.Time:
+		return value.Time, true
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
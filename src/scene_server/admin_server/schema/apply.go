@@ -0,0 +1,269 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/auditoplog"
+	"icenter/src/common/blog"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+)
+
+// tableNames the tables of the kinds
+var tableNames = map[string]string{
+	KindAssociationKind: common.BKTableNameAsstDes,
+	KindClassification:  common.BKTableNameObjClassifiction,
+	KindModel:           common.BKTableNameObjDes,
+	KindGroup:           common.BKTableNamePropertyGroup,
+	KindAttribute:       common.BKTableNameObjAttDes,
+	KindUnique:          common.BKTableNameObjUnique,
+	KindAssociation:     common.BKTableNameObjAsst,
+}
+
+// Apply execute the changes of the plan in order, an audit log is saved for every change with the user.
+// the changes which have been executed are kept if one fails, the plan built again only contains the
+// remaining changes.
+func Apply(ctx context.Context, db dal.RDB, ownerID, user string, plan *Plan) error {
+	for _, change := range plan.Changes {
+		var err error
+		id := change.id
+		switch change.Action {
+		case ActionCreate:
+			id, err = create(ctx, db, ownerID, change)
+		case ActionUpdate:
+			err = update(ctx, db, ownerID, change)
+		case ActionDelete:
+			err = db.Table(tableNames[change.Kind]).Delete(ctx, idCondition(ownerID, change.id))
+		default:
+			err = fmt.Errorf("unknown action %s", change.Action)
+		}
+		if err == nil {
+			err = audit(ctx, db, ownerID, user, change, id)
+		}
+		if err != nil {
+			blog.Errorf("[schema] %s %s %s failed, err: %v", change.Action, change.Kind, change.Key, err)
+			return fmt.Errorf("%s %s %s failed, err: %v", change.Action, change.Kind, change.Key, err)
+		}
+		blog.Infof("[schema] %s %s %s success", change.Action, change.Kind, change.Key)
+	}
+	return nil
+}
+
+// audit save the operation log of the change, the target is the kind of the item,
+// the content is the item before and after the change.
+func audit(ctx context.Context, db dal.RDB, ownerID, user string, change Change, id int64) error {
+	logRow := &metadata.OperationLog{
+		OwnerID:    ownerID,
+		OpTarget:   change.Kind,
+		User:       user,
+		ExtKey:     change.Key,
+		OpDesc:     fmt.Sprintf("%s %s", change.Action, change.Kind),
+		CreateTime: time.Now(),
+		InstID:     id,
+	}
+	// the content is saved like the one posted to the coreservice as a json document.
+	content := map[string]interface{}{"pre_data": nil, "cur_data": nil}
+	switch change.Action {
+	case ActionCreate:
+		logRow.OpType = int(auditoplog.AuditOpTypeAdd)
+		content["cur_data"] = fields(change.item)
+	case ActionUpdate:
+		logRow.OpType = int(auditoplog.AuditOpTypeModify)
+		content["pre_data"], content["cur_data"] = fields(change.old), fields(change.item)
+	case ActionDelete:
+		logRow.OpType = int(auditoplog.AuditOpTypeDel)
+		content["pre_data"] = fields(change.old)
+	}
+	logRow.Content = content
+
+	seq, err := db.NextSequence(ctx, common.BKTableNameOperationLog)
+	if err != nil {
+		return err
+	}
+	logRow.ID = int64(seq)
+	return db.Table(logRow.TableName()).Insert(ctx, logRow)
+}
+
+// create insert the item of the change, and returns the id of it.
+func create(ctx context.Context, db dal.RDB, ownerID string, change Change) (int64, error) {
+	tableName := tableNames[change.Kind]
+	seq, err := db.NextSequence(ctx, tableName)
+	if err != nil {
+		return 0, err
+	}
+	id := int64(seq)
+	now := time.Now()
+	isPre := false
+
+	var doc interface{}
+	switch item := change.item.(type) {
+	case AssociationKind:
+		doc = metadata.AssociationKind{
+			ID:                      id,
+			AssociationKindID:       item.ID,
+			AssociationKindName:     item.Name,
+			OwnerID:                 ownerID,
+			SourceToDestinationNote: item.SrcDes,
+			DestinationToSourceNote: item.DestDes,
+			Direction:               metadata.AssociationDirection(item.Direction),
+			IsPre:                   &isPre,
+		}
+	case Classification:
+		doc = metadata.Classification{
+			ID:                 id,
+			ClassificationID:   item.ID,
+			ClassificationName: item.Name,
+			ClassificationType: item.Type,
+			ClassificationIcon: item.Icon,
+			OwnerID:            ownerID,
+		}
+	case Model:
+		doc = metadata.Object{
+			ID:          id,
+			ObjCls:      item.Classification,
+			ObjIcon:     item.Icon,
+			ObjectID:    item.ID,
+			ObjectName:  item.Name,
+			IsPaused:    item.IsPaused,
+			OwnerID:     ownerID,
+			Description: item.Description,
+			Creator:     common.CCSystemOperatorUserName,
+			CreateTime:  &metadata.Time{Time: now},
+			LastTime:    &metadata.Time{Time: now},
+		}
+	case Group:
+		doc = metadata.Group{
+			ID:         id,
+			GroupID:    item.ID,
+			GroupName:  item.Name,
+			GroupIndex: item.Index,
+			ObjectID:   change.objID,
+			OwnerID:    ownerID,
+			IsDefault:  item.IsDefault,
+		}
+	case Attribute:
+		doc = metadata.Attribute{
+			ID:            id,
+			OwnerID:       ownerID,
+			ObjectID:      change.objID,
+			PropertyID:    item.ID,
+			PropertyName:  item.Name,
+			PropertyGroup: item.Group,
+			PropertyIndex: item.Index,
+			Unit:          item.Unit,
+			Placeholder:   item.Placeholder,
+			IsEditable:    item.IsEditable,
+			IsRequired:    item.IsRequired,
+			IsReadOnly:    item.IsReadOnly,
+			IsOnly:        item.IsOnly,
+			IsSystem:      item.IsSystem,
+			IsAPI:         item.IsAPI,
+			PropertyType:  item.Type,
+			Option:        item.Option,
+			Description:   item.Description,
+			Creator:       common.CCSystemOperatorUserName,
+			CreateTime:    &metadata.Time{Time: now},
+			LastTime:      &metadata.Time{Time: now},
+		}
+	case Unique:
+		keys, err := uniqueKeys(ctx, db, ownerID, change.objID, item.Keys)
+		if err != nil {
+			return 0, err
+		}
+		doc = metadata.ObjectUnique{
+			ID:        uint64(id),
+			ObjID:     change.objID,
+			MustCheck: item.MustCheck,
+			Keys:      keys,
+			OwnerID:   ownerID,
+			LastTime:  metadata.Now(),
+		}
+	case Association:
+		doc = metadata.Association{
+			ID:                   id,
+			OwnerID:              ownerID,
+			AssociationName:      item.ID,
+			AssociationAliasName: item.Name,
+			ObjectID:             item.ObjectID,
+			AsstObjID:            item.AsstObjID,
+			AsstKindID:           item.AsstKindID,
+			Mapping:              metadata.AssociationMapping(item.Mapping),
+			OnDelete:             metadata.AssociationOnDeleteAction(item.OnDelete),
+			IsPre:                &isPre,
+		}
+	default:
+		return 0, fmt.Errorf("unknown item %T", change.item)
+	}
+	return id, db.Table(tableName).Insert(ctx, doc)
+}
+
+func update(ctx context.Context, db dal.RDB, ownerID string, change Change) error {
+	data := make(map[string]interface{}, len(change.Diff)+1)
+	for field, value := range change.Diff {
+		// the numbers in the json document are float64, the indexes are saved as integers.
+		if number, ok := value.New.(float64); ok && number == math.Trunc(number) {
+			data[field] = int64(number)
+			continue
+		}
+		data[field] = value.New
+	}
+	if attr, ok := change.item.(Attribute); ok {
+		// keep the option as what it is in the schema, the diff is a json document.
+		if _, changed := data["option"]; changed {
+			data["option"] = attr.Option
+		}
+	}
+	switch change.Kind {
+	case KindModel, KindAttribute, KindUnique:
+		data[common.LastTimeField] = time.Now()
+	}
+	return db.Table(tableNames[change.Kind]).Update(ctx, idCondition(ownerID, change.id), data)
+}
+
+func idCondition(ownerID string, id int64) map[string]interface{} {
+	return map[string]interface{}{
+		common.BKFieldID:      id,
+		common.BKOwnerIDField: ownerID,
+	}
+}
+
+// uniqueKeys returns the unique keys of the attributes, the attributes are created before the uniques.
+func uniqueKeys(ctx context.Context, db dal.RDB, ownerID, objID string, propertyIDs []string) ([]metadata.UniqueKey, error) {
+	cond := ownerCondition(ownerID)
+	cond[common.BKObjIDField] = objID
+	cond[common.BKPropertyIDField] = map[string]interface{}{common.BKDBIN: propertyIDs}
+	attrs := make([]metadata.Attribute, 0)
+	if err := db.Table(common.BKTableNameObjAttDes).Find(cond).All(ctx, &attrs); err != nil {
+		return nil, err
+	}
+	ids := make(map[string]int64, len(attrs))
+	for _, attr := range attrs {
+		ids[attr.PropertyID] = attr.ID
+	}
+
+	keys := make([]metadata.UniqueKey, 0, len(propertyIDs))
+	for _, propertyID := range propertyIDs {
+		id, exist := ids[propertyID]
+		if !exist {
+			return nil, fmt.Errorf("the attribute %s of %s does not exist", propertyID, objID)
+		}
+		keys = append(keys, metadata.UniqueKey{Kind: metadata.UniqueKeyKindProperty, ID: uint64(id)})
+	}
+	return keys, nil
+}
@@ -0,0 +1,358 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	mgobson "gopkg.in/mgo.v2/bson"
+	"gopkg.in/yaml.v2"
+
+	"icenter/src/common"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+)
+
+// state the current model layer in db
+type state struct {
+	schema *Schema
+	// ids the db row ids of the items, the key is the kind and the key of the item.
+	ids map[string]int64
+	// preset the preset items, the key is the same as the ids.
+	preset map[string]bool
+	// mainline the models in the mainline topology, the key is the model id.
+	mainline map[string]bool
+}
+
+// Export returns the current model layer of the supplier account
+func Export(ctx context.Context, db dal.RDB, ownerID string) (*Schema, error) {
+	st, err := load(ctx, db, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return st.schema, nil
+}
+
+// Marshal encode the schema in the format
+func Marshal(s *Schema, format string) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		return yaml.Marshal(s)
+	case FormatJSON:
+		return json.MarshalIndent(s, "", "    ")
+	default:
+		return nil, fmt.Errorf("unsupported format %s", format)
+	}
+}
+
+// Unmarshal decode the schema in the format
+func Unmarshal(data []byte, format string) (*Schema, error) {
+	s := new(Schema)
+	var err error
+	switch format {
+	case FormatYAML:
+		err = yaml.Unmarshal(data, s)
+	case FormatJSON:
+		err = json.Unmarshal(data, s)
+	default:
+		return nil, fmt.Errorf("unsupported format %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Version) != 0 && s.Version != Version {
+		return nil, fmt.Errorf("unsupported schema version %s", s.Version)
+	}
+	for i := range s.Models {
+		for j := range s.Models[i].Attributes {
+			s.Models[i].Attributes[j].Option = normalize(s.Models[i].Attributes[j].Option)
+		}
+	}
+	return s, nil
+}
+
+// FormatOfFile returns the format by the extension of the file, it's yaml by default.
+func FormatOfFile(path string) string {
+	if strings.HasSuffix(strings.ToLower(path), ".json") {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+func ownerCondition(ownerID string) map[string]interface{} {
+	cond := map[string]interface{}{common.BKOwnerIDField: ownerID}
+	// the models of the businesses are not part of the global model layer.
+	for key, value := range metadata.BizLabelNotExist {
+		cond[key] = value
+	}
+	return cond
+}
+
+func itemKey(kind, key string) string {
+	return kind + "/" + key
+}
+
+func childKey(objID, key string) string {
+	return objID + "." + key
+}
+
+func uniqueKey(keys []string) string {
+	return strings.Join(sortedKeys(keys), "+")
+}
+
+func sortedKeys(keys []string) []string {
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func load(ctx context.Context, db dal.RDB, ownerID string) (*state, error) {
+	st := &state{
+		schema:   &Schema{Version: Version},
+		ids:      make(map[string]int64),
+		preset:   make(map[string]bool),
+		mainline: make(map[string]bool),
+	}
+	cond := ownerCondition(ownerID)
+
+	kinds := make([]metadata.AssociationKind, 0)
+	if err := db.Table(common.BKTableNameAsstDes).Find(cond).All(ctx, &kinds); err != nil {
+		return nil, fmt.Errorf("find association kinds failed, err: %v", err)
+	}
+	for _, kind := range kinds {
+		if kind.AssociationKindID == common.AssociationKindMainline {
+			continue
+		}
+		st.add(KindAssociationKind, kind.AssociationKindID, kind.ID, kind.IsPre != nil && *kind.IsPre)
+		st.schema.AssociationKinds = append(st.schema.AssociationKinds, AssociationKind{
+			ID:        kind.AssociationKindID,
+			Name:      kind.AssociationKindName,
+			SrcDes:    kind.SourceToDestinationNote,
+			DestDes:   kind.DestinationToSourceNote,
+			Direction: string(kind.Direction),
+		})
+	}
+
+	classifications := make([]metadata.Classification, 0)
+	if err := db.Table(common.BKTableNameObjClassifiction).Find(cond).All(ctx, &classifications); err != nil {
+		return nil, fmt.Errorf("find classifications failed, err: %v", err)
+	}
+	for _, cls := range classifications {
+		// the classifications have no preset flag, the built in ones are protected by their type.
+		st.add(KindClassification, cls.ClassificationID, cls.ID, cls.ClassificationType == "inner")
+		st.schema.Classifications = append(st.schema.Classifications, Classification{
+			ID:   cls.ClassificationID,
+			Name: cls.ClassificationName,
+			Type: cls.ClassificationType,
+			Icon: cls.ClassificationIcon,
+		})
+	}
+
+	objects := make([]metadata.Object, 0)
+	if err := db.Table(common.BKTableNameObjDes).Find(cond).All(ctx, &objects); err != nil {
+		return nil, fmt.Errorf("find models failed, err: %v", err)
+	}
+	models := make(map[string]*Model)
+	for _, object := range objects {
+		st.add(KindModel, object.ObjectID, object.ID, object.IsPre)
+		st.schema.Models = append(st.schema.Models, Model{
+			ID:             object.ObjectID,
+			Name:           object.ObjectName,
+			Classification: object.ObjCls,
+			Icon:           object.ObjIcon,
+			IsPaused:       object.IsPaused,
+			Description:    object.Description,
+			Groups:         make([]Group, 0),
+			Attributes:     make([]Attribute, 0),
+			Uniques:        make([]Unique, 0),
+		})
+	}
+	for i := range st.schema.Models {
+		models[st.schema.Models[i].ID] = &st.schema.Models[i]
+	}
+
+	groups := make([]metadata.Group, 0)
+	if err := db.Table(common.BKTableNamePropertyGroup).Find(cond).All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("find attribute groups failed, err: %v", err)
+	}
+	for _, group := range groups {
+		model, exist := models[group.ObjectID]
+		if !exist {
+			continue
+		}
+		st.add(KindGroup, childKey(group.ObjectID, group.GroupID), group.ID, group.IsPre)
+		model.Groups = append(model.Groups, Group{
+			ID:        group.GroupID,
+			Name:      group.GroupName,
+			Index:     group.GroupIndex,
+			IsDefault: group.IsDefault,
+		})
+	}
+
+	attributes := make([]metadata.Attribute, 0)
+	if err := db.Table(common.BKTableNameObjAttDes).Find(cond).All(ctx, &attributes); err != nil {
+		return nil, fmt.Errorf("find attributes failed, err: %v", err)
+	}
+	propertyIDs := make(map[int64]string)
+	for _, attr := range attributes {
+		model, exist := models[attr.ObjectID]
+		if !exist {
+			continue
+		}
+		propertyIDs[attr.ID] = attr.PropertyID
+		st.add(KindAttribute, childKey(attr.ObjectID, attr.PropertyID), attr.ID, attr.IsPre)
+		model.Attributes = append(model.Attributes, Attribute{
+			ID:          attr.PropertyID,
+			Name:        attr.PropertyName,
+			Group:       attr.PropertyGroup,
+			Index:       attr.PropertyIndex,
+			Type:        attr.PropertyType,
+			Unit:        attr.Unit,
+			Placeholder: attr.Placeholder,
+			IsEditable:  attr.IsEditable,
+			IsRequired:  attr.IsRequired,
+			IsReadOnly:  attr.IsReadOnly,
+			IsOnly:      attr.IsOnly,
+			IsSystem:    attr.IsSystem,
+			IsAPI:       attr.IsAPI,
+			Option:      normalize(attr.Option),
+			Description: attr.Description,
+		})
+	}
+
+	uniques := make([]metadata.ObjectUnique, 0)
+	if err := db.Table(common.BKTableNameObjUnique).Find(cond).All(ctx, &uniques); err != nil {
+		return nil, fmt.Errorf("find uniques failed, err: %v", err)
+	}
+	for _, unique := range uniques {
+		model, exist := models[unique.ObjID]
+		if !exist {
+			continue
+		}
+		keys := make([]string, 0, len(unique.Keys))
+		for _, key := range unique.Keys {
+			propertyID, exist := propertyIDs[int64(key.ID)]
+			if key.Kind != metadata.UniqueKeyKindProperty || !exist {
+				return nil, fmt.Errorf("the key %s:%d of the unique %d of %s is not an attribute", key.Kind, key.ID, unique.ID, unique.ObjID)
+			}
+			keys = append(keys, propertyID)
+		}
+		sort.Strings(keys)
+		st.add(KindUnique, childKey(unique.ObjID, uniqueKey(keys)), int64(unique.ID), unique.Ispre)
+		model.Uniques = append(model.Uniques, Unique{MustCheck: unique.MustCheck, Keys: keys})
+	}
+
+	assts := make([]metadata.Association, 0)
+	if err := db.Table(common.BKTableNameObjAsst).Find(cond).All(ctx, &assts); err != nil {
+		return nil, fmt.Errorf("find model associations failed, err: %v", err)
+	}
+	for _, asst := range assts {
+		if asst.AsstKindID == common.AssociationKindMainline {
+			st.mainline[asst.ObjectID] = true
+			st.mainline[asst.AsstObjID] = true
+			continue
+		}
+		st.add(KindAssociation, asst.AssociationName, asst.ID, asst.IsPre != nil && *asst.IsPre)
+		st.schema.Associations = append(st.schema.Associations, Association{
+			ID:         asst.AssociationName,
+			Name:       asst.AssociationAliasName,
+			ObjectID:   asst.ObjectID,
+			AsstObjID:  asst.AsstObjID,
+			AsstKindID: asst.AsstKindID,
+			Mapping:    string(asst.Mapping),
+			OnDelete:   string(asst.OnDelete),
+		})
+	}
+
+	st.schema.sort()
+	return st, nil
+}
+
+// kept whether the model is kept with all its items when it's not in the schema, the preset models
+// and the mainline models are kept, the mainline topology could not be changed with schema.
+func (st *state) kept(objID string) bool {
+	return st.preset[itemKey(KindModel, objID)] || st.mainline[objID]
+}
+
+func (st *state) add(kind, key string, id int64, preset bool) {
+	st.ids[itemKey(kind, key)] = id
+	if preset {
+		st.preset[itemKey(kind, key)] = true
+	}
+}
+
+// sort the items by their keys, so that the exported file is stable.
+func (s *Schema) sort() {
+	sort.Slice(s.AssociationKinds, func(i, j int) bool { return s.AssociationKinds[i].ID < s.AssociationKinds[j].ID })
+	sort.Slice(s.Classifications, func(i, j int) bool { return s.Classifications[i].ID < s.Classifications[j].ID })
+	sort.Slice(s.Models, func(i, j int) bool { return s.Models[i].ID < s.Models[j].ID })
+	sort.Slice(s.Associations, func(i, j int) bool { return s.Associations[i].ID < s.Associations[j].ID })
+	for i := range s.Models {
+		groups := s.Models[i].Groups
+		sort.Slice(groups, func(a, b int) bool {
+			if groups[a].Index != groups[b].Index {
+				return groups[a].Index < groups[b].Index
+			}
+			return groups[a].ID < groups[b].ID
+		})
+		attrs := s.Models[i].Attributes
+		sort.Slice(attrs, func(a, b int) bool {
+			if attrs[a].Index != attrs[b].Index {
+				return attrs[a].Index < attrs[b].Index
+			}
+			return attrs[a].ID < attrs[b].ID
+		})
+		uniques := s.Models[i].Uniques
+		sort.Slice(uniques, func(a, b int) bool { return uniqueKey(uniques[a].Keys) < uniqueKey(uniques[b].Keys) })
+	}
+}
+
+// normalize convert the bson documents read from db and the yaml maps into
+// the json compatible maps and slices, so that the values can be compared.
+func normalize(val interface{}) interface{} {
+	switch value := val.(type) {
+	case bson.D:
+		return normalize(value.Map())
+	case bson.M:
+		return normalize(map[string]interface{}(value))
+	case mgobson.M:
+		return normalize(map[string]interface{}(value))
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[fmt.Sprint(k)] = normalize(v)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[k] = normalize(v)
+		}
+		return result
+	case bson.A:
+		return normalize([]interface{}(value))
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			result[i] = normalize(v)
+		}
+		return result
+	default:
+		return val
+	}
+}
@@ -0,0 +1,358 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"icenter/src/common"
+	"icenter/src/common/storage/dal"
+)
+
+// BuildPlan compare the schema with the current model layer of the supplier account, and returns
+// the changes to make them the same. the preset items are never deleted, and the preset models and
+// the mainline models which are not in the schema are kept with all their items, so a schema could
+// only contain the custom models. a model which still has instances and an association which has
+// been instantiated could not be deleted, only the name of an association could be changed.
+func BuildPlan(ctx context.Context, db dal.RDB, ownerID string, target *Schema) (*Plan, error) {
+	st, err := load(ctx, db, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(target, st); err != nil {
+		return nil, err
+	}
+
+	plan := diff(st, target)
+	for _, change := range plan.Changes {
+		switch {
+		case change.Kind == KindModel && change.Action == ActionDelete:
+			count, err := countInstances(ctx, db, ownerID, change.Key)
+			if err != nil {
+				return nil, fmt.Errorf("count the instances of %s failed, err: %v", change.Key, err)
+			}
+			if count != 0 {
+				return nil, fmt.Errorf("model %s could not be deleted, it still has %d instances", change.Key, count)
+			}
+		case change.Kind == KindAssociation && change.Action == ActionDelete:
+			count, err := countInstAssociations(ctx, db, ownerID, change.Key)
+			if err != nil {
+				return nil, fmt.Errorf("count the instance associations of %s failed, err: %v", change.Key, err)
+			}
+			if count != 0 {
+				return nil, fmt.Errorf("association %s could not be deleted, it has been instantiated %d times", change.Key, count)
+			}
+		case change.Kind == KindAssociation && change.Action == ActionUpdate:
+			for field := range change.Diff {
+				if !mutableAssociationFields[field] {
+					return nil, fmt.Errorf("the %s of association %s could not be changed", field, change.Key)
+				}
+			}
+		}
+	}
+	return plan, nil
+}
+
+// mutableAssociationFields the fields of an association which could be changed, the others
+// decide how the instances are associated.
+var mutableAssociationFields = map[string]bool{
+	"bk_obj_asst_name": true,
+}
+
+// String returns the readable plan, one change per line.
+func (p *Plan) String() string {
+	buf := bytes.NewBuffer(nil)
+	for _, change := range p.Changes {
+		switch change.Action {
+		case ActionCreate:
+			fmt.Fprintf(buf, "+ create %s %s\n", change.Kind, change.Key)
+		case ActionDelete:
+			fmt.Fprintf(buf, "- delete %s %s\n", change.Kind, change.Key)
+		case ActionUpdate:
+			fmt.Fprintf(buf, "~ update %s %s\n", change.Kind, change.Key)
+			fields := make([]string, 0, len(change.Diff))
+			for field := range change.Diff {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			for _, field := range fields {
+				old, _ := json.Marshal(change.Diff[field].Old)
+				new, _ := json.Marshal(change.Diff[field].New)
+				fmt.Fprintf(buf, "    %s: %s => %s\n", field, old, new)
+			}
+		}
+	}
+	fmt.Fprintf(buf, "%d to create, %d to update, %d to delete\n", p.Create, p.Update, p.Delete)
+	return buf.String()
+}
+
+// validate check the keys of the schema are unique and all the references exist,
+// the preset items which are kept in db could be referenced too.
+func validate(s *Schema, st *state) error {
+	kinds := make(map[string]bool)
+	for _, kind := range s.AssociationKinds {
+		if err := checkKey(kinds, KindAssociationKind, kind.ID); err != nil {
+			return err
+		}
+	}
+	classifications := make(map[string]bool)
+	for _, cls := range s.Classifications {
+		if err := checkKey(classifications, KindClassification, cls.ID); err != nil {
+			return err
+		}
+	}
+	models := make(map[string]bool)
+	for _, model := range s.Models {
+		if err := checkKey(models, KindModel, model.ID); err != nil {
+			return err
+		}
+	}
+	for _, kind := range st.schema.AssociationKinds {
+		kinds[kind.ID] = kinds[kind.ID] || st.preset[itemKey(KindAssociationKind, kind.ID)]
+	}
+	for _, cls := range st.schema.Classifications {
+		classifications[cls.ID] = classifications[cls.ID] || st.preset[itemKey(KindClassification, cls.ID)]
+	}
+	for _, model := range st.schema.Models {
+		models[model.ID] = models[model.ID] || st.preset[itemKey(KindModel, model.ID)]
+	}
+
+	for _, model := range s.Models {
+		if !classifications[model.Classification] {
+			return fmt.Errorf("the classification %s of model %s does not exist", model.Classification, model.ID)
+		}
+		groups := make(map[string]bool)
+		for _, group := range model.Groups {
+			if err := checkKey(groups, KindGroup, childKey(model.ID, group.ID)); err != nil {
+				return err
+			}
+		}
+		attrs := make(map[string]bool)
+		for _, attr := range model.Attributes {
+			if len(attr.Type) == 0 {
+				return fmt.Errorf("the bk_property_type of attribute %s is not set", childKey(model.ID, attr.ID))
+			}
+			if err := checkKey(attrs, KindAttribute, childKey(model.ID, attr.ID)); err != nil {
+				return err
+			}
+		}
+		uniques := make(map[string]bool)
+		for _, unique := range model.Uniques {
+			if len(unique.Keys) == 0 {
+				return fmt.Errorf("the unique of model %s has no keys", model.ID)
+			}
+			for _, key := range unique.Keys {
+				if !attrs[childKey(model.ID, key)] {
+					return fmt.Errorf("the unique key %s of model %s is not an attribute of it", key, model.ID)
+				}
+			}
+			if err := checkKey(uniques, KindUnique, childKey(model.ID, uniqueKey(unique.Keys))); err != nil {
+				return err
+			}
+		}
+	}
+
+	assts := make(map[string]bool)
+	for _, asst := range s.Associations {
+		if err := checkKey(assts, KindAssociation, asst.ID); err != nil {
+			return err
+		}
+		if asst.AsstKindID == common.AssociationKindMainline {
+			return fmt.Errorf("the mainline association %s could not be changed with schema", asst.ID)
+		}
+		if !models[asst.ObjectID] || !models[asst.AsstObjID] {
+			return fmt.Errorf("the models of association %s do not exist", asst.ID)
+		}
+		if !kinds[asst.AsstKindID] {
+			return fmt.Errorf("the association kind %s of association %s does not exist", asst.AsstKindID, asst.ID)
+		}
+	}
+	return nil
+}
+
+// checkKey check the key is not empty and not duplicated, the key is added to the keys.
+func checkKey(keys map[string]bool, kind, key string) error {
+	if len(key) == 0 {
+		return fmt.Errorf("the key of %s is empty", kind)
+	}
+	if keys[key] {
+		return fmt.Errorf("%s %s is duplicated", kind, key)
+	}
+	keys[key] = true
+	return nil
+}
+
+// diff returns the changes from the current state to the target schema, the creations and
+// the updates are in the dependency order, then the deletions are in the reverse order.
+func diff(st *state, target *Schema) *Plan {
+	current := items(st.schema)
+	wanted := items(target)
+
+	// the items of the models which are not in the target schema
+	removedModels := make(map[string]bool)
+	for _, model := range st.schema.Models {
+		removedModels[model.ID] = true
+	}
+	for _, model := range target.Models {
+		delete(removedModels, model.ID)
+	}
+
+	plan := &Plan{Changes: make([]Change, 0)}
+	for _, kind := range kindOrder {
+		for _, item := range sortedItems(wanted[kind]) {
+			old, exist := current[kind][item.key]
+			if !exist {
+				plan.Changes = append(plan.Changes, Change{Action: ActionCreate, Kind: kind, Key: item.key, objID: item.objID, item: item.value})
+				continue
+			}
+			if fields := diffFields(old.value, item.value); len(fields) != 0 {
+				plan.Changes = append(plan.Changes, Change{Action: ActionUpdate, Kind: kind, Key: item.key, Diff: fields,
+					objID: item.objID, item: item.value, old: old.value, id: st.ids[itemKey(kind, item.key)]})
+			}
+		}
+	}
+
+	for i := len(kindOrder) - 1; i >= 0; i-- {
+		kind := kindOrder[i]
+		for _, item := range sortedItems(current[kind]) {
+			if _, exist := wanted[kind][item.key]; exist {
+				continue
+			}
+			preset := st.preset[itemKey(kind, item.key)]
+			if kind == KindGroup || kind == KindAttribute || kind == KindUnique {
+				if !removedModels[item.objID] {
+					if preset {
+						continue
+					}
+				} else if st.kept(item.objID) {
+					// the preset model and the mainline model are kept with all their items.
+					continue
+				}
+			} else if preset || (kind == KindModel && st.mainline[item.key]) {
+				continue
+			}
+			plan.Changes = append(plan.Changes, Change{Action: ActionDelete, Kind: kind, Key: item.key,
+				objID: item.objID, old: item.value, id: st.ids[itemKey(kind, item.key)]})
+		}
+	}
+
+	for _, change := range plan.Changes {
+		switch change.Action {
+		case ActionCreate:
+			plan.Create++
+		case ActionUpdate:
+			plan.Update++
+		case ActionDelete:
+			plan.Delete++
+		}
+	}
+	return plan
+}
+
+type schemaItem struct {
+	key   string
+	objID string
+	value interface{}
+}
+
+// items returns the items of the schema by kind and key
+func items(s *Schema) map[string]map[string]schemaItem {
+	result := make(map[string]map[string]schemaItem)
+	for _, kind := range kindOrder {
+		result[kind] = make(map[string]schemaItem)
+	}
+	add := func(kind, key, objID string, value interface{}) {
+		result[kind][key] = schemaItem{key: key, objID: objID, value: value}
+	}
+
+	for _, kind := range s.AssociationKinds {
+		add(KindAssociationKind, kind.ID, "", kind)
+	}
+	for _, cls := range s.Classifications {
+		add(KindClassification, cls.ID, "", cls)
+	}
+	for _, model := range s.Models {
+		object := model
+		object.Groups, object.Attributes, object.Uniques = nil, nil, nil
+		add(KindModel, model.ID, model.ID, object)
+		for _, group := range model.Groups {
+			add(KindGroup, childKey(model.ID, group.ID), model.ID, group)
+		}
+		for _, attr := range model.Attributes {
+			add(KindAttribute, childKey(model.ID, attr.ID), model.ID, attr)
+		}
+		for _, unique := range model.Uniques {
+			// the order of the keys makes no difference.
+			unique.Keys = sortedKeys(unique.Keys)
+			add(KindUnique, childKey(model.ID, uniqueKey(unique.Keys)), model.ID, unique)
+		}
+	}
+	for _, asst := range s.Associations {
+		add(KindAssociation, asst.ID, asst.ObjectID, asst)
+	}
+	return result
+}
+
+func sortedItems(items map[string]schemaItem) []schemaItem {
+	result := make([]schemaItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].key < result[j].key })
+	return result
+}
+
+// fields returns the fields of the item as a json document
+func fields(item interface{}) map[string]interface{} {
+	data, _ := json.Marshal(item)
+	result := make(map[string]interface{})
+	json.Unmarshal(data, &result)
+	// the items of a model are compared separately.
+	delete(result, "groups")
+	delete(result, "attributes")
+	delete(result, "uniques")
+	return result
+}
+
+func diffFields(old, new interface{}) map[string]FieldDiff {
+	oldFields, newFields := fields(old), fields(new)
+	result := make(map[string]FieldDiff)
+	for field, value := range newFields {
+		if !reflect.DeepEqual(oldFields[field], value) {
+			result[field] = FieldDiff{Old: oldFields[field], New: value}
+		}
+	}
+	return result
+}
+
+func countInstances(ctx context.Context, db dal.RDB, ownerID, objID string) (uint64, error) {
+	tableName := common.GetInstTableName(objID)
+	cond := map[string]interface{}{common.BKOwnerIDField: ownerID}
+	if tableName == common.BKTableNameBaseInst {
+		cond[common.BKObjIDField] = objID
+	}
+	return db.Table(tableName).Find(cond).Count(ctx)
+}
+
+func countInstAssociations(ctx context.Context, db dal.RDB, ownerID, asstID string) (uint64, error) {
+	cond := map[string]interface{}{
+		common.BKOwnerIDField:            ownerID,
+		common.AssociationObjAsstIDField: asstID,
+	}
+	return db.Table(common.BKTableNameInstAsst).Find(cond).Count(ctx)
+}
@@ -0,0 +1,269 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"icenter/src/common"
+	"icenter/src/common/auditoplog"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal/memory"
+)
+
+const ownerID = common.BKDefaultOwnerID
+
+// presetDB returns a db with the preset items, their ids are out of the range of the sequences.
+func presetDB(t *testing.T) *memory.Memory {
+	ctx := context.Background()
+	db := memory.NewMemory()
+	isPre := true
+	now := metadata.Now()
+	require.NoError(t, db.Table(common.BKTableNameAsstDes).Insert(ctx, metadata.AssociationKind{
+		ID: 1000, AssociationKindID: "belong", AssociationKindName: "属于", OwnerID: ownerID, IsPre: &isPre,
+	}))
+	require.NoError(t, db.Table(common.BKTableNameObjClassifiction).Insert(ctx, metadata.Classification{
+		ID: 1000, ClassificationID: "bk_host_manage", ClassificationName: "主机管理", ClassificationType: "inner", OwnerID: ownerID,
+	}))
+	require.NoError(t, db.Table(common.BKTableNameObjDes).Insert(ctx, metadata.Object{
+		ID: 1000, ObjCls: "bk_host_manage", ObjectID: common.BKInnerObjIDHost, ObjectName: "主机", IsPre: true, OwnerID: ownerID,
+		CreateTime: &now, LastTime: &now,
+	}))
+	require.NoError(t, db.Table(common.BKTableNameObjAttDes).Insert(ctx, metadata.Attribute{
+		ID: 1000, ObjectID: common.BKInnerObjIDHost, PropertyID: "bk_host_name", PropertyName: "主机名",
+		PropertyType: common.FieldTypeSingleChar, IsPre: true, OwnerID: ownerID, CreateTime: &now, LastTime: &now,
+	}))
+	return db
+}
+
+func switchSchema() *Schema {
+	return &Schema{
+		Version:         Version,
+		Classifications: []Classification{{ID: "bk_network", Name: "网络"}},
+		Models: []Model{{
+			ID:             "switch",
+			Name:           "交换机",
+			Classification: "bk_network",
+			Groups:         []Group{{ID: "default", Name: "基础信息", IsDefault: true}},
+			Attributes: []Attribute{
+				{ID: "bk_inst_name", Name: "名称", Group: "default", Index: 1, Type: common.FieldTypeSingleChar, IsRequired: true},
+				{ID: "bk_sn", Name: "序列号", Group: "default", Index: 2, Type: common.FieldTypeSingleChar,
+					Option: map[string]interface{}{"regex": "^[A-Z0-9]+$"}},
+			},
+			Uniques: []Unique{{MustCheck: true, Keys: []string{"bk_sn", "bk_inst_name"}}},
+		}},
+		Associations: []Association{{
+			ID: "switch_belong_host", ObjectID: "switch", AsstObjID: common.BKInnerObjIDHost, AsstKindID: "belong", Mapping: "n:n",
+		}},
+	}
+}
+
+func TestApplyIdempotent(t *testing.T) {
+	ctx := context.Background()
+	db := presetDB(t)
+	target := switchSchema()
+
+	plan, err := BuildPlan(ctx, db, ownerID, target)
+	require.NoError(t, err)
+	// the classification, the model, the group, two attributes, the unique and the association.
+	require.Equal(t, 7, plan.Create)
+	require.Equal(t, 0, plan.Update)
+	require.Equal(t, 0, plan.Delete)
+	require.NoError(t, Apply(ctx, db, ownerID, common.CCSystemOperatorUserName, plan))
+
+	plan, err = BuildPlan(ctx, db, ownerID, target)
+	require.NoError(t, err)
+	require.Empty(t, plan.Changes, plan.String())
+
+	exported, err := Export(ctx, db, ownerID)
+	require.NoError(t, err)
+	require.Len(t, exported.Models, 2)
+	require.Equal(t, []string{"bk_inst_name", "bk_sn"}, exported.Models[1].Uniques[0].Keys)
+	require.Equal(t, "^[A-Z0-9]+$", exported.Models[1].Attributes[1].Option.(map[string]interface{})["regex"])
+
+	// the exported schema could be applied without any change.
+	plan, err = BuildPlan(ctx, db, ownerID, exported)
+	require.NoError(t, err)
+	require.Empty(t, plan.Changes, plan.String())
+}
+
+func TestPlanUpdateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	db := presetDB(t)
+	plan, err := BuildPlan(ctx, db, ownerID, switchSchema())
+	require.NoError(t, err)
+	require.NoError(t, Apply(ctx, db, ownerID, common.CCSystemOperatorUserName, plan))
+
+	target := switchSchema()
+	target.Models[0].Attributes[1].Name = "SN"
+	target.Models[0].Attributes[1].Index = 3
+	target.Models[0].Uniques[0].MustCheck = false
+	plan, err = BuildPlan(ctx, db, ownerID, target)
+	require.NoError(t, err)
+	require.Equal(t, 2, plan.Update, plan.String())
+	require.Equal(t, FieldDiff{Old: "序列号", New: "SN"}, plan.Changes[0].Diff["bk_property_name"])
+	require.NoError(t, Apply(ctx, db, ownerID, common.CCSystemOperatorUserName, plan))
+	plan, err = BuildPlan(ctx, db, ownerID, target)
+	require.NoError(t, err)
+	require.Empty(t, plan.Changes, plan.String())
+
+	// the index is saved as an integer like it's created.
+	attr := make(map[string]interface{})
+	require.NoError(t, db.Table(common.BKTableNameObjAttDes).Find(map[string]interface{}{
+		common.BKObjIDField: "switch", common.BKPropertyIDField: "bk_sn",
+	}).One(ctx, &attr))
+	require.Equal(t, int64(3), attr[metadata.AttributeFieldPropertyIndex])
+
+	// the preset host model is not in the schema, it is kept.
+	target = &Schema{Version: Version}
+	plan, err = BuildPlan(ctx, db, ownerID, target)
+	require.NoError(t, err)
+	require.Equal(t, 7, plan.Delete, plan.String())
+	require.Equal(t, KindAssociation, plan.Changes[0].Kind)
+	require.Equal(t, KindClassification, plan.Changes[len(plan.Changes)-1].Kind)
+	require.NoError(t, Apply(ctx, db, ownerID, common.CCSystemOperatorUserName, plan))
+
+	exported, err := Export(ctx, db, ownerID)
+	require.NoError(t, err)
+	require.Len(t, exported.Models, 1)
+	require.Equal(t, common.BKInnerObjIDHost, exported.Models[0].ID)
+	require.Len(t, exported.Models[0].Attributes, 1)
+}
+
+func TestPlanKeepMainline(t *testing.T) {
+	ctx := context.Background()
+	db := presetDB(t)
+	plan, err := BuildPlan(ctx, db, ownerID, switchSchema())
+	require.NoError(t, err)
+	require.NoError(t, Apply(ctx, db, ownerID, common.CCSystemOperatorUserName, plan))
+
+	// the custom switch model is added to the mainline topology
+	require.NoError(t, db.Table(common.BKTableNameObjAsst).Insert(ctx, metadata.Association{
+		ID: 1001, OwnerID: ownerID, AssociationName: "switch_bk_mainline_host", ObjectID: "switch",
+		AsstObjID: common.BKInnerObjIDHost, AsstKindID: common.AssociationKindMainline,
+	}))
+
+	// the mainline model is kept with all its items, only the classification has nothing to do with it.
+	target := switchSchema()
+	target.Models, target.Associations = nil, nil
+	plan, err = BuildPlan(ctx, db, ownerID, target)
+	require.NoError(t, err)
+	require.Equal(t, 1, plan.Delete, plan.String())
+	require.Equal(t, KindAssociation, plan.Changes[0].Kind)
+}
+
+func TestPlanRejected(t *testing.T) {
+	ctx := context.Background()
+	db := presetDB(t)
+	plan, err := BuildPlan(ctx, db, ownerID, switchSchema())
+	require.NoError(t, err)
+	require.NoError(t, Apply(ctx, db, ownerID, common.CCSystemOperatorUserName, plan))
+
+	// the model with instances could not be deleted.
+	require.NoError(t, db.Table(common.BKTableNameBaseInst).Insert(ctx, map[string]interface{}{
+		common.BKObjIDField: "switch", common.BKInstIDField: 1, common.BKOwnerIDField: ownerID,
+	}))
+	_, err = BuildPlan(ctx, db, ownerID, &Schema{})
+	require.Error(t, err)
+
+	target := switchSchema()
+	target.Associations[0].AsstKindID = "connect"
+	_, err = BuildPlan(ctx, db, ownerID, target)
+	require.Error(t, err)
+
+	target = switchSchema()
+	target.Models[0].Uniques[0].Keys = []string{"bk_ip"}
+	_, err = BuildPlan(ctx, db, ownerID, target)
+	require.Error(t, err)
+
+	// only the name of the association could be changed.
+	target = switchSchema()
+	target.Associations[0].Mapping = "1:n"
+	_, err = BuildPlan(ctx, db, ownerID, target)
+	require.Error(t, err)
+	target = switchSchema()
+	target.Associations[0].Name = "switch to host"
+	plan, err = BuildPlan(ctx, db, ownerID, target)
+	require.NoError(t, err)
+	require.Equal(t, 1, plan.Update, plan.String())
+
+	// the instantiated association could not be deleted.
+	require.NoError(t, db.Table(common.BKTableNameInstAsst).Insert(ctx, metadata.InstAsst{
+		ID: 1, OwnerID: ownerID, ObjectAsstID: "switch_belong_host", ObjectID: "switch", InstID: 1,
+		AsstObjectID: common.BKInnerObjIDHost, AsstInstID: 1, AssociationKindID: "belong",
+	}))
+	target = switchSchema()
+	target.Associations = nil
+	_, err = BuildPlan(ctx, db, ownerID, target)
+	require.Error(t, err)
+}
+
+func TestApplyAudit(t *testing.T) {
+	ctx := context.Background()
+	db := presetDB(t)
+	plan, err := BuildPlan(ctx, db, ownerID, switchSchema())
+	require.NoError(t, err)
+	require.NoError(t, Apply(ctx, db, ownerID, "admin", plan))
+
+	target := switchSchema()
+	target.Models[0].Attributes[1].Name = "SN"
+	target.Associations = nil
+	plan, err = BuildPlan(ctx, db, ownerID, target)
+	require.NoError(t, err)
+	require.NoError(t, Apply(ctx, db, ownerID, "admin", plan))
+
+	logs := make([]metadata.OperationLog, 0)
+	require.NoError(t, db.Table(common.BKTableNameOperationLog).Find(nil).Sort("id").All(ctx, &logs))
+	require.Len(t, logs, 9)
+	for _, log := range logs {
+		require.Equal(t, "admin", log.User)
+		require.Equal(t, ownerID, log.OwnerID)
+	}
+
+	update := logs[7]
+	require.Equal(t, int(auditoplog.AuditOpTypeModify), update.OpType)
+	require.Equal(t, KindAttribute, update.OpTarget)
+	require.Equal(t, "switch.bk_sn", update.ExtKey)
+	require.Equal(t, "序列号", logData(t, update, "pre_data")["bk_property_name"])
+	require.Equal(t, "SN", logData(t, update, "cur_data")["bk_property_name"])
+
+	deletion := logs[8]
+	require.Equal(t, int(auditoplog.AuditOpTypeDel), deletion.OpType)
+	require.Equal(t, KindAssociation, deletion.OpTarget)
+	require.Equal(t, "switch_belong_host", logData(t, deletion, "pre_data")["bk_obj_asst_id"])
+}
+
+// logData returns the data before or after the change of the audit log
+func logData(t *testing.T, log metadata.OperationLog, key string) map[string]interface{} {
+	data, err := json.Marshal(log.Content)
+	require.NoError(t, err)
+	content := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(data, &content))
+	return content[key].(map[string]interface{})
+}
+
+func TestMarshal(t *testing.T) {
+	for _, format := range []string{FormatYAML, FormatJSON} {
+		data, err := Marshal(switchSchema(), format)
+		require.NoError(t, err)
+		s, err := Unmarshal(data, format)
+		require.NoError(t, err)
+		require.Equal(t, switchSchema(), s, format)
+	}
+	require.Equal(t, FormatJSON, FormatOfFile("model.JSON"))
+	require.Equal(t, FormatYAML, FormatOfFile("model.yml"))
+}
@@ -0,0 +1,172 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package schema exports the whole model layer (classifications, models, attributes,
+// attribute groups, uniques, association kinds and model associations) as a declarative
+// yaml or json file, and applies such a file back idempotently with a plan of the changes.
+package schema
+
+// Version the version of the schema file format
+const Version = "v1"
+
+// the formats of the schema file
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// the actions of the changes
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// the kinds of the changes
+const (
+	KindAssociationKind = "association_kind"
+	KindClassification  = "classification"
+	KindModel           = "model"
+	KindGroup           = "group"
+	KindAttribute       = "attribute"
+	KindUnique          = "unique"
+	KindAssociation     = "association"
+)
+
+// kindOrder the order that the creations and the updates are executed in,
+// the deletions are executed in the reverse order.
+var kindOrder = []string{
+	KindAssociationKind,
+	KindClassification,
+	KindModel,
+	KindGroup,
+	KindAttribute,
+	KindUnique,
+	KindAssociation,
+}
+
+// Schema the whole model layer of a supplier account.
+// the mainline associations are not included, they are changed with the mainline topo api.
+type Schema struct {
+	Version          string            `json:"version" yaml:"version"`
+	AssociationKinds []AssociationKind `json:"association_kinds,omitempty" yaml:"association_kinds,omitempty"`
+	Classifications  []Classification  `json:"classifications,omitempty" yaml:"classifications,omitempty"`
+	Models           []Model           `json:"models,omitempty" yaml:"models,omitempty"`
+	Associations     []Association     `json:"associations,omitempty" yaml:"associations,omitempty"`
+}
+
+// AssociationKind the association kind, the key is the bk_asst_id
+type AssociationKind struct {
+	ID        string `json:"bk_asst_id" yaml:"bk_asst_id"`
+	Name      string `json:"bk_asst_name" yaml:"bk_asst_name"`
+	SrcDes    string `json:"src_des" yaml:"src_des"`
+	DestDes   string `json:"dest_des" yaml:"dest_des"`
+	Direction string `json:"direction" yaml:"direction"`
+}
+
+// Classification the model classification, the key is the bk_classification_id
+type Classification struct {
+	ID   string `json:"bk_classification_id" yaml:"bk_classification_id"`
+	Name string `json:"bk_classification_name" yaml:"bk_classification_name"`
+	Type string `json:"bk_classification_type" yaml:"bk_classification_type"`
+	Icon string `json:"bk_classification_icon" yaml:"bk_classification_icon"`
+}
+
+// Model the model with its attribute groups, attributes and uniques, the key is the bk_obj_id
+type Model struct {
+	ID             string      `json:"bk_obj_id" yaml:"bk_obj_id"`
+	Name           string      `json:"bk_obj_name" yaml:"bk_obj_name"`
+	Classification string      `json:"bk_classification_id" yaml:"bk_classification_id"`
+	Icon           string      `json:"bk_obj_icon" yaml:"bk_obj_icon"`
+	IsPaused       bool        `json:"bk_ispaused" yaml:"bk_ispaused"`
+	Description    string      `json:"description" yaml:"description"`
+	Groups         []Group     `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Attributes     []Attribute `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+	Uniques        []Unique    `json:"uniques,omitempty" yaml:"uniques,omitempty"`
+}
+
+// Group the attribute group of a model, the key is the bk_group_id
+type Group struct {
+	ID        string `json:"bk_group_id" yaml:"bk_group_id"`
+	Name      string `json:"bk_group_name" yaml:"bk_group_name"`
+	Index     int64  `json:"bk_group_index" yaml:"bk_group_index"`
+	IsDefault bool   `json:"bk_isdefault" yaml:"bk_isdefault"`
+}
+
+// Attribute the attribute of a model, the key is the bk_property_id
+type Attribute struct {
+	ID          string      `json:"bk_property_id" yaml:"bk_property_id"`
+	Name        string      `json:"bk_property_name" yaml:"bk_property_name"`
+	Group       string      `json:"bk_property_group" yaml:"bk_property_group"`
+	Index       int64       `json:"bk_property_index" yaml:"bk_property_index"`
+	Type        string      `json:"bk_property_type" yaml:"bk_property_type"`
+	Unit        string      `json:"unit" yaml:"unit"`
+	Placeholder string      `json:"placeholder" yaml:"placeholder"`
+	IsEditable  bool        `json:"editable" yaml:"editable"`
+	IsRequired  bool        `json:"isrequired" yaml:"isrequired"`
+	IsReadOnly  bool        `json:"isreadonly" yaml:"isreadonly"`
+	IsOnly      bool        `json:"isonly" yaml:"isonly"`
+	IsSystem    bool        `json:"bk_issystem" yaml:"bk_issystem"`
+	IsAPI       bool        `json:"bk_isapi" yaml:"bk_isapi"`
+	Option      interface{} `json:"option" yaml:"option"`
+	Description string      `json:"description" yaml:"description"`
+}
+
+// Unique the unique of a model, the keys are the bk_property_id of the attributes,
+// the sorted keys are the key of the unique.
+type Unique struct {
+	MustCheck bool     `json:"must_check" yaml:"must_check"`
+	Keys      []string `json:"keys" yaml:"keys"`
+}
+
+// Association the model association, the key is the bk_obj_asst_id
+type Association struct {
+	ID         string `json:"bk_obj_asst_id" yaml:"bk_obj_asst_id"`
+	Name       string `json:"bk_obj_asst_name" yaml:"bk_obj_asst_name"`
+	ObjectID   string `json:"bk_obj_id" yaml:"bk_obj_id"`
+	AsstObjID  string `json:"bk_asst_obj_id" yaml:"bk_asst_obj_id"`
+	AsstKindID string `json:"bk_asst_id" yaml:"bk_asst_id"`
+	Mapping    string `json:"mapping" yaml:"mapping"`
+	OnDelete   string `json:"on_delete" yaml:"on_delete"`
+}
+
+// Plan the changes to make the model layer the same as the schema
+type Plan struct {
+	Changes []Change `json:"changes"`
+	Create  int      `json:"create"`
+	Update  int      `json:"update"`
+	Delete  int      `json:"delete"`
+}
+
+// Change a change of the plan
+type Change struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	// Key the key of the changed item, the items of a model are prefixed with the bk_obj_id, like host.bk_host_name
+	Key string `json:"key"`
+	// Diff the changed fields of an update
+	Diff map[string]FieldDiff `json:"diff,omitempty"`
+
+	objID string
+	// item the item in the schema of a creation or an update
+	item interface{}
+	// old the item in db of an update or a deletion
+	old interface{}
+	// id the id of the db row of an update or a deletion
+	id int64
+}
+
+// FieldDiff the value of a field before and after an update
+type FieldDiff struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
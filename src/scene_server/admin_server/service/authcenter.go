@@ -15,6 +15,7 @@ package service
 import (
 	"net/http"
 
+	"icenter/src/auth/authcenter"
 	"icenter/src/auth/meta"
 	"icenter/src/common"
 	"icenter/src/common/blog"
@@ -28,7 +29,7 @@ import (
 func (s *Service) InitAuthCenter(req *restful.Request, resp *restful.Response) {
 	rHeader := req.Request.Header
 	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
-	if !s.Config.AuthCenter.Enable {
+	if !s.Config.AuthCenter.Enable || s.Config.AuthCenter.Backend == authcenter.BackendLocal {
 		blog.Error("received init auth center request, but not enable authcenter, maybe the configure is wrong.")
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommAuthCenterIsNotEnabled)})
 		return
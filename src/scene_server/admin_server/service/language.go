@@ -0,0 +1,113 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/metadata"
+	"icenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// languagePacks returns the validation reports of the published packs of the kind, error or language.
+func (s *Service) languagePacks(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	kind := req.PathParameter("kind")
+
+	reports, err := s.confCenter.Packs(s.ctx, kind)
+	if err != nil {
+		blog.Errorf("get the %s packs failed, err: %v, rid: %s", kind, err, util.GetHTTPCCRequestID(rHeader))
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(reports))
+}
+
+// languagePack returns the published pack of the language
+func (s *Service) languagePack(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	kind, lang := req.PathParameter("kind"), req.PathParameter("language")
+
+	pack, err := s.confCenter.GetPack(s.ctx, kind, lang)
+	if err != nil {
+		blog.Errorf("get the %s pack of %s failed, err: %v, rid: %s", kind, lang, err, util.GetHTTPCCRequestID(rHeader))
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(pack))
+}
+
+// languagePackVersions returns the saved versions of the pack
+func (s *Service) languagePackVersions(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	kind, lang := req.PathParameter("kind"), req.PathParameter("language")
+
+	versions, err := s.confCenter.PackVersions(s.ctx, kind, lang)
+	if err != nil {
+		blog.Errorf("get the versions of the %s pack of %s failed, err: %v, rid: %s", kind, lang, err, util.GetHTTPCCRequestID(rHeader))
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(versions))
+}
+
+// saveLanguagePack validate the pack and publish it as a new version, the services reload it at once.
+func (s *Service) saveLanguagePack(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	rid := util.GetHTTPCCRequestID(rHeader)
+	kind, lang := req.PathParameter("kind"), req.PathParameter("language")
+
+	input := metadata.SaveLanguagePackRequest{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("save the %s pack of %s failed, decode body err: %v, rid: %s", kind, lang, err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	result, err := s.confCenter.SavePack(s.ctx, kind, lang, util.GetUser(rHeader), input)
+	if err != nil {
+		blog.Errorf("save the %s pack of %s failed, err: %v, rid: %s", kind, lang, err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// activateLanguagePack publish a saved version of the pack, version 0 restores the pack in the resource files.
+func (s *Service) activateLanguagePack(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	rid := util.GetHTTPCCRequestID(rHeader)
+	kind, lang := req.PathParameter("kind"), req.PathParameter("language")
+
+	version, err := strconv.ParseInt(req.PathParameter("version"), 10, 64)
+	if err != nil || version < 0 {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "version")})
+		return
+	}
+	if err := s.confCenter.ActivatePack(s.ctx, kind, lang, version); err != nil {
+		blog.Errorf("activate version %d of the %s pack of %s failed, err: %v, rid: %s", version, kind, lang, err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
@@ -13,7 +13,9 @@
 package service
 
 import (
+	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"icenter/src/common"
 	"icenter/src/common/blog"
@@ -24,17 +26,23 @@ import (
 	"github.com/emicklei/go-restful"
 )
 
+// RollbackRequest the request to roll back to the version
+type RollbackRequest struct {
+	Version string `json:"version"`
+	// DryRun only returns the changes of the down steps, nothing is changed.
+	DryRun bool `json:"dryrun"`
+}
+
 func (s *Service) migrate(req *restful.Request, resp *restful.Response) {
 	rHeader := req.Request.Header
 	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
-	ownerID := common.BKDefaultOwnerID
 
-	err := upgrader.Upgrade(s.ctx, s.db, &upgrader.Config{
-		OwnerID:      ownerID,
-		SupplierID:   common.BKDefaultSupplierID,
-		User:         "migrate",
-		CCApiSrvAddr: s.ccApiSrvAddr,
-	})
+	if dryRun, _ := strconv.ParseBool(req.QueryParameter("dryrun")); dryRun {
+		s.planMigration(req, resp)
+		return
+	}
+
+	err := upgrader.Upgrade(s.ctx, s.db, s.upgraderConfig())
 
 	if nil != err {
 		blog.Errorf("db upgrade error: %v", err)
@@ -44,3 +52,95 @@ func (s *Service) migrate(req *restful.Request, resp *restful.Response) {
 
 	resp.WriteEntity(metadata.NewSuccessResp("migrate success"))
 }
+
+// planMigration returns the changes of the pending versions, nothing is changed.
+func (s *Service) planMigration(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	plan, err := upgrader.Plan(s.ctx, s.db, s.upgraderConfig())
+	if nil != err {
+		blog.Errorf("plan db upgrade error: %v, rid: %s", err, util.GetHTTPCCRequestID(rHeader))
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommMigrateFailed, err.Error())})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(plan))
+}
+
+// rollbackMigration roll back to the version with the down steps of the newer versions
+func (s *Service) rollbackMigration(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	rid := util.GetHTTPCCRequestID(rHeader)
+
+	input := new(RollbackRequest)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("roll back db failed, decode body err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if len(input.Version) == 0 {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, "version")})
+		return
+	}
+
+	plan, err := upgrader.Rollback(s.ctx, s.db, s.upgraderConfig(), input.Version, input.DryRun)
+	if nil != err {
+		blog.Errorf("roll back db to version %s error: %v, rid: %s", input.Version, err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommMigrateFailed, err.Error())})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(plan))
+}
+
+// migrationVersions returns the applied and pending versions
+func (s *Service) migrationVersions(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	status, err := upgrader.Versions(s.ctx, s.db)
+	if nil != err {
+		blog.Errorf("get db versions error: %v, rid: %s", err, util.GetHTTPCCRequestID(rHeader))
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(status))
+}
+
+// migrationHistory returns the runs of the migration steps from the newest one
+func (s *Service) migrationHistory(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	var start, limit uint64
+	var err error
+	if value := req.QueryParameter("start"); len(value) != 0 {
+		if start, err = strconv.ParseUint(value, 10, 64); err != nil {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "start")})
+			return
+		}
+	}
+	if value := req.QueryParameter("limit"); len(value) != 0 {
+		if limit, err = strconv.ParseUint(value, 10, 64); err != nil {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "limit")})
+			return
+		}
+	}
+
+	histories, count, err := upgrader.ListHistory(s.ctx, s.db, req.QueryParameter("version"), start, limit)
+	if nil != err {
+		blog.Errorf("get migration history error: %v, rid: %s", err, util.GetHTTPCCRequestID(rHeader))
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(map[string]interface{}{"count": count, "info": histories}))
+}
+
+func (s *Service) upgraderConfig() *upgrader.Config {
+	return &upgrader.Config{
+		OwnerID:      common.BKDefaultOwnerID,
+		SupplierID:   common.BKDefaultSupplierID,
+		User:         "migrate",
+		CCApiSrvAddr: s.ccApiSrvAddr,
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/metadata"
+	"icenter/src/common/util"
+	"icenter/src/scene_server/admin_server/schema"
+
+	"github.com/emicklei/go-restful"
+)
+
+// ImportModelsRequest the request to apply a model schema file
+type ImportModelsRequest struct {
+	// Format the format of the content, yaml or json, default is yaml.
+	Format  string `json:"format"`
+	Content string `json:"content"`
+	// DryRun only returns the plan, nothing is changed.
+	DryRun bool `json:"dryrun"`
+}
+
+// exportModels returns the model layer as a schema, it's a yaml document if the format is yaml.
+func (s *Service) exportModels(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	ownerID := modelOwnerID(rHeader)
+
+	result, err := schema.Export(s.ctx, s.db, ownerID)
+	if err != nil {
+		blog.Errorf("export the models of %s failed, err: %v, rid: %s", ownerID, err, util.GetHTTPCCRequestID(rHeader))
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommMigrateFailed, err.Error())})
+		return
+	}
+
+	format := req.QueryParameter("format")
+	if len(format) == 0 || format == schema.FormatJSON {
+		resp.WriteEntity(metadata.NewSuccessResp(result))
+		return
+	}
+	data, err := schema.Marshal(result, format)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "format")})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(string(data)))
+}
+
+// importModels apply the model schema, and returns the plan of the changes.
+func (s *Service) importModels(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	rid := util.GetHTTPCCRequestID(rHeader)
+	ownerID := modelOwnerID(rHeader)
+
+	input := new(ImportModelsRequest)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("import models failed, decode body err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if len(input.Format) == 0 {
+		input.Format = schema.FormatYAML
+	}
+	target, err := schema.Unmarshal([]byte(input.Content), input.Format)
+	if err != nil {
+		blog.Errorf("import models failed, decode the %s content err: %v, rid: %s", input.Format, err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "content")})
+		return
+	}
+
+	plan, err := schema.BuildPlan(s.ctx, s.db, ownerID, target)
+	if err != nil {
+		blog.Errorf("import models failed, build plan err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommMigrateFailed, err.Error())})
+		return
+	}
+	if !input.DryRun {
+		if err := schema.Apply(s.ctx, s.db, ownerID, util.GetUser(rHeader), plan); err != nil {
+			blog.Errorf("import models failed, apply err: %v, rid: %s", err, rid)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommMigrateFailed, err.Error())})
+			return
+		}
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(plan))
+}
+
+func modelOwnerID(header http.Header) string {
+	if ownerID := util.GetOwnerID(header); len(ownerID) != 0 {
+		return ownerID
+	}
+	return common.BKDefaultOwnerID
+}
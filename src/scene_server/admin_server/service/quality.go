@@ -0,0 +1,232 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/errors"
+	"icenter/src/common/metadata"
+	"icenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// qualityEnabled writes the error if the data quality checks are disabled
+func (s *Service) qualityEnabled(resp *restful.Response, defErr errors.DefaultCCErrorIf) bool {
+	if s.checker == nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommNotFound, "quality")})
+		return false
+	}
+	return true
+}
+
+// searchQualityRules returns the data quality rules, of the model if the bk_obj_id query parameter is set
+func (s *Service) searchQualityRules(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	if !s.qualityEnabled(resp, defErr) {
+		return
+	}
+
+	rules, err := s.checker.SearchRules(s.ctx, util.GetOwnerID(rHeader), req.QueryParameter(common.BKObjIDField))
+	if err != nil {
+		blog.Errorf("search the quality rules failed, err: %v, rid: %s", err, util.GetHTTPCCRequestID(rHeader))
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(rules))
+}
+
+// createQualityRule validate and save a new data quality rule, it's checked in the next round.
+func (s *Service) createQualityRule(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	rid := util.GetHTTPCCRequestID(rHeader)
+	if !s.qualityEnabled(resp, defErr) {
+		return
+	}
+
+	input := metadata.QualityRule{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("create quality rule failed, decode body err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	rule, err := s.checker.CreateRule(s.ctx, util.GetOwnerID(rHeader), util.GetUser(rHeader), input)
+	if err != nil {
+		blog.Errorf("create quality rule failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(rule))
+}
+
+// updateQualityRule validate and replace the data quality rule
+func (s *Service) updateQualityRule(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	rid := util.GetHTTPCCRequestID(rHeader)
+	if !s.qualityEnabled(resp, defErr) {
+		return
+	}
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "id")})
+		return
+	}
+	input := metadata.QualityRule{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("update quality rule %d failed, decode body err: %v, rid: %s", id, err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	rule, err := s.checker.UpdateRule(s.ctx, util.GetOwnerID(rHeader), id, input)
+	if err != nil {
+		blog.Errorf("update quality rule %d failed, err: %v, rid: %s", id, err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(rule))
+}
+
+// deleteQualityRule delete the data quality rule with its violations
+func (s *Service) deleteQualityRule(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	if !s.qualityEnabled(resp, defErr) {
+		return
+	}
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "id")})
+		return
+	}
+	if err := s.checker.DeleteRule(s.ctx, util.GetOwnerID(rHeader), id); err != nil {
+		blog.Errorf("delete quality rule %d failed, err: %v, rid: %s", id, err, util.GetHTTPCCRequestID(rHeader))
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// checkQuality checks all the rules at once instead of waiting for the next round
+func (s *Service) checkQuality(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	if !s.qualityEnabled(resp, defErr) {
+		return
+	}
+
+	result, err := s.checker.Check(s.ctx)
+	if err != nil {
+		blog.Errorf("check the quality rules failed, err: %v, rid: %s", err, util.GetHTTPCCRequestID(rHeader))
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// searchQualityViolations returns the violations of the rules
+func (s *Service) searchQualityViolations(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	rid := util.GetHTTPCCRequestID(rHeader)
+	if !s.qualityEnabled(resp, defErr) {
+		return
+	}
+
+	input := metadata.SearchQualityViolationsRequest{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("search quality violations failed, decode body err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	result, err := s.checker.SearchViolations(s.ctx, util.GetOwnerID(rHeader), input)
+	if err != nil {
+		blog.Errorf("search quality violations failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// acknowledgeQualityViolations mark the open violations as acknowledged
+func (s *Service) acknowledgeQualityViolations(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	rid := util.GetHTTPCCRequestID(rHeader)
+	if !s.qualityEnabled(resp, defErr) {
+		return
+	}
+
+	input := metadata.QualityViolationIDs{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("acknowledge quality violations failed, decode body err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if err := s.checker.Acknowledge(s.ctx, util.GetOwnerID(rHeader), util.GetUser(rHeader), input.IDs); err != nil {
+		blog.Errorf("acknowledge quality violations %v failed, err: %v, rid: %s", input.IDs, err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// fixQualityViolations fix the violations through the instance apis of the coreservice
+func (s *Service) fixQualityViolations(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	rid := util.GetHTTPCCRequestID(rHeader)
+	if !s.qualityEnabled(resp, defErr) {
+		return
+	}
+
+	input := metadata.QualityViolationIDs{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("fix quality violations failed, decode body err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	results, err := s.checker.Fix(s.ctx, rHeader, util.GetOwnerID(rHeader), input.IDs)
+	if err != nil {
+		blog.Errorf("fix quality violations %v failed, err: %v, rid: %s", input.IDs, err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(results))
+}
+
+// qualityScores returns the data quality scores of the businesses
+func (s *Service) qualityScores(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	if !s.qualityEnabled(resp, defErr) {
+		return
+	}
+
+	scores, err := s.checker.Scores(s.ctx, util.GetOwnerID(rHeader))
+	if err != nil {
+		blog.Errorf("get the quality scores failed, err: %v, rid: %s", err, util.GetHTTPCCRequestID(rHeader))
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(scores))
+}
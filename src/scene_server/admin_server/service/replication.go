@@ -0,0 +1,52 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"net/http"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/metadata"
+	"icenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// replicationStatus returns the replication status of the targets
+func (s *Service) replicationStatus(req *restful.Request, resp *restful.Response) {
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+	if s.replicator == nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommNotFound, "replication")})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(s.replicator.Status()))
+}
+
+// replicationFullSync request a full synchronization of the target, it runs in the next replication round.
+func (s *Service) replicationFullSync(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	if s.replicator == nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommNotFound, "replication")})
+		return
+	}
+
+	target := req.PathParameter("target")
+	if err := s.replicator.FullSync(target); err != nil {
+		blog.Errorf("request full synchronization failed, err: %v, rid: %s", err, util.GetHTTPCCRequestID(rHeader))
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "target")})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
@@ -25,6 +25,9 @@ import (
 	"icenter/src/common/storage/dal"
 	"icenter/src/common/types"
 	"icenter/src/scene_server/admin_server/app/options"
+	"icenter/src/scene_server/admin_server/configures"
+	"icenter/src/scene_server/admin_server/quality"
+	"icenter/src/scene_server/admin_server/replicator"
 
 	"github.com/emicklei/go-restful"
 )
@@ -36,6 +39,9 @@ type Service struct {
 	ctx          context.Context
 	Config       options.Config
 	authCenter   *authcenter.AuthCenter
+	replicator   *replicator.Replicator
+	confCenter   *configures.ConfCenter
+	checker      *quality.Checker
 }
 
 func NewService(ctx context.Context) *Service {
@@ -52,6 +58,18 @@ func (s *Service) SetAuthcenter(authCenter *authcenter.AuthCenter) {
 	s.authCenter = authCenter
 }
 
+func (s *Service) SetReplicator(r *replicator.Replicator) {
+	s.replicator = r
+}
+
+func (s *Service) SetQualityChecker(checker *quality.Checker) {
+	s.checker = checker
+}
+
+func (s *Service) SetConfCenter(confCenter *configures.ConfCenter) {
+	s.confCenter = confCenter
+}
+
 func (s *Service) SetApiSrvAddr(ccApiSrvAddr string) {
 	s.ccApiSrvAddr = ccApiSrvAddr
 }
@@ -67,8 +85,30 @@ func (s *Service) WebService() *restful.Container {
 
 	api.Route(api.POST("/authcenter/init").To(s.InitAuthCenter))
 	api.Route(api.POST("/migrate/{distribution}/{ownerID}").To(s.migrate))
+	api.Route(api.POST("/migrate/plan").To(s.planMigration))
+	api.Route(api.POST("/migrate/rollback").To(s.rollbackMigration))
+	api.Route(api.GET("/migrate/versions").To(s.migrationVersions))
+	api.Route(api.GET("/migrate/history").To(s.migrationHistory))
 	api.Route(api.POST("/migrate/system/hostcrossbiz/{ownerID}").To(s.SetSystemConfiguration))
 	api.Route(api.POST("/clear").To(s.clear))
+	api.Route(api.GET("/model/export").To(s.exportModels))
+	api.Route(api.POST("/model/import").To(s.importModels))
+	api.Route(api.GET("/replication/status").To(s.replicationStatus))
+	api.Route(api.POST("/replication/fullsync/{target}").To(s.replicationFullSync))
+	api.Route(api.GET("/language/{kind}").To(s.languagePacks))
+	api.Route(api.GET("/language/{kind}/{language}").To(s.languagePack))
+	api.Route(api.GET("/language/{kind}/{language}/versions").To(s.languagePackVersions))
+	api.Route(api.POST("/language/{kind}/{language}").To(s.saveLanguagePack))
+	api.Route(api.POST("/language/{kind}/{language}/activate/{version}").To(s.activateLanguagePack))
+	api.Route(api.GET("/quality/rules").To(s.searchQualityRules))
+	api.Route(api.POST("/quality/rule").To(s.createQualityRule))
+	api.Route(api.PUT("/quality/rule/{id}").To(s.updateQualityRule))
+	api.Route(api.DELETE("/quality/rule/{id}").To(s.deleteQualityRule))
+	api.Route(api.POST("/quality/check").To(s.checkQuality))
+	api.Route(api.POST("/quality/violations/search").To(s.searchQualityViolations))
+	api.Route(api.POST("/quality/violations/acknowledge").To(s.acknowledgeQualityViolations))
+	api.Route(api.POST("/quality/violations/fix").To(s.fixQualityViolations))
+	api.Route(api.GET("/quality/scores").To(s.qualityScores))
 	api.Route(api.GET("/healthz").To(s.Healthz))
 
 	container.Add(api)
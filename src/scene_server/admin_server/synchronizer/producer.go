@@ -60,12 +60,8 @@ func (p *Producer) Start() {
 			}
 
 			if finished == false {
-				// get jobs
-				jobs := producer.generateJobs()
-
-				for _, job := range *jobs {
-					p.WorkerQueue <- job
-				}
+				// the jobs are enqueued while the businesses are listed page by page
+				producer.produceJobs()
 				finished = true
 			}
 			time.Sleep(time.Millisecond * 100)
@@ -73,69 +69,40 @@ func (p *Producer) Start() {
 	}(p)
 }
 
-func (p *Producer) generateJobs() *[]meta.WorkRequest {
-	// split all jobs
-	jobs := make([]meta.WorkRequest, 0)
+// listBusinessPageSize the businesses count listed in one page
+const listBusinessPageSize = 200
 
-	businessSyncJob := meta.WorkRequest{
+// produceJobs enqueue the jobs of all the businesses, the businesses are listed page by page,
+// so that neither the businesses nor their jobs are held at once.
+func (p *Producer) produceJobs() {
+	p.WorkerQueue <- meta.WorkRequest{
 		ResourceType: meta.BusinessResource,
 		Data:         map[string]interface{}{},
 	}
-	jobs = append(jobs, businessSyncJob)
 
-	// list all business
 	header := utils.NewListBusinessAPIHeader()
-	condition := metadata.QueryCondition{}
-	result, err := p.clientSet.CoreService().Instance().ReadInstance(context.TODO(), *header, common.BKInnerObjIDApp, &condition)
-	if err != nil {
-		blog.Errorf("list business failed, err: %v", err)
-		return &jobs
-	}
-
-	businessList := make([]extensions.BusinessSimplify, 0)
-	for _, business := range result.Data.Info {
-		businessSimplify := extensions.BusinessSimplify{}
-		if _, err := businessSimplify.Parse(business); err != nil {
-			blog.Errorf("parse business %+v simplify information failed, err: %+v", business, err)
-			continue
+	for start := int64(0); ; start += listBusinessPageSize {
+		condition := metadata.QueryCondition{
+			Limit:   metadata.SearchLimit{Offset: start, Limit: listBusinessPageSize},
+			SortArr: []metadata.SearchSort{{Field: common.BKAppIDField}},
 		}
-		businessList = append(businessList, businessSimplify)
-	}
-	blog.Info("list business businessList: %+v", businessList)
-
-	// job of synchronize business scope resources to iam
-	resourceTypes := []meta.ResourceType{
-		meta.HostResource,
-		meta.SetResource,
-		meta.ModuleResource,
-		meta.ModelResource,
-		meta.ProcessResource,
-		meta.DynamicGroupResource,
-		meta.AuditCategory,
-		meta.ClassificationResource,
-	}
-	for _, resourceType := range resourceTypes {
-		for _, businessSimplify := range businessList {
-			jobs = append(jobs, meta.WorkRequest{
-				ResourceType: resourceType,
-				Data:         businessSimplify,
-			})
+		result, err := p.clientSet.CoreService().Instance().ReadInstance(context.TODO(), *header, common.BKInnerObjIDApp, &condition)
+		if err != nil {
+			blog.Errorf("list business from %d failed, err: %v", start, err)
+			break
 		}
-	}
 
-	for _, business := range businessList {
-		header := utils.NewListBusinessAPIHeader()
-		objects, err := p.authManager.CollectObjectsByBusinessID(context.Background(), *header, business.BKAppIDField)
-		if err != nil {
-			blog.Errorf("get models by business id: %d failed, err: %+v", business.BKAppIDField, err)
-			continue
+		for _, business := range result.Data.Info {
+			businessSimplify := extensions.BusinessSimplify{}
+			if _, err := businessSimplify.Parse(business); err != nil {
+				blog.Errorf("parse business %+v simplify information failed, err: %+v", business, err)
+				continue
+			}
+			blog.V(5).Infof("produce the jobs of business %+v", businessSimplify)
+			p.produceBusinessJobs(businessSimplify)
 		}
-		for _, object := range objects {
-			jobs = append(jobs, meta.WorkRequest{
-				ResourceType: meta.InstanceResource,
-				Data:         object,
-				Header:       *header,
-			})
+		if len(result.Data.Info) < listBusinessPageSize {
+			break
 		}
 	}
 
@@ -147,15 +114,48 @@ func (p *Producer) generateJobs() *[]meta.WorkRequest {
 		BKOwnerIDField:    "",
 		IsDefault:         0,
 	}
-	resourceTypes = []meta.ResourceType{
+	resourceTypes := []meta.ResourceType{
 		meta.AuditCategory,
 		meta.ClassificationResource,
 	}
 	for _, resourceType := range resourceTypes {
-		jobs = append(jobs, meta.WorkRequest{
+		p.WorkerQueue <- meta.WorkRequest{
 			ResourceType: resourceType,
 			Data:         globalBusiness,
-		})
+		}
+	}
+}
+
+// produceBusinessJobs enqueue the jobs of the business scope resources and the instances of the business models
+func (p *Producer) produceBusinessJobs(business extensions.BusinessSimplify) {
+	resourceTypes := []meta.ResourceType{
+		meta.HostResource,
+		meta.SetResource,
+		meta.ModuleResource,
+		meta.ModelResource,
+		meta.ProcessResource,
+		meta.DynamicGroupResource,
+		meta.AuditCategory,
+		meta.ClassificationResource,
+	}
+	for _, resourceType := range resourceTypes {
+		p.WorkerQueue <- meta.WorkRequest{
+			ResourceType: resourceType,
+			Data:         business,
+		}
+	}
+
+	header := utils.NewListBusinessAPIHeader()
+	objects, err := p.authManager.CollectObjectsByBusinessID(context.Background(), *header, business.BKAppIDField)
+	if err != nil {
+		blog.Errorf("get models by business id: %d failed, err: %+v", business.BKAppIDField, err)
+		return
+	}
+	for _, object := range objects {
+		p.WorkerQueue <- meta.WorkRequest{
+			ResourceType: meta.InstanceResource,
+			Data:         object,
+			Header:       *header,
+		}
 	}
-	return &jobs
 }
@@ -47,6 +47,11 @@ func (d *AuthSynchronizer) Run() error {
 		return nil
 	}
 
+	if d.AuthConfig.Backend == authcenter.BackendLocal {
+		blog.Info("local auth backend does not need to synchronize resources, exit now")
+		return nil
+	}
+
 	blog.Infof("auth synchronize start...")
 
 	// init queue
@@ -54,7 +59,7 @@ func (d *AuthSynchronizer) Run() error {
 
 	// make fake handler
 	blog.Infof("new auth client with config: %+v", d.AuthConfig)
-	authorize, err := auth.NewAuthorize(nil, d.AuthConfig)
+	authorize, err := auth.NewAuthorize(nil, d.AuthConfig, nil)
 	if err != nil {
 		blog.Errorf("new auth client failed, err: %+v", err)
 		return fmt.Errorf("new auth client failed, err: %+v", err)
@@ -15,5 +15,7 @@
 // we use date instead of version later since 2018.09.04, because the version wasn't manage by the developer
 // when use date instead of version, the date should add x prefix, cause x > v
 // example: x08.09.04.01
+// the upgrader registered with RegistReversibleUpgrader has a down step, so that the version can be rolled back,
+// every step run is recorded in the migration history table.
 
 package upgrader
@@ -0,0 +1,273 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upgrader
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/types"
+)
+
+// the operations recorded by the dry run db
+const (
+	OperationInsert       = "insert"
+	OperationUpdate       = "update"
+	OperationUpsert       = "upsert"
+	OperationDelete       = "delete"
+	OperationCreateTable  = "create_table"
+	OperationDropTable    = "drop_table"
+	OperationCreateIndex  = "create_index"
+	OperationDropIndex    = "drop_index"
+	OperationAddColumn    = "add_column"
+	OperationRenameColumn = "rename_column"
+	OperationDropColumn   = "drop_column"
+)
+
+// recorder collects the changes of a dry run, the changes of the same operation on a table are merged.
+type recorder struct {
+	lock    sync.Mutex
+	changes []Change
+}
+
+func (r *recorder) record(table, operation string, count uint64, detail string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for index := range r.changes {
+		change := &r.changes[index]
+		if change.Table == table && change.Operation == operation {
+			change.Count += count
+			if len(detail) != 0 {
+				change.Details = append(change.Details, detail)
+			}
+			return
+		}
+	}
+	change := Change{Table: table, Operation: operation, Count: count}
+	if len(detail) != 0 {
+		change.Details = []string{detail}
+	}
+	r.changes = append(r.changes, change)
+}
+
+// dryRunDB reads from the db, and records the writes instead of doing them.
+type dryRunDB struct {
+	db       dal.RDB
+	recorder *recorder
+	lock     sync.Mutex
+	// sequences the fake sequences, the real ones are not consumed by the dry run.
+	sequences map[string]uint64
+}
+
+var _ dal.DB = (*dryRunDB)(nil)
+
+func newDryRunDB(db dal.RDB) *dryRunDB {
+	return &dryRunDB{
+		db:        db,
+		recorder:  &recorder{changes: make([]Change, 0)},
+		sequences: make(map[string]uint64),
+	}
+}
+
+func (d *dryRunDB) Clone() dal.DB {
+	return d
+}
+
+func (d *dryRunDB) Table(collection string) dal.Table {
+	return &dryRunTable{name: collection, table: d.db.Table(collection), recorder: d.recorder}
+}
+
+func (d *dryRunDB) StartTransaction(ctx context.Context) (dal.DB, error) {
+	return d, nil
+}
+
+func (d *dryRunDB) Commit(context.Context) error {
+	return nil
+}
+
+func (d *dryRunDB) Abort(context.Context) error {
+	return nil
+}
+
+func (d *dryRunDB) TxnInfo() *types.Transaction {
+	return nil
+}
+
+func (d *dryRunDB) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.sequences[sequenceName]++
+	return d.sequences[sequenceName], nil
+}
+
+func (d *dryRunDB) Ping() error {
+	return d.db.Ping()
+}
+
+func (d *dryRunDB) HasTable(tablename string) (bool, error) {
+	return d.db.HasTable(tablename)
+}
+
+func (d *dryRunDB) DropTable(tablename string) error {
+	count, err := d.db.Table(tablename).Find(nil).Count(context.Background())
+	if err != nil {
+		return err
+	}
+	d.recorder.record(tablename, OperationDropTable, count, "")
+	return nil
+}
+
+func (d *dryRunDB) CreateTable(tablename string) error {
+	d.recorder.record(tablename, OperationCreateTable, 0, "")
+	return nil
+}
+
+func (d *dryRunDB) IsDuplicatedError(err error) bool {
+	return d.db.IsDuplicatedError(err)
+}
+
+func (d *dryRunDB) IsNotFoundError(err error) bool {
+	return d.db.IsNotFoundError(err)
+}
+
+func (d *dryRunDB) Close() error {
+	return nil
+}
+
+type dryRunTable struct {
+	name     string
+	table    dal.Table
+	recorder *recorder
+}
+
+func (t *dryRunTable) Find(filter dal.Filter) dal.Find {
+	return t.table.Find(filter)
+}
+
+func (t *dryRunTable) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
+	return t.table.AggregateOne(ctx, pipeline, result)
+}
+
+func (t *dryRunTable) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error {
+	return t.table.AggregateAll(ctx, pipeline, result)
+}
+
+func (t *dryRunTable) Insert(ctx context.Context, docs interface{}) error {
+	count := uint64(1)
+	if value := reflect.ValueOf(docs); value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+		count = uint64(value.Len())
+	}
+	t.recorder.record(t.name, OperationInsert, count, "")
+	return nil
+}
+
+func (t *dryRunTable) Update(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	return t.recordMatched(ctx, filter, OperationUpdate, "")
+}
+
+func (t *dryRunTable) Delete(ctx context.Context, filter dal.Filter) error {
+	return t.recordMatched(ctx, filter, OperationDelete, "")
+}
+
+func (t *dryRunTable) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	return t.recordUpsert(ctx, filter)
+}
+
+// FindOneAndUpdate returns the matched document before updated, it's not changed by the dry run
+func (t *dryRunTable) FindOneAndUpdate(ctx context.Context, filter dal.Filter, doc interface{}, opts dal.FindOneAndUpdateOptions, result interface{}) error {
+	count, err := t.table.Find(filter).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count == 0 && opts.Upsert {
+		t.recorder.record(t.name, OperationUpsert, 1, "")
+		return nil
+	}
+	if err := t.table.Find(filter).One(ctx, result); err != nil {
+		return err
+	}
+	t.recorder.record(t.name, OperationUpdate, 1, "")
+	return nil
+}
+
+func (t *dryRunTable) BulkWrite(ctx context.Context, models []dal.WriteModel, opts dal.BulkWriteOptions) (*dal.BulkWriteResult, error) {
+	for _, model := range models {
+		var err error
+		switch model.Kind {
+		case dal.WriteKindInsert:
+			err = t.Insert(ctx, model.Doc)
+		case dal.WriteKindUpdate:
+			err = t.Update(ctx, model.Filter, model.Doc)
+		case dal.WriteKindUpsert:
+			err = t.recordUpsert(ctx, model.Filter)
+		case dal.WriteKindDelete:
+			err = t.Delete(ctx, model.Filter)
+		}
+		if err != nil {
+			return &dal.BulkWriteResult{}, err
+		}
+	}
+	return &dal.BulkWriteResult{}, nil
+}
+
+// recordUpsert record an update if the filter matches a document, otherwise an upsert
+func (t *dryRunTable) recordUpsert(ctx context.Context, filter dal.Filter) error {
+	count, err := t.table.Find(filter).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		t.recorder.record(t.name, OperationUpdate, 1, "")
+	} else {
+		t.recorder.record(t.name, OperationUpsert, 1, "")
+	}
+	return nil
+}
+
+func (t *dryRunTable) CreateIndex(ctx context.Context, index dal.Index) error {
+	t.recorder.record(t.name, OperationCreateIndex, 0, index.Name)
+	return nil
+}
+
+func (t *dryRunTable) DropIndex(ctx context.Context, indexName string) error {
+	t.recorder.record(t.name, OperationDropIndex, 0, indexName)
+	return nil
+}
+
+func (t *dryRunTable) Indexes(ctx context.Context) ([]dal.Index, error) {
+	return t.table.Indexes(ctx)
+}
+
+func (t *dryRunTable) AddColumn(ctx context.Context, column string, value interface{}) error {
+	return t.recordMatched(ctx, nil, OperationAddColumn, column)
+}
+
+func (t *dryRunTable) RenameColumn(ctx context.Context, oldName, newColumn string) error {
+	return t.recordMatched(ctx, nil, OperationRenameColumn, oldName+" => "+newColumn)
+}
+
+func (t *dryRunTable) DropColumn(ctx context.Context, field string) error {
+	return t.recordMatched(ctx, nil, OperationDropColumn, field)
+}
+
+// recordMatched record the operation with the count of the documents matching the filter
+func (t *dryRunTable) recordMatched(ctx context.Context, filter dal.Filter, operation, detail string) error {
+	count, err := t.table.Find(filter).Count(ctx)
+	if err != nil {
+		return err
+	}
+	t.recorder.record(t.name, operation, count, detail)
+	return nil
+}
@@ -0,0 +1,148 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upgrader
+
+import (
+	"context"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/storage/dal"
+)
+
+// the directions of a migration step
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+)
+
+// the status of a migration step
+const (
+	StepStatusRunning = "running"
+	StepStatusSuccess = "success"
+	StepStatusFailed  = "failed"
+)
+
+// MigrationHistory the record of a migration step run
+type MigrationHistory struct {
+	ID        uint64    `json:"id" bson:"id"`
+	Version   string    `json:"version" bson:"version"`
+	Direction string    `json:"direction" bson:"direction"`
+	Status    string    `json:"status" bson:"status"`
+	Error     string    `json:"error" bson:"error"`
+	StartTime time.Time `json:"start_time" bson:"start_time"`
+	EndTime   time.Time `json:"end_time" bson:"end_time"`
+}
+
+// MigrationPlan the steps to migrate from the current version to the target version
+type MigrationPlan struct {
+	CurrentVersion string     `json:"current_version"`
+	TargetVersion  string     `json:"target_version"`
+	Steps          []StepPlan `json:"steps"`
+}
+
+// StepPlan the changes of a migration step, the error is set if the step fails.
+type StepPlan struct {
+	Version   string   `json:"version"`
+	Direction string   `json:"direction"`
+	Changes   []Change `json:"changes,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Change the operations of a kind on a collection, the count is the affected documents count.
+type Change struct {
+	Table     string   `json:"table"`
+	Operation string   `json:"operation"`
+	Count     uint64   `json:"count"`
+	Details   []string `json:"details,omitempty"`
+}
+
+// VersionStatus the applied and pending versions
+type VersionStatus struct {
+	CurrentVersion string        `json:"current_version"`
+	Applied        []VersionInfo `json:"applied"`
+	Pending        []VersionInfo `json:"pending"`
+}
+
+// VersionInfo a registered version, it can be rolled back if it's reversible.
+type VersionInfo struct {
+	Version    string `json:"version"`
+	Reversible bool   `json:"reversible"`
+}
+
+// runStep run the migration step, and record the run in the history
+func runStep(ctx context.Context, db dal.RDB, conf *Config, version, direction string, step func(context.Context, dal.RDB, *Config) error) error {
+	id, err := db.NextSequence(ctx, common.BKTableNameMigrationHistory)
+	if err != nil {
+		return err
+	}
+	history := MigrationHistory{
+		ID:        id,
+		Version:   version,
+		Direction: direction,
+		Status:    StepStatusRunning,
+		StartTime: time.Now(),
+	}
+	if err := db.Table(common.BKTableNameMigrationHistory).Insert(ctx, history); err != nil {
+		return err
+	}
+
+	stepErr := step(ctx, db, conf)
+	history.Status = StepStatusSuccess
+	if stepErr != nil {
+		history.Status = StepStatusFailed
+		history.Error = stepErr.Error()
+	}
+	history.EndTime = time.Now()
+	if err := db.Table(common.BKTableNameMigrationHistory).Update(ctx, map[string]interface{}{"id": id}, history); err != nil {
+		blog.Errorf("save the migration history of version %s failed, err: %v", version, err)
+		if stepErr == nil {
+			return err
+		}
+	}
+	return stepErr
+}
+
+// dryRunStep run the migration step on a dry run db, and returns the changes it would make
+func dryRunStep(ctx context.Context, db dal.RDB, conf *Config, version, direction string, step func(context.Context, dal.RDB, *Config) error) StepPlan {
+	dryRun := newDryRunDB(db)
+	plan := StepPlan{Version: version, Direction: direction}
+	if err := step(ctx, dryRun, conf); err != nil {
+		plan.Error = err.Error()
+	}
+	plan.Changes = dryRun.recorder.changes
+	return plan
+}
+
+// ListHistory returns the migration history from the newest one, all the versions are returned if the version is empty.
+func ListHistory(ctx context.Context, db dal.RDB, version string, start, limit uint64) ([]MigrationHistory, uint64, error) {
+	cond := map[string]interface{}{}
+	if len(version) != 0 {
+		cond["version"] = version
+	}
+	table := db.Table(common.BKTableNameMigrationHistory)
+	count, err := table.Find(cond).Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	histories := make([]MigrationHistory, 0)
+	find := table.Find(cond).Sort("-id").Start(start)
+	if limit > 0 {
+		find = find.Limit(limit)
+	}
+	if err := find.All(ctx, &histories); err != nil {
+		return nil, 0, err
+	}
+	return histories, count, nil
+}
@@ -14,6 +14,7 @@ package upgrader
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"sync"
 
@@ -35,6 +36,8 @@ type Config struct {
 type Upgrader struct {
 	version string // v3.0.8-beta.11
 	do      func(context.Context, dal.RDB, *Config) error
+	// down revert the changes of do, the version can't be rolled back if it's nil.
+	down func(context.Context, dal.RDB, *Config) error
 }
 
 var upgraderPool = []Upgrader{}
@@ -43,21 +46,38 @@ var registLock sync.Mutex
 
 // RegistUpgrader register upgrader
 func RegistUpgrader(version string, handlerFunc func(context.Context, dal.RDB, *Config) error) {
+	regist(Upgrader{version: version, do: handlerFunc})
+}
+
+// RegistReversibleUpgrader register upgrader with the down step which reverts its changes,
+// so that the version can be rolled back.
+func RegistReversibleUpgrader(version string, handlerFunc, downFunc func(context.Context, dal.RDB, *Config) error) {
+	regist(Upgrader{version: version, do: handlerFunc, down: downFunc})
+}
+
+func regist(v Upgrader) {
 	registLock.Lock()
 	defer registLock.Unlock()
-	v := Upgrader{version: version, do: handlerFunc}
 	upgraderPool = append(upgraderPool, v)
 	blog.Infof("registed upgrader for version: %s", v.version)
 }
 
+// sortedUpgraders returns the registered upgraders in version order
+func sortedUpgraders() []Upgrader {
+	registLock.Lock()
+	defer registLock.Unlock()
+	sort.Slice(upgraderPool, func(i, j int) bool {
+		return upgraderPool[i].version < upgraderPool[j].version
+	})
+	return append([]Upgrader{}, upgraderPool...)
+}
+
 // Upgrade upgrade the db data to newest version
 // we use date instead of version later since 2018.09.04, because the version wasn't manage by the developer
 // ps: when use date instead of version, the date should add x prefix cause x > v
 func Upgrade(ctx context.Context, db dal.RDB, conf *Config) (err error) {
 
-	sort.Slice(upgraderPool, func(i, j int) bool {
-		return upgraderPool[i].version < upgraderPool[j].version
-	})
+	upgraders := sortedUpgraders()
 
 	cmdbVersion, err := getVersion(ctx, db)
 	if err != nil {
@@ -68,13 +88,13 @@ func Upgrade(ctx context.Context, db dal.RDB, conf *Config) (err error) {
 
 	currentVision := remapVersion(cmdbVersion.CurrentVersion)
 	lastVersion := ""
-	for _, v := range upgraderPool {
+	for _, v := range upgraders {
 		lastVersion = remapVersion(v.version)
 		if v.version <= currentVision {
 			blog.Infof(`currentVision is "%s" skip upgrade "%s"`, currentVision, v.version)
 			continue
 		}
-		err = v.do(ctx, db, conf)
+		err = runStep(ctx, db, conf, v.version, DirectionUp, v.do)
 		if err != nil {
 			blog.Errorf("upgrade version %s error: %s", v.version, err.Error())
 			return err
@@ -95,6 +115,111 @@ func Upgrade(ctx context.Context, db dal.RDB, conf *Config) (err error) {
 	return nil
 }
 
+// Plan run the pending upgraders on a dry run db, and returns the changes they would make, nothing is changed.
+// every upgrader sees the db as it is now, the changes of the previous pending upgraders are not visible to it.
+func Plan(ctx context.Context, db dal.RDB, conf *Config) (*MigrationPlan, error) {
+	cmdbVersion, err := getVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	currentVersion := remapVersion(cmdbVersion.CurrentVersion)
+
+	plan := &MigrationPlan{CurrentVersion: currentVersion, Steps: make([]StepPlan, 0)}
+	for _, v := range sortedUpgraders() {
+		if v.version <= currentVersion {
+			continue
+		}
+		plan.Steps = append(plan.Steps, dryRunStep(ctx, db, conf, v.version, DirectionUp, v.do))
+		plan.TargetVersion = v.version
+	}
+	return plan, nil
+}
+
+// Rollback run the down steps of the applied versions newer than the target version from the newest one,
+// the current version is set to the version before every step after the step is done. all the versions
+// to roll back should be reversible. if dryRun is true, the down steps run on a dry run db, nothing is changed.
+func Rollback(ctx context.Context, db dal.RDB, conf *Config, target string, dryRun bool) (*MigrationPlan, error) {
+	upgraders := sortedUpgraders()
+
+	cmdbVersion, err := getVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	currentVersion := remapVersion(cmdbVersion.CurrentVersion)
+	if target >= currentVersion {
+		return nil, fmt.Errorf("the target version %s is not older than the current version %s", target, currentVersion)
+	}
+
+	// the version that every step rolls back to is the one registered before it.
+	steps := make([]Upgrader, 0)
+	previous := make(map[string]string)
+	found := false
+	for index, v := range upgraders {
+		if v.version == target {
+			found = true
+		}
+		if v.version <= target || v.version > currentVersion {
+			continue
+		}
+		if v.down == nil {
+			return nil, fmt.Errorf("the version %s can not be rolled back", v.version)
+		}
+		if index > 0 {
+			previous[v.version] = upgraders[index-1].version
+		}
+		steps = append([]Upgrader{v}, steps...)
+	}
+	if !found {
+		return nil, fmt.Errorf("the target version %s is not registered", target)
+	}
+
+	plan := &MigrationPlan{CurrentVersion: currentVersion, TargetVersion: target, Steps: make([]StepPlan, 0)}
+	for _, v := range steps {
+		if dryRun {
+			plan.Steps = append(plan.Steps, dryRunStep(ctx, db, conf, v.version, DirectionDown, v.down))
+			continue
+		}
+
+		if err := runStep(ctx, db, conf, v.version, DirectionDown, v.down); err != nil {
+			blog.Errorf("roll back version %s error: %s", v.version, err.Error())
+			plan.Steps = append(plan.Steps, StepPlan{Version: v.version, Direction: DirectionDown, Error: err.Error()})
+			return plan, err
+		}
+		cmdbVersion.CurrentVersion = previous[v.version]
+		if err := saveVersion(ctx, db, cmdbVersion); err != nil {
+			blog.Errorf("save version %s error: %s", cmdbVersion.CurrentVersion, err.Error())
+			return plan, err
+		}
+		plan.Steps = append(plan.Steps, StepPlan{Version: v.version, Direction: DirectionDown})
+		blog.Infof("roll back version %s success", v.version)
+	}
+	return plan, nil
+}
+
+// Versions returns the applied and pending versions of the registered upgraders
+func Versions(ctx context.Context, db dal.RDB) (*VersionStatus, error) {
+	cmdbVersion, err := getVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	currentVersion := remapVersion(cmdbVersion.CurrentVersion)
+
+	status := &VersionStatus{
+		CurrentVersion: currentVersion,
+		Applied:        make([]VersionInfo, 0),
+		Pending:        make([]VersionInfo, 0),
+	}
+	for _, v := range sortedUpgraders() {
+		info := VersionInfo{Version: v.version, Reversible: v.down != nil}
+		if v.version <= currentVersion {
+			status.Applied = append(status.Applied, info)
+		} else {
+			status.Pending = append(status.Pending, info)
+		}
+	}
+	return status, nil
+}
+
 func remapVersion(v string) string {
 	if correct, ok := wrongVersion[v]; ok {
 		return correct
@@ -0,0 +1,138 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upgrader
+
+import (
+	"context"
+	"testing"
+
+	"icenter/src/common"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/dal/memory"
+)
+
+const testTable = "cc_UpgraderTest"
+
+func insertStep(name string) func(context.Context, dal.RDB, *Config) error {
+	return func(ctx context.Context, db dal.RDB, conf *Config) error {
+		docs := []map[string]interface{}{{"name": name, "index": 1}, {"name": name, "index": 2}}
+		return db.Table(testTable).Insert(ctx, docs)
+	}
+}
+
+func deleteStep(name string) func(context.Context, dal.RDB, *Config) error {
+	return func(ctx context.Context, db dal.RDB, conf *Config) error {
+		return db.Table(testTable).Delete(ctx, map[string]interface{}{"name": name})
+	}
+}
+
+func countDocs(t *testing.T, db dal.RDB, name string) uint64 {
+	count, err := db.Table(testTable).Find(map[string]interface{}{"name": name}).Count(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestMigration(t *testing.T) {
+	RegistReversibleUpgrader("x99.01.01.01", insertStep("a"), deleteStep("a"))
+	RegistUpgrader("x99.01.02.01", insertStep("b"))
+	RegistReversibleUpgrader("x99.01.03.01", insertStep("c"), deleteStep("c"))
+	RegistReversibleUpgrader("x99.01.04.01", insertStep("d"), deleteStep("d"))
+
+	ctx := context.Background()
+	db := memory.NewMemory()
+	conf := &Config{OwnerID: common.BKDefaultOwnerID}
+
+	plan, err := Plan(ctx, db, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Steps) != 4 || plan.TargetVersion != "x99.01.04.01" {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+	change := plan.Steps[0].Changes[0]
+	if change.Table != testTable || change.Operation != OperationInsert || change.Count != 2 {
+		t.Errorf("unexpected change %+v", change)
+	}
+	if countDocs(t, db, "a") != 0 {
+		t.Fatal("the plan should not change the db")
+	}
+
+	if err := Upgrade(ctx, db, conf); err != nil {
+		t.Fatal(err)
+	}
+	status, err := Versions(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.CurrentVersion != "x99.01.04.01" || len(status.Applied) != 4 || len(status.Pending) != 0 {
+		t.Fatalf("unexpected versions %+v", status)
+	}
+	if status.Applied[1].Reversible || !status.Applied[2].Reversible {
+		t.Errorf("unexpected reversible flags %+v", status.Applied)
+	}
+
+	// the irreversible version blocks the rollback before anything is changed.
+	if _, err := Rollback(ctx, db, conf, "x99.01.01.01", false); err == nil {
+		t.Fatal("the irreversible version should not be rolled back")
+	}
+	if _, err := Rollback(ctx, db, conf, "x99.01.02.02", false); err == nil {
+		t.Fatal("the unregistered version should not be the target")
+	}
+
+	plan, err = Rollback(ctx, db, conf, "x99.01.02.01", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Steps) != 2 || plan.Steps[0].Version != "x99.01.04.01" || plan.Steps[0].Direction != DirectionDown {
+		t.Fatalf("unexpected rollback plan %+v", plan)
+	}
+	if change := plan.Steps[1].Changes[0]; change.Operation != OperationDelete || change.Count != 2 {
+		t.Errorf("unexpected change %+v", change)
+	}
+	if countDocs(t, db, "c") != 2 || countDocs(t, db, "d") != 2 {
+		t.Fatal("the dry run rollback should not change the db")
+	}
+
+	if _, err := Rollback(ctx, db, conf, "x99.01.02.01", false); err != nil {
+		t.Fatal(err)
+	}
+	if countDocs(t, db, "c") != 0 || countDocs(t, db, "d") != 0 || countDocs(t, db, "b") != 2 {
+		t.Fatal("the newer versions should be rolled back")
+	}
+	status, err = Versions(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.CurrentVersion != "x99.01.02.01" || len(status.Pending) != 2 {
+		t.Fatalf("unexpected versions after rollback %+v", status)
+	}
+
+	histories, count, err := ListHistory(ctx, db, "", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 6 || histories[0].Version != "x99.01.03.01" || histories[0].Direction != DirectionDown ||
+		histories[0].Status != StepStatusSuccess {
+		t.Fatalf("unexpected history %+v", histories)
+	}
+
+	// the rolled back versions are pending, and upgraded again.
+	if err := Upgrade(ctx, db, conf); err != nil {
+		t.Fatal(err)
+	}
+	if countDocs(t, db, "d") != 2 {
+		t.Fatal("the rolled back version should be upgraded again")
+	}
+}
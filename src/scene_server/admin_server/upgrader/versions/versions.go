@@ -0,0 +1,53 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package versions registers all the upgraders, import it to upgrade the db to the newest version.
+package versions
+
+import (
+	_ "icenter/src/scene_server/admin_server/upgrader/v3.0.8"
+	_ "icenter/src/scene_server/admin_server/upgrader/v3.0.9-beta.1"
+	_ "icenter/src/scene_server/admin_server/upgrader/v3.0.9-beta.3"
+	_ "icenter/src/scene_server/admin_server/upgrader/v3.1.0-alpha.2"
+	_ "icenter/src/scene_server/admin_server/upgrader/x08.09.04.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x08.09.11.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x08.09.13.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x08.09.17.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x08.09.18.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x08.09.26.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x18.09.30.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x18.10.10.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x18.10.30.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x18.10.30.02"
+	_ "icenter/src/scene_server/admin_server/upgrader/x18.11.07.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x18.11.19.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.05.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.12.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.12.02"
+	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.12.03"
+	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.12.04"
+	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.12.05"
+	_ "icenter/src/scene_server/admin_server/upgrader/x18.12.12.06"
+	_ "icenter/src/scene_server/admin_server/upgrader/x19.01.18.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x19.02.15.10"
+	_ "icenter/src/scene_server/admin_server/upgrader/x19.04.16.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x19.04.16.02"
+	_ "icenter/src/scene_server/admin_server/upgrader/x19.04.16.03"
+	_ "icenter/src/scene_server/admin_server/upgrader/x19.05.20.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x19.05.24.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x19.05.27.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x19.05.29.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x19.06.03.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x19.06.05.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x19.06.07.01"
+	_ "icenter/src/scene_server/admin_server/upgrader/x19.06.10.01"
+)
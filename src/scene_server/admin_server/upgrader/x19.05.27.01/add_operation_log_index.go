@@ -0,0 +1,55 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package x19_05_27_01
+
+import (
+	"context"
+
+	"icenter/src/common"
+	"icenter/src/common/storage/dal"
+	"icenter/src/scene_server/admin_server/upgrader"
+)
+
+// addOperationLogIndex add the indexes to search the audit logs to roll back by id or request id
+func addOperationLogIndex(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	for _, index := range indexs {
+		if err := db.Table(common.BKTableNameOperationLog).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropOperationLogIndex drop the indexes added by the upgrade, the ones not exist are skipped.
+func dropOperationLogIndex(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	existing, err := db.Table(common.BKTableNameOperationLog).Indexes(ctx)
+	if err != nil {
+		return err
+	}
+	for _, index := range indexs {
+		for _, exist := range existing {
+			if exist.Name != index.Name {
+				continue
+			}
+			if err := db.Table(common.BKTableNameOperationLog).DropIndex(ctx, index.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var indexs = []dal.Index{
+	{Name: "id_1", Keys: map[string]int32{"id": 1}, Background: true},
+	{Name: "rid_1", Keys: map[string]int32{"rid": 1}, Background: true},
+}
@@ -0,0 +1,43 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package x19_05_27_01
+
+import (
+	"context"
+
+	"icenter/src/common/blog"
+	"icenter/src/common/storage/dal"
+	"icenter/src/scene_server/admin_server/upgrader"
+)
+
+func init() {
+	upgrader.RegistReversibleUpgrader("x19.05.27.01", upgrade, downgrade)
+}
+
+func upgrade(ctx context.Context, db dal.RDB, conf *upgrader.Config) (err error) {
+	err = addOperationLogIndex(ctx, db, conf)
+	if err != nil {
+		blog.Errorf("[upgrade x19.05.27.01] add operation log index error  %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+func downgrade(ctx context.Context, db dal.RDB, conf *upgrader.Config) (err error) {
+	err = dropOperationLogIndex(ctx, db, conf)
+	if err != nil {
+		blog.Errorf("[downgrade x19.05.27.01] drop operation log index error  %s", err.Error())
+		return err
+	}
+	return nil
+}
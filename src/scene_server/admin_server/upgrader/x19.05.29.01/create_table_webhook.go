@@ -0,0 +1,70 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package x19_05_29_01
+
+import (
+	"context"
+
+	"icenter/src/common"
+	"icenter/src/common/storage/dal"
+	"icenter/src/scene_server/admin_server/upgrader"
+)
+
+func createWebhookTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) (err error) {
+	for tablename, indexs := range tables {
+		exists, err := db.HasTable(tablename)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err = db.CreateTable(tablename); err != nil && !db.IsDuplicatedError(err) {
+				return err
+			}
+		}
+		for index := range indexs {
+			if err = db.Table(tablename).CreateIndex(ctx, indexs[index]); err != nil && !db.IsDuplicatedError(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dropWebhookTable drop the tables created by the upgrade, the data in them are dropped too.
+func dropWebhookTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	for tablename := range tables {
+		exists, err := db.HasTable(tablename)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		if err = db.DropTable(tablename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var tables = map[string][]dal.Index{
+	common.BKTableNameWebhookSubscription: []dal.Index{
+		{Name: "id_1", Keys: map[string]int32{"id": 1}, Unique: true, Background: true},
+	},
+
+	common.BKTableNameWebhookDelivery: []dal.Index{
+		{Name: "id_1", Keys: map[string]int32{"id": 1}, Unique: true, Background: true},
+		{Name: "status_1_next_time_1", Keys: map[string]int32{"status": 1, "next_time": 1}, Background: true},
+		{Name: "subscription_id_1", Keys: map[string]int32{"subscription_id": 1}, Background: true},
+	},
+}
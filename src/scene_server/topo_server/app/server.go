@@ -20,12 +20,14 @@ import (
 	"strconv"
 	"time"
 
+	"icenter/src/auth"
 	"icenter/src/auth/authcenter"
 	"icenter/src/auth/extensions"
 	"icenter/src/common"
 	"icenter/src/common/backbone"
 	cc "icenter/src/common/backbone/configcenter"
 	"icenter/src/common/blog"
+	"icenter/src/common/storage/dal/metrics"
 	"icenter/src/common/storage/dal/mongo"
 	"icenter/src/common/storage/dal/mongo/remote"
 	"icenter/src/common/types"
@@ -100,10 +102,12 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 		blog.Errorf("failed to connect the txc server, error info is %v", err)
 		return err
 	}
+	txn = metrics.NewDB(txn)
 
-	authorize, err := authcenter.NewAuthCenter(nil, server.Config.Auth)
+	authorize, err := auth.NewAuthorize(nil, server.Config.Auth, txn)
 	if err != nil {
 		blog.Errorf("it is failed to create a new auth API, err:%s", err.Error())
+		return err
 	}
 
 	authManager := extensions.NewAuthManager(engine.CoreAPI, authorize)
@@ -111,7 +115,7 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 		Language:    engine.Language,
 		Engine:      engine,
 		AuthManager: authManager,
-		Core:        core.New(engine.CoreAPI, authManager),
+		Core:        core.New(engine.CoreAPI, authManager, txn, server.Config.Auth.Admins),
 		Error:       engine.CCErr,
 		Txn:         txn,
 		Config:      server.Config,
@@ -23,6 +23,7 @@ import (
 	"icenter/src/auth/meta"
 	"icenter/src/common/blog"
 	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
 	"icenter/src/common/util"
 )
 
@@ -33,8 +34,8 @@ type TopoAuth struct {
 
 // initialize a topology auth instance for auth usage.
 // can be used for resources authorize and resources register/deregister management.
-func NewTopologyAuth(tls *apiutil.TLSClientConfig, config authcenter.AuthConfig) (*TopoAuth, error) {
-	authAuthorizer, err := auth.NewAuthorize(tls, config)
+func NewTopologyAuth(tls *apiutil.TLSClientConfig, config authcenter.AuthConfig, db dal.RDB) (*TopoAuth, error) {
+	authAuthorizer, err := auth.NewAuthorize(tls, config, db)
 	if err != nil {
 		blog.Errorf("new topo server authorizer failed, err: %+v", err)
 		return nil, fmt.Errorf("new topo server authorizer failed, err: %+v", err)
@@ -15,6 +15,7 @@ package core
 import (
 	"icenter/src/apimachinery"
 	"icenter/src/auth/extensions"
+	"icenter/src/common/storage/dal"
 	"icenter/src/scene_server/topo_server/core/inst"
 	"icenter/src/scene_server/topo_server/core/model"
 	"icenter/src/scene_server/topo_server/core/operation"
@@ -38,6 +39,8 @@ type Core interface {
 	AuditOperation() operation.AuditOperationInterface
 	HealthOperation() operation.HealthOperationInterface
 	UniqueOperation() operation.UniqueOperationInterface
+	HistoryOperation() operation.HistoryOperationInterface
+	InstTransferOperation() operation.InstTransferOperationInterface
 }
 
 type core struct {
@@ -57,10 +60,12 @@ type core struct {
 	identifier     operation.IdentifierOperationInterface
 	health         operation.HealthOperationInterface
 	unique         operation.UniqueOperationInterface
+	history        operation.HistoryOperationInterface
+	instTransfer   operation.InstTransferOperationInterface
 }
 
-// New create a core manager
-func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthManager) Core {
+// New create a core manager, the db stores the field privileges which the admins are not restricted by.
+func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthManager, db dal.RDB, admins []string) Core {
 
 	// health
 	healthOpeartion := operation.NewHealthOperation(client)
@@ -75,12 +80,14 @@ func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthMan
 	setOperation := operation.NewSetOperation(client)
 	businessOperation := operation.NewBusinessOperation(client, authManager)
 	associationOperation := operation.NewAssociationOperation(client, authManager)
-	permissionOperation := operation.NewPermissionOperation(client)
+	permissionOperation := operation.NewPermissionOperation(client, db, admins)
 	compatibleV2Operation := operation.NewCompatibleV2Operation(client)
 	graphics := operation.NewGraphics(client, authManager)
 	identifier := operation.NewIdentifier(client)
 	audit := operation.NewAuditOperation(client)
 	unique := operation.NewUniqueOperation(client, authManager)
+	history := operation.NewHistoryOperation(client)
+	instTransfer := operation.NewInstTransferOperation(client, authManager)
 
 	targetModel := model.New(client)
 	targetInst := inst.New(client)
@@ -98,6 +105,7 @@ func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthMan
 	businessOperation.SetProxy(setOperation, moduleOperation, instOperation, objectOperation)
 
 	graphics.SetProxy(objectOperation, associationOperation)
+	instTransfer.SetProxy(instOperation, unique)
 
 	return &core{
 		set:            setOperation,
@@ -116,6 +124,8 @@ func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthMan
 		identifier:     identifier,
 		health:         healthOpeartion,
 		unique:         unique,
+		history:        history,
+		instTransfer:   instTransfer,
 	}
 }
 
@@ -170,3 +180,9 @@ func (c *core) HealthOperation() operation.HealthOperationInterface {
 func (c *core) UniqueOperation() operation.UniqueOperationInterface {
 	return c.unique
 }
+func (c *core) HistoryOperation() operation.HistoryOperationInterface {
+	return c.history
+}
+func (c *core) InstTransferOperation() operation.InstTransferOperationInterface {
+	return c.instTransfer
+}
@@ -150,7 +150,7 @@ func (a *attribute) IsValid(isUpdate bool, data mapstr.MapStr) error {
 			return a.params.Err.New(common.CCErrCommParamsIsInvalid, err.Error())
 		}
 
-		if option, exists := data.Get(metadata.AttributeFieldOption); exists && (propertyType == common.FieldTypeInt || propertyType == common.FieldTypeEnum) {
+		if option, exists := data.Get(metadata.AttributeFieldOption); exists && util.InStrArr(optionPropertyTypes, propertyType) {
 			if err := util.ValidPropertyOption(propertyType, option, a.params.Err); nil != err {
 				return err
 			}
@@ -168,6 +168,18 @@ func (a *attribute) IsValid(isUpdate bool, data mapstr.MapStr) error {
 	return nil
 }
 
+// optionPropertyTypes the property types whose option should be validated
+var optionPropertyTypes = []string{
+	common.FieldTypeInt,
+	common.FieldTypeEnum,
+	common.FieldTypeIPv4,
+	common.FieldTypeIPv6,
+	common.FieldTypeCIDR,
+	common.FieldTypeURL,
+	common.FieldTypeList,
+	common.FieldTypeComputed,
+}
+
 func (a *attribute) Create() error {
 
 	if err := a.IsValid(false, a.attr.ToMapStr()); nil != err {
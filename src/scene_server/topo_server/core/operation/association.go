@@ -57,6 +57,10 @@ var (
 			PropertyName: "AssociationKindID",
 			PropertyID:   "AssociationKindID",
 		},
+		{
+			PropertyName: "Attributes",
+			PropertyID:   "Attributes",
+		},
 	}
 )
 
@@ -94,7 +98,9 @@ type AssociationOperationInterface interface {
 
 	SearchInst(params types.ContextParams, request *metadata.SearchAssociationInstRequest) (resp *metadata.SearchAssociationInstResult, err error)
 	CreateInst(params types.ContextParams, request *metadata.CreateAssociationInstRequest) (resp *metadata.CreateAssociationInstResult, err error)
+	UpdateInst(params types.ContextParams, assoID int64, attributes mapstr.MapStr) error
 	DeleteInst(params types.ContextParams, assoID int64) (resp *metadata.DeleteAssociationInstResult, err error)
+	TraverseInst(params types.ContextParams, request *metadata.GraphTraversalParams) (*metadata.GraphTraversalResult, error)
 
 	ImportInstAssociation(ctx context.Context, params types.ContextParams, objID string, importData map[int]metadata.ExcelAssocation) (resp metadata.ResponeImportAssociationData, err error)
 
@@ -605,6 +611,10 @@ func (a *association) UpdateType(params types.ContextParams, asstTypeID int64, r
 		Condition: condition.CreateCondition().Field(common.BKFieldID).Eq(asstTypeID).ToMapStr(),
 		Data:      mapstr.NewFromStruct(request, "json"),
 	}
+	if request.Attributes == nil {
+		// the attributes are kept if not set
+		input.Data.Remove(metadata.AssociationFieldAttributes)
+	}
 
 	rsp, err := a.clientSet.CoreService().Association().UpdateAssociationType(context.Background(), params.Header, &input)
 	if err != nil {
@@ -710,6 +720,10 @@ func (a *association) UpdateObject(params types.ContextParams, asstID int, reque
 		Condition: condition.CreateCondition().Field(common.BKFieldID).Eq(asstID).ToMapStr(),
 		Data:      mapstr.NewFromStruct(request, "json"),
 	}
+	if request.Attributes == nil {
+		// the attributes are kept if not set
+		input.Data.Remove(metadata.AssociationFieldAttributes)
+	}
 
 	rsp, err := a.clientSet.CoreService().Association().UpdateModelAssociation(context.Background(), params.Header, &input)
 	resp = &metadata.UpdateAssociationObjectResult{
@@ -840,6 +854,7 @@ func (a *association) CreateInst(params types.ContextParams, request *metadata.C
 			ObjectID:          objID,
 			AsstObjectID:      asstObjID,
 			AssociationKindID: objectAsst.AsstKindID,
+			Attributes:        request.Attributes,
 		},
 	}
 	createResult, err := a.clientSet.CoreService().Association().CreateInstAssociation(context.Background(), params.Header, &input)
@@ -937,3 +952,100 @@ func (a *association) DeleteInst(params types.ContextParams, assoID int64) (resp
 
 	return resp, err
 }
+
+// UpdateInst update the attribute values of an instance association, the values are merged into the current ones,
+// and a null value removes the attribute.
+func (a *association) UpdateInst(params types.ContextParams, assoID int64, attributes mapstr.MapStr) error {
+	var bizID int64
+	var err error
+	if params.MetaData != nil {
+		bizID, err = metadata.BizIDFromMetadata(*params.MetaData)
+		if err != nil {
+			blog.Errorf("parse business id from request failed, params: %+v, err: %+v, rid: %s", params, err, params.ReqID)
+			return params.Err.Error(common.CCErrCommHTTPInputInvalid)
+		}
+	}
+
+	cond := condition.CreateCondition().Field(common.BKFieldID).Eq(assoID).ToMapStr()
+	preData, err := a.getInstAssociation(params, cond)
+	if err != nil {
+		return err
+	}
+
+	input := metadata.UpdateOption{
+		Condition: cond,
+		Data:      mapstr.MapStr{metadata.AssociationFieldAttributes: attributes},
+	}
+	rsp, err := a.clientSet.CoreService().Association().UpdateInstAssociation(context.Background(), params.Header, &input)
+	if err != nil {
+		blog.Errorf("[operation-asst] failed to request object controller, err: %s, rid: %s", err.Error(), params.ReqID)
+		return params.Err.New(common.CCErrCommHTTPDoRequestFailed, err.Error())
+	}
+	if !rsp.Result {
+		blog.Errorf("[operation-asst] failed to update the instance association %d, err: %s, rid: %s", assoID, rsp.ErrMsg, params.ReqID)
+		return params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	curData, err := a.getInstAssociation(params, cond)
+	if err != nil {
+		return err
+	}
+
+	// record audit log
+	auditlog := metadata.SaveAuditLogParams{
+		ID:    assoID,
+		Model: "instance_association",
+		Content: metadata.Content{
+			PreData: preData,
+			CurData: curData,
+			Headers: InstanceAssociationAuditHeaders,
+		},
+		OpDesc: "update instance association",
+		OpType: auditoplog.AuditOpTypeModify,
+		BizID:  bizID,
+	}
+	auditresp, err := a.clientSet.CoreService().Audit().SaveAuditLog(params.Context, params.Header, auditlog)
+	if err != nil {
+		blog.Errorf("UpdateInst success, but save audit log failed, err: %+v, rid: %s", err, params.ReqID)
+		return params.Err.Error(common.CCErrAuditSaveLogFaile)
+	}
+	if !auditresp.Result {
+		blog.Errorf("UpdateInst success, but save audit log failed, err: %s, rid: %s", auditresp.ErrMsg, params.ReqID)
+		return params.Err.New(auditresp.Code, auditresp.ErrMsg)
+	}
+	return nil
+}
+
+// getInstAssociation returns the only instance association matching the condition
+func (a *association) getInstAssociation(params types.ContextParams, cond mapstr.MapStr) (*metadata.InstAsst, error) {
+	rsp, err := a.clientSet.CoreService().Association().ReadInstAssociation(context.Background(), params.Header, &metadata.QueryCondition{Condition: cond})
+	if err != nil {
+		blog.Errorf("[operation-asst] failed to request object controller, err: %s, rid: %s", err.Error(), params.ReqID)
+		return nil, params.Err.New(common.CCErrCommHTTPDoRequestFailed, err.Error())
+	}
+	if !rsp.Result {
+		blog.Errorf("[operation-asst] failed to search the instance association %#v, err: %s, rid: %s", cond, rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	if len(rsp.Data.Info) != 1 {
+		blog.Errorf("[operation-asst] get %d instance associations with %#v, rid: %s", len(rsp.Data.Info), cond, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommNotFound)
+	}
+	return &rsp.Data.Info[0], nil
+}
+
+// TraverseInst walk the instance associations from the start instances
+func (a *association) TraverseInst(params types.ContextParams, request *metadata.GraphTraversalParams) (*metadata.GraphTraversalResult, error) {
+	rsp, err := a.clientSet.CoreService().Association().TraverseInstAssociation(context.Background(), params.Header, request)
+	if nil != err {
+		blog.Errorf("[operation-asst] failed to request object controller, err: %s, rid: %s", err.Error(), params.ReqID)
+		return nil, params.Err.New(common.CCErrCommHTTPDoRequestFailed, err.Error())
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-asst] failed to traverse the association from %#v, err: %s, rid: %s", request.Start, rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	return &rsp.Data, nil
+}
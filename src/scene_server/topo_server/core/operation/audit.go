@@ -18,12 +18,15 @@ import (
 	"icenter/src/apimachinery"
 	"icenter/src/common"
 	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
 	"icenter/src/common/metadata"
+	"icenter/src/scene_server/topo_server/core/privilege"
 	"icenter/src/scene_server/topo_server/core/types"
 )
 
 type AuditOperationInterface interface {
-	Query(params types.ContextParams, query metadata.QueryInput) (interface{}, error)
+	Query(params types.ContextParams, query metadata.QueryInput, fields privilege.FieldPermission) (interface{}, error)
+	Rollback(params types.ContextParams, input metadata.RollbackParams) (*metadata.RollbackResult, error)
 }
 
 // NewAuditOperation create a new inst operation instance
@@ -37,7 +40,8 @@ type audit struct {
 	clientSet apimachinery.ClientSetInterface
 }
 
-func (a *audit) Query(params types.ContextParams, query metadata.QueryInput) (interface{}, error) {
+// Query search the audit logs, the properties in the logs that the user can not read are hidden.
+func (a *audit) Query(params types.ContextParams, query metadata.QueryInput, fields privilege.FieldPermission) (interface{}, error) {
 	rsp, err := a.clientSet.CoreService().Audit().SearchAuditLog(context.Background(), params.Header, query)
 	if nil != err {
 		blog.Errorf("[audit] failed request audit controller, error info is %s", err.Error())
@@ -49,11 +53,51 @@ func (a *audit) Query(params types.ContextParams, query metadata.QueryInput) (in
 		return nil, params.Err.New(common.CCErrAuditTakeSnapshotFaile, rsp.ErrMsg)
 	}
 
+	objIDs := make([]string, 0)
 	for index := range rsp.Data.Info {
 		if desc := params.Lang.Language("auditlog_" + rsp.Data.Info[index].OpDesc); len(desc) > 0 {
 			rsp.Data.Info[index].OpDesc = desc
 		}
+		objIDs = append(objIDs, rsp.Data.Info[index].OpTarget)
+	}
+
+	access, err := fields.Access(objIDs...)
+	if err != nil {
+		return nil, err
+	}
+	for index := range rsp.Data.Info {
+		maskAuditContent(access, rsp.Data.Info[index].OpTarget, rsp.Data.Info[index].Content)
 	}
 
 	return rsp.Data, nil
 }
+
+// maskAuditContent hide the properties of the data before and after the change that the user can not read
+func maskAuditContent(access *privilege.FieldAccess, objID string, content interface{}) {
+	data, ok := content.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, key := range []string{"pre_data", "cur_data"} {
+		switch values := data[key].(type) {
+		case map[string]interface{}:
+			access.Mask(objID, values)
+		case mapstr.MapStr:
+			access.Mask(objID, values)
+		}
+	}
+}
+
+func (a *audit) Rollback(params types.ContextParams, input metadata.RollbackParams) (*metadata.RollbackResult, error) {
+	rsp, err := a.clientSet.CoreService().Audit().RollbackAuditLogs(context.Background(), params.Header, input)
+	if nil != err {
+		blog.Errorf("[audit] failed request audit controller, error info is %s, rid: %s", err.Error(), params.ReqID)
+		return nil, params.Err.New(common.CCErrCommHTTPDoRequestFailed, err.Error())
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[audit] failed to roll back the audit logs, error info is %s, rid: %s", rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	return &rsp.Data, nil
+}
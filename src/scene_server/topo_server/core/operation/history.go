@@ -0,0 +1,83 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"context"
+	"time"
+
+	"icenter/src/apimachinery"
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/scene_server/topo_server/core/types"
+)
+
+type HistoryOperationInterface interface {
+	SearchInstanceVersions(params types.ContextParams, objID string, instID int64) (*metadata.InstanceVersionsResult, error)
+	GetInstanceAsOf(params types.ContextParams, objID string, instID int64, asOf time.Time) (mapstr.MapStr, error)
+	SearchMainlineInstanceTopoAsOf(params types.ContextParams, bizID int64, withDetail bool, asOf time.Time) (*metadata.TopoInstanceNode, error)
+}
+
+// NewHistoryOperation create a new history operation instance
+func NewHistoryOperation(client apimachinery.ClientSetInterface) HistoryOperationInterface {
+	return &history{
+		clientSet: client,
+	}
+}
+
+type history struct {
+	clientSet apimachinery.ClientSetInterface
+}
+
+func (h *history) SearchInstanceVersions(params types.ContextParams, objID string, instID int64) (*metadata.InstanceVersionsResult, error) {
+	rsp, err := h.clientSet.CoreService().History().SearchInstanceVersions(context.Background(), params.Header, objID, instID)
+	if nil != err {
+		blog.Errorf("[history] failed to request core service, err: %s, rid: %s", err.Error(), params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("[history] failed to search the versions of %s instance %d, err: %s, rid: %s", objID, instID, rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	return &rsp.Data, nil
+}
+
+func (h *history) GetInstanceAsOf(params types.ContextParams, objID string, instID int64, asOf time.Time) (mapstr.MapStr, error) {
+	input := metadata.InstanceAsOfParams{AsOf: asOf}
+	rsp, err := h.clientSet.CoreService().History().GetInstanceAsOf(context.Background(), params.Header, objID, instID, input)
+	if nil != err {
+		blog.Errorf("[history] failed to request core service, err: %s, rid: %s", err.Error(), params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("[history] failed to get %s instance %d as of %s, err: %s, rid: %s", objID, instID, asOf, rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	return rsp.Data, nil
+}
+
+func (h *history) SearchMainlineInstanceTopoAsOf(params types.ContextParams, bizID int64, withDetail bool, asOf time.Time) (*metadata.TopoInstanceNode, error) {
+	input := metadata.MainlineTopoAsOfParams{AsOf: asOf, WithDetail: withDetail}
+	rsp, err := h.clientSet.CoreService().History().SearchMainlineInstanceTopoAsOf(context.Background(), params.Header, bizID, input)
+	if nil != err {
+		blog.Errorf("[history] failed to request core service, err: %s, rid: %s", err.Error(), params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("[history] failed to search the topo of business %d as of %s, err: %s, rid: %s", bizID, asOf, rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	return &rsp.Data, nil
+}
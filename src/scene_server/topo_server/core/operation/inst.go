@@ -44,6 +44,7 @@ type InstOperationInterface interface {
 	FindInstParentTopo(params types.ContextParams, obj model.Object, instID int64, query *metadata.QueryInput) (count int, results []*CommonInstTopo, err error)
 	FindInstTopo(params types.ContextParams, obj model.Object, instID int64, query *metadata.QueryInput) (count int, results []CommonInstTopoV2, err error)
 	UpdateInst(params types.ContextParams, data mapstr.MapStr, obj model.Object, cond condition.Condition, instID int64) error
+	AggregateInst(params types.ContextParams, obj model.Object, request *metadata.AggregateInstanceParams) (*metadata.AggregateInstanceResult, error)
 
 	SetProxy(modelFactory model.Factory, instFactory inst.Factory, asst AssociationOperationInterface, obj ObjectOperationInterface)
 }
@@ -954,3 +955,19 @@ func (c *commonInst) UpdateInst(params types.ContextParams, data mapstr.MapStr,
 	NewSupplementary().Audit(params, c.clientSet, obj, c).CommitUpdateLog(preAuditLog, currAuditLog, nil)
 	return nil
 }
+
+// AggregateInst group the instances of the model by the properties and compute the metrics of the groups
+func (c *commonInst) AggregateInst(params types.ContextParams, obj model.Object, request *metadata.AggregateInstanceParams) (*metadata.AggregateInstanceResult, error) {
+	rsp, err := c.clientSet.CoreService().Instance().AggregateInstance(context.Background(), params.Header, obj.GetObjectID(), request)
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to request object controller, err: %s, rid: %s", err.Error(), params.ReqID)
+		return nil, params.Err.New(common.CCErrCommHTTPDoRequestFailed, err.Error())
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-inst] failed to aggregate the object(%s) instances by %#v, err: %s, rid: %s", obj.GetObjectID(), request.GroupBy, rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	return &rsp.Data, nil
+}
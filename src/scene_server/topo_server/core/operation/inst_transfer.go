@@ -0,0 +1,707 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"icenter/src/apimachinery"
+	"icenter/src/auth/extensions"
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/condition"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/spreadsheet"
+	"icenter/src/common/util"
+	"icenter/src/common/validator"
+	"icenter/src/scene_server/topo_server/core/model"
+	"icenter/src/scene_server/topo_server/core/privilege"
+	"icenter/src/scene_server/topo_server/core/types"
+)
+
+const (
+	// maxImportRows the max rows of an import, the header is not counted.
+	maxImportRows = 10000
+	// exportPageSize the instances read in a page when they're exported
+	exportPageSize = 500
+
+	dateLayout = "2006-01-02"
+	timeLayout = "2006-01-02 15:04:05"
+)
+
+// foreignKeyModels the models referenced by the foreign key attributes, the values of the
+// foreign key attributes are exported as the instance names of the referenced models.
+var foreignKeyModels = map[string]string{
+	common.BKCloudIDField: common.BKInnerObjIDPlat,
+}
+
+// excelEpoch the day zero of the excel date serial numbers
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.Local)
+
+// InstTransferOperationInterface imports and exports the instances of a model with the spreadsheets
+type InstTransferOperationInterface interface {
+	ImportInsts(params types.ContextParams, obj model.Object, reader spreadsheet.Reader, dryRun bool, access *privilege.FieldAccess) (*metadata.InstImportResult, error)
+	ExportInsts(params types.ContextParams, obj model.Object, request *metadata.InstExportRequest, writer spreadsheet.Writer, access *privilege.FieldAccess) error
+
+	SetProxy(inst InstOperationInterface, unique UniqueOperationInterface)
+}
+
+// NewInstTransferOperation create a new inst transfer operation instance
+func NewInstTransferOperation(client apimachinery.ClientSetInterface, authManager *extensions.AuthManager) InstTransferOperationInterface {
+	return &instTransfer{
+		clientSet:   client,
+		authManager: authManager,
+	}
+}
+
+type instTransfer struct {
+	clientSet   apimachinery.ClientSetInterface
+	authManager *extensions.AuthManager
+	inst        InstOperationInterface
+	unique      UniqueOperationInterface
+}
+
+func (t *instTransfer) SetProxy(inst InstOperationInterface, unique UniqueOperationInterface) {
+	t.inst = inst
+	t.unique = unique
+}
+
+// transferModel the attributes of the model and the names of the instances referenced by its foreign keys
+type transferModel struct {
+	params types.ContextParams
+	obj    model.Object
+	client apimachinery.ClientSetInterface
+	attrs  []metadata.Attribute
+	// foreignNames the instance names of the referenced models by id
+	foreignNames map[string]map[int64]string
+}
+
+func (t *instTransfer) newTransferModel(params types.ContextParams, obj model.Object) (*transferModel, error) {
+	attrs, err := obj.GetAttributes()
+	if err != nil {
+		blog.Errorf("[operation-transfer] failed to get the attributes of %s, err: %v, rid: %s", obj.GetObjectID(), err, params.ReqID)
+		return nil, err
+	}
+	m := &transferModel{
+		params:       params,
+		obj:          obj,
+		client:       t.clientSet,
+		attrs:        make([]metadata.Attribute, 0, len(attrs)),
+		foreignNames: make(map[string]map[int64]string),
+	}
+	for _, attr := range attrs {
+		m.attrs = append(m.attrs, *attr.Attribute())
+	}
+	sort.SliceStable(m.attrs, func(i, j int) bool {
+		return m.attrs[i].PropertyIndex < m.attrs[j].PropertyIndex
+	})
+	return m, nil
+}
+
+// attribute returns the attribute whose property id or property name is the column name
+func (m *transferModel) attribute(column string) *metadata.Attribute {
+	for i := range m.attrs {
+		if m.attrs[i].PropertyID == column {
+			return &m.attrs[i]
+		}
+	}
+	for i := range m.attrs {
+		if strings.EqualFold(m.attrs[i].PropertyName, column) {
+			return &m.attrs[i]
+		}
+	}
+	return nil
+}
+
+// writable returns whether the values of the attribute are imported
+func writable(attr *metadata.Attribute) bool {
+	switch attr.PropertyType {
+	case common.FieldTypeComputed, common.FieldTypeSingleAsst, common.FieldTypeMultiAsst:
+		return false
+	}
+	return true
+}
+
+// names returns the instance names of the model referenced by the foreign key by id
+func (m *transferModel) names(objID string) (map[int64]string, error) {
+	if names, exists := m.foreignNames[objID]; exists {
+		return names, nil
+	}
+	idField, nameField := common.GetInstIDField(objID), common.GetInstNameField(objID)
+	input := &metadata.QueryCondition{Condition: mapstr.New(), Fields: []string{idField, nameField}}
+	rsp, err := m.client.CoreService().Instance().ReadInstance(context.Background(), m.params.Header, objID, input)
+	if err != nil {
+		blog.Errorf("[operation-transfer] failed to read the %s instances, err: %v, rid: %s", objID, err, m.params.ReqID)
+		return nil, m.params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("[operation-transfer] failed to read the %s instances, err: %s, rid: %s", objID, rsp.ErrMsg, m.params.ReqID)
+		return nil, m.params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	names := make(map[int64]string)
+	for _, item := range rsp.Data.Info {
+		id, err := util.GetInt64ByInterface(item[idField])
+		if err != nil {
+			continue
+		}
+		names[id] = util.GetStrByInterface(item[nameField])
+	}
+	m.foreignNames[objID] = names
+	return names, nil
+}
+
+// parseValue converts the text of the cell to the value of the attribute
+func (m *transferModel) parseValue(attr *metadata.Attribute, text string) (interface{}, error) {
+	invalid := m.params.Err.Errorf(common.CCErrCommParamsInvalid, attr.PropertyName)
+	switch attr.PropertyType {
+	case common.FieldTypeInt:
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil || value != math.Trunc(value) {
+			return nil, invalid
+		}
+		return int64(value), nil
+	case common.FieldTypeFloat:
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		return value, nil
+	case common.FieldTypeBool:
+		switch strings.ToLower(text) {
+		case "true", "1", "yes", "y":
+			return true, nil
+		case "false", "0", "no", "n":
+			return false, nil
+		}
+		return nil, invalid
+	case common.FieldTypeEnum:
+		for _, option := range validator.ParseEnumOption(attr.Option) {
+			if option.ID == text || option.Name == text {
+				return option.ID, nil
+			}
+		}
+		return nil, invalid
+	case common.FieldTypeDate:
+		return parseTime(text, dateLayout, invalid)
+	case common.FieldTypeTime:
+		return parseTime(text, timeLayout, invalid)
+	case common.FieldTypeJSON:
+		var value interface{}
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return nil, invalid
+		}
+		return value, nil
+	case common.FieldTypeList:
+		return parseList(attr, text, invalid)
+	case common.FieldTypeForeignKey:
+		if id, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return id, nil
+		}
+		objID, exists := foreignKeyModels[attr.PropertyID]
+		if !exists {
+			return nil, invalid
+		}
+		names, err := m.names(objID)
+		if err != nil {
+			return nil, err
+		}
+		for id, name := range names {
+			if name == text {
+				return id, nil
+			}
+		}
+		return nil, invalid
+	default:
+		return text, nil
+	}
+}
+
+// parseTime parses the time in the layout, or the serial number of the excel date
+func parseTime(text, layout string, invalid error) (interface{}, error) {
+	if _, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+		return text, nil
+	}
+	if value, err := time.ParseInLocation(strings.Replace(layout, "-", "/", -1), text, time.Local); err == nil {
+		return value.Format(layout), nil
+	}
+	serial, err := strconv.ParseFloat(text, 64)
+	if err != nil || serial < 0 {
+		return nil, invalid
+	}
+	value := excelEpoch.Add(time.Duration(serial * float64(24*time.Hour))).Round(time.Second)
+	return value.Format(layout), nil
+}
+
+// parseList parses the json array or the comma separated items of the list
+func parseList(attr *metadata.Attribute, text string, invalid error) (interface{}, error) {
+	if strings.HasPrefix(text, "[") {
+		items := make([]interface{}, 0)
+		if err := json.Unmarshal([]byte(text), &items); err != nil {
+			return nil, invalid
+		}
+		return items, nil
+	}
+
+	option, err := util.ParseListOption(attr.Option)
+	if err != nil {
+		return nil, invalid
+	}
+	items := make([]interface{}, 0)
+	for _, text := range strings.Split(text, ",") {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		var item interface{} = text
+		switch option.ItemType {
+		case common.FieldTypeInt:
+			value, err := strconv.ParseInt(text, 10, 64)
+			if err != nil {
+				return nil, invalid
+			}
+			item = value
+		case common.FieldTypeFloat:
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, invalid
+			}
+			item = value
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// formatValue renders the value of the attribute as a human readable text
+func (m *transferModel) formatValue(attr *metadata.Attribute, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	switch attr.PropertyType {
+	case common.FieldTypeEnum:
+		id := util.GetStrByInterface(value)
+		for _, option := range validator.ParseEnumOption(attr.Option) {
+			if option.ID == id {
+				return option.Name
+			}
+		}
+		return id
+	case common.FieldTypeForeignKey:
+		if objID, exists := foreignKeyModels[attr.PropertyID]; exists {
+			id, err := util.GetInt64ByInterface(value)
+			if err != nil {
+				break
+			}
+			names, err := m.names(objID)
+			if err != nil {
+				blog.Warnf("[operation-transfer] failed to get the names of %s, err: %v, rid: %s", objID, err, m.params.ReqID)
+				break
+			}
+			if name, exists := names[id]; exists {
+				return name
+			}
+		}
+	}
+	return formatText(value)
+}
+
+func formatText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case int, int32, int64, uint64:
+		return fmt.Sprint(v)
+	case []interface{}:
+		// the items of a list are joined with comma, unless they contain comma or they're not scalars.
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			text := formatText(item)
+			switch item.(type) {
+			case []interface{}, map[string]interface{}, mapstr.MapStr:
+				text = ","
+			}
+			if strings.Contains(text, ",") {
+				items = nil
+				break
+			}
+			items = append(items, text)
+		}
+		if items != nil {
+			return strings.Join(items, ",")
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// uniqueKeys returns the property ids of the must check unique of the model, or the first unique
+// if there is no must check one.
+func (t *instTransfer) uniqueKeys(params types.ContextParams, m *transferModel) ([]string, error) {
+	uniques, err := t.unique.Search(params, m.obj.GetObjectID())
+	if err != nil {
+		return nil, err
+	}
+	if len(uniques) == 0 {
+		return []string{}, nil
+	}
+	selected := uniques[0]
+	for _, unique := range uniques {
+		if unique.MustCheck {
+			selected = unique
+			break
+		}
+	}
+
+	keys := make([]string, 0, len(selected.Keys))
+	for _, key := range selected.Keys {
+		for _, attr := range m.attrs {
+			if uint64(attr.ID) == key.ID {
+				keys = append(keys, attr.PropertyID)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// ImportInsts imports the rows of the spreadsheet, the first row is the header, whose columns are
+// the property ids or the property names of the attributes. a row updates the instance whose id
+// is the one in the instance id column or whose unique keys are the same, or it creates a new instance.
+func (t *instTransfer) ImportInsts(params types.ContextParams, obj model.Object, reader spreadsheet.Reader, dryRun bool, access *privilege.FieldAccess) (*metadata.InstImportResult, error) {
+	m, err := t.newTransferModel(params, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedSet, "header")
+	}
+	if err != nil {
+		blog.Errorf("[operation-transfer] failed to read the header, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Err.New(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	result := &metadata.InstImportResult{
+		DryRun:         dryRun,
+		IgnoredColumns: make([]string, 0),
+		Rows:           make([]metadata.InstImportRow, 0),
+	}
+	columns := make([]*metadata.Attribute, len(header))
+	idColumn := -1
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name == obj.GetInstIDFieldName() {
+			idColumn = i
+			continue
+		}
+		attr := m.attribute(name)
+		if attr == nil || !writable(attr) || !access.Writable(obj.GetObjectID(), attr.PropertyID) {
+			result.IgnoredColumns = append(result.IgnoredColumns, name)
+			continue
+		}
+		columns[i] = attr
+	}
+
+	if result.UniqueKeys, err = t.uniqueKeys(params, m); err != nil {
+		return nil, err
+	}
+
+	importer := &rowImporter{
+		transfer: t,
+		model:    m,
+		columns:  columns,
+		idColumn: idColumn,
+		keys:     result.UniqueKeys,
+		seen:     make(map[string]int),
+		dryRun:   dryRun,
+	}
+	created, updated := make([]int64, 0), make([]int64, 0)
+	for num := 2; ; num++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			blog.Errorf("[operation-transfer] failed to read the row %d, err: %v, rid: %s", num, err, params.ReqID)
+			return nil, params.Err.New(common.CCErrCommParamsIsInvalid, err.Error())
+		}
+		if isEmptyRow(row) {
+			continue
+		}
+		if result.Total >= maxImportRows {
+			blog.Errorf("[operation-transfer] the rows exceed the limit %d, rid: %s", maxImportRows, params.ReqID)
+			return nil, params.Err.Error(common.CCErrCommOverLimit)
+		}
+
+		result.Total++
+		item := importer.importRow(num, row)
+		switch item.Action {
+		case metadata.InstImportActionCreate:
+			result.Created++
+			if !dryRun {
+				created = append(created, item.InstID)
+			}
+		case metadata.InstImportActionUpdate:
+			result.Updated++
+			if !dryRun {
+				updated = append(updated, item.InstID)
+			}
+		default:
+			result.Failed++
+		}
+		result.Rows = append(result.Rows, item)
+	}
+
+	if len(created) != 0 {
+		if err := t.authManager.RegisterInstancesByID(params.Context, params.Header, obj.GetObjectID(), created...); err != nil {
+			blog.Errorf("[operation-transfer] register the created instances %v to iam failed, err: %v, rid: %s", created, err, params.ReqID)
+			return result, params.Err.Error(common.CCErrCommRegistResourceToIAMFailed)
+		}
+	}
+	if len(updated) != 0 {
+		if err := t.authManager.UpdateRegisteredInstanceByID(params.Context, params.Header, obj.GetObjectID(), updated...); err != nil {
+			blog.Errorf("[operation-transfer] update the registered instances %v to iam failed, err: %v, rid: %s", updated, err, params.ReqID)
+			return result, params.Err.Error(common.CCErrCommRegistResourceToIAMFailed)
+		}
+	}
+	return result, nil
+}
+
+// conditionFields returns the sorted fields of the condition
+func conditionFields(cond mapstr.MapStr) []string {
+	fields := make([]string, 0, len(cond))
+	for field := range cond {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func isEmptyRow(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// rowImporter imports the rows of a spreadsheet
+type rowImporter struct {
+	transfer *instTransfer
+	model    *transferModel
+	columns  []*metadata.Attribute
+	idColumn int
+	keys     []string
+	// seen the rows by the instance id or the unique keys, a row can't be imported twice.
+	seen   map[string]int
+	dryRun bool
+}
+
+func (r *rowImporter) importRow(num int, row []string) metadata.InstImportRow {
+	params, obj := r.model.params, r.model.obj
+	result := metadata.InstImportRow{Row: num, Action: metadata.InstImportActionFailed}
+
+	data := mapstr.New()
+	for i, attr := range r.columns {
+		if attr == nil || i >= len(row) || strings.TrimSpace(row[i]) == "" {
+			continue
+		}
+		value, err := r.model.parseValue(attr, strings.TrimSpace(row[i]))
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		data[attr.PropertyID] = value
+	}
+
+	// the instance is matched by the id, or the unique keys
+	var cond mapstr.MapStr
+	if r.idColumn >= 0 && r.idColumn < len(row) && strings.TrimSpace(row[r.idColumn]) != "" {
+		id, err := strconv.ParseInt(strings.TrimSpace(row[r.idColumn]), 10, 64)
+		if err != nil {
+			result.Error = params.Err.Errorf(common.CCErrCommParamsNeedInt, obj.GetInstIDFieldName()).Error()
+			return result
+		}
+		cond = mapstr.MapStr{obj.GetInstIDFieldName(): id}
+	} else if len(r.keys) != 0 {
+		cond = mapstr.New()
+		for _, key := range r.keys {
+			value, exists := data[key]
+			if !exists {
+				result.Error = params.Err.Errorf(common.CCErrCommParamsNeedSet, r.model.attribute(key).PropertyName).Error()
+				return result
+			}
+			cond[key] = value
+		}
+	}
+
+	if cond != nil {
+		seenKey, _ := json.Marshal(cond)
+		if prev, exists := r.seen[string(seenKey)]; exists {
+			result.Error = fmt.Sprintf("%s, row %d", params.Err.Errorf(common.CCErrCommDuplicateItem, strings.Join(conditionFields(cond), ",")).Error(), prev)
+			return result
+		}
+		r.seen[string(seenKey)] = num
+
+		instID, found, err := r.find(cond)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		if found {
+			result.InstID = instID
+			if !r.dryRun {
+				updateCond := condition.CreateCondition()
+				updateCond.Field(obj.GetInstIDFieldName()).Eq(instID)
+				if err := r.transfer.inst.UpdateInst(params, data, obj, updateCond, instID); err != nil {
+					result.Error = err.Error()
+					return result
+				}
+			}
+			result.Action = metadata.InstImportActionUpdate
+			return result
+		}
+		if _, byID := cond[obj.GetInstIDFieldName()]; byID {
+			result.Error = params.Err.Error(common.CCErrCommNotFound).Error()
+			return result
+		}
+	}
+
+	for _, attr := range r.model.attrs {
+		if attr.IsRequired && writable(&attr) && !data.Exists(attr.PropertyID) {
+			result.Error = params.Err.Errorf(common.CCErrCommParamsNeedSet, attr.PropertyName).Error()
+			return result
+		}
+	}
+	if !r.dryRun {
+		item, err := r.transfer.inst.CreateInst(params, obj, data)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		if result.InstID, err = item.GetInstID(); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+	result.Action = metadata.InstImportActionCreate
+	return result
+}
+
+// find returns the id of the instance matching the condition
+func (r *rowImporter) find(cond mapstr.MapStr) (int64, bool, error) {
+	params, obj := r.model.params, r.model.obj
+	rsp, err := r.transfer.inst.FindOriginInst(params, obj, &metadata.QueryInput{Condition: cond, Limit: 2})
+	if err != nil {
+		return 0, false, err
+	}
+	switch len(rsp.Info) {
+	case 0:
+		return 0, false, nil
+	case 1:
+		instID, err := util.GetInt64ByInterface(rsp.Info[0][obj.GetInstIDFieldName()])
+		if err != nil {
+			return 0, false, params.Err.Error(common.CCErrCommParseDataFailed)
+		}
+		return instID, true, nil
+	default:
+		return 0, false, params.Err.Errorf(common.CCErrCommDuplicateItem, strings.Join(conditionFields(cond), ","))
+	}
+}
+
+// ExportInsts writes the instances matching the condition to the spreadsheet, the first row is the header
+// with the instance id and the property names. The properties that the user can not read are hidden.
+func (t *instTransfer) ExportInsts(params types.ContextParams, obj model.Object, request *metadata.InstExportRequest, writer spreadsheet.Writer, access *privilege.FieldAccess) error {
+	m, err := t.newTransferModel(params, obj)
+	if err != nil {
+		return err
+	}
+
+	attrs := m.attrs
+	if len(request.Fields) != 0 {
+		attrs = make([]metadata.Attribute, 0, len(request.Fields))
+		for _, field := range request.Fields {
+			if attr := m.attribute(field); attr != nil {
+				attrs = append(attrs, *attr)
+			}
+		}
+	}
+	visible := make([]metadata.Attribute, 0, len(attrs))
+	for _, attr := range attrs {
+		if !access.Omitted(obj.GetObjectID(), attr.PropertyID) {
+			visible = append(visible, attr)
+		}
+	}
+	attrs = visible
+
+	header := []string{obj.GetInstIDFieldName()}
+	for _, attr := range attrs {
+		header = append(header, attr.PropertyName)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	cond := request.Condition
+	if cond == nil {
+		cond = mapstr.New()
+	}
+	for start := 0; ; start += exportPageSize {
+		query := &metadata.QueryInput{Condition: cond, Start: start, Limit: exportPageSize, Sort: obj.GetInstIDFieldName()}
+		rsp, err := t.inst.FindOriginInst(params, obj, query)
+		if err != nil {
+			return err
+		}
+		for _, item := range rsp.Info {
+			row := make([]string, 0, len(header))
+			row = append(row, formatText(item[obj.GetInstIDFieldName()]))
+			for i := range attrs {
+				if !access.Readable(obj.GetObjectID(), attrs[i].PropertyID) {
+					row = append(row, metadata.FieldRedactedValue)
+					continue
+				}
+				row = append(row, m.formatValue(&attrs[i], item[attrs[i].PropertyID]))
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+		if len(rsp.Info) < exportPageSize {
+			return nil
+		}
+	}
+}
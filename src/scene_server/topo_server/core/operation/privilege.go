@@ -14,6 +14,7 @@ package operation
 
 import (
 	"icenter/src/apimachinery"
+	"icenter/src/common/storage/dal"
 	"icenter/src/scene_server/topo_server/core/privilege"
 	"icenter/src/scene_server/topo_server/core/types"
 )
@@ -23,17 +24,23 @@ type PermissionOperationInterface interface {
 	Permission(params types.ContextParams) privilege.PermissionInterface
 	UserGroup(params types.ContextParams) privilege.UserGroupInterface
 	Role(params types.ContextParams) privilege.RolePermission
+	Field(params types.ContextParams) privilege.FieldPermission
 }
 
-// NewPermissionOperation create the permission operation
-func NewPermissionOperation(client apimachinery.ClientSetInterface) PermissionOperationInterface {
+// NewPermissionOperation create the permission operation, the field privileges are stored in the db,
+// and the admins can read and write all the properties.
+func NewPermissionOperation(client apimachinery.ClientSetInterface, db dal.RDB, admins []string) PermissionOperationInterface {
 	return &permissionOperation{
 		client: client,
+		db:     db,
+		admins: admins,
 	}
 }
 
 type permissionOperation struct {
 	client apimachinery.ClientSetInterface
+	db     dal.RDB
+	admins []string
 }
 
 func (p *permissionOperation) Permission(params types.ContextParams) privilege.PermissionInterface {
@@ -47,3 +54,7 @@ func (p *permissionOperation) UserGroup(params types.ContextParams) privilege.Us
 func (p *permissionOperation) Role(params types.ContextParams) privilege.RolePermission {
 	return privilege.NewRole(params, p.client)
 }
+
+func (p *permissionOperation) Field(params types.ContextParams) privilege.FieldPermission {
+	return privilege.NewFieldPermission(params, p.client, p.db, p.admins)
+}
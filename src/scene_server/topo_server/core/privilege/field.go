@@ -0,0 +1,406 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package privilege
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"icenter/src/apimachinery"
+	"icenter/src/auth/local"
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/condition"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/util"
+	"icenter/src/scene_server/topo_server/core/types"
+)
+
+// FieldPermission the field level privileges of the model attributes
+type FieldPermission interface {
+	SetFieldPrivilege(privilege *metadata.FieldPrivilege) error
+	GetFieldPrivilege(objID, propertyID string) (*metadata.FieldPrivilege, error)
+	DeleteFieldPrivilege(objID, propertyID string) error
+	SearchFieldPrivileges(objID string) ([]metadata.FieldPrivilege, error)
+
+	// Access returns the access of the current user to the sensitive properties of the models
+	Access(objIDs ...string) (*FieldAccess, error)
+}
+
+// NewFieldPermission create a field permission instance, the admins can read and write all the properties.
+func NewFieldPermission(params types.ContextParams, client apimachinery.ClientSetInterface, db dal.RDB, admins []string) FieldPermission {
+	return &fieldPermission{
+		params: params,
+		client: client,
+		db:     db,
+		admins: admins,
+	}
+}
+
+type fieldPermission struct {
+	params types.ContextParams
+	client apimachinery.ClientSetInterface
+	db     dal.RDB
+	admins []string
+}
+
+func (f *fieldPermission) filter(objID, propertyID string) mapstr.MapStr {
+	cond := mapstr.MapStr{
+		common.BKOwnerIDField: f.params.SupplierAccount,
+		common.BKObjIDField:   objID,
+	}
+	if len(propertyID) != 0 {
+		cond[common.BKPropertyIDField] = propertyID
+	}
+	return cond
+}
+
+// checkAdmin only the admins can change the field privileges if the admins are configured
+func (f *fieldPermission) checkAdmin() error {
+	if len(f.admins) != 0 && !util.InStrArr(f.admins, f.params.User) {
+		blog.Errorf("[permission] the user %s is not an admin to change the field privileges, rid: %s", f.params.User, f.params.ReqID)
+		return f.params.Err.Error(common.CCErrCommAuthNotHavePermission)
+	}
+	return nil
+}
+
+func (f *fieldPermission) SetFieldPrivilege(privilege *metadata.FieldPrivilege) error {
+	if err := f.checkAdmin(); err != nil {
+		return err
+	}
+	switch privilege.Mask {
+	case "":
+		privilege.Mask = metadata.FieldMaskRedact
+	case metadata.FieldMaskRedact, metadata.FieldMaskOmit:
+	default:
+		return f.params.Err.Errorf(common.CCErrCommParamsIsInvalid, "mask")
+	}
+	if err := f.checkProperty(privilege.ObjectID, privilege.PropertyID); err != nil {
+		return err
+	}
+	privilege.ReadGroups = util.RemoveDuplicatesAndEmpty(privilege.ReadGroups)
+	privilege.WriteGroups = util.RemoveDuplicatesAndEmpty(privilege.WriteGroups)
+	if err := f.checkGroups(append(privilege.ReadGroups, privilege.WriteGroups...)); err != nil {
+		return err
+	}
+
+	ctx := f.params.Context
+	privilege.OwnerID = f.params.SupplierAccount
+	privilege.Modifier = f.params.User
+	privilege.LastTime = time.Now()
+	filter := f.filter(privilege.ObjectID, privilege.PropertyID)
+	existing := metadata.FieldPrivilege{}
+	err := f.db.Table(common.BKTableNameFieldPrivilege).Find(filter).One(ctx, &existing)
+	if err != nil && !f.db.IsNotFoundError(err) {
+		blog.Errorf("[permission] failed to find the privilege of %s.%s, err: %v, rid: %s", privilege.ObjectID, privilege.PropertyID, err, f.params.ReqID)
+		return f.params.Err.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	if err == nil {
+		privilege.CreateTime = existing.CreateTime
+		if err := f.db.Table(common.BKTableNameFieldPrivilege).Update(ctx, filter, privilege); err != nil {
+			blog.Errorf("[permission] failed to update the privilege of %s.%s, err: %v, rid: %s", privilege.ObjectID, privilege.PropertyID, err, f.params.ReqID)
+			return f.params.Err.Error(common.CCErrCommDBUpdateFailed)
+		}
+		return nil
+	}
+
+	privilege.CreateTime = privilege.LastTime
+	if err := f.db.Table(common.BKTableNameFieldPrivilege).Insert(ctx, privilege); err != nil {
+		blog.Errorf("[permission] failed to create the privilege of %s.%s, err: %v, rid: %s", privilege.ObjectID, privilege.PropertyID, err, f.params.ReqID)
+		return f.params.Err.Error(common.CCErrCommDBInsertFailed)
+	}
+	return nil
+}
+
+// checkProperty check the property exists, the id property can not be hidden.
+func (f *fieldPermission) checkProperty(objID, propertyID string) error {
+	if len(objID) == 0 {
+		return f.params.Err.Errorf(common.CCErrCommParamsNeedSet, common.BKObjIDField)
+	}
+	switch propertyID {
+	case "":
+		return f.params.Err.Errorf(common.CCErrCommParamsNeedSet, common.BKPropertyIDField)
+	case common.GetInstIDField(objID), common.BKInstIDField, common.BKObjIDField, common.BKOwnerIDField:
+		return f.params.Err.Errorf(common.CCErrCommParamsIsInvalid, common.BKPropertyIDField)
+	}
+
+	cond := condition.CreateCondition()
+	cond.Field(common.BKObjIDField).Eq(objID)
+	cond.Field(common.BKPropertyIDField).Eq(propertyID)
+	rsp, err := f.client.CoreService().Model().ReadModelAttr(context.Background(), f.params.Header, objID, &metadata.QueryCondition{Condition: cond.ToMapStr()})
+	if err != nil {
+		blog.Errorf("[permission] failed to request core service, err: %v, rid: %s", err, f.params.ReqID)
+		return f.params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("[permission] failed to search the property %s.%s, err: %s, rid: %s", objID, propertyID, rsp.ErrMsg, f.params.ReqID)
+		return f.params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	if len(rsp.Data.Info) == 0 {
+		return f.params.Err.Errorf(common.CCErrCommParamsIsInvalid, common.BKPropertyIDField)
+	}
+	return nil
+}
+
+// checkGroups check all the user groups exist
+func (f *fieldPermission) checkGroups(groupIDs []string) error {
+	groupIDs = util.RemoveDuplicatesAndEmpty(groupIDs)
+	if len(groupIDs) == 0 {
+		return nil
+	}
+	cond := util.SetQueryOwner(map[string]interface{}{
+		common.BKUserGroupIDField: map[string]interface{}{common.BKDBIN: groupIDs},
+	}, f.params.SupplierAccount)
+	groups := make([]metadata.UserGroup, 0)
+	if err := f.db.Table(common.BKTableNameUserGroup).Find(cond).Fields(common.BKUserGroupIDField).All(f.params.Context, &groups); err != nil {
+		blog.Errorf("[permission] failed to search the user groups %v, err: %v, rid: %s", groupIDs, err, f.params.ReqID)
+		return f.params.Err.Error(common.CCErrCommDBSelectFailed)
+	}
+	for _, groupID := range groupIDs {
+		found := false
+		for _, group := range groups {
+			if group.GroupID == groupID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return f.params.Err.Errorf(common.CCErrCommParamsIsInvalid, groupID)
+		}
+	}
+	return nil
+}
+
+func (f *fieldPermission) GetFieldPrivilege(objID, propertyID string) (*metadata.FieldPrivilege, error) {
+	privilege := new(metadata.FieldPrivilege)
+	err := f.db.Table(common.BKTableNameFieldPrivilege).Find(f.filter(objID, propertyID)).One(f.params.Context, privilege)
+	if f.db.IsNotFoundError(err) {
+		return nil, f.params.Err.Error(common.CCErrCommNotFound)
+	}
+	if err != nil {
+		blog.Errorf("[permission] failed to find the privilege of %s.%s, err: %v, rid: %s", objID, propertyID, err, f.params.ReqID)
+		return nil, f.params.Err.Error(common.CCErrCommDBSelectFailed)
+	}
+	return privilege, nil
+}
+
+func (f *fieldPermission) DeleteFieldPrivilege(objID, propertyID string) error {
+	if err := f.checkAdmin(); err != nil {
+		return err
+	}
+	if err := f.db.Table(common.BKTableNameFieldPrivilege).Delete(f.params.Context, f.filter(objID, propertyID)); err != nil {
+		blog.Errorf("[permission] failed to delete the privilege of %s.%s, err: %v, rid: %s", objID, propertyID, err, f.params.ReqID)
+		return f.params.Err.Error(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+func (f *fieldPermission) SearchFieldPrivileges(objID string) ([]metadata.FieldPrivilege, error) {
+	privileges := make([]metadata.FieldPrivilege, 0)
+	err := f.db.Table(common.BKTableNameFieldPrivilege).Find(f.filter(objID, "")).Sort(common.BKPropertyIDField).All(f.params.Context, &privileges)
+	if err != nil {
+		blog.Errorf("[permission] failed to search the privileges of %s, err: %v, rid: %s", objID, err, f.params.ReqID)
+		return nil, f.params.Err.Error(common.CCErrCommDBSelectFailed)
+	}
+	return privileges, nil
+}
+
+func (f *fieldPermission) Access(objIDs ...string) (*FieldAccess, error) {
+	access := &FieldAccess{rules: make(map[string]map[string]fieldRule)}
+	if len(objIDs) == 0 || util.InStrArr(f.admins, f.params.User) {
+		return access, nil
+	}
+
+	ctx := f.params.Context
+	cond := mapstr.MapStr{
+		common.BKOwnerIDField: f.params.SupplierAccount,
+		common.BKObjIDField:   mapstr.MapStr{common.BKDBIN: util.RemoveDuplicatesAndEmpty(objIDs)},
+	}
+	privileges := make([]metadata.FieldPrivilege, 0)
+	if err := f.db.Table(common.BKTableNameFieldPrivilege).Find(cond).All(ctx, &privileges); err != nil {
+		blog.Errorf("[permission] failed to search the privileges of %v, err: %v, rid: %s", objIDs, err, f.params.ReqID)
+		return nil, f.params.Err.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(privileges) == 0 {
+		return access, nil
+	}
+
+	groupIDs, err := local.UserGroupIDs(ctx, f.db, f.params.SupplierAccount, f.params.User)
+	if err != nil {
+		blog.Errorf("[permission] failed to search the user groups of %s, err: %v, rid: %s", f.params.User, err, f.params.ReqID)
+		return nil, f.params.Err.Error(common.CCErrCommDBSelectFailed)
+	}
+	for _, privilege := range privileges {
+		access.add(privilege, groupIDs)
+	}
+	return access, nil
+}
+
+// fieldRule the access of the user to a sensitive property
+type fieldRule struct {
+	mask     string
+	readable bool
+	writable bool
+}
+
+// FieldAccess the access of a user to the sensitive properties, the properties
+// without privileges can be read and written by everyone. The nil access allows everything.
+type FieldAccess struct {
+	// object id -> property id -> the rule
+	rules map[string]map[string]fieldRule
+}
+
+// add the rule of the privilege for the user in the user groups
+func (a *FieldAccess) add(privilege metadata.FieldPrivilege, groupIDs []string) {
+	rule := fieldRule{mask: privilege.Mask}
+	for _, groupID := range groupIDs {
+		if util.InStrArr(privilege.WriteGroups, groupID) {
+			rule.writable = true
+		}
+		if util.InStrArr(privilege.ReadGroups, groupID) {
+			rule.readable = true
+		}
+	}
+	rule.readable = rule.readable || rule.writable
+	if rule.readable && rule.writable {
+		return
+	}
+	if _, exists := a.rules[privilege.ObjectID]; !exists {
+		a.rules[privilege.ObjectID] = make(map[string]fieldRule)
+	}
+	a.rules[privilege.ObjectID][privilege.PropertyID] = rule
+}
+
+func (a *FieldAccess) rule(objID, propertyID string) (fieldRule, bool) {
+	if a == nil {
+		return fieldRule{}, false
+	}
+	rule, exists := a.rules[objID][propertyID]
+	return rule, exists
+}
+
+// Readable returns whether the user can read the property
+func (a *FieldAccess) Readable(objID, propertyID string) bool {
+	rule, exists := a.rule(objID, propertyID)
+	return !exists || rule.readable
+}
+
+// Writable returns whether the user can write the property
+func (a *FieldAccess) Writable(objID, propertyID string) bool {
+	rule, exists := a.rule(objID, propertyID)
+	return !exists || rule.writable
+}
+
+// Omitted returns whether the property is removed instead of redacted when the user can not read it
+func (a *FieldAccess) Omitted(objID, propertyID string) bool {
+	rule, exists := a.rule(objID, propertyID)
+	return exists && !rule.readable && rule.mask == metadata.FieldMaskOmit
+}
+
+// Mask hide the properties of the data that the user can not read, the data is changed in place.
+func (a *FieldAccess) Mask(objID string, data map[string]interface{}) {
+	if a == nil || data == nil {
+		return
+	}
+	for propertyID := range a.rules[objID] {
+		if _, exists := data[propertyID]; !exists || a.Readable(objID, propertyID) {
+			continue
+		}
+		if a.Omitted(objID, propertyID) {
+			delete(data, propertyID)
+			continue
+		}
+		data[propertyID] = metadata.FieldRedactedValue
+	}
+}
+
+// RemoveRedacted remove the redacted values of the properties that the user can not read from the data,
+// which are sent back unchanged by the clients, so that they are not written as the values.
+func (a *FieldAccess) RemoveRedacted(objID string, data map[string]interface{}) {
+	if a == nil || data == nil {
+		return
+	}
+	for propertyID := range a.rules[objID] {
+		if value, exists := data[propertyID]; exists && value == metadata.FieldRedactedValue && !a.Readable(objID, propertyID) {
+			delete(data, propertyID)
+		}
+	}
+}
+
+// Unwritable returns the properties in the data that the user can not write
+func (a *FieldAccess) Unwritable(objID string, data map[string]interface{}) []string {
+	denied := make([]string, 0)
+	for propertyID := range data {
+		if !a.Writable(objID, propertyID) {
+			denied = append(denied, propertyID)
+		}
+	}
+	sort.Strings(denied)
+	return denied
+}
+
+// Unreadable returns the properties used in the condition or the fields that the user can not read,
+// so that the sensitive values can not be guessed by searching with them.
+func (a *FieldAccess) Unreadable(objID string, cond interface{}, fields ...string) []string {
+	if a == nil || len(a.rules[objID]) == 0 {
+		return nil
+	}
+	properties := make(map[string]bool)
+	conditionProperties(cond, properties)
+	for _, field := range fields {
+		properties[strings.TrimLeft(field, "+-")] = true
+	}
+
+	denied := make([]string, 0)
+	for propertyID := range properties {
+		if !a.Readable(objID, propertyID) {
+			denied = append(denied, propertyID)
+		}
+	}
+	sort.Strings(denied)
+	return denied
+}
+
+// conditionProperties collect the properties of the mongodb style condition, the operators such as
+// $and and $or are walked through, the dotted fields are reduced to the property.
+func conditionProperties(cond interface{}, properties map[string]bool) {
+	switch value := cond.(type) {
+	case mapstr.MapStr:
+		conditionProperties(map[string]interface{}(value), properties)
+	case map[string]interface{}:
+		for key, item := range value {
+			if strings.HasPrefix(key, "$") {
+				conditionProperties(item, properties)
+				continue
+			}
+			properties[strings.SplitN(key, ".", 2)[0]] = true
+		}
+	case []interface{}:
+		for _, item := range value {
+			conditionProperties(item, properties)
+		}
+	case []map[string]interface{}:
+		for _, item := range value {
+			conditionProperties(item, properties)
+		}
+	case []mapstr.MapStr:
+		for _, item := range value {
+			conditionProperties(item, properties)
+		}
+	}
+}
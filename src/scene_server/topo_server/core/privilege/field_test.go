@@ -0,0 +1,102 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package privilege
+
+import (
+	"reflect"
+	"testing"
+
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+)
+
+func newTestAccess() *FieldAccess {
+	access := &FieldAccess{rules: make(map[string]map[string]fieldRule)}
+	access.add(metadata.FieldPrivilege{ObjectID: "host", PropertyID: "password", ReadGroups: []string{"ops"}, Mask: metadata.FieldMaskOmit}, []string{"dev"})
+	access.add(metadata.FieldPrivilege{ObjectID: "host", PropertyID: "bk_comment", ReadGroups: []string{"dev"}, Mask: metadata.FieldMaskRedact}, []string{"dev"})
+	access.add(metadata.FieldPrivilege{ObjectID: "host", PropertyID: "serial", Mask: metadata.FieldMaskRedact}, []string{"dev"})
+	access.add(metadata.FieldPrivilege{ObjectID: "host", PropertyID: "owner", WriteGroups: []string{"dev"}}, []string{"dev"})
+	return access
+}
+
+func TestFieldAccess(t *testing.T) {
+	access := newTestAccess()
+
+	cases := []struct {
+		propertyID string
+		readable   bool
+		writable   bool
+		omitted    bool
+	}{
+		{"password", false, false, true},
+		{"bk_comment", true, false, false},
+		{"serial", false, false, false},
+		{"owner", true, true, false},
+		{"bk_host_name", true, true, false},
+	}
+	for _, c := range cases {
+		if access.Readable("host", c.propertyID) != c.readable {
+			t.Errorf("readable of %s should be %v", c.propertyID, c.readable)
+		}
+		if access.Writable("host", c.propertyID) != c.writable {
+			t.Errorf("writable of %s should be %v", c.propertyID, c.writable)
+		}
+		if access.Omitted("host", c.propertyID) != c.omitted {
+			t.Errorf("omitted of %s should be %v", c.propertyID, c.omitted)
+		}
+	}
+
+	var none *FieldAccess
+	if !none.Readable("host", "password") || !none.Writable("host", "password") {
+		t.Errorf("the nil access should allow everything")
+	}
+}
+
+func TestFieldAccessMask(t *testing.T) {
+	access := newTestAccess()
+
+	data := map[string]interface{}{"password": "secret", "serial": "sn-1", "bk_comment": "c", "bk_host_name": "h"}
+	access.Mask("host", data)
+	expected := map[string]interface{}{"serial": metadata.FieldRedactedValue, "bk_comment": "c", "bk_host_name": "h"}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("mask result %v, expected %v", data, expected)
+	}
+
+	update := map[string]interface{}{"serial": metadata.FieldRedactedValue, "bk_comment": "c", "owner": "o"}
+	access.RemoveRedacted("host", update)
+	if _, exists := update["serial"]; exists {
+		t.Errorf("the redacted value should be removed")
+	}
+	if denied := access.Unwritable("host", update); !reflect.DeepEqual(denied, []string{"bk_comment"}) {
+		t.Errorf("unwritable %v, expected [bk_comment]", denied)
+	}
+}
+
+func TestFieldAccessUnreadable(t *testing.T) {
+	access := newTestAccess()
+
+	cond := mapstr.MapStr{
+		"bk_host_name": "h",
+		"$or": []interface{}{
+			map[string]interface{}{"serial": map[string]interface{}{"$regex": "sn"}},
+			mapstr.MapStr{"password.value": "x"},
+		},
+	}
+	denied := access.Unreadable("host", cond, "-bk_comment", "+serial")
+	if !reflect.DeepEqual(denied, []string{"password", "serial"}) {
+		t.Errorf("unreadable %v, expected [password serial]", denied)
+	}
+	if denied := access.Unreadable("set", cond); len(denied) != 0 {
+		t.Errorf("the properties of set should be readable, got %v", denied)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"context"
 	"strconv"
 
+	"icenter/src/auth/meta"
 	"icenter/src/common"
 	"icenter/src/common/blog"
 	"icenter/src/common/mapstr"
@@ -303,3 +304,42 @@ func (s *Service) DeleteAssociationInst(params types.ContextParams, pathParams,
 
 	}
 }
+
+// UpdateAssociationInst update the attribute values of an instance association
+func (s *Service) UpdateAssociationInst(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams("association_id"), 10, 64)
+	if err != nil {
+		return nil, params.Err.Error(common.CCErrCommParamsIsInvalid)
+	}
+
+	attributes, err := data.MapStr(metadata.AssociationFieldAttributes)
+	if err != nil {
+		blog.Errorf("update instance association %d, but got invalid attributes, err: %v, rid: %s", id, err, params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommParamsInvalid, metadata.AssociationFieldAttributes)
+	}
+
+	if err := s.Core.AssociationOperation().UpdateInst(params, id, attributes); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// TraverseAssociationInst walk the instance associations from the start instances in several hops,
+// returns the subgraph or the paths reached.
+func (s *Service) TraverseAssociationInst(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	request := &metadata.GraphTraversalParams{}
+	if err := data.MarshalJSONInto(request); err != nil {
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+	if len(request.Start) == 0 {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedSet, "start")
+	}
+
+	for _, node := range request.Start {
+		if err := s.authorizeInstance(params, meta.Find, node.ObjectID, node.InstID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.Core.AssociationOperation().TraverseInst(params, request)
+}
@@ -13,7 +13,10 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"strings"
+
 	"icenter/src/auth"
 
 	"icenter/src/auth/meta"
@@ -22,11 +25,15 @@ import (
 	"icenter/src/common/mapstr"
 	"icenter/src/common/metadata"
 	"icenter/src/common/util"
+	"icenter/src/scene_server/topo_server/core/privilege"
 	"icenter/src/scene_server/topo_server/core/types"
 )
 
 const CCTimeTypeParseFlag = "cc_time_type"
 
+// rollbackAssociationTarget the op target of the instance association audit logs
+const rollbackAssociationTarget = "instance_association"
+
 // AuditQuery search audit logs
 func (s *Service) AuditQuery(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
 	query := metadata.QueryInput{}
@@ -97,7 +104,7 @@ func (s *Service) AuditQuery(params types.ContextParams, pathParams, queryParams
 	}
 
 	blog.V(5).Infof("AuditQuery, AuditOperation parameter: %+v", query)
-	return s.Core.AuditOperation().Query(params, query)
+	return s.Core.AuditOperation().Query(params, query, s.Core.PermissionOperation().Field(params))
 }
 
 // InstanceAuditQuery search instance audit logs
@@ -217,5 +224,173 @@ func (s *Service) InstanceAuditQuery(params types.ContextParams, pathParams, que
 	}
 
 	blog.V(4).Infof("InstanceAuditQuery failed, AuditOperation parameter: %+v", query)
-	return s.Core.AuditOperation().Query(params, query)
+	return s.Core.AuditOperation().Query(params, query, s.Core.PermissionOperation().Field(params))
+}
+
+// RollbackAuditLogs roll back the instance and association changes recorded in the audit logs,
+// the changes are returned without applied in dry run mode.
+func (s *Service) RollbackAuditLogs(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := metadata.RollbackParams{}
+	if err := data.MarshalJSONInto(&input); nil != err {
+		blog.Errorf("RollbackAuditLogs failed, failed to parse the input (%#v), error info is %s, rid: %s", data, err.Error(), params.ReqID)
+		return nil, params.Err.New(common.CCErrCommJSONUnmarshalFailed, err.Error())
+	}
+	if len(input.AuditIDs) == 0 && len(input.RequestID) == 0 {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedSet, "audit_ids")
+	}
+
+	// plan the changes first to authorize all the instances to be changed
+	dryRun := input
+	dryRun.DryRun = true
+	plan, err := s.Core.AuditOperation().Rollback(params, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	objIDs := make([]string, 0)
+	for _, change := range plan.Changes {
+		objIDs = append(objIDs, change.ObjectID)
+	}
+	access, err := s.Core.PermissionOperation().Field(params).Access(objIDs...)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range plan.Changes {
+		if change.Skipped {
+			continue
+		}
+		if err := s.authorizeRollback(params, access, change); err != nil {
+			return nil, err
+		}
+	}
+	if input.DryRun {
+		maskRollback(access, plan)
+		return plan, nil
+	}
+
+	// the changes of all the audit logs are rolled back together, or none of them.
+	tx, err := s.Txn.StartTransaction(context.Background())
+	if err != nil {
+		blog.Errorf("RollbackAuditLogs failed, start transaction failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrObjectDBOpErrno)
+	}
+	params.Header = tx.TxnInfo().IntoHeader(params.Header)
+	result, err := s.Core.AuditOperation().Rollback(params, input)
+	if err != nil {
+		if txnErr := tx.Abort(context.Background()); txnErr != nil {
+			blog.Errorf("RollbackAuditLogs failed, abort transaction[id: %s] failed, err: %v, rid: %s", tx.TxnInfo().TxnID, txnErr, params.ReqID)
+		}
+		return nil, err
+	}
+	if txnErr := tx.Commit(context.Background()); txnErr != nil {
+		blog.Errorf("RollbackAuditLogs failed, commit transaction[id: %s] failed, err: %v, rid: %s", tx.TxnInfo().TxnID, txnErr, params.ReqID)
+		return nil, params.Err.Error(common.CCErrObjectDBOpErrno)
+	}
+	return result, nil
+}
+
+// authorizeRollback authorize the change to roll back an audit log, the deleted instance
+// or association is authorized to be created as it is restored.
+func (s *Service) authorizeRollback(params types.ContextParams, access *privilege.FieldAccess, change metadata.RollbackChange) error {
+	action := meta.Update
+	switch change.Action {
+	case metadata.RollbackActionCreate:
+		action = meta.Create
+	case metadata.RollbackActionDelete:
+		action = meta.Delete
+	}
+
+	if change.ObjectID == rollbackAssociationTarget {
+		var asstID int64
+		if action != meta.Create {
+			asstID = change.InstID
+		}
+		if err := s.AuthManager.AuthorizeByInstanceAssociationID(params.Context, params.Header, action, asstID); err != nil {
+			blog.Errorf("authorize %s on instance association %d failed, err: %+v, rid: %s", action, asstID, err, params.ReqID)
+			return params.Err.Error(common.CCErrCommAuthorizeFailed)
+		}
+		return nil
+	}
+
+	if err := checkRollbackFieldWrite(params, access, change); err != nil {
+		return err
+	}
+	if action != meta.Create {
+		return s.authorizeInstance(params, action, change.ObjectID, change.InstID)
+	}
+
+	// the instance is restored within the business it belonged to
+	var bizID int64
+	var err error
+	switch change.ObjectID {
+	case common.BKInnerObjIDSet, common.BKInnerObjIDModule:
+		bizID, err = change.Restore.Int64(common.BKAppIDField)
+	case common.BKInnerObjIDApp:
+	default:
+		if _, exist := change.Restore.Get(metadata.BKMetadata); exist {
+			bizID, err = metadata.ParseBizIDFromData(change.Restore)
+		}
+	}
+	if err != nil {
+		blog.Errorf("parse the business of %s instance %d to restore failed, err: %v, rid: %s", change.ObjectID, change.InstID, err, params.ReqID)
+		return params.Err.Errorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+	}
+
+	switch change.ObjectID {
+	case common.BKInnerObjIDHost:
+		err = s.AuthManager.AuthorizeCreateHost(params.Context, params.Header, bizID)
+	case common.BKInnerObjIDSet:
+		err = s.AuthManager.AuthorizeResourceCreate(params.Context, params.Header, bizID, meta.ModelSet)
+	case common.BKInnerObjIDModule:
+		err = s.AuthManager.AuthorizeResourceCreate(params.Context, params.Header, bizID, meta.ModelModule)
+	case common.BKInnerObjIDApp:
+		err = s.AuthManager.AuthorizeResourceCreate(params.Context, params.Header, 0, meta.Business)
+	default:
+		err = s.AuthManager.AuthorizeInstanceCreate(params.Context, params.Header, bizID, change.ObjectID)
+	}
+	if err != nil {
+		blog.Errorf("authorize to restore %s instance %d failed, err: %+v, rid: %s", change.ObjectID, change.InstID, err, params.ReqID)
+		return params.Err.Error(common.CCErrCommAuthorizeFailed)
+	}
+	return nil
+}
+
+// checkRollbackFieldWrite the properties restored by the change can not be the ones that the user can not write
+func checkRollbackFieldWrite(params types.ContextParams, access *privilege.FieldAccess, change metadata.RollbackChange) error {
+	data := make(map[string]interface{})
+	switch change.Action {
+	case metadata.RollbackActionCreate:
+		data = change.Restore
+	case metadata.RollbackActionUpdate:
+		for _, diff := range change.Diff {
+			data[diff.Field] = diff.Restored
+		}
+	}
+	if denied := access.Unwritable(change.ObjectID, data); len(denied) != 0 {
+		blog.Errorf("[api-privilege] the user %s can not roll back the properties %v of %s, rid: %s", params.User, denied, change.ObjectID, params.ReqID)
+		return params.Err.Errorf(common.CCErrorTopoFieldNoWritePermission, strings.Join(denied, ","))
+	}
+	return nil
+}
+
+// maskRollback hide the properties of the changes that the user can not read
+func maskRollback(access *privilege.FieldAccess, result *metadata.RollbackResult) {
+	for index := range result.Changes {
+		change := &result.Changes[index]
+		access.Mask(change.ObjectID, change.Current)
+		access.Mask(change.ObjectID, change.Restore)
+		diffs := make([]metadata.RollbackFieldDiff, 0, len(change.Diff))
+		for _, diff := range change.Diff {
+			if access.Readable(change.ObjectID, diff.Field) {
+				diffs = append(diffs, diff)
+				continue
+			}
+			if access.Omitted(change.ObjectID, diff.Field) {
+				continue
+			}
+			diff.Current = metadata.FieldRedactedValue
+			diff.Restored = metadata.FieldRedactedValue
+			diffs = append(diffs, diff)
+		}
+		change.Diff = diffs
+	}
 }
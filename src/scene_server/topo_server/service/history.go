@@ -0,0 +1,174 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"icenter/src/auth/meta"
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/scene_server/topo_server/core/privilege"
+	"icenter/src/scene_server/topo_server/core/types"
+)
+
+// SearchInstanceVersions search all the versions of an instance rebuilt from the audit logs
+func (s *Service) SearchInstanceVersions(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	objID := pathParams(common.BKObjIDField)
+	instID, err := strconv.ParseInt(pathParams("inst_id"), 10, 64)
+	if err != nil {
+		blog.Errorf("SearchInstanceVersions failed, invalid inst_id %s, rid: %s", pathParams("inst_id"), params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommParamsInvalid, "inst_id")
+	}
+
+	if err := s.authorizeInstance(params, meta.Find, objID, instID); err != nil {
+		return nil, err
+	}
+	result, err := s.Core.HistoryOperation().SearchInstanceVersions(params, objID, instID)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := s.Core.PermissionOperation().Field(params).Access(objID)
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range result.Info {
+		access.Mask(objID, version.Data)
+	}
+	return result, nil
+}
+
+// GetInstanceAsOf get an instance as of the time of the as_of query parameter
+func (s *Service) GetInstanceAsOf(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	objID := pathParams(common.BKObjIDField)
+	instID, err := strconv.ParseInt(pathParams("inst_id"), 10, 64)
+	if err != nil {
+		blog.Errorf("GetInstanceAsOf failed, invalid inst_id %s, rid: %s", pathParams("inst_id"), params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommParamsInvalid, "inst_id")
+	}
+	asOf, err := parseAsOf(params, queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeInstance(params, meta.Find, objID, instID); err != nil {
+		return nil, err
+	}
+	inst, err := s.Core.HistoryOperation().GetInstanceAsOf(params, objID, instID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := s.Core.PermissionOperation().Field(params).Access(objID)
+	if err != nil {
+		return nil, err
+	}
+	access.Mask(objID, inst)
+	return inst, nil
+}
+
+// SearchBusinessTopoAsOf search the mainline instance topo of the business as of the time of the as_of query parameter
+func (s *Service) SearchBusinessTopoAsOf(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	bizID, err := strconv.ParseInt(pathParams(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("SearchBusinessTopoAsOf failed, invalid %s %s, rid: %s", common.BKAppIDField, pathParams(common.BKAppIDField), params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+	}
+	asOf, err := parseAsOf(params, queryParams)
+	if err != nil {
+		return nil, err
+	}
+	withDetail, _ := strconv.ParseBool(queryParams("with_detail"))
+
+	if err := s.AuthManager.AuthorizeByBusinessID(params.Context, params.Header, meta.Find, bizID); err != nil {
+		blog.Errorf("SearchBusinessTopoAsOf failed, authorize on business %d failed, err: %+v, rid: %s", bizID, err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommAuthorizeFailed)
+	}
+
+	topo, err := s.Core.HistoryOperation().SearchMainlineInstanceTopoAsOf(params, bizID, withDetail, asOf)
+	if err != nil || !withDetail {
+		return topo, err
+	}
+
+	access, err := s.Core.PermissionOperation().Field(params).Access(topoObjectIDs(topo, nil)...)
+	if err != nil {
+		return nil, err
+	}
+	maskTopo(access, topo)
+	return topo, nil
+}
+
+// topoObjectIDs returns the object ids of the nodes in the topo
+func topoObjectIDs(node *metadata.TopoInstanceNode, objIDs []string) []string {
+	if node == nil {
+		return objIDs
+	}
+	objIDs = append(objIDs, node.ObjectID)
+	for _, child := range node.Children {
+		objIDs = topoObjectIDs(child, objIDs)
+	}
+	return objIDs
+}
+
+// maskTopo hide the properties of the nodes' detail in the topo that the user can not read
+func maskTopo(access *privilege.FieldAccess, node *metadata.TopoInstanceNode) {
+	if node == nil {
+		return
+	}
+	access.Mask(node.ObjectID, node.Detail)
+	for _, child := range node.Children {
+		maskTopo(access, child)
+	}
+}
+
+// parseAsOf parse the as_of query parameter in RFC3339 format
+func parseAsOf(params types.ContextParams, queryParams ParamsGetter) (time.Time, error) {
+	value := queryParams("as_of")
+	if len(value) == 0 {
+		return time.Time{}, params.Err.Errorf(common.CCErrCommParamsNeedSet, "as_of")
+	}
+	asOf, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		blog.Errorf("invalid as_of %s, err: %v, rid: %s", value, err, params.ReqID)
+		return time.Time{}, params.Err.Errorf(common.CCErrCommParamsInvalid, "as_of")
+	}
+	return asOf, nil
+}
+
+// authorizeInstance authorize the action on an instance of any model
+func (s *Service) authorizeInstance(params types.ContextParams, action meta.Action, objID string, instID int64) error {
+	var err error
+	switch objID {
+	case common.BKInnerObjIDHost:
+		err = s.AuthManager.AuthorizeByHostsIDs(params.Context, params.Header, action, instID)
+	case common.BKInnerObjIDProc:
+		err = s.AuthManager.AuthorizeByProcessID(params.Context, params.Header, action, instID)
+	case common.BKInnerObjIDModule:
+		err = s.AuthManager.AuthorizeByModuleID(params.Context, params.Header, action, instID)
+	case common.BKInnerObjIDSet:
+		err = s.AuthManager.AuthorizeBySetID(params.Context, params.Header, action, instID)
+	case common.BKInnerObjIDApp:
+		err = s.AuthManager.AuthorizeByBusinessID(params.Context, params.Header, action, instID)
+	default:
+		err = s.AuthManager.AuthorizeByInstanceID(params.Context, params.Header, action, objID, instID)
+	}
+	if err != nil {
+		blog.Errorf("authorize %s on %s instance %d failed, err: %+v, rid: %s", action, objID, instID, err, params.ReqID)
+		return params.Err.Error(common.CCErrCommAuthorizeFailed)
+	}
+	return nil
+}
@@ -56,6 +56,15 @@ func (s *Service) CreateInst(params types.ContextParams, pathParams, queryParams
 			blog.Errorf("create instance failed, import object[%s] instance batch, but got invalid BatchInfo:[%v], err: %+v", objID, batchInfo, err)
 			return nil, params.Err.Error(common.CCErrCommParamsIsInvalid)
 		}
+		if batchInfo.BatchInfo != nil {
+			rows := make([]map[string]interface{}, 0, len(*batchInfo.BatchInfo))
+			for _, row := range *batchInfo.BatchInfo {
+				rows = append(rows, row)
+			}
+			if err := s.checkFieldWrite(params, objID, rows...); err != nil {
+				return nil, err
+			}
+		}
 
 		setInst, err := s.Core.InstOperation().CreateInstBatch(params, obj, batchInfo)
 		if nil != err {
@@ -82,6 +91,10 @@ func (s *Service) CreateInst(params types.ContextParams, pathParams, queryParams
 		return setInst, nil
 	}
 
+	if err := s.checkFieldWrite(params, objID, data); err != nil {
+		return nil, err
+	}
+
 	setInst, err := s.Core.InstOperation().CreateInst(params, obj, data)
 	if nil != err {
 		blog.Errorf("failed to create a new %s, %s", objID, err.Error())
@@ -182,6 +195,14 @@ func (s *Service) UpdateInsts(params types.ContextParams, pathParams, queryParam
 		return nil, err
 	}
 
+	updateDatas := make([]map[string]interface{}, 0, len(updateCondition.Update))
+	for _, item := range updateCondition.Update {
+		updateDatas = append(updateDatas, item.InstInfo)
+	}
+	if err := s.checkFieldWrite(params, objID, updateDatas...); err != nil {
+		return nil, err
+	}
+
 	instanceIDs := make([]int64, 0)
 	for _, item := range updateCondition.Update {
 		instanceIDs = append(instanceIDs, item.InstID)
@@ -234,6 +255,9 @@ func (s *Service) UpdateInst(params types.ContextParams, pathParams, queryParams
 	if yes {
 		data.Remove("metadata")
 	}
+	if err := s.checkFieldWrite(params, objID, data); err != nil {
+		return nil, err
+	}
 
 	cond := condition.CreateCondition()
 	cond.Field(obj.GetInstIDFieldName()).Eq(instID)
@@ -280,6 +304,10 @@ func (s *Service) SearchInsts(params types.ContextParams, pathParams, queryParam
 	query.Limit = page.Limit
 	query.Sort = page.Sort
 	query.Start = page.Start
+	access, err := s.checkFieldRead(params, objID, query.Condition, query.Sort)
+	if err != nil {
+		return nil, err
+	}
 
 	cnt, instItems, err := s.Core.InstOperation().FindInst(params, obj, query, false)
 	if nil != err {
@@ -289,7 +317,7 @@ func (s *Service) SearchInsts(params types.ContextParams, pathParams, queryParam
 
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
-	result.Set("info", instItems)
+	result.Set("info", maskInsts(access, objID, instItems))
 	return result, nil
 }
 
@@ -317,6 +345,10 @@ func (s *Service) SearchInstAndAssociationDetail(params types.ContextParams, pat
 	query.Limit = page.Limit
 	query.Sort = page.Sort
 	query.Start = page.Start
+	access, err := s.checkFieldRead(params, objID, query.Condition, query.Sort)
+	if err != nil {
+		return nil, err
+	}
 
 	cnt, instItems, err := s.Core.InstOperation().FindInst(params, obj, query, true)
 	if nil != err {
@@ -326,7 +358,7 @@ func (s *Service) SearchInstAndAssociationDetail(params types.ContextParams, pat
 
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
-	result.Set("info", instItems)
+	result.Set("info", maskInsts(access, objID, instItems))
 	return result, nil
 }
 
@@ -354,6 +386,10 @@ func (s *Service) SearchInstByObject(params types.ContextParams, pathParams, que
 	query.Limit = page.Limit
 	query.Sort = page.Sort
 	query.Start = page.Start
+	access, err := s.checkFieldRead(params, objID, query.Condition, query.Sort)
+	if err != nil {
+		return nil, err
+	}
 	cnt, instItems, err := s.Core.InstOperation().FindInst(params, obj, query, false)
 	if nil != err {
 		blog.Errorf("[api-inst] failed to find the objects(%s), error info is %s", pathParams("bk_obj_id"), err.Error())
@@ -362,7 +398,7 @@ func (s *Service) SearchInstByObject(params types.ContextParams, pathParams, que
 
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
-	result.Set("info", instItems)
+	result.Set("info", maskInsts(access, objID, instItems))
 	return result, nil
 }
 
@@ -377,6 +413,11 @@ func (s *Service) SearchInstByAssociation(params types.ContextParams, pathParams
 		return nil, err
 	}
 
+	access, err := s.checkAssociationFieldRead(params, objID, data)
+	if err != nil {
+		return nil, err
+	}
+
 	cnt, instItems, err := s.Core.InstOperation().FindInstByAssociationInst(params, obj, data)
 	if nil != err {
 		blog.Errorf("[api-inst] failed to find the objects(%s), error info is %s", pathParams("bk_obj_id"), err.Error())
@@ -385,7 +426,7 @@ func (s *Service) SearchInstByAssociation(params types.ContextParams, pathParams
 
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
-	result.Set("info", instItems)
+	result.Set("info", maskInsts(access, objID, instItems))
 	return result, nil
 }
 
@@ -409,6 +450,11 @@ func (s *Service) SearchInstByInstID(params types.ContextParams, pathParams, que
 	queryCond := &metadata.QueryInput{}
 	queryCond.Condition = cond.ToMapStr()
 
+	access, err := s.checkFieldRead(params, objID, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
 	cnt, instItems, err := s.Core.InstOperation().FindInst(params, obj, queryCond, false)
 	if nil != err {
 		blog.Errorf("[api-inst] failed to find the objects(%s), error info is %s", pathParams("bk_obj_id"), err.Error())
@@ -417,7 +463,7 @@ func (s *Service) SearchInstByInstID(params types.ContextParams, pathParams, que
 
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
-	result.Set("info", instItems)
+	result.Set("info", maskInsts(access, objID, instItems))
 
 	return result, nil
 }
@@ -476,3 +522,36 @@ func (s *Service) SearchInstTopo(params types.ContextParams, pathParams, queryPa
 
 	return instItems, err
 }
+
+// AggregateInsts group the instances of the model by the properties and compute the metrics of every group,
+// the instances are limited to the business if the bk_biz_id is set.
+func (s *Service) AggregateInsts(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	objID := pathParams("bk_obj_id")
+	obj, err := s.Core.ObjectOperation().FindSingleObject(params, objID)
+	if nil != err {
+		blog.Errorf("[api-inst] failed to find the objects(%s), error info is %s", objID, err.Error())
+		return nil, err
+	}
+
+	request := &metadata.AggregateInstanceParams{}
+	if err := data.MarshalJSONInto(request); nil != err {
+		blog.Errorf("[api-inst] failed to parse the aggregate params (%#v), error info is %s", data, err.Error())
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	// the groups and the metrics disclose the values of the properties as the searches do.
+	fields := make([]string, 0, len(request.GroupBy)+len(request.Metrics))
+	for _, groupBy := range request.GroupBy {
+		fields = append(fields, groupBy.Field)
+	}
+	for _, metric := range request.Metrics {
+		if metric.Field != "" {
+			fields = append(fields, metric.Field)
+		}
+	}
+	if _, err := s.checkFieldRead(params, objID, request.Condition, strings.Join(fields, ",")); err != nil {
+		return nil, err
+	}
+
+	return s.Core.InstOperation().AggregateInst(params, obj, request)
+}
@@ -24,6 +24,7 @@ import (
 	"icenter/src/common/blog"
 	"icenter/src/common/condition"
 	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
 	gparams "icenter/src/common/paraparse"
 	"icenter/src/common/util"
 	"icenter/src/scene_server/topo_server/core/types"
@@ -37,6 +38,10 @@ func (s *Service) CreateBusiness(params types.ContextParams, pathParams, queryPa
 		return nil, err
 	}
 
+	if err := s.checkFieldWrite(params, common.BKInnerObjIDApp, data); err != nil {
+		return nil, err
+	}
+
 	data.Set(common.BKDefaultField, 0)
 	business, err := s.Core.BusinessOperation().CreateBusiness(params, obj, data)
 	if err != nil {
@@ -97,6 +102,9 @@ func (s *Service) UpdateBusiness(params types.ContextParams, pathParams, queryPa
 		blog.Errorf("[api-business]failed to parse the biz id, error info is %s", err.Error())
 		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "business id")
 	}
+	if err := s.checkFieldWrite(params, common.BKInnerObjIDApp, data); err != nil {
+		return nil, err
+	}
 
 	err = s.Core.BusinessOperation().UpdateBusiness(params, data, obj, bizID)
 	if err != nil {
@@ -326,6 +334,10 @@ func (s *Service) SearchBusiness(params types.ContextParams, pathParams, queryPa
 	innerCond.Field(common.BKDefaultField).Eq(0)
 	innerCond.SetPage(searchCond.Page)
 	innerCond.SetFields(searchCond.Fields)
+	access, err := s.checkFieldRead(params, common.BKInnerObjIDApp, innerCond.ToMapStr(), metadata.ParsePage(searchCond.Page).Sort)
+	if err != nil {
+		return nil, err
+	}
 
 	cnt, instItems, err := s.Core.BusinessOperation().FindBusiness(params, obj, searchCond.Fields, innerCond)
 	if nil != err {
@@ -335,7 +347,7 @@ func (s *Service) SearchBusiness(params types.ContextParams, pathParams, queryPa
 
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
-	result.Set("info", instItems)
+	result.Set("info", maskInsts(access, common.BKInnerObjIDApp, instItems))
 
 	return result, nil
 }
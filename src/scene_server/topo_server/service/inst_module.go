@@ -45,6 +45,10 @@ func (s *Service) CreateModule(params types.ContextParams, pathParams, queryPara
 		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "set id")
 	}
 
+	if err := s.checkFieldWrite(params, common.BKInnerObjIDModule, data); err != nil {
+		return nil, err
+	}
+
 	module, err := s.Core.ModuleOperation().CreateModule(params, obj, bizID, setID, data)
 	if err != nil {
 		blog.Errorf("[api-module] create module failed, error info is %s", err.Error())
@@ -135,6 +139,10 @@ func (s *Service) UpdateModule(params types.ContextParams, pathParams, queryPara
 		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "module id")
 	}
 
+	if err := s.checkFieldWrite(params, common.BKInnerObjIDModule, data); err != nil {
+		return nil, err
+	}
+
 	err = s.Core.ModuleOperation().UpdateModule(params, data, obj, bizID, setID, moduleID)
 	if err != nil {
 		blog.Errorf("update module failed, err: %+v", err)
@@ -188,6 +196,10 @@ func (s *Service) SearchModule(params types.ContextParams, pathParams, queryPara
 	queryCond.Limit = page.Limit
 	queryCond.Sort = page.Sort
 	queryCond.Start = page.Start
+	access, err := s.checkFieldRead(params, common.BKInnerObjIDModule, queryCond.Condition, queryCond.Sort)
+	if err != nil {
+		return nil, err
+	}
 
 	cnt, instItems, err := s.Core.ModuleOperation().FindModule(params, obj, queryCond)
 	if nil != err {
@@ -197,7 +209,7 @@ func (s *Service) SearchModule(params types.ContextParams, pathParams, queryPara
 
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
-	result.Set("info", instItems)
+	result.Set("info", maskInsts(access, common.BKInnerObjIDModule, instItems))
 
 	return result, nil
 }
@@ -40,6 +40,10 @@ func (s *Service) CreateSet(params types.ContextParams, pathParams, queryParams
 		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "business id")
 	}
 
+	if err := s.checkFieldWrite(params, common.BKInnerObjIDSet, data); err != nil {
+		return nil, err
+	}
+
 	set, err := s.Core.SetOperation().CreateSet(params, obj, bizID, data)
 	if err != nil {
 		return nil, err
@@ -152,6 +156,10 @@ func (s *Service) UpdateSet(params types.ContextParams, pathParams, queryParams
 		return nil, err
 	}
 
+	if err := s.checkFieldWrite(params, common.BKInnerObjIDSet, data); err != nil {
+		return nil, err
+	}
+
 	err = s.Core.SetOperation().UpdateSet(params, data, obj, bizID, setID)
 	if err != nil {
 		blog.Errorf("update set failed, err: %+v", err)
@@ -198,6 +206,10 @@ func (s *Service) SearchSet(params types.ContextParams, pathParams, queryParams
 	queryCond.Start = page.Start
 	queryCond.Sort = page.Sort
 	queryCond.Limit = page.Limit
+	access, err := s.checkFieldRead(params, common.BKInnerObjIDSet, queryCond.Condition, queryCond.Sort)
+	if err != nil {
+		return nil, err
+	}
 
 	cnt, instItems, err := s.Core.SetOperation().FindSet(params, obj, queryCond)
 	if nil != err {
@@ -207,7 +219,7 @@ func (s *Service) SearchSet(params types.ContextParams, pathParams, queryParams
 
 	result := mapstr.MapStr{}
 	result.Set("count", cnt)
-	result.Set("info", instItems)
+	result.Set("info", maskInsts(access, common.BKInnerObjIDSet, instItems))
 
 	return result, nil
 
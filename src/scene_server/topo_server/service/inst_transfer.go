@@ -0,0 +1,217 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/errors"
+	"icenter/src/common/metadata"
+	"icenter/src/common/spreadsheet"
+	"icenter/src/scene_server/topo_server/core/types"
+)
+
+// maxImportFileSize the max size of the uploaded spreadsheet
+const maxImportFileSize = 32 << 20
+
+// initInstTransfer registers the routes of the instance import and export, they're not actions
+// because the spreadsheets are uploaded and downloaded instead of the json data.
+func (s *Service) initInstTransfer(api *restful.WebService) {
+	api.Route(api.POST("/inst/transfer/import/{owner_id}/{bk_obj_id}").To(s.ImportInsts))
+	api.Route(api.POST("/inst/transfer/export/{owner_id}/{bk_obj_id}").
+		Produces(spreadsheet.ContentTypeXLSX, spreadsheet.ContentTypeCSV, restful.MIME_JSON).To(s.ExportInsts))
+}
+
+// ImportInsts imports the instances of the model from the uploaded csv or xlsx file,
+// the rows are upserted by the unique keys of the model, nothing is written if dry_run is true.
+func (s *Service) ImportInsts(req *restful.Request, resp *restful.Response) {
+	params := s.newContextParams(req)
+	objID := req.PathParameter(common.BKObjIDField)
+	if bizID := req.QueryParameter(common.BKAppIDField); len(bizID) != 0 {
+		md := metadata.NewMetaDataFromBusinessID(bizID)
+		params.MetaData = &md
+	}
+	dryRun, _ := strconv.ParseBool(req.QueryParameter("dry_run"))
+
+	data, format, err := readSpreadsheet(params, req)
+	if err != nil {
+		s.sendResult(resp, nil, err)
+		return
+	}
+	reader, err := spreadsheet.NewReader(format, data)
+	if err != nil {
+		blog.Errorf("import %s instances failed, invalid %s file, err: %v, rid: %s", objID, format, err, params.ReqID)
+		s.sendResult(resp, nil, params.Err.New(common.CCErrCommParamsIsInvalid, err.Error()))
+		return
+	}
+
+	obj, err := s.Core.ObjectOperation().FindSingleObject(params, objID)
+	if err != nil {
+		blog.Errorf("import %s instances failed, find the object failed, err: %v, rid: %s", objID, err, params.ReqID)
+		s.sendResult(resp, nil, err)
+		return
+	}
+
+	access, err := s.Core.PermissionOperation().Field(params).Access(objID)
+	if err != nil {
+		s.sendResult(resp, nil, err)
+		return
+	}
+
+	result, err := s.Core.InstTransferOperation().ImportInsts(params, obj, reader, dryRun, access)
+	if err != nil {
+		blog.Errorf("import %s instances failed, err: %v, rid: %s", objID, err, params.ReqID)
+	}
+	s.sendResult(resp, result, err)
+}
+
+// readSpreadsheet reads the spreadsheet from the file field of the multipart form, or the request body,
+// the format is the format query parameter, or it's decided by the file name or the content type.
+func readSpreadsheet(params types.ContextParams, req *restful.Request) ([]byte, spreadsheet.Format, error) {
+	formatName := req.QueryParameter("format")
+	var body io.Reader = req.Request.Body
+	mediaType, _, _ := mime.ParseMediaType(req.Request.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := req.Request.ParseMultipartForm(maxImportFileSize); err != nil {
+			blog.Errorf("parse the multipart form failed, err: %v, rid: %s", err, params.ReqID)
+			return nil, "", params.Err.Error(common.CCErrCommHTTPReadBodyFailed)
+		}
+		file, header, err := req.Request.FormFile("file")
+		if err != nil {
+			blog.Errorf("get the uploaded file failed, err: %v, rid: %s", err, params.ReqID)
+			return nil, "", params.Err.Errorf(common.CCErrCommParamsNeedSet, "file")
+		}
+		defer file.Close()
+		body = file
+		if len(formatName) == 0 {
+			formatName = header.Filename
+		}
+	} else if len(formatName) == 0 {
+		formatName = mediaType
+	}
+
+	format, err := spreadsheet.ParseFormat(formatName)
+	if err != nil {
+		blog.Errorf("unsupported spreadsheet format %s, rid: %s", formatName, params.ReqID)
+		return nil, "", params.Err.Errorf(common.CCErrCommParamsIsInvalid, "format")
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxImportFileSize+1))
+	if err != nil {
+		blog.Errorf("read the spreadsheet failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, "", params.Err.Error(common.CCErrCommHTTPReadBodyFailed)
+	}
+	if len(data) > maxImportFileSize {
+		return nil, "", params.Err.Error(common.CCErrCommOverLimit)
+	}
+	return data, format, nil
+}
+
+// ExportInsts streams the instances of the model matching the condition as a csv or xlsx file
+func (s *Service) ExportInsts(req *restful.Request, resp *restful.Response) {
+	params := s.newContextParams(req)
+	objID := req.PathParameter(common.BKObjIDField)
+
+	request := new(metadata.InstExportRequest)
+	body, err := ioutil.ReadAll(req.Request.Body)
+	if err != nil {
+		blog.Errorf("export %s instances failed, read the body failed, err: %v, rid: %s", objID, err, params.ReqID)
+		s.sendResult(resp, nil, params.Err.Error(common.CCErrCommHTTPReadBodyFailed))
+		return
+	}
+	if len(body) != 0 {
+		if err := json.Unmarshal(body, request); err != nil {
+			blog.Errorf("export %s instances failed, invalid body %s, err: %v, rid: %s", objID, body, err, params.ReqID)
+			s.sendResult(resp, nil, params.Err.Error(common.CCErrCommJSONUnmarshalFailed))
+			return
+		}
+	}
+
+	format := spreadsheet.FormatXLSX
+	if formatName := req.QueryParameter("format"); len(formatName) != 0 {
+		if format, err = spreadsheet.ParseFormat(formatName); err != nil {
+			s.sendResult(resp, nil, params.Err.Errorf(common.CCErrCommParamsIsInvalid, "format"))
+			return
+		}
+	}
+
+	obj, err := s.Core.ObjectOperation().FindSingleObject(params, objID)
+	if err != nil {
+		blog.Errorf("export %s instances failed, find the object failed, err: %v, rid: %s", objID, err, params.ReqID)
+		s.sendResult(resp, nil, err)
+		return
+	}
+	access, err := s.checkFieldRead(params, objID, request.Condition, "")
+	if err != nil {
+		s.sendResult(resp, nil, err)
+		return
+	}
+
+	// the rows are buffered, so that the errors before the first flush are still sent as json.
+	counter := &countingWriter{writer: resp}
+	buffer := bufio.NewWriterSize(counter, 64<<10)
+	resp.Header().Set("Content-Type", format.ContentType())
+	resp.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", objID, format))
+	writer, err := spreadsheet.NewWriter(format, buffer)
+	if err == nil {
+		err = s.Core.InstTransferOperation().ExportInsts(params, obj, request, writer, access)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		err = buffer.Flush()
+	}
+	if err != nil {
+		blog.Errorf("export %s instances failed, %d bytes are sent, err: %v, rid: %s", objID, counter.written, err, params.ReqID)
+		if counter.written == 0 {
+			resp.Header().Del("Content-Disposition")
+			s.sendResult(resp, nil, err)
+		}
+	}
+}
+
+// sendResult sends the data or the error as the actions do
+func (s *Service) sendResult(resp *restful.Response, data interface{}, err error) {
+	if err == nil {
+		s.sendResponse(resp, common.CCSuccess, data)
+		return
+	}
+	switch e := err.(type) {
+	case errors.CCErrorCoder:
+		s.sendCompleteResponse(resp, e.GetCode(), err.Error(), data)
+	default:
+		s.sendCompleteResponse(resp, common.CCSystemBusy, err.Error(), data)
+	}
+}
+
+type countingWriter struct {
+	writer  io.Writer
+	written int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.writer.Write(p)
+	c.written += int64(n)
+	return n, err
+}
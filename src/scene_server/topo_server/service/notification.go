@@ -0,0 +1,139 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/scene_server/topo_server/core/types"
+)
+
+// CreateNotificationRule create a notification rule
+func (s *Service) CreateNotificationRule(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	rule := metadata.NotificationRule{}
+	if err := data.MarshalJSONInto(&rule); err != nil {
+		blog.Errorf("create notification rule failed, invalid data: %v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Err.New(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Notification().CreateRule(params.Context, params.Header, rule)
+	if err != nil {
+		blog.Errorf("create notification rule failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("create notification rule failed, err: %s, rid: %s", resp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return resp.Data, nil
+}
+
+// UpdateNotificationRule update a notification rule
+func (s *Service) UpdateNotificationRule(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("update notification rule failed, invalid id %s, rid: %s", pathParams("id"), params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommParamsIsInvalid, "id")
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Notification().UpdateRule(params.Context, params.Header, id, data)
+	if err != nil {
+		blog.Errorf("update notification rule %d failed, err: %v, rid: %s", id, err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("update notification rule %d failed, err: %s, rid: %s", id, resp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return nil, nil
+}
+
+// DeleteNotificationRule delete a notification rule
+func (s *Service) DeleteNotificationRule(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("delete notification rule failed, invalid id %s, rid: %s", pathParams("id"), params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommParamsIsInvalid, "id")
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Notification().DeleteRule(params.Context, params.Header, id)
+	if err != nil {
+		blog.Errorf("delete notification rule %d failed, err: %v, rid: %s", id, err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("delete notification rule %d failed, err: %s, rid: %s", id, resp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return nil, nil
+}
+
+// SearchNotificationRules search the notification rules
+func (s *Service) SearchNotificationRules(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(&input); err != nil {
+		blog.Errorf("search notification rules failed, invalid data: %v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Err.New(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Notification().SearchRules(params.Context, params.Header, input)
+	if err != nil {
+		blog.Errorf("search notification rules failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("search notification rules failed, err: %s, rid: %s", resp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return resp.Data, nil
+}
+
+// GetNotificationSetting get the notification setting of the current user
+func (s *Service) GetNotificationSetting(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	resp, err := s.Engine.CoreAPI.CoreService().Notification().GetSetting(params.Context, params.Header, params.User)
+	if err != nil {
+		blog.Errorf("get the notification setting of %s failed, err: %v, rid: %s", params.User, err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("get the notification setting of %s failed, err: %s, rid: %s", params.User, resp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return resp.Data, nil
+}
+
+// SaveNotificationSetting save the notification setting of the current user, such as the opted out rules,
+// the users can only change their own settings.
+func (s *Service) SaveNotificationSetting(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	setting := metadata.NotificationSetting{}
+	if err := data.MarshalJSONInto(&setting); err != nil {
+		blog.Errorf("save notification setting failed, invalid data: %v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Err.New(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+	setting.User = params.User
+
+	resp, err := s.Engine.CoreAPI.CoreService().Notification().SaveSetting(params.Context, params.Header, setting)
+	if err != nil {
+		blog.Errorf("save the notification setting of %s failed, err: %v, rid: %s", params.User, err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("save the notification setting of %s failed, err: %s, rid: %s", params.User, resp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return nil, nil
+}
@@ -31,6 +31,7 @@ func (s *Service) UpdateUserGroupPrivi(params types.ContextParams, pathParams, q
 	}
 
 	err = s.Core.PermissionOperation().Permission(params).SetUserGroupPermission(params.SupplierAccount, pathParams("group_id"), priviData)
+	s.refreshPolicies()
 	return nil, err
 }
 
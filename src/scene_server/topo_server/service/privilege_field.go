@@ -0,0 +1,142 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strings"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/scene_server/topo_server/core/inst"
+	"icenter/src/scene_server/topo_server/core/operation"
+	"icenter/src/scene_server/topo_server/core/privilege"
+	"icenter/src/scene_server/topo_server/core/types"
+)
+
+// SetFieldPrivilege set the user groups which can read or write the property
+func (s *Service) SetFieldPrivilege(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := new(metadata.FieldPrivilege)
+	if err := data.MarshalJSONInto(input); err != nil {
+		blog.Errorf("[api-privilege] failed to parse the input data(%#v), err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Err.New(common.CCErrCommJSONUnmarshalFailed, err.Error())
+	}
+	input.ObjectID = pathParams(common.BKObjIDField)
+	input.PropertyID = pathParams(common.BKPropertyIDField)
+
+	if err := s.Core.PermissionOperation().Field(params).SetFieldPrivilege(input); err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+// GetFieldPrivilege get the privilege of the property
+func (s *Service) GetFieldPrivilege(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	return s.Core.PermissionOperation().Field(params).GetFieldPrivilege(pathParams(common.BKObjIDField), pathParams(common.BKPropertyIDField))
+}
+
+// DeleteFieldPrivilege delete the privilege of the property, everyone can read and write it then.
+func (s *Service) DeleteFieldPrivilege(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	return nil, s.Core.PermissionOperation().Field(params).DeleteFieldPrivilege(pathParams(common.BKObjIDField), pathParams(common.BKPropertyIDField))
+}
+
+// SearchFieldPrivileges search the privileges of the properties of the model
+func (s *Service) SearchFieldPrivileges(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	return s.Core.PermissionOperation().Field(params).SearchFieldPrivileges(pathParams(common.BKObjIDField))
+}
+
+// checkFieldRead returns the field access of the user to the model, the condition and the sort fields
+// can not use the properties that the user can not read.
+func (s *Service) checkFieldRead(params types.ContextParams, objID string, cond interface{}, sort string) (*privilege.FieldAccess, error) {
+	access, err := s.Core.PermissionOperation().Field(params).Access(objID)
+	if err != nil {
+		return nil, err
+	}
+
+	if denied := access.Unreadable(objID, cond, sortFields(sort)...); len(denied) != 0 {
+		blog.Errorf("[api-privilege] the user %s can not search %s by the properties %v, rid: %s", params.User, objID, denied, params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrorTopoFieldNoReadPermission, strings.Join(denied, ","))
+	}
+	return access, nil
+}
+
+// checkAssociationFieldRead check the conditions on the model and its associated models of the
+// search by association api, and returns the field access of the user to the model.
+func (s *Service) checkAssociationFieldRead(params types.ContextParams, objID string, data mapstr.MapStr) (*privilege.FieldAccess, error) {
+	input := new(operation.AssociationParams)
+	if err := data.MarshalJSONInto(input); err != nil {
+		blog.Errorf("[api-privilege] failed to parse the input data(%#v), err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrTopoInstSelectFailed, err.Error())
+	}
+
+	objIDs := []string{objID}
+	for asstObjID := range input.Condition {
+		objIDs = append(objIDs, asstObjID)
+	}
+	access, err := s.Core.PermissionOperation().Field(params).Access(objIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	denied := access.Unreadable(objID, nil, sortFields(input.Page.Sort)...)
+	for asstObjID, items := range input.Condition {
+		fields := make([]string, 0, len(items))
+		for _, item := range items {
+			fields = append(fields, item.Field)
+		}
+		denied = append(denied, access.Unreadable(asstObjID, nil, fields...)...)
+	}
+	if len(denied) != 0 {
+		blog.Errorf("[api-privilege] the user %s can not search %s by the properties %v, rid: %s", params.User, objID, denied, params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrorTopoFieldNoReadPermission, strings.Join(denied, ","))
+	}
+	return access, nil
+}
+
+// sortFields returns the fields of the sort parameter, such as "-bk_inst_name,create_time"
+func sortFields(sort string) []string {
+	fields := make([]string, 0)
+	for _, field := range strings.Split(sort, ",") {
+		if field = strings.TrimSpace(field); len(field) != 0 {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// checkFieldWrite the data can not have the properties that the user can not write, the redacted
+// values sent back by the clients are removed from the data.
+func (s *Service) checkFieldWrite(params types.ContextParams, objID string, datas ...map[string]interface{}) error {
+	access, err := s.Core.PermissionOperation().Field(params).Access(objID)
+	if err != nil {
+		return err
+	}
+
+	for _, data := range datas {
+		access.RemoveRedacted(objID, data)
+		if denied := access.Unwritable(objID, data); len(denied) != 0 {
+			blog.Errorf("[api-privilege] the user %s can not write the properties %v of %s, rid: %s", params.User, denied, objID, params.ReqID)
+			return params.Err.Errorf(common.CCErrorTopoFieldNoWritePermission, strings.Join(denied, ","))
+		}
+	}
+	return nil
+}
+
+// maskInsts hide the properties of the instances that the user can not read
+func maskInsts(access *privilege.FieldAccess, objID string, insts []inst.Inst) []inst.Inst {
+	for _, item := range insts {
+		access.Mask(objID, item.GetValues())
+	}
+	return insts
+}
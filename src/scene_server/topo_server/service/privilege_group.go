@@ -13,6 +13,7 @@
 package service
 
 import (
+	"icenter/src/auth/local"
 	"icenter/src/common"
 	"icenter/src/common/blog"
 	"icenter/src/common/condition"
@@ -32,12 +33,14 @@ func (s *Service) CreateUserGroup(params types.ContextParams, pathParams, queryP
 	}
 
 	err = s.Core.PermissionOperation().UserGroup(params).CreateUserGroup(params.SupplierAccount, userGroup)
+	s.refreshPolicies()
 	return nil, err
 }
 
 // DeleteUserGroup delete user goup
 func (s *Service) DeleteUserGroup(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
 	err := s.Core.PermissionOperation().UserGroup(params).DeleteUserGroup(pathParams("bk_supplier_account"), pathParams("group_id"))
+	s.refreshPolicies()
 	return nil, err
 }
 
@@ -45,6 +48,7 @@ func (s *Service) DeleteUserGroup(params types.ContextParams, pathParams, queryP
 func (s *Service) UpdateUserGroup(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
 
 	err := s.Core.PermissionOperation().UserGroup(params).UpdateUserGroup(pathParams("bk_supplier_account"), pathParams("group_id"), data)
+	s.refreshPolicies()
 	return nil, err
 }
 
@@ -60,3 +64,11 @@ func (s *Service) SearchUserGroup(params types.ContextParams, pathParams, queryP
 
 	return s.Core.PermissionOperation().UserGroup(params).SearchUserGroup(pathParams("bk_supplier_account"), cond)
 }
+
+// refreshPolicies drop the policies cached by the local authorizer, so that the changed
+// user groups and privileges take effect immediately in this process.
+func (s *Service) refreshPolicies() {
+	if authorizer, ok := s.AuthManager.Authorize.(*local.Local); ok {
+		authorizer.Refresh()
+	}
+}
@@ -86,6 +86,8 @@ func (s *Service) WebService() *restful.Container {
 			blog.Errorf(" the url (%s), the http method (%s) is not supported", actionItem.Path, actionItem.Verb)
 		}
 	}
+	s.initInstTransfer(api)
+
 	container := restful.NewContainer().Add(api)
 	container.Add(healthz)
 
@@ -197,17 +199,9 @@ func (s *Service) Actions() []*httpserver.Action {
 		func(act action) {
 
 			httpActions = append(httpActions, &httpserver.Action{Verb: act.Method, Path: act.Path, Handler: func(req *restful.Request, resp *restful.Response) {
-				ownerID := util.GetOwnerID(req.Request.Header)
-				user := util.GetUser(req.Request.Header)
-				rid := util.GetHTTPCCRequestID(req.Request.Header)
-
-				// get the language
-				language := util.GetLanguage(req.Request.Header)
-
-				defLang := s.Language.CreateDefaultCCLanguageIf(language)
-
-				// get the error info by the language
-				defErr := s.Error.CreateDefaultCCErrorIf(language)
+				handlerContext := s.newContextParams(req)
+				rid := handlerContext.ReqID
+				defErr := handlerContext.Err
 
 				value, err := ioutil.ReadAll(req.Request.Body)
 				if err != nil {
@@ -236,22 +230,6 @@ func (s *Service) Actions() []*httpserver.Action {
 					}
 				}
 
-				ctx, _ := s.Engine.CCCtx.WithCancel()
-				ctx = context.WithValue(ctx, common.ContextRequestIDField, rid)
-				ctx = context.WithValue(ctx, common.ContextRequestUserField, user)
-
-				handlerContext := types.ContextParams{
-					Context:         ctx,
-					Err:             defErr,
-					Lang:            defLang,
-					MaxTopoLevel:    s.Config.BusinessTopoLevelMax,
-					Header:          req.Request.Header,
-					SupplierAccount: ownerID,
-					User:            user,
-					Engin:           s.Engine,
-					ReqID:           rid,
-				}
-
 				// parse metadata for none public only handler
 				if act.PublicOnly == false {
 					md := new(MetaShell)
@@ -291,6 +269,37 @@ func (s *Service) Actions() []*httpserver.Action {
 	return httpActions
 }
 
+// newContextParams returns the context params of the request
+func (s *Service) newContextParams(req *restful.Request) types.ContextParams {
+	ownerID := util.GetOwnerID(req.Request.Header)
+	user := util.GetUser(req.Request.Header)
+	rid := util.GetHTTPCCRequestID(req.Request.Header)
+
+	// get the language
+	language := util.GetLanguage(req.Request.Header)
+
+	defLang := s.Language.CreateDefaultCCLanguageIf(language)
+
+	// get the error info by the language
+	defErr := s.Error.CreateDefaultCCErrorIf(language)
+
+	ctx, _ := s.Engine.CCCtx.WithCancel()
+	ctx = context.WithValue(ctx, common.ContextRequestIDField, rid)
+	ctx = context.WithValue(ctx, common.ContextRequestUserField, user)
+
+	return types.ContextParams{
+		Context:         ctx,
+		Err:             defErr,
+		Lang:            defLang,
+		MaxTopoLevel:    s.Config.BusinessTopoLevelMax,
+		Header:          req.Request.Header,
+		SupplierAccount: ownerID,
+		User:            user,
+		Engin:           s.Engine,
+		ReqID:           rid,
+	}
+}
+
 type MetaShell struct {
 	Metadata *metadata.Metadata `json:"metadata"`
 }
@@ -50,6 +50,8 @@ func (s *Service) initAssociation() {
 	s.addAction(http.MethodPost, "/inst/association/action/search", s.SearchAssociationInst, nil)
 	s.addAction(http.MethodPost, "/inst/association/action/create", s.CreateAssociationInst, nil)
 	s.addAction(http.MethodDelete, "/inst/association/{association_id}/action/delete", s.DeleteAssociationInst, nil)
+	s.addAction(http.MethodPut, "/inst/association/{association_id}/action/update", s.UpdateAssociationInst, nil)
+	s.addAction(http.MethodPost, "/inst/association/action/traverse", s.TraverseAssociationInst, nil)
 
 	// topo search methods
 	s.addAction(http.MethodPost, "/inst/association/search/owner/{owner_id}/object/{bk_obj_id}", s.SearchInstByAssociation, nil)
@@ -65,6 +67,32 @@ func (s *Service) initAuditLog() {
 
 	s.addAction(http.MethodPost, "/audit/search", s.AuditQuery, nil)
 	s.addAction(http.MethodPost, "/object/{bk_obj_id}/audit/search", s.InstanceAuditQuery, nil)
+	s.addAction(http.MethodPost, "/audit/rollback", s.RollbackAuditLogs, nil)
+}
+
+func (s *Service) initHistory() {
+	s.addAction(http.MethodGet, "/history/object/{bk_obj_id}/inst/{inst_id}/versions", s.SearchInstanceVersions, nil)
+	s.addAction(http.MethodGet, "/history/object/{bk_obj_id}/inst/{inst_id}", s.GetInstanceAsOf, nil)
+	s.addAction(http.MethodGet, "/history/topo/inst/{bk_biz_id}", s.SearchBusinessTopoAsOf, nil)
+}
+
+func (s *Service) initWebhook() {
+	s.addAction(http.MethodPost, "/webhook/subscription", s.CreateWebhookSubscription, nil)
+	s.addAction(http.MethodPut, "/webhook/subscription/{id}", s.UpdateWebhookSubscription, nil)
+	s.addAction(http.MethodDelete, "/webhook/subscription/{id}", s.DeleteWebhookSubscription, nil)
+	s.addAction(http.MethodPost, "/webhook/subscription/search", s.SearchWebhookSubscriptions, nil)
+	s.addAction(http.MethodPost, "/webhook/deadletter/search", s.SearchWebhookDeadLetters, nil)
+	s.addAction(http.MethodPost, "/webhook/deadletter/redeliver", s.RedeliverWebhookDeadLetters, nil)
+	s.addAction(http.MethodDelete, "/webhook/deadletter", s.DeleteWebhookDeadLetters, nil)
+}
+
+func (s *Service) initNotification() {
+	s.addAction(http.MethodPost, "/notification/rule", s.CreateNotificationRule, nil)
+	s.addAction(http.MethodPut, "/notification/rule/{id}", s.UpdateNotificationRule, nil)
+	s.addAction(http.MethodDelete, "/notification/rule/{id}", s.DeleteNotificationRule, nil)
+	s.addAction(http.MethodPost, "/notification/rule/search", s.SearchNotificationRules, nil)
+	s.addAction(http.MethodGet, "/notification/setting", s.GetNotificationSetting, nil)
+	s.addAction(http.MethodPut, "/notification/setting", s.SaveNotificationSetting, nil)
 }
 
 func (s *Service) initCompatiblev2() {
@@ -123,6 +151,7 @@ func (s *Service) initInst() {
 	s.addAction(http.MethodPost, "/inst/search/owner/{owner_id}/object/{bk_obj_id}/detail", s.SearchInstAndAssociationDetail, nil)
 	s.addAction(http.MethodPost, "/inst/search/owner/{owner_id}/object/{bk_obj_id}", s.SearchInstByObject, nil)
 	s.addAction(http.MethodPost, "/inst/search/{owner_id}/{bk_obj_id}/{inst_id}", s.SearchInstByInstID, nil)
+	s.addAction(http.MethodPost, "/inst/aggregate/{owner_id}/{bk_obj_id}", s.AggregateInsts, nil)
 
 }
 
@@ -179,6 +208,13 @@ func (s *Service) initPrivilegeRole() {
 	s.addAction(http.MethodGet, "/topo/privilege/{bk_supplier_account}/{bk_obj_id}/{bk_property_id}", s.GetPrivilege, nil)
 }
 
+func (s *Service) initPrivilegeField() {
+	s.addAction(http.MethodPost, "/topo/privilege/field/{bk_supplier_account}/{bk_obj_id}/{bk_property_id}", s.SetFieldPrivilege, nil)
+	s.addAction(http.MethodGet, "/topo/privilege/field/{bk_supplier_account}/{bk_obj_id}/{bk_property_id}", s.GetFieldPrivilege, nil)
+	s.addAction(http.MethodDelete, "/topo/privilege/field/{bk_supplier_account}/{bk_obj_id}/{bk_property_id}", s.DeleteFieldPrivilege, nil)
+	s.addAction(http.MethodGet, "/topo/privilege/field/{bk_supplier_account}/{bk_obj_id}", s.SearchFieldPrivileges, nil)
+}
+
 func (s *Service) initPrivilege() {
 	s.addAction(http.MethodPost, "/topo/privilege/group/detail/{bk_supplier_account}/{group_id}", s.UpdateUserGroupPrivi, nil)
 	s.addAction(http.MethodGet, "/topo/privilege/group/detail/{bk_supplier_account}/{group_id}", s.GetUserGroupPrivi, nil)
@@ -197,6 +233,9 @@ func (s *Service) initService() {
 	s.initHealth()
 	s.initAssociation()
 	s.initAuditLog()
+	s.initHistory()
+	s.initWebhook()
+	s.initNotification()
 	s.initCompatiblev2()
 	s.initBusiness()
 	s.initInst()
@@ -208,6 +247,7 @@ func (s *Service) initService() {
 	s.initObjectGroup()
 	s.initPrivilegeGroup()
 	s.initPrivilegeRole()
+	s.initPrivilegeField()
 	s.initPrivilege()
 	s.initGraphics()
 	s.initIdentifier()
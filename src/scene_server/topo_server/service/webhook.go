@@ -0,0 +1,163 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/scene_server/topo_server/core/types"
+)
+
+// CreateWebhookSubscription create a webhook subscription
+func (s *Service) CreateWebhookSubscription(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	subscription := metadata.WebhookSubscription{}
+	if err := data.MarshalJSONInto(&subscription); err != nil {
+		blog.Errorf("create webhook subscription failed, invalid data: %v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Err.New(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Webhook().CreateSubscription(params.Context, params.Header, subscription)
+	if err != nil {
+		blog.Errorf("create webhook subscription failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("create webhook subscription failed, err: %s, rid: %s", resp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return resp.Data, nil
+}
+
+// UpdateWebhookSubscription update a webhook subscription
+func (s *Service) UpdateWebhookSubscription(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("update webhook subscription failed, invalid id %s, rid: %s", pathParams("id"), params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommParamsIsInvalid, "id")
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Webhook().UpdateSubscription(params.Context, params.Header, id, data)
+	if err != nil {
+		blog.Errorf("update webhook subscription %d failed, err: %v, rid: %s", id, err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("update webhook subscription %d failed, err: %s, rid: %s", id, resp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return nil, nil
+}
+
+// DeleteWebhookSubscription delete a webhook subscription with all its deliveries
+func (s *Service) DeleteWebhookSubscription(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("delete webhook subscription failed, invalid id %s, rid: %s", pathParams("id"), params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommParamsIsInvalid, "id")
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Webhook().DeleteSubscription(params.Context, params.Header, id)
+	if err != nil {
+		blog.Errorf("delete webhook subscription %d failed, err: %v, rid: %s", id, err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("delete webhook subscription %d failed, err: %s, rid: %s", id, resp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return nil, nil
+}
+
+// SearchWebhookSubscriptions search the webhook subscriptions with their delivery stats
+func (s *Service) SearchWebhookSubscriptions(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(&input); err != nil {
+		blog.Errorf("search webhook subscriptions failed, invalid data: %v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Err.New(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Webhook().SearchSubscriptions(params.Context, params.Header, input)
+	if err != nil {
+		blog.Errorf("search webhook subscriptions failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("search webhook subscriptions failed, err: %s, rid: %s", resp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return resp.Data, nil
+}
+
+// SearchWebhookDeadLetters search the deliveries which are failed after all the retries
+func (s *Service) SearchWebhookDeadLetters(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := metadata.SearchWebhookDeadLetterParams{}
+	if err := data.MarshalJSONInto(&input); err != nil {
+		blog.Errorf("search webhook dead letters failed, invalid data: %v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Err.New(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Webhook().SearchDeadLetters(params.Context, params.Header, input)
+	if err != nil {
+		blog.Errorf("search webhook dead letters failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("search webhook dead letters failed, err: %s, rid: %s", resp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return resp.Data, nil
+}
+
+// RedeliverWebhookDeadLetters move the dead letters back to the pending deliveries
+func (s *Service) RedeliverWebhookDeadLetters(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := metadata.WebhookDeadLetterParams{}
+	if err := data.MarshalJSONInto(&input); err != nil {
+		blog.Errorf("redeliver webhook dead letters failed, invalid data: %v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Err.New(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Webhook().RedeliverDeadLetters(params.Context, params.Header, input)
+	if err != nil {
+		blog.Errorf("redeliver webhook dead letters failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("redeliver webhook dead letters failed, err: %s, rid: %s", resp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return resp.Data, nil
+}
+
+// DeleteWebhookDeadLetters delete the dead letters
+func (s *Service) DeleteWebhookDeadLetters(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := metadata.WebhookDeadLetterParams{}
+	if err := data.MarshalJSONInto(&input); err != nil {
+		blog.Errorf("delete webhook dead letters failed, invalid data: %v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Err.New(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Webhook().DeleteDeadLetters(params.Context, params.Header, input)
+	if err != nil {
+		blog.Errorf("delete webhook dead letters failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("delete webhook dead letters failed, err: %s, rid: %s", resp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return resp.Data, nil
+}
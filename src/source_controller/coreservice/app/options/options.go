@@ -14,24 +14,43 @@ package options
 
 import (
 	"icenter/src/common/core/cc/config"
+	"icenter/src/common/eventclient"
 	"icenter/src/common/storage/dal/mongo"
 	"icenter/src/common/storage/dal/redis"
+	"icenter/src/source_controller/coreservice/core/history"
+	"icenter/src/source_controller/coreservice/core/notification"
 
 	"github.com/spf13/pflag"
 )
 
-//ServerOption define option of server in flags
+// ServerOption define option of server in flags
 type ServerOption struct {
 	ServConf *config.CCAPIConfig
+	// Storage the storage of the data, mongodb or memory
+	Storage string
+	// Seed the file of the initial data in memory
+	Seed string
 }
 
+const (
+	// StorageMongoDB save the data in mongodb
+	StorageMongoDB = "mongodb"
+	// StorageMemory save the data in the process memory, it's used by the single node dev mode only.
+	StorageMemory = "memory"
+)
+
 // Config export
 type Config struct {
-	Mongo mongo.Config
-	Redis redis.Config
+	Storage      string
+	Seed         string
+	Mongo        mongo.Config
+	Redis        redis.Config
+	EventLog     eventclient.StreamConfig
+	History      history.Config
+	Notification notification.Config
 }
 
-//NewServerOption create a ServerOption object
+// NewServerOption create a ServerOption object
 func NewServerOption() *ServerOption {
 	s := ServerOption{
 		ServConf: config.NewCCAPIConfig(),
@@ -40,9 +59,11 @@ func NewServerOption() *ServerOption {
 	return &s
 }
 
-//AddFlags add flags
+// AddFlags add flags
 func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ServConf.AddrPort, "addrport", "127.0.0.1:60001", "The ip address and port for the serve on")
 	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "", "hosts of register and discover server. e.g: 127.0.0.1:2181")
 	fs.StringVar(&s.ServConf.ExConfig, "config", "", "The config path. e.g conf/api.conf")
+	fs.StringVar(&s.Storage, "storage", StorageMongoDB, "The storage of the data, mongodb or memory. the data in memory is lost when the process exits, it's for the single node dev mode only")
+	fs.StringVar(&s.Seed, "seed", "", "The file of the initial data in memory, which is dumped by the bkseed command of the admin server")
 }
@@ -22,26 +22,34 @@ import (
 	"icenter/src/common/backbone"
 	cc "icenter/src/common/backbone/configcenter"
 	"icenter/src/common/blog"
+	"icenter/src/common/eventclient"
 	"icenter/src/common/rdapi"
 	"icenter/src/common/storage/dal/mongo"
 	"icenter/src/common/storage/dal/redis"
 	"icenter/src/common/types"
 	"icenter/src/common/version"
 	"icenter/src/source_controller/coreservice/app/options"
+	"icenter/src/source_controller/coreservice/core/history"
+	"icenter/src/source_controller/coreservice/core/notification"
 	coresvr "icenter/src/source_controller/coreservice/service"
 )
 
 // CoreServer the core server
 type CoreServer struct {
-	Core    *backbone.Engine
-	Config  options.Config
-	Service coresvr.CoreServiceInterface
+	Core        *backbone.Engine
+	Config      options.Config
+	Service     coresvr.CoreServiceInterface
+	configReady bool
 }
 
 func (t *CoreServer) onCoreServiceConfigUpdate(previous, current cc.ProcessConfig) {
+	t.configReady = true
 
 	t.Config.Mongo = mongo.ParseConfigFromKV("mongodb", current.ConfigMap)
 	t.Config.Redis = redis.ParseConfigFromKV("redis", current.ConfigMap)
+	t.Config.EventLog = eventclient.ParseStreamConfigFromKV("eventlog", current.ConfigMap)
+	t.Config.History = history.ParseConfigFromKV("history", current.ConfigMap)
+	t.Config.Notification = notification.ParseConfigFromKV("notification", current.ConfigMap)
 
 	blog.V(3).Infof("the new cfg:%#v the origin cfg:%#v", t.Config, current.ConfigMap)
 
@@ -54,7 +62,13 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 		return fmt.Errorf("wrap server info failed, err: %v", err)
 	}
 
+	if op.Storage != options.StorageMongoDB && op.Storage != options.StorageMemory {
+		return fmt.Errorf("unsupported storage %s", op.Storage)
+	}
+
 	coreSvr := new(CoreServer)
+	coreSvr.Config.Storage = op.Storage
+	coreSvr.Config.Seed = op.Seed
 	coreService := coresvr.New()
 	coreSvr.Service = coreService
 
@@ -75,6 +89,15 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 
 	var configReady bool
 	for sleepCnt := 0; sleepCnt < common.APPConfigWaitTime; sleepCnt++ {
+		// the data in memory needs neither redis nor mongo, wait for the config to be loaded only
+		if options.StorageMemory == coreSvr.Config.Storage {
+			if !coreSvr.configReady {
+				time.Sleep(time.Second)
+				continue
+			}
+			configReady = true
+			break
+		}
 		// redis not found
 		if "" == coreSvr.Config.Redis.Address {
 			time.Sleep(time.Second)
@@ -99,6 +122,13 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 	if err != nil {
 		return err
 	}
+	if options.StorageMemory == coreSvr.Config.Storage {
+		// the other processes start the transactions with the coreservice as the tmserver
+		txcPath := fmt.Sprintf("%s/%s/%s", types.CC_SERV_BASEPATH, types.CC_MODULE_TXC, svrInfo.IP)
+		if err := engine.SvcDisc.Register(txcPath, *svrInfo); err != nil {
+			return fmt.Errorf("register as the tmserver failed, err: %v", err)
+		}
+	}
 	if err := backbone.StartServer(ctx, engine, webhandler); err != nil {
 		return err
 	}
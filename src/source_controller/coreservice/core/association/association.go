@@ -13,6 +13,7 @@
 package association
 
 import (
+	"icenter/src/common/eventclient"
 	"icenter/src/common/storage/dal"
 	"icenter/src/source_controller/coreservice/core"
 )
@@ -26,8 +27,8 @@ type associationManager struct {
 	dbProxy dal.RDB
 }
 
-// New create a new association manager instance
-func New(dbProxy dal.RDB, dependent OperationDependences) core.AssociationOperation {
+// New create a new association manager instance, the changes of the instance associations are pushed by the eventC
+func New(dbProxy dal.RDB, dependent OperationDependences, eventC eventclient.Client) core.AssociationOperation {
 	asstModel := &associationModel{dbProxy: dbProxy}
 	asstKind := &associationKind{
 		dbProxy:          dbProxy,
@@ -41,6 +42,7 @@ func New(dbProxy dal.RDB, dependent OperationDependences) core.AssociationOperat
 			associationKind:  asstKind,
 			associationModel: asstModel,
 			dependent:        dependent,
+			eventC:           eventC,
 		},
 		associationModel: &associationModel{
 			dbProxy: dbProxy,
@@ -0,0 +1,299 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"regexp"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/util"
+	"icenter/src/source_controller/coreservice/core"
+)
+
+// attributeIDRegexp the rule of the id of the association attributes
+var attributeIDRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{0,127}$`)
+
+// attributeTypes the types supported by the association attributes
+var attributeTypes = []string{
+	common.FieldTypeSingleChar,
+	common.FieldTypeLongChar,
+	common.FieldTypeInt,
+	common.FieldTypeFloat,
+	common.FieldTypeEnum,
+	common.FieldTypeDate,
+	common.FieldTypeTime,
+	common.FieldTypeBool,
+}
+
+// validAttributes check the attributes declared by an association kind or a model association
+func validAttributes(ctx core.ContextParams, attrs []metadata.AssociationAttribute) error {
+	ids := make(map[string]bool, len(attrs))
+	for _, attr := range attrs {
+		if !attributeIDRegexp.MatchString(attr.PropertyID) {
+			blog.Errorf("invalid association attribute id %s, rid: %s", attr.PropertyID, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsInvalid, "attributes.bk_property_id")
+		}
+		if ids[attr.PropertyID] {
+			blog.Errorf("association attribute %s is duplicated, rid: %s", attr.PropertyID, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommDuplicateItem, attr.PropertyID)
+		}
+		ids[attr.PropertyID] = true
+
+		if !util.InStrArr(attributeTypes, attr.PropertyType) {
+			blog.Errorf("association attribute %s has unsupported type %s, rid: %s", attr.PropertyID, attr.PropertyType, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsInvalid, "attributes.bk_property_type")
+		}
+
+		option, err := attributeOption(attr.Option)
+		if err != nil {
+			blog.Errorf("association attribute %s has invalid option %#v, err: %v, rid: %s", attr.PropertyID, attr.Option, err, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsInvalid, "attributes.option")
+		}
+		switch attr.PropertyType {
+		case common.FieldTypeSingleChar, common.FieldTypeLongChar:
+			if option == nil || option == "" {
+				continue
+			}
+			pattern, ok := option.(string)
+			if !ok {
+				return ctx.Error.Errorf(common.CCErrCommParamsShouldBeString, "attributes.option")
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				blog.Errorf("association attribute %s has invalid regexp %s, err: %v, rid: %s", attr.PropertyID, pattern, err, ctx.ReqID)
+				return ctx.Error.Errorf(common.CCErrCommParamsInvalid, "attributes.option")
+			}
+		case common.FieldTypeInt, common.FieldTypeFloat:
+			min, max, err := attributeRange(option)
+			if err != nil {
+				blog.Errorf("association attribute %s has invalid range %#v, err: %v, rid: %s", attr.PropertyID, attr.Option, err, ctx.ReqID)
+				return ctx.Error.Errorf(common.CCErrCommParamsInvalid, "attributes.option")
+			}
+			if min != nil && max != nil && *min > *max {
+				return ctx.Error.Errorf(common.CCErrCommParamsInvalid, "attributes.option")
+			}
+		case common.FieldTypeEnum:
+			if err := util.ValidPropertyOption(common.FieldTypeEnum, option, ctx.Error); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseAttributes decode and check the attributes in the data to update an association kind or a model association
+func parseAttributes(ctx core.ContextParams, data mapstr.MapStr) error {
+	value, exists := data[metadata.AssociationFieldAttributes]
+	if !exists {
+		return nil
+	}
+	attrs := make([]metadata.AssociationAttribute, 0)
+	if value != nil {
+		raw, err := json.Marshal(value)
+		if err == nil {
+			err = json.Unmarshal(raw, &attrs)
+		}
+		if err != nil {
+			blog.Errorf("decode association attributes %#v failed, err: %v, rid: %s", value, err, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsInvalid, metadata.AssociationFieldAttributes)
+		}
+	}
+	if err := validAttributes(ctx, attrs); err != nil {
+		return err
+	}
+	data[metadata.AssociationFieldAttributes] = attrs
+	return nil
+}
+
+// mergeAttributes returns the attributes of the instance associations of the model association, which are the ones
+// of the association kind overridden by the ones with the same id of the model association.
+func mergeAttributes(kindAttrs, asstAttrs []metadata.AssociationAttribute) []metadata.AssociationAttribute {
+	attrs := make([]metadata.AssociationAttribute, 0, len(kindAttrs)+len(asstAttrs))
+	index := make(map[string]int)
+	for _, group := range [][]metadata.AssociationAttribute{kindAttrs, asstAttrs} {
+		for _, attr := range group {
+			if idx, exists := index[attr.PropertyID]; exists {
+				attrs[idx] = attr
+				continue
+			}
+			index[attr.PropertyID] = len(attrs)
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
+// validAttributeValues check the attribute values of an instance association, the values of the attributes not
+// declared are rejected and the required ones must be set. the int values are saved as int64.
+func validAttributeValues(ctx core.ContextParams, attrs []metadata.AssociationAttribute, values mapstr.MapStr) error {
+	declared := make(map[string]bool, len(attrs))
+	for _, attr := range attrs {
+		declared[attr.PropertyID] = true
+	}
+	for key := range values {
+		if !declared[key] {
+			blog.Errorf("association attribute %s is not declared, rid: %s", key, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metadata.AssociationFieldAttributes+"."+key)
+		}
+	}
+
+	for _, attr := range attrs {
+		key := metadata.AssociationFieldAttributes + "." + attr.PropertyID
+		value, exists := values[attr.PropertyID]
+		if !exists || value == nil || value == "" {
+			if attr.IsRequired {
+				return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, key)
+			}
+			continue
+		}
+
+		option, err := attributeOption(attr.Option)
+		if err != nil {
+			blog.Errorf("association attribute %s has invalid option %#v, err: %v, rid: %s", attr.PropertyID, attr.Option, err, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsInvalid, key)
+		}
+
+		switch attr.PropertyType {
+		case common.FieldTypeSingleChar, common.FieldTypeLongChar:
+			str, ok := value.(string)
+			if !ok {
+				return ctx.Error.Errorf(common.CCErrCommParamsNeedString, key)
+			}
+			limit := common.FieldTypeSingleLenChar
+			if attr.PropertyType == common.FieldTypeLongChar {
+				limit = common.FieldTypeLongLenChar
+			}
+			if len(str) > limit {
+				return ctx.Error.Errorf(common.CCErrCommOverLimit, key)
+			}
+			if pattern, ok := option.(string); ok && pattern != "" {
+				reg, err := regexp.Compile(pattern)
+				if err != nil || !reg.MatchString(str) {
+					blog.Errorf("association attribute %s value %s not match regexp %s, rid: %s", attr.PropertyID, str, pattern, ctx.ReqID)
+					return ctx.Error.Error(common.CCErrFieldRegValidFailed)
+				}
+			}
+
+		case common.FieldTypeInt, common.FieldTypeFloat:
+			errCode := common.CCErrCommParamsNeedFloat
+			if attr.PropertyType == common.FieldTypeInt {
+				errCode = common.CCErrCommParamsNeedInt
+			}
+			if _, isStr := value.(string); isStr {
+				return ctx.Error.Errorf(errCode, key)
+			}
+			number, err := util.GetFloat64ByInterface(value)
+			if err != nil {
+				return ctx.Error.Errorf(errCode, key)
+			}
+			if attr.PropertyType == common.FieldTypeInt {
+				if number != math.Trunc(number) {
+					return ctx.Error.Errorf(errCode, key)
+				}
+				values[attr.PropertyID] = int64(number)
+			}
+			min, max, err := attributeRange(option)
+			if err != nil {
+				blog.Errorf("association attribute %s has invalid range %#v, err: %v, rid: %s", attr.PropertyID, attr.Option, err, ctx.ReqID)
+				return ctx.Error.Errorf(common.CCErrCommParamsInvalid, key)
+			}
+			if (min != nil && number < *min) || (max != nil && number > *max) {
+				return ctx.Error.Errorf(common.CCErrCommParamsInvalid, key)
+			}
+
+		case common.FieldTypeEnum:
+			str, ok := value.(string)
+			if !ok {
+				return ctx.Error.Errorf(common.CCErrCommParamsInvalid, key)
+			}
+			items, _ := option.([]interface{})
+			match := false
+			for _, item := range items {
+				if enum, ok := item.(map[string]interface{}); ok && enum["id"] == str {
+					match = true
+					break
+				}
+			}
+			if !match {
+				return ctx.Error.Errorf(common.CCErrCommParamsInvalid, key)
+			}
+
+		case common.FieldTypeDate, common.FieldTypeTime:
+			str, ok := value.(string)
+			if !ok {
+				return ctx.Error.Errorf(common.CCErrCommParamsShouldBeString, key)
+			}
+			if (attr.PropertyType == common.FieldTypeDate && !util.IsDate(str)) ||
+				(attr.PropertyType == common.FieldTypeTime && !util.IsTime(str)) {
+				return ctx.Error.Errorf(common.CCErrCommParamsInvalid, key)
+			}
+
+		case common.FieldTypeBool:
+			if _, ok := value.(bool); !ok {
+				return ctx.Error.Errorf(common.CCErrCommParamsNeedBool, key)
+			}
+		}
+	}
+	return nil
+}
+
+// attributeOption returns the option in the form decoded from json, the option read from db is in the bson form.
+func attributeOption(option interface{}) (interface{}, error) {
+	if option == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(option)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// attributeRange returns the min and the max of an int or float option, the bounds are numbers or numeric strings,
+// nil means not limited.
+func attributeRange(option interface{}) (min, max *float64, err error) {
+	if option == nil || option == "" {
+		return nil, nil, nil
+	}
+	opt, ok := option.(map[string]interface{})
+	if !ok {
+		return nil, nil, errors.New("the option should be an object")
+	}
+	if min, err = attributeBound(opt["min"]); err != nil {
+		return nil, nil, err
+	}
+	if max, err = attributeBound(opt["max"]); err != nil {
+		return nil, nil, err
+	}
+	return min, max, nil
+}
+
+func attributeBound(value interface{}) (*float64, error) {
+	if value == nil || value == "" {
+		return nil, nil
+	}
+	bound, err := util.GetFloat64ByInterface(value)
+	if err != nil {
+		return nil, err
+	}
+	return &bound, nil
+}
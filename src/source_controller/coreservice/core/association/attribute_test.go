@@ -0,0 +1,217 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"icenter/src/common"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func enumOption(ids ...string) []interface{} {
+	option := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		option = append(option, map[string]interface{}{"id": id, "name": id, "type": "text"})
+	}
+	return option
+}
+
+func TestAssociationAttributesInvalid(t *testing.T) {
+	asstMgr := newAssociation(t)
+
+	invalids := map[string][]metadata.AssociationAttribute{
+		"invalid id":       {{PropertyID: "1port", PropertyType: common.FieldTypeInt}},
+		"duplicated id":    {{PropertyID: "port", PropertyType: common.FieldTypeInt}, {PropertyID: "port", PropertyType: common.FieldTypeFloat}},
+		"unsupported type": {{PropertyID: "owner", PropertyType: common.FieldTypeUser}},
+		"invalid regexp":   {{PropertyID: "note", PropertyType: common.FieldTypeSingleChar, Option: "[a-z"}},
+		"invalid range":    {{PropertyID: "port", PropertyType: common.FieldTypeInt, Option: map[string]interface{}{"min": 10, "max": 1}}},
+		"range not number": {{PropertyID: "port", PropertyType: common.FieldTypeInt, Option: map[string]interface{}{"min": "one"}}},
+		"enum no option":   {{PropertyID: "level", PropertyType: common.FieldTypeEnum}},
+		"enum no name":     {{PropertyID: "level", PropertyType: common.FieldTypeEnum, Option: []interface{}{map[string]interface{}{"id": "low"}}}},
+	}
+	for name, attrs := range invalids {
+		kind := metadata.CreateAssociationKind{}
+		kind.Data.AssociationKindID = xid.New().String()
+		kind.Data.AssociationKindName = xid.New().String()
+		kind.Data.Attributes = attrs
+		_, err := asstMgr.CreateAssociationKind(defaultCtx, kind)
+		require.Error(t, err, name)
+
+		asst := metadata.CreateModelAssociation{}
+		asst.Spec.AsstKindID = "connect"
+		asst.Spec.ObjectID = "bk_firewall"
+		asst.Spec.AsstObjID = "bk_load_balance"
+		asst.Spec.AssociationName = xid.New().String()
+		asst.Spec.Attributes = attrs
+		_, err = asstMgr.CreateModelAssociation(defaultCtx, asst)
+		require.Error(t, err, name)
+	}
+
+	// the attributes of an existing association kind are checked on update too
+	kind := metadata.CreateAssociationKind{}
+	kind.Data.AssociationKindID = xid.New().String()
+	kind.Data.AssociationKindName = xid.New().String()
+	kind.Data.Attributes = []metadata.AssociationAttribute{{PropertyID: "port", PropertyType: common.FieldTypeInt}}
+	_, err := asstMgr.CreateAssociationKind(defaultCtx, kind)
+	require.NoError(t, err)
+	_, err = asstMgr.UpdateAssociationKind(defaultCtx, metadata.UpdateOption{
+		Condition: mapstr.MapStr{common.AssociationKindIDField: kind.Data.AssociationKindID},
+		Data:      mapstr.MapStr{metadata.AssociationFieldAttributes: invalids["duplicated id"]},
+	})
+	require.Error(t, err)
+}
+
+func TestUpdateInstanceAssociationAttributes(t *testing.T) {
+	asstMgr := newAssociation(t)
+	instMgr := newInstances(t)
+
+	// the weight of the kind is overridden by the model association
+	kind := metadata.CreateAssociationKind{}
+	kind.Data.AssociationKindID = xid.New().String()
+	kind.Data.AssociationKindName = xid.New().String()
+	kind.Data.Attributes = []metadata.AssociationAttribute{
+		{PropertyID: "port", PropertyType: common.FieldTypeInt, IsRequired: true, Option: map[string]interface{}{"min": 1, "max": 65535}},
+		{PropertyID: "note", PropertyType: common.FieldTypeSingleChar, Option: "^[a-z]+$"},
+		{PropertyID: "weight", PropertyType: common.FieldTypeFloat},
+	}
+	_, err := asstMgr.CreateAssociationKind(defaultCtx, kind)
+	require.NoError(t, err)
+
+	asstName := "bk_firewall_" + kind.Data.AssociationKindID + "_bk_load_balance"
+	asst := metadata.CreateModelAssociation{}
+	asst.Spec.AsstKindID = kind.Data.AssociationKindID
+	asst.Spec.ObjectID = "bk_firewall"
+	asst.Spec.AsstObjID = "bk_load_balance"
+	asst.Spec.AssociationName = asstName
+	asst.Spec.Attributes = []metadata.AssociationAttribute{
+		{PropertyID: "weight", PropertyType: common.FieldTypeEnum, Option: enumOption("low", "high")},
+		{PropertyID: "up", PropertyType: common.FieldTypeBool},
+	}
+	_, err = asstMgr.CreateModelAssociation(defaultCtx, asst)
+	require.NoError(t, err)
+
+	instIDs := make([]int64, 0, 2)
+	for _, objID := range []string{"bk_firewall", "bk_load_balance"} {
+		inst := metadata.CreateModelInstance{Data: mapstr.MapStr{
+			common.BKInstNameField: xid.New().String(),
+			common.BKAssetIDField:  xid.New().String(),
+		}}
+		result, err := instMgr.CreateModelInstance(defaultCtx, objID, inst)
+		require.NoError(t, err)
+		instIDs = append(instIDs, int64(result.Created.ID))
+	}
+	input := metadata.CreateOneInstanceAssociation{}
+	input.Data.ObjectAsstID = asstName
+	input.Data.ObjectID = "bk_firewall"
+	input.Data.InstID = instIDs[0]
+	input.Data.AsstObjectID = "bk_load_balance"
+	input.Data.AsstInstID = instIDs[1]
+
+	invalids := map[string]mapstr.MapStr{
+		"required missing": {"note": "eth"},
+		"not declared":     {"port": float64(80), "vlan": float64(1)},
+		"int as string":    {"port": "80"},
+		"int not integer":  {"port": 80.5},
+		"int out of range": {"port": float64(70000)},
+		"regexp mismatch":  {"port": float64(80), "note": "ETH"},
+		"enum mismatch":    {"port": float64(80), "weight": "middle"},
+		"kind enum value":  {"port": float64(80), "weight": 0.5},
+		"bool as string":   {"port": float64(80), "up": "true"},
+	}
+	for name, values := range invalids {
+		input.Data.Attributes = values
+		_, err := asstMgr.CreateOneInstanceAssociation(defaultCtx, input)
+		require.Error(t, err, name)
+	}
+
+	input.Data.Attributes = mapstr.MapStr{"port": float64(80), "note": "eth", "weight": "low"}
+	created, err := asstMgr.CreateOneInstanceAssociation(defaultCtx, input)
+	require.NoError(t, err)
+	asstID := int64(created.Created.ID)
+	attributes := func() mapstr.MapStr {
+		result := metadata.InstAsst{}
+		require.NoError(t, testDB.Table(common.BKTableNameInstAsst).Find(mapstr.MapStr{common.BKFieldID: asstID}).One(defaultCtx, &result))
+		return result.Attributes
+	}
+	update := func(values mapstr.MapStr) error {
+		_, err := asstMgr.UpdateInstanceAssociation(defaultCtx, metadata.UpdateOption{
+			Condition: mapstr.MapStr{common.BKFieldID: asstID},
+			Data:      mapstr.MapStr{metadata.AssociationFieldAttributes: values},
+		})
+		return err
+	}
+
+	// the values are merged into the current ones
+	require.NoError(t, update(mapstr.MapStr{"weight": "high", "up": true}))
+	current := attributes()
+	require.EqualValues(t, 80, current["port"])
+	require.Equal(t, "eth", current["note"])
+	require.Equal(t, "high", current["weight"])
+	require.Equal(t, true, current["up"])
+
+	// a null value removes the attribute, but the required ones can not be removed
+	require.NoError(t, update(mapstr.MapStr{"note": nil}))
+	require.NotContains(t, attributes(), "note")
+	require.Error(t, update(mapstr.MapStr{"port": nil}))
+	require.Error(t, update(mapstr.MapStr{"weight": "middle"}))
+	require.EqualValues(t, 80, attributes()["port"])
+	require.Equal(t, "high", attributes()["weight"])
+
+	// only the attributes can be updated
+	_, err = asstMgr.UpdateInstanceAssociation(defaultCtx, metadata.UpdateOption{
+		Condition: mapstr.MapStr{common.BKFieldID: asstID},
+		Data:      mapstr.MapStr{common.BKInstIDField: instIDs[1]},
+	})
+	require.Error(t, err)
+
+	// the attributes of the model association are replaced, the values not declared any more are dropped
+	_, err = asstMgr.UpdateModelAssociation(defaultCtx, metadata.UpdateOption{
+		Condition: mapstr.MapStr{common.AssociationObjAsstIDField: asstName},
+		Data:      mapstr.MapStr{metadata.AssociationFieldAttributes: []metadata.AssociationAttribute{}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, update(mapstr.MapStr{"weight": 0.5}))
+	current = attributes()
+	require.NotContains(t, current, "up")
+	require.Equal(t, 0.5, current["weight"])
+
+	// every applied update emits an event with the previous and the current association
+	logs := make([]metadata.EventLog, 0)
+	filter := mapstr.MapStr{
+		"event_type": metadata.EventTypeAssociation,
+		"action":     metadata.EventActionUpdate,
+		"obj_type":   "bk_firewall",
+	}
+	require.NoError(t, testDB.Table(common.BKTableNameEventLog).Find(filter).Sort("event_id").All(defaultCtx, &logs))
+	events := make([]metadata.EventData, 0)
+	for _, log := range logs {
+		event := metadata.EventInst{}
+		require.NoError(t, json.Unmarshal([]byte(log.Data), &event))
+		data := event.Data[0]
+		if data.CurData.(map[string]interface{})[common.BKFieldID] == float64(asstID) {
+			events = append(events, data)
+		}
+	}
+	require.Len(t, events, 3)
+	pre := events[1].PreData.(map[string]interface{})[metadata.AssociationFieldAttributes].(map[string]interface{})
+	cur := events[1].CurData.(map[string]interface{})[metadata.AssociationFieldAttributes].(map[string]interface{})
+	require.Equal(t, "eth", pre["note"])
+	require.NotContains(t, cur, "note")
+	require.Equal(t, "high", cur["weight"])
+}
@@ -23,4 +23,7 @@ type OperationDependences interface {
 
 	// IsInstanceExist used to check if the  instances exist
 	IsInstanceExist(ctx core.ContextParams, objID string, instID uint64) (exists bool, err error)
+
+	// RefreshComputedAttributes used to evaluate the computed fields of the instances whose associations are changed
+	RefreshComputedAttributes(ctx core.ContextParams, objID string, instIDs []int64) error
 }
@@ -0,0 +1,55 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+import (
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/eventclient"
+	"icenter/src/common/metadata"
+	"icenter/src/source_controller/coreservice/core"
+)
+
+// newAssociationEvent returns the event of a changed instance association, which is an association event of the
+// source object, the pre is nil for the created one and the cur is nil for the deleted one.
+func newAssociationEvent(ctx core.ContextParams, action string, pre, cur *metadata.InstAsst) *metadata.EventInst {
+	data := metadata.EventData{}
+	asst := cur
+	if pre != nil {
+		data.PreData = *pre
+		asst = pre
+	}
+	if cur != nil {
+		data.CurData = *cur
+		asst = cur
+	}
+
+	event := eventclient.NewEventWithHeader(ctx.Header)
+	event.EventType = metadata.EventTypeAssociation
+	event.ObjType = asst.ObjectID
+	event.Action = action
+	event.Data = []metadata.EventData{data}
+	return event
+}
+
+// pushEvents push the events of the changed instance associations to the event server
+func (m *associationInstance) pushEvents(ctx core.ContextParams, events ...*metadata.EventInst) error {
+	if m.eventC == nil || len(events) == 0 {
+		return nil
+	}
+	if err := m.eventC.Push(ctx, events...); err != nil {
+		blog.ErrorJSON("push the instance association events failed, err: %s, events: %s, rid: %s", err.Error(), events, ctx.ReqID)
+		return ctx.Error.Errorf(common.CCErrEventPushEventFailed)
+	}
+	return nil
+}
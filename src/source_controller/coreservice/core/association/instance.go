@@ -16,10 +16,12 @@ import (
 	"icenter/src/common"
 	"icenter/src/common/blog"
 	"icenter/src/common/errors"
+	"icenter/src/common/eventclient"
 	"icenter/src/common/mapstr"
 	"icenter/src/common/metadata"
 	"icenter/src/common/storage/dal"
 	"icenter/src/common/universalsql/mongo"
+	"icenter/src/common/util"
 	"icenter/src/source_controller/coreservice/core"
 )
 
@@ -28,6 +30,7 @@ type associationInstance struct {
 	*associationKind
 	*associationModel
 	dependent OperationDependences
+	eventC    eventclient.Client
 }
 
 func (m *associationInstance) isExists(ctx core.ContextParams, instID, asstInstID int64, objAsstID string, meta metadata.Metadata) (origin *metadata.InstAsst, exists bool, err error) {
@@ -102,16 +105,14 @@ func (m *associationInstance) CreateOneInstanceAssociation(ctx core.ContextParam
 		return nil, ctx.Error.Errorf(common.CCErrCommDuplicateItem, "")
 	}
 	//check association kind
-	cond := mongo.NewCondition()
-	cond.Element(&mongo.Eq{Key: common.AssociationObjAsstIDField, Val: inputParam.Data.ObjectAsstID})
-	_, exists, err = m.associationModel.isExists(ctx, cond)
+	attrs, err := m.modelAttributes(ctx, inputParam.Data.ObjectAsstID)
 	if nil != err {
-		blog.Errorf("check asst kind(%#v)is not exist", inputParam.Data.ObjectAsstID)
+		blog.Errorf("check asst kind(%#v) failed, err: %v", inputParam.Data.ObjectAsstID, err)
 		return nil, err
 	}
-	if !exists {
-		blog.Errorf("association asst kind(%#v)is not exist", inputParam.Data.ObjectAsstID)
-		return nil, ctx.Error.Error(common.CCErrorTopoAsstKindIsNotExist)
+	if err := validAttributeValues(ctx, attrs, inputParam.Data.Attributes); nil != err {
+		blog.Errorf("association instance (%#v) has invalid attributes, err: %v", inputParam.Data, err)
+		return nil, err
 	}
 	//check association inst
 	exists, err = m.dependent.IsInstanceExist(ctx, inputParam.Data.ObjectID, uint64(inputParam.Data.InstID))
@@ -134,11 +135,19 @@ func (m *associationInstance) CreateOneInstanceAssociation(ctx core.ContextParam
 		return nil, ctx.Error.Error(common.CCErrorInstToAsstIsNotExist)
 	}
 	id, err := m.save(ctx, inputParam.Data)
+	if nil != err {
+		return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, err
+	}
+	inputParam.Data.ID = int64(id)
+	m.refreshComputedFields(ctx, inputParam.Data)
+	err = m.pushEvents(ctx, newAssociationEvent(ctx, metadata.EventActionCreate, nil, &inputParam.Data))
 	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, err
 }
 
 func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextParams, inputParam metadata.CreateManyInstanceAssociation) (*metadata.CreateManyDataResult, error) {
 	dataResult := &metadata.CreateManyDataResult{}
+	events := make([]*metadata.EventInst, 0, len(inputParam.Datas))
+	created := make([]metadata.InstAsst, 0, len(inputParam.Datas))
 	for itemIdx, item := range inputParam.Datas {
 		item.OwnerID = ctx.SupplierAccount
 		//check is exist
@@ -158,7 +167,10 @@ func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextPara
 			continue
 		}
 		//check asst kind
-		_, exists, err = m.associationKind.isExists(ctx, item.ObjectAsstID)
+		attrs, err := m.modelAttributes(ctx, item.ObjectAsstID)
+		if nil == err {
+			err = validAttributeValues(ctx, attrs, item.Attributes)
+		}
 		if nil != err {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
 				Message:     err.Error(),
@@ -168,15 +180,6 @@ func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextPara
 			})
 			continue
 		}
-		if !exists {
-			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
-				Message:     ctx.Error.Error(common.CCErrorAsstInstIsNotExist).Error(),
-				Code:        int64(common.CCErrorAsstInstIsNotExist),
-				Data:        item,
-				OriginIndex: int64(itemIdx),
-			})
-			continue
-		}
 		//check asst inst exist
 		exists, err = m.dependent.IsInstanceExist(ctx, item.ObjectID, uint64(item.InstID))
 		if nil != err {
@@ -234,10 +237,14 @@ func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextPara
 		dataResult.Created = append(dataResult.Created, metadata.CreatedDataResult{
 			ID: id,
 		})
+		item.ID = int64(id)
+		created = append(created, item)
+		events = append(events, newAssociationEvent(ctx, metadata.EventActionCreate, nil, &item))
 
 	}
 
-	return dataResult, nil
+	m.refreshComputedFields(ctx, created...)
+	return dataResult, m.pushEvents(ctx, events...)
 }
 
 func (m *associationInstance) SearchInstanceAssociation(ctx core.ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryResult, error) {
@@ -277,10 +284,131 @@ func (m *associationInstance) DeleteInstanceAssociation(ctx core.ContextParams,
 		return &metadata.DeletedCount{}, err
 	}
 
+	origins := make([]metadata.InstAsst, 0, cnt)
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).Find(inputParam.Condition).All(ctx, &origins); nil != err {
+		blog.Errorf("delete inst association get inst [%#v] err [%#v]", inputParam.Condition, err)
+		return &metadata.DeletedCount{}, err
+	}
+
 	err = m.dbProxy.Table(common.BKTableNameInstAsst).Delete(ctx, inputParam.Condition)
 	if nil != err {
 		blog.Errorf("delete inst association [%#v] err [%#v]", inputParam.Condition, err)
 		return &metadata.DeletedCount{}, err
 	}
-	return &metadata.DeletedCount{Count: cnt}, nil
+
+	m.refreshComputedFields(ctx, origins...)
+	events := make([]*metadata.EventInst, 0, len(origins))
+	for idx := range origins {
+		events = append(events, newAssociationEvent(ctx, metadata.EventActionDelete, &origins[idx], nil))
+	}
+	return &metadata.DeletedCount{Count: cnt}, m.pushEvents(ctx, events...)
+}
+
+// UpdateInstanceAssociation update the attribute values of the instance associations, the other fields identify the
+// association and can not be updated. the values are merged into the current ones, a null value removes the attribute.
+func (m *associationInstance) UpdateInstanceAssociation(ctx core.ContextParams, inputParam metadata.UpdateOption) (*metadata.UpdatedCount, error) {
+	for key := range inputParam.Data {
+		if key != metadata.AssociationFieldAttributes {
+			blog.Errorf("update inst association, but the field %s can not be updated, rid: %s", key, ctx.ReqID)
+			return &metadata.UpdatedCount{}, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, key)
+		}
+	}
+	values, err := inputParam.Data.MapStr(metadata.AssociationFieldAttributes)
+	if nil != err {
+		blog.Errorf("update inst association, but the attributes is invalid, err: %v, rid: %s", err, ctx.ReqID)
+		return &metadata.UpdatedCount{}, ctx.Error.Errorf(common.CCErrCommParamsInvalid, metadata.AssociationFieldAttributes)
+	}
+
+	inputParam.Condition.Set(common.BKOwnerIDField, ctx.SupplierAccount)
+	origins := make([]metadata.InstAsst, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).Find(inputParam.Condition).All(ctx, &origins); nil != err {
+		blog.Errorf("update inst association get inst [%#v] err [%#v], rid: %s", inputParam.Condition, err, ctx.ReqID)
+		return &metadata.UpdatedCount{}, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	// check all the associations before any of them is updated
+	modelAttrs := make(map[string][]metadata.AssociationAttribute)
+	currents := make([]metadata.InstAsst, len(origins))
+	for idx, origin := range origins {
+		attrs, exists := modelAttrs[origin.ObjectAsstID]
+		if !exists {
+			if attrs, err = m.modelAttributes(ctx, origin.ObjectAsstID); nil != err {
+				return &metadata.UpdatedCount{}, err
+			}
+			modelAttrs[origin.ObjectAsstID] = attrs
+		}
+
+		// the values of the attributes which are not declared any more are dropped
+		current := origin
+		current.Attributes = mapstr.New()
+		for _, attr := range attrs {
+			if value, exists := origin.Attributes[attr.PropertyID]; exists {
+				current.Attributes[attr.PropertyID] = value
+			}
+		}
+		for key, value := range values {
+			if value == nil {
+				current.Attributes.Remove(key)
+				continue
+			}
+			current.Attributes[key] = value
+		}
+		if err := validAttributeValues(ctx, attrs, current.Attributes); nil != err {
+			blog.Errorf("update inst association %d, but the attributes %#v is invalid, err: %v, rid: %s", origin.ID, values, err, ctx.ReqID)
+			return &metadata.UpdatedCount{}, err
+		}
+		currents[idx] = current
+	}
+
+	events := make([]*metadata.EventInst, 0, len(origins))
+	for idx := range currents {
+		cond := mongo.NewCondition()
+		cond.Element(&mongo.Eq{Key: common.BKFieldID, Val: currents[idx].ID})
+		data := mapstr.MapStr{metadata.AssociationFieldAttributes: currents[idx].Attributes}
+		if err := m.dbProxy.Table(common.BKTableNameInstAsst).Update(ctx, cond.ToMapStr(), data); nil != err {
+			blog.Errorf("update inst association %d err [%#v], rid: %s", currents[idx].ID, err, ctx.ReqID)
+			return &metadata.UpdatedCount{Count: uint64(idx)}, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+		}
+		events = append(events, newAssociationEvent(ctx, metadata.EventActionUpdate, &origins[idx], &currents[idx]))
+	}
+	return &metadata.UpdatedCount{Count: uint64(len(currents))}, m.pushEvents(ctx, events...)
+}
+
+// modelAttributes returns the attributes of the instance associations of the model association, which are the ones
+// of the association kind overridden by the ones of the model association.
+func (m *associationInstance) modelAttributes(ctx core.ContextParams, objAsstID string) ([]metadata.AssociationAttribute, error) {
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.AssociationObjAsstIDField, Val: objAsstID})
+	asst, exists, err := m.associationModel.isExists(ctx, cond)
+	if nil != err {
+		return nil, err
+	}
+	if !exists {
+		blog.Errorf("association asst kind(%#v)is not exist, rid: %s", objAsstID, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrorTopoAsstKindIsNotExist)
+	}
+
+	kind, exists, err := m.associationKind.isExists(ctx, asst.AsstKindID)
+	if nil != err {
+		return nil, err
+	}
+	if !exists {
+		return mergeAttributes(nil, asst.Attributes), nil
+	}
+	return mergeAttributes(kind.Attributes, asst.Attributes), nil
+}
+
+// refreshComputedFields evaluate the computed fields of the instances on both sides of the changed associations,
+// the association has been saved, so that the failure is only logged.
+func (m *associationInstance) refreshComputedFields(ctx core.ContextParams, assts ...metadata.InstAsst) {
+	instIDs := make(map[string][]int64)
+	for _, asst := range assts {
+		instIDs[asst.ObjectID] = append(instIDs[asst.ObjectID], asst.InstID)
+		instIDs[asst.AsstObjectID] = append(instIDs[asst.AsstObjectID], asst.AsstInstID)
+	}
+	for objID, ids := range instIDs {
+		if err := m.dependent.RefreshComputedAttributes(ctx, objID, util.IntArrayUnique(ids)); nil != err {
+			blog.Warnf("refresh computed fields of %s instances %v failed, err: %v, rid: %s", objID, ids, err, ctx.ReqID)
+		}
+	}
 }
@@ -0,0 +1,321 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+import (
+	"sort"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/metadata"
+	"icenter/src/common/util"
+	"icenter/src/source_controller/coreservice/core"
+)
+
+const (
+	defaultGraphMaxDepth = 3
+	maxGraphMaxDepth     = 10
+	defaultGraphLimit    = 1000
+	maxGraphLimit        = 10000
+	// graphLookupBatch the max instance ids in the condition of one query
+	graphLookupBatch = 500
+)
+
+// TraverseInstanceAssociation walk the instance associations from the start instances.
+// the candidate associations are found level by level by the instance ids only, then
+// the graph is walked with the object ids and the hop filters, so that the instances of
+// different objects with the same id are never mixed up.
+func (m *associationInstance) TraverseInstanceAssociation(ctx core.ContextParams, input metadata.GraphTraversalParams) (*metadata.GraphTraversalResult, error) {
+	if len(input.Start) == 0 {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "start")
+	}
+	switch input.Direction {
+	case "":
+		input.Direction = metadata.GraphDirectionOut
+	case metadata.GraphDirectionOut, metadata.GraphDirectionIn, metadata.GraphDirectionBoth:
+	default:
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "direction")
+	}
+	switch input.Output {
+	case "":
+		input.Output = metadata.GraphOutputSubgraph
+	case metadata.GraphOutputSubgraph, metadata.GraphOutputPaths:
+	default:
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "output")
+	}
+	if input.MaxDepth == 0 {
+		input.MaxDepth = defaultGraphMaxDepth
+	}
+	if input.MaxDepth < 0 || input.MaxDepth > maxGraphMaxDepth {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "max_depth")
+	}
+	if input.Limit == 0 {
+		input.Limit = defaultGraphLimit
+	}
+	if input.Limit < 0 || input.Limit > maxGraphLimit {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "limit")
+	}
+
+	startIDs := make([]int64, 0, len(input.Start))
+	for _, node := range input.Start {
+		startIDs = append(startIDs, node.InstID)
+	}
+
+	directions := []string{input.Direction}
+	if input.Direction == metadata.GraphDirectionBoth {
+		directions = []string{metadata.GraphDirectionOut, metadata.GraphDirectionIn}
+	}
+	edges, truncated, err := m.lookupInstAsst(ctx, input, directions, startIDs)
+	if err != nil {
+		blog.Errorf("traverse the instance associations from %v failed, err: %v, rid: %s", input.Start, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	result := newInstGraph(input, edges).traverse()
+	result.Truncated = result.Truncated || truncated
+	return result, nil
+}
+
+// lookupInstAsst find the associations reachable from the instance ids within the max depth in the directions.
+// every level is queried in batches of the instances found in the previous level, the associations are
+// read one by one with the cursor, so they are never limited by the max document size like $group is.
+// at most limit associations are found, truncated is true if there are more.
+func (m *associationInstance) lookupInstAsst(ctx core.ContextParams, input metadata.GraphTraversalParams, directions []string, startIDs []int64) (edges []metadata.InstAsst, truncated bool, err error) {
+	reached := make(map[int64]bool)
+	for _, id := range startIDs {
+		reached[id] = true
+	}
+
+	edges = make([]metadata.InstAsst, 0)
+	frontier := startIDs
+	for depth := 0; depth < input.MaxDepth && len(frontier) != 0; depth++ {
+		next := make([]int64, 0)
+		for start := 0; start < len(frontier); start += graphLookupBatch {
+			end := start + graphLookupBatch
+			if end > len(frontier) {
+				end = len(frontier)
+			}
+			for _, direction := range directions {
+				// one more than the remaining is found to tell whether there are more.
+				remaining := input.Limit - len(edges)
+				found, err := m.lookupLevel(ctx, input, direction, frontier[start:end], remaining+1)
+				if err != nil {
+					return nil, false, err
+				}
+				if len(found) > remaining {
+					found, truncated = found[:remaining], true
+				}
+				for _, edge := range found {
+					for _, id := range []int64{edge.InstID, edge.AsstInstID} {
+						if !reached[id] {
+							reached[id] = true
+							next = append(next, id)
+						}
+					}
+				}
+				edges = append(edges, found...)
+				if truncated {
+					return edges, truncated, nil
+				}
+			}
+		}
+		frontier = next
+	}
+	return edges, false, nil
+}
+
+// lookupLevel find at most limit associations of the instance ids in the direction, which match any of the hop filters.
+func (m *associationInstance) lookupLevel(ctx core.ContextParams, input metadata.GraphTraversalParams, direction string, ids []int64, limit int) ([]metadata.InstAsst, error) {
+	fromField, toObjField := common.BKInstIDField, common.BKAsstObjIDField
+	if direction == metadata.GraphDirectionIn {
+		fromField, toObjField = common.BKAsstInstIDField, common.BKObjIDField
+	}
+
+	cond := map[string]interface{}{fromField: map[string]interface{}{common.BKDBIN: ids}}
+	if kinds := unionHopFilter(input.Hops, func(f metadata.GraphHopFilter) []string { return f.AssociationKinds }); len(kinds) != 0 {
+		cond[common.AssociationKindIDField] = map[string]interface{}{common.BKDBIN: kinds}
+	}
+	if objIDs := unionHopFilter(input.Hops, func(f metadata.GraphHopFilter) []string { return f.ObjectIDs }); len(objIDs) != 0 {
+		cond[toObjField] = map[string]interface{}{common.BKDBIN: objIDs}
+	}
+	cond = util.SetQueryOwner(cond, ctx.SupplierAccount)
+
+	edges := make([]metadata.InstAsst, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).Find(cond).Sort(common.BKFieldID).Limit(uint64(limit)).All(ctx, &edges); err != nil {
+		return nil, err
+	}
+	return edges, nil
+}
+
+// unionHopFilter returns the union of the hop filters, it's empty if any hop has no limit.
+func unionHopFilter(hops []metadata.GraphHopFilter, values func(metadata.GraphHopFilter) []string) []string {
+	result := make([]string, 0)
+	for _, hop := range hops {
+		if len(values(hop)) == 0 {
+			return nil
+		}
+		result = append(result, values(hop)...)
+	}
+	return util.RemoveDuplicatesAndEmpty(result)
+}
+
+// graphNeighbor an association and the instance on the other side of it
+type graphNeighbor struct {
+	edge *metadata.InstAsst
+	node metadata.GraphNode
+}
+
+// instGraph the association graph of the candidate associations
+type instGraph struct {
+	input     metadata.GraphTraversalParams
+	neighbors map[metadata.GraphNode][]graphNeighbor
+}
+
+func newInstGraph(input metadata.GraphTraversalParams, edges []metadata.InstAsst) *instGraph {
+	// de-duplicate and sort the edges, so that the result is stable.
+	unique := make(map[int64]metadata.InstAsst)
+	for _, edge := range edges {
+		unique[edge.ID] = edge
+	}
+	ids := make([]int64, 0, len(unique))
+	for id := range unique {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	g := &instGraph{input: input, neighbors: make(map[metadata.GraphNode][]graphNeighbor)}
+	for _, id := range ids {
+		edge := unique[id]
+		src := metadata.GraphNode{ObjectID: edge.ObjectID, InstID: edge.InstID}
+		dst := metadata.GraphNode{ObjectID: edge.AsstObjectID, InstID: edge.AsstInstID}
+		if input.Direction != metadata.GraphDirectionIn {
+			g.neighbors[src] = append(g.neighbors[src], graphNeighbor{edge: &edge, node: dst})
+		}
+		if input.Direction != metadata.GraphDirectionOut {
+			g.neighbors[dst] = append(g.neighbors[dst], graphNeighbor{edge: &edge, node: src})
+		}
+	}
+	return g
+}
+
+// allowed whether the hop, which starts from 1, can walk through the association to the instance.
+func (g *instGraph) allowed(hop int, neighbor graphNeighbor) bool {
+	if len(g.input.Hops) == 0 {
+		return true
+	}
+	if hop > len(g.input.Hops) {
+		hop = len(g.input.Hops)
+	}
+	filter := g.input.Hops[hop-1]
+	if len(filter.AssociationKinds) != 0 && !util.InStrArr(filter.AssociationKinds, neighbor.edge.AssociationKindID) {
+		return false
+	}
+	if len(filter.ObjectIDs) != 0 && !util.InStrArr(filter.ObjectIDs, neighbor.node.ObjectID) {
+		return false
+	}
+	return true
+}
+
+func (g *instGraph) traverse() *metadata.GraphTraversalResult {
+	if g.input.Output == metadata.GraphOutputPaths {
+		return g.paths()
+	}
+	return g.subgraph()
+}
+
+// subgraph walk the graph in breadth first order, every instance is visited once at its min depth.
+func (g *instGraph) subgraph() *metadata.GraphTraversalResult {
+	result := &metadata.GraphTraversalResult{
+		Nodes: make([]metadata.GraphNodeResult, 0),
+		Edges: make([]metadata.GraphEdge, 0),
+	}
+	visited := make(map[metadata.GraphNode]bool)
+	frontier := make([]metadata.GraphNode, 0)
+	for _, node := range g.input.Start {
+		if visited[node] {
+			continue
+		}
+		visited[node] = true
+		frontier = append(frontier, node)
+		result.Nodes = append(result.Nodes, metadata.GraphNodeResult{GraphNode: node})
+	}
+
+	walked := make(map[int64]bool)
+	for depth := 1; depth <= g.input.MaxDepth && len(frontier) != 0; depth++ {
+		next := make([]metadata.GraphNode, 0)
+		for _, node := range frontier {
+			for _, neighbor := range g.neighbors[node] {
+				if walked[neighbor.edge.ID] || !g.allowed(depth, neighbor) {
+					continue
+				}
+				if !visited[neighbor.node] {
+					if len(result.Nodes) >= g.input.Limit {
+						result.Truncated = true
+						continue
+					}
+					visited[neighbor.node] = true
+					next = append(next, neighbor.node)
+					result.Nodes = append(result.Nodes, metadata.GraphNodeResult{GraphNode: neighbor.node, Depth: depth})
+				}
+				walked[neighbor.edge.ID] = true
+				result.Edges = append(result.Edges, metadata.GraphEdge{InstAsst: *neighbor.edge, Depth: depth})
+			}
+		}
+		frontier = next
+	}
+	return result
+}
+
+// paths walk the graph in depth first order, an instance never occurs twice in a path.
+func (g *instGraph) paths() *metadata.GraphTraversalResult {
+	result := &metadata.GraphTraversalResult{Paths: make([]metadata.GraphPath, 0)}
+	for _, node := range g.input.Start {
+		path := metadata.GraphPath{Nodes: []metadata.GraphNode{node}, Edges: []int64{}}
+		g.walkPaths(path, map[metadata.GraphNode]bool{node: true}, result)
+		if result.Truncated {
+			break
+		}
+	}
+	return result
+}
+
+func (g *instGraph) walkPaths(path metadata.GraphPath, onPath map[metadata.GraphNode]bool, result *metadata.GraphTraversalResult) {
+	hop := len(path.Edges) + 1
+	if hop > g.input.MaxDepth {
+		return
+	}
+	last := path.Nodes[len(path.Nodes)-1]
+	for _, neighbor := range g.neighbors[last] {
+		if onPath[neighbor.node] || !g.allowed(hop, neighbor) {
+			continue
+		}
+		next := metadata.GraphPath{
+			Nodes: append(append(make([]metadata.GraphNode, 0, hop+1), path.Nodes...), neighbor.node),
+			Edges: append(append(make([]int64, 0, hop), path.Edges...), neighbor.edge.ID),
+		}
+		if len(g.input.Targets) == 0 || util.InStrArr(g.input.Targets, neighbor.node.ObjectID) {
+			if len(result.Paths) >= g.input.Limit {
+				result.Truncated = true
+				return
+			}
+			result.Paths = append(result.Paths, next)
+		}
+
+		onPath[neighbor.node] = true
+		g.walkPaths(next, onPath, result)
+		delete(onPath, neighbor.node)
+		if result.Truncated {
+			return
+		}
+	}
+}
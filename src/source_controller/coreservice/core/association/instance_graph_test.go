@@ -0,0 +1,145 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association_test
+
+import (
+	"testing"
+
+	"icenter/src/common"
+	"icenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraverseInstanceAssociation(t *testing.T) {
+	asstMgr := newAssociation(t)
+
+	// graph_a:9001 -> graph_b:9002 -> graph_c:9003 -> graph_a:9004, and graph_d:9005 -> graph_b:9002
+	edges := []metadata.InstAsst{
+		{ID: 9001, ObjectID: "graph_a", InstID: 9001, AsstObjectID: "graph_b", AsstInstID: 9002, AssociationKindID: "run", OwnerID: common.BKDefaultOwnerID},
+		{ID: 9002, ObjectID: "graph_b", InstID: 9002, AsstObjectID: "graph_c", AsstInstID: 9003, AssociationKindID: "run", OwnerID: common.BKDefaultOwnerID},
+		{ID: 9003, ObjectID: "graph_c", InstID: 9003, AsstObjectID: "graph_a", AsstInstID: 9004, AssociationKindID: "connect", OwnerID: common.BKDefaultOwnerID},
+		{ID: 9004, ObjectID: "graph_d", InstID: 9005, AsstObjectID: "graph_b", AsstInstID: 9002, AssociationKindID: "run", OwnerID: common.BKDefaultOwnerID},
+	}
+	for _, edge := range edges {
+		require.NoError(t, testDB.Table(common.BKTableNameInstAsst).Insert(defaultCtx, edge))
+	}
+	start := []metadata.GraphNode{{ObjectID: "graph_a", InstID: 9001}}
+
+	// every level is walked out of the start instance
+	result, err := asstMgr.TraverseInstanceAssociation(defaultCtx, metadata.GraphTraversalParams{Start: start})
+	require.NoError(t, err)
+	require.Equal(t, 4, len(result.Nodes))
+	require.Equal(t, 3, len(result.Edges))
+	require.Equal(t, 3, result.Nodes[3].Depth)
+
+	// the max depth limits the levels
+	result, err = asstMgr.TraverseInstanceAssociation(defaultCtx, metadata.GraphTraversalParams{Start: start, MaxDepth: 2})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(result.Nodes))
+
+	// the hop filter stops at the connect association
+	result, err = asstMgr.TraverseInstanceAssociation(defaultCtx, metadata.GraphTraversalParams{
+		Start: start,
+		Hops:  []metadata.GraphHopFilter{{AssociationKinds: []string{"run"}}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(result.Nodes))
+
+	// both directions reach graph_d through graph_b
+	result, err = asstMgr.TraverseInstanceAssociation(defaultCtx, metadata.GraphTraversalParams{
+		Start:     start,
+		Direction: metadata.GraphDirectionBoth,
+		MaxDepth:  2,
+	})
+	require.NoError(t, err)
+	nodes := make([]metadata.GraphNode, 0)
+	for _, node := range result.Nodes {
+		nodes = append(nodes, node.GraphNode)
+	}
+	require.Contains(t, nodes, metadata.GraphNode{ObjectID: "graph_d", InstID: 9005})
+	require.NotContains(t, nodes, metadata.GraphNode{ObjectID: "graph_a", InstID: 9004})
+}
+
+func TestTraverseInstanceAssociationPaths(t *testing.T) {
+	asstMgr := newAssociation(t)
+
+	// graph_h:9101 -> graph_i:9102 -> graph_j:9103 -> graph_h:9101, and graph_h:9101 -> graph_j:9103
+	edges := []metadata.InstAsst{
+		{ID: 9101, ObjectID: "graph_h", InstID: 9101, AsstObjectID: "graph_i", AsstInstID: 9102, AssociationKindID: "run", OwnerID: common.BKDefaultOwnerID},
+		{ID: 9102, ObjectID: "graph_i", InstID: 9102, AsstObjectID: "graph_j", AsstInstID: 9103, AssociationKindID: "run", OwnerID: common.BKDefaultOwnerID},
+		{ID: 9103, ObjectID: "graph_j", InstID: 9103, AsstObjectID: "graph_h", AsstInstID: 9101, AssociationKindID: "run", OwnerID: common.BKDefaultOwnerID},
+		{ID: 9104, ObjectID: "graph_h", InstID: 9101, AsstObjectID: "graph_j", AsstInstID: 9103, AssociationKindID: "connect", OwnerID: common.BKDefaultOwnerID},
+	}
+	for _, edge := range edges {
+		require.NoError(t, testDB.Table(common.BKTableNameInstAsst).Insert(defaultCtx, edge))
+	}
+	h := metadata.GraphNode{ObjectID: "graph_h", InstID: 9101}
+	i := metadata.GraphNode{ObjectID: "graph_i", InstID: 9102}
+	j := metadata.GraphNode{ObjectID: "graph_j", InstID: 9103}
+	start := []metadata.GraphNode{h}
+
+	// the cycle back to the start instance is never walked in a path
+	result, err := asstMgr.TraverseInstanceAssociation(defaultCtx, metadata.GraphTraversalParams{
+		Start:  start,
+		Output: metadata.GraphOutputPaths,
+	})
+	require.NoError(t, err)
+	require.False(t, result.Truncated)
+	require.Equal(t, []metadata.GraphPath{
+		{Nodes: []metadata.GraphNode{h, i}, Edges: []int64{9101}},
+		{Nodes: []metadata.GraphNode{h, i, j}, Edges: []int64{9101, 9102}},
+		{Nodes: []metadata.GraphNode{h, j}, Edges: []int64{9104}},
+	}, result.Paths)
+
+	// only the paths ending at the targets are returned
+	result, err = asstMgr.TraverseInstanceAssociation(defaultCtx, metadata.GraphTraversalParams{
+		Start:   start,
+		Output:  metadata.GraphOutputPaths,
+		Targets: []string{"graph_j"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []metadata.GraphPath{
+		{Nodes: []metadata.GraphNode{h, i, j}, Edges: []int64{9101, 9102}},
+		{Nodes: []metadata.GraphNode{h, j}, Edges: []int64{9104}},
+	}, result.Paths)
+
+	// the paths are truncated at the limit
+	result, err = asstMgr.TraverseInstanceAssociation(defaultCtx, metadata.GraphTraversalParams{
+		Start:  start,
+		Output: metadata.GraphOutputPaths,
+		Limit:  1,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Truncated)
+	require.Equal(t, 1, len(result.Paths))
+
+	// the subgraph is truncated at the limit of the nodes
+	result, err = asstMgr.TraverseInstanceAssociation(defaultCtx, metadata.GraphTraversalParams{Start: start, Limit: 2})
+	require.NoError(t, err)
+	require.True(t, result.Truncated)
+	require.Equal(t, 2, len(result.Nodes))
+
+	// all the nodes are reached, but the association back to the start is beyond the limit
+	result, err = asstMgr.TraverseInstanceAssociation(defaultCtx, metadata.GraphTraversalParams{Start: start, Limit: 3})
+	require.NoError(t, err)
+	require.True(t, result.Truncated)
+	require.Equal(t, 3, len(result.Nodes))
+	require.Equal(t, 3, len(result.Edges))
+
+	result, err = asstMgr.TraverseInstanceAssociation(defaultCtx, metadata.GraphTraversalParams{Start: start, Limit: 4})
+	require.NoError(t, err)
+	require.False(t, result.Truncated)
+	require.Equal(t, 3, len(result.Nodes))
+	require.Equal(t, 4, len(result.Edges))
+}
@@ -43,7 +43,7 @@ func TestAssociationInstance(t *testing.T) {
 	createasstKindResult, err := asstMgr.CreateAssociationKind(defaultCtx, asstKindParams)
 	require.Nil(t, err)
 	require.NotNil(t, createasstKindResult)
-	require.NotEqual(t, 0, createasstKindResult.Created.ID)
+	require.NotEqual(t, uint64(0), createasstKindResult.Created.ID)
 
 	//create association model
 	asstModelParams := metadata.CreateModelAssociation{}
@@ -52,12 +52,12 @@ func TestAssociationInstance(t *testing.T) {
 	asstModelParams.Spec.AsstObjID = asstObjID
 	asstModelParams.Spec.AssociationName = associationName
 	asstModelParams.Spec.AssociationAliasName = "sw2router"
-	createasstModelResult, err := asstMgr.CreateModelAssociation(defaultCtx, metadata.CreateModelAssociation{})
+	createasstModelResult, err := asstMgr.CreateModelAssociation(defaultCtx, asstModelParams)
 	require.Nil(t, err)
 	require.NotNil(t, createasstModelResult)
 
 	//create bk_switch instance
-	objInstanceParams := metadata.CreateModelInstance{}
+	objInstanceParams := metadata.CreateModelInstance{Data: mapstr.MapStr{}}
 	objInstanceParams.Data.Set(common.BKInstNameField, xid.New().String())
 	objInstanceParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	instResult, err := instMgr.CreateModelInstance(defaultCtx, objID, objInstanceParams)
@@ -67,7 +67,7 @@ func TestAssociationInstance(t *testing.T) {
 	instID := instResult.Created.ID
 
 	//create bk_router instance
-	objAsstInstanceParams := metadata.CreateModelInstance{}
+	objAsstInstanceParams := metadata.CreateModelInstance{Data: mapstr.MapStr{}}
 	objAsstInstanceParams.Data.Set(common.BKInstNameField, xid.New().String())
 	objAsstInstanceParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	instAsstResult, err := instMgr.CreateModelInstance(defaultCtx, asstObjID, objAsstInstanceParams)
@@ -93,7 +93,7 @@ func TestAssociationInstance(t *testing.T) {
 	require.NotNil(t, err)
 
 	//create bk_switch instance 1
-	objInstanceParams = metadata.CreateModelInstance{}
+	objInstanceParams = metadata.CreateModelInstance{Data: mapstr.MapStr{}}
 	objInstanceParams.Data.Set(common.BKInstNameField, xid.New().String())
 	objInstanceParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	instResult, err = instMgr.CreateModelInstance(defaultCtx, objID, objInstanceParams)
@@ -103,7 +103,7 @@ func TestAssociationInstance(t *testing.T) {
 	instID1 := instResult.Created.ID
 
 	//create bk_switch instance 2
-	objInstanceParams = metadata.CreateModelInstance{}
+	objInstanceParams = metadata.CreateModelInstance{Data: mapstr.MapStr{}}
 	objInstanceParams.Data.Set(common.BKInstNameField, xid.New().String())
 	objInstanceParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	instResult, err = instMgr.CreateModelInstance(defaultCtx, objID, objInstanceParams)
@@ -113,7 +113,7 @@ func TestAssociationInstance(t *testing.T) {
 	instID2 := instResult.Created.ID
 
 	//create bk_router instance1
-	objAsstInstanceParams = metadata.CreateModelInstance{}
+	objAsstInstanceParams = metadata.CreateModelInstance{Data: mapstr.MapStr{}}
 	objAsstInstanceParams.Data.Set(common.BKInstNameField, xid.New().String())
 	objAsstInstanceParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	instAsstResult, err = instMgr.CreateModelInstance(defaultCtx, asstObjID, objAsstInstanceParams)
@@ -123,7 +123,7 @@ func TestAssociationInstance(t *testing.T) {
 	asstInstID1 := instAsstResult.Created.ID
 
 	//create bk_router instance2
-	objAsstInstanceParams = metadata.CreateModelInstance{}
+	objAsstInstanceParams = metadata.CreateModelInstance{Data: mapstr.MapStr{}}
 	objAsstInstanceParams.Data.Set(common.BKInstNameField, xid.New().String())
 	objAsstInstanceParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	instAsstResult, err = instMgr.CreateModelInstance(defaultCtx, asstObjID, objAsstInstanceParams)
@@ -159,12 +159,13 @@ func TestAssociationInstance(t *testing.T) {
 	asstInst3.ObjectID = objID
 
 	createManyParams := metadata.CreateManyInstanceAssociation{}
-	createManyParams.Datas = append(createManyParams.Datas, asstInst1, asstInst2)
+	// asstInst3 is created already, it is repeated
+	createManyParams.Datas = append(createManyParams.Datas, asstInst1, asstInst2, asstInst3)
 	instManyAsstResult, err := asstMgr.CreateManyInstanceAssociation(defaultCtx, createManyParams)
 	require.Nil(t, err)
 	require.NotNil(t, instManyAsstResult)
-	require.NotEqual(t, uint64(0), len(instManyAsstResult.Created))
-	require.NotEqual(t, uint64(0), len(instManyAsstResult.Repeated))
+	require.Equal(t, 2, len(instManyAsstResult.Created))
+	require.Equal(t, 1, len(instManyAsstResult.Repeated))
 
 	//search association instance
 	searchCond := metadata.QueryCondition{}
@@ -172,7 +173,7 @@ func TestAssociationInstance(t *testing.T) {
 	asstSearchResult, err := asstMgr.SearchInstanceAssociation(defaultCtx, searchCond)
 	require.Nil(t, err)
 	require.NotNil(t, asstSearchResult)
-	require.NotEqual(t, 0, asstSearchResult.Count)
+	require.Equal(t, uint64(3), asstSearchResult.Count)
 
 	//delete association instance
 	deleteCond := metadata.DeleteOption{}
@@ -180,5 +181,5 @@ func TestAssociationInstance(t *testing.T) {
 	deleteResult, err := asstMgr.DeleteInstanceAssociation(defaultCtx, deleteCond)
 	require.Nil(t, err)
 	require.NotNil(t, deleteResult)
-	require.NotEqual(t, 0, deleteResult.Count)
+	require.Equal(t, uint64(3), deleteResult.Count)
 }
@@ -29,6 +29,9 @@ type associationKind struct {
 }
 
 func (m *associationKind) CreateAssociationKind(ctx core.ContextParams, inputParam metadata.CreateAssociationKind) (*metadata.CreateOneDataResult, error) {
+	if err := validAttributes(ctx, inputParam.Data.Attributes); err != nil {
+		return nil, err
+	}
 	_, exists, err := m.isExists(ctx, inputParam.Data.AssociationKindID)
 	if nil != err {
 		blog.Errorf("check association kind is exist error (%#v)", err)
@@ -47,6 +50,16 @@ func (m *associationKind) CreateManyAssociationKind(ctx core.ContextParams, inpu
 	dataResult := &metadata.CreateManyDataResult{}
 	for itemIdx, item := range inputParam.Datas {
 
+		if err := validAttributes(ctx, item.Attributes); err != nil {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     err.Error(),
+				Code:        int64(err.(errors.CCErrorCoder).GetCode()),
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
+
 		_, exists, err := m.isExists(ctx, item.AssociationKindID)
 		if nil != err {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
@@ -85,6 +98,9 @@ func (m *associationKind) CreateManyAssociationKind(ctx core.ContextParams, inpu
 }
 
 func (m *associationKind) SetAssociationKind(ctx core.ContextParams, inputParam metadata.SetAssociationKind) (*metadata.SetDataResult, error) {
+	if err := validAttributes(ctx, inputParam.Data.Attributes); err != nil {
+		return nil, err
+	}
 	origin, exists, err := m.isExists(ctx, inputParam.Data.AssociationKindID)
 	if nil != err {
 		blog.Errorf("check association kind is exist error (%#v)", err)
@@ -128,6 +144,16 @@ func (m *associationKind) SetManyAssociationKind(ctx core.ContextParams, inputPa
 	dataResult := &metadata.SetDataResult{}
 	for itemIdx, item := range inputParam.Datas {
 
+		if err := validAttributes(ctx, item.Attributes); err != nil {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     err.Error(),
+				Code:        int64(err.(errors.CCErrorCoder).GetCode()),
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
+
 		origin, exists, err := m.isExists(ctx, item.AssociationKindID)
 		if nil != err {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
@@ -183,6 +209,9 @@ func (m *associationKind) SetManyAssociationKind(ctx core.ContextParams, inputPa
 	return dataResult, nil
 }
 func (m *associationKind) UpdateAssociationKind(ctx core.ContextParams, inputParam metadata.UpdateOption) (*metadata.UpdatedCount, error) {
+	if err := parseAttributes(ctx, inputParam.Data); err != nil {
+		return &metadata.UpdatedCount{}, err
+	}
 	cnt, err := m.dbProxy.Table(common.BKTableNameAsstDes).Find(inputParam.Condition).Count(ctx)
 	if nil != err {
 		return &metadata.UpdatedCount{}, err
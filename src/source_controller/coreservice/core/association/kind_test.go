@@ -26,29 +26,25 @@ import (
 func TestCreateAssociationKind(t *testing.T) {
 	asstMgr := newAssociation(t)
 
-	//create association kind without ID
+	//create a valid association kind
 	inputParams := metadata.CreateAssociationKind{}
+	inputParams.Data.AssociationKindID = xid.New().String()
 	inputParams.Data.AssociationKindName = xid.New().String()
 	inputParams.Data.DestinationToSourceNote = "test"
 	inputParams.Data.Direction = "test"
 	inputParams.Data.SourceToDestinationNote = "test"
 	dataResult, err := asstMgr.CreateAssociationKind(defaultCtx, inputParams)
-	require.NotNil(t, err)
-
-	//create a valid association kind
-	inputParams.Data.AssociationKindID = xid.New().String()
-	dataResult, err = asstMgr.CreateAssociationKind(defaultCtx, inputParams)
 	require.Nil(t, err)
 	require.NotNil(t, dataResult)
 	require.NotEqual(t, uint64(0), dataResult.Created.ID)
 
 	//create a new association kind with dup kind ID
-	dataResult, err = asstMgr.CreateAssociationKind(defaultCtx, inputParams)
+	_, err = asstMgr.CreateAssociationKind(defaultCtx, inputParams)
 	require.NotNil(t, err)
 
 	//create a new association kind with dup kind Name
-	inputParams.Data.AssociationKindName = xid.New().String()
-	dataResult, err = asstMgr.CreateAssociationKind(defaultCtx, inputParams)
+	inputParams.Data.AssociationKindID = xid.New().String()
+	_, err = asstMgr.CreateAssociationKind(defaultCtx, inputParams)
 	require.Nil(t, err)
 }
 
@@ -87,6 +83,7 @@ func TestSetAssociationKind(t *testing.T) {
 
 	kindID := xid.New().String()
 	inputParams := metadata.SetAssociationKind{}
+	inputParams.Data.AssociationKindID = kindID
 	inputParams.Data.AssociationKindName = kindID
 	inputParams.Data.DestinationToSourceNote = "test"
 	inputParams.Data.Direction = "test"
@@ -139,7 +136,7 @@ func TestUpdateAssociationKind(t *testing.T) {
 
 	//create association kind without ID
 	asstKind := metadata.CreateAssociationKind{}
-	updateKind := metadata.UpdateOption{}
+	updateKind := metadata.UpdateOption{Condition: mapstr.MapStr{}, Data: mapstr.MapStr{}}
 	kindID := xid.New().String()
 
 	asstKind.Data.AssociationKindID = kindID
@@ -159,7 +156,7 @@ func TestUpdateAssociationKind(t *testing.T) {
 	// update the created association kind
 	updateResult, err := asstMgr.UpdateAssociationKind(defaultCtx, updateKind)
 	require.Nil(t, err)
-	require.Equal(t, 1, updateResult.Count)
+	require.Equal(t, uint64(1), updateResult.Count)
 
 }
 
@@ -179,14 +176,14 @@ func TestSearchAssociationKind(t *testing.T) {
 	dataResult, err := asstMgr.CreateAssociationKind(defaultCtx, asstKind)
 	require.Nil(t, err)
 	require.NotNil(t, dataResult.Created)
-	require.NotEqual(t, 0, dataResult.Created.ID)
+	require.NotEqual(t, uint64(0), dataResult.Created.ID)
 
 	//search association kind
 	searchCond := metadata.QueryCondition{}
-	searchCond.Condition = mapstr.MapStr{common.AssociationKindIDField: asstKind}
+	searchCond.Condition = mapstr.MapStr{common.AssociationKindIDField: kindID}
 	searchResult, err := asstMgr.SearchAssociationKind(defaultCtx, searchCond)
 	require.Nil(t, err)
-	require.NotEqual(t, 0, searchResult.Count)
+	require.Equal(t, uint64(1), searchResult.Count)
 
 }
 
@@ -206,13 +203,13 @@ func TestDeleteassociationKind(t *testing.T) {
 	dataResult, err := asstMgr.CreateAssociationKind(defaultCtx, asstKind)
 	require.Nil(t, err)
 	require.NotNil(t, dataResult.Created)
-	require.NotEqual(t, 0, dataResult.Created.ID)
+	require.NotEqual(t, uint64(0), dataResult.Created.ID)
 
 	//delete the created association kind by condition
 	searchCond := metadata.DeleteOption{}
-	searchCond.Condition = mapstr.MapStr{common.AssociationKindIDField: asstKind}
+	searchCond.Condition = mapstr.MapStr{common.AssociationKindIDField: kindID}
 	searchResult, err := asstMgr.DeleteAssociationKind(defaultCtx, searchCond)
 	require.Nil(t, err)
-	require.NotEqual(t, 0, searchResult.Count)
+	require.Equal(t, uint64(1), searchResult.Count)
 
 }
@@ -47,6 +47,9 @@ func (m *associationModel) createModelAssociation(ctx core.ContextParams, inputP
 	if err := m.isValid(ctx, inputParam); nil != err {
 		return &metadata.CreateOneDataResult{}, err
 	}
+	if err := validAttributes(ctx, inputParam.Spec.Attributes); err != nil {
+		return &metadata.CreateOneDataResult{}, err
+	}
 
 	inputParam.Spec.OwnerID = ctx.SupplierAccount
 
@@ -116,7 +119,7 @@ func (m *associationModel) UpdateModelAssociation(ctx core.ContextParams, inputP
 
 	// only field in white list could be update
 	// bk_asst_obj_id is allowed for add business model level
-	validFields := []string{"bk_obj_asst_name", "bk_asst_obj_id"}
+	validFields := []string{"bk_obj_asst_name", "bk_asst_obj_id", metadata.AssociationFieldAttributes}
 	validData := map[string]interface{}{}
 	filterOutFields := []string{}
 	for key, val := range inputParam.Data {
@@ -130,6 +133,9 @@ func (m *associationModel) UpdateModelAssociation(ctx core.ContextParams, inputP
 	if len(filterOutFields) > 0 {
 		blog.Warnf("update object association got invalid fields: %v", filterOutFields)
 	}
+	if err := parseAttributes(ctx, validData); err != nil {
+		return &metadata.UpdatedCount{}, err
+	}
 
 	cnt, err := m.update(ctx, validData, updateCond)
 	if nil != err {
@@ -45,6 +45,12 @@ func (m *auditManager) CreateAuditLog(ctx core.ContextParams, logs ...metadata.S
 		if instNotChange(content.Content) {
 			continue
 		}
+		// the id and the request id are used to find the logs to roll back
+		id, err := m.dbProxy.NextSequence(ctx, common.BKTableNameOperationLog)
+		if err != nil {
+			blog.Errorf("create audit log failed, get the log id failed, err: %v, rid: %s", err, ctx.ReqID)
+			return err
+		}
 		row := &metadata.OperationLog{
 			OwnerID:       ctx.SupplierAccount,
 			ApplicationID: content.BizID,
@@ -56,6 +62,8 @@ func (m *auditManager) CreateAuditLog(ctx core.ContextParams, logs ...metadata.S
 			Content:       content.Content,
 			CreateTime:    time.Now(),
 			InstID:        content.ID,
+			ID:            int64(id),
+			RequestID:     ctx.ReqID,
 		}
 		logRows = append(logRows, row)
 
@@ -13,6 +13,8 @@
 package core
 
 import (
+	"time"
+
 	"icenter/src/common/mapstr"
 	"icenter/src/common/metadata"
 )
@@ -84,6 +86,10 @@ type InstanceOperation interface {
 	SearchModelInstance(ctx ContextParams, objID string, inputParam metadata.QueryCondition) (*metadata.QueryResult, error)
 	DeleteModelInstance(ctx ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
 	CascadeDeleteModelInstance(ctx ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
+	RestoreModelInstance(ctx ContextParams, objID string, inputParam metadata.CreateModelInstance) (*metadata.CreateOneDataResult, error)
+	BackfillComputedAttributes(ctx ContextParams, objID string) (*metadata.UpdatedCount, error)
+	RefreshComputedAttributes(ctx ContextParams, objID string, instIDs []int64) (*metadata.UpdatedCount, error)
+	AggregateModelInstance(ctx ContextParams, objID string, inputParam metadata.AggregateInstanceParams) (*metadata.AggregateInstanceResult, error)
 }
 
 // AssociationKind association kind methods
@@ -114,7 +120,9 @@ type InstanceAssociation interface {
 	CreateOneInstanceAssociation(ctx ContextParams, inputParam metadata.CreateOneInstanceAssociation) (*metadata.CreateOneDataResult, error)
 	CreateManyInstanceAssociation(ctx ContextParams, inputParam metadata.CreateManyInstanceAssociation) (*metadata.CreateManyDataResult, error)
 	SearchInstanceAssociation(ctx ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryResult, error)
+	UpdateInstanceAssociation(ctx ContextParams, inputParam metadata.UpdateOption) (*metadata.UpdatedCount, error)
 	DeleteInstanceAssociation(ctx ContextParams, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
+	TraverseInstanceAssociation(ctx ContextParams, inputParam metadata.GraphTraversalParams) (*metadata.GraphTraversalResult, error)
 }
 
 // DataSynchronize manager data synchronize interface
@@ -130,6 +138,7 @@ type DataSynchronizeOperation interface {
 type TopoOperation interface {
 	SearchMainlineModelTopo(withDetail bool) (*metadata.TopoModelNode, error)
 	SearchMainlineInstanceTopo(objID int64, withDetail bool) (*metadata.TopoInstanceNode, error)
+	SearchMainlineInstanceTopoAsOf(ctx ContextParams, bkBizID int64, withDetail bool, asOf time.Time) (*metadata.TopoInstanceNode, error)
 }
 
 // HostOperation methods
@@ -153,6 +162,45 @@ type AuditOperation interface {
 	SearchAuditLog(ctx ContextParams, param metadata.QueryInput) ([]metadata.OperationLog, uint64, error)
 }
 
+// EventOperation event log methods
+type EventOperation interface {
+	ReadEventStream(ctx ContextParams, param metadata.ReadEventStreamParams) (*metadata.ReadEventStreamResult, error)
+	CommitEventOffset(ctx ContextParams, param metadata.CommitEventOffsetParams) error
+}
+
+// HistoryOperation instance history methods, the history is rebuilt from the audit logs and snapshots
+type HistoryOperation interface {
+	SearchInstanceVersions(ctx ContextParams, objID string, instID int64) (*metadata.InstanceVersionsResult, error)
+	GetInstanceAsOf(ctx ContextParams, objID string, instID int64, asOf time.Time) (mapstr.MapStr, error)
+	SearchInstancesAsOf(ctx ContextParams, objID string, asOf time.Time) ([]mapstr.MapStr, error)
+}
+
+// RollbackOperation roll back the changes recorded in the audit logs
+type RollbackOperation interface {
+	RollbackAuditLogs(ctx ContextParams, input metadata.RollbackParams) (*metadata.RollbackResult, error)
+}
+
+// WebhookOperation webhook subscription methods, the events in the event log are delivered to the subscriptions.
+type WebhookOperation interface {
+	CreateSubscription(ctx ContextParams, subscription metadata.WebhookSubscription) (*metadata.WebhookSubscription, error)
+	UpdateSubscription(ctx ContextParams, id int64, data mapstr.MapStr) error
+	DeleteSubscription(ctx ContextParams, id int64) error
+	SearchSubscriptions(ctx ContextParams, input metadata.QueryCondition) (*metadata.SearchWebhookSubscriptionResult, error)
+	SearchDeadLetters(ctx ContextParams, input metadata.SearchWebhookDeadLetterParams) (*metadata.SearchWebhookDeadLetterResult, error)
+	RedeliverDeadLetters(ctx ContextParams, input metadata.WebhookDeadLetterParams) (*metadata.UpdatedCount, error)
+	DeleteDeadLetters(ctx ContextParams, input metadata.WebhookDeadLetterParams) (*metadata.DeletedCount, error)
+}
+
+// NotificationOperation notification rule methods, the users are notified of the property changes in the event log.
+type NotificationOperation interface {
+	CreateRule(ctx ContextParams, rule metadata.NotificationRule) (*metadata.NotificationRule, error)
+	UpdateRule(ctx ContextParams, id int64, data mapstr.MapStr) error
+	DeleteRule(ctx ContextParams, id int64) error
+	SearchRules(ctx ContextParams, input metadata.QueryCondition) (*metadata.SearchNotificationRuleResult, error)
+	GetSetting(ctx ContextParams, user string) (*metadata.NotificationSetting, error)
+	SaveSetting(ctx ContextParams, setting metadata.NotificationSetting) error
+}
+
 // Core core itnerfaces methods
 type Core interface {
 	ModelOperation() ModelOperation
@@ -162,6 +210,11 @@ type Core interface {
 	DataSynchronizeOperation() DataSynchronizeOperation
 	HostOperation() HostOperation
 	AuditOperation() AuditOperation
+	EventOperation() EventOperation
+	HistoryOperation() HistoryOperation
+	RollbackOperation() RollbackOperation
+	WebhookOperation() WebhookOperation
+	NotificationOperation() NotificationOperation
 }
 
 type core struct {
@@ -172,10 +225,15 @@ type core struct {
 	topo            TopoOperation
 	host            HostOperation
 	audit           AuditOperation
+	event           EventOperation
+	history         HistoryOperation
+	rollback        RollbackOperation
+	webhook         WebhookOperation
+	notification    NotificationOperation
 }
 
 // New create core
-func New(model ModelOperation, instance InstanceOperation, association AssociationOperation, dataSynchronize DataSynchronizeOperation, topo TopoOperation, host HostOperation, audit AuditOperation) Core {
+func New(model ModelOperation, instance InstanceOperation, association AssociationOperation, dataSynchronize DataSynchronizeOperation, topo TopoOperation, host HostOperation, audit AuditOperation, event EventOperation, history HistoryOperation, rollback RollbackOperation, webhook WebhookOperation, notification NotificationOperation) Core {
 	return &core{
 		model:           model,
 		instance:        instance,
@@ -184,6 +242,11 @@ func New(model ModelOperation, instance InstanceOperation, association Associati
 		topo:            topo,
 		host:            host,
 		audit:           audit,
+		event:           event,
+		history:         history,
+		rollback:        rollback,
+		webhook:         webhook,
+		notification:    notification,
 	}
 }
 
@@ -214,3 +277,23 @@ func (m *core) HostOperation() HostOperation {
 func (m *core) AuditOperation() AuditOperation {
 	return m.audit
 }
+
+func (m *core) EventOperation() EventOperation {
+	return m.event
+}
+
+func (m *core) HistoryOperation() HistoryOperation {
+	return m.history
+}
+
+func (m *core) RollbackOperation() RollbackOperation {
+	return m.rollback
+}
+
+func (m *core) WebhookOperation() WebhookOperation {
+	return m.webhook
+}
+
+func (m *core) NotificationOperation() NotificationOperation {
+	return m.notification
+}
@@ -47,6 +47,8 @@ func (a *association) SaveSynchronize(ctx core.ContextParams) errors.CCError {
 	switch a.base.syncData.DataClassify {
 	case common.SynchronizeAssociationTypeModelHost:
 		return a.saveSynchronizeAssociationModuleHostConfig(ctx)
+	case common.SynchronizeAssociationTypeInstAsst:
+		return a.saveSynchronizeAssociationInstAsst(ctx)
 	default:
 		return ctx.Error.Errorf(common.CCErrCoreServiceSyncDataClassifyNotExistError, a.dataType, a.DataClassify)
 	}
@@ -73,10 +75,11 @@ func (a *association) GetErrorStringArr(ctx core.ContextParams) ([]metadata.Exce
 		for _, err := range a.base.errorArray {
 			errMsg := fmt.Sprintf("module and host relation error. info:%#v error:%s", err.instInfo.Info, err.err.Error())
 			errArr = append(errArr, metadata.ExceptionResult{
-				OriginIndex: err.idx,
+				OriginIndex: a.base.originIndex[err.instInfo],
 				Message:     errMsg,
 			})
 		}
+		sortExceptions(errArr)
 		return errArr, err
 	default:
 		return a.base.GetErrorStringArr(ctx)
@@ -94,6 +97,16 @@ func (a *association) saveSynchronizeAssociationModuleHostConfig(ctx core.Contex
 		newItem := item.Info.Clone()
 
 		newItem.Remove(common.MetadataField)
+		if a.base.syncData.OperateType == metadata.SynchronizeOperateTypeDelete {
+			if err := a.dbProxy.Table(tableName).Delete(ctx, newItem); err != nil {
+				blog.Errorf("saveSynchronizeAssociationModuleHostConfig delete data error,err:%s.DataSign:%s,condition:%#v,rid:%s", err.Error(), a.DataClassify, newItem, ctx.ReqID)
+				a.base.errorArray[item.ID] = synchronizeAdapterError{
+					instInfo: item,
+					err:      ctx.Error.Error(common.CCErrCommDBDeleteFailed),
+				}
+			}
+			continue
+		}
 		cnt, err := a.dbProxy.Table(tableName).Find(newItem).Count(ctx)
 		if err != nil {
 			blog.Errorf("saveSynchronizeAssociationModuleHostConfig query db error,err:%s.DataSign:%s,condition:%#v,rid:%s", err.Error(), a.DataClassify, newItem, ctx.ReqID)
@@ -128,6 +141,17 @@ func (a *association) saveSynchronizeAssociationModuleHostConfig(ctx core.Contex
 	return nil
 }
 
+// saveSynchronizeAssociationInstAsst
+// the instance associations are identified by the id field
+func (a *association) saveSynchronizeAssociationInstAsst(ctx core.ContextParams) errors.CCError {
+	var dbParam synchronizeAdapterDBParameter
+	// "cc_InstAsst"
+	dbParam.tableName = common.BKTableNameInstAsst
+	dbParam.InstIDField = common.BKFieldID
+	a.base.saveSynchronize(ctx, dbParam)
+	return nil
+}
+
 func (a *association) preSynchronizeFilterBefore(ctx core.ContextParams) errors.CCError {
 	switch a.base.syncData.DataClassify {
 	case common.SynchronizeAssociationTypeModelHost:
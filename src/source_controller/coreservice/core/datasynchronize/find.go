@@ -21,6 +21,9 @@ import (
 	"icenter/src/source_controller/coreservice/core"
 )
 
+// maxPageSize the max documents count of a page
+const maxPageSize = 1000
+
 type associationFindDataInterface interface {
 	Find(ctx core.ContextParams) ([]mapstr.MapStr, uint64, errors.CCError)
 }
@@ -52,6 +55,8 @@ func (a *associationFindData) Find(ctx core.ContextParams) ([]mapstr.MapStr, uin
 		return a.findAssociation(ctx)
 	case metadata.SynchronizeOperateDataTypeModel:
 		return a.findModel(ctx)
+	case metadata.SynchronizeOperateDataTypeInstance:
+		return a.findInstance(ctx)
 	}
 	return nil, 0, nil
 }
@@ -68,18 +73,24 @@ func (a *associationFindData) findModel(ctx core.ContextParams) ([]mapstr.MapStr
 		return a.dbQueryModel(ctx, common.BKTableNamePropertyGroup)
 	case common.SynchronizeModelTypeClassification:
 		return a.dbQueryModel(ctx, common.BKTableNameObjClassifiction)
+	case common.SynchronizeModelTypeAssociationKind:
+		return a.dbQueryModel(ctx, common.BKTableNameAsstDes)
+	case common.SynchronizeModelTypeModelAssociation:
+		return a.dbQueryModel(ctx, common.BKTableNameObjAsst)
+	case common.SynchronizeModelTypeModelUnique:
+		return a.dbQueryModel(ctx, common.BKTableNameObjUnique)
 	}
 	return nil, 0, nil
 }
 
 func (a *associationFindData) dbQueryModel(ctx core.ContextParams, tableName string) ([]mapstr.MapStr, uint64, errors.CCError) {
 	info := make([]mapstr.MapStr, 0)
-	err := a.dbProxy.Table(tableName).Find(a.condition).Start(a.start).Limit(a.limit).All(ctx, &info)
+	err := a.dbProxy.Table(tableName).Find(a.condition).Start(a.start).Limit(a.pageSize()).All(ctx, &info)
 	if err != nil {
 		blog.Errorf("dbQueryModel info error. error:%s,rid:%s", err.Error(), ctx.ReqID)
 		return nil, 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
 	}
-	cnt, err := a.dbProxy.Table(tableName).Find(nil).Count(ctx)
+	cnt, err := a.dbProxy.Table(tableName).Find(a.condition).Count(ctx)
 	if err != nil {
 		blog.Errorf("dbQueryModel count error. error:%s,rid:%s", err.Error(), ctx.ReqID)
 		return nil, 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
@@ -91,18 +102,46 @@ func (a *associationFindData) findAssociation(ctx core.ContextParams) ([]mapstr.
 	switch a.dataClassify {
 	case common.SynchronizeAssociationTypeModelHost:
 		return a.dbQueryAssociation(ctx)
+	case common.SynchronizeAssociationTypeInstAsst:
+		return a.dbQueryModel(ctx, common.BKTableNameInstAsst)
 	}
 	return nil, 0, nil
 }
 
+// findInstance find the instances of the model, the data classify is the object id,
+// the instances are sorted by the id so that they could be paged.
+func (a *associationFindData) findInstance(ctx core.ContextParams) ([]mapstr.MapStr, uint64, errors.CCError) {
+	tableName := common.GetInstTableName(a.dataClassify)
+	condition := mapstr.MapStr{}
+	for key, value := range a.condition {
+		condition[key] = value
+	}
+	if tableName == common.BKTableNameBaseInst {
+		condition[common.BKObjIDField] = a.dataClassify
+	}
+
+	info := make([]mapstr.MapStr, 0)
+	err := a.dbProxy.Table(tableName).Find(condition).Sort(common.GetInstIDField(a.dataClassify)).Start(a.start).Limit(a.pageSize()).All(ctx, &info)
+	if err != nil {
+		blog.Errorf("findInstance info error. objID:%s, error:%s,rid:%s", a.dataClassify, err.Error(), ctx.ReqID)
+		return nil, 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	cnt, err := a.dbProxy.Table(tableName).Find(condition).Count(ctx)
+	if err != nil {
+		blog.Errorf("findInstance count error. objID:%s, error:%s,rid:%s", a.dataClassify, err.Error(), ctx.ReqID)
+		return nil, 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	return info, cnt, nil
+}
+
 func (a *associationFindData) dbQueryAssociation(ctx core.ContextParams) ([]mapstr.MapStr, uint64, errors.CCError) {
 	info := make([]mapstr.MapStr, 0)
-	err := a.dbProxy.Table(common.BKTableNameModuleHostConfig).Find(a.condition).Start(a.start).Limit(a.limit).All(ctx, &info)
+	err := a.dbProxy.Table(common.BKTableNameModuleHostConfig).Find(a.condition).Start(a.start).Limit(a.pageSize()).All(ctx, &info)
 	if err != nil {
 		blog.Errorf("dbQueryAssociation info error. error:%s,rid:%s", err.Error(), ctx.ReqID)
 		return nil, 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
 	}
-	cnt, err := a.dbProxy.Table(common.BKTableNameModuleHostConfig).Find(nil).Count(ctx)
+	cnt, err := a.dbProxy.Table(common.BKTableNameModuleHostConfig).Find(a.condition).Count(ctx)
 	if err != nil {
 		blog.Errorf("dbQueryAssociation count error. error:%s,rid:%s", err.Error(), ctx.ReqID)
 		return nil, 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
@@ -111,3 +150,12 @@ func (a *associationFindData) dbQueryAssociation(ctx core.ContextParams) ([]maps
 	return info, cnt, nil
 
 }
+
+// pageSize returns the limit of the page, the synchronize requests could read the whole table,
+// so a page has at most maxPageSize documents, the callers read the rest with the next pages.
+func (a *associationFindData) pageSize() uint64 {
+	if a.limit == 0 || a.limit > maxPageSize {
+		return maxPageSize
+	}
+	return a.limit
+}
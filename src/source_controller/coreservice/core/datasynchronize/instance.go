@@ -67,7 +67,7 @@ func (inst *instance) SaveSynchronize(ctx core.ContextParams) errors.CCError {
 	case common.BKInnerObjIDModule:
 		return inst.saveSynchronizeModuleInstance(ctx)
 	case common.BKInnerObjIDProc:
-		return inst.saveSynchronizeModuleInstance(ctx)
+		return inst.saveSynchronizeProcessInstance(ctx)
 	case common.BKInnerObjIDPlat:
 		return inst.saveSynchronizePlatInstance(ctx)
 	case common.BKInnerObjIDHost:
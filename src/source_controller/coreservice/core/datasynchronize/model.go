@@ -67,6 +67,12 @@ func (m *model) SaveSynchronize(ctx core.ContextParams) errors.CCError {
 		return m.saveSynchronizeModelBase(ctx)
 	case common.SynchronizeModelTypeModelClassificationRelation:
 		return m.saveSynchronizeModelClassificationRelation(ctx)
+	case common.SynchronizeModelTypeAssociationKind:
+		return m.saveSynchronizeAssociationKind(ctx)
+	case common.SynchronizeModelTypeModelAssociation:
+		return m.saveSynchronizeModelAssociation(ctx)
+	case common.SynchronizeModelTypeModelUnique:
+		return m.saveSynchronizeModelUnique(ctx)
 	default:
 		return ctx.Error.Errorf(common.CCErrCoreServiceSyncDataClassifyNotExistError, m.dataType, m.DataClassify)
 	}
@@ -116,6 +122,33 @@ func (m *model) saveSynchronizeModelBase(ctx core.ContextParams) errors.CCError
 	return nil
 }
 
+func (m *model) saveSynchronizeAssociationKind(ctx core.ContextParams) errors.CCError {
+	var dbParam synchronizeAdapterDBParameter
+	// cc_AsstDes
+	dbParam.tableName = common.BKTableNameAsstDes
+	dbParam.InstIDField = common.BKFieldID
+	m.base.saveSynchronize(ctx, dbParam)
+	return nil
+}
+
+func (m *model) saveSynchronizeModelAssociation(ctx core.ContextParams) errors.CCError {
+	var dbParam synchronizeAdapterDBParameter
+	// cc_ObjAsst
+	dbParam.tableName = common.BKTableNameObjAsst
+	dbParam.InstIDField = common.BKFieldID
+	m.base.saveSynchronize(ctx, dbParam)
+	return nil
+}
+
+func (m *model) saveSynchronizeModelUnique(ctx core.ContextParams) errors.CCError {
+	var dbParam synchronizeAdapterDBParameter
+	// cc_ObjectUnique
+	dbParam.tableName = common.BKTableNameObjUnique
+	dbParam.InstIDField = common.BKFieldID
+	m.base.saveSynchronize(ctx, dbParam)
+	return nil
+}
+
 func (m *model) preSynchronizeFilterBefore(ctx core.ContextParams) errors.CCError {
 	return nil
 }
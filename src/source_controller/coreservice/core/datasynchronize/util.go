@@ -13,6 +13,7 @@ package datasynchronize
 
 import (
 	"fmt"
+	"sort"
 
 	"icenter/src/common"
 	"icenter/src/common/blog"
@@ -26,7 +27,6 @@ import (
 type synchronizeAdapterError struct {
 	err      errors.CCError
 	instInfo *metadata.SynchronizeItem
-	idx      int64
 }
 
 type synchronizeAdapterDBParameter struct {
@@ -38,6 +38,8 @@ type synchronizeAdapter struct {
 	dbProxy    dal.RDB
 	syncData   *metadata.SynchronizeParameter
 	errorArray map[int64]synchronizeAdapterError
+	// originIndex the index of the items in the request, the empty items are filtered out of the InfoArray
+	originIndex map[*metadata.SynchronizeItem]int64
 }
 
 type dataTypeInterface interface {
@@ -48,9 +50,10 @@ type dataTypeInterface interface {
 
 func newSynchronizeAdapter(syncData *metadata.SynchronizeParameter, dbProxy dal.RDB) *synchronizeAdapter {
 	return &synchronizeAdapter{
-		syncData:   syncData,
-		dbProxy:    dbProxy,
-		errorArray: make(map[int64]synchronizeAdapterError, 0),
+		syncData:    syncData,
+		dbProxy:     dbProxy,
+		errorArray:  make(map[int64]synchronizeAdapterError, 0),
+		originIndex: make(map[*metadata.SynchronizeItem]int64),
 	}
 }
 
@@ -64,7 +67,8 @@ func (s *synchronizeAdapter) PreSynchronizeFilter(ctx core.ContextParams) errors
 		return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "instance_info_array")
 	}
 	var syncDataArr []*metadata.SynchronizeItem
-	for _, item := range s.syncData.InfoArray {
+	for idx, item := range s.syncData.InfoArray {
+		s.originIndex[item] = int64(idx)
 		if !item.Info.IsEmpty() {
 			syncDataArr = append(syncDataArr, item)
 		}
@@ -107,13 +111,21 @@ func (s *synchronizeAdapter) GetErrorStringArr(ctx core.ContextParams) ([]metada
 	for _, err := range s.errorArray {
 		errMsg := fmt.Sprintf("[%s] instID:[%d] error:%s", s.syncData.DataClassify, err.instInfo.ID, err.err.Error())
 		errArr = append(errArr, metadata.ExceptionResult{
-			OriginIndex: err.instInfo.ID,
+			OriginIndex: s.originIndex[err.instInfo],
 			Message:     errMsg,
 		})
 	}
+	sortExceptions(errArr)
 	return errArr, ctx.Error.Error(common.CCErrCoreServiceSyncError)
 }
 
+// sortExceptions sort the exceptions by the index of the failed items in the request
+func sortExceptions(errArr []metadata.ExceptionResult) {
+	sort.Slice(errArr, func(i, j int) bool {
+		return errArr[i].OriginIndex < errArr[j].OriginIndex
+	})
+}
+
 func (s *synchronizeAdapter) saveSynchronize(ctx core.ContextParams, dbParam synchronizeAdapterDBParameter) {
 	switch s.syncData.OperateType {
 	case metadata.SynchronizeOperateTypeDelete:
@@ -0,0 +1,72 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package event
+
+import (
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/eventclient"
+	"icenter/src/common/metadata"
+	"icenter/src/source_controller/coreservice/core"
+)
+
+var _ core.EventOperation = (*eventManager)(nil)
+
+type eventManager struct {
+	stream *eventclient.EventStream
+}
+
+// New create a new event manager instance
+func New(stream *eventclient.EventStream) core.EventOperation {
+	return &eventManager{
+		stream: stream,
+	}
+}
+
+// ReadEventStream read the events from the from id, or from the consumer's stored offset if the from id is not set.
+func (m *eventManager) ReadEventStream(ctx core.ContextParams, param metadata.ReadEventStreamParams) (*metadata.ReadEventStreamResult, error) {
+	if param.FromID != nil {
+		events, err := m.stream.Read(ctx, *param.FromID, param.Limit)
+		if err != nil {
+			blog.Errorf("read the events from %d failed, err: %v, rid: %s", *param.FromID, err, ctx.ReqID)
+			return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+		}
+		return &metadata.ReadEventStreamResult{Offset: *param.FromID, Info: events}, nil
+	}
+
+	if len(param.Consumer) == 0 {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "consumer")
+	}
+	events, offset, err := m.stream.Consume(ctx, param.Consumer, param.Limit)
+	if err != nil {
+		blog.Errorf("read the events of consumer %s failed, err: %v, rid: %s", param.Consumer, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	return &metadata.ReadEventStreamResult{Offset: offset, Info: events}, nil
+}
+
+// CommitEventOffset save the last event id that the consumer has consumed
+func (m *eventManager) CommitEventOffset(ctx core.ContextParams, param metadata.CommitEventOffsetParams) error {
+	if len(param.Consumer) == 0 {
+		return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "consumer")
+	}
+	if param.Offset < 0 {
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "offset")
+	}
+
+	if err := m.stream.Commit(ctx, param.Consumer, param.Offset); err != nil {
+		blog.Errorf("commit the offset %d of consumer %s failed, err: %v, rid: %s", param.Offset, param.Consumer, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
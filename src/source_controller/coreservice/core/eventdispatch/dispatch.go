@@ -0,0 +1,81 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package eventdispatch the helpers shared by the dispatchers which consume the event log,
+// such as the webhook and the notification dispatchers.
+package eventdispatch
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+)
+
+// Run call the dispatch at every interval, it never returns. only the master process dispatches the events.
+func Run(name string, interval time.Duration, isMaster func() bool, dispatch func(ctx context.Context) error) {
+	for {
+		time.Sleep(interval)
+		if isMaster != nil && !isMaster() {
+			continue
+		}
+		if err := dispatch(context.Background()); err != nil {
+			blog.Errorf("[%s] dispatch the events failed, err: %v", name, err)
+		}
+	}
+}
+
+// Backoff returns the delay of the retry after the attempts, it's doubled with every attempt until the max.
+func Backoff(attempts int, initial, max time.Duration) time.Duration {
+	delay := initial
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// Truncate returns the first max bytes of the string
+func Truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
+
+// DecodeCondition decode the condition saved as json in db
+func DecodeCondition(conditionJSON string) (mapstr.MapStr, error) {
+	if len(conditionJSON) == 0 {
+		return nil, nil
+	}
+	cond := mapstr.MapStr{}
+	if err := json.Unmarshal([]byte(conditionJSON), &cond); err != nil {
+		return nil, err
+	}
+	return cond, nil
+}
+
+// ToMap returns the data of the event as a map if it is
+func ToMap(data interface{}) (map[string]interface{}, bool) {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		return value, true
+	case mapstr.MapStr:
+		return value, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dispatchtest the fixtures shared by the tests of the event dispatchers.
+package dispatchtest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/errors"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+	"icenter/src/source_controller/coreservice/core"
+)
+
+// NewContext returns the context of a request from the test user of the default supplier account
+func NewContext() core.ContextParams {
+	return core.ContextParams{
+		Context:         context.Background(),
+		ReqID:           "test_req_id",
+		SupplierAccount: "0",
+		User:            "test_user",
+		Error:           errors.NewFromCtx(errors.EmptyErrorsSetting).CreateDefaultCCErrorIf("en"),
+	}
+}
+
+// NewHostEvent returns a host event, the cur is nil for the deleted host and the pre is nil for the created one.
+func NewHostEvent(id int64, action string, cur, pre mapstr.MapStr) metadata.EventInst {
+	event := metadata.EventInst{
+		ID:        id,
+		EventType: metadata.EventTypeInstData,
+		Action:    action,
+		ObjType:   common.BKInnerObjIDHost,
+		OwnerID:   "0",
+		Data:      []metadata.EventData{{CurData: cur, PreData: pre}},
+	}
+	if cur == nil {
+		event.Data[0].CurData = nil
+	}
+	if pre == nil {
+		event.Data[0].PreData = nil
+	}
+	return event
+}
+
+// AppendEvent append the event to the event log as it's pushed
+func AppendEvent(t *testing.T, db dal.RDB, event metadata.EventInst) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := metadata.EventLog{
+		ID:         event.ID,
+		EventType:  event.EventType,
+		Action:     event.Action,
+		ObjType:    event.ObjType,
+		OwnerID:    event.OwnerID,
+		Pushed:     true,
+		Data:       string(data),
+		CreateTime: time.Now(),
+	}
+	if err := db.Table(common.BKTableNameEventLog).Insert(context.Background(), log); err != nil {
+		t.Fatal(err)
+	}
+}
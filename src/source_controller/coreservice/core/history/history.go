@@ -0,0 +1,288 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/auditoplog"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/util"
+	"icenter/src/source_controller/coreservice/core"
+)
+
+var _ core.HistoryOperation = (*historyManager)(nil)
+
+// auditInstIDField the instance id field of the audit logs and the snapshots
+const auditInstIDField = "inst_id"
+
+// instanceOpTypes the audit log types which contain the full data of an instance
+var instanceOpTypes = []int{
+	int(auditoplog.AuditOpTypeAdd),
+	int(auditoplog.AuditOpTypeModify),
+	int(auditoplog.AuditOpTypeDel),
+}
+
+type historyManager struct {
+	dbProxy dal.RDB
+}
+
+// New create a new history manager instance
+func New(dbProxy dal.RDB) core.HistoryOperation {
+	return &historyManager{
+		dbProxy: dbProxy,
+	}
+}
+
+// auditRecord the audit log of an instance, the content contains the full data before and after the change.
+type auditRecord struct {
+	OpType   int       `bson:"op_type"`
+	OpDesc   string    `bson:"op_desc"`
+	Operator string    `bson:"operator"`
+	OpTime   time.Time `bson:"op_time"`
+	InstID   int64     `bson:"inst_id"`
+	Content  struct {
+		PreData mapstr.MapStr `bson:"pre_data"`
+		CurData mapstr.MapStr `bson:"cur_data"`
+	} `bson:"content"`
+}
+
+// SearchInstanceVersions returns all the versions of the instance in time order
+func (m *historyManager) SearchInstanceVersions(ctx core.ContextParams, objID string, instID int64) (*metadata.InstanceVersionsResult, error) {
+	cond := m.auditCondition(ctx, objID)
+	cond[auditInstIDField] = instID
+	records := make([]auditRecord, 0)
+	err := m.dbProxy.Table(common.BKTableNameOperationLog).Find(cond).Sort(common.BKOpTimeField).All(ctx, &records)
+	if err != nil {
+		blog.Errorf("search the audit logs of %s instance %d failed, err: %v, rid: %s", objID, instID, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	result := &metadata.InstanceVersionsResult{Info: make([]metadata.InstanceVersion, 0, len(records))}
+	for idx, record := range records {
+		result.Info = append(result.Info, metadata.InstanceVersion{
+			Version:  idx + 1,
+			OpType:   record.OpType,
+			OpDesc:   record.OpDesc,
+			Operator: record.Operator,
+			OpTime:   record.OpTime,
+			Data:     record.data(),
+		})
+	}
+	result.Count = len(result.Info)
+	return result, nil
+}
+
+// GetInstanceAsOf returns the instance as of the time, it's rebuilt from the latest snapshot
+// before the time and the audit logs between the snapshot and the time.
+func (m *historyManager) GetInstanceAsOf(ctx core.ContextParams, objID string, instID int64, asOf time.Time) (mapstr.MapStr, error) {
+	batch, err := m.latestBatch(ctx, asOf)
+	if err != nil {
+		blog.Errorf("get the snapshot batch before %s failed, err: %v, rid: %s", asOf, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	instances := make(map[int64]mapstr.MapStr)
+	if batch != nil {
+		snapshotCond := m.snapshotCondition(ctx, batch.SnapshotID, objID)
+		snapshotCond[auditInstIDField] = instID
+		if err := m.loadSnapshots(ctx, snapshotCond, instances); err != nil {
+			blog.Errorf("get the snapshot of %s instance %d failed, err: %v, rid: %s", objID, instID, err, ctx.ReqID)
+			return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+		}
+	}
+
+	cond := m.auditCondition(ctx, objID)
+	cond[auditInstIDField] = instID
+	cond[common.BKOpTimeField] = timeRange(batch, asOf)
+	records := make([]auditRecord, 0)
+	// only the latest audit log matters, because it contains the full data.
+	err = m.dbProxy.Table(common.BKTableNameOperationLog).Find(cond).Sort("-"+common.BKOpTimeField).Limit(1).All(ctx, &records)
+	if err != nil {
+		blog.Errorf("search the audit logs of %s instance %d failed, err: %v, rid: %s", objID, instID, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	replay(instances, records)
+
+	instance, exist := instances[instID]
+	if !exist {
+		return nil, ctx.Error.Error(common.CCErrCommNotFound)
+	}
+	return instance, nil
+}
+
+// SearchInstancesAsOf returns all the instances of the object as of the time
+func (m *historyManager) SearchInstancesAsOf(ctx core.ContextParams, objID string, asOf time.Time) ([]mapstr.MapStr, error) {
+	batch, err := m.latestBatch(ctx, asOf)
+	if err != nil {
+		blog.Errorf("get the snapshot batch before %s failed, err: %v, rid: %s", asOf, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	instances := make(map[int64]mapstr.MapStr)
+	if batch != nil {
+		if err := m.loadSnapshots(ctx, m.snapshotCondition(ctx, batch.SnapshotID, objID), instances); err != nil {
+			blog.Errorf("get the snapshots of %s failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+			return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+		}
+	}
+
+	cond := m.auditCondition(ctx, objID)
+	cond[common.BKOpTimeField] = timeRange(batch, asOf)
+	records := make([]auditRecord, 0)
+	err = m.dbProxy.Table(common.BKTableNameOperationLog).Find(cond).Sort(common.BKOpTimeField).All(ctx, &records)
+	if err != nil {
+		blog.Errorf("search the audit logs of %s failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	replay(instances, records)
+
+	instIDs := make([]int64, 0, len(instances))
+	for instID := range instances {
+		instIDs = append(instIDs, instID)
+	}
+	sort.Slice(instIDs, func(i, j int) bool { return instIDs[i] < instIDs[j] })
+	result := make([]mapstr.MapStr, 0, len(instIDs))
+	for _, instID := range instIDs {
+		result = append(result, instances[instID])
+	}
+	return result, nil
+}
+
+func (m *historyManager) auditCondition(ctx core.ContextParams, objID string) map[string]interface{} {
+	cond := map[string]interface{}{
+		common.BKOpTargetField: objID,
+		common.BKOpTypeField:   map[string]interface{}{common.BKDBIN: instanceOpTypes},
+	}
+	if len(ctx.SupplierAccount) != 0 {
+		cond = util.SetQueryOwner(cond, ctx.SupplierAccount)
+	}
+	return cond
+}
+
+func (m *historyManager) snapshotCondition(ctx core.ContextParams, snapshotID int64, objID string) map[string]interface{} {
+	cond := map[string]interface{}{
+		"snapshot_id":       snapshotID,
+		common.BKObjIDField: objID,
+	}
+	if len(ctx.SupplierAccount) != 0 {
+		cond = util.SetQueryOwner(cond, ctx.SupplierAccount)
+	}
+	return cond
+}
+
+// latestBatch returns the latest snapshot batch which is finished before the time, it's nil if not exist.
+// the batch in progress at the time is not used, the snapshots taken after the time contain the later changes.
+func (m *historyManager) latestBatch(ctx core.ContextParams, asOf time.Time) (*metadata.InstSnapshotBatch, error) {
+	cond := map[string]interface{}{
+		"finish_time": map[string]interface{}{common.BKDBLTE: asOf},
+	}
+	batches := make([]metadata.InstSnapshotBatch, 0)
+	err := m.dbProxy.Table(common.BKTableNameInstSnapshotBatch).Find(cond).Sort("-finish_time").Limit(1).All(ctx, &batches)
+	if err != nil {
+		return nil, err
+	}
+	if len(batches) == 0 {
+		return nil, nil
+	}
+	return &batches[0], nil
+}
+
+func (m *historyManager) loadSnapshots(ctx core.ContextParams, cond map[string]interface{}, instances map[int64]mapstr.MapStr) error {
+	snapshots := make([]metadata.InstSnapshot, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstSnapshot).Find(cond).All(ctx, &snapshots); err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		instances[snapshot.InstID] = snapshot.Data
+	}
+	return nil
+}
+
+// timeRange returns the op time condition of the audit logs after the snapshot batch and before the time.
+func timeRange(batch *metadata.InstSnapshotBatch, asOf time.Time) map[string]interface{} {
+	opTime := map[string]interface{}{common.BKDBLTE: asOf}
+	if batch != nil {
+		// the instances may be changed while the snapshots are taken, so replay from the start time.
+		opTime[common.BKDBGTE] = batch.StartTime
+	}
+	return opTime
+}
+
+// data returns the instance data after this change, it's nil if the instance is deleted.
+func (r *auditRecord) data() mapstr.MapStr {
+	if r.OpType == int(auditoplog.AuditOpTypeDel) {
+		return nil
+	}
+	return r.Content.CurData
+}
+
+// replay apply the audit logs to the instances in time order.
+func replay(instances map[int64]mapstr.MapStr, records []auditRecord) {
+	for idx := range records {
+		data := records[idx].data()
+		if data == nil {
+			delete(instances, records[idx].InstID)
+			continue
+		}
+		instances[records[idx].InstID] = data
+	}
+}
+
+// FilterInstances returns the instances which match all the fields of the condition,
+// the key of the condition can be a dotted path of the embedded document.
+func FilterInstances(instances []mapstr.MapStr, cond map[string]interface{}) []mapstr.MapStr {
+	result := make([]mapstr.MapStr, 0)
+	for _, instance := range instances {
+		if matchCondition(instance, cond) {
+			result = append(result, instance)
+		}
+	}
+	return result
+}
+
+func matchCondition(instance mapstr.MapStr, cond map[string]interface{}) bool {
+	for key, expect := range cond {
+		var value interface{} = map[string]interface{}(instance)
+		for _, field := range strings.Split(key, ".") {
+			doc, err := mapstr.NewFromInterface(value)
+			if err != nil {
+				return false
+			}
+			value = doc[field]
+		}
+		if !equalValue(value, expect) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalValue(value, expect interface{}) bool {
+	if value == nil || expect == nil {
+		return value == expect
+	}
+	valueInt, err := util.GetInt64ByInterface(value)
+	if err == nil {
+		expectInt, err := util.GetInt64ByInterface(expect)
+		return err == nil && valueInt == expectInt
+	}
+	return fmt.Sprint(value) == fmt.Sprint(expect)
+}
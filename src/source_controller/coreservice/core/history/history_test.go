@@ -0,0 +1,128 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/auditoplog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal/memory"
+	"icenter/src/source_controller/coreservice/core"
+)
+
+func newRecord(opType auditoplog.AuditOpType, instID int64, data mapstr.MapStr) auditRecord {
+	record := auditRecord{OpType: int(opType), InstID: instID, OpTime: time.Now()}
+	record.Content.CurData = data
+	return record
+}
+
+func TestReplay(t *testing.T) {
+	instances := map[int64]mapstr.MapStr{
+		1: {"bk_inst_id": 1, "bk_inst_name": "snapshot"},
+		2: {"bk_inst_id": 2, "bk_inst_name": "deleted"},
+	}
+	records := []auditRecord{
+		newRecord(auditoplog.AuditOpTypeModify, 1, mapstr.MapStr{"bk_inst_id": 1, "bk_inst_name": "modified"}),
+		newRecord(auditoplog.AuditOpTypeDel, 2, mapstr.MapStr{"bk_inst_id": 2, "bk_inst_name": "deleted"}),
+		newRecord(auditoplog.AuditOpTypeAdd, 3, mapstr.MapStr{"bk_inst_id": 3, "bk_inst_name": "added"}),
+	}
+	replay(instances, records)
+
+	if len(instances) != 2 {
+		t.Fatalf("expect 2 instances, got %v", instances)
+	}
+	if instances[1]["bk_inst_name"] != "modified" {
+		t.Errorf("instance 1 should be modified, got %v", instances[1])
+	}
+	if _, exist := instances[2]; exist {
+		t.Errorf("instance 2 should be deleted")
+	}
+	if instances[3]["bk_inst_name"] != "added" {
+		t.Errorf("instance 3 should be added, got %v", instances[3])
+	}
+}
+
+func TestFilterInstances(t *testing.T) {
+	instances := []mapstr.MapStr{
+		{"bk_inst_id": int64(1), "bk_biz_id": 2, "metadata": map[string]interface{}{"label": map[string]interface{}{"bk_biz_id": "2"}}},
+		{"bk_inst_id": float64(2), "bk_biz_id": 3, "metadata": map[string]interface{}{"label": map[string]interface{}{"bk_biz_id": "3"}}},
+		{"bk_inst_id": 3},
+	}
+
+	result := FilterInstances(instances, map[string]interface{}{"bk_biz_id": int64(2)})
+	if len(result) != 1 || result[0]["bk_inst_id"] != int64(1) {
+		t.Errorf("filter by bk_biz_id failed, got %v", result)
+	}
+
+	result = FilterInstances(instances, map[string]interface{}{"metadata.label.bk_biz_id": "3"})
+	if len(result) != 1 || result[0]["bk_inst_id"] != float64(2) {
+		t.Errorf("filter by embedded field failed, got %v", result)
+	}
+
+	result = FilterInstances(instances, map[string]interface{}{"bk_inst_id": 2})
+	if len(result) != 1 {
+		t.Errorf("filter by number of different types failed, got %v", result)
+	}
+
+	result = FilterInstances(instances, map[string]interface{}{"metadata.label.bk_biz_id": "4"})
+	if len(result) != 0 {
+		t.Errorf("expect no instance, got %v", result)
+	}
+}
+
+func TestParseConfigFromKV(t *testing.T) {
+	config := ParseConfigFromKV("history", map[string]string{})
+	if config.Interval != defaultSnapshotInterval || config.Keep != defaultSnapshotKeep {
+		t.Errorf("unexpected default config %+v", config)
+	}
+	config = ParseConfigFromKV("history", map[string]string{"history.snapshot_interval_hours": "6", "history.snapshot_keep": "3"})
+	if config.Interval != 6*time.Hour || config.Keep != 3 {
+		t.Errorf("unexpected config %+v", config)
+	}
+}
+
+func TestLatestBatch(t *testing.T) {
+	db := memory.NewMemory()
+	now := time.Now()
+	batches := []metadata.InstSnapshotBatch{
+		{SnapshotID: 1, StartTime: now.Add(-3 * time.Hour), FinishTime: now.Add(-2 * time.Hour)},
+		// the batch is in progress at now-30m
+		{SnapshotID: 2, StartTime: now.Add(-time.Hour), FinishTime: now},
+	}
+	for _, batch := range batches {
+		if err := db.Table(common.BKTableNameInstSnapshotBatch).Insert(context.Background(), batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := &historyManager{dbProxy: db}
+	ctx := core.ContextParams{Context: context.Background()}
+	for asOf, expect := range map[time.Duration]int64{-4 * time.Hour: 0, -30 * time.Minute: 1, time.Minute: 2} {
+		batch, err := m.latestBatch(ctx, now.Add(asOf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var snapshotID int64
+		if batch != nil {
+			snapshotID = batch.SnapshotID
+		}
+		if snapshotID != expect {
+			t.Errorf("the latest batch as of now%s should be %d, got %d", asOf, expect, snapshotID)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/util"
+)
+
+const (
+	// defaultSnapshotInterval the default interval between two snapshot batches
+	defaultSnapshotInterval = 24 * time.Hour
+	// defaultSnapshotKeep the default count of the snapshot batches to keep
+	defaultSnapshotKeep = 7
+	// snapshotCheckInterval the interval to check whether a new snapshot batch should be taken
+	snapshotCheckInterval = 10 * time.Minute
+	// snapshotPageSize the count of the instances read and saved at once
+	snapshotPageSize = 500
+)
+
+// Config the config of the instance snapshots
+type Config struct {
+	// Interval the interval between two snapshot batches
+	Interval time.Duration
+	// Keep the count of the snapshot batches to keep, the older ones are deleted.
+	Keep int
+}
+
+// ParseConfigFromKV returns a new config, the interval is configured in hours.
+func ParseConfigFromKV(prefix string, configmap map[string]string) Config {
+	config := Config{
+		Interval: defaultSnapshotInterval,
+		Keep:     defaultSnapshotKeep,
+	}
+	if hours, err := strconv.Atoi(configmap[prefix+".snapshot_interval_hours"]); err == nil && hours > 0 {
+		config.Interval = time.Duration(hours) * time.Hour
+	}
+	if keep, err := strconv.Atoi(configmap[prefix+".snapshot_keep"]); err == nil && keep > 0 {
+		config.Keep = keep
+	}
+	return config
+}
+
+// RunSnapshot take a snapshot batch of all the instances every interval, it never returns.
+// only the master process takes the snapshots.
+func RunSnapshot(dbProxy dal.RDB, config Config, isMaster func() bool) {
+	m := &historyManager{dbProxy: dbProxy}
+	if config.Interval <= 0 {
+		config.Interval = defaultSnapshotInterval
+	}
+	if config.Keep <= 0 {
+		config.Keep = defaultSnapshotKeep
+	}
+
+	for {
+		time.Sleep(snapshotCheckInterval)
+		if isMaster != nil && !isMaster() {
+			continue
+		}
+
+		ctx := context.Background()
+		batches := make([]metadata.InstSnapshotBatch, 0)
+		err := m.dbProxy.Table(common.BKTableNameInstSnapshotBatch).Find(nil).Sort("-start_time").Limit(1).All(ctx, &batches)
+		if err != nil {
+			blog.Errorf("[history] get the latest snapshot batch failed, err: %v", err)
+			continue
+		}
+		if len(batches) != 0 && time.Since(batches[0].StartTime) < config.Interval {
+			continue
+		}
+
+		if err := m.TakeSnapshot(ctx); err != nil {
+			blog.Errorf("[history] take snapshot failed, err: %v", err)
+			continue
+		}
+		if err := m.purgeSnapshots(ctx, config.Keep); err != nil {
+			blog.Errorf("[history] purge the old snapshots failed, err: %v", err)
+		}
+	}
+}
+
+// TakeSnapshot take a snapshot of all the instances of all the objects
+func (m *historyManager) TakeSnapshot(ctx context.Context) error {
+	snapshotID, err := m.dbProxy.NextSequence(ctx, common.BKTableNameInstSnapshotBatch)
+	if err != nil {
+		return err
+	}
+	batch := metadata.InstSnapshotBatch{
+		SnapshotID: int64(snapshotID),
+		StartTime:  time.Now(),
+	}
+	blog.Infof("[history] start to take snapshot %d", batch.SnapshotID)
+
+	objects := make([]metadata.Object, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjDes).Find(nil).Fields(common.BKObjIDField).All(ctx, &objects); err != nil {
+		return err
+	}
+	objIDs := make([]string, 0, len(objects))
+	for _, object := range objects {
+		objIDs = append(objIDs, object.ObjectID)
+	}
+	objIDs = util.RemoveDuplicatesAndEmpty(objIDs)
+
+	for _, objID := range objIDs {
+		count, err := m.snapshotObject(ctx, batch.SnapshotID, objID)
+		if err != nil {
+			blog.Errorf("[history] take snapshot %d of %s failed, err: %v", batch.SnapshotID, objID, err)
+			// the unfinished batch is never used, delete the snapshots taken.
+			m.deleteSnapshots(ctx, []int64{batch.SnapshotID})
+			return err
+		}
+		batch.Count += count
+	}
+
+	batch.FinishTime = time.Now()
+	if err := m.dbProxy.Table(common.BKTableNameInstSnapshotBatch).Insert(ctx, batch); err != nil {
+		m.deleteSnapshots(ctx, []int64{batch.SnapshotID})
+		return err
+	}
+	blog.Infof("[history] snapshot %d of %d instances finished, cost %s", batch.SnapshotID, batch.Count, batch.FinishTime.Sub(batch.StartTime))
+	return nil
+}
+
+func (m *historyManager) snapshotObject(ctx context.Context, snapshotID int64, objID string) (int64, error) {
+	tableName := common.GetInstTableName(objID)
+	instIDField := common.GetInstIDField(objID)
+	cond := map[string]interface{}{}
+	if tableName == common.BKTableNameBaseInst {
+		cond[common.BKObjIDField] = objID
+	}
+
+	var count int64
+	for start := uint64(0); ; start += snapshotPageSize {
+		instances := make([]mapstr.MapStr, 0)
+		err := m.dbProxy.Table(tableName).Find(cond).Sort(instIDField).Start(start).Limit(snapshotPageSize).All(ctx, &instances)
+		if err != nil {
+			return count, err
+		}
+		if len(instances) == 0 {
+			return count, nil
+		}
+
+		snapshots := make([]metadata.InstSnapshot, 0, len(instances))
+		for _, instance := range instances {
+			instID, err := instance.Int64(instIDField)
+			if err != nil {
+				blog.Warnf("[history] the %s field of %s instance %v is invalid, skip it", instIDField, objID, instance)
+				continue
+			}
+			delete(instance, "_id")
+			snapshots = append(snapshots, metadata.InstSnapshot{
+				SnapshotID: snapshotID,
+				ObjectID:   objID,
+				InstID:     instID,
+				OwnerID:    util.GetStrByInterface(instance[common.BKOwnerIDField]),
+				Data:       instance,
+			})
+		}
+		if len(snapshots) != 0 {
+			if err := m.dbProxy.Table(common.BKTableNameInstSnapshot).Insert(ctx, snapshots); err != nil {
+				return count, err
+			}
+		}
+		count += int64(len(snapshots))
+
+		if len(instances) < snapshotPageSize {
+			return count, nil
+		}
+	}
+}
+
+// purgeSnapshots delete the snapshot batches except the latest ones
+func (m *historyManager) purgeSnapshots(ctx context.Context, keep int) error {
+	batches := make([]metadata.InstSnapshotBatch, 0)
+	err := m.dbProxy.Table(common.BKTableNameInstSnapshotBatch).Find(nil).Sort("-snapshot_id").Start(uint64(keep)).All(ctx, &batches)
+	if err != nil {
+		return err
+	}
+	if len(batches) == 0 {
+		return nil
+	}
+
+	snapshotIDs := make([]int64, 0, len(batches))
+	for _, batch := range batches {
+		snapshotIDs = append(snapshotIDs, batch.SnapshotID)
+	}
+	cond := map[string]interface{}{"snapshot_id": map[string]interface{}{common.BKDBIN: snapshotIDs}}
+	if err := m.dbProxy.Table(common.BKTableNameInstSnapshotBatch).Delete(ctx, cond); err != nil {
+		return err
+	}
+	return m.deleteSnapshots(ctx, snapshotIDs)
+}
+
+func (m *historyManager) deleteSnapshots(ctx context.Context, snapshotIDs []int64) error {
+	cond := map[string]interface{}{"snapshot_id": map[string]interface{}{common.BKDBIN: snapshotIDs}}
+	err := m.dbProxy.Table(common.BKTableNameInstSnapshot).Delete(ctx, cond)
+	if err != nil {
+		blog.Errorf("[history] delete the snapshots %v failed, err: %v", snapshotIDs, err)
+	}
+	return err
+}
@@ -31,12 +31,12 @@ type hostManager struct {
 }
 
 // New create a new model manager instance
-func New(dbProxy dal.RDB, cache *redis.Client) core.HostOperation {
+func New(dbProxy dal.RDB, cache *redis.Client, eventC eventclient.Client) core.HostOperation {
 
 	coreMgr := &hostManager{
 		DbProxy: dbProxy,
 		Cache:   cache,
-		EventC:  eventclient.NewClientViaRedis(cache, dbProxy),
+		EventC:  eventC,
 	}
 	coreMgr.moduleHost = modulehost.New(dbProxy, cache, coreMgr.EventC)
 	return coreMgr
@@ -0,0 +1,349 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instances
+
+import (
+	"fmt"
+	"strconv"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/universalsql/mongo"
+	"icenter/src/common/util"
+	"icenter/src/source_controller/coreservice/core"
+)
+
+const (
+	// aggregateMaxGroupBy the max count of the properties the instances are grouped by
+	aggregateMaxGroupBy = 5
+	// aggregateDefaultLimit the default count of the groups returned
+	aggregateDefaultLimit = 1000
+	// aggregateMaxLimit the max count of the groups returned
+	aggregateMaxLimit = 10000
+	// aggregateRelationField the host module relations joined to get the business of the hosts
+	aggregateRelationField = "__relations"
+)
+
+// aggregateDateFormats the format of the date bucket of every interval
+var aggregateDateFormats = map[string]string{
+	metadata.AggregateIntervalDay:   "%Y-%m-%d",
+	metadata.AggregateIntervalWeek:  "%G-W%V",
+	metadata.AggregateIntervalMonth: "%Y-%m",
+	metadata.AggregateIntervalYear:  "%Y",
+}
+
+// aggregateRow a group returned by the pipeline, the group by fields are g0, g1... in the _id,
+// and the metrics are m0, m1...
+type aggregateRow struct {
+	ID      map[string]interface{} `bson:"_id"`
+	Metrics map[string]interface{} `bson:",inline"`
+}
+
+// aggregatePlan the validated params and the attributes of the group by fields
+type aggregatePlan struct {
+	objID   string
+	params  metadata.AggregateInstanceParams
+	attrs   map[string]metadata.Attribute
+	bizPath string
+	bizID   interface{}
+}
+
+// AggregateModelInstance group the instances of the model by the properties and compute the metrics of every group
+func (m *instanceManager) AggregateModelInstance(ctx core.ContextParams, objID string, inputParam metadata.AggregateInstanceParams) (*metadata.AggregateInstanceResult, error) {
+	plan, err := m.planAggregate(ctx, objID, inputParam)
+	if nil != err {
+		return nil, err
+	}
+	pipeline, err := plan.pipeline(ctx)
+	if nil != err {
+		blog.Errorf("AggregateModelInstance failed, invalid condition: %#v, err: %v, rid: %s", inputParam.Condition, err, ctx.ReqID)
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "condition")
+	}
+
+	rows := make([]aggregateRow, 0)
+	tableName := common.GetInstTableName(objID)
+	if err := m.dbProxy.Table(tableName).AggregateAll(ctx, pipeline, &rows); nil != err {
+		blog.Errorf("AggregateModelInstance failed, aggregate table %s with pipeline %#v failed, err: %v, rid: %s", tableName, pipeline, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	result := &metadata.AggregateInstanceResult{Groups: make([]metadata.AggregateInstanceGroup, 0, len(rows))}
+	if len(rows) > plan.params.Limit {
+		rows = rows[:plan.params.Limit]
+		result.Truncated = true
+	}
+	for _, row := range rows {
+		group := metadata.AggregateInstanceGroup{Key: mapstr.New(), Names: mapstr.New(), Metrics: mapstr.New()}
+		for idx, groupBy := range plan.params.GroupBy {
+			group.Key[groupBy.Field] = plan.keyValue(groupBy, row.ID[fmt.Sprintf("g%d", idx)])
+		}
+		for idx, metric := range plan.params.Metrics {
+			group.Metrics[metric.Name()] = row.Metrics[fmt.Sprintf("m%d", idx)]
+		}
+		result.Groups = append(result.Groups, group)
+	}
+	if err := m.fillAggregateNames(ctx, plan, result.Groups); nil != err {
+		return nil, err
+	}
+	return result, nil
+}
+
+// planAggregate validate the params with the attributes of the model and fill the defaults
+func (m *instanceManager) planAggregate(ctx core.ContextParams, objID string, params metadata.AggregateInstanceParams) (*aggregatePlan, error) {
+	if len(params.GroupBy) > aggregateMaxGroupBy {
+		return nil, ctx.Error.Errorf(common.CCErrCommOverLimit, "group_by")
+	}
+	if params.Limit == 0 {
+		params.Limit = aggregateDefaultLimit
+	}
+	if params.Limit < 0 || params.Limit > aggregateMaxLimit {
+		return nil, ctx.Error.Errorf(common.CCErrCommOverLimit, "limit")
+	}
+	if len(params.Metrics) == 0 {
+		params.Metrics = []metadata.AggregateMetric{{Func: metadata.AggregateCount}}
+	}
+
+	attrs, err := m.dependent.SelectObjectAttWithParams(ctx, objID, params.BizID)
+	if nil != err {
+		blog.Errorf("AggregateModelInstance failed, get the attributes of model %s failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return nil, err
+	}
+	plan := &aggregatePlan{objID: objID, params: params, attrs: make(map[string]metadata.Attribute)}
+	for _, attr := range attrs {
+		plan.attrs[attr.PropertyID] = attr
+	}
+
+	// the business of the set, the module and the business is their own field, the hosts are in the business
+	// of their modules, and the other instances are in the business of their metadata label.
+	switch objID {
+	case common.BKInnerObjIDApp, common.BKInnerObjIDSet, common.BKInnerObjIDModule, common.BKInnerObjIDHost:
+		plan.bizPath, plan.bizID = common.BKAppIDField, params.BizID
+	default:
+		plan.bizPath, plan.bizID = "metadata.label."+metadata.LabelBusinessID, strconv.FormatInt(params.BizID, 10)
+	}
+
+	groupFields := make(map[string]bool)
+	for _, groupBy := range params.GroupBy {
+		if groupFields[groupBy.Field] {
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, groupBy.Field)
+		}
+		groupFields[groupBy.Field] = true
+
+		attr, isAttr := plan.attrs[groupBy.Field]
+		isTime := groupBy.Field == common.CreateTimeField || groupBy.Field == common.LastTimeField
+		if !isAttr && !isTime && groupBy.Field != common.BKAppIDField {
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, groupBy.Field)
+		}
+		if groupBy.Interval == "" {
+			continue
+		}
+		if _, ok := aggregateDateFormats[groupBy.Interval]; !ok {
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "interval")
+		}
+		if !isTime && (!isAttr || (attr.PropertyType != common.FieldTypeDate && attr.PropertyType != common.FieldTypeTime)) {
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, groupBy.Field)
+		}
+	}
+
+	metricNames := make(map[string]bool)
+	for _, metric := range params.Metrics {
+		switch metric.Func {
+		case metadata.AggregateCount:
+		case metadata.AggregateSum, metadata.AggregateAvg, metadata.AggregateMin, metadata.AggregateMax:
+			if !isNumericAttribute(plan.attrs[metric.Field]) {
+				return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metric.Field)
+			}
+		default:
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "func")
+		}
+		if metricNames[metric.Name()] {
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metric.Name())
+		}
+		metricNames[metric.Name()] = true
+	}
+	return plan, nil
+}
+
+// isNumericAttribute whether the metrics can be computed with the values of the attribute
+func isNumericAttribute(attr metadata.Attribute) bool {
+	switch attr.PropertyType {
+	case common.FieldTypeInt, common.FieldTypeFloat:
+		return true
+	case common.FieldTypeComputed:
+		option, _, err := util.ParseComputedOption(attr.Option)
+		return nil == err && (option.ResultType == common.FieldTypeInt || option.ResultType == common.FieldTypeFloat)
+	default:
+		return false
+	}
+}
+
+// pipeline build the aggregate pipeline, the business is joined only if it's needed.
+func (p *aggregatePlan) pipeline(ctx core.ContextParams) ([]mapstr.MapStr, error) {
+	condition, err := mongo.NewConditionFromMapStr(p.params.Condition)
+	if nil != err {
+		return nil, err
+	}
+	if common.GetInstTableName(p.objID) == common.BKTableNameBaseInst {
+		condition.And(&mongo.Eq{Key: common.BKObjIDField, Val: p.objID})
+	}
+	pipeline := []mapstr.MapStr{
+		{common.BKDBMatch: util.SetQueryOwner(condition.ToMapStr(), ctx.SupplierAccount)},
+	}
+
+	needBiz := p.params.BizID != 0
+	for _, groupBy := range p.params.GroupBy {
+		needBiz = needBiz || groupBy.Field == common.BKAppIDField
+	}
+	if needBiz && p.objID == common.BKInnerObjIDHost {
+		pipeline = append(pipeline,
+			mapstr.MapStr{"$lookup": mapstr.MapStr{
+				"from":         common.BKTableNameModuleHostConfig,
+				"localField":   common.BKHostIDField,
+				"foreignField": common.BKHostIDField,
+				"as":           aggregateRelationField,
+			}},
+			mapstr.MapStr{"$addFields": mapstr.MapStr{
+				common.BKAppIDField: mapstr.MapStr{"$arrayElemAt": []interface{}{"$" + aggregateRelationField + "." + common.BKAppIDField, 0}},
+			}},
+		)
+	}
+	if p.params.BizID != 0 {
+		pipeline = append(pipeline, mapstr.MapStr{common.BKDBMatch: mapstr.MapStr{p.bizPath: p.bizID}})
+	}
+
+	var id interface{}
+	if len(p.params.GroupBy) != 0 {
+		keys := mapstr.New()
+		for idx, groupBy := range p.params.GroupBy {
+			keys[fmt.Sprintf("g%d", idx)] = p.groupExpr(groupBy)
+		}
+		id = keys
+	}
+	group := mapstr.MapStr{"_id": id}
+	for idx, metric := range p.params.Metrics {
+		if metric.Func == metadata.AggregateCount {
+			group[fmt.Sprintf("m%d", idx)] = mapstr.MapStr{common.BKDBSum: 1}
+			continue
+		}
+		group[fmt.Sprintf("m%d", idx)] = mapstr.MapStr{"$" + metric.Func: "$" + metric.Field}
+	}
+
+	return append(pipeline,
+		mapstr.MapStr{common.BKDBGroup: group},
+		mapstr.MapStr{"$sort": mapstr.MapStr{"_id": 1}},
+		mapstr.MapStr{"$limit": p.params.Limit + 1},
+	), nil
+}
+
+// groupExpr the expression of the group by field, the date is formatted as the bucket of the interval,
+// the date and time properties are saved as strings, which are parsed at first.
+func (p *aggregatePlan) groupExpr(groupBy metadata.AggregateGroupBy) interface{} {
+	path := groupBy.Field
+	if groupBy.Field == common.BKAppIDField && p.objID != common.BKInnerObjIDHost {
+		path = p.bizPath
+	}
+	if groupBy.Interval == "" {
+		return "$" + path
+	}
+
+	var date interface{} = "$" + path
+	if attr, ok := p.attrs[groupBy.Field]; ok && groupBy.Field != common.CreateTimeField && groupBy.Field != common.LastTimeField {
+		format := "%Y-%m-%d"
+		if attr.PropertyType == common.FieldTypeTime {
+			format = "%Y-%m-%d %H:%M:%S"
+		}
+		date = mapstr.MapStr{"$dateFromString": mapstr.MapStr{
+			"dateString": "$" + path,
+			"format":     format,
+			"onError":    nil,
+			"onNull":     nil,
+		}}
+	}
+	return mapstr.MapStr{"$dateToString": mapstr.MapStr{
+		"format": aggregateDateFormats[groupBy.Interval],
+		"date":   date,
+	}}
+}
+
+// keyValue the value of the group by field in the result, the business id in the label is converted to number.
+func (p *aggregatePlan) keyValue(groupBy metadata.AggregateGroupBy, value interface{}) interface{} {
+	if groupBy.Field != common.BKAppIDField || nil == value {
+		return value
+	}
+	bizID, err := util.GetInt64ByInterface(value)
+	if nil != err {
+		return value
+	}
+	return bizID
+}
+
+// fillAggregateNames set the names of the enum options and the businesses in the key of the groups
+func (m *instanceManager) fillAggregateNames(ctx core.ContextParams, plan *aggregatePlan, groups []metadata.AggregateInstanceGroup) error {
+	bizIDs := make([]int64, 0)
+	for _, group := range groups {
+		if bizID, ok := group.Key[common.BKAppIDField].(int64); ok {
+			bizIDs = append(bizIDs, bizID)
+		}
+	}
+	bizNames := make(map[int64]string)
+	if len(bizIDs) != 0 {
+		businesses := make([]struct {
+			ID   int64  `bson:"bk_biz_id"`
+			Name string `bson:"bk_biz_name"`
+		}, 0)
+		cond := mapstr.MapStr{common.BKAppIDField: mapstr.MapStr{common.BKDBIN: bizIDs}}
+		err := m.dbProxy.Table(common.BKTableNameBaseApp).Find(cond).Fields(common.BKAppIDField, common.BKAppNameField).All(ctx, &businesses)
+		if nil != err {
+			blog.Errorf("AggregateModelInstance failed, get the business names failed, err: %v, rid: %s", err, ctx.ReqID)
+			return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+		}
+		for _, biz := range businesses {
+			bizNames[biz.ID] = biz.Name
+		}
+	}
+
+	enumNames := make(map[string]map[string]string)
+	for _, groupBy := range plan.params.GroupBy {
+		attr, ok := plan.attrs[groupBy.Field]
+		if !ok || attr.PropertyType != common.FieldTypeEnum {
+			continue
+		}
+		options, err := ParseEnumOption(attr.Option)
+		if nil != err {
+			blog.Warnf("AggregateModelInstance, the enum option of %s is invalid, err: %v, rid: %s", groupBy.Field, err, ctx.ReqID)
+			continue
+		}
+		names := make(map[string]string)
+		for _, option := range options {
+			names[option.ID] = option.Name
+		}
+		enumNames[groupBy.Field] = names
+	}
+
+	for _, group := range groups {
+		for field, names := range enumNames {
+			if id, ok := group.Key[field].(string); ok {
+				if name, exist := names[id]; exist {
+					group.Names[field] = name
+				}
+			}
+		}
+		if bizID, ok := group.Key[common.BKAppIDField].(int64); ok {
+			if name, exist := bizNames[bizID]; exist {
+				group.Names[common.BKAppIDField] = name
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,535 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/expression"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/util"
+	"icenter/src/source_controller/coreservice/core"
+)
+
+// backfillPageSize the count of the instances handled in a page when backfilling the computed fields
+const backfillPageSize = 500
+
+// computedField the computed field of a model, whose value is evaluated from the expression
+// and saved with the instance, so that it can be searched as the other fields.
+type computedField struct {
+	propertyID string
+	resultType string
+	expr       *expression.Expression
+}
+
+// computedFields returns the computed fields of the model in the order of evaluation, the
+// field which is referenced by the other computed fields is evaluated before them.
+func (m *instanceManager) computedFields(ctx core.ContextParams, objID string, bizID int64) ([]computedField, error) {
+	attrs, err := m.dependent.SelectObjectAttWithParams(ctx, objID, bizID)
+	if nil != err {
+		return nil, err
+	}
+	return sortComputedFields(ctx, objID, attrs)
+}
+
+func sortComputedFields(ctx core.ContextParams, objID string, attrs []metadata.Attribute) ([]computedField, error) {
+	fields := make(map[string]computedField)
+	for _, attr := range attrs {
+		if attr.PropertyType != common.FieldTypeComputed {
+			continue
+		}
+		option, expr, err := util.ParseComputedOption(attr.Option)
+		if nil != err {
+			// the option is validated when the attribute is saved, skip the broken one.
+			blog.Warnf("computed field %s of model %s has invalid option %v, err: %v, rid: %s", attr.PropertyID, objID, attr.Option, err, ctx.ReqID)
+			continue
+		}
+		fields[attr.PropertyID] = computedField{propertyID: attr.PropertyID, resultType: option.ResultType, expr: expr}
+	}
+
+	ids := make([]string, 0, len(fields))
+	for id := range fields {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// visit the fields in depth first order, 1 is visiting and 2 is visited.
+	sorted := make([]computedField, 0, len(fields))
+	state := make(map[string]int)
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case 1:
+			return fmt.Errorf("the computed field %s of model %s references itself", id, objID)
+		case 2:
+			return nil
+		}
+		state[id] = 1
+		for _, ref := range fields[id].expr.Fields() {
+			if _, ok := fields[ref]; ok {
+				if err := visit(ref); err != nil {
+					return err
+				}
+			}
+		}
+		state[id] = 2
+		sorted = append(sorted, fields[id])
+		return nil
+	}
+	for _, id := range ids {
+		if err := visit(id); err != nil {
+			blog.Errorf("sort computed fields failed, err: %v, rid: %s", err, ctx.ReqID)
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, id)
+		}
+	}
+	return sorted, nil
+}
+
+// evalComputedFields evaluate the computed fields with the instance data, the values are set into
+// the data and returned. the value is null if the evaluation failed, so that a broken expression
+// never blocks saving the instance.
+func (m *instanceManager) evalComputedFields(ctx core.ContextParams, objID string, instID int64, fields []computedField, data mapstr.MapStr) mapstr.MapStr {
+	values := mapstr.New()
+	env := &instanceEnv{ctx: ctx, manager: m, objID: objID, instID: instID, data: data}
+	for _, field := range fields {
+		result, err := evalComputedField(env, field)
+		if nil != err {
+			blog.Warnf("eval computed field %s of model %s instance %d failed, expression: %s, err: %v, rid: %s",
+				field.propertyID, objID, instID, field.expr, err, ctx.ReqID)
+			result = nil
+		}
+		data[field.propertyID] = result
+		values[field.propertyID] = result
+	}
+	return values
+}
+
+// evalComputedField evaluate the expression of the field, the panic of the evaluation is
+// returned as an error, so that it never breaks the request.
+func evalComputedField(env expression.Env, field computedField) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("eval panic: %v", r)
+		}
+	}()
+
+	result, err = field.expr.Eval(env)
+	if nil != err {
+		return nil, err
+	}
+	return convertComputedResult(result, field.resultType)
+}
+
+func convertComputedResult(result interface{}, resultType string) (interface{}, error) {
+	if nil == result {
+		return nil, nil
+	}
+	switch resultType {
+	case common.FieldTypeInt:
+		num, err := expression.ToNumber(result)
+		if nil != err {
+			return nil, err
+		}
+		if math.IsNaN(num) || math.IsInf(num, 0) || math.Abs(num) > math.MaxInt64 {
+			return nil, fmt.Errorf("%v is out of the int range", num)
+		}
+		return int64(math.Round(num)), nil
+	case common.FieldTypeFloat:
+		num, err := expression.ToNumber(result)
+		if nil != err {
+			return nil, err
+		}
+		if math.IsNaN(num) || math.IsInf(num, 0) {
+			return nil, fmt.Errorf("%v is not a valid float", num)
+		}
+		return num, nil
+	case common.FieldTypeBool:
+		return expression.ToBool(result), nil
+	default:
+		value := expression.ToString(result)
+		if len(value) > common.FieldTypeSingleLenChar {
+			return nil, fmt.Errorf("the result is longer than %d", common.FieldTypeSingleLenChar)
+		}
+		return value, nil
+	}
+}
+
+// fillComputedFields evaluate the computed fields of the new instance, the values of the computed
+// fields in the input data are dropped as they can only be set by the system.
+func (m *instanceManager) fillComputedFields(ctx core.ContextParams, objID string, instID int64, instanceData mapstr.MapStr) error {
+	bizID, err := FetchBizIDFromInstance(objID, instanceData)
+	if nil != err {
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField)
+	}
+	fields, err := m.computedFields(ctx, objID, bizID)
+	if nil != err {
+		return err
+	}
+	for _, field := range fields {
+		delete(instanceData, field.propertyID)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	m.evalComputedFields(ctx, objID, instID, fields, instanceData)
+	return nil
+}
+
+// refreshComputedFields evaluate the computed fields of the instances again and save the changed values,
+// the ids of the changed instances are returned.
+func (m *instanceManager) refreshComputedFields(ctx core.ContextParams, objID string, instances []mapstr.MapStr, fieldsCache map[int64][]computedField) ([]int64, error) {
+	tableName := common.GetInstTableName(objID)
+	instIDField := common.GetInstIDField(objID)
+	changedIDs := make([]int64, 0)
+	for _, inst := range instances {
+		bizID, err := FetchBizIDFromInstance(objID, inst)
+		if nil != err {
+			blog.Warnf("refresh computed fields of %s instance %v skipped, parse biz id failed, err: %v, rid: %s", objID, inst[instIDField], err, ctx.ReqID)
+			continue
+		}
+		fields, exists := fieldsCache[bizID]
+		if !exists {
+			if fields, err = m.computedFields(ctx, objID, bizID); nil != err {
+				return changedIDs, err
+			}
+			fieldsCache[bizID] = fields
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		instID, err := util.GetInt64ByInterface(inst[instIDField])
+		if nil != err {
+			return changedIDs, err
+		}
+		origin := inst.Clone()
+		values := m.evalComputedFields(ctx, objID, instID, fields, inst)
+		changed := mapstr.New()
+		for key, val := range values {
+			if !reflect.DeepEqual(normalizeComputedValue(origin[key]), normalizeComputedValue(val)) {
+				changed[key] = val
+			}
+		}
+		if len(changed) == 0 {
+			continue
+		}
+
+		cond := mapstr.MapStr{instIDField: instID}
+		if tableName == common.BKTableNameBaseInst {
+			cond[common.BKObjIDField] = objID
+		}
+		if err := m.dbProxy.Table(tableName).Update(ctx, cond, changed); nil != err {
+			blog.Errorf("refresh computed fields of %s instance %d failed, err: %v, rid: %s", objID, instID, err, ctx.ReqID)
+			return changedIDs, ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+		}
+		changedIDs = append(changedIDs, instID)
+	}
+	return changedIDs, nil
+}
+
+// refreshDependents refresh the computed fields of the instances associated with the changed instances,
+// which look up the changed fields of them. the dependents which are changed refresh their dependents
+// in turn, visited records the refreshed instances, so that every instance is refreshed once at most.
+func (m *instanceManager) refreshDependents(ctx core.ContextParams, objID string, instIDs []int64, changedFields []string, visited map[string]bool) error {
+	if len(instIDs) == 0 || len(changedFields) == 0 {
+		return nil
+	}
+	for _, instID := range instIDs {
+		visited[instanceKey(objID, instID)] = true
+	}
+
+	cond := util.SetQueryOwner(mapstr.MapStr{
+		common.BKDBOR: []mapstr.MapStr{
+			{common.BKObjIDField: objID, common.BKInstIDField: mapstr.MapStr{common.BKDBIN: instIDs}},
+			{common.BKAsstObjIDField: objID, common.BKAsstInstIDField: mapstr.MapStr{common.BKDBIN: instIDs}},
+		},
+	}, ctx.SupplierAccount)
+	assts := make([]metadata.InstAsst, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).Find(cond).All(ctx, &assts); nil != err {
+		blog.Errorf("refresh the dependents of %s instances %v failed, search associations failed, err: %v, rid: %s", objID, instIDs, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	// the associated instances of every model, an association is walked from either side.
+	dependents := make(map[string][]int64)
+	add := func(depObjID string, depInstID int64) {
+		if key := instanceKey(depObjID, depInstID); !visited[key] {
+			visited[key] = true
+			dependents[depObjID] = append(dependents[depObjID], depInstID)
+		}
+	}
+	for _, asst := range assts {
+		if asst.ObjectID == objID && util.InArray(asst.InstID, instIDs) {
+			add(asst.AsstObjectID, asst.AsstInstID)
+		}
+		if asst.AsstObjectID == objID && util.InArray(asst.AsstInstID, instIDs) {
+			add(asst.ObjectID, asst.InstID)
+		}
+	}
+	depObjIDs := make([]string, 0, len(dependents))
+	for depObjID := range dependents {
+		depObjIDs = append(depObjIDs, depObjID)
+	}
+	sort.Strings(depObjIDs)
+
+	for _, depObjID := range depObjIDs {
+		instIDField := common.GetInstIDField(depObjID)
+		instances, _, err := m.getInsts(ctx, depObjID, util.SetModOwner(mapstr.MapStr{
+			instIDField: mapstr.MapStr{common.BKDBIN: dependents[depObjID]},
+		}, ctx.SupplierAccount))
+		if nil != err {
+			blog.Errorf("refresh the dependents of %s instances %v failed, search %s instances failed, err: %v, rid: %s", objID, instIDs, depObjID, err, ctx.ReqID)
+			return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+		}
+
+		// only the instances whose computed fields look up the changed fields are refreshed.
+		fieldsCache := make(map[int64][]computedField)
+		lookups := make([]mapstr.MapStr, 0, len(instances))
+		for _, inst := range instances {
+			bizID, err := FetchBizIDFromInstance(depObjID, inst)
+			if nil != err {
+				continue
+			}
+			fields, exists := fieldsCache[bizID]
+			if !exists {
+				if fields, err = m.computedFields(ctx, depObjID, bizID); nil != err {
+					return err
+				}
+				fieldsCache[bizID] = fields
+			}
+			if lookupFields(fields, objID, changedFields) {
+				lookups = append(lookups, inst)
+			}
+		}
+		if len(lookups) == 0 {
+			continue
+		}
+
+		changedIDs, err := m.refreshComputedFields(ctx, depObjID, lookups, fieldsCache)
+		if nil != err {
+			return err
+		}
+		if err := m.refreshDependents(ctx, depObjID, changedIDs, computedFieldIDs(fieldsCache), visited); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupFields whether the computed fields look up any of the fields of the associated instances of the model
+func lookupFields(fields []computedField, objID string, propertyIDs []string) bool {
+	for _, field := range fields {
+		for _, asst := range field.expr.Associations() {
+			if asst.ObjectID == objID && util.InStrArr(propertyIDs, asst.Field) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// computedFieldIDs returns the property ids of the computed fields of all the businesses
+func computedFieldIDs(fieldsCache map[int64][]computedField) []string {
+	ids := make([]string, 0)
+	for _, fields := range fieldsCache {
+		for _, field := range fields {
+			ids = append(ids, field.propertyID)
+		}
+	}
+	return util.StrArrayUnique(ids)
+}
+
+func instanceKey(objID string, instID int64) string {
+	return objID + "/" + strconv.FormatInt(instID, 10)
+}
+
+// normalizeComputedValue make the value read from db comparable with the evaluated one
+func normalizeComputedValue(val interface{}) interface{} {
+	switch val.(type) {
+	case nil, bool, string, float64:
+		return val
+	}
+	if num, err := util.GetInt64ByInterface(val); nil == err {
+		return num
+	}
+	return val
+}
+
+// RefreshComputedAttributes evaluate the computed fields of the instances again, it's called when
+// the associations of the instances are changed, as the computed fields may reference the associated instances.
+func (m *instanceManager) RefreshComputedAttributes(ctx core.ContextParams, objID string, instIDs []int64) (*metadata.UpdatedCount, error) {
+	result := &metadata.UpdatedCount{}
+	if len(instIDs) == 0 {
+		return result, nil
+	}
+	tableName := common.GetInstTableName(objID)
+	instIDField := common.GetInstIDField(objID)
+	cond := mapstr.MapStr{instIDField: mapstr.MapStr{common.BKDBIN: instIDs}}
+	if tableName == common.BKTableNameBaseInst {
+		cond[common.BKObjIDField] = objID
+	}
+	cond = util.SetModOwner(cond, ctx.SupplierAccount)
+
+	instances := make([]mapstr.MapStr, 0, len(instIDs))
+	if err := m.dbProxy.Table(tableName).Find(cond).All(ctx, &instances); nil != err {
+		blog.Errorf("refresh computed fields of %s instances %v failed, search instances failed, err: %v, rid: %s", objID, instIDs, err, ctx.ReqID)
+		return result, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	fieldsCache := make(map[int64][]computedField)
+	changedIDs, err := m.refreshComputedFields(ctx, objID, instances, fieldsCache)
+	result.Count = uint64(len(changedIDs))
+	if nil != err {
+		return result, err
+	}
+	visited := make(map[string]bool)
+	for _, instID := range instIDs {
+		visited[instanceKey(objID, instID)] = true
+	}
+	return result, m.refreshDependents(ctx, objID, changedIDs, computedFieldIDs(fieldsCache), visited)
+}
+
+// BackfillComputedAttributes evaluate the computed fields of all the instances of the model again,
+// it's called when the expression of a computed field is changed.
+func (m *instanceManager) BackfillComputedAttributes(ctx core.ContextParams, objID string) (*metadata.UpdatedCount, error) {
+	tableName := common.GetInstTableName(objID)
+	instIDField := common.GetInstIDField(objID)
+	cond := mapstr.MapStr{common.BKOwnerIDField: ctx.SupplierAccount}
+	if tableName == common.BKTableNameBaseInst {
+		cond[common.BKObjIDField] = objID
+	}
+
+	fieldsCache := make(map[int64][]computedField)
+	visited := make(map[string]bool)
+	result := &metadata.UpdatedCount{}
+	var lastID int64
+	for {
+		// page with the instance id, as the instances may be updated during backfilling.
+		pageCond := mapstr.MapStr{}
+		pageCond.Merge(cond)
+		pageCond[instIDField] = mapstr.MapStr{common.BKDBGT: lastID}
+		instances := make([]mapstr.MapStr, 0)
+		err := m.dbProxy.Table(tableName).Find(pageCond).Sort(instIDField).Limit(backfillPageSize).All(ctx, &instances)
+		if nil != err {
+			blog.Errorf("backfill computed fields of model %s failed, search instances failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+			return result, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+		}
+		if len(instances) == 0 {
+			break
+		}
+
+		changedIDs, err := m.refreshComputedFields(ctx, objID, instances, fieldsCache)
+		result.Count += uint64(len(changedIDs))
+		if nil != err {
+			return result, err
+		}
+		// the instances of the model are all refreshed here, only the other models' are refreshed as dependents.
+		for _, inst := range instances {
+			if instID, err := util.GetInt64ByInterface(inst[instIDField]); nil == err {
+				visited[instanceKey(objID, instID)] = true
+			}
+		}
+		if err := m.refreshDependents(ctx, objID, changedIDs, computedFieldIDs(fieldsCache), visited); nil != err {
+			return result, err
+		}
+		if lastID, err = util.GetInt64ByInterface(instances[len(instances)-1][instIDField]); nil != err {
+			return result, err
+		}
+		if len(instances) < backfillPageSize {
+			break
+		}
+	}
+	blog.Infof("backfill computed fields of model %s finished, %d instances updated, rid: %s", objID, result.Count, ctx.ReqID)
+	return result, nil
+}
+
+// instanceEnv the environment of an instance to evaluate the computed fields
+type instanceEnv struct {
+	ctx     core.ContextParams
+	manager *instanceManager
+	objID   string
+	instID  int64
+	data    mapstr.MapStr
+	// assts the ids of the associated instances of every model
+	assts map[string][]int64
+}
+
+// Field returns the value of the field of the instance
+func (e *instanceEnv) Field(name string) interface{} {
+	return e.data[name]
+}
+
+// Associated returns the values of the field of the associated instances in both directions
+func (e *instanceEnv) Associated(objID, field string) ([]interface{}, error) {
+	if e.instID == 0 {
+		return nil, nil
+	}
+	instIDs, err := e.associatedIDs(objID)
+	if nil != err || len(instIDs) == 0 {
+		return nil, err
+	}
+
+	tableName := common.GetInstTableName(objID)
+	instIDField := common.GetInstIDField(objID)
+	cond := mapstr.MapStr{instIDField: mapstr.MapStr{common.BKDBIN: instIDs}}
+	if tableName == common.BKTableNameBaseInst {
+		cond[common.BKObjIDField] = objID
+	}
+	instances := make([]mapstr.MapStr, 0)
+	err = e.manager.dbProxy.Table(tableName).Find(cond).Fields(instIDField, field).Sort(instIDField).All(e.ctx, &instances)
+	if nil != err {
+		return nil, err
+	}
+	values := make([]interface{}, 0, len(instances))
+	for _, inst := range instances {
+		values = append(values, inst[field])
+	}
+	return values, nil
+}
+
+func (e *instanceEnv) associatedIDs(objID string) ([]int64, error) {
+	if ids, ok := e.assts[objID]; ok {
+		return ids, nil
+	}
+
+	cond := util.SetQueryOwner(mapstr.MapStr{
+		common.BKDBOR: []mapstr.MapStr{
+			{common.BKObjIDField: e.objID, common.BKInstIDField: e.instID, common.BKAsstObjIDField: objID},
+			{common.BKAsstObjIDField: e.objID, common.BKAsstInstIDField: e.instID, common.BKObjIDField: objID},
+		},
+	}, e.ctx.SupplierAccount)
+	assts := make([]metadata.InstAsst, 0)
+	if err := e.manager.dbProxy.Table(common.BKTableNameInstAsst).Find(cond).All(e.ctx, &assts); nil != err {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(assts))
+	for _, asst := range assts {
+		if asst.ObjectID == e.objID && asst.InstID == e.instID {
+			ids = append(ids, asst.AsstInstID)
+		} else {
+			ids = append(ids, asst.InstID)
+		}
+	}
+	if e.assts == nil {
+		e.assts = make(map[string][]int64)
+	}
+	e.assts[objID] = ids
+	return ids, nil
+}
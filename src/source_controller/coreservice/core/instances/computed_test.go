@@ -0,0 +1,99 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances_test
+
+import (
+	"testing"
+
+	"icenter/src/common"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func addComputedAttribute(t *testing.T, id int64, objID, propertyID, expr string) {
+	now := metadata.Now()
+	attr := metadata.Attribute{
+		ID:           id,
+		OwnerID:      common.BKDefaultOwnerID,
+		ObjectID:     objID,
+		PropertyID:   propertyID,
+		PropertyName: propertyID,
+		PropertyType: common.FieldTypeComputed,
+		Option:       map[string]interface{}{"expression": expr},
+		CreateTime:   &now,
+		LastTime:     &now,
+	}
+	require.NoError(t, testDB.Table(common.BKTableNameObjAttDes).Insert(defaultCtx, attr))
+}
+
+func createInstance(t *testing.T, objID string, data mapstr.MapStr) int64 {
+	data.Set(common.BKInstNameField, xid.New().String())
+	data.Set(common.BKAssetIDField, xid.New().String())
+	result, err := newInstances(t).CreateModelInstance(defaultCtx, objID, metadata.CreateModelInstance{Data: data})
+	require.NoError(t, err)
+	return int64(result.Created.ID)
+}
+
+func instanceField(t *testing.T, objID string, instID int64, field string) interface{} {
+	inst := mapstr.MapStr{}
+	cond := mapstr.MapStr{common.BKObjIDField: objID, common.BKInstIDField: instID}
+	require.NoError(t, testDB.Table(common.BKTableNameBaseInst).Find(cond).One(defaultCtx, &inst))
+	return inst[field]
+}
+
+func TestRefreshComputedDependents(t *testing.T) {
+	instMgr := newInstances(t)
+	// the router looks up the switch, and the load balance looks up the computed field of the router.
+	addComputedAttribute(t, 90001, common.BKInnerObjIDRouter, "switch_sn", `asst("bk_switch", "bk_sn")`)
+	addComputedAttribute(t, 90002, common.BKInnerObjIDBlance, "router_switch_sn", `upper(asst("bk_router", "switch_sn"))`)
+
+	switchID := createInstance(t, common.BKInnerObjIDSwitch, mapstr.MapStr{"bk_sn": "sn_a"})
+	routerID := createInstance(t, common.BKInnerObjIDRouter, mapstr.MapStr{})
+	balanceID := createInstance(t, common.BKInnerObjIDBlance, mapstr.MapStr{})
+	assts := []metadata.InstAsst{
+		{ID: 90001, ObjectID: common.BKInnerObjIDRouter, InstID: routerID, AsstObjectID: common.BKInnerObjIDSwitch,
+			AsstInstID: switchID, ObjectAsstID: "bk_router_connect_bk_switch", OwnerID: common.BKDefaultOwnerID},
+		{ID: 90002, ObjectID: common.BKInnerObjIDBlance, InstID: balanceID, AsstObjectID: common.BKInnerObjIDRouter,
+			AsstInstID: routerID, ObjectAsstID: "bk_load_balance_connect_bk_router", OwnerID: common.BKDefaultOwnerID},
+	}
+	require.NoError(t, testDB.Table(common.BKTableNameInstAsst).Insert(defaultCtx, assts))
+
+	// the associations are created, the dependents of the router are refreshed with it.
+	result, err := instMgr.RefreshComputedAttributes(defaultCtx, common.BKInnerObjIDRouter, []int64{routerID})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), result.Count)
+	require.Equal(t, "sn_a", instanceField(t, common.BKInnerObjIDRouter, routerID, "switch_sn"))
+	require.Equal(t, "SN_A", instanceField(t, common.BKInnerObjIDBlance, balanceID, "router_switch_sn"))
+
+	// the field of the switch looked up by the router is changed
+	update := metadata.UpdateOption{
+		Condition: mapstr.MapStr{common.BKInstIDField: switchID},
+		Data:      mapstr.MapStr{"bk_sn": "sn_b"},
+	}
+	_, err = instMgr.UpdateModelInstance(defaultCtx, common.BKInnerObjIDSwitch, update)
+	require.NoError(t, err)
+	require.Equal(t, "sn_b", instanceField(t, common.BKInnerObjIDRouter, routerID, "switch_sn"))
+	require.Equal(t, "SN_B", instanceField(t, common.BKInnerObjIDBlance, balanceID, "router_switch_sn"))
+
+	// the field which is not looked up never refreshes the dependents
+	require.NoError(t, testDB.Table(common.BKTableNameBaseInst).Update(defaultCtx,
+		mapstr.MapStr{common.BKObjIDField: common.BKInnerObjIDRouter, common.BKInstIDField: routerID},
+		mapstr.MapStr{"switch_sn": "stale"}))
+	update.Data = mapstr.MapStr{"bk_operator": "tom"}
+	_, err = instMgr.UpdateModelInstance(defaultCtx, common.BKInnerObjIDSwitch, update)
+	require.NoError(t, err)
+	require.Equal(t, "stale", instanceField(t, common.BKInnerObjIDRouter, routerID, "switch_sn"))
+}
@@ -39,11 +39,12 @@ type instanceManager struct {
 }
 
 // New create a new instance manager instance
-func New(dbProxy dal.RDB, dependent OperationDependences, cache *redis.Client) core.InstanceOperation {
+func New(dbProxy dal.RDB, dependent OperationDependences, cache *redis.Client, eventC eventclient.Client) core.InstanceOperation {
 	return &instanceManager{
 		dbProxy:   dbProxy,
 		dependent: dependent,
-		EventC:    eventclient.NewClientViaRedis(cache, dbProxy),
+		Cache:     cache,
+		EventC:    eventC,
 	}
 }
 
@@ -62,7 +63,16 @@ func (m *instanceManager) CreateModelInstance(ctx core.ContextParams, objID stri
 		blog.Errorf("CreateModelInstance failed, valid error: %+v, rid: %s", err, rid)
 		return nil, err
 	}
-	id, err := m.save(ctx, objID, inputParam.Data)
+	id, err := m.nextInstID(ctx, objID)
+	if err != nil {
+		blog.Errorf("CreateModelInstance failed, generate instance id error: %+v, rid: %s", err, rid)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	if err := m.fillComputedFields(ctx, objID, int64(id), inputParam.Data); nil != err {
+		blog.Errorf("CreateModelInstance failed, eval computed fields error: %+v, rid: %s", err, rid)
+		return nil, err
+	}
+	err = m.save(ctx, objID, id, inputParam.Data)
 	if err != nil {
 		blog.ErrorJSON("CreateModelInstance create objID(%s) instance error. err:%s, data:%s, rid:%s", objID, err.Error(), inputParam.Data, ctx.ReqID)
 		return nil, err
@@ -94,9 +104,27 @@ func (m *instanceManager) CreateManyModelInstance(ctx core.ContextParams, objID
 			})
 			continue
 		}
-		item.Set(common.BKOwnerIDField, ctx.SupplierAccount)
-		id, err := m.save(ctx, objID, item)
+		id, err := m.nextInstID(ctx, objID)
 		if nil != err {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     err.Error(),
+				Code:        int64(common.CCErrObjectDBOpErrno),
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
+		if err := m.fillComputedFields(ctx, objID, int64(id), item); nil != err {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     err.Error(),
+				Code:        int64(err.(errors.CCErrorCoder).GetCode()),
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
+		item.Set(common.BKOwnerIDField, ctx.SupplierAccount)
+		if err := m.save(ctx, objID, id, item); nil != err {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
 				Message:     err.Error(),
 				Code:        int64(err.(errors.CCErrorCoder).GetCode()),
@@ -152,9 +180,11 @@ func (m *instanceManager) UpdateModelInstance(ctx core.ContextParams, objID stri
 		}
 	}
 
+	instIDs := make([]int64, 0, len(origins))
 	for _, origin := range origins {
 		instIDI := origin[instIDFieldName]
 		instID, _ := util.GetInt64ByInterface(instIDI)
+		instIDs = append(instIDs, instID)
 		err := m.validUpdateInstanceData(ctx, objID, inputParam.Data, instMedataData, uint64(instID))
 		if nil != err {
 			blog.Errorf("update module instance validate error :%v ,rid:%s", err, ctx.ReqID)
@@ -173,6 +203,24 @@ func (m *instanceManager) UpdateModelInstance(ctx core.ContextParams, objID stri
 		blog.ErrorJSON("UpdateModelInstance update objID(%s) inst error. err:%s, condition:%s, rid:%s", objID, inputParam.Condition, ctx.ReqID)
 		return nil, err
 	}
+	// the computed fields may reference the updated fields, the condition may not match the updated instances.
+	// then the computed fields of the associated instances which look up the changed fields are refreshed.
+	fieldsCache := make(map[int64][]computedField)
+	updated, _, err := m.getInsts(ctx, objID, mapstr.MapStr{instIDFieldName: mapstr.MapStr{common.BKDBIN: instIDs}})
+	if nil == err {
+		_, err = m.refreshComputedFields(ctx, objID, updated, fieldsCache)
+	}
+	if nil == err {
+		changedFields := computedFieldIDs(fieldsCache)
+		for field := range inputParam.Data {
+			changedFields = append(changedFields, field)
+		}
+		err = m.refreshDependents(ctx, objID, instIDs, changedFields, make(map[string]bool))
+	}
+	if nil != err {
+		blog.Errorf("UpdateModelInstance refresh objID(%s) computed fields error. err:%v, rid:%s", objID, err, ctx.ReqID)
+		return nil, err
+	}
 	err = eh.SetCurDataAndPush(ctx, objID, metadata.EventActionUpdate, inputParam.Condition)
 	if err != nil {
 		blog.ErrorJSON("UpdateModelInstance  event push instance current data error. err:%s, condition:%s, rid:%s", err, inputParam.Condition, ctx.ReqID)
@@ -250,6 +298,55 @@ func (m *instanceManager) DeleteModelInstance(ctx core.ContextParams, objID stri
 	return &metadata.DeletedCount{Count: uint64(len(origins))}, nil
 }
 
+// RestoreModelInstance create the deleted instance again with its original id, the data is validated as a new instance.
+func (m *instanceManager) RestoreModelInstance(ctx core.ContextParams, objID string, inputParam metadata.CreateModelInstance) (*metadata.CreateOneDataResult, error) {
+	instIDFieldName := common.GetInstIDField(objID)
+	instID, err := util.GetInt64ByInterface(inputParam.Data[instIDFieldName])
+	if err != nil || instID <= 0 {
+		blog.Errorf("RestoreModelInstance failed, invalid %s: %v, rid: %s", instIDFieldName, inputParam.Data[instIDFieldName], ctx.ReqID)
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedInt, instIDFieldName)
+	}
+
+	_, exists, err := m.instCnt(ctx, objID, mapstr.MapStr{instIDFieldName: instID})
+	if nil != err {
+		blog.Errorf("RestoreModelInstance failed, check %s instance %d failed, err: %v, rid: %s", objID, instID, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if exists {
+		return nil, ctx.Error.Errorf(common.CCErrCoreServiceInstanceAlreadyExist, objID, instID)
+	}
+
+	// the fields generated when the instance is saved are not the properties of the model
+	data := inputParam.Data.Clone()
+	createTime := data[common.CreateTimeField]
+	data.Remove("_id")
+	data.Remove(instIDFieldName)
+	data.Remove(common.CreateTimeField)
+	data.Remove(common.LastTimeField)
+
+	err = m.validCreateInstanceData(ctx, objID, data)
+	if nil != err {
+		blog.Errorf("RestoreModelInstance failed, valid error: %+v, rid: %s", err, ctx.ReqID)
+		return nil, err
+	}
+	if err := m.fillComputedFields(ctx, objID, instID, data); nil != err {
+		blog.Errorf("RestoreModelInstance failed, eval computed fields error: %+v, rid: %s", err, ctx.ReqID)
+		return nil, err
+	}
+	if err := m.restore(ctx, objID, uint64(instID), createTime, data); err != nil {
+		blog.ErrorJSON("RestoreModelInstance restore objID(%s) instance error. err:%s, data:%s, rid:%s", objID, err.Error(), data, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+
+	eh := m.NewEventHandle(objID)
+	err = eh.SetCurDataAndPush(ctx, objID, metadata.EventActionCreate, mapstr.MapStr{instIDFieldName: instID})
+	if err != nil {
+		blog.ErrorJSON("RestoreModelInstance event push instance current data error. err:%s, objID:%s inst id:%s, rid:%s", err, objID, instID, ctx.ReqID)
+		return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: uint64(instID)}}, err
+	}
+	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: uint64(instID)}}, nil
+}
+
 func (m *instanceManager) CascadeDeleteModelInstance(ctx core.ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error) {
 	tableName := common.GetInstTableName(objID)
 	instIDFieldName := common.GetInstIDField(objID)
@@ -24,13 +24,15 @@ import (
 	"icenter/src/source_controller/coreservice/core"
 )
 
-func (m *instanceManager) save(ctx core.ContextParams, objID string, inputParam mapstr.MapStr) (id uint64, err error) {
+// nextInstID returns the id of the new instance, which is allocated before the instance is saved
+// so that the computed fields of the instance are evaluated with it.
+func (m *instanceManager) nextInstID(ctx core.ContextParams, objID string) (uint64, error) {
+	return m.dbProxy.NextSequence(ctx, common.GetInstTableName(objID))
+}
+
+func (m *instanceManager) save(ctx core.ContextParams, objID string, id uint64, inputParam mapstr.MapStr) error {
 
 	tableName := common.GetInstTableName(objID)
-	id, err = m.dbProxy.NextSequence(ctx, tableName)
-	if nil != err {
-		return id, err
-	}
 	instIDFieldName := common.GetInstIDField(objID)
 	inputParam[instIDFieldName] = id
 	if !util.IsInnerObject(objID) {
@@ -40,8 +42,25 @@ func (m *instanceManager) save(ctx core.ContextParams, objID string, inputParam
 	inputParam.Set(common.BKOwnerIDField, ctx.SupplierAccount)
 	inputParam.Set(common.CreateTimeField, ts)
 	inputParam.Set(common.LastTimeField, ts)
-	err = m.dbProxy.Table(tableName).Insert(ctx, inputParam)
-	return id, err
+	return m.dbProxy.Table(tableName).Insert(ctx, inputParam)
+}
+
+// restore save the instance with its original id and create time
+func (m *instanceManager) restore(ctx core.ContextParams, objID string, id uint64, createTime interface{}, inputParam mapstr.MapStr) error {
+	tableName := common.GetInstTableName(objID)
+	instIDFieldName := common.GetInstIDField(objID)
+	inputParam[instIDFieldName] = id
+	if !util.IsInnerObject(objID) {
+		inputParam[common.BKObjIDField] = objID
+	}
+	ts := time.Now()
+	if createTime == nil {
+		createTime = ts
+	}
+	inputParam.Set(common.BKOwnerIDField, ctx.SupplierAccount)
+	inputParam.Set(common.CreateTimeField, createTime)
+	inputParam.Set(common.LastTimeField, ts)
+	return m.dbProxy.Table(tableName).Insert(ctx, inputParam)
 }
 
 func (m *instanceManager) update(ctx core.ContextParams, objID string, data mapstr.MapStr, cond mapstr.MapStr) (cnt uint64, err error) {
@@ -28,14 +28,13 @@ func TestCreateOneInstance(t *testing.T) {
 
 	instMgr := newInstances(t)
 	objID := "bk_switch"
-	inputParams := metadata.CreateModelInstance{}
+	inputParams := metadata.CreateModelInstance{Data: mapstr.MapStr{}}
 	inputParams.Data.Set(common.BKInstNameField, xid.New().String())
 
 	// create a new bk_switch instance without bk_asset_id
 	dataResult, err := instMgr.CreateModelInstance(defaultCtx, objID, inputParams)
 	require.NotNil(t, err)
-	require.NotNil(t, dataResult)
-	require.Equal(t, uint64(0), dataResult.Created.ID)
+	require.Nil(t, dataResult)
 	tmpErr, ok := err.(errors.CCErrorCoder)
 	require.True(t, ok, "err must be the errors of the cmdb")
 	require.Equal(t, common.CCErrCommParamsNeedSet, tmpErr.GetCode())
@@ -79,7 +78,8 @@ func TestCreateManyInstance(t *testing.T) {
 
 	require.Nil(t, err)
 	require.NotNil(t, dataResult)
-	require.NotEqual(t, 0, len(dataResult.Repeated))
+	// the instance with the duplicated bk_asset_id is rejected by the unique check
+	require.NotEqual(t, 0, len(dataResult.Exceptions))
 	require.NotEqual(t, 0, len(dataResult.Created))
 }
 
@@ -89,14 +89,14 @@ func TestUpdateOneInstance(t *testing.T) {
 	objID := "bk_switch"
 
 	//create one bk_switch instance data
-	inputParams := metadata.CreateModelInstance{}
+	inputParams := metadata.CreateModelInstance{Data: mapstr.MapStr{}}
 	inputParams.Data.Set(common.BKInstNameField, xid.New().String())
 	inputParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	inputParams.Data.Set("bk_sn", "cmdb_sn")
 	dataResult, err := instMgr.CreateModelInstance(defaultCtx, objID, inputParams)
 	require.Nil(t, err)
 	require.NotNil(t, dataResult)
-	require.Equal(t, uint64(0), dataResult.Created.ID)
+	require.NotEqual(t, uint64(0), dataResult.Created.ID)
 
 	//update one bk_switch instance by condition
 	updateParams := metadata.UpdateOption{}
@@ -115,17 +115,17 @@ func TestSearchAndDeleteInstance(t *testing.T) {
 	objID := "bk_switch"
 
 	//create one bk_switch instance data
-	inputParams := metadata.CreateModelInstance{}
+	inputParams := metadata.CreateModelInstance{Data: mapstr.MapStr{}}
 	inputParams.Data.Set(common.BKInstNameField, "test_sw1")
 	inputParams.Data.Set(common.BKAssetIDField, "test_sw_001")
 	inputParams.Data.Set("bk_sn", "cmdb_sn")
 	dataResult, err := instMgr.CreateModelInstance(defaultCtx, objID, inputParams)
 	require.Nil(t, err)
 	require.NotNil(t, dataResult)
-	require.Equal(t, uint64(0), dataResult.Created.ID)
+	require.NotEqual(t, uint64(0), dataResult.Created.ID)
 
 	//search  this instance
-	searchCond := metadata.QueryCondition{}
+	searchCond := metadata.QueryCondition{Condition: mapstr.MapStr{}}
 	searchCond.Condition.Set("bk_sn", "cmdb_sn")
 	searchResult, err := instMgr.SearchModelInstance(defaultCtx, objID, searchCond)
 	require.Nil(t, err)
@@ -134,7 +134,7 @@ func TestSearchAndDeleteInstance(t *testing.T) {
 	require.NotEqual(t, uint64(0), len(searchResult.Info))
 
 	//delete   this instance
-	deleteCond := metadata.DeleteOption{}
+	deleteCond := metadata.DeleteOption{Condition: mapstr.MapStr{}}
 	deleteCond.Condition.Set("bk_sn", "cmdb_sn")
 	deleteResult, err := instMgr.DeleteModelInstance(defaultCtx, objID, deleteCond)
 	require.Nil(t, err)
@@ -147,17 +147,17 @@ func TestCascadeDeleteInstance(t *testing.T) {
 	objID := "bk_switch"
 
 	//create one bk_switch instance data
-	inputParams := metadata.CreateModelInstance{}
+	inputParams := metadata.CreateModelInstance{Data: mapstr.MapStr{}}
 	inputParams.Data.Set(common.BKInstNameField, xid.New().String())
 	inputParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	inputParams.Data.Set("bk_sn", "cmdb_sn")
 	dataResult, err := instMgr.CreateModelInstance(defaultCtx, objID, inputParams)
 	require.Nil(t, err)
 	require.NotNil(t, dataResult)
-	require.Equal(t, uint64(0), dataResult.Created.ID)
+	require.NotEqual(t, uint64(0), dataResult.Created.ID)
 
 	//delete   this instance
-	deleteCond := metadata.DeleteOption{}
+	deleteCond := metadata.DeleteOption{Condition: mapstr.MapStr{}}
 	deleteCond.Condition.Set("bk_sn", "cmdb_sn")
 	deleteResult, err := instMgr.CascadeDeleteModelInstance(defaultCtx, objID, deleteCond)
 	require.Nil(t, err)
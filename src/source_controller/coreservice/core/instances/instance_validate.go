@@ -129,6 +129,18 @@ func (m *instanceManager) validCreateInstanceData(ctx core.ContextParams, objID
 			err = valid.validBool(val, key)
 		case common.FieldTypeForeignKey:
 			err = valid.validForeignKey(val, key)
+		case common.FieldTypeIPv4, common.FieldTypeIPv6:
+			err = valid.validIP(val, key, fieldType)
+		case common.FieldTypeCIDR:
+			err = valid.validCIDR(val, key)
+		case common.FieldTypeURL:
+			err = valid.validURL(val, key)
+		case common.FieldTypeEmail:
+			err = valid.validEmail(val, key)
+		case common.FieldTypeJSON:
+			err = valid.validJSON(val, key)
+		case common.FieldTypeList:
+			err = valid.validList(val, key)
 		default:
 			continue
 		}
@@ -165,8 +177,10 @@ func (m *instanceManager) validUpdateInstanceData(ctx core.ContextParams, objID
 		}
 
 		property, ok := valid.propertys[key]
-		if !ok {
+		if !ok || property.PropertyType == common.FieldTypeComputed {
+			// the computed field can only be set by the system
 			delete(instanceData, key)
+			continue
 		}
 		fieldType := property.PropertyType
 		switch fieldType {
@@ -190,6 +204,18 @@ func (m *instanceManager) validUpdateInstanceData(ctx core.ContextParams, objID
 			err = valid.validBool(val, key)
 		case common.FieldTypeForeignKey:
 			err = valid.validForeignKey(val, key)
+		case common.FieldTypeIPv4, common.FieldTypeIPv6:
+			err = valid.validIP(val, key, fieldType)
+		case common.FieldTypeCIDR:
+			err = valid.validCIDR(val, key)
+		case common.FieldTypeURL:
+			err = valid.validURL(val, key)
+		case common.FieldTypeEmail:
+			err = valid.validEmail(val, key)
+		case common.FieldTypeJSON:
+			err = valid.validJSON(val, key)
+		case common.FieldTypeList:
+			err = valid.validList(val, key)
 		default:
 			continue
 		}
@@ -15,17 +15,18 @@ package instances_test
 import (
 	"context"
 	"testing"
-	"time"
 
+	"icenter/src/common"
 	"icenter/src/common/errors"
+	"icenter/src/common/eventclient"
 	"icenter/src/common/language"
 	"icenter/src/common/metadata"
-	"icenter/src/common/storage/dal/mongo"
-	"icenter/src/common/storage/dal/mongo/local"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/dal/memory"
+	"icenter/src/scene_server/admin_server/upgrader"
+	_ "icenter/src/scene_server/admin_server/upgrader/versions"
 	"icenter/src/source_controller/coreservice/core"
 	"icenter/src/source_controller/coreservice/core/instances"
-
-	"github.com/stretchr/testify/require"
 )
 
 type mockDependences struct {
@@ -42,29 +43,41 @@ func (s *mockDependences) DeleteInstAsst(ctx core.ContextParams, objID string, i
 }
 
 // SelectObjectAttWithParams select object att with params
-func (s *mockDependences) SelectObjectAttWithParams(ctx core.ContextParams, objID string) (attribute []metadata.Attribute, err error) {
-	return nil, nil
+func (s *mockDependences) SelectObjectAttWithParams(ctx core.ContextParams, objID string, bizID int64) (attribute []metadata.Attribute, err error) {
+	attribute = make([]metadata.Attribute, 0)
+	err = testDB.Table(common.BKTableNameObjAttDes).Find(map[string]interface{}{common.BKObjIDField: objID}).All(ctx, &attribute)
+	return attribute, err
 }
 
 // SearchUnique search unique attribute
 func (s *mockDependences) SearchUnique(ctx core.ContextParams, objID string) (uniqueAttr []metadata.ObjectUnique, err error) {
-	return nil, nil
+	uniqueAttr = make([]metadata.ObjectUnique, 0)
+	err = testDB.Table(common.BKTableNameObjUnique).Find(map[string]interface{}{common.BKObjIDField: objID}).All(ctx, &uniqueAttr)
+	return uniqueAttr, err
 }
 
 func newInstances(t *testing.T) core.InstanceOperation {
 
-	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
-	require.NoError(t, err)
-	return instances.New(db, &mockDependences{})
+	return instances.New(testDB, &mockDependences{}, nil, eventclient.NewClientViaStream(testDB))
 }
 
+// testDB the data of the tests is saved in memory with the preset models, e.g. bk_switch
+var testDB = func() dal.RDB {
+	db := memory.NewMemory()
+	conf := &upgrader.Config{OwnerID: common.BKDefaultOwnerID, SupplierID: common.BKDefaultSupplierID, User: "migrate"}
+	if err := upgrader.Upgrade(context.Background(), db, conf); err != nil {
+		panic(err)
+	}
+	return db
+}()
+
 var defaultCtx = func() core.ContextParams {
-	err, _ := errors.New("../../../../../resources/errors/")
+	err, _ := errors.NewFactory("../../../../../resources/errors/")
 	lan, _ := language.New("../../../../../resources/language/")
 	return core.ContextParams{
 		Context:         context.Background(),
 		ReqID:           "test_req_id",
-		SupplierAccount: "test_owner",
+		SupplierAccount: common.BKDefaultOwnerID,
 		User:            "test_user",
 		Error:           err.CreateDefaultCCErrorIf("en"),
 		Lang:            lan.CreateDefaultCCLanguageIf("en"),
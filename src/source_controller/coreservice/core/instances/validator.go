@@ -50,7 +50,8 @@ func NewValidator(ctx core.ContextParams, dependent OperationDependences, objID
 		valid.propertys[attr.PropertyID] = attr
 		valid.idToProperty[attr.ID] = attr
 		valid.propertyslice = append(valid.propertyslice, attr)
-		if attr.IsRequired {
+		// the value of the computed field is always evaluated by the system.
+		if attr.IsRequired && attr.PropertyType != common.FieldTypeComputed {
 			valid.require[attr.PropertyID] = true
 			valid.requirefields = append(valid.requirefields, attr.PropertyID)
 		}
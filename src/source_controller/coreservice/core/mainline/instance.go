@@ -15,26 +15,45 @@ package mainline
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"icenter/src/common/blog"
 	"icenter/src/common/metadata"
+	"icenter/src/source_controller/coreservice/core"
 )
 
 // SearchMainlineBusinessTopo get topo tree of mainline model
 func (m *topoManager) SearchMainlineInstanceTopo(bkBizID int64, withDetail bool) (*metadata.TopoInstanceNode, error) {
 
-	bizTopoNode, err := m.SearchMainlineModelTopo(false)
+	im, err := NewInstanceMainline(m.DbProxy, bkBizID)
 	if err != nil {
-		blog.Errorf("get mainline model topo info failed, %+v", err)
-		return nil, fmt.Errorf("get mainline model topo info failed, %+v", err)
+		blog.Errorf("SearchMainlineInstanceTopo failed, NewInstanceMainline failed, bizID: %d, err: %+v", bkBizID, err)
+		return nil, fmt.Errorf("new mainline instance by business:%d failed, %+v", bkBizID, err)
 	}
-	blog.V(9).Infof("model mainline: %+v", bizTopoNode)
+	return m.searchMainlineInstanceTopo(im, bkBizID, withDetail)
+}
+
+// SearchMainlineInstanceTopoAsOf get topo tree of mainline instances as of the time,
+// the instances are rebuilt from the history, but the mainline model is the current one.
+func (m *topoManager) SearchMainlineInstanceTopoAsOf(ctx core.ContextParams, bkBizID int64, withDetail bool, asOf time.Time) (*metadata.TopoInstanceNode, error) {
 
 	im, err := NewInstanceMainline(m.DbProxy, bkBizID)
 	if err != nil {
-		blog.Errorf("SearchMainlineInstanceTopo failed, NewInstanceMainline failed, bizID: %d, err: %+v", bkBizID, err)
+		blog.Errorf("SearchMainlineInstanceTopoAsOf failed, NewInstanceMainline failed, bizID: %d, err: %+v, rid: %s", bkBizID, err, ctx.ReqID)
 		return nil, fmt.Errorf("new mainline instance by business:%d failed, %+v", bkBizID, err)
 	}
+	im.SetAsOf(ctx, m.history, asOf)
+	return m.searchMainlineInstanceTopo(im, bkBizID, withDetail)
+}
+
+func (m *topoManager) searchMainlineInstanceTopo(im *InstanceMainline, bkBizID int64, withDetail bool) (*metadata.TopoInstanceNode, error) {
+
+	bizTopoNode, err := m.SearchMainlineModelTopo(false)
+	if err != nil {
+		blog.Errorf("get mainline model topo info failed, %+v", err)
+		return nil, fmt.Errorf("get mainline model topo info failed, %+v", err)
+	}
+	blog.V(9).Infof("model mainline: %+v", bizTopoNode)
 
 	im.SetModelTree(bizTopoNode)
 	im.LoadModelParentMap()
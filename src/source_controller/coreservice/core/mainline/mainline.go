@@ -21,11 +21,12 @@ var _ core.TopoOperation = (*topoManager)(nil)
 
 type topoManager struct {
 	DbProxy dal.RDB
+	history core.HistoryOperation
 }
 
 // New create a new model manager instance
-func New(dbProxy dal.RDB) core.TopoOperation {
+func New(dbProxy dal.RDB, history core.HistoryOperation) core.TopoOperation {
 
-	coreMgr := &topoManager{DbProxy: dbProxy}
+	coreMgr := &topoManager{DbProxy: dbProxy, history: history}
 	return coreMgr
 }
@@ -16,6 +16,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	"icenter/src/common"
 	"icenter/src/common/blog"
@@ -25,6 +26,7 @@ import (
 	"icenter/src/common/universalsql/mongo"
 	"icenter/src/common/util"
 	"icenter/src/source_controller/coreservice/core"
+	"icenter/src/source_controller/coreservice/core/history"
 )
 
 type InstanceMainline struct {
@@ -44,6 +46,12 @@ type InstanceMainline struct {
 	allTopoInstances []*metadata.TopoInstance
 
 	root *metadata.TopoInstanceNode
+
+	// history is set when the topo is rebuilt as of the time
+	history         core.HistoryOperation
+	historyCtx      core.ContextParams
+	asOf            time.Time
+	historyInstance map[string][]mapstr.MapStr
 }
 
 func NewInstanceMainline(proxy dal.DB, bkBizID int64) (*InstanceMainline, error) {
@@ -65,6 +73,28 @@ func (im *InstanceMainline) SetModelTree(modelTree *metadata.TopoModelNode) {
 	im.modelTree = modelTree
 }
 
+// SetAsOf make the instances loaded from the history as of the time instead of the current ones
+func (im *InstanceMainline) SetAsOf(ctx core.ContextParams, history core.HistoryOperation, asOf time.Time) {
+	im.history = history
+	im.historyCtx = ctx
+	im.asOf = asOf
+	im.historyInstance = map[string][]mapstr.MapStr{}
+}
+
+// instancesAsOf returns the instances of the object which match the condition as of the time
+func (im *InstanceMainline) instancesAsOf(objID string, cond map[string]interface{}) ([]mapstr.MapStr, error) {
+	instances, exist := im.historyInstance[objID]
+	if !exist {
+		var err error
+		instances, err = im.history.SearchInstancesAsOf(im.historyCtx, objID, im.asOf)
+		if err != nil {
+			return nil, err
+		}
+		im.historyInstance[objID] = instances
+	}
+	return history.FilterInstances(instances, cond), nil
+}
+
 func (im *InstanceMainline) LoadModelParentMap() {
 	// step2
 	im.modelIDs = im.modelTree.LeftestObjectIDList()
@@ -84,7 +114,12 @@ func (im *InstanceMainline) LoadSetInstances() error {
 	mongoCondition := mongo.NewCondition()
 	mongoCondition.Element(&mongo.Eq{Key: common.BKAppIDField, Val: im.bkBizID})
 
-	err := im.dbProxy.Table(common.BKTableNameBaseSet).Find(mongoCondition.ToMapStr()).All(ctx, &im.setInstances)
+	var err error
+	if im.history != nil {
+		im.setInstances, err = im.instancesAsOf(common.BKInnerObjIDSet, mongoCondition.ToMapStr())
+	} else {
+		err = im.dbProxy.Table(common.BKTableNameBaseSet).Find(mongoCondition.ToMapStr()).All(ctx, &im.setInstances)
+	}
 	if err != nil {
 		blog.Errorf("get set instances by business:%d failed, %+v", im.bkBizID, err)
 		return fmt.Errorf("get set instances by business:%d failed, %+v", im.bkBizID, err)
@@ -99,7 +134,12 @@ func (im *InstanceMainline) LoadModuleInstances() error {
 	mongoCondition := mongo.NewCondition()
 	mongoCondition.Element(&mongo.Eq{Key: common.BKAppIDField, Val: im.bkBizID})
 
-	err := im.dbProxy.Table(common.BKTableNameBaseModule).Find(mongoCondition.ToMapStr()).All(ctx, &im.moduleInstances)
+	var err error
+	if im.history != nil {
+		im.moduleInstances, err = im.instancesAsOf(common.BKInnerObjIDModule, mongoCondition.ToMapStr())
+	} else {
+		err = im.dbProxy.Table(common.BKTableNameBaseModule).Find(mongoCondition.ToMapStr()).All(ctx, &im.moduleInstances)
+	}
 	if err != nil {
 		blog.Errorf("get module instances by business:%d failed, %+v", im.bkBizID, err)
 		return fmt.Errorf("get module instances by business:%d failed, %+v", im.bkBizID, err)
@@ -112,6 +152,9 @@ func (im *InstanceMainline) LoadMainlineInstances() error {
 	// load other mainline instance(except business,set,module) list of target business
 	var err error
 	ctx := core.ContextParams{}
+	if im.history != nil {
+		return im.loadMainlineInstancesAsOf()
+	}
 	condCheckModel := mongo.NewCondition()
 	_, metaCond := condCheckModel.Embed(metadata.BKMetadata)
 	_, labelCond := metaCond.Embed(metadata.BKLabel)
@@ -127,6 +170,25 @@ func (im *InstanceMainline) LoadMainlineInstances() error {
 	return nil
 }
 
+func (im *InstanceMainline) loadMainlineInstancesAsOf() error {
+	cond := map[string]interface{}{
+		metadata.BKMetadata + "." + metadata.BKLabel + "." + common.BKAppIDField: strconv.FormatInt(im.bkBizID, 10),
+	}
+	for _, objID := range im.modelIDs {
+		if common.IsInnerModel(objID) {
+			continue
+		}
+		instances, err := im.instancesAsOf(objID, cond)
+		if err != nil {
+			blog.Errorf("get other mainline instances by business:%d as of %s failed, %+v", im.bkBizID, im.asOf, err)
+			return fmt.Errorf("get other mainline instances by business:%d failed, %+v", im.bkBizID, err)
+		}
+		im.mainlineInstances = append(im.mainlineInstances, instances...)
+	}
+	blog.V(5).Infof("get other mainline instances by business:%d as of %s result: %+v", im.bkBizID, im.asOf, im.mainlineInstances)
+	return nil
+}
+
 func (im *InstanceMainline) ConstructBizTopoInstance(withDetail bool) error {
 	// enqueue business instance to allTopoInstances, instanceMap
 	ctx := core.ContextParams{}
@@ -142,7 +204,12 @@ func (im *InstanceMainline) ConstructBizTopoInstance(withDetail bool) error {
 		mongoCondition := mongo.NewCondition()
 		mongoCondition.Element(&mongo.Eq{Key: common.BKAppIDField, Val: im.bkBizID})
 
-		err := im.dbProxy.Table(common.BKTableNameBaseApp).Find(mongoCondition.ToMapStr()).All(ctx, &im.businessInstances)
+		var err error
+		if im.history != nil {
+			im.businessInstances, err = im.instancesAsOf(common.BKInnerObjIDApp, mongoCondition.ToMapStr())
+		} else {
+			err = im.dbProxy.Table(common.BKTableNameBaseApp).Find(mongoCondition.ToMapStr()).All(ctx, &im.businessInstances)
+		}
 		if err != nil {
 			blog.Errorf("get business instances by business:%d failed, err: %+v", im.bkBizID, err)
 			return fmt.Errorf("get business instances by business:%d failed, err: %+v", im.bkBizID, err)
@@ -288,7 +355,12 @@ func (im *InstanceMainline) CheckAndFillingMissingModels(withDetail bool) error
 		mongoCondition.Element(&mongo.Eq{Key: common.BKInstIDField, Val: topoInstance.ParentInstanceID})
 
 		missedInstances := make([]mapstr.MapStr, 0)
-		err := im.dbProxy.Table(common.BKTableNameBaseInst).Find(mongoCondition.ToMapStr()).All(ctx, &missedInstances)
+		var err error
+		if im.history != nil {
+			missedInstances, err = im.instancesAsOf(im.objectParentMap[topoInstance.ObjectID], mongoCondition.ToMapStr())
+		} else {
+			err = im.dbProxy.Table(common.BKTableNameBaseInst).Find(mongoCondition.ToMapStr()).All(ctx, &missedInstances)
+		}
 		if err != nil {
 			blog.Errorf("get common instances with ID:%d failed, %+v", topoInstance.ParentInstanceID, err)
 			return err
@@ -376,7 +448,7 @@ func (im *InstanceMainline) ConstructInstanceTopoTree(withDetail bool) error {
 						common.BKInstIDField: topoInstance.ParentInstanceID,
 					}
 					inst := mapstr.MapStr{}
-					if err := im.dbProxy.Table(common.BKTableNameBaseInst).Find(cond).One(context.Background(), &inst); err != nil {
+					if err := im.findParentInstance(cond, &inst); err != nil {
 						if im.dbProxy.IsNotFoundError(err) == false {
 							blog.Errorf("get mainline instances failed, filter: %+v, err: %+v", cond, err)
 							return fmt.Errorf("get other mainline instances failed, filer: %+v, err: %+v", cond, err)
@@ -437,6 +509,22 @@ func (im *InstanceMainline) ConstructInstanceTopoTree(withDetail bool) error {
 	return nil
 }
 
+// findParentInstance find the parent instance from the db, or from the history if the topo is rebuilt as of the time
+func (im *InstanceMainline) findParentInstance(cond map[string]interface{}, inst *mapstr.MapStr) error {
+	if im.history == nil {
+		return im.dbProxy.Table(common.BKTableNameBaseInst).Find(cond).One(context.Background(), inst)
+	}
+	instances, err := im.instancesAsOf(util.GetStrByInterface(cond[common.BKObjIDField]), cond)
+	if err != nil {
+		return err
+	}
+	if len(instances) == 0 {
+		return dal.ErrDocumentNotFound
+	}
+	*inst = instances[0]
+	return nil
+}
+
 func (im *InstanceMainline) GetInstanceMap() map[string]*metadata.TopoInstance {
 	return im.instanceMap
 }
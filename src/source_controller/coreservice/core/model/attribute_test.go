@@ -77,7 +77,7 @@ func TestAttributeMaintainces(t *testing.T) {
 	// update attribute
 	updateResult, err := modelMgr.UpdateModelAttributes(defaultCtx, objectID, metadata.UpdateOption{
 		Data: mapstr.MapStr{
-			metadata.AttributeFieldPropertyName: "create_attribute_to_updated",
+			metadata.AttributeFieldPropertyName: "attribute_updated",
 		},
 		Condition: mapstr.MapStr{
 			metadata.AttributeFieldPropertyID: mapstr.MapStr{
@@ -103,7 +103,7 @@ func TestAttributeMaintainces(t *testing.T) {
 	require.Equal(t, int64(1), searchResult.Count)
 
 	for _, attr := range searchResult.Info {
-		require.Equal(t, "create_attribute_to_updated", attr.PropertyName)
+		require.Equal(t, "attribute_updated", attr.PropertyName)
 	}
 
 	// delete the attribues
@@ -238,7 +238,7 @@ func TestDeleteSearchModelClassification(t *testing.T) {
 	t.Log("search:", queryResult.Info)
 
 	// delete all classification
-	delResult, err := modelMgr.DeleteModelClassificaiton(defaultCtx, metadata.DeleteOption{
+	delResult, err := modelMgr.DeleteModelClassification(defaultCtx, metadata.DeleteOption{
 		Condition: mapstr.MapStr{
 			metadata.ClassFieldClassificationID: mapstr.MapStr{
 				"$regex": "delete_",
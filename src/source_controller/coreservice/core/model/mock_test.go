@@ -15,16 +15,12 @@ package model_test
 import (
 	"context"
 	"testing"
-	"time"
 
 	"icenter/src/common/errors"
 	"icenter/src/common/language"
-	"icenter/src/common/storage/dal/mongo"
-	"icenter/src/common/storage/dal/mongo/local"
+	"icenter/src/common/storage/dal/memory"
 	"icenter/src/source_controller/coreservice/core"
 	"icenter/src/source_controller/coreservice/core/model"
-
-	"github.com/stretchr/testify/require"
 )
 
 type mockDependences struct {
@@ -52,13 +48,14 @@ func (s *mockDependences) CascadeDeleteInstances(ctx core.ContextParams, objIDS
 
 func newModel(t *testing.T) core.ModelOperation {
 
-	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
-	require.NoError(t, err)
-	return model.New(db, &mockDependences{})
+	return model.New(testDB, &mockDependences{})
 }
 
+// testDB the data of the tests is saved in memory, the models and instances created are shared by the tests
+var testDB = memory.NewMemory()
+
 var defaultCtx = func() core.ContextParams {
-	err, _ := errors.New("../../../../../resources/errors/")
+	err, _ := errors.NewFactory("../../../../../resources/errors/")
 	lan, _ := language.New("../../../../../resources/language/")
 	return core.ContextParams{
 		Context:         context.Background(),
@@ -0,0 +1,163 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"icenter/src/common/metadata"
+)
+
+// Channel send the notification messages to the users
+type Channel interface {
+	// Address returns the address of the user in the channel, the user is not notified through
+	// the channel if it's empty.
+	Address(user string, setting *metadata.NotificationSetting) string
+	// Send send the message to its address
+	Send(ctx context.Context, message *metadata.NotificationMessage) error
+}
+
+// newChannels returns the channels which are configured
+func newChannels(config Config) map[string]Channel {
+	channels := map[string]Channel{
+		metadata.NotificationChannelHTTP: &httpChannel{
+			url:    config.HTTPURL,
+			client: &http.Client{Timeout: config.Timeout},
+		},
+	}
+	if len(config.SMTPAddress) != 0 && len(config.SMTPFrom) != 0 {
+		channels[metadata.NotificationChannelEmail] = &emailChannel{
+			config:   config,
+			sendMail: smtp.SendMail,
+		}
+	}
+	return channels
+}
+
+// emailChannel send the messages by email through the smtp server
+type emailChannel struct {
+	config   Config
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// Address returns the email of the user, or the user name at the email domain.
+func (c *emailChannel) Address(user string, setting *metadata.NotificationSetting) string {
+	if setting != nil && len(setting.Email) != 0 {
+		return setting.Email
+	}
+	if len(c.config.EmailDomain) != 0 {
+		return user + "@" + c.config.EmailDomain
+	}
+	return ""
+}
+
+func (c *emailChannel) Send(ctx context.Context, message *metadata.NotificationMessage) error {
+	var auth smtp.Auth
+	if len(c.config.SMTPUser) != 0 {
+		host, _, err := net.SplitHostPort(c.config.SMTPAddress)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", c.config.SMTPUser, c.config.SMTPPassword, host)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", c.config.SMTPFrom)
+	fmt.Fprintf(&buf, "To: %s\r\n", message.Address)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	buf.WriteString(strings.Replace(message.Content, "\n", "\r\n", -1))
+	return c.sendMail(c.config.SMTPAddress, auth, c.config.SMTPFrom, []string{message.Address}, buf.Bytes())
+}
+
+// httpChannel post the messages in json to the url of the user or the configured one,
+// such as the gateway of an instant messaging service.
+type httpChannel struct {
+	url    string
+	client *http.Client
+}
+
+// Address returns the url of the user, or the configured url.
+func (c *httpChannel) Address(user string, setting *metadata.NotificationSetting) string {
+	if setting != nil && len(setting.HTTPURL) != 0 {
+		return setting.HTTPURL
+	}
+	return c.url
+}
+
+func (c *httpChannel) Send(ctx context.Context, message *metadata.NotificationMessage) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, message.Address, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("response status %d, body: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+// newMessage render the notifications of a rule to a message, a line for every change of an instance.
+func newMessage(rule *metadata.NotificationRule, user, channel string, items []metadata.Notification) *metadata.NotificationMessage {
+	var content strings.Builder
+	for _, item := range items {
+		fmt.Fprintf(&content, "%s %s %s %s(%d):", item.CreateTime.Format("2006-01-02 15:04:05"),
+			item.Action, item.ObjectID, item.InstName, item.InstID)
+		for _, change := range item.Changes {
+			fmt.Fprintf(&content, " %s: %s -> %s;", change.PropertyID, formatValue(change.PreValue), formatValue(change.CurValue))
+		}
+		content.WriteString("\n")
+	}
+	return &metadata.NotificationMessage{
+		User:     user,
+		Channel:  channel,
+		RuleID:   rule.ID,
+		RuleName: rule.Name,
+		Subject:  fmt.Sprintf("[CMDB] %s: %d change(s)", rule.Name, len(items)),
+		Content:  content.String(),
+		Items:    items,
+	}
+}
+
+func formatValue(value interface{}) string {
+	if value == nil {
+		return `""`
+	}
+	return fmt.Sprintf("%q", fmt.Sprint(value))
+}
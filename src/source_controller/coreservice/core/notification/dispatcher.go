@@ -0,0 +1,373 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/eventclient"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+	"icenter/src/source_controller/coreservice/core/eventdispatch"
+)
+
+const (
+	// consumerName the consumer name of the dispatcher in the event log
+	consumerName = "notification"
+	// dispatchInterval the interval to check the new events and the notifications which are due
+	dispatchInterval = 5 * time.Second
+	// eventBatch the max events count read from the event log at once
+	eventBatch = 500
+	// notificationBatch the max notifications count checked at once
+	notificationBatch = 200
+	// maxDigestItems the max notifications count sent in one message, the others are sent in the next one.
+	maxDigestItems = 100
+	// initialBackoff the delay of the first retry, it's doubled with every retry.
+	initialBackoff = time.Minute
+	// maxBackoff the max delay of a retry
+	maxBackoff = time.Hour
+	// failedRetention how long the failed notifications are kept
+	failedRetention = 7 * 24 * time.Hour
+	// maxErrorLength the max length of the error kept in the notification
+	maxErrorLength = 512
+
+	defaultTimeout    = 10 * time.Second
+	defaultMaxRetries = 5
+)
+
+// Config the config of the notification channels
+type Config struct {
+	// SMTPAddress the host:port of the smtp server, the email channel is disabled if it's not set.
+	SMTPAddress  string
+	SMTPUser     string
+	SMTPPassword string
+	// SMTPFrom the sender of the emails
+	SMTPFrom string
+	// EmailDomain the email of the user without an email set is the user name at the domain
+	EmailDomain string
+	// HTTPURL the url the messages are posted to for the users without an http url set
+	HTTPURL string
+	// Timeout the timeout of sending a message
+	Timeout time.Duration
+	// MaxRetries the max retries of a failed message
+	MaxRetries int
+}
+
+// ParseConfigFromKV returns a new config, the timeout is configured in seconds.
+func ParseConfigFromKV(prefix string, configmap map[string]string) Config {
+	config := Config{
+		SMTPAddress:  configmap[prefix+".smtp_address"],
+		SMTPUser:     configmap[prefix+".smtp_user"],
+		SMTPPassword: configmap[prefix+".smtp_password"],
+		SMTPFrom:     configmap[prefix+".smtp_from"],
+		EmailDomain:  configmap[prefix+".email_domain"],
+		HTTPURL:      configmap[prefix+".http_url"],
+		Timeout:      defaultTimeout,
+		MaxRetries:   defaultMaxRetries,
+	}
+	if seconds, err := strconv.Atoi(configmap[prefix+".timeout_seconds"]); err == nil && seconds > 0 {
+		config.Timeout = time.Duration(seconds) * time.Second
+	}
+	if retries, err := strconv.Atoi(configmap[prefix+".max_retries"]); err == nil && retries >= 0 {
+		config.MaxRetries = retries
+	}
+	return config
+}
+
+// Run notify the users of the changes in the event log which match the rules, it never returns.
+// only the master process dispatches the events.
+func Run(dbProxy dal.RDB, stream *eventclient.EventStream, config Config, isMaster func() bool) {
+	eventdispatch.Run(consumerName, dispatchInterval, isMaster, newDispatcher(dbProxy, stream, config).dispatch)
+}
+
+type dispatcher struct {
+	dbProxy    dal.RDB
+	stream     *eventclient.EventStream
+	channels   map[string]Channel
+	maxRetries int
+	timeout    time.Duration
+	now        func() time.Time
+}
+
+func newDispatcher(dbProxy dal.RDB, stream *eventclient.EventStream, config Config) *dispatcher {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	return &dispatcher{
+		dbProxy:    dbProxy,
+		stream:     stream,
+		channels:   newChannels(config),
+		maxRetries: config.MaxRetries,
+		timeout:    config.Timeout,
+		now:        time.Now,
+	}
+}
+
+// groupKey the notifications of a rule to a user through a channel are sent in one message
+type groupKey struct {
+	ownerID string
+	ruleID  int64
+	user    string
+	channel string
+}
+
+// dispatch save the notifications of the new events, and then send the ones which are due.
+func (d *dispatcher) dispatch(ctx context.Context) error {
+	rules := make([]metadata.NotificationRule, 0)
+	if err := d.dbProxy.Table(common.BKTableNameNotificationRule).Find(nil).All(ctx, &rules); err != nil {
+		return err
+	}
+	byID := make(map[int64]*metadata.NotificationRule, len(rules))
+	for index := range rules {
+		rule := &rules[index]
+		if err := decodeCondition(rule); err != nil {
+			blog.Errorf("[notification] decode the condition of rule %d failed, err: %v", rule.ID, err)
+			continue
+		}
+		byID[rule.ID] = rule
+	}
+
+	allSettings := make([]metadata.NotificationSetting, 0)
+	if err := d.dbProxy.Table(common.BKTableNameNotificationSetting).Find(nil).All(ctx, &allSettings); err != nil {
+		return err
+	}
+	settings := make(map[string]*metadata.NotificationSetting, len(allSettings))
+	for index := range allSettings {
+		setting := &allSettings[index]
+		settings[settingKey(setting.OwnerID, setting.User)] = setting
+	}
+
+	for {
+		count, err := d.fanOut(ctx, byID, settings)
+		if err != nil {
+			return err
+		}
+		if count < eventBatch {
+			break
+		}
+	}
+	if err := d.deliver(ctx, byID, settings); err != nil {
+		return err
+	}
+
+	cond := mapstr.MapStr{
+		"status":    metadata.NotificationFailed,
+		"last_time": mapstr.MapStr{common.BKDBLT: d.now().Add(-failedRetention)},
+	}
+	return d.dbProxy.Table(common.BKTableNameNotification).Delete(ctx, cond)
+}
+
+// fanOut save a notification for every recipient and channel of the rules that the new event matches,
+// and commit the offset of the events.
+func (d *dispatcher) fanOut(ctx context.Context, rules map[int64]*metadata.NotificationRule,
+	settings map[string]*metadata.NotificationSetting) (int, error) {
+
+	events, offset, err := d.stream.Consume(ctx, consumerName, eventBatch)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	for index := range events {
+		event := &events[index]
+		for _, rule := range rules {
+			if !rule.Enabled {
+				continue
+			}
+			if rule.OwnerID != event.OwnerID && rule.OwnerID != common.BKSuperOwnerID {
+				continue
+			}
+			for _, data := range event.Data {
+				changes, err := matchChanges(rule, event, data)
+				if err != nil {
+					blog.Errorf("[notification] match event %d with rule %d failed, err: %v", event.ID, rule.ID, err)
+					break
+				}
+				if len(changes) == 0 {
+					continue
+				}
+				if err := d.saveNotifications(ctx, rule, event, data, changes, settings); err != nil {
+					return 0, err
+				}
+			}
+		}
+		offset = event.ID
+	}
+
+	// the notifications saved before the offset is committed are saved again when the process restarts.
+	if err := d.stream.Commit(ctx, consumerName, offset); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+func (d *dispatcher) saveNotifications(ctx context.Context, rule *metadata.NotificationRule, event *metadata.EventInst,
+	data metadata.EventData, changes []metadata.NotificationChange, settings map[string]*metadata.NotificationSetting) error {
+
+	now := d.now()
+	instID, instName := instIdentity(rule.ObjectID, data)
+	for _, user := range recipients(rule, data) {
+		setting := settings[settingKey(event.OwnerID, user)]
+		if optedOut(setting, rule.ID) {
+			continue
+		}
+		for _, name := range rule.Channels {
+			channel, exists := d.channels[name]
+			if !exists || len(channel.Address(user, setting)) == 0 {
+				blog.V(4).Infof("[notification] the %s channel of user %s is not available, skip the change of event %d", name, user, event.ID)
+				continue
+			}
+			id, err := d.dbProxy.NextSequence(ctx, common.BKTableNameNotification)
+			if err != nil {
+				return err
+			}
+			notification := metadata.Notification{
+				ID:         int64(id),
+				RuleID:     rule.ID,
+				User:       user,
+				Channel:    name,
+				EventID:    event.ID,
+				ObjectID:   event.ObjType,
+				InstID:     instID,
+				InstName:   instName,
+				Action:     event.Action,
+				Changes:    changes,
+				OwnerID:    event.OwnerID,
+				Status:     metadata.NotificationPending,
+				NextTime:   now.Add(time.Duration(rule.DigestMinutes) * time.Minute),
+				CreateTime: now,
+				LastTime:   now,
+			}
+			if err := d.dbProxy.Table(common.BKTableNameNotification).Insert(ctx, notification); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deliver send the pending notifications which are due, a notification is due when its digest window ends,
+// and it's sent with all the pending ones of the same rule, user and channel.
+func (d *dispatcher) deliver(ctx context.Context, rules map[int64]*metadata.NotificationRule,
+	settings map[string]*metadata.NotificationSetting) error {
+
+	cond := mapstr.MapStr{
+		"status":    metadata.NotificationPending,
+		"next_time": mapstr.MapStr{common.BKDBLTE: d.now()},
+	}
+	due := make([]metadata.Notification, 0)
+	err := d.dbProxy.Table(common.BKTableNameNotification).Find(cond).Sort("id").Limit(notificationBatch).All(ctx, &due)
+	if err != nil {
+		return err
+	}
+
+	groups := make([]groupKey, 0)
+	seen := make(map[groupKey]bool)
+	for _, item := range due {
+		key := groupKey{ownerID: item.OwnerID, ruleID: item.RuleID, user: item.User, channel: item.Channel}
+		if !seen[key] {
+			seen[key] = true
+			groups = append(groups, key)
+		}
+	}
+	for _, key := range groups {
+		if err := d.deliverGroup(ctx, key, rules[key.ruleID], settings[settingKey(key.ownerID, key.user)]); err != nil {
+			blog.Errorf("[notification] deliver the notifications of rule %d to %s failed, err: %v", key.ruleID, key.user, err)
+		}
+	}
+	return nil
+}
+
+func (d *dispatcher) deliverGroup(ctx context.Context, key groupKey, rule *metadata.NotificationRule,
+	setting *metadata.NotificationSetting) error {
+
+	table := d.dbProxy.Table(common.BKTableNameNotification)
+	cond := mapstr.MapStr{
+		"status":              metadata.NotificationPending,
+		"rule_id":             key.ruleID,
+		"bk_username":         key.user,
+		"channel":             key.channel,
+		common.BKOwnerIDField: key.ownerID,
+	}
+	// the rule is deleted or disabled, or the user opts out after the notifications are saved,
+	// all the pending ones are dropped so that they never hold the due ones of the other rules back.
+	if rule == nil || !rule.Enabled || optedOut(setting, rule.ID) {
+		return table.Delete(ctx, cond)
+	}
+
+	items := make([]metadata.Notification, 0)
+	if err := table.Find(cond).Sort("id").Limit(maxDigestItems).All(ctx, &items); err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(items))
+	for index := range items {
+		ids[index] = items[index].ID
+	}
+	idCond := mapstr.MapStr{"id": mapstr.MapStr{common.BKDBIN: ids}}
+
+	now := d.now()
+	if end, quiet := quietUntil(setting, now); quiet {
+		return table.Update(ctx, idCond, mapstr.MapStr{"next_time": end})
+	}
+
+	message := newMessage(rule, key.user, key.channel, items)
+	err := d.send(ctx, message, setting)
+	if err == nil {
+		return table.Delete(ctx, idCond)
+	}
+
+	attempts := items[0].Attempts + 1
+	doc := mapstr.MapStr{
+		"attempts":   attempts,
+		"next_time":  now.Add(eventdispatch.Backoff(attempts, initialBackoff, maxBackoff)),
+		"last_error": eventdispatch.Truncate(err.Error(), maxErrorLength),
+		"last_time":  now,
+	}
+	if attempts > d.maxRetries {
+		doc["status"] = metadata.NotificationFailed
+		blog.Warnf("[notification] %d notifications of rule %d to %s through %s are failed after %d attempts, err: %v",
+			len(items), rule.ID, key.user, key.channel, attempts, err)
+	}
+	return table.Update(ctx, idCond, doc)
+}
+
+func (d *dispatcher) send(ctx context.Context, message *metadata.NotificationMessage, setting *metadata.NotificationSetting) error {
+	channel, exists := d.channels[message.Channel]
+	if !exists {
+		return errChannelUnavailable(message.Channel)
+	}
+	message.Address = channel.Address(message.User, setting)
+	if len(message.Address) == 0 {
+		return errChannelUnavailable(message.Channel)
+	}
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	return channel.Send(ctx, message)
+}
+
+type errChannelUnavailable string
+
+func (e errChannelUnavailable) Error() string {
+	return "the " + string(e) + " channel is not available"
+}
+
+func settingKey(ownerID, user string) string {
+	return ownerID + "/" + user
+}
@@ -0,0 +1,188 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal/matcher"
+	"icenter/src/common/util"
+	"icenter/src/source_controller/coreservice/core/eventdispatch"
+)
+
+// matchChanges returns the changes of the watched properties in the data if it matches the rule,
+// the created and deleted data are treated as all the properties are changed.
+func matchChanges(rule *metadata.NotificationRule, event *metadata.EventInst, data metadata.EventData) ([]metadata.NotificationChange, error) {
+	if event.EventType != metadata.EventTypeInstData || event.ObjType != rule.ObjectID {
+		return nil, nil
+	}
+	if len(rule.Actions) != 0 && !util.InStrArr(rule.Actions, event.Action) {
+		return nil, nil
+	}
+
+	cur, _ := eventdispatch.ToMap(data.CurData)
+	pre, _ := eventdispatch.ToMap(data.PreData)
+	changes := make([]metadata.NotificationChange, 0)
+	for _, property := range rule.PropertyIDs {
+		if cur != nil && pre != nil && reflect.DeepEqual(cur[property], pre[property]) {
+			continue
+		}
+		changes = append(changes, metadata.NotificationChange{
+			PropertyID: property,
+			PreValue:   pre[property],
+			CurValue:   cur[property],
+		})
+	}
+	if len(changes) == 0 || len(rule.Condition) == 0 {
+		return changes, nil
+	}
+
+	doc := mapstr.MapStr{"cur_data": data.CurData, "pre_data": data.PreData}
+	matched, err := matcher.Match(doc, rule.Condition)
+	if err != nil || !matched {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// recipients returns the users to notify of the change, the users in the recipient fields
+// of both the current and the previous data are notified, so the replaced owner is told too.
+func recipients(rule *metadata.NotificationRule, data metadata.EventData) []string {
+	users := append([]string{}, rule.Recipients...)
+	for _, item := range []interface{}{data.CurData, data.PreData} {
+		doc, ok := eventdispatch.ToMap(item)
+		if !ok {
+			continue
+		}
+		for _, field := range rule.RecipientFields {
+			value, ok := doc[field].(string)
+			if !ok {
+				continue
+			}
+			users = append(users, splitUsers(value)...)
+		}
+	}
+	return util.StrArrayUnique(users)
+}
+
+// splitUsers split the value of a user field, the users are separated by commas or semicolons.
+func splitUsers(value string) []string {
+	users := make([]string, 0)
+	for _, user := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		if user = strings.TrimSpace(user); len(user) != 0 {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// instIdentity returns the id and the name of the instance in the data
+func instIdentity(objID string, data metadata.EventData) (int64, string) {
+	doc, ok := eventdispatch.ToMap(data.CurData)
+	if !ok {
+		doc, _ = eventdispatch.ToMap(data.PreData)
+	}
+	id, _ := util.GetInt64ByInterface(doc[common.GetInstIDField(objID)])
+	name := doc[common.GetInstNameField(objID)]
+	if objID == common.BKInnerObjIDHost {
+		name = doc[common.BKHostInnerIPField]
+	}
+	if name == nil {
+		return id, ""
+	}
+	return id, fmt.Sprint(name)
+}
+
+// optedOut whether the user receives no notification of the rule
+func optedOut(setting *metadata.NotificationSetting, ruleID int64) bool {
+	if setting == nil {
+		return false
+	}
+	if setting.OptOutAll {
+		return true
+	}
+	for _, id := range setting.OptOutRules {
+		if id == ruleID {
+			return true
+		}
+	}
+	return false
+}
+
+// quietUntil returns the end of the quiet hours if the time is in them
+func quietUntil(setting *metadata.NotificationSetting, now time.Time) (time.Time, bool) {
+	if setting == nil || setting.QuietHours == nil {
+		return time.Time{}, false
+	}
+	start, end, err := parseQuietHours(setting.QuietHours)
+	if err != nil || start == end {
+		return time.Time{}, false
+	}
+	loc := time.Local
+	if len(setting.QuietHours.Timezone) != 0 {
+		if loc, err = time.LoadLocation(setting.QuietHours.Timezone); err != nil {
+			return time.Time{}, false
+		}
+	}
+
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	elapsed := local.Sub(midnight)
+	if start < end {
+		// such as 12:00 to 14:00
+		if elapsed >= start && elapsed < end {
+			return midnight.Add(end), true
+		}
+		return time.Time{}, false
+	}
+	// such as 22:00 to 08:00 of the next day
+	if elapsed >= start {
+		return midnight.AddDate(0, 0, 1).Add(end), true
+	}
+	if elapsed < end {
+		return midnight.Add(end), true
+	}
+	return time.Time{}, false
+}
+
+// parseQuietHours returns the start and the end of the quiet hours as the durations since midnight
+func parseQuietHours(quiet *metadata.NotificationQuietHours) (time.Duration, time.Duration, error) {
+	start, err := parseClock(quiet.Start)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(quiet.End)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(quiet.Timezone) != 0 {
+		if _, err := time.LoadLocation(quiet.Timezone); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, end, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
@@ -0,0 +1,362 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"encoding/json"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/dal/matcher"
+	"icenter/src/common/util"
+	"icenter/src/source_controller/coreservice/core"
+	"icenter/src/source_controller/coreservice/core/eventdispatch"
+)
+
+const (
+	// maxDigestMinutes the max digest window of a rule
+	maxDigestMinutes = 24 * 60
+)
+
+var channels = []string{
+	metadata.NotificationChannelEmail,
+	metadata.NotificationChannelHTTP,
+}
+
+var eventActions = []string{
+	metadata.EventActionCreate,
+	metadata.EventActionUpdate,
+	metadata.EventActionDelete,
+}
+
+// updatableFields the fields of a rule which can be updated
+var updatableFields = []string{
+	"name",
+	"bk_property_ids",
+	"actions",
+	"condition",
+	"channels",
+	"recipients",
+	"recipient_fields",
+	"digest_minutes",
+	"enabled",
+}
+
+var _ core.NotificationOperation = (*notificationManager)(nil)
+
+type notificationManager struct {
+	dbProxy dal.RDB
+}
+
+// New create a new notification manager instance
+func New(dbProxy dal.RDB) core.NotificationOperation {
+	return &notificationManager{
+		dbProxy: dbProxy,
+	}
+}
+
+// CreateRule create a notification rule, the changes after it are notified to the recipients.
+func (m *notificationManager) CreateRule(ctx core.ContextParams, rule metadata.NotificationRule) (*metadata.NotificationRule, error) {
+	if err := m.validRule(ctx, &rule); err != nil {
+		return nil, err
+	}
+
+	id, err := m.dbProxy.NextSequence(ctx, common.BKTableNameNotificationRule)
+	if err != nil {
+		blog.Errorf("create notification rule failed, generate id failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	now := time.Now()
+	rule.ID = int64(id)
+	rule.OwnerID = ctx.SupplierAccount
+	rule.Creator = ctx.User
+	rule.CreateTime = now
+	rule.LastTime = now
+	if err := m.dbProxy.Table(common.BKTableNameNotificationRule).Insert(ctx, rule); err != nil {
+		blog.Errorf("create notification rule failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	return &rule, nil
+}
+
+// UpdateRule update the rule with the fields in the data, the other fields are kept.
+func (m *notificationManager) UpdateRule(ctx core.ContextParams, id int64, data mapstr.MapStr) error {
+	rule, err := m.getRule(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// overlay the updated fields on the rule, so that the result is validated as a whole.
+	origin, err := json.Marshal(rule)
+	if err != nil {
+		return ctx.Error.Error(common.CCErrCommJSONMarshalFailed)
+	}
+	merged := mapstr.MapStr{}
+	if err := json.Unmarshal(origin, &merged); err != nil {
+		return ctx.Error.Error(common.CCErrCommJSONUnmarshalFailed)
+	}
+	for key, val := range data {
+		if !util.InStrArr(updatableFields, key) {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, key)
+		}
+		merged[key] = val
+	}
+	updated := metadata.NotificationRule{}
+	if err := merged.MarshalJSONInto(&updated); err != nil {
+		return ctx.Error.Error(common.CCErrCommJSONUnmarshalFailed)
+	}
+	if err := m.validRule(ctx, &updated); err != nil {
+		return err
+	}
+
+	doc := mapstr.MapStr{
+		"name":             updated.Name,
+		"bk_property_ids":  updated.PropertyIDs,
+		"actions":          updated.Actions,
+		"condition":        updated.ConditionJSON,
+		"channels":         updated.Channels,
+		"recipients":       updated.Recipients,
+		"recipient_fields": updated.RecipientFields,
+		"digest_minutes":   updated.DigestMinutes,
+		"enabled":          updated.Enabled,
+		"last_time":        time.Now(),
+	}
+	if err := m.dbProxy.Table(common.BKTableNameNotificationRule).Update(ctx, m.idCondition(ctx, id), doc); err != nil {
+		blog.Errorf("update notification rule %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// DeleteRule delete the rule and its pending notifications
+func (m *notificationManager) DeleteRule(ctx core.ContextParams, id int64) error {
+	if _, err := m.getRule(ctx, id); err != nil {
+		return err
+	}
+	if err := m.dbProxy.Table(common.BKTableNameNotificationRule).Delete(ctx, m.idCondition(ctx, id)); err != nil {
+		blog.Errorf("delete notification rule %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBDeleteFailed)
+	}
+	if err := m.dbProxy.Table(common.BKTableNameNotification).Delete(ctx, mapstr.MapStr{"rule_id": id}); err != nil {
+		blog.Errorf("delete the notifications of rule %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+// SearchRules search the notification rules
+func (m *notificationManager) SearchRules(ctx core.ContextParams, input metadata.QueryCondition) (*metadata.SearchNotificationRuleResult, error) {
+	cond := util.SetModOwner(input.Condition, ctx.SupplierAccount)
+	table := m.dbProxy.Table(common.BKTableNameNotificationRule)
+	count, err := table.Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("search notification rules failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	rules := make([]metadata.NotificationRule, 0)
+	find := table.Find(cond).Sort("id").Start(uint64(input.Limit.Offset))
+	if input.Limit.Limit > 0 {
+		find = find.Limit(uint64(input.Limit.Limit))
+	}
+	if err := find.All(ctx, &rules); err != nil {
+		blog.Errorf("search notification rules failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	for index := range rules {
+		if err := decodeCondition(&rules[index]); err != nil {
+			blog.Errorf("decode the condition of notification rule %d failed, err: %v, rid: %s", rules[index].ID, err, ctx.ReqID)
+		}
+	}
+	return &metadata.SearchNotificationRuleResult{Count: count, Info: rules}, nil
+}
+
+// GetSetting returns the notification setting of the user, the default one is returned if it's not saved.
+func (m *notificationManager) GetSetting(ctx core.ContextParams, user string) (*metadata.NotificationSetting, error) {
+	setting := new(metadata.NotificationSetting)
+	err := m.dbProxy.Table(common.BKTableNameNotificationSetting).Find(m.userCondition(ctx, user)).One(ctx, setting)
+	if err != nil {
+		if m.dbProxy.IsNotFoundError(err) {
+			return &metadata.NotificationSetting{User: user, OwnerID: ctx.SupplierAccount, OptOutRules: []int64{}}, nil
+		}
+		blog.Errorf("get the notification setting of %s failed, err: %v, rid: %s", user, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	return setting, nil
+}
+
+// SaveSetting save the notification setting of the user
+func (m *notificationManager) SaveSetting(ctx core.ContextParams, setting metadata.NotificationSetting) error {
+	if err := validSetting(ctx, &setting); err != nil {
+		return err
+	}
+	setting.OwnerID = ctx.SupplierAccount
+	setting.LastTime = time.Now()
+
+	table := m.dbProxy.Table(common.BKTableNameNotificationSetting)
+	cond := m.userCondition(ctx, setting.User)
+	count, err := table.Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("save the notification setting of %s failed, err: %v, rid: %s", setting.User, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if count == 0 {
+		err = table.Insert(ctx, setting)
+	} else {
+		err = table.Update(ctx, cond, setting)
+	}
+	if err != nil {
+		blog.Errorf("save the notification setting of %s failed, err: %v, rid: %s", setting.User, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+func (m *notificationManager) getRule(ctx core.ContextParams, id int64) (*metadata.NotificationRule, error) {
+	rule := new(metadata.NotificationRule)
+	err := m.dbProxy.Table(common.BKTableNameNotificationRule).Find(m.idCondition(ctx, id)).One(ctx, rule)
+	if err != nil {
+		if m.dbProxy.IsNotFoundError(err) {
+			return nil, ctx.Error.Error(common.CCErrCommNotFound)
+		}
+		blog.Errorf("get notification rule %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if err := decodeCondition(rule); err != nil {
+		blog.Errorf("decode the condition of notification rule %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+	}
+	return rule, nil
+}
+
+func (m *notificationManager) idCondition(ctx core.ContextParams, id int64) mapstr.MapStr {
+	return mapstr.MapStr{"id": id, common.BKOwnerIDField: ctx.SupplierAccount}
+}
+
+func (m *notificationManager) userCondition(ctx core.ContextParams, user string) mapstr.MapStr {
+	return mapstr.MapStr{"bk_username": user, common.BKOwnerIDField: ctx.SupplierAccount}
+}
+
+// validRule valid the rule and fill the default values
+func (m *notificationManager) validRule(ctx core.ContextParams, rule *metadata.NotificationRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if len(rule.Name) == 0 {
+		return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "name")
+	}
+	if len(rule.ObjectID) == 0 {
+		return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, common.BKObjIDField)
+	}
+	if len(rule.PropertyIDs) == 0 {
+		return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "bk_property_ids")
+	}
+	if len(rule.Recipients) == 0 && len(rule.RecipientFields) == 0 {
+		return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "recipients")
+	}
+	if len(rule.Channels) == 0 {
+		return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "channels")
+	}
+	for _, channel := range rule.Channels {
+		if !util.InStrArr(channels, channel) {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "channels")
+		}
+	}
+	for _, action := range rule.Actions {
+		if !util.InStrArr(eventActions, action) {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "actions")
+		}
+	}
+	if rule.DigestMinutes < 0 || rule.DigestMinutes > maxDigestMinutes {
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "digest_minutes")
+	}
+	rule.PropertyIDs = util.StrArrayUnique(rule.PropertyIDs)
+	rule.Recipients = util.StrArrayUnique(rule.Recipients)
+	rule.RecipientFields = util.StrArrayUnique(rule.RecipientFields)
+
+	// the watched properties and the recipient properties should be the properties of the model.
+	fields := append(append([]string{}, rule.PropertyIDs...), rule.RecipientFields...)
+	cond := mapstr.MapStr{
+		common.BKObjIDField:               rule.ObjectID,
+		metadata.AttributeFieldPropertyID: mapstr.MapStr{common.BKDBIN: fields},
+	}
+	attrs := make([]metadata.Attribute, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).Fields(metadata.AttributeFieldPropertyID).All(ctx, &attrs); err != nil {
+		blog.Errorf("get the attributes of %s failed, err: %v, rid: %s", rule.ObjectID, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	exists := make(map[string]bool, len(attrs))
+	for _, attr := range attrs {
+		exists[attr.PropertyID] = true
+	}
+	for _, field := range fields {
+		if !exists[field] {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, field)
+		}
+	}
+
+	rule.ConditionJSON = ""
+	if len(rule.Condition) != 0 {
+		// the condition is matched with an empty change, so that the invalid operators are found.
+		if _, err := matcher.Match(mapstr.MapStr{}, rule.Condition); err != nil {
+			blog.Errorf("invalid notification condition %v, err: %v, rid: %s", rule.Condition, err, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "condition")
+		}
+		data, err := json.Marshal(rule.Condition)
+		if err != nil {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "condition")
+		}
+		rule.ConditionJSON = string(data)
+	}
+	return nil
+}
+
+// validSetting valid the setting of a user
+func validSetting(ctx core.ContextParams, setting *metadata.NotificationSetting) error {
+	setting.User = strings.TrimSpace(setting.User)
+	if len(setting.User) == 0 {
+		setting.User = ctx.User
+	}
+	if len(setting.Email) != 0 {
+		if _, err := mail.ParseAddress(setting.Email); err != nil {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "email")
+		}
+	}
+	if len(setting.HTTPURL) != 0 {
+		target, err := url.Parse(setting.HTTPURL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || len(target.Host) == 0 {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "http_url")
+		}
+	}
+	if setting.OptOutRules == nil {
+		setting.OptOutRules = []int64{}
+	}
+	if setting.QuietHours != nil {
+		if _, _, err := parseQuietHours(setting.QuietHours); err != nil {
+			blog.Errorf("invalid quiet hours %+v, err: %v, rid: %s", *setting.QuietHours, err, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "quiet_hours")
+		}
+	}
+	return nil
+}
+
+// decodeCondition decode the condition saved in db
+func decodeCondition(rule *metadata.NotificationRule) error {
+	cond, err := eventdispatch.DecodeCondition(rule.ConditionJSON)
+	rule.Condition = cond
+	return err
+}
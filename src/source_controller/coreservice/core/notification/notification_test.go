@@ -0,0 +1,351 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/eventclient"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/dal/memory"
+	"icenter/src/source_controller/coreservice/core/eventdispatch/dispatchtest"
+)
+
+func addHostAttributes(t *testing.T, db dal.RDB, properties ...string) {
+	for _, property := range properties {
+		attr := mapstr.MapStr{
+			common.BKObjIDField:               common.BKInnerObjIDHost,
+			metadata.AttributeFieldPropertyID: property,
+			common.BKOwnerIDField:             "0",
+		}
+		if err := db.Table(common.BKTableNameObjAttDes).Insert(context.Background(), attr); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMatchChanges(t *testing.T) {
+	rule := &metadata.NotificationRule{
+		ObjectID:        common.BKInnerObjIDHost,
+		PropertyIDs:     []string{"bk_bak_operator"},
+		Condition:       mapstr.MapStr{"cur_data.bk_os_type": "1"},
+		RecipientFields: []string{"operator", "bk_bak_operator"},
+		Recipients:      []string{"admin"},
+	}
+
+	event := dispatchtest.NewHostEvent(1, metadata.EventActionUpdate,
+		mapstr.MapStr{"bk_os_type": "1", "operator": "alice", "bk_bak_operator": "bob;carol"},
+		mapstr.MapStr{"bk_os_type": "1", "operator": "alice", "bk_bak_operator": "dave"})
+	changes, err := matchChanges(rule, &event, event.Data[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].PreValue != "dave" || changes[0].CurValue != "bob;carol" {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+	users := recipients(rule, event.Data[0])
+	expect := []string{"admin", "alice", "bob", "carol", "dave"}
+	if len(users) != len(expect) {
+		t.Fatalf("expect recipients %v, got %v", expect, users)
+	}
+	for index := range expect {
+		if users[index] != expect[index] {
+			t.Fatalf("expect recipients %v, got %v", expect, users)
+		}
+	}
+
+	// the watched property is not changed
+	event = dispatchtest.NewHostEvent(2, metadata.EventActionUpdate,
+		mapstr.MapStr{"bk_os_type": "1", "bk_bak_operator": "dave", "bk_comment": "a"},
+		mapstr.MapStr{"bk_os_type": "1", "bk_bak_operator": "dave"})
+	if changes, _ := matchChanges(rule, &event, event.Data[0]); len(changes) != 0 {
+		t.Errorf("the unchanged property should not match, got %+v", changes)
+	}
+	// the condition is not matched
+	event = dispatchtest.NewHostEvent(3, metadata.EventActionUpdate, mapstr.MapStr{"bk_os_type": "2", "bk_bak_operator": "bob"}, mapstr.MapStr{"bk_os_type": "2"})
+	if changes, _ := matchChanges(rule, &event, event.Data[0]); len(changes) != 0 {
+		t.Errorf("the condition should not match, got %+v", changes)
+	}
+}
+
+func TestQuietUntil(t *testing.T) {
+	at := func(hour, minute int) time.Time { return time.Date(2019, 6, 3, hour, minute, 0, 0, time.UTC) }
+	cases := []struct {
+		start, end string
+		now        time.Time
+		quiet      bool
+		until      time.Time
+	}{
+		{"12:00", "14:00", at(13, 0), true, at(14, 0)},
+		{"12:00", "14:00", at(14, 0), false, time.Time{}},
+		{"22:00", "08:00", at(23, 30), true, at(32, 0)},
+		{"22:00", "08:00", at(7, 59), true, at(8, 0)},
+		{"22:00", "08:00", at(12, 0), false, time.Time{}},
+		{"08:00", "08:00", at(8, 0), false, time.Time{}},
+	}
+	for _, c := range cases {
+		setting := &metadata.NotificationSetting{
+			QuietHours: &metadata.NotificationQuietHours{Start: c.start, End: c.end, Timezone: "UTC"},
+		}
+		until, quiet := quietUntil(setting, c.now)
+		if quiet != c.quiet || !until.Equal(c.until) {
+			t.Errorf("quiet hours %s-%s at %v: expect %v %v, got %v %v", c.start, c.end, c.now, c.quiet, c.until, quiet, until)
+		}
+	}
+
+	// the time zone of the quiet hours is used
+	setting := &metadata.NotificationSetting{
+		QuietHours: &metadata.NotificationQuietHours{Start: "20:00", End: "22:00", Timezone: "Asia/Shanghai"},
+	}
+	if _, quiet := quietUntil(setting, at(13, 0)); !quiet {
+		t.Errorf("13:00 UTC is 21:00 in Asia/Shanghai, it should be quiet")
+	}
+}
+
+func TestValidRule(t *testing.T) {
+	db := memory.NewMemory()
+	addHostAttributes(t, db, "bk_bak_operator")
+	manager := New(db)
+	ctx := dispatchtest.NewContext()
+
+	rule := metadata.NotificationRule{
+		Name:        "backup operator",
+		ObjectID:    common.BKInnerObjIDHost,
+		PropertyIDs: []string{"bk_bak_operator"},
+		Channels:    []string{metadata.NotificationChannelEmail},
+		Recipients:  []string{"admin"},
+	}
+	if _, err := manager.CreateRule(ctx, rule); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := rule
+	invalid.PropertyIDs = []string{"bk_not_exists"}
+	if _, err := manager.CreateRule(ctx, invalid); err == nil {
+		t.Errorf("the unknown property should be invalid")
+	}
+	invalid = rule
+	invalid.Channels = []string{"sms"}
+	if _, err := manager.CreateRule(ctx, invalid); err == nil {
+		t.Errorf("the unknown channel should be invalid")
+	}
+	invalid = rule
+	invalid.Recipients = nil
+	if _, err := manager.CreateRule(ctx, invalid); err == nil {
+		t.Errorf("the rule without recipients should be invalid")
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	var lock sync.Mutex
+	received := make(map[string][]metadata.NotificationMessage)
+	failures := map[string]int{"/bob": 1}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if failures[r.URL.Path] > 0 {
+			failures[r.URL.Path]--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		message := metadata.NotificationMessage{}
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received[message.User] = append(received[message.User], message)
+	}))
+	defer server.Close()
+	count := func(user string) int {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(received[user])
+	}
+
+	db := memory.NewMemory()
+	addHostAttributes(t, db, "bk_bak_operator", "operator")
+	ctx := dispatchtest.NewContext()
+	manager := New(db)
+	rule, err := manager.CreateRule(ctx, metadata.NotificationRule{
+		Name:            "backup operator",
+		ObjectID:        common.BKInnerObjIDHost,
+		PropertyIDs:     []string{"bk_bak_operator"},
+		Channels:        []string{metadata.NotificationChannelHTTP, metadata.NotificationChannelEmail},
+		Recipients:      []string{"admin"},
+		RecipientFields: []string{"operator", "bk_bak_operator"},
+		DigestMinutes:   10,
+		Enabled:         true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := []metadata.NotificationSetting{
+		{User: "admin", OptOutRules: []int64{rule.ID}},
+		{User: "bob", HTTPURL: server.URL + "/bob"},
+		{User: "carol", QuietHours: &metadata.NotificationQuietHours{Start: "09:00", End: "12:00", Timezone: "UTC"}},
+	}
+	for _, setting := range settings {
+		if err := manager.SaveSetting(ctx, setting); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	host := func(operator, bakOperator, comment string) mapstr.MapStr {
+		return mapstr.MapStr{
+			common.BKHostIDField:      1,
+			common.BKHostInnerIPField: "10.0.0.1",
+			"operator":                operator,
+			"bk_bak_operator":         bakOperator,
+			"bk_comment":              comment,
+		}
+	}
+	dispatchtest.AppendEvent(t, db, dispatchtest.NewHostEvent(1000, metadata.EventActionUpdate, host("alice", "bob,carol", ""), host("alice", "bob", "")))
+	dispatchtest.AppendEvent(t, db, dispatchtest.NewHostEvent(1001, metadata.EventActionUpdate, host("alice", "bob,carol", "a"), host("alice", "bob,carol", "")))
+	dispatchtest.AppendEvent(t, db, dispatchtest.NewHostEvent(1002, metadata.EventActionUpdate, host("alice", "bob", "a"), host("alice", "bob,carol", "a")))
+
+	// the email channel is not configured, so only the http notifications are saved.
+	clock := time.Date(2019, 6, 3, 10, 0, 0, 0, time.UTC)
+	d := newDispatcher(db, eventclient.NewEventStream(db), Config{HTTPURL: server.URL + "/default", MaxRetries: 3})
+	d.now = func() time.Time { return clock }
+	if err := d.dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	pending := make([]metadata.Notification, 0)
+	if err := db.Table(common.BKTableNameNotification).Find(nil).All(context.Background(), &pending); err != nil {
+		t.Fatal(err)
+	}
+	// alice, bob and carol are notified of the 2 changes, admin opts out.
+	if len(pending) != 6 {
+		t.Fatalf("expect 6 pending notifications, got %+v", pending)
+	}
+	if count("alice")+count("bob")+count("carol") != 0 {
+		t.Fatalf("the notifications should be held in the digest window")
+	}
+
+	// the digest window ends, alice gets the 2 changes in one message, bob's url is failed,
+	// and carol's are held to the end of the quiet hours.
+	clock = clock.Add(10 * time.Minute)
+	if err := d.dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if count("alice") != 1 || count("bob") != 0 || count("carol") != 0 {
+		t.Fatalf("unexpected messages %+v", received)
+	}
+	message := received["alice"][0]
+	if len(message.Items) != 2 || message.RuleID != rule.ID || message.Items[0].InstID != 1 || message.Items[0].InstName != "10.0.0.1" {
+		t.Fatalf("unexpected message %+v", message)
+	}
+
+	clock = time.Date(2019, 6, 3, 12, 0, 0, 0, time.UTC)
+	if err := d.dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if count("alice") != 1 || count("bob") != 1 || count("carol") != 1 {
+		t.Fatalf("unexpected messages %+v", received)
+	}
+	left, err := db.Table(common.BKTableNameNotification).Find(nil).Count(context.Background())
+	if err != nil || left != 0 {
+		t.Fatalf("the sent notifications should be deleted, count: %d, err: %v", left, err)
+	}
+}
+
+func TestDispatchDisabledRule(t *testing.T) {
+	var lock sync.Mutex
+	received := make([]metadata.NotificationMessage, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		message := metadata.NotificationMessage{}
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received = append(received, message)
+	}))
+	defer server.Close()
+
+	db := memory.NewMemory()
+	addHostAttributes(t, db, "operator")
+	ctx := dispatchtest.NewContext()
+	manager := New(db)
+	rule := metadata.NotificationRule{
+		Name:        "operator",
+		ObjectID:    common.BKInnerObjIDHost,
+		PropertyIDs: []string{"operator"},
+		Channels:    []string{metadata.NotificationChannelHTTP},
+		Recipients:  []string{"alice"},
+		Enabled:     false,
+	}
+	disabled, err := manager.CreateRule(ctx, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule.Name, rule.Enabled = "operator again", true
+	enabled, err := manager.CreateRule(ctx, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the notifications of the disabled rule fill a whole batch ahead of the enabled one.
+	clock := time.Date(2019, 6, 3, 10, 0, 0, 0, time.UTC)
+	for id := int64(1); id <= notificationBatch+1; id++ {
+		ruleID := disabled.ID
+		if id > notificationBatch {
+			ruleID = enabled.ID
+		}
+		notification := metadata.Notification{
+			ID:       id,
+			RuleID:   ruleID,
+			User:     "alice",
+			Channel:  metadata.NotificationChannelHTTP,
+			ObjectID: common.BKInnerObjIDHost,
+			InstID:   1,
+			Action:   metadata.EventActionUpdate,
+			OwnerID:  "0",
+			Status:   metadata.NotificationPending,
+			NextTime: clock,
+		}
+		if err := db.Table(common.BKTableNameNotification).Insert(context.Background(), notification); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := newDispatcher(db, eventclient.NewEventStream(db), Config{HTTPURL: server.URL})
+	d.now = func() time.Time { return clock }
+	// the first batch drops the notifications of the disabled rule, then the next one is sent.
+	if err := d.dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if len(received) != 1 || received[0].RuleID != enabled.ID {
+		t.Fatalf("only the notification of the enabled rule should be sent, got %+v", received)
+	}
+	left, err := db.Table(common.BKTableNameNotification).Find(nil).Count(context.Background())
+	if err != nil || left != 0 {
+		t.Fatalf("the notifications of the disabled rule should be deleted, count: %d, err: %v", left, err)
+	}
+}
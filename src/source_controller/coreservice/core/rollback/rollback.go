@@ -0,0 +1,393 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rollback
+
+import (
+	"fmt"
+	"sort"
+
+	"icenter/src/common"
+	"icenter/src/common/auditoplog"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/util"
+	"icenter/src/source_controller/coreservice/core"
+)
+
+var _ core.RollbackOperation = (*rollbackManager)(nil)
+
+// instanceAssociationTarget the op target of the instance association audit logs
+const instanceAssociationTarget = "instance_association"
+
+// ignoreFields the fields which are generated when the instance is saved, they are never restored.
+var ignoreFields = []string{
+	"_id",
+	common.CreateTimeField,
+	common.LastTimeField,
+	common.BKObjIDField,
+	common.BKOwnerIDField,
+}
+
+type rollbackManager struct {
+	dbProxy     dal.RDB
+	instance    core.InstanceOperation
+	association core.AssociationOperation
+	audit       core.AuditOperation
+}
+
+// New create a new rollback manager instance, the changes are applied through the
+// instance and association operations, so that they are validated and the events are pushed.
+func New(dbProxy dal.RDB, instance core.InstanceOperation, association core.AssociationOperation, audit core.AuditOperation) core.RollbackOperation {
+	return &rollbackManager{
+		dbProxy:     dbProxy,
+		instance:    instance,
+		association: association,
+		audit:       audit,
+	}
+}
+
+// auditRecord the audit log to roll back
+type auditRecord struct {
+	ID       int64  `bson:"id"`
+	OpType   int    `bson:"op_type"`
+	OpTarget string `bson:"op_target"`
+	InstID   int64  `bson:"inst_id"`
+	BizID    int64  `bson:"bk_biz_id"`
+	Content  struct {
+		PreData mapstr.MapStr `bson:"pre_data"`
+		CurData mapstr.MapStr `bson:"cur_data"`
+	} `bson:"content"`
+}
+
+// RollbackAuditLogs roll back the audit logs from the latest one to the earliest one.
+func (m *rollbackManager) RollbackAuditLogs(ctx core.ContextParams, input metadata.RollbackParams) (*metadata.RollbackResult, error) {
+	records, err := m.searchAuditLogs(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &metadata.RollbackResult{DryRun: input.DryRun, Changes: make([]metadata.RollbackChange, 0, len(records))}
+	for idx := range records {
+		change, err := m.plan(ctx, &records[idx])
+		if err != nil {
+			return nil, err
+		}
+		if change.Conflict && !input.DryRun && !input.Force {
+			blog.Errorf("roll back audit log %d failed, %s %d has been changed, rid: %s", change.AuditID, change.ObjectID, change.InstID, ctx.ReqID)
+			return nil, ctx.Error.Errorf(common.CCErrCoreServiceRollbackConflict, change.ObjectID, change.InstID, change.AuditID)
+		}
+		result.Changes = append(result.Changes, *change)
+	}
+	if input.DryRun {
+		return result, nil
+	}
+
+	for idx := range result.Changes {
+		change := &result.Changes[idx]
+		if change.Skipped {
+			blog.Warnf("roll back audit log %d, skip the change of %s %d, rid: %s", change.AuditID, change.ObjectID, change.InstID, ctx.ReqID)
+			continue
+		}
+		if err := m.apply(ctx, &records[idx], change); err != nil {
+			blog.Errorf("roll back audit log %d failed, err: %v, rid: %s", change.AuditID, err, ctx.ReqID)
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// searchAuditLogs returns the audit logs to roll back, the latest one is the first.
+func (m *rollbackManager) searchAuditLogs(ctx core.ContextParams, input metadata.RollbackParams) ([]auditRecord, error) {
+	cond := map[string]interface{}{}
+	switch {
+	case len(input.AuditIDs) != 0:
+		cond["id"] = map[string]interface{}{common.BKDBIN: input.AuditIDs}
+	case len(input.RequestID) != 0:
+		cond["rid"] = input.RequestID
+	default:
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "audit_ids")
+	}
+	cond = util.SetQueryOwner(cond, ctx.SupplierAccount)
+
+	records := make([]auditRecord, 0)
+	if err := m.dbProxy.Table(common.BKTableNameOperationLog).Find(cond).Sort("-id").All(ctx, &records); err != nil {
+		blog.Errorf("search the audit logs to roll back failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(records) == 0 {
+		return nil, ctx.Error.Error(common.CCErrCommNotFound)
+	}
+
+	found := make(map[int64]bool, len(records))
+	for _, record := range records {
+		found[record.ID] = true
+	}
+	for _, id := range input.AuditIDs {
+		if !found[id] {
+			blog.Errorf("the audit log %d to roll back is not found, rid: %s", id, ctx.ReqID)
+			return nil, ctx.Error.Errorf(common.CCErrCoreServiceAuditLogNotRollbackable, id)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].ID > records[j].ID })
+	return records, nil
+}
+
+// plan compare the current data with the data before the audit log, and returns the change to roll back the audit log.
+func (m *rollbackManager) plan(ctx core.ContextParams, record *auditRecord) (*metadata.RollbackChange, error) {
+	change := &metadata.RollbackChange{
+		AuditID:  record.ID,
+		ObjectID: record.OpTarget,
+		InstID:   record.InstID,
+	}
+	switch auditoplog.AuditOpType(record.OpType) {
+	case auditoplog.AuditOpTypeAdd:
+		change.Action = metadata.RollbackActionDelete
+	case auditoplog.AuditOpTypeModify:
+		change.Action = metadata.RollbackActionUpdate
+	case auditoplog.AuditOpTypeDel:
+		change.Action = metadata.RollbackActionCreate
+	default:
+		return nil, ctx.Error.Errorf(common.CCErrCoreServiceAuditLogNotRollbackable, record.ID)
+	}
+	current, err := m.current(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	change.Current = current
+
+	var ignores []string
+	if record.OpTarget == instanceAssociationTarget {
+		// the association is restored with a new id, and its metadata is never changed.
+		ignores = []string{common.BKFieldID, metadata.BKMetadata}
+	}
+	switch change.Action {
+	case metadata.RollbackActionDelete:
+		// the created one must exist and not be changed after the audit log.
+		change.Conflict = current == nil || len(diff(current, m.target(record, record.Content.CurData), ignores...)) != 0
+		change.Skipped = current == nil
+	case metadata.RollbackActionUpdate:
+		change.Restore = m.target(record, record.Content.PreData)
+		change.Conflict = current == nil || len(diff(current, m.target(record, record.Content.CurData), ignores...)) != 0
+		change.Skipped = current == nil
+	case metadata.RollbackActionCreate:
+		change.Restore = m.target(record, record.Content.PreData)
+		change.Conflict = current != nil
+		change.Skipped = current != nil
+	}
+	if len(change.Restore) == 0 && change.Action != metadata.RollbackActionDelete {
+		return nil, ctx.Error.Errorf(common.CCErrCoreServiceAuditLogNotRollbackable, record.ID)
+	}
+	if change.Restore != nil {
+		change.Diff = diff(current, change.Restore, ignores...)
+	}
+	return change, nil
+}
+
+// current returns the current instance or association of the audit log, it's nil if not exist.
+func (m *rollbackManager) current(ctx core.ContextParams, record *auditRecord) (mapstr.MapStr, error) {
+	var result *metadata.QueryResult
+	var err error
+	if record.OpTarget == instanceAssociationTarget {
+		cond := mapstr.MapStr{common.BKFieldID: record.InstID}
+		if record.OpType == int(auditoplog.AuditOpTypeDel) {
+			// the association is created with a new id when it is restored, so find it by the instances.
+			asst := m.target(record, record.Content.PreData)
+			cond = mapstr.MapStr{
+				common.AssociationObjAsstIDField: asst[common.AssociationObjAsstIDField],
+				common.BKInstIDField:             asst[common.BKInstIDField],
+				common.BKAsstInstIDField:         asst[common.BKAsstInstIDField],
+			}
+		}
+		result, err = m.association.SearchInstanceAssociation(ctx, metadata.QueryCondition{Condition: cond})
+	} else {
+		cond := mapstr.MapStr{common.GetInstIDField(record.OpTarget): record.InstID}
+		result, err = m.instance.SearchModelInstance(ctx, record.OpTarget, metadata.QueryCondition{Condition: cond})
+	}
+	if err != nil {
+		blog.Errorf("get the current data of audit log %d failed, err: %v, rid: %s", record.ID, err, ctx.ReqID)
+		return nil, err
+	}
+	if len(result.Info) == 0 {
+		return nil, nil
+	}
+	return result.Info[0], nil
+}
+
+// target returns the instance or association data saved in the audit log
+func (m *rollbackManager) target(record *auditRecord, data mapstr.MapStr) mapstr.MapStr {
+	if record.OpTarget == instanceAssociationTarget && data != nil {
+		// the association is saved in the data field when it is created
+		if asst, err := data.MapStr("data"); err == nil {
+			return asst
+		}
+	}
+	return data
+}
+
+// apply roll back the audit log, and record a new audit log for the change.
+func (m *rollbackManager) apply(ctx core.ContextParams, record *auditRecord, change *metadata.RollbackChange) error {
+	var opType auditoplog.AuditOpType
+	var curData mapstr.MapStr
+	var err error
+	if record.OpTarget == instanceAssociationTarget {
+		opType, curData, err = m.applyAssociation(ctx, change)
+	} else {
+		opType, curData, err = m.applyInstance(ctx, change)
+	}
+	if err != nil {
+		return err
+	}
+
+	auditLog := metadata.SaveAuditLogParams{
+		ID:    change.InstID,
+		Model: change.ObjectID,
+		Content: map[string]interface{}{
+			"pre_data": change.Current,
+			"cur_data": curData,
+		},
+		OpDesc: fmt.Sprintf("rollback audit log %d", change.AuditID),
+		OpType: opType,
+		BizID:  record.BizID,
+	}
+	if err := m.audit.CreateAuditLog(ctx, auditLog); err != nil {
+		blog.Errorf("roll back audit log %d success, but save audit log failed, err: %v, rid: %s", change.AuditID, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrAuditSaveLogFaile)
+	}
+	return nil
+}
+
+func (m *rollbackManager) applyInstance(ctx core.ContextParams, change *metadata.RollbackChange) (auditoplog.AuditOpType, mapstr.MapStr, error) {
+	instIDField := common.GetInstIDField(change.ObjectID)
+	cond := mapstr.MapStr{instIDField: change.InstID}
+	switch change.Action {
+	case metadata.RollbackActionDelete:
+		_, err := m.instance.DeleteModelInstance(ctx, change.ObjectID, metadata.DeleteOption{Condition: cond})
+		return auditoplog.AuditOpTypeDel, nil, err
+
+	case metadata.RollbackActionUpdate:
+		data := mapstr.New()
+		for _, field := range change.Diff {
+			if field.Field == instIDField {
+				continue
+			}
+			data.Set(field.Field, field.Restored)
+		}
+		if len(data) == 0 {
+			return auditoplog.AuditOpTypeModify, change.Current, nil
+		}
+		_, err := m.instance.UpdateModelInstance(ctx, change.ObjectID, metadata.UpdateOption{Data: data, Condition: cond})
+		if err != nil {
+			return auditoplog.AuditOpTypeModify, nil, err
+		}
+		return auditoplog.AuditOpTypeModify, change.Restore, nil
+
+	default:
+		data := change.Restore.Clone()
+		data.Set(instIDField, change.InstID)
+		_, err := m.instance.RestoreModelInstance(ctx, change.ObjectID, metadata.CreateModelInstance{Data: data})
+		return auditoplog.AuditOpTypeAdd, change.Restore, err
+	}
+}
+
+func (m *rollbackManager) applyAssociation(ctx core.ContextParams, change *metadata.RollbackChange) (auditoplog.AuditOpType, mapstr.MapStr, error) {
+	if change.Action == metadata.RollbackActionDelete {
+		cond := mapstr.MapStr{common.BKFieldID: change.InstID}
+		_, err := m.association.DeleteInstanceAssociation(ctx, metadata.DeleteOption{Condition: cond})
+		return auditoplog.AuditOpTypeDel, nil, err
+	}
+
+	asst := metadata.InstAsst{}
+	if err := change.Restore.MarshalJSONInto(&asst); err != nil {
+		blog.Errorf("parse the instance association %+v failed, err: %v, rid: %s", change.Restore, err, ctx.ReqID)
+		return auditoplog.AuditOpTypeAdd, nil, ctx.Error.Errorf(common.CCErrCoreServiceAuditLogNotRollbackable, change.AuditID)
+	}
+	if change.Action == metadata.RollbackActionUpdate {
+		// only the attributes of the association are updated, the ones added after the audit log are removed.
+		current := metadata.InstAsst{}
+		if err := change.Current.MarshalJSONInto(&current); err != nil {
+			blog.Errorf("parse the instance association %+v failed, err: %v, rid: %s", change.Current, err, ctx.ReqID)
+			return auditoplog.AuditOpTypeModify, nil, ctx.Error.Errorf(common.CCErrCoreServiceAuditLogNotRollbackable, change.AuditID)
+		}
+		values := mapstr.New()
+		for key := range current.Attributes {
+			values[key] = nil
+		}
+		for key, value := range asst.Attributes {
+			values[key] = value
+		}
+		input := metadata.UpdateOption{
+			Condition: mapstr.MapStr{common.BKFieldID: change.InstID},
+			Data:      mapstr.MapStr{metadata.AssociationFieldAttributes: values},
+		}
+		if _, err := m.association.UpdateInstanceAssociation(ctx, input); err != nil {
+			return auditoplog.AuditOpTypeModify, nil, err
+		}
+		return auditoplog.AuditOpTypeModify, change.Restore, nil
+	}
+
+	asst.ID = 0
+	result, err := m.association.CreateOneInstanceAssociation(ctx, metadata.CreateOneInstanceAssociation{Data: asst})
+	if err != nil {
+		return auditoplog.AuditOpTypeAdd, nil, err
+	}
+	// the association is restored with a new id
+	change.InstID = int64(result.Created.ID)
+	restored := change.Restore.Clone()
+	restored.Set(common.BKFieldID, change.InstID)
+	return auditoplog.AuditOpTypeAdd, restored, nil
+}
+
+// diff returns the fields whose current value is different from the restored one,
+// only the fields of the restored data are compared.
+func diff(current, restore mapstr.MapStr, ignores ...string) []metadata.RollbackFieldDiff {
+	fields := make([]string, 0, len(restore))
+	for field := range restore {
+		if util.InStrArr(ignoreFields, field) || util.InStrArr(ignores, field) {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	result := make([]metadata.RollbackFieldDiff, 0)
+	for _, field := range fields {
+		var value interface{}
+		if current != nil {
+			value = current[field]
+		}
+		if sameValue(value, restore[field]) {
+			continue
+		}
+		result = append(result, metadata.RollbackFieldDiff{
+			Field:    field,
+			Current:  value,
+			Restored: restore[field],
+		})
+	}
+	return result
+}
+
+// sameValue compare the values read from db and the audit log, the numbers may be saved as different types.
+func sameValue(value, expect interface{}) bool {
+	if value == nil || expect == nil {
+		return value == nil && expect == nil
+	}
+	valueInt, err := util.GetInt64ByInterface(value)
+	if err == nil {
+		expectInt, err := util.GetInt64ByInterface(expect)
+		return err == nil && valueInt == expectInt
+	}
+	return fmt.Sprint(value) == fmt.Sprint(expect)
+}
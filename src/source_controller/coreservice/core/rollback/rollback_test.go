@@ -0,0 +1,69 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rollback
+
+import (
+	"testing"
+
+	"icenter/src/common/mapstr"
+)
+
+func TestDiff(t *testing.T) {
+	current := mapstr.MapStr{
+		"bk_inst_id":   int64(1),
+		"bk_inst_name": "new",
+		"count":        int64(3),
+		"last_time":    "2019-05-27",
+	}
+	restore := mapstr.MapStr{
+		"bk_inst_id":   1,
+		"bk_inst_name": "old",
+		"count":        float64(3),
+		"owner":        "admin",
+		"last_time":    "2019-05-20",
+	}
+
+	result := diff(current, restore, "bk_inst_id")
+	if len(result) != 2 {
+		t.Fatalf("expect 2 changed fields, got %v", result)
+	}
+	if result[0].Field != "bk_inst_name" || result[0].Current != "new" || result[0].Restored != "old" {
+		t.Errorf("unexpected diff %v", result[0])
+	}
+	if result[1].Field != "owner" || result[1].Current != nil {
+		t.Errorf("unexpected diff %v", result[1])
+	}
+
+	if result := diff(nil, restore); len(result) != 4 {
+		t.Errorf("expect all the fields restored, got %v", result)
+	}
+}
+
+func TestSameValue(t *testing.T) {
+	cases := []struct {
+		value, expect interface{}
+		same          bool
+	}{
+		{nil, nil, true},
+		{nil, "", false},
+		{int64(1), float64(1), true},
+		{int32(1), "1", true},
+		{"a", "b", false},
+		{[]interface{}{"a"}, []interface{}{"a"}, true},
+	}
+	for _, c := range cases {
+		if sameValue(c.value, c.expect) != c.same {
+			t.Errorf("sameValue(%v, %v) should be %v", c.value, c.expect, c.same)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/eventclient"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+	"icenter/src/source_controller/coreservice/core/eventdispatch"
+)
+
+const (
+	// consumerName the consumer name of the dispatcher in the event log
+	consumerName = "webhook"
+	// dispatchInterval the interval to check the new events and the deliveries to retry
+	dispatchInterval = time.Second
+	// eventBatch the max events count read from the event log at once
+	eventBatch = 500
+	// deliveryBatch the max deliveries count sent at once
+	deliveryBatch = 200
+	// initialBackoff the delay of the first retry, it's doubled with every retry.
+	initialBackoff = 10 * time.Second
+	// maxBackoff the max delay of a retry
+	maxBackoff = time.Hour
+	// maxErrorLength the max length of the error and the response body kept in the delivery
+	maxErrorLength = 512
+)
+
+// Run dispatch the events in the event log to the webhook subscriptions, it never returns.
+// only the master process dispatches the events, the events are delivered at least once.
+func Run(dbProxy dal.RDB, stream *eventclient.EventStream, isMaster func() bool) {
+	eventdispatch.Run(consumerName, dispatchInterval, isMaster, newDispatcher(dbProxy, stream).dispatch)
+}
+
+type dispatcher struct {
+	dbProxy dal.RDB
+	stream  *eventclient.EventStream
+	client  *http.Client
+	now     func() time.Time
+}
+
+func newDispatcher(dbProxy dal.RDB, stream *eventclient.EventStream) *dispatcher {
+	return &dispatcher{
+		dbProxy: dbProxy,
+		stream:  stream,
+		client:  &http.Client{},
+		now:     time.Now,
+	}
+}
+
+// dispatch save the deliveries of the new events, and then send the deliveries which are due.
+func (d *dispatcher) dispatch(ctx context.Context) error {
+	subscriptions := make([]metadata.WebhookSubscription, 0)
+	if err := d.dbProxy.Table(common.BKTableNameWebhookSubscription).Find(nil).All(ctx, &subscriptions); err != nil {
+		return err
+	}
+	byID := make(map[int64]*metadata.WebhookSubscription, len(subscriptions))
+	ids := make([]int64, 0, len(subscriptions))
+	for index := range subscriptions {
+		subscription := &subscriptions[index]
+		ids = append(ids, subscription.ID)
+		if err := decodeCondition(&subscription.Filter); err != nil {
+			blog.Errorf("[webhook] decode the condition of subscription %d failed, err: %v", subscription.ID, err)
+			continue
+		}
+		byID[subscription.ID] = subscription
+	}
+
+	for {
+		count, err := d.fanOut(ctx, byID)
+		if err != nil {
+			return err
+		}
+		if count < eventBatch {
+			break
+		}
+	}
+
+	// the deliveries saved for the subscriptions which are deleted after they're loaded are removed.
+	orphan := mapstr.MapStr{"subscription_id": mapstr.MapStr{common.BKDBNIN: ids}}
+	if err := d.dbProxy.Table(common.BKTableNameWebhookDelivery).Delete(ctx, orphan); err != nil {
+		blog.Errorf("[webhook] delete the deliveries of the deleted subscriptions failed, err: %v", err)
+	}
+	return d.deliver(ctx, byID)
+}
+
+// fanOut save a delivery for every subscription that the new event matches, and commit the offset of the events.
+func (d *dispatcher) fanOut(ctx context.Context, subscriptions map[int64]*metadata.WebhookSubscription) (int, error) {
+	events, offset, err := d.stream.Consume(ctx, consumerName, eventBatch)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	for index := range events {
+		event := &events[index]
+		for _, subscription := range subscriptions {
+			if !subscription.Enabled {
+				continue
+			}
+			if subscription.OwnerID != event.OwnerID && subscription.OwnerID != common.BKSuperOwnerID {
+				continue
+			}
+			matched, err := matchEvent(subscription, event)
+			if err != nil {
+				blog.Errorf("[webhook] match event %d with subscription %d failed, err: %v", event.ID, subscription.ID, err)
+				continue
+			}
+			if !matched {
+				continue
+			}
+			if err := d.saveDelivery(ctx, subscription, event); err != nil {
+				return 0, err
+			}
+		}
+		offset = event.ID
+	}
+
+	// the deliveries saved before the offset is committed are saved again when the process restarts,
+	// the receiver should ignore the duplicated deliveries with the event id.
+	if err := d.stream.Commit(ctx, consumerName, offset); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+func (d *dispatcher) saveDelivery(ctx context.Context, subscription *metadata.WebhookSubscription, event *metadata.EventInst) error {
+	id, err := d.dbProxy.NextSequence(ctx, common.BKTableNameWebhookDelivery)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(metadata.DistInst{
+		EventInst:      *event,
+		DstbID:         int64(id),
+		SubscriptionID: subscription.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := d.now()
+	delivery := metadata.WebhookDelivery{
+		ID:             int64(id),
+		SubscriptionID: subscription.ID,
+		EventID:        event.ID,
+		OwnerID:        subscription.OwnerID,
+		Payload:        string(payload),
+		Status:         metadata.WebhookDeliveryPending,
+		NextTime:       now,
+		CreateTime:     now,
+		LastTime:       now,
+	}
+	return d.dbProxy.Table(common.BKTableNameWebhookDelivery).Insert(ctx, delivery)
+}
+
+// deliver send the pending deliveries which are due, the deliveries of a subscription are sent in order,
+// and the subscriptions are handled concurrently, so a slow callback does not delay the others.
+func (d *dispatcher) deliver(ctx context.Context, subscriptions map[int64]*metadata.WebhookSubscription) error {
+	grouped := make(map[int64][]metadata.WebhookDelivery)
+	for id, subscription := range subscriptions {
+		if !subscription.Enabled {
+			continue
+		}
+		deliveries, err := d.dueDeliveries(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(deliveries) != 0 {
+			grouped[id] = deliveries
+		}
+	}
+
+	var wg sync.WaitGroup
+	for subscriptionID, items := range grouped {
+		wg.Add(1)
+		go func(subscription *metadata.WebhookSubscription, items []metadata.WebhookDelivery) {
+			defer wg.Done()
+			d.deliverSubscription(ctx, subscription, items)
+		}(subscriptions[subscriptionID], items)
+	}
+	wg.Wait()
+	return nil
+}
+
+// dueDeliveries returns the pending deliveries of the subscription in order, it returns none if the first one
+// is waiting for a retry, so that the deliveries after it are not sent before it.
+func (d *dispatcher) dueDeliveries(ctx context.Context, subscriptionID int64) ([]metadata.WebhookDelivery, error) {
+	cond := mapstr.MapStr{
+		"subscription_id": subscriptionID,
+		"status":          metadata.WebhookDeliveryPending,
+	}
+	deliveries := make([]metadata.WebhookDelivery, 0)
+	err := d.dbProxy.Table(common.BKTableNameWebhookDelivery).Find(cond).Sort("id").Limit(deliveryBatch).All(ctx, &deliveries)
+	if err != nil {
+		return nil, err
+	}
+	now := d.now()
+	for index := range deliveries {
+		if deliveries[index].NextTime.After(now) {
+			return deliveries[:index], nil
+		}
+	}
+	return deliveries, nil
+}
+
+// deliverSubscription send the deliveries of the subscription in order, it stops at the first failure,
+// the deliveries after the failed one are sent after it's retried successfully or it's dead.
+func (d *dispatcher) deliverSubscription(ctx context.Context, subscription *metadata.WebhookSubscription, deliveries []metadata.WebhookDelivery) {
+	stats := subscription.Stats
+	for index := range deliveries {
+		delivery := &deliveries[index]
+		statusCode, err := d.send(ctx, subscription, delivery)
+		now := d.now()
+		stats.Total++
+		stats.LastTime = &now
+		delivery.Attempts++
+		delivery.StatusCode = statusCode
+		delivery.LastTime = now
+
+		filter := mapstr.MapStr{"id": delivery.ID}
+		table := d.dbProxy.Table(common.BKTableNameWebhookDelivery)
+		if err == nil {
+			stats.Success++
+			if err := table.Delete(ctx, filter); err != nil {
+				blog.Errorf("[webhook] delete the sent delivery %d failed, err: %v", delivery.ID, err)
+			}
+			continue
+		}
+
+		stats.Failure++
+		stats.LastError = eventdispatch.Truncate(err.Error(), maxErrorLength)
+		delivery.LastError = stats.LastError
+		if delivery.Attempts > subscription.MaxRetries {
+			stats.DeadLetter++
+			delivery.Status = metadata.WebhookDeliveryDead
+			blog.Warnf("[webhook] delivery %d of event %d to subscription %d is dead after %d attempts, err: %v",
+				delivery.ID, delivery.EventID, subscription.ID, delivery.Attempts, err)
+		} else {
+			delivery.NextTime = now.Add(eventdispatch.Backoff(delivery.Attempts, initialBackoff, maxBackoff))
+		}
+		doc := mapstr.MapStr{
+			"status":      delivery.Status,
+			"attempts":    delivery.Attempts,
+			"next_time":   delivery.NextTime,
+			"status_code": delivery.StatusCode,
+			"last_error":  delivery.LastError,
+			"last_time":   delivery.LastTime,
+		}
+		if err := table.Update(ctx, filter, doc); err != nil {
+			blog.Errorf("[webhook] update the failed delivery %d failed, err: %v", delivery.ID, err)
+		}
+		break
+	}
+
+	// only the dispatcher updates the stats, so they can be overwritten with the ones counted in the process.
+	filter := mapstr.MapStr{"id": subscription.ID}
+	if err := d.dbProxy.Table(common.BKTableNameWebhookSubscription).Update(ctx, filter, mapstr.MapStr{"stats": stats}); err != nil {
+		blog.Errorf("[webhook] update the stats of subscription %d failed, err: %v", subscription.ID, err)
+	}
+	subscription.Stats = stats
+}
+
+// send post the payload to the callback url, the delivery is successful if the response status is 2xx.
+func (d *dispatcher) send(ctx context.Context, subscription *metadata.WebhookSubscription, delivery *metadata.WebhookDelivery) (int, error) {
+	timeout := time.Duration(subscription.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeoutSeconds * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, subscription.CallbackURL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, strconv.FormatInt(delivery.EventID, 10))
+	req.Header.Set(DeliveryIDHeader, strconv.FormatInt(delivery.ID, 10))
+	if len(subscription.Secret) != 0 {
+		timestamp := d.now().Unix()
+		req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(SignatureHeader, Sign(subscription.Secret, timestamp, payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("response status %d, body: %s", resp.StatusCode, body)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,104 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"strconv"
+
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal/matcher"
+	"icenter/src/common/util"
+	"icenter/src/source_controller/coreservice/core/eventdispatch"
+)
+
+const (
+	// SignatureHeader the header of the payload signature, which is "sha256=" and the hex encoded
+	// hmac-sha256 of the timestamp, a "." and the payload, with the subscription's secret as key.
+	SignatureHeader = "X-Bkcc-Signature"
+	// TimestampHeader the header of the unix timestamp when the payload is signed
+	TimestampHeader = "X-Bkcc-Timestamp"
+	// EventIDHeader the header of the event id
+	EventIDHeader = "X-Bkcc-Event-Id"
+	// DeliveryIDHeader the header of the delivery id, the retries of a delivery have the same id.
+	DeliveryIDHeader = "X-Bkcc-Delivery-Id"
+)
+
+// Sign returns the signature of the payload, the receiver should compute it with the
+// timestamp header and the payload, and compare it with the signature header.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// matchEvent whether the event matches the filter of the subscription
+func matchEvent(subscription *metadata.WebhookSubscription, event *metadata.EventInst) (bool, error) {
+	filter := &subscription.Filter
+	if len(filter.EventTypes) != 0 && !util.InStrArr(filter.EventTypes, event.EventType) {
+		return false, nil
+	}
+	if len(filter.ObjectIDs) != 0 && !util.InStrArr(filter.ObjectIDs, event.ObjType) {
+		return false, nil
+	}
+	if len(filter.Actions) != 0 && !util.InStrArr(filter.Actions, event.Action) {
+		return false, nil
+	}
+	if len(filter.Condition) == 0 && len(filter.ChangedFields) == 0 {
+		return true, nil
+	}
+
+	// the event may have several data, it matches if any of them matches.
+	for _, data := range event.Data {
+		if !fieldsChanged(data, filter.ChangedFields) {
+			continue
+		}
+		if len(filter.Condition) == 0 {
+			return true, nil
+		}
+		doc := mapstr.MapStr{"cur_data": data.CurData, "pre_data": data.PreData}
+		matched, err := matcher.Match(doc, filter.Condition)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fieldsChanged whether any of the fields is different between the current and previous data,
+// the created and deleted data are treated as all the fields are changed.
+func fieldsChanged(data metadata.EventData, fields []string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+	cur, curOK := eventdispatch.ToMap(data.CurData)
+	pre, preOK := eventdispatch.ToMap(data.PreData)
+	if !curOK || !preOK {
+		return true
+	}
+	for _, field := range fields {
+		if !reflect.DeepEqual(cur[field], pre[field]) {
+			return true
+		}
+	}
+	return false
+}
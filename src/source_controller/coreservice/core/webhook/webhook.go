@@ -0,0 +1,353 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/dal/matcher"
+	"icenter/src/common/util"
+	"icenter/src/source_controller/coreservice/core"
+	"icenter/src/source_controller/coreservice/core/eventdispatch"
+)
+
+const (
+	// defaultTimeoutSeconds the default timeout of a delivery request
+	defaultTimeoutSeconds = 10
+	// maxTimeoutSeconds the max timeout of a delivery request
+	maxTimeoutSeconds = 60
+	// defaultMaxRetries the default retries of a failed delivery
+	defaultMaxRetries = 5
+	// maxMaxRetries the max retries of a failed delivery
+	maxMaxRetries = 10
+	// defaultDeadLetterLimit the default page size of the dead letters
+	defaultDeadLetterLimit = 50
+)
+
+var eventTypes = []string{
+	metadata.EventTypeInstData,
+	metadata.EventTypeRelation,
+	metadata.EventTypeAssociation,
+	metadata.EventTypeResourcePoolModule,
+}
+
+var eventActions = []string{
+	metadata.EventActionCreate,
+	metadata.EventActionUpdate,
+	metadata.EventActionDelete,
+}
+
+// updatableFields the fields of a subscription which can be updated
+var updatableFields = []string{
+	"name",
+	"callback_url",
+	"secret",
+	"filter",
+	"enabled",
+	"timeout_seconds",
+	"max_retries",
+}
+
+var _ core.WebhookOperation = (*webhookManager)(nil)
+
+type webhookManager struct {
+	dbProxy dal.RDB
+}
+
+// New create a new webhook manager instance
+func New(dbProxy dal.RDB) core.WebhookOperation {
+	return &webhookManager{
+		dbProxy: dbProxy,
+	}
+}
+
+// CreateSubscription create a webhook subscription, the events pushed after it are delivered to it.
+func (m *webhookManager) CreateSubscription(ctx core.ContextParams, subscription metadata.WebhookSubscription) (*metadata.WebhookSubscription, error) {
+	if err := m.validSubscription(ctx, &subscription); err != nil {
+		return nil, err
+	}
+
+	id, err := m.dbProxy.NextSequence(ctx, common.BKTableNameWebhookSubscription)
+	if err != nil {
+		blog.Errorf("create webhook subscription failed, generate id failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	now := time.Now()
+	subscription.ID = int64(id)
+	subscription.Stats = metadata.WebhookStats{}
+	subscription.OwnerID = ctx.SupplierAccount
+	subscription.Creator = ctx.User
+	subscription.CreateTime = now
+	subscription.LastTime = now
+	if err := m.dbProxy.Table(common.BKTableNameWebhookSubscription).Insert(ctx, subscription); err != nil {
+		blog.Errorf("create webhook subscription failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	subscription.Secret = ""
+	return &subscription, nil
+}
+
+// UpdateSubscription update the subscription with the fields in the data, the other fields are kept.
+func (m *webhookManager) UpdateSubscription(ctx core.ContextParams, id int64, data mapstr.MapStr) error {
+	subscription, err := m.getSubscription(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// overlay the updated fields on the subscription, so that the result is validated as a whole.
+	origin, err := json.Marshal(subscription)
+	if err != nil {
+		return ctx.Error.Error(common.CCErrCommJSONMarshalFailed)
+	}
+	merged := mapstr.MapStr{}
+	if err := json.Unmarshal(origin, &merged); err != nil {
+		return ctx.Error.Error(common.CCErrCommJSONUnmarshalFailed)
+	}
+	for key, val := range data {
+		if !util.InStrArr(updatableFields, key) {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, key)
+		}
+		merged[key] = val
+	}
+	updated := metadata.WebhookSubscription{}
+	if err := merged.MarshalJSONInto(&updated); err != nil {
+		return ctx.Error.Error(common.CCErrCommJSONUnmarshalFailed)
+	}
+	if err := m.validSubscription(ctx, &updated); err != nil {
+		return err
+	}
+
+	doc := mapstr.MapStr{
+		"name":            updated.Name,
+		"callback_url":    updated.CallbackURL,
+		"secret":          updated.Secret,
+		"filter":          updated.Filter,
+		"enabled":         updated.Enabled,
+		"timeout_seconds": updated.TimeoutSeconds,
+		"max_retries":     updated.MaxRetries,
+		"last_time":       time.Now(),
+	}
+	if err := m.dbProxy.Table(common.BKTableNameWebhookSubscription).Update(ctx, m.idCondition(ctx, id), doc); err != nil {
+		blog.Errorf("update webhook subscription %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// DeleteSubscription delete the subscription and its pending deliveries and dead letters
+func (m *webhookManager) DeleteSubscription(ctx core.ContextParams, id int64) error {
+	if _, err := m.getSubscription(ctx, id); err != nil {
+		return err
+	}
+	if err := m.dbProxy.Table(common.BKTableNameWebhookSubscription).Delete(ctx, m.idCondition(ctx, id)); err != nil {
+		blog.Errorf("delete webhook subscription %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBDeleteFailed)
+	}
+	if err := m.dbProxy.Table(common.BKTableNameWebhookDelivery).Delete(ctx, mapstr.MapStr{"subscription_id": id}); err != nil {
+		blog.Errorf("delete the deliveries of webhook subscription %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+// SearchSubscriptions search the subscriptions with their delivery statistics, the secrets are not returned.
+func (m *webhookManager) SearchSubscriptions(ctx core.ContextParams, input metadata.QueryCondition) (*metadata.SearchWebhookSubscriptionResult, error) {
+	cond := util.SetModOwner(input.Condition, ctx.SupplierAccount)
+	table := m.dbProxy.Table(common.BKTableNameWebhookSubscription)
+	count, err := table.Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("search webhook subscriptions failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	subscriptions := make([]metadata.WebhookSubscription, 0)
+	find := table.Find(cond).Sort("id").Start(uint64(input.Limit.Offset))
+	if input.Limit.Limit > 0 {
+		find = find.Limit(uint64(input.Limit.Limit))
+	}
+	if err := find.All(ctx, &subscriptions); err != nil {
+		blog.Errorf("search webhook subscriptions failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	for index := range subscriptions {
+		subscriptions[index].Secret = ""
+		if err := decodeCondition(&subscriptions[index].Filter); err != nil {
+			blog.Errorf("decode the condition of webhook subscription %d failed, err: %v, rid: %s", subscriptions[index].ID, err, ctx.ReqID)
+		}
+	}
+	return &metadata.SearchWebhookSubscriptionResult{Count: count, Info: subscriptions}, nil
+}
+
+// SearchDeadLetters search the deliveries which are failed after all the retries
+func (m *webhookManager) SearchDeadLetters(ctx core.ContextParams, input metadata.SearchWebhookDeadLetterParams) (*metadata.SearchWebhookDeadLetterResult, error) {
+	cond := m.deadLetterCondition(ctx, input.SubscriptionID, nil)
+	table := m.dbProxy.Table(common.BKTableNameWebhookDelivery)
+	count, err := table.Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("search webhook dead letters failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	if input.Page.Limit <= 0 {
+		input.Page.Limit = defaultDeadLetterLimit
+	}
+	if len(input.Page.Sort) == 0 {
+		input.Page.Sort = "-id"
+	}
+	deliveries := make([]metadata.WebhookDelivery, 0)
+	err = table.Find(cond).Sort(input.Page.Sort).Start(uint64(input.Page.Start)).Limit(uint64(input.Page.Limit)).All(ctx, &deliveries)
+	if err != nil {
+		blog.Errorf("search webhook dead letters failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	return &metadata.SearchWebhookDeadLetterResult{Count: count, Info: deliveries}, nil
+}
+
+// RedeliverDeadLetters move the dead letters back to the pending deliveries, they're delivered with all the retries again.
+func (m *webhookManager) RedeliverDeadLetters(ctx core.ContextParams, input metadata.WebhookDeadLetterParams) (*metadata.UpdatedCount, error) {
+	cond := m.deadLetterCondition(ctx, input.SubscriptionID, input.IDs)
+	table := m.dbProxy.Table(common.BKTableNameWebhookDelivery)
+	count, err := table.Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("redeliver webhook dead letters failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	now := time.Now()
+	doc := mapstr.MapStr{
+		"status":    metadata.WebhookDeliveryPending,
+		"attempts":  0,
+		"next_time": now,
+		"last_time": now,
+	}
+	if err := table.Update(ctx, cond, doc); err != nil {
+		blog.Errorf("redeliver webhook dead letters failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return &metadata.UpdatedCount{Count: count}, nil
+}
+
+// DeleteDeadLetters delete the dead letters
+func (m *webhookManager) DeleteDeadLetters(ctx core.ContextParams, input metadata.WebhookDeadLetterParams) (*metadata.DeletedCount, error) {
+	cond := m.deadLetterCondition(ctx, input.SubscriptionID, input.IDs)
+	table := m.dbProxy.Table(common.BKTableNameWebhookDelivery)
+	count, err := table.Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("delete webhook dead letters failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if err := table.Delete(ctx, cond); err != nil {
+		blog.Errorf("delete webhook dead letters failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBDeleteFailed)
+	}
+	return &metadata.DeletedCount{Count: count}, nil
+}
+
+func (m *webhookManager) getSubscription(ctx core.ContextParams, id int64) (*metadata.WebhookSubscription, error) {
+	subscription := new(metadata.WebhookSubscription)
+	err := m.dbProxy.Table(common.BKTableNameWebhookSubscription).Find(m.idCondition(ctx, id)).One(ctx, subscription)
+	if err != nil {
+		if m.dbProxy.IsNotFoundError(err) {
+			return nil, ctx.Error.Error(common.CCErrCommNotFound)
+		}
+		blog.Errorf("get webhook subscription %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if err := decodeCondition(&subscription.Filter); err != nil {
+		blog.Errorf("decode the condition of webhook subscription %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+	}
+	return subscription, nil
+}
+
+func (m *webhookManager) idCondition(ctx core.ContextParams, id int64) mapstr.MapStr {
+	return mapstr.MapStr{"id": id, common.BKOwnerIDField: ctx.SupplierAccount}
+}
+
+func (m *webhookManager) deadLetterCondition(ctx core.ContextParams, subscriptionID int64, ids []int64) mapstr.MapStr {
+	cond := mapstr.MapStr{
+		"status":              metadata.WebhookDeliveryDead,
+		common.BKOwnerIDField: ctx.SupplierAccount,
+	}
+	if subscriptionID > 0 {
+		cond["subscription_id"] = subscriptionID
+	}
+	if len(ids) > 0 {
+		cond["id"] = mapstr.MapStr{common.BKDBIN: ids}
+	}
+	return cond
+}
+
+// validSubscription valid the subscription and fill the default values
+func (m *webhookManager) validSubscription(ctx core.ContextParams, subscription *metadata.WebhookSubscription) error {
+	subscription.Name = strings.TrimSpace(subscription.Name)
+	if len(subscription.Name) == 0 {
+		return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "name")
+	}
+	callback, err := url.Parse(subscription.CallbackURL)
+	if err != nil || (callback.Scheme != "http" && callback.Scheme != "https") || len(callback.Host) == 0 {
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "callback_url")
+	}
+
+	switch {
+	case subscription.TimeoutSeconds == 0:
+		subscription.TimeoutSeconds = defaultTimeoutSeconds
+	case subscription.TimeoutSeconds < 0 || subscription.TimeoutSeconds > maxTimeoutSeconds:
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "timeout_seconds")
+	}
+	switch {
+	case subscription.MaxRetries == 0:
+		subscription.MaxRetries = defaultMaxRetries
+	case subscription.MaxRetries < 0 || subscription.MaxRetries > maxMaxRetries:
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "max_retries")
+	}
+
+	filter := &subscription.Filter
+	for _, eventType := range filter.EventTypes {
+		if !util.InStrArr(eventTypes, eventType) {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "filter.event_types")
+		}
+	}
+	for _, action := range filter.Actions {
+		if !util.InStrArr(eventActions, action) {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "filter.actions")
+		}
+	}
+	filter.ConditionJSON = ""
+	if len(filter.Condition) != 0 {
+		// the condition is matched with an empty event, so that the invalid operators are found.
+		if _, err := matcher.Match(mapstr.MapStr{}, filter.Condition); err != nil {
+			blog.Errorf("invalid webhook condition %v, err: %v, rid: %s", filter.Condition, err, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "filter.condition")
+		}
+		data, err := json.Marshal(filter.Condition)
+		if err != nil {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "filter.condition")
+		}
+		filter.ConditionJSON = string(data)
+	}
+	return nil
+}
+
+// decodeCondition decode the condition saved in db
+func decodeCondition(filter *metadata.WebhookFilter) error {
+	cond, err := eventdispatch.DecodeCondition(filter.ConditionJSON)
+	filter.Condition = cond
+	return err
+}
@@ -0,0 +1,280 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"icenter/src/common"
+	"icenter/src/common/eventclient"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/common/storage/dal/memory"
+	"icenter/src/source_controller/coreservice/core/eventdispatch"
+	"icenter/src/source_controller/coreservice/core/eventdispatch/dispatchtest"
+)
+
+func TestMatchEvent(t *testing.T) {
+	subscription := &metadata.WebhookSubscription{
+		Filter: metadata.WebhookFilter{
+			EventTypes:    []string{metadata.EventTypeInstData},
+			ObjectIDs:     []string{common.BKInnerObjIDHost},
+			Actions:       []string{metadata.EventActionUpdate},
+			Condition:     mapstr.MapStr{"cur_data.bk_os_type": "1"},
+			ChangedFields: []string{"bk_host_innerip"},
+		},
+	}
+
+	cases := []struct {
+		event   metadata.EventInst
+		matched bool
+	}{
+		{dispatchtest.NewHostEvent(1, metadata.EventActionUpdate,
+			mapstr.MapStr{"bk_os_type": "1", "bk_host_innerip": "127.0.0.2"},
+			mapstr.MapStr{"bk_os_type": "1", "bk_host_innerip": "127.0.0.1"}), true},
+		// the watched field is not changed
+		{dispatchtest.NewHostEvent(2, metadata.EventActionUpdate,
+			mapstr.MapStr{"bk_os_type": "1", "bk_host_innerip": "127.0.0.1", "bk_comment": "a"},
+			mapstr.MapStr{"bk_os_type": "1", "bk_host_innerip": "127.0.0.1"}), false},
+		// the condition is not matched
+		{dispatchtest.NewHostEvent(3, metadata.EventActionUpdate,
+			mapstr.MapStr{"bk_os_type": "2", "bk_host_innerip": "127.0.0.2"},
+			mapstr.MapStr{"bk_os_type": "2", "bk_host_innerip": "127.0.0.1"}), false},
+		// the action is not matched
+		{dispatchtest.NewHostEvent(4, metadata.EventActionCreate,
+			mapstr.MapStr{"bk_os_type": "1", "bk_host_innerip": "127.0.0.2"}, nil), false},
+	}
+	for _, c := range cases {
+		matched, err := matchEvent(subscription, &c.event)
+		if err != nil {
+			t.Fatalf("match event %d failed, err: %v", c.event.ID, err)
+		}
+		if matched != c.matched {
+			t.Errorf("event %d should be matched: %v, got %v", c.event.ID, c.matched, matched)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"event_id":1}`)
+	signature := Sign("secret", 1559000000, payload)
+	if signature != Sign("secret", 1559000000, payload) {
+		t.Fatal("the signature should be stable")
+	}
+	if signature == Sign("other", 1559000000, payload) || signature == Sign("secret", 1559000001, payload) {
+		t.Fatal("the signature should depend on the secret and the timestamp")
+	}
+	if len(signature) != len("sha256=")+64 {
+		t.Fatalf("unexpected signature %s", signature)
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	var failures int32 = 100
+	var received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		timestamp := r.Header.Get(TimestampHeader)
+		if r.Header.Get(SignatureHeader) != signHeader("secret", timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		atomic.AddInt32(&received, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	db := memory.NewMemory()
+	ctx := dispatchtest.NewContext()
+	manager := New(db)
+	subscription, err := manager.CreateSubscription(ctx, metadata.WebhookSubscription{
+		Name:        "host",
+		CallbackURL: server.URL,
+		Secret:      "secret",
+		Enabled:     true,
+		MaxRetries:  2,
+		Filter: metadata.WebhookFilter{
+			ObjectIDs: []string{common.BKInnerObjIDHost},
+			Condition: mapstr.MapStr{"cur_data.bk_os_type": "1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dispatchtest.AppendEvent(t, db, dispatchtest.NewHostEvent(1000, metadata.EventActionCreate, mapstr.MapStr{"bk_os_type": "1"}, nil))
+	dispatchtest.AppendEvent(t, db, dispatchtest.NewHostEvent(1001, metadata.EventActionCreate, mapstr.MapStr{"bk_os_type": "2"}, nil))
+
+	// the times are saved in milliseconds
+	clock := time.Now().Truncate(time.Second)
+	d := newDispatcher(db, eventclient.NewEventStream(db))
+	d.now = func() time.Time { return clock }
+
+	// the first attempt and 2 retries are failed, then the delivery is dead.
+	for attempt := 1; attempt <= 3; attempt++ {
+		if err := d.dispatch(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		deliveries := make([]metadata.WebhookDelivery, 0)
+		if err := db.Table(common.BKTableNameWebhookDelivery).Find(nil).All(context.Background(), &deliveries); err != nil {
+			t.Fatal(err)
+		}
+		if len(deliveries) != 1 || deliveries[0].EventID != 1000 || deliveries[0].Attempts != attempt {
+			t.Fatalf("unexpected deliveries after attempt %d: %+v", attempt, deliveries)
+		}
+		if attempt < 3 {
+			if deliveries[0].Status != metadata.WebhookDeliveryPending || deliveries[0].StatusCode != http.StatusInternalServerError {
+				t.Fatalf("the delivery should be pending after attempt %d: %+v", attempt, deliveries[0])
+			}
+			backoff := eventdispatch.Backoff(attempt, initialBackoff, maxBackoff)
+			if delay := deliveries[0].NextTime.Sub(clock); delay != backoff {
+				t.Fatalf("the delivery should be retried after %v, got %v", backoff, delay)
+			}
+			// not due yet, nothing is sent.
+			if err := d.dispatch(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			clock = clock.Add(backoff)
+			continue
+		}
+		if deliveries[0].Status != metadata.WebhookDeliveryDead {
+			t.Fatalf("the delivery should be dead: %+v", deliveries[0])
+		}
+	}
+
+	deadLetters, err := manager.SearchDeadLetters(ctx, metadata.SearchWebhookDeadLetterParams{SubscriptionID: subscription.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deadLetters.Count != 1 {
+		t.Fatalf("expect 1 dead letter, got %d", deadLetters.Count)
+	}
+
+	// the callback recovers, and the dead letter is redelivered.
+	atomic.StoreInt32(&failures, 0)
+	if _, err := manager.RedeliverDeadLetters(ctx, metadata.WebhookDeadLetterParams{SubscriptionID: subscription.ID}); err != nil {
+		t.Fatal(err)
+	}
+	clock = time.Now().Add(time.Second)
+	if err := d.dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&received) != 1 {
+		t.Fatalf("the event should be received once, got %d", received)
+	}
+	count, err := db.Table(common.BKTableNameWebhookDelivery).Find(nil).Count(context.Background())
+	if err != nil || count != 0 {
+		t.Fatalf("the sent delivery should be deleted, count: %d, err: %v", count, err)
+	}
+
+	result, err := manager.SearchSubscriptions(ctx, metadata.QueryCondition{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Info) != 1 {
+		t.Fatalf("expect 1 subscription, got %d", len(result.Info))
+	}
+	stats := result.Info[0].Stats
+	if stats.Total != 4 || stats.Success != 1 || stats.Failure != 3 || stats.DeadLetter != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if result.Info[0].Secret != "" {
+		t.Errorf("the secret should not be returned")
+	}
+}
+
+func signHeader(secret, timestamp string, payload []byte) string {
+	var ts int64
+	if err := json.Unmarshal([]byte(timestamp), &ts); err != nil {
+		return ""
+	}
+	return Sign(secret, ts, payload)
+}
+
+func TestDeliverInOrder(t *testing.T) {
+	var failing int32 = 1
+	received := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		received <- r.Header.Get(EventIDHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	db := memory.NewMemory()
+	manager := New(db)
+	_, err := manager.CreateSubscription(dispatchtest.NewContext(), metadata.WebhookSubscription{
+		Name:        "host",
+		CallbackURL: server.URL,
+		Enabled:     true,
+		MaxRetries:  3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dispatchtest.AppendEvent(t, db, dispatchtest.NewHostEvent(1000, metadata.EventActionCreate, mapstr.MapStr{"bk_os_type": "1"}, nil))
+	dispatchtest.AppendEvent(t, db, dispatchtest.NewHostEvent(1001, metadata.EventActionCreate, mapstr.MapStr{"bk_os_type": "2"}, nil))
+
+	clock := time.Now().Truncate(time.Second)
+	d := newDispatcher(db, eventclient.NewEventStream(db))
+	d.now = func() time.Time { return clock }
+
+	// the batch stops at the failed delivery, the next one is not attempted.
+	if err := d.dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	deliveries := make([]metadata.WebhookDelivery, 0)
+	if err := db.Table(common.BKTableNameWebhookDelivery).Find(nil).Sort("id").All(context.Background(), &deliveries); err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 2 || deliveries[0].Attempts != 1 || deliveries[1].Attempts != 0 {
+		t.Fatalf("only the first delivery should be attempted: %+v", deliveries)
+	}
+
+	// the later delivery is not sent while the failed one waits for the retry.
+	atomic.StoreInt32(&failing, 0)
+	if err := d.dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("no delivery should be sent before the retry, got %d", len(received))
+	}
+
+	clock = clock.Add(eventdispatch.Backoff(1, initialBackoff, maxBackoff))
+	if err := d.dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	close(received)
+	order := make([]string, 0)
+	for id := range received {
+		order = append(order, id)
+	}
+	if fmt.Sprint(order) != "[1000 1001]" {
+		t.Errorf("the deliveries should be sent in order, got %v", order)
+	}
+}
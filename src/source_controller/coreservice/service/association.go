@@ -180,6 +180,15 @@ func (s *coreService) SearchInstanceAssociation(params core.ContextParams, pathP
 	return s.core.AssociationOperation().SearchInstanceAssociation(params, inputData)
 }
 
+func (s *coreService) UpdateInstanceAssociation(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+
+	inputData := metadata.UpdateOption{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.AssociationOperation().UpdateInstanceAssociation(params, inputData)
+}
+
 func (s *coreService) DeleteInstanceAssociation(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
 
 	inputData := metadata.DeleteOption{}
@@ -188,3 +197,12 @@ func (s *coreService) DeleteInstanceAssociation(params core.ContextParams, pathP
 	}
 	return s.core.AssociationOperation().DeleteInstanceAssociation(params, inputData)
 }
+
+func (s *coreService) TraverseInstanceAssociation(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+
+	inputData := metadata.GraphTraversalParams{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.AssociationOperation().TraverseInstanceAssociation(params, inputData)
+}
@@ -35,3 +35,9 @@ func (s *coreService) IsInstanceExist(ctx core.ContextParams, objID string, inst
 	}
 	return true, nil
 }
+
+// RefreshComputedAttributes evaluate the computed fields of the instances whose associations are changed
+func (s *coreService) RefreshComputedAttributes(ctx core.ContextParams, objID string, instIDs []int64) error {
+	_, err := s.core.InstanceOperation().RefreshComputedAttributes(ctx, objID, instIDs)
+	return err
+}
@@ -19,6 +19,7 @@ import (
 	"icenter/src/common/metadata"
 	"icenter/src/common/metric"
 	"icenter/src/common/types"
+	"icenter/src/source_controller/coreservice/app/options"
 )
 
 func (s *coreService) Healthz(req *restful.Request, resp *restful.Response) {
@@ -43,9 +44,11 @@ func (s *coreService) Healthz(req *restful.Request, resp *restful.Response) {
 	}
 	meta.Items = append(meta.Items, mongoItem)
 
-	// redis status
+	// redis status, there is no redis when the data is saved in memory
 	redisItem := metric.HealthItem{IsHealthy: true, Name: types.CCFunctionalityRedis}
-	if s.cahce == nil {
+	if s.cfg.Storage == options.StorageMemory {
+		redisItem.Message = "not used"
+	} else if s.cahce == nil {
 		redisItem.IsHealthy = false
 		redisItem.Message = "not connected"
 	} else if err := s.cahce.Ping().Err(); err != nil {
@@ -0,0 +1,70 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/source_controller/coreservice/core"
+)
+
+func (s *coreService) SearchInstanceVersions(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	instID, err := strconv.ParseInt(pathParams("inst_id"), 10, 64)
+	if err != nil {
+		blog.Errorf("search instance versions failed, invalid inst_id %s, rid: %s", pathParams("inst_id"), params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsIsInvalid, "inst_id")
+	}
+	return s.core.HistoryOperation().SearchInstanceVersions(params, pathParams(common.BKObjIDField), instID)
+}
+
+func (s *coreService) GetInstanceAsOf(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	instID, err := strconv.ParseInt(pathParams("inst_id"), 10, 64)
+	if err != nil {
+		blog.Errorf("get instance as of failed, invalid inst_id %s, rid: %s", pathParams("inst_id"), params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsIsInvalid, "inst_id")
+	}
+	inputData := metadata.InstanceAsOfParams{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	if inputData.AsOf.IsZero() {
+		return nil, params.Error.Errorf(common.CCErrCommParamsNeedSet, "as_of")
+	}
+	return s.core.HistoryOperation().GetInstanceAsOf(params, pathParams(common.BKObjIDField), instID, inputData.AsOf)
+}
+
+func (s *coreService) SearchMainlineInstanceTopoAsOf(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	bizID, err := strconv.ParseInt(pathParams(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("search mainline instance topo as of failed, invalid %s %s, rid: %s", common.BKAppIDField, pathParams(common.BKAppIDField), params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField)
+	}
+	inputData := metadata.MainlineTopoAsOfParams{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	if inputData.AsOf.IsZero() {
+		return nil, params.Error.Errorf(common.CCErrCommParamsNeedSet, "as_of")
+	}
+
+	result, err := s.core.TopoOperation().SearchMainlineInstanceTopoAsOf(params, bizID, inputData.WithDetail, inputData.AsOf)
+	if err != nil {
+		blog.Errorf("search mainline instance topo by business:%d as of %s failed, %+v, rid: %s", bizID, inputData.AsOf, err, params.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
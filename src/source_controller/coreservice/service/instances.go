@@ -15,6 +15,7 @@ package service
 import (
 	"fmt"
 
+	"icenter/src/common/blog"
 	"icenter/src/common/mapstr"
 	"icenter/src/common/metadata"
 	"icenter/src/common/util"
@@ -84,3 +85,30 @@ func (s *coreService) CascadeDeleteModelInstances(params core.ContextParams, pat
 	}
 	return s.core.InstanceOperation().CascadeDeleteModelInstance(params, pathParams("bk_obj_id"), inputData)
 }
+
+// AggregateModelInstances group the instances of the model and compute the metrics of the groups
+func (s *coreService) AggregateModelInstances(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.AggregateInstanceParams{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.InstanceOperation().AggregateModelInstance(params, pathParams("bk_obj_id"), inputData)
+}
+
+// BackfillComputedAttributes evaluate the computed attributes of all the instances of the model again
+func (s *coreService) BackfillComputedAttributes(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	return s.core.InstanceOperation().BackfillComputedAttributes(params, pathParams("bk_obj_id"))
+}
+
+// backfillComputedAttributes evaluate the computed attributes of the models within the request, so that
+// the instances are backfilled in the transaction of the attribute change, and it's rolled back if failed.
+// the instances are handled page by page, a large model could be backfilled again with the backfill api.
+func (s *coreService) backfillComputedAttributes(params core.ContextParams, objIDs ...string) error {
+	for _, objID := range objIDs {
+		if _, err := s.core.InstanceOperation().BackfillComputedAttributes(params, objID); nil != err {
+			blog.Errorf("backfill computed attributes of model %s failed, err: %v, rid: %s", objID, err, params.ReqID)
+			return err
+		}
+	}
+	return nil
+}
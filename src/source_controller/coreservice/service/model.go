@@ -16,8 +16,10 @@ import (
 	"strconv"
 
 	"icenter/src/common"
+	"icenter/src/common/blog"
 	"icenter/src/common/mapstr"
 	"icenter/src/common/metadata"
+	"icenter/src/common/util"
 	"icenter/src/source_controller/coreservice/core"
 )
 
@@ -263,7 +265,11 @@ func (s *coreService) CreateModelAttributes(params core.ContextParams, pathParam
 	if err := data.MarshalJSONInto(&inputData); nil != err {
 		return nil, err
 	}
-	return s.core.ModelOperation().CreateModelAttributes(params, pathParams("bk_obj_id"), inputData)
+	result, err := s.core.ModelOperation().CreateModelAttributes(params, pathParams("bk_obj_id"), inputData)
+	if nil == err && hasComputedAttribute(inputData.Attributes) {
+		err = s.backfillComputedAttributes(params, pathParams("bk_obj_id"))
+	}
+	return result, err
 }
 
 func (s *coreService) SetModelAttributes(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
@@ -272,7 +278,11 @@ func (s *coreService) SetModelAttributes(params core.ContextParams, pathParams,
 	if err := data.MarshalJSONInto(&inputData); nil != err {
 		return nil, err
 	}
-	return s.core.ModelOperation().SetModelAttributes(params, pathParams("bk_obj_id"), inputData)
+	result, err := s.core.ModelOperation().SetModelAttributes(params, pathParams("bk_obj_id"), inputData)
+	if nil == err && hasComputedAttribute(inputData.Attributes) {
+		err = s.backfillComputedAttributes(params, pathParams("bk_obj_id"))
+	}
+	return result, err
 }
 
 func (s *coreService) UpdateModelAttributes(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
@@ -282,7 +292,11 @@ func (s *coreService) UpdateModelAttributes(params core.ContextParams, pathParam
 		return nil, err
 	}
 
-	return s.core.ModelOperation().UpdateModelAttributes(params, pathParams("bk_obj_id"), inputData)
+	result, err := s.core.ModelOperation().UpdateModelAttributes(params, pathParams("bk_obj_id"), inputData)
+	if nil == err {
+		err = s.backfillUpdatedComputedAttributes(params, pathParams("bk_obj_id"), inputData)
+	}
+	return result, err
 }
 
 func (s *coreService) UpdateModelAttributesByCondition(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
@@ -292,7 +306,46 @@ func (s *coreService) UpdateModelAttributesByCondition(params core.ContextParams
 		return nil, err
 	}
 
-	return s.core.ModelOperation().UpdateModelAttributesByCondition(params, inputData)
+	result, err := s.core.ModelOperation().UpdateModelAttributesByCondition(params, inputData)
+	if nil == err {
+		err = s.backfillUpdatedComputedAttributes(params, "", inputData)
+	}
+	return result, err
+}
+
+func hasComputedAttribute(attrs []metadata.Attribute) bool {
+	for _, attr := range attrs {
+		if attr.PropertyType == common.FieldTypeComputed {
+			return true
+		}
+	}
+	return false
+}
+
+// backfillUpdatedComputedAttributes backfill the models whose computed attributes are updated,
+// the updated attributes are limited to the model if the objID is set.
+func (s *coreService) backfillUpdatedComputedAttributes(params core.ContextParams, objID string, inputData metadata.UpdateOption) error {
+	if !inputData.Data.Exists(metadata.AttributeFieldOption) && !inputData.Data.Exists(metadata.AttributeFieldPropertyType) {
+		return nil
+	}
+	cond := mapstr.MapStr{}
+	cond.Merge(inputData.Condition)
+	cond.Set(metadata.AttributeFieldPropertyType, common.FieldTypeComputed)
+	if len(objID) != 0 {
+		cond.Set(common.BKObjIDField, objID)
+	}
+	attrs, err := s.core.ModelOperation().SearchModelAttributesByCondition(params, metadata.QueryCondition{Condition: cond})
+	if nil != err {
+		blog.Errorf("search the updated computed attributes failed, condition: %v, err: %v, rid: %s", cond, err, params.ReqID)
+		return err
+	}
+	objIDs := make([]string, 0)
+	for _, attr := range attrs.Info {
+		if !util.InStrArr(objIDs, attr.ObjectID) {
+			objIDs = append(objIDs, attr.ObjectID)
+		}
+	}
+	return s.backfillComputedAttributes(params, objIDs...)
 }
 
 func (s *coreService) DeleteModelAttribute(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
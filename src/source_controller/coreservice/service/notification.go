@@ -0,0 +1,69 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/source_controller/coreservice/core"
+)
+
+func (s *coreService) CreateNotificationRule(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.NotificationRule{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.NotificationOperation().CreateRule(params, inputData)
+}
+
+func (s *coreService) UpdateNotificationRule(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("update notification rule failed, invalid id %s, rid: %s", pathParams("id"), params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsIsInvalid, "id")
+	}
+	return nil, s.core.NotificationOperation().UpdateRule(params, id, data)
+}
+
+func (s *coreService) DeleteNotificationRule(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("delete notification rule failed, invalid id %s, rid: %s", pathParams("id"), params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsIsInvalid, "id")
+	}
+	return nil, s.core.NotificationOperation().DeleteRule(params, id)
+}
+
+func (s *coreService) SearchNotificationRules(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.NotificationOperation().SearchRules(params, inputData)
+}
+
+func (s *coreService) GetNotificationSetting(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	return s.core.NotificationOperation().GetSetting(params, pathParams("bk_username"))
+}
+
+func (s *coreService) SaveNotificationSetting(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.NotificationSetting{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return nil, s.core.NotificationOperation().SaveSetting(params, inputData)
+}
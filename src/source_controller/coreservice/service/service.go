@@ -19,6 +19,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/emicklei/go-restful"
@@ -28,25 +29,35 @@ import (
 	"icenter/src/common/backbone"
 	"icenter/src/common/blog"
 	"icenter/src/common/errors"
+	"icenter/src/common/eventclient"
 	"icenter/src/common/http/httpserver"
 	"icenter/src/common/language"
 	"icenter/src/common/mapstr"
 	"icenter/src/common/metadata"
+	"icenter/src/common/metric"
 	"icenter/src/common/rdapi"
 	"icenter/src/common/storage/dal"
+	"icenter/src/common/storage/dal/memory"
+	"icenter/src/common/storage/dal/metrics"
 	"icenter/src/common/storage/dal/mongo/local"
 	"icenter/src/common/storage/dal/mongo/remote"
 	dalredis "icenter/src/common/storage/dal/redis"
+	"icenter/src/common/storage/rpc"
 	"icenter/src/common/util"
 	"icenter/src/source_controller/coreservice/app/options"
 	"icenter/src/source_controller/coreservice/core"
 	"icenter/src/source_controller/coreservice/core/association"
 	"icenter/src/source_controller/coreservice/core/auditlog"
 	"icenter/src/source_controller/coreservice/core/datasynchronize"
+	"icenter/src/source_controller/coreservice/core/event"
+	"icenter/src/source_controller/coreservice/core/history"
 	"icenter/src/source_controller/coreservice/core/host"
 	"icenter/src/source_controller/coreservice/core/instances"
 	"icenter/src/source_controller/coreservice/core/mainline"
 	"icenter/src/source_controller/coreservice/core/model"
+	"icenter/src/source_controller/coreservice/core/notification"
+	"icenter/src/source_controller/coreservice/core/rollback"
+	"icenter/src/source_controller/coreservice/core/webhook"
 )
 
 // CoreServiceInterface the topo service methods used to init
@@ -70,6 +81,8 @@ type coreService struct {
 	core     core.Core
 	db       dal.RDB
 	cahce    *redis.Client
+	// txnServer serves the transactions of the other processes when the data is saved in memory
+	txnServer *rpc.Server
 }
 
 func (s *coreService) SetConfig(cfg options.Config, engin *backbone.Engine, err errors.CCErrorIf, language language.CCLanguageIf) error {
@@ -87,7 +100,23 @@ func (s *coreService) SetConfig(cfg options.Config, engin *backbone.Engine, err
 
 	var db dal.DB
 	var dbErr error
-	if cfg.Mongo.Transaction == "enable" {
+	if cfg.Storage == options.StorageMemory {
+		blog.Warnf("the data is saved in memory, it's lost when the process exits")
+		mdb := memory.NewMemory()
+		// the admin server can not migrate the data in memory, so the initial data dumped by it is loaded
+		if dbErr = loadSeed(mdb, cfg.Seed); dbErr != nil {
+			blog.Errorf("failed to init the data in memory, error info is %s", dbErr.Error())
+			return dbErr
+		}
+		// the other processes start the transactions here as the tmserver, so the requests join them with the txn id
+		rpcOpt, rpcErr := cfg.Mongo.RPC.ServerOptions()
+		if rpcErr != nil {
+			blog.Errorf("failed to get the rpc server options, error info is %s", rpcErr.Error())
+			return rpcErr
+		}
+		s.txnServer = memory.NewTxnServer(mdb, *rpcOpt)
+		db = mdb
+	} else if cfg.Mongo.Transaction == "enable" {
 		blog.Infof("connecting to transaction manager")
 		db, dbErr = remote.NewWithDiscover(engin.ServiceManageInterface.TMServer().GetServers, cfg.Mongo)
 		if dbErr != nil {
@@ -101,28 +130,74 @@ func (s *coreService) SetConfig(cfg options.Config, engin *backbone.Engine, err
 			return dbErr
 		}
 	}
-	cache, cacheRrr := dalredis.NewFromConfig(cfg.Redis)
-	if cacheRrr != nil {
-		blog.Errorf("new redis client failed, err: %v", cacheRrr)
-		return cacheRrr
+	db = metrics.NewDB(db)
+	s.db = db
+
+	var cache *redis.Client
+	var eventC eventclient.StreamClient
+	if cfg.Storage == options.StorageMemory {
+		// there is no redis in the single node dev mode, the events are consumed from the event log only
+		eventC = eventclient.NewClientViaStream(db)
+	} else {
+		var cacheRrr error
+		cache, cacheRrr = dalredis.NewFromConfig(cfg.Redis)
+		if cacheRrr != nil {
+			blog.Errorf("new redis client failed, err: %v", cacheRrr)
+			return cacheRrr
+		}
+		s.cahce = cache
+		// all the events are saved in the event log before pushed to redis
+		eventC = eventclient.NewClientViaRedis(cache, db)
 	}
+	go eventC.Stream().RunRetention(cfg.EventLog)
+	metric.Register(metric.NewGaugeFunc("cmdb_event_queue_depth",
+		"Number of the events waiting to be pushed to redis.", func() float64 { return float64(eventC.QueueDepth()) }))
 
-	s.db = db
-	s.cahce = cache
+	// the snapshots bound the audit logs to replay when the history is rebuilt
+	go history.RunSnapshot(db, cfg.History, engin.ServiceManageInterface.IsMaster)
+	historyMgr := history.New(db)
+
+	// the webhook subscriptions are delivered with the events in the event log
+	go webhook.Run(db, eventC.Stream(), engin.ServiceManageInterface.IsMaster)
+	// the users are notified of the property changes in the event log which match the notification rules
+	go notification.Run(db, eventC.Stream(), cfg.Notification, engin.ServiceManageInterface.IsMaster)
+
+	instanceMgr := instances.New(db, s, cache, eventC)
+	associationMgr := association.New(db, s, eventC)
+	auditMgr := auditlog.New(db)
 
 	// connect the remote mongodb
 	s.core = core.New(
 		model.New(db, s),
-		instances.New(db, s, cache),
-		association.New(db, s),
+		instanceMgr,
+		associationMgr,
 		datasynchronize.New(db, s),
-		mainline.New(db),
-		host.New(db, cache),
-		auditlog.New(db),
+		mainline.New(db, historyMgr),
+		host.New(db, cache, eventC),
+		auditMgr,
+		event.New(eventC.Stream()),
+		historyMgr,
+		rollback.New(db, instanceMgr, associationMgr, auditMgr),
+		webhook.New(db),
+		notification.New(db),
 	)
 	return nil
 }
 
+// loadSeed load the initial data of the memory db from the file
+func loadSeed(db *memory.Memory, file string) error {
+	if len(file) == 0 {
+		blog.Warnf("the seed file is not set, there is no initial data in memory")
+		return nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return db.Load(f)
+}
+
 // WebService the web service
 func (s *coreService) WebService() *restful.Container {
 
@@ -160,9 +235,22 @@ func (s *coreService) WebService() *restful.Container {
 	healthzAPI.Route(healthzAPI.GET("/healthz").To(s.Healthz))
 	container.Add(healthzAPI)
 
+	txnAPI := new(restful.WebService).Path("/txn/v3")
+	txnAPI.Route(txnAPI.Method(http.MethodConnect).Path("rpc").To(s.TxnRPC))
+	container.Add(txnAPI)
+
 	return container
 }
 
+// TxnRPC serve the transactions of the other processes as the tmserver when the data is saved in memory
+func (s *coreService) TxnRPC(req *restful.Request, resp *restful.Response) {
+	if s.txnServer == nil {
+		resp.WriteErrorString(http.StatusNotFound, "the transactions are served by the tmserver")
+		return
+	}
+	s.txnServer.ServeHTTP(resp.ResponseWriter, req.Request)
+}
+
 func (s *coreService) createAPIRspStr(errcode int, info interface{}) (string, error) {
 
 	rsp := metadata.Response{
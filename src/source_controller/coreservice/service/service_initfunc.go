@@ -68,8 +68,10 @@ func (s *coreService) initModelInstances() {
 	s.addAction(http.MethodPost, "/createmany/model/{bk_obj_id}/instance", s.CreateManyModelInstances, nil)
 	s.addAction(http.MethodPut, "/update/model/{bk_obj_id}/instance", s.UpdateModelInstances, nil)
 	s.addAction(http.MethodPost, "/read/model/{bk_obj_id}/instances", s.SearchModelInstances, nil)
+	s.addAction(http.MethodPost, "/read/model/{bk_obj_id}/instances/aggregate", s.AggregateModelInstances, nil)
 	s.addAction(http.MethodDelete, "/delete/model/{bk_obj_id}/instance", s.DeleteModelInstances, nil)
 	s.addAction(http.MethodDelete, "/delete/model/{bk_obj_id}/instance/cascade", s.CascadeDeleteModelInstances, nil)
+	s.addAction(http.MethodPost, "/update/model/{bk_obj_id}/computed/backfill", s.BackfillComputedAttributes, nil)
 }
 
 func (s *coreService) initAssociationKind() {
@@ -101,7 +103,9 @@ func (s *coreService) initInstanceAssociation() {
 	s.addAction(http.MethodPost, "/create/instanceassociation", s.CreateOneInstanceAssociation, nil)
 	s.addAction(http.MethodPost, "/createmany/instanceassociation", s.CreateManyInstanceAssociation, nil)
 	s.addAction(http.MethodPost, "/read/instanceassociation", s.SearchInstanceAssociation, nil)
+	s.addAction(http.MethodPut, "/update/instanceassociation", s.UpdateInstanceAssociation, nil)
 	s.addAction(http.MethodDelete, "/delete/instanceassociation", s.DeleteInstanceAssociation, nil)
+	s.addAction(http.MethodPost, "/read/instanceassociation/traverse", s.TraverseInstanceAssociation, nil)
 }
 
 func (s *coreService) initMainline() {
@@ -122,6 +126,37 @@ func (s *coreService) host() {
 func (s *coreService) audit() {
 	s.addAction(http.MethodPost, "/create/auditlog", s.CreateAuditLog, nil)
 	s.addAction(http.MethodPost, "/read/auditlog", s.SearchAuditLog, nil)
+	s.addAction(http.MethodPost, "/rollback/auditlog", s.RollbackAuditLogs, nil)
+}
+
+func (s *coreService) initEventStream() {
+	s.addAction(http.MethodPost, "/read/event/stream", s.ReadEventStream, nil)
+	s.addAction(http.MethodPut, "/update/event/stream/offset", s.CommitEventOffset, nil)
+}
+
+func (s *coreService) initHistory() {
+	s.addAction(http.MethodPost, "/read/history/model/{bk_obj_id}/instance/{inst_id}/versions", s.SearchInstanceVersions, nil)
+	s.addAction(http.MethodPost, "/read/history/model/{bk_obj_id}/instance/{inst_id}", s.GetInstanceAsOf, nil)
+	s.addAction(http.MethodPost, "/read/history/mainline/instance/{bk_biz_id}", s.SearchMainlineInstanceTopoAsOf, nil)
+}
+
+func (s *coreService) initWebhook() {
+	s.addAction(http.MethodPost, "/create/webhook/subscription", s.CreateWebhookSubscription, nil)
+	s.addAction(http.MethodPut, "/update/webhook/subscription/{id}", s.UpdateWebhookSubscription, nil)
+	s.addAction(http.MethodDelete, "/delete/webhook/subscription/{id}", s.DeleteWebhookSubscription, nil)
+	s.addAction(http.MethodPost, "/read/webhook/subscription", s.SearchWebhookSubscriptions, nil)
+	s.addAction(http.MethodPost, "/read/webhook/deadletter", s.SearchWebhookDeadLetters, nil)
+	s.addAction(http.MethodPost, "/redeliver/webhook/deadletter", s.RedeliverWebhookDeadLetters, nil)
+	s.addAction(http.MethodDelete, "/delete/webhook/deadletter", s.DeleteWebhookDeadLetters, nil)
+}
+
+func (s *coreService) initNotification() {
+	s.addAction(http.MethodPost, "/create/notification/rule", s.CreateNotificationRule, nil)
+	s.addAction(http.MethodPut, "/update/notification/rule/{id}", s.UpdateNotificationRule, nil)
+	s.addAction(http.MethodDelete, "/delete/notification/rule/{id}", s.DeleteNotificationRule, nil)
+	s.addAction(http.MethodPost, "/read/notification/rule", s.SearchNotificationRules, nil)
+	s.addAction(http.MethodPost, "/read/notification/setting/{bk_username}", s.GetNotificationSetting, nil)
+	s.addAction(http.MethodPut, "/update/notification/setting", s.SaveNotificationSetting, nil)
 }
 
 func (s *coreService) initService() {
@@ -136,4 +171,8 @@ func (s *coreService) initService() {
 	s.initMainline()
 	s.host()
 	s.audit()
+	s.initEventStream()
+	s.initHistory()
+	s.initWebhook()
+	s.initNotification()
 }
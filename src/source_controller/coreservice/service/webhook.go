@@ -0,0 +1,81 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"icenter/src/common"
+	"icenter/src/common/blog"
+	"icenter/src/common/mapstr"
+	"icenter/src/common/metadata"
+	"icenter/src/source_controller/coreservice/core"
+)
+
+func (s *coreService) CreateWebhookSubscription(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.WebhookSubscription{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.WebhookOperation().CreateSubscription(params, inputData)
+}
+
+func (s *coreService) UpdateWebhookSubscription(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("update webhook subscription failed, invalid id %s, rid: %s", pathParams("id"), params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsIsInvalid, "id")
+	}
+	return nil, s.core.WebhookOperation().UpdateSubscription(params, id, data)
+}
+
+func (s *coreService) DeleteWebhookSubscription(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("delete webhook subscription failed, invalid id %s, rid: %s", pathParams("id"), params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsIsInvalid, "id")
+	}
+	return nil, s.core.WebhookOperation().DeleteSubscription(params, id)
+}
+
+func (s *coreService) SearchWebhookSubscriptions(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.WebhookOperation().SearchSubscriptions(params, inputData)
+}
+
+func (s *coreService) SearchWebhookDeadLetters(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.SearchWebhookDeadLetterParams{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.WebhookOperation().SearchDeadLetters(params, inputData)
+}
+
+func (s *coreService) RedeliverWebhookDeadLetters(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.WebhookDeadLetterParams{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.WebhookOperation().RedeliverDeadLetters(params, inputData)
+}
+
+func (s *coreService) DeleteWebhookDeadLetters(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.WebhookDeadLetterParams{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.WebhookOperation().DeleteDeadLetters(params, inputData)
+}